@@ -72,6 +72,11 @@ type Config interface {
 	// Dir returns the agent's directory.
 	Dir() string
 
+	// EnvironTag returns the tag of the environment the agent
+	// belongs to, which is named when it connects to the API.
+	// It is empty if the environment is not known.
+	EnvironTag() string
+
 	// Nonce returns the nonce saved when the machine was provisioned
 	// TODO: make this one of the key/value pairs.
 	Nonce() string
@@ -86,9 +91,20 @@ type Config interface {
 	// password accordingly.
 	OpenAPI(dialOpts api.DialOpts) (st *api.State, newPassword string, err error)
 
+	// OpenEnvironAPI connects to the API end-point as OpenAPI does, but
+	// to the environment with the given tag rather than the agent's own.
+	// It never changes the agent's password, so OpenAPI must have
+	// succeeded first.
+	OpenEnvironAPI(environTag string, dialOpts api.DialOpts) (*api.State, error)
+
 	// APIAddresses returns the addresses needed to connect to the api server
 	APIAddresses() ([]string, error)
 
+	// StatePassword returns the password the agent uses to connect to
+	// the state database. As in OpenState, the old password is used if
+	// no new one has been set.
+	StatePassword() string
+
 	// OpenState tries to open a direct connection to the state database using
 	// the given Conf.
 	OpenState(policy state.Policy) (*state.State, error)
@@ -112,6 +128,12 @@ type Config interface {
 	// the new agent configuration.
 	WriteUpgradedToVersion(newVersion version.Number) error
 
+	// WriteAPIInfo replaces the API addresses, CA certificate and
+	// environment tag the agent connects with, and writes the new
+	// agent configuration. It is used when the agent's environment
+	// has moved to another state server.
+	WriteAPIInfo(addrs []string, caCert []byte, environTag string) error
+
 	// Value returns the value associated with the key, or an empty string if
 	// the key is not found.
 	Value(key string) string
@@ -150,6 +172,7 @@ type configInternal struct {
 	dataDir           string
 	logDir            string
 	tag               string
+	environTag        string
 	nonce             string
 	jobs              []params.MachineJob
 	upgradedToVersion version.Number
@@ -161,6 +184,9 @@ type configInternal struct {
 	stateServerKey    []byte
 	apiPort           int
 	values            map[string]string
+	// secretKey holds the key used to encrypt the
+	// secrets in the agent config file.
+	secretKey []byte
 }
 
 type AgentConfigParams struct {
@@ -169,6 +195,7 @@ type AgentConfigParams struct {
 	Jobs              []params.MachineJob
 	UpgradedToVersion version.Number
 	Tag               string
+	EnvironTag        string
 	Password          string
 	Nonce             string
 	StateAddresses    []string
@@ -207,6 +234,7 @@ func NewAgentConfig(configParams AgentConfigParams) (Config, error) {
 		jobs:              configParams.Jobs,
 		upgradedToVersion: configParams.UpgradedToVersion,
 		tag:               configParams.Tag,
+		environTag:        configParams.EnvironTag,
 		nonce:             configParams.Nonce,
 		caCert:            configParams.CACert,
 		oldPassword:       configParams.Password,
@@ -306,10 +334,10 @@ func ReadConf(configFilePath string) (Config, error) {
 		if err != nil {
 			return nil, err
 		}
-		config, err = format.unmarshal(configData)
+		config, err = format.unmarshal(configData, dir)
 	} else {
 		// Does not exist, just parse the data.
-		format, config, err = parseConfigData(configData)
+		format, config, err = parseConfigData(configData, dir)
 	}
 	if err != nil {
 		return nil, err
@@ -393,10 +421,21 @@ func (c *configInternal) APIAddresses() ([]string, error) {
 	return append([]string{}, c.apiDetails.addresses...), nil
 }
 
+func (c *configInternal) StatePassword() string {
+	if c.stateDetails != nil && c.stateDetails.password != "" {
+		return c.stateDetails.password
+	}
+	return c.oldPassword
+}
+
 func (c *configInternal) Tag() string {
 	return c.tag
 }
 
+func (c *configInternal) EnvironTag() string {
+	return c.environTag
+}
+
 func (c *configInternal) Dir() string {
 	return Dir(c.dataDir, c.tag)
 }
@@ -472,21 +511,52 @@ func (c *configInternal) fileContents() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// ensureSecretKey generates a secret key for the
+// configuration if it does not already have one.
+func (c *configInternal) ensureSecretKey() error {
+	if c.secretKey != nil {
+		return nil
+	}
+	key, err := newSecretKey()
+	if err != nil {
+		return err
+	}
+	c.secretKey = key
+	return nil
+}
+
 // write is the internal implementation of c.Write().
 func (c *configInternal) write() error {
+	configDir := filepath.Dir(c.configFilePath)
+	if c.secretKey == nil {
+		// Reuse any existing key, so that the configuration
+		// already written remains readable until it is replaced.
+		key, err := readSecretKey(configDir)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		c.secretKey = key
+	}
+	if err := c.ensureSecretKey(); err != nil {
+		return err
+	}
 	data, err := c.fileContents()
 	if err != nil {
 		return err
 	}
 	// Make sure the config dir gets created.
-	configDir := filepath.Dir(c.configFilePath)
 	if err := os.MkdirAll(configDir, 0755); err != nil {
 		return fmt.Errorf("cannot create agent config dir %q: %v", configDir, err)
 	}
+	writeFile := utils.AtomicWriteFile
 	if runtime.GOOS == "windows" {
-		return utils.WriteFile(c.configFilePath, data, 0600)
+		writeFile = utils.WriteFile
+	}
+	keyPath := filepath.Join(configDir, secretKeyFilename)
+	if err := writeFile(keyPath, encodeSecretKey(c.secretKey), 0600); err != nil {
+		return fmt.Errorf("cannot write secret key: %v", err)
 	}
-	return utils.AtomicWriteFile(c.configFilePath, data, 0600)
+	return writeFile(c.configFilePath, data, 0600)
 }
 
 func (c *configInternal) Write() error {
@@ -507,6 +577,24 @@ func (c *configInternal) WriteUpgradedToVersion(newVersion version.Number) error
 	return err
 }
 
+func (c *configInternal) WriteAPIInfo(addrs []string, caCert []byte, environTag string) error {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+	if c.apiDetails == nil {
+		return errgo.New("No apidetails in config")
+	}
+	oldAddrs, oldCACert, oldEnvironTag := c.apiDetails.addresses, c.caCert, c.environTag
+	c.apiDetails.addresses = append([]string{}, addrs...)
+	c.caCert = append([]byte{}, caCert...)
+	c.environTag = environTag
+	if err := c.write(); err != nil {
+		// We don't want to retain the new details if there's been an error writing the file.
+		c.apiDetails.addresses, c.caCert, c.environTag = oldAddrs, oldCACert, oldEnvironTag
+		return err
+	}
+	return nil
+}
+
 func (c *configInternal) WriteCommands(serie string) ([]string, error) {
 	if serie[:3] == "win"{
 		return c.winWriteCommands()
@@ -515,32 +603,53 @@ func (c *configInternal) WriteCommands(serie string) ([]string, error) {
 }
 
 func (c *configInternal) winWriteCommands() ([]string, error) {
+	if err := c.ensureSecretKey(); err != nil {
+		return nil, err
+	}
 	data, err := c.fileContents()
 	if err != nil {
 		return nil, err
 	}
 	commands := []string{"mkdir " + utils.ShQuote(c.Dir())}
+	commands = append(commands, winWriteFileCommands(c.File(secretKeyFilename), encodeSecretKey(c.secretKey), 0600)...)
 	commands = append(commands, winWriteFileCommands(c.File(agentConfigFilename), data, 0600)...)
 	return commands, nil
 }
 
 func (c *configInternal) writeCommands() ([]string, error) {
+	if err := c.ensureSecretKey(); err != nil {
+		return nil, err
+	}
 	data, err := c.fileContents()
 	if err != nil {
 		return nil, err
 	}
 	commands := []string{"mkdir -p " + utils.ShQuote(c.Dir())}
+	commands = append(commands, writeFileCommands(c.File(secretKeyFilename), encodeSecretKey(c.secretKey), 0600)...)
 	commands = append(commands, writeFileCommands(c.File(agentConfigFilename), data, 0600)...)
 	return commands, nil
 }
 
+func (c *configInternal) OpenEnvironAPI(environTag string, dialOpts api.DialOpts) (*api.State, error) {
+	info := api.Info{
+		Addrs:      c.apiDetails.addresses,
+		Password:   c.apiDetails.password,
+		CACert:     c.caCert,
+		Tag:        c.tag,
+		Nonce:      c.nonce,
+		EnvironTag: environTag,
+	}
+	return api.Open(&info, dialOpts)
+}
+
 func (c *configInternal) OpenAPI(dialOpts api.DialOpts) (st *api.State, newPassword string, err error) {
 	info := api.Info{
-		Addrs:    c.apiDetails.addresses,
-		Password: c.apiDetails.password,
-		CACert:   c.caCert,
-		Tag:      c.tag,
-		Nonce:    c.nonce,
+		Addrs:      c.apiDetails.addresses,
+		Password:   c.apiDetails.password,
+		CACert:     c.caCert,
+		Tag:        c.tag,
+		Nonce:      c.nonce,
+		EnvironTag: c.environTag,
 	}
 	if info.Password != "" {
 		st, err := api.Open(&info, dialOpts)
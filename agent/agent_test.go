@@ -7,6 +7,7 @@ import (
 	gc "launchpad.net/gocheck"
 
 	"launchpad.net/juju-core/agent"
+	jc "launchpad.net/juju-core/testing/checkers"
 	"launchpad.net/juju-core/testing/testbase"
 	"launchpad.net/juju-core/version"
 )
@@ -239,13 +240,10 @@ func (s *suite) TestApiAddressesCantWriteBack(c *gc.C) {
 }
 
 func assertConfigEqual(c *gc.C, c1, c2 agent.Config) {
-	// Since we can't directly poke the internals, we'll use the WriteCommands
-	// method.
-	conf1Commands, err := c1.WriteCommands()
-	c.Assert(err, gc.IsNil)
-	conf2Commands, err := c2.WriteCommands()
-	c.Assert(err, gc.IsNil)
-	c.Assert(conf1Commands, gc.DeepEquals, conf2Commands)
+	// The secrets are encrypted afresh each time the configuration
+	// is written, so compare the configurations themselves rather
+	// than the output of their WriteCommands methods.
+	c.Assert(c1, jc.DeepEquals, c2)
 }
 
 func (*suite) TestWriteAndRead(c *gc.C) {
@@ -311,6 +309,34 @@ func (*suite) TestWriteNewPassword(c *gc.C) {
 	}
 }
 
+func (*suite) TestStatePassword(c *gc.C) {
+	testParams := attributeParams
+	testParams.DataDir = c.MkDir()
+	conf, err := agent.NewAgentConfig(testParams)
+	c.Assert(err, gc.IsNil)
+	// The old password is used until a new one is written.
+	c.Assert(conf.StatePassword(), gc.Equals, "sekrit")
+
+	newPass, err := agent.WriteNewPassword(conf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(conf.StatePassword(), gc.Equals, newPass)
+	reread, err := agent.ReadConf(agent.ConfigPath(conf.DataDir(), conf.Tag()))
+	c.Assert(err, gc.IsNil)
+	c.Assert(reread.StatePassword(), gc.Equals, newPass)
+}
+
+func (*suite) TestEnvironTag(c *gc.C) {
+	testParams := attributeParams
+	testParams.DataDir = c.MkDir()
+	testParams.EnvironTag = "environment-0fa6ab96-7a5c-4c3b-9d0c-4ea1c8a0e7e2"
+	conf, err := agent.NewAgentConfig(testParams)
+	c.Assert(err, gc.IsNil)
+	c.Assert(conf.Write(), gc.IsNil)
+	reread, err := agent.ReadConf(agent.ConfigPath(conf.DataDir(), conf.Tag()))
+	c.Assert(err, gc.IsNil)
+	c.Assert(reread.EnvironTag(), gc.Equals, testParams.EnvironTag)
+}
+
 func (*suite) TestWriteUpgradedToVersion(c *gc.C) {
 	testParams := attributeParams
 	testParams.DataDir = c.MkDir()
@@ -328,6 +354,28 @@ func (*suite) TestWriteUpgradedToVersion(c *gc.C) {
 	assertConfigEqual(c, conf, reread)
 }
 
+func (*suite) TestWriteAPIInfo(c *gc.C) {
+	testParams := attributeParams
+	testParams.DataDir = c.MkDir()
+	conf, err := agent.NewAgentConfig(testParams)
+	c.Assert(err, gc.IsNil)
+	c.Assert(conf.Write(), gc.IsNil)
+
+	environTag := "environment-0fa6ab96-7a5c-4c3b-9d0c-4ea1c8a0e7e2"
+	err = conf.WriteAPIInfo([]string{"new.example:17070"}, []byte("new-ca-cert"), environTag)
+	c.Assert(err, gc.IsNil)
+	addrs, err := conf.APIAddresses()
+	c.Assert(err, gc.IsNil)
+	c.Assert(addrs, gc.DeepEquals, []string{"new.example:17070"})
+	c.Assert(string(conf.CACert()), gc.Equals, "new-ca-cert")
+	c.Assert(conf.EnvironTag(), gc.Equals, environTag)
+
+	// Show that the new details are saved.
+	reread, err := agent.ReadConf(agent.ConfigPath(conf.DataDir(), conf.Tag()))
+	c.Assert(err, gc.IsNil)
+	assertConfigEqual(c, conf, reread)
+}
+
 // Actual opening of state and api requires a lot more boiler plate to make
 // sure they are valid connections.  This is done in the cmd/jujud tests for
 // bootstrap, machine and unit tests.
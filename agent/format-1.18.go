@@ -51,7 +51,7 @@ func (formatter_1_18) version() string {
 	return "1.18"
 }
 
-func (formatter_1_18) unmarshal(data []byte) (*configInternal, error) {
+func (formatter_1_18) unmarshal(data []byte, dir string) (*configInternal, error) {
 	var format format_1_18Serialization
 	if err := goyaml.Unmarshal(data, &format); err != nil {
 		return nil, err
@@ -92,29 +92,3 @@ func (formatter_1_18) unmarshal(data []byte) (*configInternal, error) {
 	}
 	return config, nil
 }
-
-func (formatter_1_18) marshal(config *configInternal) ([]byte, error) {
-	format := &format_1_18Serialization{
-		Tag:               config.tag,
-		DataDir:           config.dataDir,
-		LogDir:            config.logDir,
-		Jobs:              config.jobs,
-		UpgradedToVersion: &config.upgradedToVersion,
-		Nonce:             config.nonce,
-		CACert:            string(config.caCert),
-		OldPassword:       config.oldPassword,
-		StateServerCert:   string(config.stateServerCert),
-		StateServerKey:    string(config.stateServerKey),
-		APIPort:           config.apiPort,
-		Values:            config.values,
-	}
-	if config.stateDetails != nil {
-		format.StateAddresses = config.stateDetails.addresses
-		format.StatePassword = config.stateDetails.password
-	}
-	if config.apiDetails != nil {
-		format.APIAddresses = config.apiDetails.addresses
-		format.APIPassword = config.apiDetails.password
-	}
-	return goyaml.Marshal(format)
-}
@@ -0,0 +1,153 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package agent
+
+import (
+	"fmt"
+
+	"launchpad.net/goyaml"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/version"
+)
+
+var format_1_20 = formatter_1_20{}
+
+// formatter_1_20 is the formatter for the 1.20 format. It differs
+// from the 1.18 format in that the passwords and the state server
+// key are not stored in plain text: they are encrypted with the
+// key held in the secret key file alongside the agent config.
+type formatter_1_20 struct {
+}
+
+// Ensure that the formatter_1_20 struct implements the formatter interface.
+var _ formatter = formatter_1_20{}
+
+// format_1_20Serialization holds information for a given agent.
+type format_1_20Serialization struct {
+	Tag               string
+	EnvironTag        string `yaml:",omitempty"`
+	DataDir           string
+	LogDir            string
+	Nonce             string
+	Jobs              []params.MachineJob `yaml:",omitempty"`
+	UpgradedToVersion *version.Number     `yaml:"upgradedToVersion"`
+
+	CACert         string
+	StateAddresses []string `yaml:",omitempty"`
+	APIAddresses   []string `yaml:",omitempty"`
+	Values         map[string]string
+
+	// Only state server machines have these next two items
+	StateServerCert string `yaml:",omitempty"`
+	APIPort         int    `yaml:",omitempty"`
+
+	// Secrets holds the encrypted format_1_20Secrets.
+	Secrets string
+}
+
+// format_1_20Secrets holds the secrets for a given agent.
+type format_1_20Secrets struct {
+	StatePassword  string `yaml:",omitempty"`
+	APIPassword    string `yaml:",omitempty"`
+	OldPassword    string `yaml:",omitempty"`
+	StateServerKey string `yaml:",omitempty"`
+}
+
+func init() {
+	registerFormat(format_1_20)
+}
+
+func (formatter_1_20) version() string {
+	return "1.20"
+}
+
+func (formatter_1_20) unmarshal(data []byte, dir string) (*configInternal, error) {
+	var format format_1_20Serialization
+	if err := goyaml.Unmarshal(data, &format); err != nil {
+		return nil, err
+	}
+	if format.UpgradedToVersion == nil {
+		return nil, requiredError("upgradedToVersion")
+	}
+	key, err := readSecretKey(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read secret key: %v", err)
+	}
+	secretsData, err := decryptSecrets(key, format.Secrets)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt agent config secrets: %v", err)
+	}
+	var secrets format_1_20Secrets
+	if err := goyaml.Unmarshal(secretsData, &secrets); err != nil {
+		return nil, err
+	}
+	config := &configInternal{
+		tag:               format.Tag,
+		environTag:        format.EnvironTag,
+		dataDir:           format.DataDir,
+		logDir:            format.LogDir,
+		jobs:              format.Jobs,
+		upgradedToVersion: *format.UpgradedToVersion,
+		nonce:             format.Nonce,
+		caCert:            []byte(format.CACert),
+		oldPassword:       secrets.OldPassword,
+		stateServerCert:   []byte(format.StateServerCert),
+		stateServerKey:    []byte(secrets.StateServerKey),
+		apiPort:           format.APIPort,
+		values:            format.Values,
+		secretKey:         key,
+	}
+	if config.logDir == "" {
+		config.logDir = DefaultLogDir
+	}
+	if len(format.StateAddresses) > 0 {
+		config.stateDetails = &connectionDetails{
+			format.StateAddresses,
+			secrets.StatePassword,
+		}
+	}
+	if len(format.APIAddresses) > 0 {
+		config.apiDetails = &connectionDetails{
+			format.APIAddresses,
+			secrets.APIPassword,
+		}
+	}
+	return config, nil
+}
+
+func (formatter_1_20) marshal(config *configInternal) ([]byte, error) {
+	format := &format_1_20Serialization{
+		Tag:               config.tag,
+		EnvironTag:        config.environTag,
+		DataDir:           config.dataDir,
+		LogDir:            config.logDir,
+		Jobs:              config.jobs,
+		UpgradedToVersion: &config.upgradedToVersion,
+		Nonce:             config.nonce,
+		CACert:            string(config.caCert),
+		StateServerCert:   string(config.stateServerCert),
+		APIPort:           config.apiPort,
+		Values:            config.values,
+	}
+	secrets := &format_1_20Secrets{
+		OldPassword:    config.oldPassword,
+		StateServerKey: string(config.stateServerKey),
+	}
+	if config.stateDetails != nil {
+		format.StateAddresses = config.stateDetails.addresses
+		secrets.StatePassword = config.stateDetails.password
+	}
+	if config.apiDetails != nil {
+		format.APIAddresses = config.apiDetails.addresses
+		secrets.APIPassword = config.apiDetails.password
+	}
+	secretsData, err := goyaml.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+	if format.Secrets, err = encryptSecrets(config.secretKey, secretsData); err != nil {
+		return nil, fmt.Errorf("cannot encrypt agent config secrets: %v", err)
+	}
+	return goyaml.Marshal(format)
+}
@@ -0,0 +1,96 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The format tests are white box tests, meaning that the tests are in the
+// same package as the code, as all the format details are internal to the
+// package.
+
+package agent
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	gc "launchpad.net/gocheck"
+
+	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/testing/testbase"
+	"launchpad.net/juju-core/utils"
+	"launchpad.net/juju-core/version"
+)
+
+type format_1_20Suite struct {
+	testbase.LoggingSuite
+}
+
+var _ = gc.Suite(&format_1_20Suite{})
+
+func (*format_1_20Suite) TestSecretsEncrypted(c *gc.C) {
+	stateParams := StateMachineConfigParams{
+		AgentConfigParams: agentParams,
+		StateServerCert:   []byte("some special cert"),
+		StateServerKey:    []byte("a special key"),
+		APIPort:           23456,
+	}
+	stateParams.DataDir = c.MkDir()
+	config, err := NewStateMachineConfig(stateParams)
+	c.Assert(err, gc.IsNil)
+	newPassword, err := config.(*configInternal).writeNewPassword()
+	c.Assert(err, gc.IsNil)
+
+	data, err := ioutil.ReadFile(config.(*configInternal).configFilePath)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), jc.HasPrefix, "# format 1.20\n")
+	c.Assert(string(data), jc.Contains, "some special cert")
+	c.Assert(string(data), gc.Not(jc.Contains), "a special key")
+	c.Assert(string(data), gc.Not(jc.Contains), "sekrit")
+	c.Assert(string(data), gc.Not(jc.Contains), newPassword)
+	assertFileExists(c, filepath.Join(config.Dir(), secretKeyFilename))
+}
+
+func (*format_1_20Suite) TestReadConfMissingSecretKey(c *gc.C) {
+	config := newTestConfig(c)
+	err := config.Write()
+	c.Assert(err, gc.IsNil)
+	err = os.Remove(filepath.Join(config.Dir(), secretKeyFilename))
+	c.Assert(err, gc.IsNil)
+	_, err = ReadConf(config.configFilePath)
+	c.Assert(err, gc.ErrorMatches, "cannot read secret key: .*")
+}
+
+func (*format_1_20Suite) TestReadConfWrongSecretKey(c *gc.C) {
+	config := newTestConfig(c)
+	err := config.Write()
+	c.Assert(err, gc.IsNil)
+	key, err := newSecretKey()
+	c.Assert(err, gc.IsNil)
+	err = ioutil.WriteFile(filepath.Join(config.Dir(), secretKeyFilename), encodeSecretKey(key), 0600)
+	c.Assert(err, gc.IsNil)
+	_, err = ReadConf(config.configFilePath)
+	c.Assert(err, gc.ErrorMatches, "cannot decrypt agent config secrets: .*")
+}
+
+func (*format_1_20Suite) TestReadConfMigrates1_18(c *gc.C) {
+	dataDir := c.MkDir()
+	configPath := filepath.Join(dataDir, agentConfigFilename)
+	err := utils.AtomicWriteFile(configPath, []byte(agentConfig1_18Contents), 0600)
+	c.Assert(err, gc.IsNil)
+
+	config, err := ReadConf(configPath)
+	c.Assert(err, gc.IsNil)
+	assertFileExists(c, filepath.Join(dataDir, secretKeyFilename))
+	data, err := ioutil.ReadFile(configPath)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), jc.HasPrefix, "# format 1.20\n")
+	c.Assert(string(data), gc.Not(jc.Contains), "NB5imrDaWCCRW/4akSSvUxhX")
+	c.Assert(string(data), gc.Not(jc.Contains), "BEGIN RSA PRIVATE KEY")
+
+	// The migrated configuration reads back the same.
+	reread, err := ReadConf(configPath)
+	c.Assert(err, gc.IsNil)
+	c.Assert(reread, jc.DeepEquals, config)
+	c.Assert(reread.UpgradedToVersion(), gc.Equals, version.MustParse("1.17.5.1"))
+	c.Assert(reread.(*configInternal).apiDetails.password, gc.Equals, "NB5imrDaWCCRW/4akSSvUxhX")
+	c.Assert(string(reread.(*configInternal).stateServerKey), jc.Contains, "BEGIN RSA PRIVATE KEY")
+}
@@ -14,7 +14,9 @@ import (
 // Current agent config format is defined as follows:
 // # format <version>\n   (very first line; <version> is 1.18 or later)
 // <config-encoded-as-yaml>
-// All of this is saved in a single agent.conf file.
+// All of this is saved in a single agent.conf file. From version 1.20,
+// the secrets in the agent configuration are encrypted with a key that
+// is saved in agent.key, alongside agent.conf.
 //
 // Historically the format file in the agent config directory was used
 // to identify the method of serialization. This was used by
@@ -39,9 +41,11 @@ var formats = make(map[string]formatter)
 
 // The formatter defines the two methods needed by the formatters for
 // translating to and from the internal, format agnostic, structure.
+// The unmarshal method is given the directory holding the agent
+// config, so that formats may read other files from it.
 type formatter interface {
 	version() string
-	unmarshal(data []byte) (*configInternal, error)
+	unmarshal(data []byte, dir string) (*configInternal, error)
 }
 
 func registerFormat(format formatter) {
@@ -55,7 +59,7 @@ func registerFormat(format formatter) {
 // - Remove the marshal() method from the old format;
 
 // currentFormat holds the current agent config version's formatter.
-var currentFormat = format_1_18
+var currentFormat = format_1_20
 
 // agentConfigFilename is the default file name of used for the agent
 // config.
@@ -88,7 +92,7 @@ func getFormatter(version string) (formatter, error) {
 	return format, nil
 }
 
-func parseConfigData(data []byte) (formatter, *configInternal, error) {
+func parseConfigData(data []byte, dir string) (formatter, *configInternal, error) {
 	i := bytes.IndexByte(data, '\n')
 	if i == -1 {
 		return nil, nil, fmt.Errorf("invalid agent config format: %s", string(data))
@@ -102,7 +106,7 @@ func parseConfigData(data []byte) (formatter, *configInternal, error) {
 	if err != nil {
 		return nil, nil, err
 	}
-	config, err := format.unmarshal(configData)
+	config, err := format.unmarshal(configData, dir)
 	if err != nil {
 		return nil, nil, err
 	}
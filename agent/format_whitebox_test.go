@@ -45,12 +45,15 @@ func newTestConfig(c *gc.C) *configInternal {
 
 func (*formatSuite) TestWriteCommands(c *gc.C) {
 	config := newTestConfig(c)
-	commands, err := config.WriteCommands()
+	commands, err := config.WriteCommands("precise")
 	c.Assert(err, gc.IsNil)
-	c.Assert(commands, gc.HasLen, 3)
+	c.Assert(commands, gc.HasLen, 5)
 	c.Assert(commands[0], gc.Matches, `mkdir -p '\S+/agents/omg'`)
-	c.Assert(commands[1], gc.Matches, `install -m 600 /dev/null '\S+/agents/omg/agent.conf'`)
-	c.Assert(commands[2], gc.Matches, `printf '%s\\n' '(.|\n)*' > '\S+/agents/omg/agent.conf'`)
+	c.Assert(commands[1], gc.Matches, `install -m 600 /dev/null '\S+/agents/omg/agent.key'`)
+	c.Assert(commands[2], gc.Matches, `printf '%s\\n' '[A-Za-z0-9+/=]+' > '\S+/agents/omg/agent.key'`)
+	c.Assert(commands[3], gc.Matches, `install -m 600 /dev/null '\S+/agents/omg/agent.conf'`)
+	c.Assert(commands[4], gc.Matches, `printf '%s\\n' '(.|\n)*' > '\S+/agents/omg/agent.conf'`)
+	c.Assert(commands[4], gc.Not(jc.Contains), "sekrit")
 }
 
 func (*formatSuite) TestWriteAgentConfig(c *gc.C) {
@@ -61,9 +64,27 @@ func (*formatSuite) TestWriteAgentConfig(c *gc.C) {
 	configPath := ConfigPath(config.DataDir(), config.Tag())
 	formatPath := filepath.Join(config.Dir(), legacyFormatFilename)
 	assertFileExists(c, configPath)
+	assertFileExists(c, filepath.Join(config.Dir(), secretKeyFilename))
 	assertFileNotExist(c, formatPath)
 }
 
+func (*formatSuite) TestWriteKeepsSecretKey(c *gc.C) {
+	config := newTestConfig(c)
+	err := config.Write()
+	c.Assert(err, gc.IsNil)
+	c.Assert(config.secretKey, gc.HasLen, secretKeySize)
+
+	// A new configuration written to the same
+	// place uses the existing key.
+	params := agentParams
+	params.DataDir = config.DataDir()
+	other, err := NewAgentConfig(params)
+	c.Assert(err, gc.IsNil)
+	err = other.Write()
+	c.Assert(err, gc.IsNil)
+	c.Assert(other.(*configInternal).secretKey, gc.DeepEquals, config.secretKey)
+}
+
 func (*formatSuite) TestRead(c *gc.C) {
 	config := newTestConfig(c)
 	assertWriteAndRead(c, config)
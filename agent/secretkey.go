@@ -0,0 +1,96 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package agent
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// secretKeyFilename is the name of the file, alongside the agent
+// config file, that holds the key used to encrypt the secrets in
+// the agent config. It is only readable by its owner, so that
+// the secrets are not exposed if the agent config file is.
+const secretKeyFilename = "agent.key"
+
+// secretKeySize is the size of the AES-256 secret key.
+const secretKeySize = 32
+
+// newSecretKey returns a new random secret key.
+func newSecretKey() ([]byte, error) {
+	key := make([]byte, secretKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("cannot generate secret key: %v", err)
+	}
+	return key, nil
+}
+
+// encodeSecretKey returns the contents of a secret key file
+// holding the given key.
+func encodeSecretKey(key []byte) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(key))
+}
+
+// readSecretKey reads the secret key from the given agent
+// config directory. If the file does not exist, the returned
+// error satisfies os.IsNotExist.
+func readSecretKey(dir string) ([]byte, error) {
+	path := filepath.Join(dir, secretKeyFilename)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != secretKeySize {
+		return nil, fmt.Errorf("invalid secret key in %q", path)
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptSecrets encrypts and authenticates data with the given
+// key, returning it base64-encoded, prefixed with its nonce.
+func encryptSecrets(key, data []byte) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("cannot generate nonce: %v", err)
+	}
+	sealed := aead.Seal(nonce, nonce, data, nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptSecrets reverses encryptSecrets.
+func decryptSecrets(key []byte, encrypted string) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("secrets too short")
+	}
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, nil)
+}
@@ -23,6 +23,7 @@ type Bundle struct {
 	Path     string // May be empty if Bundle wasn't read from a file
 	meta     *Meta
 	config   *Config
+	metrics  *Metrics
 	revision int
 	r        io.ReaderAt
 	size     int64
@@ -85,6 +86,19 @@ func readBundle(r io.ReaderAt, size int64) (bundle *Bundle, err error) {
 		}
 	}
 
+	reader, err = zipOpen(zipr, "metrics.yaml")
+	if _, ok := err.(*noBundleFile); ok {
+		// Declaring metrics is optional.
+	} else if err != nil {
+		return nil, err
+	} else {
+		b.metrics, err = ReadMetrics(reader)
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	reader, err = zipOpen(zipr, "revision")
 	if err != nil {
 		if _, ok := err.(*noBundleFile); !ok {
@@ -142,6 +156,13 @@ func (b *Bundle) Config() *Config {
 	return b.config
 }
 
+// Metrics returns the Metrics representing the metrics.yaml file
+// for the charm bundle, or nil if the charm does not declare any
+// metrics.
+func (b *Bundle) Metrics() *Metrics {
+	return b.metrics
+}
+
 type zipReadCloser struct {
 	io.Closer
 	*zip.Reader
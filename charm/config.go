@@ -8,6 +8,7 @@ import (
 	"io"
 	"io/ioutil"
 	"strconv"
+	"strings"
 
 	"launchpad.net/goyaml"
 
@@ -19,29 +20,69 @@ import (
 // C, and every value either has the correct type or is nil.
 type Settings map[string]interface{}
 
+// RedactedValue is reported in place of the value of a secret option
+// wherever settings are shown to users.
+const RedactedValue = "<redacted>"
+
 // Option represents a single charm config option.
 type Option struct {
 	Type        string
 	Description string
 	Default     interface{}
+
+	// Values holds the allowed values of an enum option.
+	Values []string `yaml:",omitempty" bson:",omitempty"`
+
+	// Min and Max, if not nil, hold the inclusive bounds of an
+	// int or float option.
+	Min interface{} `yaml:",omitempty" bson:",omitempty"`
+	Max interface{} `yaml:",omitempty" bson:",omitempty"`
+}
+
+// Secret reports whether values of the option must be hidden
+// from users.
+func (option Option) Secret() bool {
+	return option.Type == "secret"
+}
+
+// describe returns a representation of the supplied value that is
+// suitable for use in error messages.
+func (option Option) describe(value interface{}) string {
+	if option.Secret() {
+		return RedactedValue
+	}
+	return fmt.Sprintf("%#v", value)
 }
 
 // error replaces any supplied non-nil error with a new error describing a
 // validation failure for the supplied value.
 func (option Option) error(err *error, name string, value interface{}) {
 	if *err != nil {
-		*err = fmt.Errorf("option %q expected %s, got %#v", name, option.Type, value)
+		*err = fmt.Errorf("option %q expected %s, got %s", name, option.Type, option.describe(value))
 	}
 }
 
 // validate returns an appropriately-typed value for the supplied value, or
-// returns an error if it cannot be converted to the correct type. Nil values
-// are always considered valid.
-func (option Option) validate(name string, value interface{}) (_ interface{}, err error) {
+// returns an error if it cannot be converted to the correct type or is not
+// acceptable to the option. Nil values are always considered valid.
+func (option Option) validate(name string, value interface{}) (interface{}, error) {
 	if value == nil {
 		return nil, nil
 	}
+	value, err := option.coerce(name, value)
+	if err != nil {
+		return nil, err
+	}
+	return value, option.check(name, value)
+}
+
+// coerce returns an appropriately-typed value for the supplied value, or
+// returns an error if it cannot be converted to the correct type.
+func (option Option) coerce(name string, value interface{}) (_ interface{}, err error) {
 	defer option.error(&err, name, value)
+	if str, ok := value.(string); ok && option.Type == "list" {
+		return parseList(str), nil
+	}
 	if checker := optionTypeCheckers[option.Type]; checker != nil {
 		if value, err = checker.Coerce(value, nil); err != nil {
 			return nil, err
@@ -51,19 +92,83 @@ func (option Option) validate(name string, value interface{}) (_ interface{}, er
 	panic(fmt.Errorf("option %q has unknown type %q", name, option.Type))
 }
 
+// check returns an error if the supplied correctly-typed value is not
+// one of the values allowed by an enum option, or lies outside the
+// bounds of a numeric option.
+func (option Option) check(name string, value interface{}) error {
+	switch option.Type {
+	case "enum":
+		for _, allowed := range option.Values {
+			if value == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("option %q expected one of %s, got %#v", name, strings.Join(option.Values, ", "), value)
+	case "int", "float":
+		if option.Min != nil && compareNumbers(value, option.Min) < 0 {
+			return fmt.Errorf("option %q expected value not less than %v, got %v", name, option.Min, value)
+		}
+		if option.Max != nil && compareNumbers(value, option.Max) > 0 {
+			return fmt.Errorf("option %q expected value not greater than %v, got %v", name, option.Max, value)
+		}
+	}
+	return nil
+}
+
+// compareNumbers returns -1, 0 or 1 depending on whether a is less than,
+// equal to or greater than b. Both values must be int64 or float64 values
+// of the same type.
+func compareNumbers(a, b interface{}) int {
+	switch a := a.(type) {
+	case int64:
+		b := b.(int64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	case float64:
+		b := b.(float64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	default:
+		panic(fmt.Errorf("cannot compare %#v", a))
+	}
+	return 0
+}
+
 var optionTypeCheckers = map[string]schema.Checker{
 	"string":  schema.String(),
 	"int":     schema.Int(),
 	"float":   schema.Float(),
 	"boolean": schema.Bool(),
+	"enum":    schema.String(),
+	"list":    schema.List(schema.String()),
+	"secret":  schema.String(),
 }
 
 // parse returns an appropriately-typed value for the supplied string, or
-// returns an error if it cannot be parsed to the correct type.
-func (option Option) parse(name, str string) (_ interface{}, err error) {
+// returns an error if it cannot be parsed to the correct type or is not
+// acceptable to the option.
+func (option Option) parse(name, str string) (interface{}, error) {
+	value, err := option.parseString(name, str)
+	if err != nil {
+		return nil, err
+	}
+	return value, option.check(name, value)
+}
+
+// parseString returns an appropriately-typed value for the supplied string,
+// or returns an error if it cannot be parsed to the correct type.
+func (option Option) parseString(name, str string) (_ interface{}, err error) {
 	defer option.error(&err, name, str)
 	switch option.Type {
-	case "string":
+	case "string", "enum", "secret":
 		return str, nil
 	case "int":
 		return strconv.ParseInt(str, 10, 64)
@@ -71,10 +176,58 @@ func (option Option) parse(name, str string) (_ interface{}, err error) {
 		return strconv.ParseFloat(str, 64)
 	case "boolean":
 		return strconv.ParseBool(str)
+	case "list":
+		return parseList(str), nil
 	}
 	panic(fmt.Errorf("option %q has unknown type %q", name, option.Type))
 }
 
+// parseList returns the non-empty elements of the supplied
+// comma-separated list, with surrounding whitespace removed.
+func parseList(str string) []interface{} {
+	out := []interface{}{}
+	for _, elem := range strings.Split(str, ",") {
+		if elem = strings.TrimSpace(elem); elem != "" {
+			out = append(out, elem)
+		}
+	}
+	return out
+}
+
+// validateSchema checks that any enum values and numeric bounds of
+// the option are consistent with its type, and converts the bounds to
+// the option's type.
+func (option *Option) validateSchema(name string) error {
+	if option.Type == "enum" {
+		if len(option.Values) == 0 {
+			return fmt.Errorf("option %q has no enum values", name)
+		}
+	} else if option.Values != nil {
+		return fmt.Errorf("option %q of type %q cannot specify values", name, option.Type)
+	}
+	if option.Type != "int" && option.Type != "float" {
+		if option.Min != nil || option.Max != nil {
+			return fmt.Errorf("option %q of type %q cannot specify bounds", name, option.Type)
+		}
+		return nil
+	}
+	checker := optionTypeCheckers[option.Type]
+	for _, bound := range []*interface{}{&option.Min, &option.Max} {
+		if *bound == nil {
+			continue
+		}
+		value, err := checker.Coerce(*bound, nil)
+		if err != nil {
+			return fmt.Errorf("option %q has invalid bound %#v", name, *bound)
+		}
+		*bound = value
+	}
+	if option.Min != nil && option.Max != nil && compareNumbers(option.Min, option.Max) > 0 {
+		return fmt.Errorf("option %q has min %v greater than max %v", name, option.Min, option.Max)
+	}
+	return nil
+}
+
 // Config represents the supported configuration options for a charm,
 // as declared in its config.yaml file.
 type Config struct {
@@ -101,18 +254,20 @@ func ReadConfig(r io.Reader) (*Config, error) {
 	}
 	for name, option := range config.Options {
 		switch option.Type {
-		case "string", "int", "float", "boolean":
+		case "string", "int", "float", "boolean", "enum", "list", "secret":
 		case "":
 			// Missing type is valid in python.
 			option.Type = "string"
 		default:
 			return nil, fmt.Errorf("invalid config: option %q has unknown type %q", name, option.Type)
 		}
+		if err := option.validateSchema(name); err != nil {
+			return nil, fmt.Errorf("invalid config: %v", err)
+		}
 		def := option.Default
-		if def == "" && option.Type == "string" {
+		if def == "" && (option.Type == "string" || option.Type == "secret") {
 			// Skip normal validation for compatibility with pyjuju.
 		} else if option.Default, err = option.validate(name, def); err != nil {
-			option.error(&err, name, def)
 			return nil, fmt.Errorf("invalid config default: %v", err)
 		}
 		config.Options[name] = option
@@ -168,6 +323,19 @@ func (c *Config) FilterSettings(settings Settings) Settings {
 	return out
 }
 
+// RedactSettings returns a copy of the supplied settings in which the
+// values of secret options are replaced with RedactedValue.
+func (c *Config) RedactSettings(settings Settings) Settings {
+	out := make(Settings)
+	for name, value := range settings {
+		if value != nil && c.Options[name].Secret() {
+			value = RedactedValue
+		}
+		out[name] = value
+	}
+	return out
+}
+
 // ParseSettingsStrings returns settings derived from the supplied map. Every
 // value in the map must be parseable to the correct type for the option
 // identified by its key. Empty values are interpreted as nil.
@@ -428,3 +428,224 @@ func (s *ConfigSuite) TestEmptyConfigReturnsError(c *gc.C) {
 	c.Assert(result, gc.IsNil)
 	c.Assert(err, gc.ErrorMatches, "invalid config: empty configuration")
 }
+
+type TypedConfigSuite struct {
+	config *charm.Config
+}
+
+var _ = gc.Suite(&TypedConfigSuite{})
+
+func (s *TypedConfigSuite) SetUpSuite(c *gc.C) {
+	var err error
+	s.config, err = charm.ReadConfig(bytes.NewBuffer([]byte(`
+options:
+  flavour:
+    description: The flavour of the service.
+    type: enum
+    values: [vanilla, chocolate, strawberry]
+    default: vanilla
+  peers:
+    description: Hosts to peer with.
+    type: list
+    default: "alpha, beta"
+  workers:
+    description: Number of worker processes.
+    type: int
+    min: 1
+    max: 16
+  load-factor:
+    description: Fraction of capacity to use.
+    type: float
+    min: 0
+    max: 1
+  password:
+    description: The administrator password.
+    type: secret
+`)))
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *TypedConfigSuite) TestReadTyped(c *gc.C) {
+	c.Assert(s.config.Options, gc.DeepEquals, map[string]charm.Option{
+		"flavour": {
+			Type:        "enum",
+			Description: "The flavour of the service.",
+			Default:     "vanilla",
+			Values:      []string{"vanilla", "chocolate", "strawberry"},
+		},
+		"peers": {
+			Type:        "list",
+			Description: "Hosts to peer with.",
+			Default:     []interface{}{"alpha", "beta"},
+		},
+		"workers": {
+			Type:        "int",
+			Description: "Number of worker processes.",
+			Min:         int64(1),
+			Max:         int64(16),
+		},
+		"load-factor": {
+			Type:        "float",
+			Description: "Fraction of capacity to use.",
+			Min:         0.0,
+			Max:         1.0,
+		},
+		"password": {
+			Type:        "secret",
+			Description: "The administrator password.",
+		},
+	})
+	c.Assert(s.config.Options["password"].Secret(), gc.Equals, true)
+	c.Assert(s.config.Options["flavour"].Secret(), gc.Equals, false)
+}
+
+func (s *TypedConfigSuite) TestValidateTypedSettings(c *gc.C) {
+	for i, test := range []struct {
+		info   string
+		input  charm.Settings
+		expect charm.Settings
+		err    string
+	}{{
+		info: "valid values",
+		input: charm.Settings{
+			"flavour":     "chocolate",
+			"peers":       []interface{}{"gamma"},
+			"workers":     16,
+			"load-factor": 0.5,
+			"password":    "sekrit",
+		},
+		expect: charm.Settings{
+			"flavour":     "chocolate",
+			"peers":       []interface{}{"gamma"},
+			"workers":     int64(16),
+			"load-factor": 0.5,
+			"password":    "sekrit",
+		},
+	}, {
+		info:   "list from string",
+		input:  charm.Settings{"peers": " gamma,,delta "},
+		expect: charm.Settings{"peers": []interface{}{"gamma", "delta"}},
+	}, {
+		info:  "bad enum value",
+		input: charm.Settings{"flavour": "mint"},
+		err:   `option "flavour" expected one of vanilla, chocolate, strawberry, got "mint"`,
+	}, {
+		info:  "bad list",
+		input: charm.Settings{"peers": []interface{}{1, 2}},
+		err:   `option "peers" expected list, got \[\]interface \{\}\{1, 2\}`,
+	}, {
+		info:  "int below min",
+		input: charm.Settings{"workers": 0},
+		err:   `option "workers" expected value not less than 1, got 0`,
+	}, {
+		info:  "float above max",
+		input: charm.Settings{"load-factor": 1.5},
+		err:   `option "load-factor" expected value not greater than 1, got 1.5`,
+	}, {
+		info:  "secret values are not revealed",
+		input: charm.Settings{"password": 1234},
+		err:   `option "password" expected secret, got <redacted>`,
+	}} {
+		c.Logf("test %d: %s", i, test.info)
+		result, err := s.config.ValidateSettings(test.input)
+		if test.err != "" {
+			c.Check(err, gc.ErrorMatches, test.err)
+		} else {
+			c.Check(err, gc.IsNil)
+			c.Check(result, gc.DeepEquals, test.expect)
+		}
+	}
+}
+
+func (s *TypedConfigSuite) TestParseTypedSettingsStrings(c *gc.C) {
+	result, err := s.config.ParseSettingsStrings(map[string]string{
+		"flavour":     "strawberry",
+		"peers":       "a,b, c",
+		"workers":     "3",
+		"load-factor": "0.25",
+		"password":    "sekrit",
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, charm.Settings{
+		"flavour":     "strawberry",
+		"peers":       []interface{}{"a", "b", "c"},
+		"workers":     int64(3),
+		"load-factor": 0.25,
+		"password":    "sekrit",
+	})
+
+	_, err = s.config.ParseSettingsStrings(map[string]string{"workers": "17"})
+	c.Assert(err, gc.ErrorMatches, `option "workers" expected value not greater than 16, got 17`)
+	_, err = s.config.ParseSettingsStrings(map[string]string{"flavour": ""})
+	c.Assert(err, gc.ErrorMatches, `option "flavour" expected one of .*, got ""`)
+}
+
+func (s *TypedConfigSuite) TestParseTypedSettingsYAML(c *gc.C) {
+	result, err := s.config.ParseSettingsYAML([]byte(`
+blah:
+  flavour: vanilla
+  peers: [x, y]
+  workers: "8"
+`), "blah")
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, charm.Settings{
+		"flavour": "vanilla",
+		"peers":   []interface{}{"x", "y"},
+		"workers": int64(8),
+	})
+
+	_, err = s.config.ParseSettingsYAML([]byte("blah:\n  workers: 99"), "blah")
+	c.Assert(err, gc.ErrorMatches, `option "workers" expected value not greater than 16, got 99`)
+}
+
+func (s *TypedConfigSuite) TestRedactSettings(c *gc.C) {
+	settings := charm.Settings{
+		"flavour":  "vanilla",
+		"password": "sekrit",
+		"unknown":  "whatever",
+	}
+	c.Assert(s.config.RedactSettings(settings), gc.DeepEquals, charm.Settings{
+		"flavour":  "vanilla",
+		"password": charm.RedactedValue,
+		"unknown":  "whatever",
+	})
+	// The supplied settings are left untouched.
+	c.Assert(settings["password"], gc.Equals, "sekrit")
+
+	// Unset secrets have no value to hide.
+	c.Assert(s.config.RedactSettings(charm.Settings{"password": nil}), gc.DeepEquals, charm.Settings{
+		"password": nil,
+	})
+}
+
+func (s *TypedConfigSuite) TestTypedConfigErrors(c *gc.C) {
+	for i, test := range []struct {
+		config string
+		err    string
+	}{{
+		config: `options: {t: {type: enum}}`,
+		err:    `invalid config: option "t" has no enum values`,
+	}, {
+		config: `options: {t: {type: string, values: [a, b]}}`,
+		err:    `invalid config: option "t" of type "string" cannot specify values`,
+	}, {
+		config: `options: {t: {type: boolean, min: 1}}`,
+		err:    `invalid config: option "t" of type "boolean" cannot specify bounds`,
+	}, {
+		config: `options: {t: {type: int, max: lots}}`,
+		err:    `invalid config: option "t" has invalid bound "lots"`,
+	}, {
+		config: `options: {t: {type: int, min: 10, max: 1}}`,
+		err:    `invalid config: option "t" has min 10 greater than max 1`,
+	}, {
+		config: `options: {t: {type: enum, values: [a, b], default: c}}`,
+		err:    `invalid config default: option "t" expected one of a, b, got "c"`,
+	}, {
+		config: `options: {t: {type: int, min: 10, default: 5}}`,
+		err:    `invalid config default: option "t" expected value not less than 10, got 5`,
+	}} {
+		c.Logf("test %d: %s", i, test.config)
+		_, err := charm.ReadConfig(bytes.NewBuffer([]byte(test.config)))
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
@@ -24,6 +24,7 @@ type Dir struct {
 	Path     string
 	meta     *Meta
 	config   *Config
+	metrics  *Metrics
 	revision int
 }
 
@@ -54,6 +55,18 @@ func ReadDir(path string) (dir *Dir, err error) {
 			return nil, err
 		}
 	}
+	file, err = os.Open(dir.join("metrics.yaml"))
+	if _, ok := err.(*os.PathError); ok {
+		// Declaring metrics is optional.
+	} else if err != nil {
+		return nil, err
+	} else {
+		dir.metrics, err = ReadMetrics(file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
 	if file, err = os.Open(dir.join("revision")); err == nil {
 		_, err = fmt.Fscan(file, &dir.revision)
 		file.Close()
@@ -92,6 +105,13 @@ func (dir *Dir) Config() *Config {
 	return dir.config
 }
 
+// Metrics returns the Metrics representing the metrics.yaml file
+// for the charm expanded in dir, or nil if the charm does not
+// declare any metrics.
+func (dir *Dir) Metrics() *Metrics {
+	return dir.metrics
+}
+
 // SetRevision changes the charm revision number. This affects
 // the revision reported by Revision and the revision of the
 // charm bundled by BundleTo.
@@ -17,6 +17,22 @@ const (
 	UpgradeCharm  Kind = "upgrade-charm"
 	Stop          Kind = "stop"
 
+	// CollectMetrics is run periodically by the unit agent, when the
+	// charm declares metrics, to collect values for those metrics.
+	CollectMetrics Kind = "collect-metrics"
+
+	// MigrateFrom is run on a unit that is being migrated to another
+	// machine, to pass its data on to the unit replacing it, and
+	// MigrateTo is then run on the replacement to take the data over.
+	MigrateFrom Kind = "migrate-from"
+	MigrateTo   Kind = "migrate-to"
+
+	// MaintenanceStarted is run when the unit's machine is put into
+	// maintenance mode, and MaintenanceFinished when it is taken out
+	// of it again.
+	MaintenanceStarted  Kind = "maintenance-started"
+	MaintenanceFinished Kind = "maintenance-finished"
+
 	// These hooks require an associated relation, and the name of the relation
 	// unit whose change triggered the hook. The hook file names that these
 	// kinds represent will be prefixed by the relation name; for example,
@@ -37,6 +53,11 @@ var unitHooks = []Kind{
 	ConfigChanged,
 	UpgradeCharm,
 	Stop,
+	CollectMetrics,
+	MigrateFrom,
+	MigrateTo,
+	MaintenanceStarted,
+	MaintenanceFinished,
 }
 
 // UnitHooks returns all known unit hook kinds.
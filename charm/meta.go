@@ -9,6 +9,7 @@ import (
 	"io"
 	"io/ioutil"
 	"strings"
+	"time"
 
 	"launchpad.net/goyaml"
 
@@ -102,6 +103,11 @@ type Meta struct {
 	Format      int                 `bson:",omitempty"`
 	OldRevision int                 `bson:",omitempty"` // Obsolete
 	Categories  []string            `bson:",omitempty"`
+
+	// HookTimeouts holds the maximum time each named hook may
+	// run for before it is killed. Hooks not present may run
+	// indefinitely.
+	HookTimeouts map[string]time.Duration `bson:",omitempty"`
 }
 
 func generateRelationHooks(relName string, allHooks map[string]bool) {
@@ -132,6 +138,12 @@ func (m Meta) Hooks() map[string]bool {
 	return allHooks
 }
 
+// HookTimeout returns the maximum time the named hook may run for,
+// or zero if it may run indefinitely.
+func (m Meta) HookTimeout(hookName string) time.Duration {
+	return m.HookTimeouts[hookName]
+}
+
 func parseCategories(categories interface{}) []string {
 	if categories == nil {
 		return nil
@@ -179,6 +191,9 @@ func ReadMeta(r io.Reader) (meta *Meta, err error) {
 		// Obsolete
 		meta.OldRevision = int(m["revision"].(int64))
 	}
+	if meta.HookTimeouts, err = parseHookTimeouts(m["hook-timeouts"]); err != nil {
+		return nil, errors.New("metadata: " + err.Error())
+	}
 	if err := meta.Check(); err != nil {
 		return nil, err
 	}
@@ -226,6 +241,17 @@ func (meta Meta) Check() error {
 		return err
 	}
 
+	// Timeouts may only be given for hooks the charm can have.
+	allHooks := meta.Hooks()
+	for hookName, timeout := range meta.HookTimeouts {
+		if !allHooks[hookName] {
+			return fmt.Errorf("charm %q has timeout for unknown hook %q", meta.Name, hookName)
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("charm %q has non-positive timeout %v for hook %q", meta.Name, timeout, hookName)
+		}
+	}
+
 	// Subordinate charms must have at least one relation that
 	// has container scope, otherwise they can't relate to the
 	// principal.
@@ -276,6 +302,21 @@ func parseRelations(relations interface{}, role RelationRole) map[string]Relatio
 	return result
 }
 
+func parseHookTimeouts(timeouts interface{}) (map[string]time.Duration, error) {
+	if timeouts == nil {
+		return nil, nil
+	}
+	result := make(map[string]time.Duration)
+	for hookName, timeout := range timeouts.(map[string]interface{}) {
+		d, err := time.ParseDuration(timeout.(string))
+		if err != nil {
+			return nil, fmt.Errorf("hook-timeouts.%s: invalid duration %q", hookName, timeout)
+		}
+		result[hookName] = d
+	}
+	return result, nil
+}
+
 // Schema coercer that expands the interface shorthand notation.
 // A consistent format is easier to work with than considering the
 // potential difference everywhere.
@@ -347,24 +388,26 @@ var ifaceSchema = schema.FieldMap(
 
 var charmSchema = schema.FieldMap(
 	schema.Fields{
-		"name":        schema.String(),
-		"summary":     schema.String(),
-		"description": schema.String(),
-		"peers":       schema.StringMap(ifaceExpander(int64(1))),
-		"provides":    schema.StringMap(ifaceExpander(nil)),
-		"requires":    schema.StringMap(ifaceExpander(int64(1))),
-		"revision":    schema.Int(), // Obsolete
-		"format":      schema.Int(),
-		"subordinate": schema.Bool(),
-		"categories":  schema.List(schema.String()),
+		"name":          schema.String(),
+		"summary":       schema.String(),
+		"description":   schema.String(),
+		"peers":         schema.StringMap(ifaceExpander(int64(1))),
+		"provides":      schema.StringMap(ifaceExpander(nil)),
+		"requires":      schema.StringMap(ifaceExpander(int64(1))),
+		"revision":      schema.Int(), // Obsolete
+		"format":        schema.Int(),
+		"subordinate":   schema.Bool(),
+		"categories":    schema.List(schema.String()),
+		"hook-timeouts": schema.StringMap(schema.String()),
 	},
 	schema.Defaults{
-		"provides":    schema.Omit,
-		"requires":    schema.Omit,
-		"peers":       schema.Omit,
-		"revision":    schema.Omit,
-		"format":      1,
-		"subordinate": schema.Omit,
-		"categories":  schema.Omit,
+		"provides":      schema.Omit,
+		"requires":      schema.Omit,
+		"peers":         schema.Omit,
+		"revision":      schema.Omit,
+		"format":        1,
+		"subordinate":   schema.Omit,
+		"categories":    schema.Omit,
+		"hook-timeouts": schema.Omit,
 	},
 )
@@ -5,11 +5,13 @@ package charm_test
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	gc "launchpad.net/gocheck"
 
@@ -336,6 +338,11 @@ func (s *MetaSuite) TestMetaHooks(c *gc.C) {
 		"config-changed":                    true,
 		"upgrade-charm":                     true,
 		"stop":                              true,
+		"collect-metrics":                   true,
+		"migrate-from":                      true,
+		"migrate-to":                        true,
+		"maintenance-started":               true,
+		"maintenance-finished":              true,
 		"cache-relation-joined":             true,
 		"cache-relation-changed":            true,
 		"cache-relation-departed":           true,
@@ -360,6 +367,53 @@ func (s *MetaSuite) TestMetaHooks(c *gc.C) {
 	c.Assert(hooks, gc.DeepEquals, expectedHooks)
 }
 
+const hookTimeoutsMeta = `
+name: timeouts
+summary: "A charm with hook timeouts"
+description: "This charm limits how long some hooks may run."
+requires:
+  db: mysql
+hook-timeouts:
+  %s
+`
+
+func (s *MetaSuite) TestHookTimeouts(c *gc.C) {
+	yaml := fmt.Sprintf(hookTimeoutsMeta, "{install: 10m, db-relation-changed: 30s}")
+	meta, err := charm.ReadMeta(strings.NewReader(yaml))
+	c.Assert(err, gc.IsNil)
+	c.Assert(meta.HookTimeouts, gc.DeepEquals, map[string]time.Duration{
+		"install":             10 * time.Minute,
+		"db-relation-changed": 30 * time.Second,
+	})
+	c.Assert(meta.HookTimeout("install"), gc.Equals, 10*time.Minute)
+	c.Assert(meta.HookTimeout("start"), gc.Equals, time.Duration(0))
+}
+
+var hookTimeoutErrorTests = []struct {
+	timeouts string
+	err      string
+}{{
+	timeouts: "{install: soon}",
+	err:      `metadata: hook-timeouts.install: invalid duration "soon"`,
+}, {
+	timeouts: "{install: 0s}",
+	err:      `charm "timeouts" has non-positive timeout 0 for hook "install"`,
+}, {
+	timeouts: "{cache-relation-changed: 1m}",
+	err:      `charm "timeouts" has timeout for unknown hook "cache-relation-changed"`,
+}, {
+	timeouts: "[install]",
+	err:      `metadata: hook-timeouts: expected map, got .*`,
+}}
+
+func (s *MetaSuite) TestHookTimeoutErrors(c *gc.C) {
+	for i, t := range hookTimeoutErrorTests {
+		c.Logf("test %d: %s", i, t.timeouts)
+		_, err := charm.ReadMeta(strings.NewReader(fmt.Sprintf(hookTimeoutsMeta, t.timeouts)))
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
+
 func (s *MetaSuite) TestCodecRoundTripEmpty(c *gc.C) {
 	for i, codec := range codecs {
 		c.Logf("codec %d", i)
@@ -406,6 +460,9 @@ func (s *MetaSuite) TestCodecRoundTrip(c *gc.C) {
 		Categories:  []string{"quxxxx", "quxxxxx"},
 		Format:      10,
 		OldRevision: 11,
+		HookTimeouts: map[string]time.Duration{
+			"install": 10 * time.Minute,
+		},
 	}
 	for i, codec := range codecs {
 		c.Logf("codec %d", i)
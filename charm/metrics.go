@@ -0,0 +1,87 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charm
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+
+	"launchpad.net/goyaml"
+)
+
+// MetricType is the type of a metric declared by a charm.
+type MetricType string
+
+const (
+	// MetricTypeGauge is the type of a metric whose value
+	// may go up and down, such as the number of connected users.
+	MetricTypeGauge MetricType = "gauge"
+
+	// MetricTypeAbsolute is the type of a metric whose value
+	// is a count that must not be negative, such as the number
+	// of requests served since the last collection.
+	MetricTypeAbsolute MetricType = "absolute"
+)
+
+// Metric describes a single metric declared by a charm.
+type Metric struct {
+	Type        MetricType
+	Description string
+}
+
+// Metrics represents the metrics declared in a charm's
+// metrics.yaml file.
+type Metrics struct {
+	Metrics map[string]Metric
+}
+
+var validMetricName = regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`)
+
+// ReadMetrics reads a metrics.yaml file and returns its representation.
+func ReadMetrics(r io.Reader) (*Metrics, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var metrics *Metrics
+	if err := goyaml.Unmarshal(data, &metrics); err != nil {
+		return nil, err
+	}
+	if metrics == nil || len(metrics.Metrics) == 0 {
+		return nil, fmt.Errorf("invalid metrics declaration: no metrics declared")
+	}
+	for name, metric := range metrics.Metrics {
+		if !validMetricName.MatchString(name) {
+			return nil, fmt.Errorf("invalid metrics declaration: invalid metric name %q", name)
+		}
+		switch metric.Type {
+		case MetricTypeGauge, MetricTypeAbsolute:
+		case "":
+			return nil, fmt.Errorf("invalid metrics declaration: metric %q has no type", name)
+		default:
+			return nil, fmt.Errorf("invalid metrics declaration: metric %q has unknown type %q", name, metric.Type)
+		}
+	}
+	return metrics, nil
+}
+
+// ValidateMetric returns an error if the named metric is not
+// declared, or if the value is not valid for its type.
+func (m *Metrics) ValidateMetric(name, value string) error {
+	metric, ok := m.Metrics[name]
+	if !ok {
+		return fmt.Errorf("metric %q not declared", name)
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("invalid value for metric %q: %q is not a number", name, value)
+	}
+	if metric.Type == MetricTypeAbsolute && v < 0 {
+		return fmt.Errorf("invalid value for metric %q: absolute metrics must not be negative", name)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charm_test
+
+import (
+	"strings"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/testing"
+	jc "launchpad.net/juju-core/testing/checkers"
+)
+
+type MetricsSuite struct{}
+
+var _ = gc.Suite(&MetricsSuite{})
+
+func (s *MetricsSuite) TestReadMetrics(c *gc.C) {
+	metrics, err := charm.ReadMetrics(strings.NewReader(`
+metrics:
+  users:
+    type: gauge
+    description: Connected users.
+  bytes-served:
+    type: absolute
+`))
+	c.Assert(err, gc.IsNil)
+	c.Assert(metrics, jc.DeepEquals, &charm.Metrics{
+		Metrics: map[string]charm.Metric{
+			"users":        {Type: charm.MetricTypeGauge, Description: "Connected users."},
+			"bytes-served": {Type: charm.MetricTypeAbsolute},
+		},
+	})
+}
+
+var readMetricsErrorTests = []struct {
+	yaml string
+	err  string
+}{{
+	yaml: "",
+	err:  "invalid metrics declaration: no metrics declared",
+}, {
+	yaml: "metrics:\n",
+	err:  "invalid metrics declaration: no metrics declared",
+}, {
+	yaml: "metrics:\n  Users:\n    type: gauge\n",
+	err:  `invalid metrics declaration: invalid metric name "Users"`,
+}, {
+	yaml: "metrics:\n  users:\n    description: Users.\n",
+	err:  `invalid metrics declaration: metric "users" has no type`,
+}, {
+	yaml: "metrics:\n  users:\n    type: counter\n",
+	err:  `invalid metrics declaration: metric "users" has unknown type "counter"`,
+}}
+
+func (s *MetricsSuite) TestReadMetricsErrors(c *gc.C) {
+	for i, test := range readMetricsErrorTests {
+		c.Logf("test %d: %q", i, test.yaml)
+		_, err := charm.ReadMetrics(strings.NewReader(test.yaml))
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+var validateMetricTests = []struct {
+	name  string
+	value string
+	err   string
+}{{
+	name:  "users",
+	value: "-1.5",
+}, {
+	name:  "requests",
+	value: "42",
+}, {
+	name:  "requests",
+	value: "-1",
+	err:   `invalid value for metric "requests": absolute metrics must not be negative`,
+}, {
+	name:  "users",
+	value: "many",
+	err:   `invalid value for metric "users": "many" is not a number`,
+}, {
+	name:  "unknown",
+	value: "1",
+	err:   `metric "unknown" not declared`,
+}}
+
+func (s *MetricsSuite) TestValidateMetric(c *gc.C) {
+	metrics := testing.Charms.Dir("metered").Metrics()
+	c.Assert(metrics, gc.NotNil)
+	for i, test := range validateMetricTests {
+		c.Logf("test %d: %s=%s", i, test.name, test.value)
+		err := metrics.ValidateMetric(test.name, test.value)
+		if test.err == "" {
+			c.Check(err, gc.IsNil)
+		} else {
+			c.Check(err, gc.ErrorMatches, test.err)
+		}
+	}
+}
+
+func (s *MetricsSuite) TestReadCharmMetrics(c *gc.C) {
+	dir := testing.Charms.Dir("metered")
+	c.Assert(dir.Metrics().Metrics, gc.HasLen, 2)
+	bundle := testing.Charms.Bundle(c.MkDir(), "metered")
+	c.Assert(bundle.Metrics(), jc.DeepEquals, dir.Metrics())
+
+	// Declaring metrics is optional.
+	c.Assert(testing.Charms.Dir("dummy").Metrics(), gc.IsNil)
+	c.Assert(testing.Charms.Bundle(c.MkDir(), "dummy").Metrics(), gc.IsNil)
+}
@@ -25,6 +25,9 @@ type ConfigureParams struct {
 	// If Client is nil, ssh.DefaultClient will be used.
 	Client ssh.Client
 
+	// Options holds the SSH options to connect with, and may be nil.
+	Options *ssh.Options
+
 	// Config is the cloudinit config to carry out.
 	Config *cloudinit.Config
 
@@ -53,7 +56,7 @@ func RunConfigureScript(script string, params ConfigureParams) error {
 	if client == nil {
 		client = ssh.DefaultClient
 	}
-	cmd := ssh.Command(params.Host, []string{"sudo", "/bin/bash"}, nil)
+	cmd := client.Command(params.Host, []string{"sudo", "/bin/bash"}, params.Options)
 	cmd.Stdin = strings.NewReader(script)
 	cmd.Stderr = params.ProgressWriter
 	return cmd.Run()
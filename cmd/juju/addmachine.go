@@ -43,12 +43,42 @@ This command also supports manual provisioning of existing machines via SSH. The
 target machine must be able to communicate with the API server, and be able to
 access the environment storage.
 
+Many existing machines may be provisioned at once by describing them in an
+inventory file, specified with --inventory. The inventory is a YAML file
+listing the hosts, with optional login user, SSH private key and series
+override for each, and defaults for all of them:
+
+   defaults:
+     user: admin
+     ssh-key: ~/.ssh/fleet_rsa
+   hosts:
+     - host: 10.0.0.1
+     - host: db1.example.com
+       user: root
+       series: trusty
+
+The series given with --series applies to hosts that have no series in the
+inventory. Constraints cannot be combined with --inventory, as the hosts
+already exist. Up to --parallel hosts are provisioned at once, and the result
+for each host is reported. Hosts must not require a password for sudo. Hosts that already
+run a juju machine agent are skipped.
+
+With --series, the series detected on a manually provisioned host is
+overridden. Windows hosts are provisioned via WinRM rather than SSH, so
+their series must be specified with --series; the connection is
+authenticated with the client certificate in $JUJU_HOME/winrm, as for
+"juju ssh".
+
 Examples:
    juju add-machine                      (starts a new machine)
    juju add-machine lxc                  (starts a new machine with an lxc container)
    juju add-machine lxc:4                (starts a new lxc container on machine 4)
    juju add-machine --constraints mem=8G (starts a machine with at least 8GB RAM)
    juju add-machine ssh:user@10.10.0.3   (manually provisions a machine with ssh)
+   juju add-machine --series win2012r2 ssh:10.10.0.4
+                                         (manually provisions a Windows machine with WinRM)
+   juju add-machine --inventory hosts.yaml
+                                         (manually provisions the machines in hosts.yaml)
 
 See Also:
    juju help constraints
@@ -64,12 +94,15 @@ type AddMachineCommand struct {
 	MachineId     string
 	ContainerType instance.ContainerType
 	SSHHost       string
+	// If specified, manually provision the hosts listed in this inventory file.
+	Inventory   string
+	MaxParallel int
 }
 
 func (c *AddMachineCommand) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:    "add-machine",
-		Args:    "[<container>:machine | <container> | ssh:[user@]host]",
+		Args:    "[<container>:machine | <container> | ssh:[user@]host | --inventory <file>]",
 		Purpose: "start a new, empty machine and optionally a container, or add a container to a machine",
 		Doc:     addMachineDoc,
 	}
@@ -79,6 +112,8 @@ func (c *AddMachineCommand) SetFlags(f *gnuflag.FlagSet) {
 	c.EnvCommandBase.SetFlags(f)
 	f.StringVar(&c.Series, "series", "", "the charm series")
 	f.Var(constraints.ConstraintsValue{&c.Constraints}, "constraints", "additional machine constraints")
+	f.StringVar(&c.Inventory, "inventory", "", "path to an inventory of existing hosts to provision manually")
+	f.IntVar(&c.MaxParallel, "parallel", manual.DefaultMaxParallel, "the maximum number of hosts to provision at once")
 }
 
 func (c *AddMachineCommand) Init(args []string) error {
@@ -89,6 +124,20 @@ func (c *AddMachineCommand) Init(args []string) error {
 	if err != nil {
 		return err
 	}
+	if c.MaxParallel < 1 {
+		return fmt.Errorf("--parallel must be at least 1")
+	}
+	if c.Series != "" {
+		if err := checkSeries(c.Series); err != nil {
+			return err
+		}
+	}
+	if c.Inventory != "" && containerSpec != "" {
+		return fmt.Errorf("--inventory cannot be combined with a container or ssh host")
+	}
+	if c.Inventory != "" && c.Constraints.String() != "" {
+		return fmt.Errorf("--inventory cannot be combined with --constraints")
+	}
 	if containerSpec == "" {
 		return nil
 	}
@@ -148,16 +197,60 @@ func (c *AddMachineCommand) addMachine1dot16() (string, error) {
 	return m.String(), err
 }
 
+// provisionMachine is called to provision an ssh host,
+// and provisionMachines to provision the hosts in an
+// inventory; they may be replaced in tests.
+var (
+	provisionMachine  = manual.ProvisionMachine
+	provisionMachines = manual.ProvisionMachines
+)
+
+// provisionInventory manually provisions the hosts listed in
+// the inventory file, and reports the result for each host.
+func (c *AddMachineCommand) provisionInventory(ctx *cmd.Context) error {
+	inventory, err := manual.ReadInventory(ctx.AbsPath(c.Inventory))
+	if err != nil {
+		return err
+	}
+	args, err := inventory.ProvisionMachineArgs(manual.ProvisionMachineArgs{
+		EnvName: c.EnvName,
+		Series:  c.Series,
+	})
+	if err != nil {
+		return err
+	}
+	var failed bool
+	for _, result := range provisionMachines(args, c.MaxParallel, ctx.Stderr) {
+		switch result.Error {
+		case nil:
+			fmt.Fprintf(ctx.Stdout, "%s: created machine %s\n", result.Host, result.MachineId)
+		case manual.ErrProvisioned:
+			fmt.Fprintf(ctx.Stdout, "%s: skipped, %v\n", result.Host, result.Error)
+		default:
+			fmt.Fprintf(ctx.Stderr, "cannot provision %s: %v\n", result.Host, result.Error)
+			failed = true
+		}
+	}
+	if failed {
+		return cmd.ErrSilent
+	}
+	return nil
+}
+
 func (c *AddMachineCommand) Run(ctx *cmd.Context) error {
+	if c.Inventory != "" {
+		return c.provisionInventory(ctx)
+	}
 	if c.SSHHost != "" {
 		args := manual.ProvisionMachineArgs{
 			Host:    c.SSHHost,
 			EnvName: c.EnvName,
+			Series:  c.Series,
 			Stdin:   ctx.Stdin,
 			Stdout:  ctx.Stdout,
 			Stderr:  ctx.Stderr,
 		}
-		_, err := manual.ProvisionMachine(args)
+		_, err := provisionMachine(args)
 		return err
 	}
 
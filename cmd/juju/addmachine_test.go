@@ -4,12 +4,18 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
 	"strconv"
 
 	gc "launchpad.net/gocheck"
 
+	"launchpad.net/juju-core/cmd"
 	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/environs/manual"
 	"launchpad.net/juju-core/instance"
 	jujutesting "launchpad.net/juju-core/juju/testing"
 	"launchpad.net/juju-core/state"
@@ -116,4 +122,65 @@ func (s *AddMachineSuite) TestAddMachineErrors(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, `malformed container argument "foo"`)
 	err = runAddMachine(c, "lxc", "--constraints", "container=lxc")
 	c.Assert(err, gc.ErrorMatches, `container constraint "lxc" not allowed when adding a machine`)
+	err = runAddMachine(c, "--inventory", "hosts.yaml", "ssh:10.0.0.1")
+	c.Assert(err, gc.ErrorMatches, `--inventory cannot be combined with a container or ssh host`)
+	err = runAddMachine(c, "--inventory", "hosts.yaml", "--constraints", "mem=8G")
+	c.Assert(err, gc.ErrorMatches, `--inventory cannot be combined with --constraints`)
+	err = runAddMachine(c, "--inventory", "hosts.yaml", "--parallel", "0")
+	c.Assert(err, gc.ErrorMatches, `--parallel must be at least 1`)
+	err = runAddMachine(c, "--series", "bad1")
+	c.Assert(err, gc.ErrorMatches, `invalid series name "bad1"`)
+	err = runAddMachine(c, "--series", "win2000")
+	c.Assert(err, gc.ErrorMatches, `unknown Windows series "win2000" \(expected one of .*\)`)
+}
+
+func (s *AddMachineSuite) TestAddMachineSSHSeries(c *gc.C) {
+	var provisioned []manual.ProvisionMachineArgs
+	s.PatchValue(&provisionMachine, func(args manual.ProvisionMachineArgs) (string, error) {
+		provisioned = append(provisioned, args)
+		return "0", nil
+	})
+	err := runAddMachine(c, "ssh:10.0.0.1")
+	c.Assert(err, gc.IsNil)
+	err = runAddMachine(c, "--series", "win2012r2", "ssh:admin@10.0.0.2")
+	c.Assert(err, gc.IsNil)
+	c.Assert(provisioned, gc.HasLen, 2)
+	c.Assert(provisioned[0].Host, gc.Equals, "10.0.0.1")
+	c.Assert(provisioned[0].Series, gc.Equals, "")
+	c.Assert(provisioned[1].Host, gc.Equals, "admin@10.0.0.2")
+	c.Assert(provisioned[1].Series, gc.Equals, "win2012r2")
+}
+
+func (s *AddMachineSuite) TestAddMachineInventory(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "hosts.yaml")
+	err := ioutil.WriteFile(path, []byte(`
+defaults:
+  user: admin
+hosts:
+  - host: 10.0.0.1
+  - host: 10.0.0.2
+    series: trusty
+  - host: root@10.0.0.3
+`), 0644)
+	c.Assert(err, gc.IsNil)
+	s.PatchValue(&provisionMachines, func(args []manual.ProvisionMachineArgs, maxParallel int, progress io.Writer) []manual.ProvisionResult {
+		c.Check(maxParallel, gc.Equals, 3)
+		c.Assert(args, gc.HasLen, 3)
+		c.Check(args[0].Host, gc.Equals, "admin@10.0.0.1")
+		c.Check(args[0].Series, gc.Equals, "precise")
+		c.Check(args[1].Series, gc.Equals, "trusty")
+		c.Check(args[2].Host, gc.Equals, "root@10.0.0.3")
+		c.Check(args[2].Series, gc.Equals, "precise")
+		return []manual.ProvisionResult{
+			{Host: args[0].Host, MachineId: "0"},
+			{Host: args[1].Host, Error: manual.ErrProvisioned},
+			{Host: args[2].Host, Error: errors.New("no route to host")},
+		}
+	})
+	ctx, err := testing.RunCommand(c, &AddMachineCommand{}, []string{"--inventory", path, "--parallel", "3", "--series", "precise"})
+	c.Assert(err, gc.Equals, cmd.ErrSilent)
+	c.Assert(testing.Stdout(ctx), gc.Equals, ""+
+		"admin@10.0.0.1: created machine 0\n"+
+		"admin@10.0.0.2: skipped, machine is already provisioned\n")
+	c.Assert(testing.Stderr(ctx), gc.Equals, "cannot provision root@10.0.0.3: no route to host\n")
 }
@@ -5,39 +5,81 @@ package main
 
 import (
 	"fmt"
+	"strings"
 
 	"launchpad.net/juju-core/cmd"
 	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/state/api/params"
 )
 
-// AddRelationCommand adds a relation between two service endpoints.
+const addRelationDoc = `
+Relations are given as pairs of endpoints; several relations may be
+added at once by giving several pairs, as in
+
+    juju add-relation wordpress mysql wordpress:cache memcached
+`
+
+// AddRelationCommand adds relations between pairs of service endpoints.
 type AddRelationCommand struct {
 	cmd.EnvCommandBase
-	Endpoints []string
+	Relations [][]string
 }
 
 func (c *AddRelationCommand) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:    "add-relation",
-		Args:    "<service1>[:<relation name1>] <service2>[:<relation name2>]",
+		Args:    "<service1>[:<relation name1>] <service2>[:<relation name2>] ...",
 		Purpose: "add a relation between two services",
+		Doc:     addRelationDoc,
 	}
 }
 
 func (c *AddRelationCommand) Init(args []string) error {
-	if len(args) != 2 {
+	if len(args) == 0 || len(args)%2 != 0 {
 		return fmt.Errorf("a relation must involve two services")
 	}
-	c.Endpoints = args
+	c.Relations = nil
+	for i := 0; i < len(args); i += 2 {
+		c.Relations = append(c.Relations, args[i:i+2])
+	}
 	return nil
 }
 
-func (c *AddRelationCommand) Run(_ *cmd.Context) error {
+func (c *AddRelationCommand) Run(ctx *cmd.Context) error {
 	client, err := juju.NewAPIClientFromName(c.EnvName)
 	if err != nil {
 		return err
 	}
 	defer client.Close()
-	_, err = client.AddRelation(c.Endpoints...)
-	return err
+	if len(c.Relations) == 1 {
+		_, err = client.AddRelation(c.Relations[0]...)
+		return err
+	}
+	relations := make([]params.AddRelation, len(c.Relations))
+	for i, endpoints := range c.Relations {
+		relations[i].Endpoints = endpoints
+	}
+	results, err := client.AddRelations(relations)
+	if params.IsCodeNotImplemented(err) {
+		logger.Infof("AddRelations not supported by the API server, adding relations one at a time")
+		results = make([]params.AddRelationsResult, len(c.Relations))
+		for i, endpoints := range c.Relations {
+			if _, err := client.AddRelation(endpoints...); err != nil {
+				results[i].Error = &params.Error{Message: err.Error()}
+			}
+		}
+	} else if err != nil {
+		return err
+	}
+	failed := false
+	for i, result := range results {
+		if result.Error != nil {
+			fmt.Fprintf(ctx.Stderr, "cannot add relation %s: %v\n", strings.Join(c.Relations[i], " "), result.Error)
+			failed = true
+		}
+	}
+	if failed {
+		return cmd.ErrSilent
+	}
+	return nil
 }
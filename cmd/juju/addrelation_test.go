@@ -6,6 +6,7 @@ package main
 import (
 	gc "launchpad.net/gocheck"
 
+	"launchpad.net/juju-core/cmd"
 	jujutesting "launchpad.net/juju-core/juju/testing"
 	"launchpad.net/juju-core/testing"
 )
@@ -149,3 +150,35 @@ func (s *AddRelationSuite) TestAddRelation(c *gc.C) {
 		}
 	}
 }
+
+func (s *AddRelationSuite) TestAddRelations(c *gc.C) {
+	testing.Charms.BundlePath(s.SeriesPath, "wordpress")
+	err := runDeploy(c, "local:wordpress", "wp")
+	c.Assert(err, gc.IsNil)
+	testing.Charms.BundlePath(s.SeriesPath, "mysql")
+	err = runDeploy(c, "local:mysql", "ms")
+	c.Assert(err, gc.IsNil)
+	testing.Charms.BundlePath(s.SeriesPath, "logging")
+	err = runDeploy(c, "local:logging", "lg")
+	c.Assert(err, gc.IsNil)
+
+	ctx, err := testing.RunCommand(c, &AddRelationCommand{}, []string{"ms", "wp", "wp", "lg"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stderr(ctx), gc.Equals, "")
+	wp, err := s.State.Service("wp")
+	c.Assert(err, gc.IsNil)
+	rels, err := wp.Relations()
+	c.Assert(err, gc.IsNil)
+	c.Assert(rels, gc.HasLen, 2)
+
+	// Relations that cannot be added are reported, without stopping
+	// the others from being added.
+	ctx, err = testing.RunCommand(c, &AddRelationCommand{}, []string{"ms", "wp", "ms", "lg"})
+	c.Assert(err, gc.Equals, cmd.ErrSilent)
+	c.Assert(testing.Stderr(ctx), gc.Equals, "cannot add relation ms wp: "+msWpAlreadyExists+"\n")
+	ms, err := s.State.Service("ms")
+	c.Assert(err, gc.IsNil)
+	rels, err = ms.Relations()
+	c.Assert(err, gc.IsNil)
+	c.Assert(rels, gc.HasLen, 2)
+}
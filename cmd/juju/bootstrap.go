@@ -164,14 +164,27 @@ type seriesVar struct {
 func (v seriesVar) Set(value string) error {
 	names := strings.Split(value, ",")
 	for _, name := range names {
-		if !charm.IsValidSeries(name) {
-			return fmt.Errorf("invalid series name %q", name)
+		if err := checkSeries(name); err != nil {
+			return err
 		}
 	}
 	*v.target = names
 	return nil
 }
 
+// checkSeries returns an error if name is not a valid series name,
+// or if it looks like a Windows series but is not a known one.
+func checkSeries(name string) error {
+	if !charm.IsValidSeries(name) {
+		return fmt.Errorf("invalid series name %q", name)
+	}
+	if strings.HasPrefix(name, "win") && !version.IsWindowsSeries(name) {
+		return fmt.Errorf("unknown Windows series %q (expected one of %s)",
+			name, strings.Join(version.WindowsSeries(), ", "))
+	}
+	return nil
+}
+
 func (v seriesVar) String() string {
 	return strings.Join(*v.target, ",")
 }
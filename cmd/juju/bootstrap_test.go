@@ -255,6 +255,10 @@ var bootstrapTests = []bootstrapTest{{
 	info: "bad --series",
 	args: []string{"--series", "bad1"},
 	err:  `invalid value "bad1" for flag --series: invalid series name "bad1"`,
+}, {
+	info: "unknown Windows --series",
+	args: []string{"--series", "precise,win2000"},
+	err:  `invalid value "precise,win2000" for flag --series: unknown Windows series "win2000" \(expected one of .*\)`,
 }, {
 	info: "lonely --series",
 	args: []string{"--series", "fine"},
@@ -0,0 +1,213 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/names"
+)
+
+// ConfigHistoryCommand shows the recorded revisions of a
+// service's configuration.
+type ConfigHistoryCommand struct {
+	cmd.EnvCommandBase
+	ServiceName string
+	From, To    int
+	out         cmd.Output
+}
+
+const configHistoryDoc = `
+Every change to a service's configuration, from the settings it was
+deployed with to those made with set, unset, upgrade-charm or
+config-rollback, is recorded as a numbered revision, along with the
+user that made it and when.
+
+With no revision arguments, config-history lists the recorded revisions
+of the service's configuration. Given a single revision, it shows the
+changes made by that revision; given two, it shows the differences
+between them.
+
+Values of secret options are not shown, although changes to them are.
+
+Examples:
+  juju config-history wordpress
+  juju config-history wordpress 3
+  juju config-history wordpress 2 5
+
+See Also:
+  juju help config-rollback
+`
+
+func (c *ConfigHistoryCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "config-history",
+		Args:    "<service> [<revision> [<revision>]]",
+		Purpose: "show the history of a service's configuration",
+		Doc:     configHistoryDoc,
+	}
+}
+
+func (c *ConfigHistoryCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
+		"yaml": cmd.FormatYaml,
+		"json": cmd.FormatJson,
+	})
+}
+
+func (c *ConfigHistoryCommand) Init(args []string) (err error) {
+	if len(args) == 0 {
+		return errors.New("no service name specified")
+	}
+	if !names.IsService(args[0]) {
+		return fmt.Errorf("invalid service name %q", args[0])
+	}
+	c.ServiceName, args = args[0], args[1:]
+	if len(args) > 0 {
+		if c.To, err = parseRevision(args[0]); err != nil {
+			return err
+		}
+		args = args[1:]
+	}
+	if len(args) > 0 {
+		c.From = c.To
+		if c.To, err = parseRevision(args[0]); err != nil {
+			return err
+		}
+		args = args[1:]
+	}
+	return cmd.CheckEmpty(args)
+}
+
+// parseRevision returns the settings revision number held in s.
+func parseRevision(s string) (int, error) {
+	revision, err := strconv.Atoi(s)
+	if err != nil || revision < 1 {
+		return 0, fmt.Errorf("invalid revision %q", s)
+	}
+	return revision, nil
+}
+
+// Run fetches the configuration history of the service and formats
+// the requested revisions or differences.
+func (c *ConfigHistoryCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if c.To == 0 {
+		revisions, err := client.ServiceConfigHistory(c.ServiceName)
+		if err != nil {
+			return err
+		}
+		result := make([]map[string]interface{}, len(revisions))
+		for i, rev := range revisions {
+			result[i] = map[string]interface{}{
+				"revision": rev.Revision,
+				"charm":    rev.CharmURL,
+				"author":   rev.Author,
+				"time":     rev.Time.Format(time.RFC3339),
+				"settings": rev.Settings,
+			}
+		}
+		return c.out.Write(ctx, map[string]interface{}{
+			"service":   c.ServiceName,
+			"revisions": result,
+		})
+	}
+	diff, err := client.ServiceConfigDiff(c.ServiceName, c.From, c.To)
+	if err != nil {
+		return err
+	}
+	changes := make(map[string]interface{})
+	for name, change := range diff.Changes {
+		description := make(map[string]interface{})
+		if change.Old != nil {
+			description["old"] = change.Old
+		}
+		if change.New != nil {
+			description["new"] = change.New
+		}
+		changes[name] = description
+	}
+	return c.out.Write(ctx, map[string]interface{}{
+		"service": c.ServiceName,
+		"from":    diff.From,
+		"to":      c.To,
+		"changes": changes,
+	})
+}
+
+// ConfigRollbackCommand restores a service's configuration to an
+// earlier revision.
+type ConfigRollbackCommand struct {
+	cmd.EnvCommandBase
+	ServiceName string
+	Revision    int
+}
+
+const configRollbackDoc = `
+Restore the configuration of a service to that recorded in an earlier
+revision, as shown by config-history. Options set in that revision are
+set to their recorded values, and all other options are unset. The
+rollback is applied like any other configuration change: the service's
+units run their config-changed hooks, and the rollback is itself
+recorded as a new revision.
+
+Example:
+  juju config-rollback wordpress 3
+
+See Also:
+  juju help config-history
+`
+
+func (c *ConfigRollbackCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "config-rollback",
+		Args:    "<service> <revision>",
+		Purpose: "restore a service's configuration to an earlier revision",
+		Doc:     configRollbackDoc,
+	}
+}
+
+func (c *ConfigRollbackCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+}
+
+func (c *ConfigRollbackCommand) Init(args []string) (err error) {
+	switch len(args) {
+	case 0:
+		return errors.New("no service name specified")
+	case 1:
+		return errors.New("no revision specified")
+	}
+	if !names.IsService(args[0]) {
+		return fmt.Errorf("invalid service name %q", args[0])
+	}
+	c.ServiceName = args[0]
+	if c.Revision, err = parseRevision(args[1]); err != nil {
+		return err
+	}
+	return cmd.CheckEmpty(args[2:])
+}
+
+// Run restores the service's configuration.
+func (c *ConfigRollbackCommand) Run(_ *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.ServiceConfigRollback(c.ServiceName, c.Revision)
+}
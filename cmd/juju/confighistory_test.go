@@ -0,0 +1,148 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	gc "launchpad.net/gocheck"
+	"launchpad.net/goyaml"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	coretesting "launchpad.net/juju-core/testing"
+)
+
+type ConfigHistorySuite struct {
+	testing.JujuConnSuite
+	svc *state.Service
+}
+
+var _ = gc.Suite(&ConfigHistorySuite{})
+
+func (s *ConfigHistorySuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.svc = s.AddTestingService(c, "dummy-service", s.AddTestingCharm(c, "dummy"))
+	err := s.svc.UpdateConfigSettingsBy("user-admin", charm.Settings{"title": "one", "username": "bob"})
+	c.Assert(err, gc.IsNil)
+	err = s.svc.UpdateConfigSettingsBy("user-admin", charm.Settings{"title": "two", "username": nil})
+	c.Assert(err, gc.IsNil)
+}
+
+var configHistoryInitErrorTests = []struct {
+	args []string
+	err  string
+}{{
+	args: nil,
+	err:  "no service name specified",
+}, {
+	args: []string{"dummy/0"},
+	err:  `invalid service name "dummy/0"`,
+}, {
+	args: []string{"dummy-service", "zero"},
+	err:  `invalid revision "zero"`,
+}, {
+	args: []string{"dummy-service", "1", "0"},
+	err:  `invalid revision "0"`,
+}, {
+	args: []string{"dummy-service", "1", "2", "3"},
+	err:  `unrecognized args: \["3"\]`,
+}}
+
+func (s *ConfigHistorySuite) TestInitErrors(c *gc.C) {
+	for i, t := range configHistoryInitErrorTests {
+		c.Logf("test %d: %v", i, t.args)
+		err := coretesting.InitCommand(&ConfigHistoryCommand{}, t.args)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *ConfigHistorySuite) runHistory(c *gc.C, args ...string) map[string]interface{} {
+	ctx, err := coretesting.RunCommand(c, &ConfigHistoryCommand{}, append([]string{"dummy-service"}, args...))
+	c.Assert(err, gc.IsNil)
+	result := make(map[string]interface{})
+	err = goyaml.Unmarshal([]byte(coretesting.Stdout(ctx)), &result)
+	c.Assert(err, gc.IsNil)
+	return result
+}
+
+func (s *ConfigHistorySuite) TestList(c *gc.C) {
+	result := s.runHistory(c)
+	c.Assert(result["service"], gc.Equals, "dummy-service")
+	revisions := result["revisions"].([]interface{})
+	c.Assert(revisions, gc.HasLen, 3)
+	for i, r := range revisions {
+		rev := r.(map[interface{}]interface{})
+		c.Check(rev["revision"], gc.Equals, i+1)
+		c.Check(rev["author"], gc.Equals, "user-admin")
+		c.Check(rev["charm"], gc.Equals, "local:quantal/dummy-1")
+		c.Check(rev["time"], gc.Not(gc.Equals), "")
+	}
+	c.Check(revisions[0].(map[interface{}]interface{})["settings"], gc.DeepEquals, map[interface{}]interface{}{})
+	c.Check(revisions[2].(map[interface{}]interface{})["settings"], gc.DeepEquals, map[interface{}]interface{}{
+		"title": "two",
+	})
+}
+
+func (s *ConfigHistorySuite) TestDiffPrevious(c *gc.C) {
+	result := s.runHistory(c, "3")
+	c.Assert(result, gc.DeepEquals, map[string]interface{}{
+		"service": "dummy-service",
+		"from":    2,
+		"to":      3,
+		"changes": map[interface{}]interface{}{
+			"title":    map[interface{}]interface{}{"old": "one", "new": "two"},
+			"username": map[interface{}]interface{}{"old": "bob"},
+		},
+	})
+}
+
+func (s *ConfigHistorySuite) TestDiffFromDeploy(c *gc.C) {
+	result := s.runHistory(c, "2")
+	c.Assert(result["from"], gc.Equals, 1)
+	c.Assert(result["changes"], gc.DeepEquals, map[interface{}]interface{}{
+		"title":    map[interface{}]interface{}{"new": "one"},
+		"username": map[interface{}]interface{}{"new": "bob"},
+	})
+}
+
+func (s *ConfigHistorySuite) TestDiffRevisions(c *gc.C) {
+	result := s.runHistory(c, "1", "3")
+	c.Assert(result["from"], gc.Equals, 1)
+	c.Assert(result["changes"], gc.DeepEquals, map[interface{}]interface{}{
+		"title": map[interface{}]interface{}{"new": "two"},
+	})
+}
+
+func (s *ConfigHistorySuite) TestDiffUnknownRevision(c *gc.C) {
+	_, err := coretesting.RunCommand(c, &ConfigHistoryCommand{}, []string{"dummy-service", "1", "7"})
+	c.Assert(err, gc.ErrorMatches, `settings revision 7 for service "dummy-service" not found`)
+}
+
+func (s *ConfigHistorySuite) TestRollback(c *gc.C) {
+	_, err := coretesting.RunCommand(c, &ConfigRollbackCommand{}, []string{"dummy-service", "2"})
+	c.Assert(err, gc.IsNil)
+	settings, err := s.svc.ConfigSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, gc.DeepEquals, charm.Settings{"title": "one", "username": "bob"})
+
+	revisions, err := s.svc.ConfigHistory()
+	c.Assert(err, gc.IsNil)
+	c.Assert(revisions, gc.HasLen, 4)
+	c.Assert(revisions[3].Author, gc.Equals, "user-admin")
+	c.Assert(revisions[3].Settings, gc.DeepEquals, settings)
+
+	// Rolling back to the deployed settings unsets everything.
+	_, err = coretesting.RunCommand(c, &ConfigRollbackCommand{}, []string{"dummy-service", "1"})
+	c.Assert(err, gc.IsNil)
+	settings, err = s.svc.ConfigSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, gc.DeepEquals, charm.Settings{})
+}
+
+func (s *ConfigHistorySuite) TestRollbackErrors(c *gc.C) {
+	err := coretesting.InitCommand(&ConfigRollbackCommand{}, []string{"dummy-service"})
+	c.Assert(err, gc.ErrorMatches, "no revision specified")
+	_, err = coretesting.RunCommand(c, &ConfigRollbackCommand{}, []string{"dummy-service", "9"})
+	c.Assert(err, gc.ErrorMatches, `settings revision 9 for service "dummy-service" not found`)
+}
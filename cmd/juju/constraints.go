@@ -5,6 +5,7 @@ package main
 
 import (
 	"fmt"
+	"strings"
 
 	"launchpad.net/gnuflag"
 
@@ -40,6 +41,7 @@ Examples:
 
    set-constraints mem=8G                         (all new machines in the environment must have at least 8GB of RAM)
    set-constraints --service wordpress mem=4G     (all new wordpress machines can ignore the 8G constraint above, and require only 4G)
+   set-constraints -s wordpress,mysql mem=4G      (set the same constraints on several services at once)
 
 See Also:
    juju help constraints
@@ -130,8 +132,9 @@ func (c *GetConstraintsCommand) Run(ctx *cmd.Context) error {
 // SetConstraintsCommand shows the constraints for a service or environment.
 type SetConstraintsCommand struct {
 	cmd.EnvCommandBase
-	ServiceName string
-	Constraints constraints.Value
+	ServiceName  string
+	ServiceNames []string
+	Constraints  constraints.Value
 }
 
 func (c *SetConstraintsCommand) Info() *cmd.Info {
@@ -145,13 +148,19 @@ func (c *SetConstraintsCommand) Info() *cmd.Info {
 
 func (c *SetConstraintsCommand) SetFlags(f *gnuflag.FlagSet) {
 	c.EnvCommandBase.SetFlags(f)
-	f.StringVar(&c.ServiceName, "s", "", "set service constraints")
+	f.StringVar(&c.ServiceName, "s", "", "set service constraints (a comma-separated list sets them on several services)")
 	f.StringVar(&c.ServiceName, "service", "", "")
 }
 
 func (c *SetConstraintsCommand) Init(args []string) (err error) {
-	if c.ServiceName != "" && !names.IsService(c.ServiceName) {
-		return fmt.Errorf("invalid service name %q", c.ServiceName)
+	c.ServiceNames = nil
+	if c.ServiceName != "" {
+		for _, name := range strings.Split(c.ServiceName, ",") {
+			if !names.IsService(name) {
+				return fmt.Errorf("invalid service name %q", name)
+			}
+			c.ServiceNames = append(c.ServiceNames, name)
+		}
 	}
 	c.Constraints, err = constraints.Parse(args...)
 	return err
@@ -172,13 +181,13 @@ func (c *SetConstraintsCommand) setEnvironConstraints1dot16() error {
 	return conn.State.SetEnvironConstraints(c.Constraints)
 }
 
-func (c *SetConstraintsCommand) Run(_ *cmd.Context) (err error) {
+func (c *SetConstraintsCommand) Run(ctx *cmd.Context) (err error) {
 	apiclient, err := juju.NewAPIClientFromName(c.EnvName)
 	if err != nil {
 		return err
 	}
 	defer apiclient.Close()
-	if c.ServiceName == "" {
+	if len(c.ServiceNames) == 0 {
 		err = apiclient.SetEnvironmentConstraints(c.Constraints)
 		if params.IsCodeNotImplemented(err) {
 			logger.Infof("SetEnvironmentConstraints not supported by the API server, " +
@@ -187,5 +196,34 @@ func (c *SetConstraintsCommand) Run(_ *cmd.Context) (err error) {
 		}
 		return err
 	}
-	return apiclient.SetServiceConstraints(c.ServiceName, c.Constraints)
+	if len(c.ServiceNames) == 1 {
+		return apiclient.SetServiceConstraints(c.ServiceNames[0], c.Constraints)
+	}
+	args := make([]params.SetConstraints, len(c.ServiceNames))
+	for i, name := range c.ServiceNames {
+		args[i] = params.SetConstraints{ServiceName: name, Constraints: c.Constraints}
+	}
+	results, err := apiclient.SetServicesConstraints(args)
+	if params.IsCodeNotImplemented(err) {
+		logger.Infof("SetServicesConstraints not supported by the API server, setting constraints one service at a time")
+		results = make([]params.ErrorResult, len(c.ServiceNames))
+		for i, name := range c.ServiceNames {
+			if err := apiclient.SetServiceConstraints(name, c.Constraints); err != nil {
+				results[i].Error = &params.Error{Message: err.Error()}
+			}
+		}
+	} else if err != nil {
+		return err
+	}
+	failed := false
+	for i, result := range results {
+		if result.Error != nil {
+			fmt.Fprintf(ctx.Stderr, "cannot set constraints for service %q: %v\n", c.ServiceNames[i], result.Error)
+			failed = true
+		}
+	}
+	if failed {
+		return cmd.ErrSilent
+	}
+	return nil
 }
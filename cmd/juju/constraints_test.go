@@ -11,6 +11,7 @@ import (
 	"launchpad.net/juju-core/cmd"
 	"launchpad.net/juju-core/constraints"
 	"launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
 	coretesting "launchpad.net/juju-core/testing"
 	jc "launchpad.net/juju-core/testing/checkers"
 )
@@ -77,6 +78,29 @@ func (s *ConstraintsCommandsSuite) TestSetService(c *gc.C) {
 	c.Assert(&cons, jc.Satisfies, constraints.IsEmpty)
 }
 
+func (s *ConstraintsCommandsSuite) TestSetServices(c *gc.C) {
+	svc1 := s.AddTestingService(c, "svc1", s.AddTestingCharm(c, "dummy"))
+	svc2 := s.AddTestingService(c, "svc2", s.AddTestingCharm(c, "dummy"))
+
+	assertSet(c, "-s", "svc1,svc2", "mem=4G")
+	for _, svc := range []*state.Service{svc1, svc2} {
+		cons, err := svc.Constraints()
+		c.Assert(err, gc.IsNil)
+		c.Assert(cons, gc.DeepEquals, constraints.Value{Mem: uint64p(4096)})
+	}
+
+	// Services that cannot be updated are reported, without
+	// stopping the others from being updated.
+	rcode, _, rstderr := runCmdLine(c, &SetConstraintsCommand{}, "-s", "svc1,missing,svc2", "mem=8G")
+	c.Assert(rcode, gc.Equals, 1)
+	c.Assert(rstderr, gc.Equals, `cannot set constraints for service "missing": service "missing" not found`+"\n")
+	for _, svc := range []*state.Service{svc1, svc2} {
+		cons, err := svc.Constraints()
+		c.Assert(err, gc.IsNil)
+		c.Assert(cons, gc.DeepEquals, constraints.Value{Mem: uint64p(8192)})
+	}
+}
+
 func assertSetError(c *gc.C, code int, stderr string, args ...string) {
 	rcode, rstdout, rstderr := runCmdLine(c, &SetConstraintsCommand{}, args...)
 	c.Assert(rcode, gc.Equals, code)
@@ -86,6 +110,7 @@ func assertSetError(c *gc.C, code int, stderr string, args ...string) {
 
 func (s *ConstraintsCommandsSuite) TestSetErrors(c *gc.C) {
 	assertSetError(c, 2, `invalid service name "badname-0"`, "-s", "badname-0")
+	assertSetError(c, 2, `invalid service name ""`, "-s", "svc,")
 	assertSetError(c, 2, `malformed constraint "="`, "=")
 	assertSetError(c, 2, `malformed constraint "="`, "-s", "s", "=")
 	assertSetError(c, 1, `service "missing" not found`, "-s", "missing")
@@ -0,0 +1,194 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/environs"
+	"launchpad.net/juju-core/environs/configstore"
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/utils"
+)
+
+// CreateEnvironmentCommand creates an environment hosted by the
+// state server of an existing environment.
+type CreateEnvironmentCommand struct {
+	cmd.EnvCommandBase
+	name   string
+	values attributes
+}
+
+const createEnvironmentDoc = `
+create-environment creates a new environment hosted by the state server
+of the current environment; no machines are started for it. The new
+environment has the configuration of the current one, except for its
+name, its webhooks and any attributes given as key=value pairs. The
+provider type, certificate, ports and agent version cannot be changed.
+
+The environment information for the new environment is written to
+$JUJU_HOME/environments/<name>.jenv with its own admin password, after
+which it can be named with -e like any other environment. Destroy it
+with destroy-environment; the hosting environment cannot be destroyed
+while it hosts others.
+
+Examples:
+  juju create-environment staging
+  juju create-environment -e production staging default-series=trusty
+`
+
+func (c *CreateEnvironmentCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "create-environment",
+		Args:    "<environment name> [key=value ...]",
+		Purpose: "create an environment hosted by the current environment's state server",
+		Doc:     createEnvironmentDoc,
+	}
+}
+
+func (c *CreateEnvironmentCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+}
+
+func (c *CreateEnvironmentCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no environment name specified")
+	}
+	c.name, args = args[0], args[1:]
+	c.values = make(attributes)
+	for i, arg := range args {
+		bits := strings.SplitN(arg, "=", 2)
+		if len(bits) < 2 {
+			return fmt.Errorf(`Missing "=" in arg %d: %q`, i+2, arg)
+		}
+		key := bits[0]
+		if key == "name" {
+			return fmt.Errorf("name must be given as the first argument")
+		}
+		if _, exists := c.values[key]; exists {
+			return fmt.Errorf(`Key %q specified more than once`, key)
+		}
+		c.values[key] = bits[1]
+	}
+	return nil
+}
+
+func (c *CreateEnvironmentCommand) Run(ctx *cmd.Context) (err error) {
+	store, err := configstore.Default()
+	if err != nil {
+		return fmt.Errorf("cannot open environment info storage: %v", err)
+	}
+	envName, err := resolveEnvName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	// Names in environments.yaml are taken even before the
+	// environments are bootstrapped.
+	if envs, err := environs.ReadEnvirons(""); err == nil {
+		if _, err := envs.Config(c.name); !errors.IsNotFoundError(err) {
+			return fmt.Errorf("environment %q already exists", c.name)
+		}
+	}
+	info, err := store.CreateInfo(c.name)
+	if err == configstore.ErrEnvironInfoAlreadyExists {
+		return fmt.Errorf("environment %q already exists", c.name)
+	} else if err != nil {
+		return err
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		if err := info.Destroy(); err != nil {
+			logger.Warningf("cannot remove environment information for %q: %v", c.name, err)
+		}
+	}()
+	client, err := juju.NewAPIClientFromName(envName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	// Connecting caches the state server's API endpoint, which the
+	// new environment shares.
+	serverInfo, err := store.ReadInfo(envName)
+	if err != nil {
+		return err
+	}
+	endpoint := serverInfo.APIEndpoint()
+	if len(endpoint.Addresses) == 0 {
+		return fmt.Errorf("no API addresses known for environment %q", envName)
+	}
+	password, err := utils.RandomPassword()
+	if err != nil {
+		return err
+	}
+	tag, err := client.CreateEnvironment(c.name, c.values, password)
+	if err != nil {
+		return err
+	}
+	_, uuid, err := names.ParseTag(tag, names.EnvironTagKind)
+	if err != nil {
+		return err
+	}
+	endpoint.EnvironUUID = uuid
+	info.SetAPIEndpoint(endpoint)
+	info.SetAPICredentials(configstore.APICredentials{
+		User:     "admin",
+		Password: password,
+	})
+	if err := info.Write(); err != nil {
+		logger.Errorf("environment %q was created, but its information cannot be written", c.name)
+		return err
+	}
+	fmt.Fprintf(ctx.Stderr, "created environment %q\n", c.name)
+	return nil
+}
+
+// resolveEnvName returns the given environment name, or the name of
+// the default environment in environments.yaml if it is empty.
+func resolveEnvName(envName string) (string, error) {
+	if envName != "" {
+		return envName, nil
+	}
+	envs, err := environs.ReadEnvirons("")
+	if err != nil {
+		return "", err
+	}
+	if envs.Default == "" {
+		return "", fmt.Errorf("no default environment found")
+	}
+	return envs.Default, nil
+}
+
+// hostedEnvironInfo returns the environment information written by
+// create-environment for the named environment, or nil if the
+// environment is not hosted by another environment's state server.
+// Hosted environments have no bootstrap configuration nor an entry in
+// environments.yaml, and are known only by their API endpoint.
+func hostedEnvironInfo(store configstore.Storage, envName string) (configstore.EnvironInfo, error) {
+	info, err := store.ReadInfo(envName)
+	if errors.IsNotFoundError(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if len(info.BootstrapConfig()) > 0 || info.APIEndpoint().EnvironUUID == "" {
+		return nil, nil
+	}
+	envs, err := environs.ReadEnvirons("")
+	if err == nil {
+		if _, err := envs.Config(envName); !errors.IsNotFoundError(err) {
+			return nil, nil
+		}
+	} else if !environs.IsNoEnv(err) {
+		return nil, err
+	}
+	return info, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/juju/testing"
+	coretesting "launchpad.net/juju-core/testing"
+	jc "launchpad.net/juju-core/testing/checkers"
+)
+
+type CreateEnvironmentSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&CreateEnvironmentSuite{})
+
+var createEnvironmentInitErrorTests = []struct {
+	args []string
+	err  string
+}{{
+	args: nil,
+	err:  "no environment name specified",
+}, {
+	args: []string{"hosted", "default-series"},
+	err:  `Missing "=" in arg 2: "default-series"`,
+}, {
+	args: []string{"hosted", "name=other"},
+	err:  "name must be given as the first argument",
+}, {
+	args: []string{"hosted", "default-series=precise", "default-series=trusty"},
+	err:  `Key "default-series" specified more than once`,
+}}
+
+func (s *CreateEnvironmentSuite) TestInitErrors(c *gc.C) {
+	for i, t := range createEnvironmentInitErrorTests {
+		c.Logf("test %d: %q", i, t.args)
+		err := coretesting.InitCommand(&CreateEnvironmentCommand{}, t.args)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *CreateEnvironmentSuite) TestCreateEnvironment(c *gc.C) {
+	_, err := coretesting.RunCommand(c, &CreateEnvironmentCommand{}, []string{"hosted", "default-series=precise"})
+	c.Assert(err, gc.IsNil)
+
+	env, err := s.State.HostedEnvironment("hosted")
+	c.Assert(err, gc.IsNil)
+	serverInfo, err := s.ConfigStore.ReadInfo("dummyenv")
+	c.Assert(err, gc.IsNil)
+	info, err := s.ConfigStore.ReadInfo("hosted")
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.BootstrapConfig(), gc.HasLen, 0)
+	endpoint := info.APIEndpoint()
+	c.Assert(endpoint.Addresses, gc.DeepEquals, serverInfo.APIEndpoint().Addresses)
+	c.Assert(endpoint.CACert, gc.Equals, serverInfo.APIEndpoint().CACert)
+	c.Assert(endpoint.EnvironUUID, gc.Equals, env.UUID())
+	c.Assert(info.APICredentials().User, gc.Equals, "admin")
+
+	// The new environment can be used like any other.
+	client, err := juju.NewAPIClientFromName("hosted")
+	c.Assert(err, gc.IsNil)
+	defer client.Close()
+	envInfo, err := client.EnvironmentInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(envInfo.Name, gc.Equals, "hosted")
+	attrs, err := client.EnvironmentGet()
+	c.Assert(err, gc.IsNil)
+	c.Assert(attrs["default-series"], gc.Equals, "precise")
+	status, err := client.Status(nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(status.Machines, gc.HasLen, 0)
+}
+
+func (s *CreateEnvironmentSuite) TestCreateEnvironmentNameInUse(c *gc.C) {
+	_, err := coretesting.RunCommand(c, &CreateEnvironmentCommand{}, []string{"dummyenv"})
+	c.Assert(err, gc.ErrorMatches, `environment "dummyenv" already exists`)
+
+	_, err = coretesting.RunCommand(c, &CreateEnvironmentCommand{}, []string{"hosted"})
+	c.Assert(err, gc.IsNil)
+	_, err = coretesting.RunCommand(c, &CreateEnvironmentCommand{}, []string{"hosted"})
+	c.Assert(err, gc.ErrorMatches, `environment "hosted" already exists`)
+}
+
+func (s *CreateEnvironmentSuite) TestCreateEnvironmentFails(c *gc.C) {
+	_, err := coretesting.RunCommand(c, &CreateEnvironmentCommand{}, []string{"hosted", "api-port=1234"})
+	c.Assert(err, gc.ErrorMatches, "api-port cannot be set for a hosted environment")
+
+	// No environment information is left behind.
+	_, err = s.ConfigStore.ReadInfo("hosted")
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+	_, err = s.State.HostedEnvironment("hosted")
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+}
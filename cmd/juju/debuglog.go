@@ -5,18 +5,23 @@ package main
 
 import (
 	"fmt"
+	"path"
 	"strconv"
 
 	"launchpad.net/gnuflag"
 
 	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/juju/osenv"
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/utils"
 )
 
 type DebugLogCommand struct {
 	cmd.CommandBase
 	// The debug log command simply invokes juju ssh with the required arguments.
-	sshCmd cmd.Command
-	lines  linesValue
+	sshCmd    cmd.Command
+	lines     linesValue
+	machineId string
 }
 
 // defaultLineCount is the default number of lines to
@@ -59,6 +64,11 @@ func (v *linesValue) Set(value string) error {
 const debuglogDoc = `
 Launch an ssh shell on the state server machine and tail the consolidated log file.
 The consolidated log file contains log messages from all nodes in the environment.
+
+With --machine, the log file of the given machine's agent is tailed on that
+machine instead. Windows machines do not send their log messages to the
+consolidated log file, so this is the only way to see them; their log is
+read via WinRM, as with "juju ssh".
 `
 
 func (c *DebugLogCommand) Info() *cmd.Info {
@@ -75,22 +85,51 @@ func (c *DebugLogCommand) SetFlags(f *gnuflag.FlagSet) {
 	c.lines = -defaultLineCount
 	f.Var(&c.lines, "n", "output the last K lines; or use -n +K to output lines starting with the Kth")
 	f.Var(&c.lines, "lines", "")
+	f.StringVar(&c.machineId, "machine", "", "tail the log file of the given machine's agent")
 }
 
 func (c *DebugLogCommand) AllowInterspersedFlags() bool {
 	return true
 }
 
+// windowsArgsSetter is implemented by ssh
+// commands that can manage Windows machines.
+type windowsArgsSetter interface {
+	setWindowsArgs(args []string)
+}
+
 func (c *DebugLogCommand) Init(args []string) error {
-	tailcmd := fmt.Sprintf("tail -n %s -f /var/log/juju/all-machines.log", &c.lines)
-	args = append([]string{"0"}, args...)
+	target, logFile := "0", "/var/log/juju/all-machines.log"
+	if c.machineId != "" {
+		if !names.IsMachine(c.machineId) {
+			return fmt.Errorf("invalid machine id %q", c.machineId)
+		}
+		target = c.machineId
+		logFile = path.Join("/var/log/juju", names.MachineTag(c.machineId)+".log")
+		if setter, ok := c.sshCmd.(windowsArgsSetter); ok {
+			setter.setWindowsArgs([]string{windowsTailCommand(c.machineId, c.lines)})
+		}
+	}
+	tailcmd := fmt.Sprintf("tail -n %s -f %s", &c.lines, logFile)
+	args = append([]string{target}, args...)
 	args = append(args, tailcmd)
 	return c.sshCmd.Init(args)
 }
 
+// windowsTailCommand returns the PowerShell equivalent of
+// tailing the log file of the given Windows machine's agent.
+func windowsTailCommand(machineId string, lines linesValue) string {
+	logFile := utils.PathToWindows(path.Join(osenv.WinLogDir, names.MachineTag(machineId)+".log"))
+	if lines > 0 {
+		return fmt.Sprintf("Get-Content -Path '%s' -Wait | Select-Object -Skip %d", logFile, lines-1)
+	}
+	return fmt.Sprintf("Get-Content -Path '%s' -Tail %d -Wait", logFile, -lines)
+}
+
 // Run uses "juju ssh" to log into the state server node
 // and tails the consolidated log file which captures log
-// messages from all nodes.
+// messages from all nodes, or into the machine given with
+// --machine to tail its agent's log file.
 func (c *DebugLogCommand) Run(ctx *cmd.Context) error {
 	return c.sshCmd.Run(ctx)
 }
@@ -73,3 +73,26 @@ func (s *DebugLogSuite) TestDebugLogValidation(c *gc.C) {
 	_, err = runDebugLog(c, "-n", "fnord")
 	c.Assert(err, gc.ErrorMatches, "invalid value \"fnord\" for flag -n: invalid number of lines")
 }
+
+func (s *DebugLogSuite) TestDebugLogMachine(c *gc.C) {
+	defer testing.MakeEmptyFakeHome(c).Restore()
+	debugLogCmd, err := runDebugLog(c, "--machine", "2")
+	c.Assert(err, gc.IsNil)
+	debugCmd := debugLogCmd.sshCmd.(*dummySSHCommand)
+	c.Assert(debugCmd.Target, gc.Equals, "2")
+	c.Assert(debugCmd.Args, gc.DeepEquals, []string{"tail -n 10 -f /var/log/juju/machine-2.log"})
+	c.Assert(debugCmd.windowsArgs, gc.DeepEquals, []string{
+		`Get-Content -Path 'C:\Juju\log\machine-2.log' -Tail 10 -Wait`,
+	})
+
+	debugLogCmd, err = runDebugLog(c, "--machine", "2", "-n", "+5")
+	c.Assert(err, gc.IsNil)
+	debugCmd = debugLogCmd.sshCmd.(*dummySSHCommand)
+	c.Assert(debugCmd.Args, gc.DeepEquals, []string{"tail -n +5 -f /var/log/juju/machine-2.log"})
+	c.Assert(debugCmd.windowsArgs, gc.DeepEquals, []string{
+		`Get-Content -Path 'C:\Juju\log\machine-2.log' -Wait | Select-Object -Skip 4`,
+	})
+
+	_, err = runDebugLog(c, "--machine", "mysql/0")
+	c.Assert(err, gc.ErrorMatches, `invalid machine id "mysql/0"`)
+}
@@ -7,8 +7,10 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"launchpad.net/gnuflag"
+	"launchpad.net/goyaml"
 
 	"launchpad.net/juju-core/charm"
 	"launchpad.net/juju-core/cmd"
@@ -25,6 +27,7 @@ type DeployCommand struct {
 	cmd.EnvCommandBase
 	UnitCommandBase
 	CharmName    string
+	CharmNames   []string
 	ServiceName  string
 	Config       cmd.FileVar
 	Constraints  constraints.Value
@@ -52,6 +55,11 @@ mysql-33 becomes cs:precise/mysql-33).
 
 <service name>, if omitted, will be derived from <charm name>.
 
+Several charms may be deployed at once by giving a comma-separated list of
+charm names. Each service is then named after its charm, the config given
+applies to all of them, and the number of units and constraints given apply
+to each principal service.
+
 Constraints can be specified when using deploy by specifying the --constraints
 flag.  When used with deploy, service-specific constraints are set so that later
 machines provisioned with add-unit will use the same constraints (unless changed
@@ -66,6 +74,8 @@ Examples:
    
    juju deploy mysql -n 5 --constraints mem=8G (deploy 5 instances of mysql with at least 8 GB of RAM each)
 
+   juju deploy mysql,wordpress,memcached (deploy three services at once)
+
 See Also:
    juju help constraints
    juju help set-constraints
@@ -75,7 +85,7 @@ See Also:
 func (c *DeployCommand) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:    "deploy",
-		Args:    "<charm name> [<service name>]",
+		Args:    "<charm name>[,<charm name>...] [<service name>]",
 		Purpose: "deploy a new service",
 		Doc:     deployDoc,
 	}
@@ -101,15 +111,26 @@ func (c *DeployCommand) Init(args []string) error {
 		c.ServiceName = args[1]
 		fallthrough
 	case 1:
-		if _, err := charm.InferURL(args[0], "fake"); err != nil {
-			return fmt.Errorf("invalid charm name %q", args[0])
+		c.CharmNames = strings.Split(args[0], ",")
+		for _, name := range c.CharmNames {
+			if _, err := charm.InferURL(name, "fake"); err != nil {
+				return fmt.Errorf("invalid charm name %q", name)
+			}
 		}
-		c.CharmName = args[0]
+		c.CharmName = c.CharmNames[0]
 	case 0:
 		return errors.New("no charm specified")
 	default:
 		return cmd.CheckEmpty(args[2:])
 	}
+	if len(c.CharmNames) > 1 {
+		if c.ServiceName != "" {
+			return errors.New("cannot give a service name when deploying several charms")
+		}
+		if c.ToMachineSpec != "" {
+			return errors.New("cannot use --to when deploying several charms")
+		}
+	}
 	return c.UnitCommandBase.Init(args)
 }
 
@@ -122,6 +143,9 @@ func (c *DeployCommand) Run(ctx *cmd.Context) error {
 
 	attrs, err := client.EnvironmentGet()
 	if params.IsCodeNotImplemented(err) {
+		if len(c.CharmNames) > 1 {
+			return errors.New("deploying several charms at once is not supported by the API server")
+		}
 		logger.Infof("EnvironmentGet not supported by the API server, " +
 			"falling back to 1.16 compatibility mode (direct DB access)")
 		return c.run1dot16(ctx)
@@ -133,62 +157,122 @@ func (c *DeployCommand) Run(ctx *cmd.Context) error {
 	if err != nil {
 		return err
 	}
-	curl, err := charm.InferURL(c.CharmName, conf.DefaultSeries())
-	if err != nil {
+
+	var configYAML []byte
+	configured := make(map[string]interface{})
+	if c.Config.Path != "" {
+		configYAML, err = c.Config.Read(ctx)
+		if err != nil {
+			return err
+		}
+		if len(c.CharmNames) > 1 {
+			if err := goyaml.Unmarshal(configYAML, &configured); err != nil {
+				return err
+			}
+		}
+	}
+	services := make([]params.ServiceDeploy, len(c.CharmNames))
+	for i, charmName := range c.CharmNames {
+		if services[i], err = c.serviceDeploy(ctx, client, conf, charmName); err != nil {
+			return err
+		}
+		// When several services are deployed, the config need
+		// not hold settings for all of them.
+		if _, ok := configured[services[i].ServiceName]; ok || len(services) == 1 {
+			services[i].ConfigYAML = string(configYAML)
+		}
+	}
+	if c.BumpRevision {
+		ctx.Stdout.Write([]byte("--upgrade (or -u) is deprecated and ignored; charms are always deployed with a unique revision.\n"))
+	}
+	if len(services) == 1 {
+		args := services[0]
+		return client.ServiceDeploy(
+			args.CharmUrl,
+			args.ServiceName,
+			args.NumUnits,
+			args.ConfigYAML,
+			args.Constraints,
+			args.ToMachineSpec,
+		)
+	}
+	results, err := client.ServicesDeploy(services)
+	if params.IsCodeNotImplemented(err) {
+		logger.Infof("ServicesDeploy not supported by the API server, deploying services one at a time")
+		results = make([]params.ErrorResult, len(services))
+		for i, args := range services {
+			err := client.ServiceDeploy(
+				args.CharmUrl, args.ServiceName, args.NumUnits, args.ConfigYAML, args.Constraints, "")
+			if err != nil {
+				results[i].Error = &params.Error{Message: err.Error()}
+			}
+		}
+	} else if err != nil {
 		return err
 	}
+	failed := false
+	for i, result := range results {
+		if result.Error != nil {
+			fmt.Fprintf(ctx.Stderr, "cannot deploy service %q: %v\n", services[i].ServiceName, result.Error)
+			failed = true
+		}
+	}
+	if failed {
+		return cmd.ErrSilent
+	}
+	return nil
+}
+
+// serviceDeploy adds the named charm to the environment and returns
+// the parameters for deploying a service of it.
+func (c *DeployCommand) serviceDeploy(ctx *cmd.Context, client *api.Client, conf *config.Config, charmName string) (params.ServiceDeploy, error) {
+	curl, err := charm.InferURL(charmName, conf.DefaultSeries())
+	if err != nil {
+		return params.ServiceDeploy{}, err
+	}
 	repo, err := charm.InferRepository(curl, ctx.AbsPath(c.RepoPath))
 	if err != nil {
-		return err
+		return params.ServiceDeploy{}, err
 	}
 
 	repo = config.SpecializeCharmRepo(repo, conf)
 
 	curl, err = addCharmViaAPI(client, ctx, curl, repo)
 	if err != nil {
-		return err
-	}
-
-	if c.BumpRevision {
-		ctx.Stdout.Write([]byte("--upgrade (or -u) is deprecated and ignored; charms are always deployed with a unique revision.\n"))
+		return params.ServiceDeploy{}, err
 	}
 
 	charmInfo, err := client.CharmInfo(curl.String())
 	if err != nil {
-		return err
+		return params.ServiceDeploy{}, err
 	}
 
-	numUnits := c.NumUnits
+	numUnits, cons := c.NumUnits, c.Constraints
 	if charmInfo.Meta.Subordinate {
-		if !constraints.IsEmpty(&c.Constraints) {
-			return errors.New("cannot use --constraints with subordinate service")
-		}
-		if numUnits == 1 && c.ToMachineSpec == "" {
+		switch {
+		case len(c.CharmNames) > 1:
+			// The number of units and constraints given
+			// apply to the principal services only.
+			numUnits, cons = 0, constraints.Value{}
+		case !constraints.IsEmpty(&c.Constraints):
+			return params.ServiceDeploy{}, errors.New("cannot use --constraints with subordinate service")
+		case numUnits == 1 && c.ToMachineSpec == "":
 			numUnits = 0
-		} else {
-			return errors.New("cannot use --num-units or --to with subordinate service")
+		default:
+			return params.ServiceDeploy{}, errors.New("cannot use --num-units or --to with subordinate service")
 		}
 	}
 	serviceName := c.ServiceName
 	if serviceName == "" {
 		serviceName = charmInfo.Meta.Name
 	}
-
-	var configYAML []byte
-	if c.Config.Path != "" {
-		configYAML, err = c.Config.Read(ctx)
-		if err != nil {
-			return err
-		}
-	}
-	return client.ServiceDeploy(
-		curl.String(),
-		serviceName,
-		numUnits,
-		string(configYAML),
-		c.Constraints,
-		c.ToMachineSpec,
-	)
+	return params.ServiceDeploy{
+		ServiceName:   serviceName,
+		CharmUrl:      curl.String(),
+		NumUnits:      numUnits,
+		Constraints:   cons,
+		ToMachineSpec: c.ToMachineSpec,
+	}, nil
 }
 
 // run1dot16 implements the deploy command in 1.16 compatibility mode,
@@ -9,6 +9,7 @@ import (
 	gc "launchpad.net/gocheck"
 
 	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/cmd"
 	"launchpad.net/juju-core/constraints"
 	"launchpad.net/juju-core/errors"
 	"launchpad.net/juju-core/instance"
@@ -54,6 +55,15 @@ var initErrorTests = []struct {
 	}, {
 		args: []string{"craziness", "burble1", "--constraints", "gibber=plop"},
 		err:  `invalid value "gibber=plop" for flag --constraints: unknown constraint "gibber"`,
+	}, {
+		args: []string{"craziness,craz~ness"},
+		err:  `invalid charm name "craz~ness"`,
+	}, {
+		args: []string{"craziness,madness", "burble1"},
+		err:  `cannot give a service name when deploying several charms`,
+	}, {
+		args: []string{"craziness,madness", "--to", "123"},
+		err:  `cannot use --to when deploying several charms`,
 	},
 }
 
@@ -78,6 +88,25 @@ func (s *DeploySuite) TestCharmDir(c *gc.C) {
 	s.AssertService(c, "dummy", curl, 1, 0)
 }
 
+func (s *DeploySuite) TestSeveralCharms(c *gc.C) {
+	coretesting.Charms.ClonedDirPath(s.SeriesPath, "dummy")
+	coretesting.Charms.BundlePath(s.SeriesPath, "logging")
+	err := runDeploy(c, "local:dummy,local:logging", "-n", "2")
+	c.Assert(err, gc.IsNil)
+	s.AssertService(c, "dummy", charm.MustParseURL("local:precise/dummy-1"), 2, 0)
+	s.AssertService(c, "logging", charm.MustParseURL("local:precise/logging-1"), 0, 0)
+}
+
+func (s *DeploySuite) TestSeveralCharmsReportsErrors(c *gc.C) {
+	coretesting.Charms.ClonedDirPath(s.SeriesPath, "dummy")
+	coretesting.Charms.BundlePath(s.SeriesPath, "logging")
+	s.AddTestingService(c, "logging", s.AddTestingCharm(c, "logging"))
+	ctx, err := coretesting.RunCommand(c, &DeployCommand{}, []string{"local:dummy,local:logging"})
+	c.Assert(err, gc.Equals, cmd.ErrSilent)
+	c.Assert(coretesting.Stderr(ctx), gc.Matches, `cannot deploy service "logging": .*already exists.*\n`)
+	s.AssertService(c, "dummy", charm.MustParseURL("local:precise/dummy-1"), 1, 0)
+}
+
 func (s *DeploySuite) TestUpgradeReportsDeprecated(c *gc.C) {
 	coretesting.Charms.ClonedDirPath(s.SeriesPath, "dummy")
 	ctx, err := coretesting.RunCommand(c, &DeployCommand{}, []string{"local:dummy", "-u"})
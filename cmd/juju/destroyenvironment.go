@@ -52,22 +52,21 @@ func (c *DestroyEnvironmentCommand) Run(ctx *cmd.Context) (result error) {
 	if err != nil {
 		return fmt.Errorf("cannot open environment info storage: %v", err)
 	}
+	hostedInfo, err := hostedEnvironInfo(store, c.envName)
+	if err != nil {
+		return err
+	}
+	if hostedInfo != nil {
+		return c.destroyHosted(ctx, hostedInfo)
+	}
 	environ, err := environs.NewFromName(c.envName, store)
 	if err != nil {
 		return err
 	}
 	if !c.assumeYes {
 		fmt.Fprintf(ctx.Stdout, destroyEnvMsg, environ.Name(), environ.Config().Type())
-
-		scanner := bufio.NewScanner(ctx.Stdin)
-		scanner.Scan()
-		err := scanner.Err()
-		if err != nil && err != io.EOF {
-			return fmt.Errorf("Environment destruction aborted: %s", err)
-		}
-		answer := strings.ToLower(scanner.Text())
-		if answer != "y" && answer != "yes" {
-			return errors.New("Environment destruction aborted")
+		if err := confirmDestruction(ctx); err != nil {
+			return err
 		}
 	}
 	// If --force is supplied, then don't attempt to use the API.
@@ -103,6 +102,46 @@ to be cleaned up.
 	return environs.Destroy(environ, store)
 }
 
+// destroyHosted destroys an environment hosted by the state server of
+// another environment. Its machines are stopped by the state server,
+// so it can only be destroyed through the API.
+func (c *DestroyEnvironmentCommand) destroyHosted(ctx *cmd.Context, info configstore.EnvironInfo) error {
+	if c.force {
+		return fmt.Errorf("environment %q is hosted by another environment's state server and cannot be destroyed with --force", c.envName)
+	}
+	if !c.assumeYes {
+		fmt.Fprintf(ctx.Stdout, destroyHostedEnvMsg, c.envName)
+		if err := confirmDestruction(ctx); err != nil {
+			return err
+		}
+	}
+	client, err := juju.NewAPIClientFromName(c.envName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if err := client.DestroyEnvironment(); err != nil {
+		return fmt.Errorf("destroying environment: %v", err)
+	}
+	return info.Destroy()
+}
+
+// confirmDestruction reads the user's answer to a destruction warning
+// and returns an error unless they agree.
+func confirmDestruction(ctx *cmd.Context) error {
+	scanner := bufio.NewScanner(ctx.Stdin)
+	scanner.Scan()
+	err := scanner.Err()
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("Environment destruction aborted: %s", err)
+	}
+	answer := strings.ToLower(scanner.Text())
+	if answer != "y" && answer != "yes" {
+		return errors.New("Environment destruction aborted")
+	}
+	return nil
+}
+
 func (c *DestroyEnvironmentCommand) Init(args []string) error {
 	if c.envName != "" {
 		logger.Warningf("-e/--environment flag is deprecated in 1.18, " +
@@ -133,3 +172,9 @@ WARNING! this command will destroy the %q environment (type: %s)
 This includes all machines, services, data and other resources.
 
 Continue [y/N]? `[1:]
+
+var destroyHostedEnvMsg = `
+WARNING! this command will destroy the %q environment (hosted by another environment's state server)
+This includes all machines, services, data and other resources.
+
+Continue [y/N]? `[1:]
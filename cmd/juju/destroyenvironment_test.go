@@ -15,6 +15,7 @@ import (
 	"launchpad.net/juju-core/instance"
 	"launchpad.net/juju-core/juju/testing"
 	"launchpad.net/juju-core/provider/dummy"
+	"launchpad.net/juju-core/state"
 	coretesting "launchpad.net/juju-core/testing"
 	jc "launchpad.net/juju-core/testing/checkers"
 )
@@ -186,3 +187,38 @@ func assertEnvironNotDestroyed(c *gc.C, env environs.Environ, store configstore.
 	_, err = environs.NewFromName(env.Name(), store)
 	c.Assert(err, gc.IsNil)
 }
+
+func (s *destroyEnvSuite) TestDestroyHostedEnvironment(c *gc.C) {
+	_, err := coretesting.RunCommand(c, &CreateEnvironmentCommand{}, []string{"hosted"})
+	c.Assert(err, gc.IsNil)
+
+	// Hosted environments cannot be destroyed through the provider.
+	opc, errc := runCommand(nullContext(), new(DestroyEnvironmentCommand), "hosted", "--yes", "--force")
+	c.Check(<-errc, gc.ErrorMatches, `environment "hosted" is hosted by another environment's state server and cannot be destroyed with --force`)
+	c.Check(<-opc, gc.IsNil)
+
+	var stdin, stdout bytes.Buffer
+	ctx := cmd.DefaultContext()
+	ctx.Stdout = &stdout
+	ctx.Stdin = &stdin
+	stdin.WriteString("n")
+	opc, errc = runCommand(ctx, new(DestroyEnvironmentCommand), "hosted")
+	c.Check(<-errc, gc.ErrorMatches, "Environment destruction aborted")
+	c.Check(<-opc, gc.IsNil)
+	c.Check(stdout.String(), gc.Matches, "WARNING!.*hosted.*\\(hosted by another environment's state server\\)(.|\n)*")
+	_, err = s.State.HostedEnvironment("hosted")
+	c.Assert(err, gc.IsNil)
+
+	opc, errc = runCommand(nullContext(), new(DestroyEnvironmentCommand), "hosted", "--yes")
+	c.Check(<-errc, gc.IsNil)
+	c.Check(<-opc, gc.IsNil)
+	_, err = s.State.HostedEnvironment("hosted")
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+	_, err = s.ConfigStore.ReadInfo("hosted")
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+
+	// The hosting environment is untouched.
+	env, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	c.Assert(env.Life(), gc.Equals, state.Alive)
+}
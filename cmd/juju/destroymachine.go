@@ -9,8 +9,10 @@ import (
 	"launchpad.net/gnuflag"
 
 	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/environs/manual"
 	"launchpad.net/juju-core/juju"
 	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/state/api"
 	"launchpad.net/juju-core/state/api/params"
 	"launchpad.net/juju-core/state/statecmd"
 )
@@ -18,8 +20,10 @@ import (
 // DestroyMachineCommand causes an existing machine to be destroyed.
 type DestroyMachineCommand struct {
 	cmd.EnvCommandBase
-	MachineIds []string
-	Force      bool
+	MachineIds   []string
+	Force        bool
+	Decommission bool
+	KeepData     bool
 }
 
 const destroyMachineDoc = `
@@ -27,6 +31,17 @@ Machines that are responsible for the environment cannot be destroyed. Machines
 running units or containers can only be destroyed with the --force flag; doing
 so will also destroy all those units and containers without giving them any
 opportunity to shut down cleanly.
+
+Machines that were provisioned manually with "juju add-machine ssh:..." can be
+decommissioned with the --decommission flag, which requires --force: their
+agents are stopped before they could report the machines dead, so the machines
+must be removed from the environment without their help. Once they have been
+destroyed, the juju agents and database are stopped and removed from them via
+SSH, along with
+the juju data and log directories, which hold the units' charm directories, so
+that they can be provisioned again. The data and log directories are left in
+place if --keep-data is specified. Windows machines are decommissioned via
+WinRM instead of SSH, as described in "juju help ssh".
 `
 
 func (c *DestroyMachineCommand) Info() *cmd.Info {
@@ -42,12 +57,20 @@ func (c *DestroyMachineCommand) Info() *cmd.Info {
 func (c *DestroyMachineCommand) SetFlags(f *gnuflag.FlagSet) {
 	c.EnvCommandBase.SetFlags(f)
 	f.BoolVar(&c.Force, "force", false, "completely remove machine and all dependencies")
+	f.BoolVar(&c.Decommission, "decommission", false, "remove juju from manually provisioned machines")
+	f.BoolVar(&c.KeepData, "keep-data", false, "keep juju data and logs when decommissioning")
 }
 
 func (c *DestroyMachineCommand) Init(args []string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("no machines specified")
 	}
+	if c.KeepData && !c.Decommission {
+		return fmt.Errorf("--keep-data requires --decommission")
+	}
+	if c.Decommission && !c.Force {
+		return fmt.Errorf("--decommission requires --force")
+	}
 	for _, id := range args {
 		if !names.IsMachine(id) {
 			return fmt.Errorf("invalid machine id %q", id)
@@ -72,12 +95,49 @@ func (c *DestroyMachineCommand) run1dot16() error {
 	return statecmd.DestroyMachines1dot16(conn.State, c.MachineIds...)
 }
 
-func (c *DestroyMachineCommand) Run(_ *cmd.Context) error {
+// manualMachines returns the machines to be decommissioned,
+// with their hosts, checking that they were all manually
+// provisioned.
+func (c *DestroyMachineCommand) manualMachines(client *api.Client) ([]manual.DecommissionMachineArgs, error) {
+	status, err := client.Status(nil)
+	if err != nil {
+		return nil, err
+	}
+	var machines []manual.DecommissionMachineArgs
+	for _, id := range c.MachineIds {
+		var host string
+		m, ok := status.Machines[id]
+		if ok {
+			host, ok = manual.HostFromInstanceId(m.InstanceId)
+		}
+		if !ok {
+			return nil, fmt.Errorf("cannot decommission machine %s: not manually provisioned", id)
+		}
+		machines = append(machines, manual.DecommissionMachineArgs{
+			Host:     host,
+			Series:   m.Series,
+			KeepData: c.KeepData,
+		})
+	}
+	return machines, nil
+}
+
+// decommissionMachine is called to decommission each
+// manually provisioned machine; it may be replaced in tests.
+var decommissionMachine = manual.DecommissionMachine
+
+func (c *DestroyMachineCommand) Run(ctx *cmd.Context) error {
 	apiclient, err := juju.NewAPIClientFromName(c.EnvName)
 	if err != nil {
 		return err
 	}
 	defer apiclient.Close()
+	var machines []manual.DecommissionMachineArgs
+	if c.Decommission {
+		if machines, err = c.manualMachines(apiclient); err != nil {
+			return err
+		}
+	}
 	if c.Force {
 		err = apiclient.ForceDestroyMachines(c.MachineIds...)
 	} else {
@@ -87,7 +147,21 @@ func (c *DestroyMachineCommand) Run(_ *cmd.Context) error {
 	if params.IsCodeNotImplemented(err) {
 		logger.Infof("DestroyMachines not supported by the API server, " +
 			"falling back to <=1.16.3 compatibility")
-		return c.run1dot16()
+		err = c.run1dot16()
+	}
+	if err != nil {
+		return err
+	}
+	var failed bool
+	for i, args := range machines {
+		args.Stderr = ctx.Stderr
+		if err := decommissionMachine(args); err != nil {
+			fmt.Fprintf(ctx.Stderr, "cannot decommission machine %s: %v\n", c.MachineIds[i], err)
+			failed = true
+		}
 	}
-	return err
+	if failed {
+		return cmd.ErrSilent
+	}
+	return nil
 }
@@ -4,9 +4,14 @@
 package main
 
 import (
+	stderrors "errors"
+
 	gc "launchpad.net/gocheck"
 
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/environs/manual"
 	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/instance"
 	jujutesting "launchpad.net/juju-core/juju/testing"
 	"launchpad.net/juju-core/state"
 	"launchpad.net/juju-core/testing"
@@ -129,3 +134,59 @@ func (s *DestroyMachineSuite) TestEnvironmentArg(c *gc.C) {
 	err = runDestroyMachine(c, "0", "-e", "dummyenv")
 	c.Assert(err, gc.IsNil)
 }
+
+func (s *DestroyMachineSuite) TestDestroyMachineDecommission(c *gc.C) {
+	m0, err := s.State.AddOneMachine(state.MachineTemplate{
+		Series:     "precise",
+		Jobs:       []state.MachineJob{state.JobHostUnits},
+		InstanceId: instance.Id("manual:10.0.0.1"),
+		Nonce:      "manual:10.0.0.1:nonce",
+	})
+	c.Assert(err, gc.IsNil)
+	m1, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	var decommissioned []manual.DecommissionMachineArgs
+	decommissionErr := stderrors.New("no route to host")
+	s.PatchValue(&decommissionMachine, func(args manual.DecommissionMachineArgs) error {
+		args.Stderr = nil
+		decommissioned = append(decommissioned, args)
+		return decommissionErr
+	})
+
+	// Machines that were not manually provisioned cannot be decommissioned.
+	err = runDestroyMachine(c, "--force", "--decommission", m0.Id(), m1.Id())
+	c.Assert(err, gc.ErrorMatches, "cannot decommission machine 1: not manually provisioned")
+	c.Assert(decommissioned, gc.HasLen, 0)
+	err = m0.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(m0.Life(), gc.Equals, state.Alive)
+
+	ctx, err := testing.RunCommand(c, &DestroyMachineCommand{}, []string{"--force", "--decommission", "--keep-data", m0.Id()})
+	c.Assert(err, gc.Equals, cmd.ErrSilent)
+	c.Assert(testing.Stderr(ctx), gc.Equals, "cannot decommission machine 0: no route to host\n")
+	c.Assert(decommissioned, gc.DeepEquals, []manual.DecommissionMachineArgs{{
+		Host:     "10.0.0.1",
+		Series:   "precise",
+		KeepData: true,
+	}})
+	// The machine is removed by the cleanup worker.
+	needsCleanup, err := s.State.NeedsCleanup()
+	c.Assert(err, gc.IsNil)
+	c.Assert(needsCleanup, jc.IsTrue)
+	err = s.State.Cleanup()
+	c.Assert(err, gc.IsNil)
+	err = m0.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(m0.Life(), gc.Equals, state.Dead)
+}
+
+func (s *DestroyMachineSuite) TestDestroyMachineKeepDataRequiresDecommission(c *gc.C) {
+	err := runDestroyMachine(c, "--keep-data", "0")
+	c.Assert(err, gc.ErrorMatches, "--keep-data requires --decommission")
+}
+
+func (s *DestroyMachineSuite) TestDestroyMachineDecommissionRequiresForce(c *gc.C) {
+	err := runDestroyMachine(c, "--decommission", "0")
+	c.Assert(err, gc.ErrorMatches, "--decommission requires --force")
+}
@@ -29,6 +29,9 @@ for the environment are output using the selected formatter.
 A single environment value can be output by adding the environment key name to
 the end of the command line.
 
+The apt proxy values shown are the ones machines actually use: when an
+apt proxy is not set, the corresponding general proxy value is shown.
+
 Example:
   
   juju get-environment default-series  (returns the default series for the environment)
@@ -90,6 +93,7 @@ func (c *GetEnvironmentCommand) Run(ctx *cmd.Context) error {
 	if err != nil {
 		return err
 	}
+	addEffectiveProxyValues(attrs)
 
 	if c.key != "" {
 		if value, found := attrs[c.key]; found {
@@ -101,6 +105,27 @@ func (c *GetEnvironmentCommand) Run(ctx *cmd.Context) error {
 	return c.out.Write(ctx, attrs)
 }
 
+// aptProxyFallbacks maps each apt proxy setting to the general proxy
+// setting used by machines when it is not set.
+var aptProxyFallbacks = map[string]string{
+	"apt-http-proxy":  "http-proxy",
+	"apt-https-proxy": "https-proxy",
+	"apt-ftp-proxy":   "ftp-proxy",
+}
+
+// addEffectiveProxyValues replaces unset apt proxy values in attrs
+// with the general proxy values that take effect in their place.
+func addEffectiveProxyValues(attrs map[string]interface{}) {
+	for key, fallback := range aptProxyFallbacks {
+		if value, _ := attrs[key].(string); value != "" {
+			continue
+		}
+		if value, _ := attrs[fallback].(string); value != "" {
+			attrs[key] = value
+		}
+	}
+}
+
 type attributes map[string]interface{}
 
 // SetEnvironment
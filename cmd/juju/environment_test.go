@@ -73,6 +73,28 @@ func (s *GetEnvironmentSuite) TestAllValues(c *gc.C) {
 	}
 }
 
+func (s *GetEnvironmentSuite) TestEffectiveAptProxy(c *gc.C) {
+	oldConfig, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	newConfig, err := oldConfig.Apply(map[string]interface{}{
+		"http-proxy":      "http://proxy.example.com:3128",
+		"https-proxy":     "https://proxy.example.com:3129",
+		"apt-https-proxy": "https://apt-proxy.example.com:3129",
+	})
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetEnvironConfig(newConfig, oldConfig)
+	c.Assert(err, gc.IsNil)
+	for key, expected := range map[string]string{
+		"apt-http-proxy":  "http://proxy.example.com:3128",
+		"apt-https-proxy": "https://apt-proxy.example.com:3129",
+		"apt-ftp-proxy":   "",
+	} {
+		context, err := testing.RunCommand(c, &GetEnvironmentCommand{}, []string{key})
+		c.Assert(err, gc.IsNil)
+		c.Check(strings.TrimSpace(testing.Stdout(context)), gc.Equals, expected)
+	}
+}
+
 type SetEnvironmentSuite struct {
 	jujutesting.RepoSuite
 }
@@ -0,0 +1,68 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/juju"
+)
+
+// ExportEnvironmentCommand writes out the complete model of
+// an environment.
+type ExportEnvironmentCommand struct {
+	cmd.EnvCommandBase
+	out cmd.Output
+}
+
+const exportEnvironmentDoc = `
+export-environment writes out the complete model of the environment:
+its configuration, users, machines, charms, services, units and
+relations, including the agents' password hashes and the instance
+ids of provisioned machines. The format is versioned and described in
+doc/environment-export.txt.
+
+The output contains secrets, such as provider credentials held in the
+environment configuration, and should be stored accordingly. For that
+reason, only the admin user may export the environment.
+
+Examples:
+  juju export-environment -o env.yaml
+  juju export-environment --format json
+`
+
+func (c *ExportEnvironmentCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "export-environment",
+		Purpose: "export the complete environment model",
+		Doc:     exportEnvironmentDoc,
+	}
+}
+
+func (c *ExportEnvironmentCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
+		"yaml": cmd.FormatYaml,
+		"json": cmd.FormatJson,
+	})
+}
+
+func (c *ExportEnvironmentCommand) Init(args []string) error {
+	return cmd.CheckEmpty(args)
+}
+
+func (c *ExportEnvironmentCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	model, err := client.EnvironmentExport()
+	if err != nil {
+		return err
+	}
+	return c.out.Write(ctx, model)
+}
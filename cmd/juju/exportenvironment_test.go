@@ -0,0 +1,58 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"encoding/json"
+
+	gc "launchpad.net/gocheck"
+	"launchpad.net/goyaml"
+
+	"launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	coretesting "launchpad.net/juju-core/testing"
+)
+
+type ExportEnvironmentSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&ExportEnvironmentSuite{})
+
+func (s *ExportEnvironmentSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	_, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	s.AddTestingService(c, "riak", s.AddTestingCharm(c, "riak"))
+}
+
+func (s *ExportEnvironmentSuite) TestExportYAML(c *gc.C) {
+	ctx, err := coretesting.RunCommand(c, &ExportEnvironmentCommand{}, nil)
+	c.Assert(err, gc.IsNil)
+	var model params.EnvironmentModel
+	err = goyaml.Unmarshal([]byte(coretesting.Stdout(ctx)), &model)
+	c.Assert(err, gc.IsNil)
+	s.checkModel(c, &model)
+}
+
+func (s *ExportEnvironmentSuite) TestExportJSON(c *gc.C) {
+	ctx, err := coretesting.RunCommand(c, &ExportEnvironmentCommand{}, []string{"--format", "json"})
+	c.Assert(err, gc.IsNil)
+	var model params.EnvironmentModel
+	err = json.Unmarshal([]byte(coretesting.Stdout(ctx)), &model)
+	c.Assert(err, gc.IsNil)
+	s.checkModel(c, &model)
+}
+
+func (s *ExportEnvironmentSuite) checkModel(c *gc.C, model *params.EnvironmentModel) {
+	env, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	c.Assert(model.Version, gc.Equals, params.EnvironmentModelVersion)
+	c.Assert(model.UUID, gc.Equals, env.UUID())
+	c.Assert(model.Machines, gc.HasLen, 1)
+	c.Assert(model.Machines[0].Id, gc.Equals, "0")
+	c.Assert(model.Services, gc.HasLen, 1)
+	c.Assert(model.Services[0].Name, gc.Equals, "riak")
+}
@@ -5,6 +5,7 @@ package main
 
 import (
 	"fmt"
+	"time"
 
 	"launchpad.net/gnuflag"
 
@@ -52,6 +53,14 @@ func (dummyHookContext) OwnerTag() string {
 	return ""
 }
 
+func (dummyHookContext) AddMetric(key, value string, created time.Time) error {
+	return fmt.Errorf("metrics disabled")
+}
+
+func (dummyHookContext) MigrationSettings() (jujuc.Settings, error) {
+	return nil, fmt.Errorf("unit is not being migrated")
+}
+
 type HelpToolCommand struct {
 	cmd.CommandBase
 	tool string
@@ -0,0 +1,131 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// HookStatsCommand shows statistics on the hooks recently executed
+// by the units of services.
+type HookStatsCommand struct {
+	cmd.EnvCommandBase
+	ServiceNames []string
+	out          cmd.Output
+}
+
+const hookStatsDoc = `
+Unit agents record when each hook they run starts and finishes, and how
+it exits. hook-stats summarizes the recent hook executions of the units
+of the named services, or of all services if none are named.
+
+For each service it shows the number of units currently running a hook,
+the number of hooks its units have queued to run, the number of runs, failure rate and mean and maximum duration of each
+hook, and the slowest recent hook executions. Hooks the charm does not
+define are not counted as runs.
+
+Only the most recent executions of each unit are retained.
+
+Examples:
+  juju hook-stats
+  juju hook-stats wordpress mysql
+`
+
+func (c *HookStatsCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "hook-stats",
+		Args:    "[<service> ...]",
+		Purpose: "show statistics on recently executed hooks",
+		Doc:     hookStatsDoc,
+	}
+}
+
+func (c *HookStatsCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
+		"yaml": cmd.FormatYaml,
+		"json": cmd.FormatJson,
+	})
+}
+
+func (c *HookStatsCommand) Init(args []string) error {
+	for _, name := range args {
+		if !names.IsService(name) {
+			return fmt.Errorf("invalid service name %q", name)
+		}
+	}
+	c.ServiceNames = args
+	return nil
+}
+
+// Run fetches and formats the hook statistics of the services.
+func (c *HookStatsCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	stats, err := client.HookStats(c.ServiceNames...)
+	if err != nil {
+		return err
+	}
+	services := make(map[string]interface{})
+	for _, service := range stats {
+		hooks := make(map[string]interface{})
+		for _, timing := range service.Hooks {
+			hooks[timing.Hook] = map[string]interface{}{
+				"runs":          timing.Runs,
+				"failures":      timing.Failures,
+				"failure-rate":  fmt.Sprintf("%.0f%%", 100*float64(timing.Failures)/float64(timing.Runs)),
+				"mean-duration": timing.MeanDuration.String(),
+				"max-duration":  timing.MaxDuration.String(),
+			}
+		}
+		running := make([]map[string]interface{}, len(service.Running))
+		for i, exec := range service.Running {
+			running[i] = formatHookExecution(exec)
+		}
+		slowest := make([]map[string]interface{}, len(service.Slowest))
+		for i, exec := range service.Slowest {
+			slowest[i] = formatHookExecution(exec)
+		}
+		services[service.ServiceName] = map[string]interface{}{
+			"units-running": service.UnitsRunning,
+			"queue-depth":   service.QueueDepth,
+			"running":       running,
+			"hooks":         hooks,
+			"slowest":       slowest,
+		}
+	}
+	return c.out.Write(ctx, map[string]interface{}{
+		"services": services,
+	})
+}
+
+// formatHookExecution returns a map describing the given hook execution
+// for output. The duration and exit code of running hooks are omitted.
+func formatHookExecution(exec params.UnitHookExecution) map[string]interface{} {
+	result := map[string]interface{}{
+		"unit":    exec.UnitName,
+		"hook":    exec.Hook,
+		"started": exec.Started.Format(time.RFC3339),
+	}
+	if exec.Duration > 0 {
+		result["duration"] = exec.Duration.String()
+		result["exit-code"] = exec.ExitCode
+	}
+	if exec.Error != "" {
+		result["error"] = exec.Error
+	}
+	return result
+}
@@ -0,0 +1,95 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+	"launchpad.net/goyaml"
+
+	"launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	coretesting "launchpad.net/juju-core/testing"
+)
+
+type HookStatsSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&HookStatsSuite{})
+
+func (s *HookStatsSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	svc := s.AddTestingService(c, "dummy-service", s.AddTestingCharm(c, "dummy"))
+	unit, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	for _, exec := range []state.HookExecution{{
+		Hook:     "install",
+		Started:  time.Unix(100, 0),
+		Finished: time.Unix(130, 0),
+	}, {
+		Hook:     "config-changed",
+		Started:  time.Unix(130, 0),
+		Finished: time.Unix(132, 0),
+		ExitCode: 1,
+		Error:    "exit status 1",
+	}, {
+		Hook:     "config-changed",
+		Started:  time.Unix(140, 0),
+		Finished: time.Unix(141, 0),
+	}, {
+		Hook:    "start",
+		Started: time.Unix(150, 0),
+		Queued:  2,
+	}} {
+		err := unit.RecordHookExecution(exec)
+		c.Assert(err, gc.IsNil)
+	}
+}
+
+func (s *HookStatsSuite) TestInitErrors(c *gc.C) {
+	err := coretesting.InitCommand(&HookStatsCommand{}, []string{"dummy/0"})
+	c.Assert(err, gc.ErrorMatches, `invalid service name "dummy/0"`)
+}
+
+func (s *HookStatsSuite) TestHookStats(c *gc.C) {
+	ctx, err := coretesting.RunCommand(c, &HookStatsCommand{}, []string{"dummy-service"})
+	c.Assert(err, gc.IsNil)
+	result := make(map[string]interface{})
+	err = goyaml.Unmarshal([]byte(coretesting.Stdout(ctx)), &result)
+	c.Assert(err, gc.IsNil)
+
+	services := result["services"].(map[interface{}]interface{})
+	c.Assert(services, gc.HasLen, 1)
+	stats := services["dummy-service"].(map[interface{}]interface{})
+	c.Assert(stats["units-running"], gc.Equals, 1)
+	c.Assert(stats["queue-depth"], gc.Equals, 2)
+	running := stats["running"].([]interface{})
+	c.Assert(running, gc.HasLen, 1)
+	c.Assert(running[0].(map[interface{}]interface{})["hook"], gc.Equals, "start")
+
+	hooks := stats["hooks"].(map[interface{}]interface{})
+	c.Assert(hooks["config-changed"], gc.DeepEquals, map[interface{}]interface{}{
+		"runs":          2,
+		"failures":      1,
+		"failure-rate":  "50%",
+		"mean-duration": "1.5s",
+		"max-duration":  "2s",
+	})
+	slowest := stats["slowest"].([]interface{})
+	c.Assert(slowest, gc.HasLen, 3)
+	first := slowest[0].(map[interface{}]interface{})
+	c.Assert(first["unit"], gc.Equals, "dummy-service/0")
+	c.Assert(first["hook"], gc.Equals, "install")
+	c.Assert(first["duration"], gc.Equals, "30s")
+	second := slowest[1].(map[interface{}]interface{})
+	c.Assert(second["exit-code"], gc.Equals, 1)
+	c.Assert(second["error"], gc.Equals, "exit status 1")
+}
+
+func (s *HookStatsSuite) TestUnknownService(c *gc.C) {
+	_, err := coretesting.RunCommand(c, &HookStatsCommand{}, []string{"unknown"})
+	c.Assert(err, gc.ErrorMatches, `service "unknown" not found`)
+}
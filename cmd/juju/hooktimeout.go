@@ -0,0 +1,85 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/names"
+)
+
+// SetHookTimeoutCommand sets the maximum time hooks run by the units
+// of a service may take.
+type SetHookTimeoutCommand struct {
+	cmd.EnvCommandBase
+	ServiceName string
+	Timeout     time.Duration
+}
+
+const setHookTimeoutDoc = `
+Charms may limit how long each of their hooks may run for with the
+hook-timeouts section of their metadata. A hook that runs for longer
+than its timeout is killed, along with any processes it started, and
+the unit is put into an error state; use "juju resolved --retry" to run
+the hook again.
+
+set-hook-timeout replaces the charm's timeouts for all hooks run by the
+units of a service with the given duration. A timeout of 0 restores the
+timeouts given by the charm.
+
+Examples:
+  juju set-hook-timeout mysql 10m
+  juju set-hook-timeout mysql 0
+
+See Also:
+  juju help resolved
+`
+
+func (c *SetHookTimeoutCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "set-hook-timeout",
+		Args:    "<service> <duration>",
+		Purpose: "set the maximum time a service's hooks may run for",
+		Doc:     setHookTimeoutDoc,
+	}
+}
+
+func (c *SetHookTimeoutCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+}
+
+func (c *SetHookTimeoutCommand) Init(args []string) (err error) {
+	switch len(args) {
+	case 0:
+		return errors.New("no service name specified")
+	case 1:
+		return errors.New("no timeout specified")
+	}
+	if !names.IsService(args[0]) {
+		return fmt.Errorf("invalid service name %q", args[0])
+	}
+	c.ServiceName = args[0]
+	if args[1] != "0" {
+		c.Timeout, err = time.ParseDuration(args[1])
+		if err != nil || c.Timeout <= 0 {
+			return fmt.Errorf("invalid timeout %q", args[1])
+		}
+	}
+	return cmd.CheckEmpty(args[2:])
+}
+
+func (c *SetHookTimeoutCommand) Run(_ *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.ServiceSetHookTimeout(c.ServiceName, c.Timeout)
+}
@@ -0,0 +1,70 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/juju/testing"
+	coretesting "launchpad.net/juju-core/testing"
+)
+
+type SetHookTimeoutSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&SetHookTimeoutSuite{})
+
+var setHookTimeoutInitErrorTests = []struct {
+	args []string
+	err  string
+}{{
+	args: nil,
+	err:  "no service name specified",
+}, {
+	args: []string{"mysql"},
+	err:  "no timeout specified",
+}, {
+	args: []string{"mysql/0", "1m"},
+	err:  `invalid service name "mysql/0"`,
+}, {
+	args: []string{"mysql", "soon"},
+	err:  `invalid timeout "soon"`,
+}, {
+	args: []string{"mysql", "-1m"},
+	err:  `invalid timeout "-1m"`,
+}, {
+	args: []string{"mysql", "1m", "extra"},
+	err:  `unrecognized args: \["extra"\]`,
+}}
+
+func (s *SetHookTimeoutSuite) TestInitErrors(c *gc.C) {
+	for i, t := range setHookTimeoutInitErrorTests {
+		c.Logf("test %d: %v", i, t.args)
+		err := coretesting.InitCommand(&SetHookTimeoutCommand{}, t.args)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *SetHookTimeoutSuite) TestSetHookTimeout(c *gc.C) {
+	svc := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	_, err := coretesting.RunCommand(c, &SetHookTimeoutCommand{}, []string{"mysql", "90s"})
+	c.Assert(err, gc.IsNil)
+	err = svc.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.HookTimeout(), gc.Equals, 90*time.Second)
+
+	_, err = coretesting.RunCommand(c, &SetHookTimeoutCommand{}, []string{"mysql", "0"})
+	c.Assert(err, gc.IsNil)
+	err = svc.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.HookTimeout(), gc.Equals, time.Duration(0))
+}
+
+func (s *SetHookTimeoutSuite) TestUnknownService(c *gc.C) {
+	_, err := coretesting.RunCommand(c, &SetHookTimeoutCommand{}, []string{"unknown", "1m"})
+	c.Assert(err, gc.ErrorMatches, `service "unknown" not found`)
+}
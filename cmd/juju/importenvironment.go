@@ -0,0 +1,197 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"launchpad.net/gnuflag"
+	"launchpad.net/goyaml"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/environs/configstore"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// ImportEnvironmentCommand recreates an exported environment as an
+// environment hosted by the state server of an existing environment,
+// and points the agents of the exported environment at it.
+type ImportEnvironmentCommand struct {
+	cmd.EnvCommandBase
+	modelFile string
+	source    string
+}
+
+const importEnvironmentDoc = `
+import-environment recreates the environment described by a model
+written by export-environment as an environment hosted by the state
+server of the current environment, keeping its name, its UUID, its
+users and the password hashes of its agents. The charms of the
+environment are copied from the storage of the source environment,
+the environment the model was exported from; it is named with
+--source and defaults to the name in the model.
+
+Once imported, the source environment is told to point its agents
+at the new state server. Each agent rewrites the API addresses and CA
+certificate in its agent.conf and reconnects; its machine, units and
+instance are kept. Agents on the source environment's state server
+machines are not repointed, and import fails if units are deployed
+to those machines.
+
+The environment information for the imported environment is written
+to $JUJU_HOME/environments/<name>.jenv with the admin credentials of
+the source environment, replacing that of the source environment if
+their names match. The source environment should then be left alone
+until its agents have moved, after which its state server can be
+taken down.
+
+Examples:
+  juju export-environment -e production -o production.yaml
+  juju import-environment -e controller production.yaml
+`
+
+func (c *ImportEnvironmentCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "import-environment",
+		Args:    "<model file>",
+		Purpose: "import an exported environment into the current environment's state server",
+		Doc:     importEnvironmentDoc,
+	}
+}
+
+func (c *ImportEnvironmentCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+	f.StringVar(&c.source, "source", "", "the environment the model was exported from")
+}
+
+func (c *ImportEnvironmentCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no model file specified")
+	}
+	c.modelFile, args = args[0], args[1:]
+	return cmd.CheckEmpty(args)
+}
+
+func (c *ImportEnvironmentCommand) Run(ctx *cmd.Context) (err error) {
+	data, err := ioutil.ReadFile(ctx.AbsPath(c.modelFile))
+	if err != nil {
+		return err
+	}
+	// JSON output of export-environment is valid YAML too.
+	var model params.EnvironmentModel
+	if err := goyaml.Unmarshal(data, &model); err != nil {
+		return fmt.Errorf("cannot parse model: %v", err)
+	}
+	if model.Name == "" {
+		return fmt.Errorf("no environment name found in model")
+	}
+	store, err := configstore.Default()
+	if err != nil {
+		return fmt.Errorf("cannot open environment info storage: %v", err)
+	}
+	envName, err := resolveEnvName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	sourceName := c.source
+	if sourceName == "" {
+		sourceName = model.Name
+	}
+	if sourceName == envName {
+		return fmt.Errorf("cannot import environment %q into its own state server", sourceName)
+	}
+	sourceInfo, err := store.ReadInfo(sourceName)
+	if err != nil {
+		return fmt.Errorf("cannot read information for source environment %q: %v", sourceName, err)
+	}
+	creds := sourceInfo.APICredentials()
+	if creds.Password == "" {
+		// Bootstrapped environments that have not been connected
+		// to yet only have the admin secret.
+		secret, _ := sourceInfo.BootstrapConfig()["admin-secret"].(string)
+		creds = configstore.APICredentials{
+			User:     "admin",
+			Password: secret,
+		}
+	}
+	if creds.Password == "" {
+		return fmt.Errorf("no admin credentials known for source environment %q", sourceName)
+	}
+	// The imported environment replaces the source environment
+	// under its name; otherwise its name must be free.
+	var info configstore.EnvironInfo
+	written := false
+	if model.Name != sourceName {
+		info, err = store.CreateInfo(model.Name)
+		if err == configstore.ErrEnvironInfoAlreadyExists {
+			return fmt.Errorf("environment %q already exists", model.Name)
+		} else if err != nil {
+			return err
+		}
+		defer func() {
+			if err == nil || written {
+				return
+			}
+			if err := info.Destroy(); err != nil {
+				logger.Warningf("cannot remove environment information for %q: %v", model.Name, err)
+			}
+		}()
+	}
+
+	sourceClient, err := juju.NewAPIClientFromName(sourceName)
+	if err != nil {
+		return err
+	}
+	defer sourceClient.Close()
+	client, err := juju.NewAPIClientFromName(envName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	// Connecting caches the state server's API endpoint, which the
+	// imported environment shares.
+	serverInfo, err := store.ReadInfo(envName)
+	if err != nil {
+		return err
+	}
+	endpoint := serverInfo.APIEndpoint()
+	if len(endpoint.Addresses) == 0 {
+		return fmt.Errorf("no API addresses known for environment %q", envName)
+	}
+	tag, err := client.ImportEnvironment(&model)
+	if err != nil {
+		return err
+	}
+	_, uuid, err := names.ParseTag(tag, names.EnvironTagKind)
+	if err != nil {
+		return err
+	}
+	if info == nil {
+		if err := sourceInfo.Destroy(); err != nil {
+			return err
+		}
+		if info, err = store.CreateInfo(model.Name); err != nil {
+			return err
+		}
+	}
+	endpoint.EnvironUUID = uuid
+	info.SetAPIEndpoint(endpoint)
+	info.SetAPICredentials(creds)
+	if err := info.Write(); err != nil {
+		logger.Errorf("environment %q was imported, but its information cannot be written", model.Name)
+		return err
+	}
+	written = true
+	fmt.Fprintf(ctx.Stderr, "imported environment %q\n", model.Name)
+
+	// The source environment's agents move only once the
+	// environment can be reached through the new state server.
+	if err := sourceClient.SetMigrationTarget(endpoint.Addresses, endpoint.CACert); err != nil {
+		return fmt.Errorf("cannot repoint agents of environment %q: %v", sourceName, err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	gc "launchpad.net/gocheck"
+	"launchpad.net/goyaml"
+
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	coretesting "launchpad.net/juju-core/testing"
+	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/utils"
+)
+
+type ImportEnvironmentSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&ImportEnvironmentSuite{})
+
+func (s *ImportEnvironmentSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	_, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	s.AddTestingService(c, "riak", s.AddTestingCharm(c, "riak"))
+}
+
+// exportModel exports the environment and writes it out renamed, with
+// a new UUID, so that it can be imported into its own state server.
+func (s *ImportEnvironmentSuite) exportModel(c *gc.C, name string) (*params.EnvironmentModel, string) {
+	ctx, err := coretesting.RunCommand(c, &ExportEnvironmentCommand{}, nil)
+	c.Assert(err, gc.IsNil)
+	var model params.EnvironmentModel
+	err = goyaml.Unmarshal([]byte(coretesting.Stdout(ctx)), &model)
+	c.Assert(err, gc.IsNil)
+	uuid, err := utils.NewUUID()
+	c.Assert(err, gc.IsNil)
+	model.Name = name
+	model.UUID = uuid.String()
+	model.Config["name"] = name
+	data, err := goyaml.Marshal(&model)
+	c.Assert(err, gc.IsNil)
+	path := filepath.Join(c.MkDir(), "model.yaml")
+	err = ioutil.WriteFile(path, data, 0600)
+	c.Assert(err, gc.IsNil)
+	return &model, path
+}
+
+func (s *ImportEnvironmentSuite) TestInitErrors(c *gc.C) {
+	err := coretesting.InitCommand(&ImportEnvironmentCommand{}, nil)
+	c.Assert(err, gc.ErrorMatches, "no model file specified")
+	err = coretesting.InitCommand(&ImportEnvironmentCommand{}, []string{"model.yaml", "extra"})
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["extra"\]`)
+}
+
+func (s *ImportEnvironmentSuite) TestImportEnvironment(c *gc.C) {
+	model, path := s.exportModel(c, "imported")
+
+	_, err := coretesting.RunCommand(c, &ImportEnvironmentCommand{}, []string{path, "--source", "dummyenv"})
+	c.Assert(err, gc.IsNil)
+
+	env, err := s.State.HostedEnvironment("imported")
+	c.Assert(err, gc.IsNil)
+	c.Assert(env.UUID(), gc.Equals, model.UUID)
+	serverInfo, err := s.ConfigStore.ReadInfo("dummyenv")
+	c.Assert(err, gc.IsNil)
+	info, err := s.ConfigStore.ReadInfo("imported")
+	c.Assert(err, gc.IsNil)
+	endpoint := info.APIEndpoint()
+	c.Assert(endpoint.Addresses, gc.DeepEquals, serverInfo.APIEndpoint().Addresses)
+	c.Assert(endpoint.EnvironUUID, gc.Equals, model.UUID)
+	c.Assert(info.APICredentials().User, gc.Equals, "admin")
+
+	// The imported environment can be used with the credentials
+	// of the environment it was exported from.
+	client, err := juju.NewAPIClientFromName("imported")
+	c.Assert(err, gc.IsNil)
+	defer client.Close()
+	status, err := client.Status(nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(status.Machines, gc.HasLen, 1)
+	c.Assert(status.Services, gc.HasLen, 1)
+
+	// The agents of the source environment are pointed at the
+	// state server.
+	source, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	addrs, caCert, ok := source.MigrationTarget()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(addrs, gc.DeepEquals, serverInfo.APIEndpoint().Addresses)
+	c.Assert(caCert, gc.Equals, serverInfo.APIEndpoint().CACert)
+}
+
+func (s *ImportEnvironmentSuite) TestImportEnvironmentIntoSource(c *gc.C) {
+	_, path := s.exportModel(c, "imported")
+	_, err := coretesting.RunCommand(c, &ImportEnvironmentCommand{}, []string{path, "--source", "dummyenv", "-e", "dummyenv"})
+	c.Assert(err, gc.ErrorMatches, `cannot import environment "dummyenv" into its own state server`)
+}
+
+func (s *ImportEnvironmentSuite) TestImportEnvironmentFails(c *gc.C) {
+	model, _ := s.exportModel(c, "imported")
+	model.Version = params.EnvironmentModelVersion + 1
+	data, err := goyaml.Marshal(model)
+	c.Assert(err, gc.IsNil)
+	path := filepath.Join(c.MkDir(), "model.yaml")
+	err = ioutil.WriteFile(path, data, 0600)
+	c.Assert(err, gc.IsNil)
+
+	_, err = coretesting.RunCommand(c, &ImportEnvironmentCommand{}, []string{path, "--source", "dummyenv"})
+	c.Assert(err, gc.ErrorMatches, `cannot import environment "imported": unsupported model version 2`)
+
+	// No environment information is left behind, and the source
+	// environment's agents stay where they are.
+	_, err = s.ConfigStore.ReadInfo("imported")
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+	source, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	_, _, ok := source.MigrationTarget()
+	c.Assert(ok, jc.IsFalse)
+}
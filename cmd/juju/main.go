@@ -69,10 +69,13 @@ func Main(args []string) {
 
 	// Creation commands.
 	jujucmd.Register(wrap(&BootstrapCommand{}))
+	jujucmd.Register(wrap(&CreateEnvironmentCommand{}))
+	jujucmd.Register(wrap(&ImportEnvironmentCommand{}))
 	jujucmd.Register(wrap(&AddMachineCommand{}))
 	jujucmd.Register(wrap(&DeployCommand{}))
 	jujucmd.Register(wrap(&AddRelationCommand{}))
 	jujucmd.Register(wrap(&AddUnitCommand{}))
+	jujucmd.Register(wrap(&MigrateUnitCommand{}))
 
 	// Destruction commands.
 	jujucmd.Register(wrap(&DestroyMachineCommand{}))
@@ -85,6 +88,9 @@ func Main(args []string) {
 	jujucmd.Register(wrap(&StatusCommand{}))
 	jujucmd.Register(wrap(&SwitchCommand{}))
 	jujucmd.Register(wrap(&EndpointCommand{}))
+	jujucmd.Register(wrap(&HookStatsCommand{}))
+	jujucmd.Register(wrap(&MetricsCommand{}))
+	jujucmd.Register(wrap(&RelationDataCommand{}))
 
 	// Error resolution and debugging commands.
 	jujucmd.Register(wrap(&RunCommand{}))
@@ -99,9 +105,14 @@ func Main(args []string) {
 	jujucmd.Register(wrap(&GetCommand{}))
 	jujucmd.Register(wrap(&SetCommand{}))
 	jujucmd.Register(wrap(&UnsetCommand{}))
+	jujucmd.Register(wrap(&ConfigHistoryCommand{}))
+	jujucmd.Register(wrap(&ConfigRollbackCommand{}))
 	jujucmd.Register(wrap(&GetConstraintsCommand{}))
 	jujucmd.Register(wrap(&SetConstraintsCommand{}))
+	jujucmd.Register(wrap(&SetHookTimeoutCommand{}))
+	jujucmd.Register(wrap(&MaintenanceCommand{}))
 	jujucmd.Register(wrap(&GetEnvironmentCommand{}))
+	jujucmd.Register(wrap(&ExportEnvironmentCommand{}))
 	jujucmd.Register(wrap(&SetEnvironmentCommand{}))
 	jujucmd.Register(wrap(&ExposeCommand{}))
 	jujucmd.Register(wrap(&SyncToolsCommand{}))
@@ -118,6 +129,9 @@ func Main(args []string) {
 	// Manage authorised ssh keys.
 	jujucmd.Register(wrap(NewAuthorisedKeysCommand()))
 
+	// Manage webhooks.
+	jujucmd.Register(wrap(NewWebhooksCommand()))
+
 	// Common commands.
 	jujucmd.Register(wrap(&cmd.VersionCommand{}))
 
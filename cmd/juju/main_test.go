@@ -215,6 +215,9 @@ var commandNames = []string{
 	"api-endpoints",
 	"authorised-keys",
 	"bootstrap",
+	"config-history",
+	"config-rollback",
+	"create-environment",
 	"debug-hooks",
 	"debug-log",
 	"deploy",
@@ -224,6 +227,7 @@ var commandNames = []string{
 	"destroy-service",
 	"destroy-unit",
 	"env", // alias for switch
+	"export-environment",
 	"expose",
 	"generate-config", // alias for init
 	"get",
@@ -232,8 +236,14 @@ var commandNames = []string{
 	"get-environment",
 	"help",
 	"help-tool",
+	"hook-stats",
+	"import-environment",
 	"init",
+	"maintenance",
+	"metrics",
+	"migrate-unit",
 	"publish",
+	"relation-data",
 	"remove-machine",  // alias for destroy-machine
 	"remove-relation", // alias for destroy-relation
 	"remove-service",  // alias for destroy-service
@@ -245,6 +255,7 @@ var commandNames = []string{
 	"set-constraints",
 	"set-env", // alias for set-environment
 	"set-environment",
+	"set-hook-timeout",
 	"ssh",
 	"stat", // alias for status
 	"status",
@@ -256,6 +267,7 @@ var commandNames = []string{
 	"upgrade-charm",
 	"upgrade-juju",
 	"version",
+	"webhooks",
 }
 
 func (s *MainSuite) TestHelpCommands(c *gc.C) {
@@ -0,0 +1,70 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/names"
+)
+
+// MaintenanceCommand puts a machine into maintenance mode,
+// or takes it out of maintenance mode.
+type MaintenanceCommand struct {
+	cmd.EnvCommandBase
+	MachineId   string
+	Maintenance bool
+}
+
+const maintenanceDoc = `
+A machine in maintenance mode is drained before it is patched: no new units
+are assigned to it, and no new containers are created on it, although those
+already there keep running. "juju status" marks machines in maintenance mode.
+The units on the machine run their maintenance-started hook when it is put
+into maintenance mode, and their maintenance-finished hook when it is taken
+out of it.
+`
+
+func (c *MaintenanceCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "maintenance",
+		Args:    "<machine> on|off",
+		Purpose: "put a machine into or out of maintenance mode",
+		Doc:     maintenanceDoc,
+	}
+}
+
+func (c *MaintenanceCommand) Init(args []string) error {
+	switch len(args) {
+	case 0:
+		return errors.New("no machine specified")
+	case 1:
+		return errors.New("no maintenance mode specified")
+	}
+	if !names.IsMachine(args[0]) {
+		return fmt.Errorf("invalid machine id %q", args[0])
+	}
+	c.MachineId = args[0]
+	switch args[1] {
+	case "on":
+		c.Maintenance = true
+	case "off":
+		c.Maintenance = false
+	default:
+		return fmt.Errorf(`invalid maintenance mode %q; expected "on" or "off"`, args[1])
+	}
+	return cmd.CheckEmpty(args[2:])
+}
+
+func (c *MaintenanceCommand) Run(_ *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.MachineSetMaintenance(c.MachineId, c.Maintenance)
+}
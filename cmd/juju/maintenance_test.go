@@ -0,0 +1,57 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	coretesting "launchpad.net/juju-core/testing"
+)
+
+type MaintenanceSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&MaintenanceSuite{})
+
+var maintenanceInitErrorTests = []struct {
+	args []string
+	err  string
+}{
+	{nil, "no machine specified"},
+	{[]string{"0"}, "no maintenance mode specified"},
+	{[]string{"foo", "on"}, `invalid machine id "foo"`},
+	{[]string{"0", "maybe"}, `invalid maintenance mode "maybe"; expected "on" or "off"`},
+	{[]string{"0", "on", "off"}, `unrecognized args: \["off"\]`},
+}
+
+func (s *MaintenanceSuite) TestInitErrors(c *gc.C) {
+	for i, t := range maintenanceInitErrorTests {
+		c.Logf("test %d: %v", i, t.args)
+		err := coretesting.InitCommand(&MaintenanceCommand{}, t.args)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *MaintenanceSuite) TestMaintenance(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	_, err = coretesting.RunCommand(c, &MaintenanceCommand{}, []string{"0", "on"})
+	c.Assert(err, gc.IsNil)
+	err = m.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(m.InMaintenance(), gc.Equals, true)
+
+	_, err = coretesting.RunCommand(c, &MaintenanceCommand{}, []string{"0", "off"})
+	c.Assert(err, gc.IsNil)
+	err = m.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(m.InMaintenance(), gc.Equals, false)
+
+	_, err = coretesting.RunCommand(c, &MaintenanceCommand{}, []string{"1", "on"})
+	c.Assert(err, gc.ErrorMatches, `machine 1 not found`)
+}
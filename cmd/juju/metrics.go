@@ -0,0 +1,113 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// MetricsCommand shows the metrics collected from the units
+// of a service.
+type MetricsCommand struct {
+	cmd.EnvCommandBase
+	ServiceName string
+	All         bool
+	out         cmd.Output
+}
+
+const metricsDoc = `
+Charms may declare metrics in a metrics.yaml file. The unit agent runs
+the charm's collect-metrics hook periodically, and the values the hook
+adds with the add-metric tool are sent to the state server.
+
+metrics shows the most recent value of each metric for each unit of the
+named service. With --all, every value collected is shown, oldest first.
+
+Examples:
+  juju metrics mysql
+  juju metrics --all mysql
+`
+
+func (c *MetricsCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "metrics",
+		Args:    "<service>",
+		Purpose: "show the metrics collected from a service's units",
+		Doc:     metricsDoc,
+	}
+}
+
+func (c *MetricsCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+	f.BoolVar(&c.All, "all", false, "show every collected value, not just the most recent")
+	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
+		"yaml": cmd.FormatYaml,
+		"json": cmd.FormatJson,
+	})
+}
+
+func (c *MetricsCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no service name specified")
+	}
+	if !names.IsService(args[0]) {
+		return fmt.Errorf("invalid service name %q", args[0])
+	}
+	c.ServiceName = args[0]
+	return cmd.CheckEmpty(args[1:])
+}
+
+// Run fetches and formats the metrics of the service.
+func (c *MetricsCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	batches, err := client.ServiceMetrics(c.ServiceName)
+	if err != nil {
+		return err
+	}
+	return c.out.Write(ctx, map[string]interface{}{
+		"metrics": formatMetrics(batches, c.All),
+	})
+}
+
+// formatMetrics returns the metrics in the given batches, which are
+// ordered oldest first, for output. Unless all is true, only the
+// most recent value of each metric for each unit is included.
+func formatMetrics(batches []params.UnitMetricBatch, all bool) []map[string]interface{} {
+	type unitMetric struct {
+		unit, key string
+	}
+	latest := make(map[unitMetric]int)
+	result := []map[string]interface{}{}
+	for _, batch := range batches {
+		for _, m := range batch.Metrics {
+			entry := map[string]interface{}{
+				"unit":  batch.UnitName,
+				"key":   m.Key,
+				"value": m.Value,
+				"time":  m.Time.Format(time.RFC3339),
+			}
+			id := unitMetric{batch.UnitName, m.Key}
+			if i, ok := latest[id]; ok && !all {
+				result[i] = entry
+				continue
+			}
+			latest[id] = len(result)
+			result = append(result, entry)
+		}
+	}
+	return result
+}
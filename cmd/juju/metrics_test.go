@@ -0,0 +1,90 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+	"launchpad.net/goyaml"
+
+	"launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	coretesting "launchpad.net/juju-core/testing"
+)
+
+type MetricsSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&MetricsSuite{})
+
+func (s *MetricsSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	ch := s.AddTestingCharm(c, "metered")
+	svc := s.AddTestingService(c, "metered", ch)
+	unit, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	for i, uuid := range []string{
+		"a3d5a9a8-3e8d-4b3c-8c1e-3cce5c0e0d6f",
+		"0f7e3ab6-4f5c-4d2e-9b7a-6a1c2d3e4f50",
+	} {
+		when := time.Unix(int64(100*(i+1)), 0)
+		_, err := unit.AddMetrics(uuid, when, ch.URL().String(), []state.Metric{
+			{Key: "users", Value: []string{"5", "7"}[i], Time: when},
+			{Key: "requests", Value: "42", Time: when},
+		})
+		c.Assert(err, gc.IsNil)
+	}
+}
+
+func metricEntry(key, value string, t int64) map[interface{}]interface{} {
+	return map[interface{}]interface{}{
+		"unit":  "metered/0",
+		"key":   key,
+		"value": value,
+		"time":  time.Unix(t, 0).Format(time.RFC3339),
+	}
+}
+
+func (s *MetricsSuite) runMetrics(c *gc.C, args ...string) []interface{} {
+	ctx, err := coretesting.RunCommand(c, &MetricsCommand{}, args)
+	c.Assert(err, gc.IsNil)
+	result := make(map[string]interface{})
+	err = goyaml.Unmarshal([]byte(coretesting.Stdout(ctx)), &result)
+	c.Assert(err, gc.IsNil)
+	return result["metrics"].([]interface{})
+}
+
+func (s *MetricsSuite) TestMetrics(c *gc.C) {
+	metrics := s.runMetrics(c, "metered")
+	c.Assert(metrics, gc.DeepEquals, []interface{}{
+		metricEntry("users", "7", 200),
+		metricEntry("requests", "42", 200),
+	})
+}
+
+func (s *MetricsSuite) TestMetricsAll(c *gc.C) {
+	metrics := s.runMetrics(c, "--all", "metered")
+	c.Assert(metrics, gc.DeepEquals, []interface{}{
+		metricEntry("users", "5", 100),
+		metricEntry("requests", "42", 100),
+		metricEntry("users", "7", 200),
+		metricEntry("requests", "42", 200),
+	})
+}
+
+func (s *MetricsSuite) TestInitErrors(c *gc.C) {
+	err := coretesting.InitCommand(&MetricsCommand{}, nil)
+	c.Assert(err, gc.ErrorMatches, "no service name specified")
+	err = coretesting.InitCommand(&MetricsCommand{}, []string{"metered/0"})
+	c.Assert(err, gc.ErrorMatches, `invalid service name "metered/0"`)
+	err = coretesting.InitCommand(&MetricsCommand{}, []string{"metered", "mysql"})
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["mysql"\]`)
+}
+
+func (s *MetricsSuite) TestUnknownService(c *gc.C) {
+	_, err := coretesting.RunCommand(c, &MetricsCommand{}, []string{"unknown"})
+	c.Assert(err, gc.ErrorMatches, `service "unknown" not found`)
+}
@@ -0,0 +1,84 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/names"
+)
+
+// MigrateUnitCommand moves a service unit to another machine.
+type MigrateUnitCommand struct {
+	cmd.EnvCommandBase
+	UnitName      string
+	ToMachineSpec string
+}
+
+const migrateUnitDoc = `
+Migrate-unit moves a unit of a service to another machine. A new unit of the
+service is added on the target machine and, when it joins the unit's relations,
+its settings start as a copy of those of the migrated unit.
+
+The charm moves its own data: the migrate-from hook runs on the migrated unit,
+which passes its data on with migration-set, and the migrate-to hook then runs
+on the new unit, which reads the data with migration-get. The migrated unit is
+then destroyed. Destroying the new unit before then abandons the migration.
+
+Examples:
+ juju migrate-unit mysql/0 --to 23       (Move mysql/0 to machine 23)
+ juju migrate-unit mysql/0 --to lxc:25   (Move mysql/0 to a new lxc container on machine 25)
+`
+
+func (c *MigrateUnitCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "migrate-unit",
+		Args:    "<unit> --to <machine>",
+		Purpose: "move a service unit to another machine",
+		Doc:     migrateUnitDoc,
+	}
+}
+
+func (c *MigrateUnitCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+	f.StringVar(&c.ToMachineSpec, "to", "", "the machine or container to move the unit to")
+}
+
+func (c *MigrateUnitCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no unit specified")
+	}
+	c.UnitName = args[0]
+	if !names.IsUnit(c.UnitName) {
+		return fmt.Errorf("invalid unit name %q", c.UnitName)
+	}
+	if c.ToMachineSpec == "" {
+		return errors.New("no machine specified; use --to")
+	}
+	if !cmd.IsMachineOrNewContainer(c.ToMachineSpec) {
+		return fmt.Errorf("invalid --to parameter %q", c.ToMachineSpec)
+	}
+	return cmd.CheckEmpty(args[1:])
+}
+
+// Run connects to the environment specified on the command line and
+// starts migrating the unit, reporting the unit that replaces it.
+func (c *MigrateUnitCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	target, err := client.MigrateUnit(c.UnitName, c.ToMachineSpec)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(ctx.Stdout, "migrating unit %s to unit %s\n", c.UnitName, target)
+	return nil
+}
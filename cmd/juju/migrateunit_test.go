@@ -0,0 +1,71 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/charm"
+	jujutesting "launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/testing"
+)
+
+type MigrateUnitSuite struct {
+	jujutesting.RepoSuite
+}
+
+var _ = gc.Suite(&MigrateUnitSuite{})
+
+var migrateUnitInitErrorTests = []struct {
+	args []string
+	err  string
+}{{
+	err: "no unit specified",
+}, {
+	args: []string{"mysql"},
+	err:  `invalid unit name "mysql"`,
+}, {
+	args: []string{"mysql/0"},
+	err:  "no machine specified; use --to",
+}, {
+	args: []string{"mysql/0", "--to", "bigglesplop"},
+	err:  `invalid --to parameter "bigglesplop"`,
+}, {
+	args: []string{"mysql/0", "--to", "1", "extra"},
+	err:  `unrecognized args: \["extra"\]`,
+}}
+
+func (s *MigrateUnitSuite) TestInitErrors(c *gc.C) {
+	for i, t := range migrateUnitInitErrorTests {
+		c.Logf("test %d", i)
+		err := testing.InitCommand(&MigrateUnitCommand{}, t.args)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *MigrateUnitSuite) TestMigrateUnit(c *gc.C) {
+	testing.Charms.BundlePath(s.SeriesPath, "dummy")
+	err := runDeploy(c, "local:dummy", "dummy")
+	c.Assert(err, gc.IsNil)
+	curl := charm.MustParseURL("local:precise/dummy-1")
+	s.AssertService(c, "dummy", curl, 1, 0)
+	m, err := s.State.AddMachine("precise", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	ctx, err := testing.RunCommand(c, &MigrateUnitCommand{}, []string{"dummy/0", "--to", m.Id()})
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, "migrating unit dummy/0 to unit dummy/1\n")
+
+	unit, err := s.State.Unit("dummy/0")
+	c.Assert(err, gc.IsNil)
+	to, ok := unit.MigratingTo()
+	c.Assert(ok, gc.Equals, true)
+	c.Assert(to, gc.Equals, "dummy/1")
+	target, err := s.State.Unit("dummy/1")
+	c.Assert(err, gc.IsNil)
+	machineId, err := target.AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machineId, gc.Equals, m.Id())
+}
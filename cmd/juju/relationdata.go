@@ -0,0 +1,102 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/juju"
+)
+
+// RelationDataCommand shows the settings of the units in relations.
+type RelationDataCommand struct {
+	cmd.EnvCommandBase
+	Relation string
+	out      cmd.Output
+}
+
+const relationDataDoc = `
+relation-data shows, for every unit on each side of a relation, the
+settings the unit has written to the relation, whether the unit is
+currently in the relation's scope, and the version of its settings.
+The data is read directly from state, so it reflects what the units'
+relation hooks will see.
+
+The relation may be given by its id, optionally prefixed by an endpoint
+name as printed by relation-ids (e.g. "db:2"), by a service endpoint
+(e.g. "mysql:server"), or by a service name, in which case every
+relation of the service is shown.
+
+Examples:
+  juju relation-data 2
+  juju relation-data mysql:server
+`
+
+func (c *RelationDataCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "relation-data",
+		Args:    "<relation-id|service:endpoint>",
+		Purpose: "show the settings of the units in a relation",
+		Doc:     relationDataDoc,
+	}
+}
+
+func (c *RelationDataCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
+		"yaml": cmd.FormatYaml,
+		"json": cmd.FormatJson,
+	})
+}
+
+func (c *RelationDataCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no relation specified")
+	}
+	c.Relation, args = args[0], args[1:]
+	return cmd.CheckEmpty(args)
+}
+
+// Run fetches and formats the unit data of the relations.
+func (c *RelationDataCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	results, err := client.RelationData(c.Relation)
+	if err != nil {
+		return err
+	}
+	relations := make([]map[string]interface{}, len(results))
+	for i, rel := range results {
+		endpoints := make(map[string]interface{})
+		for _, ep := range rel.Endpoints {
+			units := make(map[string]interface{})
+			for _, unit := range ep.Units {
+				units[unit.UnitName] = map[string]interface{}{
+					"in-scope": unit.InScope,
+					"settings": unit.Settings,
+					"version":  unit.Version,
+				}
+			}
+			endpoints[ep.ServiceName+":"+ep.Endpoint] = map[string]interface{}{
+				"role":  ep.Role,
+				"units": units,
+			}
+		}
+		relations[i] = map[string]interface{}{
+			"id":        rel.RelationId,
+			"key":       rel.Key,
+			"endpoints": endpoints,
+		}
+	}
+	return c.out.Write(ctx, map[string]interface{}{
+		"relations": relations,
+	})
+}
@@ -0,0 +1,83 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	gc "launchpad.net/gocheck"
+	"launchpad.net/goyaml"
+
+	"launchpad.net/juju-core/juju/testing"
+	coretesting "launchpad.net/juju-core/testing"
+)
+
+type RelationDataSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&RelationDataSuite{})
+
+func (s *RelationDataSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	eps, err := s.State.InferEndpoints([]string{"wordpress", "mysql"})
+	c.Assert(err, gc.IsNil)
+	rel, err := s.State.AddRelation(eps...)
+	c.Assert(err, gc.IsNil)
+	unit, err := mysql.AddUnit()
+	c.Assert(err, gc.IsNil)
+	ru, err := rel.Unit(unit)
+	c.Assert(err, gc.IsNil)
+	err = ru.EnterScope(map[string]interface{}{"user": "admin"})
+	c.Assert(err, gc.IsNil)
+}
+
+var relationDataInitErrorTests = []struct {
+	args []string
+	err  string
+}{{
+	args: nil,
+	err:  "no relation specified",
+}, {
+	args: []string{"0", "1"},
+	err:  `unrecognized args: \["1"\]`,
+}}
+
+func (s *RelationDataSuite) TestInitErrors(c *gc.C) {
+	for i, t := range relationDataInitErrorTests {
+		c.Logf("test %d: %v", i, t.args)
+		err := coretesting.InitCommand(&RelationDataCommand{}, t.args)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *RelationDataSuite) TestRelationData(c *gc.C) {
+	ctx, err := coretesting.RunCommand(c, &RelationDataCommand{}, []string{"mysql:server"})
+	c.Assert(err, gc.IsNil)
+	result := make(map[string]interface{})
+	err = goyaml.Unmarshal([]byte(coretesting.Stdout(ctx)), &result)
+	c.Assert(err, gc.IsNil)
+
+	relations := result["relations"].([]interface{})
+	c.Assert(relations, gc.HasLen, 1)
+	rel := relations[0].(map[interface{}]interface{})
+	c.Assert(rel["id"], gc.Equals, 0)
+	c.Assert(rel["key"], gc.Equals, "wordpress:db mysql:server")
+	endpoints := rel["endpoints"].(map[interface{}]interface{})
+	c.Assert(endpoints["wordpress:db"], gc.DeepEquals, map[interface{}]interface{}{
+		"role":  "requirer",
+		"units": map[interface{}]interface{}{},
+	})
+	server := endpoints["mysql:server"].(map[interface{}]interface{})
+	c.Assert(server["role"], gc.Equals, "provider")
+	unit := server["units"].(map[interface{}]interface{})["mysql/0"].(map[interface{}]interface{})
+	c.Assert(unit["in-scope"], gc.Equals, true)
+	c.Assert(unit["settings"], gc.DeepEquals, map[interface{}]interface{}{"user": "admin"})
+	c.Assert(unit["version"].(int) > 0, gc.Equals, true)
+}
+
+func (s *RelationDataSuite) TestUnknownRelation(c *gc.C) {
+	_, err := coretesting.RunCommand(c, &RelationDataCommand{}, []string{"42"})
+	c.Assert(err, gc.ErrorMatches, `relation 42 not found`)
+}
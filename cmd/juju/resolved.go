@@ -20,11 +20,18 @@ type ResolvedCommand struct {
 	Retry    bool
 }
 
+const resolvedDoc = `
+Mark the error state of a unit, whose hook failed or was killed for
+running longer than its timeout, as resolved. With --retry the hook is
+run again; otherwise the unit continues as if the hook had succeeded.
+`
+
 func (c *ResolvedCommand) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:    "resolved",
 		Args:    "<unit>",
 		Purpose: "marks unit errors resolved",
+		Doc:     resolvedDoc,
 	}
 }
 
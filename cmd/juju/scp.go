@@ -5,6 +5,8 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"launchpad.net/juju-core/cmd"
@@ -21,10 +23,15 @@ Launch an scp command to copy files. Each argument <file1> ... <file2>
 is either local file path or remote locations of the form <target>:<path>,
 where <target> can be either a machine id as listed by "juju status" in the
 "machines" section or a unit name as listed in the "services" section.
-Any extra arguments to scp can be passed after at the end. In case OpenSSH
-scp command cannot be found in the system PATH environment variable, this
-command is also not available for use. Please refer to the man page of scp(1)
-for the supported extra arguments.
+Any extra arguments to scp can be passed after at the end. Please refer
+to the man page of scp(1) for the supported extra arguments. In case the
+OpenSSH scp command cannot be found in the system PATH environment
+variable, an embedded client is used instead, which only supports copying
+between the local machine and remote locations, and only the -r, -p, -q
+and -v extra arguments.
+
+With --proxy, connections are tunnelled through the state server, for
+machines that cannot be reached directly.
 
 Examples:
 
@@ -45,6 +52,16 @@ server to the local directory remote-logs:
 Copy a local file to the second apache unit of the environment "testing":
 
     juju scp -e testing foo.txt apache2/1:
+
+Files can be copied to Windows machines, via WinRM as described in
+"juju help ssh", but only from the local machine, and without extra
+arguments. If several files are copied, the remote path must be an
+existing directory.
+
+Copy two local files to the directory C:\Juju\tmp on machine 4,
+running Windows:
+
+    juju scp a.txt b.txt 4:C:\Juju\tmp
 `
 
 func (c *SCPCommand) Info() *cmd.Info {
@@ -80,12 +97,26 @@ func (c *SCPCommand) Run(ctx *cmd.Context) error {
 	// and passing any others that look like extra arguments (starting
 	// with "-") verbatim to scp.
 	var targets, extraArgs []string
+	// windowsHosts holds the hosts of remote locations on
+	// Windows machines, indexed by their position in targets.
+	windowsHosts := make(map[int]string)
+	var remotes int
 	for i, arg := range c.Args {
 		if v := strings.SplitN(arg, ":", 2); len(v) > 1 {
 			host, err := c.hostFromTarget(v[0])
 			if err != nil {
 				return err
 			}
+			remotes++
+			windows, err := c.isWindowsTarget(v[0])
+			if err != nil {
+				return err
+			}
+			if windows {
+				windowsHosts[len(targets)] = host
+				targets = append(targets, v[1])
+				continue
+			}
 			// To ensure this works with IPv6 addresses, we need to
 			// wrap the host with \[..\], so the colons inside will be
 			// interpreted as part of the address and the last one as
@@ -106,5 +137,70 @@ func (c *SCPCommand) Run(ctx *cmd.Context) error {
 			targets = append(targets, arg)
 		}
 	}
-	return ssh.Copy(targets, extraArgs, nil)
+	if len(windowsHosts) > 0 {
+		if remotes > 1 {
+			return fmt.Errorf("cannot copy between remote locations involving Windows machines")
+		}
+		return c.copyToWindows(targets, extraArgs, windowsHosts)
+	}
+	var options ssh.Options
+	if err := c.setProxyHost(&options); err != nil {
+		return err
+	}
+	return ssh.Copy(targets, extraArgs, &options)
+}
+
+// copyToWindows copies the local files given by all but the last
+// target to the path given by the last one, on a Windows machine.
+func (c *SCPCommand) copyToWindows(targets, extraArgs []string, windowsHosts map[int]string) error {
+	last := len(targets) - 1
+	host, ok := windowsHosts[last]
+	if !ok {
+		return fmt.Errorf("cannot copy files from Windows machines")
+	}
+	if len(extraArgs) > 0 {
+		return fmt.Errorf("extra arguments are not supported when copying to Windows machines")
+	}
+	if c.proxy {
+		return fmt.Errorf("--proxy is not supported for Windows machines")
+	}
+	client, err := newWinRMClient(host)
+	if err != nil {
+		return err
+	}
+	sources, dest := targets[:last], targets[last]
+	for _, source := range sources {
+		remotePath := dest
+		if len(sources) > 1 || dest == "" || strings.HasSuffix(dest, `\`) || strings.HasSuffix(dest, "/") {
+			remotePath = windowsJoin(dest, filepath.Base(source))
+		}
+		if err := copyFileToWindows(client, source, remotePath); err != nil {
+			return fmt.Errorf("cannot copy %s: %v", source, err)
+		}
+	}
+	return nil
+}
+
+func copyFileToWindows(client winRMClient, source, remotePath string) error {
+	f, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err != nil {
+		return err
+	} else if info.IsDir() {
+		return fmt.Errorf("is a directory")
+	}
+	return client.Copy(f, remotePath)
+}
+
+// windowsJoin joins a file name to a Windows directory path.
+// An empty directory refers to the current directory.
+func windowsJoin(dir, name string) string {
+	dir = strings.TrimRight(dir, `\/`)
+	if dir == "" {
+		return name
+	}
+	return dir + `\` + name
 }
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/url"
+	"os"
 	"path/filepath"
 
 	gc "launchpad.net/gocheck"
@@ -71,6 +72,13 @@ var scpTests = []struct {
 		"",
 		`unexpected argument "-q"; extra arguments must be last`,
 	},
+	{
+		"scp from current dir to machine 0 through the state server",
+		[]string{"--proxy", "foo", "0:"},
+		commonArgs + "-o ProxyCommand ssh -q -o StrictHostKeyChecking=no ubuntu@dummyenv-0.dns nc -q0 %h %p " +
+			"foo ubuntu@dummyenv-0.dns:\n",
+		"",
+	},
 	{
 		"scp two local files to unit mysql/0",
 		[]string{"file1", "file2", "mysql/0:/foo/"},
@@ -140,3 +148,62 @@ func (s *SCPSuite) TestSCPCommand(c *gc.C) {
 		}
 	}
 }
+
+func (s *SCPSuite) TestSCPCommandWindows(c *gc.C) {
+	s.makeWindowsMachine(c)
+	client := s.patchWinRM()
+	dir := c.MkDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(name+" contents"), 0644)
+		c.Assert(err, gc.IsNil)
+	}
+	a, b := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")
+
+	_, err := coretesting.RunCommand(c, &SCPCommand{}, []string{a, `0:C:\Juju\a.txt`})
+	c.Assert(err, gc.IsNil)
+	_, err = coretesting.RunCommand(c, &SCPCommand{}, []string{a, b, `0:C:\Juju\tmp`})
+	c.Assert(err, gc.IsNil)
+	_, err = coretesting.RunCommand(c, &SCPCommand{}, []string{b, "0:"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(client.host, gc.Equals, "dummyenv-0.dns")
+	c.Assert(client.copies, gc.DeepEquals, map[string]string{
+		`C:\Juju\a.txt`:     "a.txt contents",
+		`C:\Juju\tmp\a.txt`: "a.txt contents",
+		`C:\Juju\tmp\b.txt`: "b.txt contents",
+		`b.txt`:             "b.txt contents",
+	})
+}
+
+func (s *SCPSuite) TestSCPCommandWindowsErrors(c *gc.C) {
+	s.makeMachines(1, c, true)
+	s.makeWindowsMachine(c)
+	client := s.patchWinRM()
+	file := filepath.Join(c.MkDir(), "file")
+	err := ioutil.WriteFile(file, nil, 0644)
+	c.Assert(err, gc.IsNil)
+
+	for i, t := range []struct {
+		args []string
+		err  string
+	}{{
+		[]string{"1:foo", "."},
+		"cannot copy files from Windows machines",
+	}, {
+		[]string{"0:foo", "1:"},
+		"cannot copy between remote locations involving Windows machines",
+	}, {
+		[]string{file, "1:", "-r"},
+		"extra arguments are not supported when copying to Windows machines",
+	}, {
+		[]string{"--proxy", file, "1:"},
+		"--proxy is not supported for Windows machines",
+	}, {
+		[]string{os.TempDir(), "1:"},
+		"cannot copy .*: is a directory",
+	}} {
+		c.Logf("test %d: %v", i, t.args)
+		_, err := coretesting.RunCommand(c, &SCPCommand{}, t.args)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+	c.Assert(client.copies, gc.HasLen, 0)
+}
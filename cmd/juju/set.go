@@ -12,6 +12,8 @@ import (
 
 	"launchpad.net/juju-core/cmd"
 	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/state/api"
 	"launchpad.net/juju-core/state/api/params"
 )
 
@@ -19,6 +21,7 @@ import (
 type SetCommand struct {
 	cmd.EnvCommandBase
 	ServiceName     string
+	ServiceNames    []string
 	SettingsStrings map[string]string
 	SettingsYAML    cmd.FileVar
 }
@@ -27,14 +30,19 @@ const setDoc = `
 Set one or more configuration options for the specified service. See also the
 unset command which sets one or more configuration options for a specified
 service to their default value. 
+
+The same options may be set on several services at once by giving a
+comma-separated list of services, as in
+
+    juju set wordpress,wordpress-staging debug=yes
 `
 
 func (c *SetCommand) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:    "set",
-		Args:    "<service> name=value ...",
+		Args:    "<service>[,<service>...] name=value ...",
 		Purpose: "set service config options",
-		Doc:     "Set one or more configuration options for the specified service.",
+		Doc:     setDoc,
 	}
 }
 
@@ -50,7 +58,16 @@ func (c *SetCommand) Init(args []string) error {
 	if c.SettingsYAML.Path != "" && len(args) > 1 {
 		return errors.New("cannot specify --config when using key=value arguments")
 	}
-	c.ServiceName = args[0]
+	c.ServiceNames = strings.Split(args[0], ",")
+	for _, name := range c.ServiceNames {
+		if !names.IsService(name) {
+			return fmt.Errorf("invalid service name %q", name)
+		}
+	}
+	if c.SettingsYAML.Path != "" && len(c.ServiceNames) > 1 {
+		return errors.New("cannot specify --config when setting several services")
+	}
+	c.ServiceName = c.ServiceNames[0]
 	settings, err := parse(args[1:])
 	if err != nil {
 		return err
@@ -104,6 +121,9 @@ func (c *SetCommand) Run(ctx *cmd.Context) error {
 	} else if len(c.SettingsStrings) == 0 {
 		return nil
 	}
+	if len(c.ServiceNames) > 1 {
+		return c.servicesSet(ctx, api)
+	}
 	err = api.ServiceSet(c.ServiceName, c.SettingsStrings)
 	if params.IsCodeNotImplemented(err) {
 		logger.Infof("NewServiceSetForClientAPI not supported by the API server, " +
@@ -113,6 +133,38 @@ func (c *SetCommand) Run(ctx *cmd.Context) error {
 	return err
 }
 
+// servicesSet sets the options on each of several services, reporting
+// the services that could not be updated.
+func (c *SetCommand) servicesSet(ctx *cmd.Context, client *api.Client) error {
+	args := make([]params.ServiceSet, len(c.ServiceNames))
+	for i, name := range c.ServiceNames {
+		args[i] = params.ServiceSet{ServiceName: name, Options: c.SettingsStrings}
+	}
+	results, err := client.ServicesSet(args)
+	if params.IsCodeNotImplemented(err) {
+		logger.Infof("ServicesSet not supported by the API server, setting services one at a time")
+		results = make([]params.ErrorResult, len(c.ServiceNames))
+		for i, name := range c.ServiceNames {
+			if err := client.ServiceSet(name, c.SettingsStrings); err != nil {
+				results[i].Error = &params.Error{Message: err.Error()}
+			}
+		}
+	} else if err != nil {
+		return err
+	}
+	failed := false
+	for i, result := range results {
+		if result.Error != nil {
+			fmt.Fprintf(ctx.Stderr, "cannot set options for service %q: %v\n", c.ServiceNames[i], result.Error)
+			failed = true
+		}
+	}
+	if failed {
+		return cmd.ErrSilent
+	}
+	return nil
+}
+
 // parse parses the option k=v strings into a map of options to be
 // updated in the config. Keys with empty values are returned separately
 // and should be removed.
@@ -70,6 +70,35 @@ func (s *SetSuite) TestSetConfig(c *gc.C) {
 	})
 }
 
+func (s *SetSuite) TestSetSeveralServices(c *gc.C) {
+	other := s.AddTestingService(c, "other-service", s.AddTestingCharm(c, "dummy"))
+	ctx := coretesting.ContextForDir(c, s.dir)
+	code := cmd.Main(&SetCommand{}, ctx, []string{"dummy-service,other-service", "username=hello"})
+	c.Assert(code, gc.Equals, 0)
+	for _, svc := range []*state.Service{s.svc, other} {
+		settings, err := svc.ConfigSettings()
+		c.Assert(err, gc.IsNil)
+		c.Assert(settings, gc.DeepEquals, charm.Settings{"username": "hello"})
+	}
+}
+
+func (s *SetSuite) TestSetSeveralServicesReportsErrors(c *gc.C) {
+	ctx := coretesting.ContextForDir(c, s.dir)
+	code := cmd.Main(&SetCommand{}, ctx, []string{"dummy-service,missing", "username=hello"})
+	c.Assert(code, gc.Equals, 1)
+	c.Assert(ctx.Stderr.(*bytes.Buffer).String(), gc.Matches, `cannot set options for service "missing": .*not found\n`)
+	settings, err := s.svc.ConfigSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, gc.DeepEquals, charm.Settings{"username": "hello"})
+}
+
+func (s *SetSuite) TestSetSeveralServicesInitErrors(c *gc.C) {
+	err := coretesting.InitCommand(&SetCommand{}, []string{"dummy-service,bad-1", "username=hello"})
+	c.Assert(err, gc.ErrorMatches, `invalid service name "bad-1"`)
+	err = coretesting.InitCommand(&SetCommand{}, []string{"--config", "testconfig.yaml", "dummy-service,other-service"})
+	c.Assert(err, gc.ErrorMatches, "cannot specify --config when setting several services")
+}
+
 // assertSetSuccess sets configuration options and checks the expected settings.
 func assertSetSuccess(c *gc.C, dir string, svc *state.Service, args []string, expect charm.Settings) {
 	ctx := coretesting.ContextForDir(c, dir)
@@ -6,29 +6,41 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"time"
 
+	"launchpad.net/gnuflag"
+
 	"launchpad.net/juju-core/cmd"
 	"launchpad.net/juju-core/instance"
 	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/juju/osenv"
 	"launchpad.net/juju-core/names"
 	"launchpad.net/juju-core/state/api"
 	"launchpad.net/juju-core/state/api/params"
 	"launchpad.net/juju-core/utils"
 	"launchpad.net/juju-core/utils/ssh"
+	"launchpad.net/juju-core/utils/winrm"
+	"launchpad.net/juju-core/version"
 )
 
 // SSHCommand is responsible for launching a ssh shell on a given unit or machine.
 type SSHCommand struct {
 	SSHCommon
+	forwardAgent bool
 }
 
 // SSHCommon provides common methods for SSHCommand, SCPCommand and DebugHooksCommand.
 type SSHCommon struct {
 	cmd.EnvCommandBase
-	Target    string
-	Args      []string
-	apiClient *api.Client
+	Target string
+	Args   []string
+	// windowsArgs, if set, is run instead of Args
+	// when the target is a Windows machine.
+	windowsArgs []string
+	proxy       bool
+	apiClient   *api.Client
 	// Only used for compatibility with 1.16
 	rawConn *juju.Conn
 }
@@ -38,6 +50,13 @@ Launch an ssh shell on the machine identified by the <target> parameter.
 <target> can be either a machine id  as listed by "juju status" in the
 "machines" section or a unit name as listed in the "services" section.
 Any extra parameters are passsed as extra parameters to the ssh command.
+With --proxy, the connection is tunnelled through the state server, for
+machines that cannot be reached directly. With --forward-agent, the
+ssh-agent on the local machine is made available on the remote one.
+
+The ssh connection is made with an ssh client built into juju, so
+no ssh binary needs to be installed. To use the OpenSSH client in
+$PATH instead, set $JUJU_SSH_CLIENT to "openssh".
 
 Examples:
 
@@ -56,6 +75,23 @@ Connect to the first mysql unit:
 Connect to the first mysql unit and run 'ls -la /var/log/juju':
 
     juju ssh mysql/0 ls -la /var/log/juju
+
+Connect to machine 3 through the state server:
+
+    juju ssh --proxy 3
+
+Windows machines are managed with WinRM rather than ssh, so a command
+must be given, which is run with PowerShell. The connection is
+authenticated with the client certificate in $JUJU_HOME/winrm, which
+is generated if necessary, and which must be mapped to a user on the
+machine. If $JUJU_HOME/winrm/ca.crt exists, it is used to verify the
+machine's certificate; otherwise the certificate is recorded in
+$JUJU_HOME/winrm/known_hosts on first connection, and connections fail
+if it changes.
+
+Run 'Get-Service jujud-*' on machine 4, running Windows:
+
+    juju ssh 4 Get-Service jujud-*
 `
 
 func (c *SSHCommand) Info() *cmd.Info {
@@ -67,6 +103,11 @@ func (c *SSHCommand) Info() *cmd.Info {
 	}
 }
 
+func (c *SSHCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.SSHCommon.SetFlags(f)
+	f.BoolVar(&c.forwardAgent, "forward-agent", false, "forward the local ssh-agent to the remote machine")
+}
+
 func (c *SSHCommand) Init(args []string) error {
 	if len(args) == 0 {
 		return errors.New("no target name specified")
@@ -90,8 +131,21 @@ func (c *SSHCommand) Run(ctx *cmd.Context) error {
 	if err != nil {
 		return err
 	}
+	windows, err := c.isWindowsTarget(c.Target)
+	if err != nil {
+		return err
+	}
+	if windows {
+		return c.runWindows(ctx, host)
+	}
 	var options ssh.Options
 	options.EnablePTY()
+	if c.forwardAgent {
+		options.EnableAgentForwarding()
+	}
+	if err := c.setProxyHost(&options); err != nil {
+		return err
+	}
 	cmd := ssh.Command("ubuntu@"+host, c.Args, &options)
 	cmd.Stdin = ctx.Stdin
 	cmd.Stdout = ctx.Stdout
@@ -99,6 +153,108 @@ func (c *SSHCommand) Run(ctx *cmd.Context) error {
 	return cmd.Run()
 }
 
+// runWindows runs the command given in c.Args on
+// a Windows machine with PowerShell, via WinRM.
+func (c *SSHCommand) runWindows(ctx *cmd.Context, host string) error {
+	args := c.Args
+	if c.windowsArgs != nil {
+		args = c.windowsArgs
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("cannot open a shell on Windows machine %q: no command specified", c.Target)
+	}
+	if c.proxy {
+		return fmt.Errorf("--proxy is not supported for Windows machines")
+	}
+	if c.forwardAgent {
+		return fmt.Errorf("--forward-agent is not supported for Windows machines")
+	}
+	client, err := newWinRMClient(host)
+	if err != nil {
+		return err
+	}
+	code, err := client.RunPowerShell(strings.Join(args, " "), nil, ctx.Stdout, ctx.Stderr)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return cmd.NewRcPassthroughError(code)
+	}
+	return nil
+}
+
+// setWindowsArgs sets the command to run instead of
+// Args if the target turns out to be a Windows machine.
+func (c *SSHCommon) setWindowsArgs(args []string) {
+	c.windowsArgs = args
+}
+
+func (c *SSHCommon) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+	f.BoolVar(&c.proxy, "proxy", false, "proxy through the state server")
+}
+
+// setProxyHost configures options to tunnel connections
+// through the state server if --proxy was specified.
+func (c *SSHCommon) setProxyHost(options *ssh.Options) error {
+	if !c.proxy {
+		return nil
+	}
+	host, err := c.hostFromTarget("0")
+	if err != nil {
+		return fmt.Errorf("cannot resolve state server address: %v", err)
+	}
+	options.SetProxyHost("ubuntu@" + host)
+	return nil
+}
+
+// winRMClient holds the methods of winrm.Client
+// used to manage Windows machines.
+type winRMClient interface {
+	RunPowerShell(script string, stdin io.Reader, stdout, stderr io.Writer) (int, error)
+	Copy(r io.Reader, remotePath string) error
+}
+
+// newWinRMClient returns a client connected to the WinRM service of
+// the given host, authenticated with the client certificate in
+// $JUJU_HOME/winrm; it may be replaced in tests.
+var newWinRMClient = func(host string) (winRMClient, error) {
+	clientCert, err := winrm.LoadClientCert(osenv.JujuHomePath("winrm"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot load WinRM client certificate: %v", err)
+	}
+	client, err := winrm.NewClient(clientCert.Params(host))
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// isWindowsTarget reports whether the machine identified by
+// target, or the machine hosting the unit it identifies, runs
+// Windows, and so must be managed with WinRM rather than ssh.
+func (c *SSHCommon) isWindowsTarget(target string) (bool, error) {
+	status, err := c.apiClient.Status(nil)
+	if params.IsCodeNotImplemented(err) {
+		// Older API servers cannot manage Windows machines.
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	machineId := target
+	if names.IsUnit(target) {
+		unit, ok := status.Services[names.UnitService(target)].Units[target]
+		if !ok {
+			return false, fmt.Errorf("unit %q not found", target)
+		}
+		machineId = unit.Machine
+	}
+	// Containers are not listed at the top level, but
+	// they never run Windows.
+	m, ok := status.Machines[machineId]
+	return ok && version.IsWindowsSeries(m.Series), nil
+}
+
 // initAPIClient initialises the API connection.
 // It is the caller's responsibility to close the connection.
 func (c *SSHCommon) initAPIClient() (*api.Client, error) {
@@ -6,6 +6,8 @@ package main
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -18,6 +20,7 @@ import (
 	"launchpad.net/juju-core/juju/testing"
 	"launchpad.net/juju-core/state"
 	coretesting "launchpad.net/juju-core/testing"
+	sshtesting "launchpad.net/juju-core/utils/ssh/testing"
 )
 
 var _ = gc.Suite(&SSHSuite{})
@@ -50,6 +53,8 @@ func (s *SSHCommonSuite) SetUpTest(c *gc.C) {
 		err = f.Close()
 		c.Assert(err, gc.IsNil)
 	}
+	restore := sshtesting.PatchOpenSSHClient(c)
+	s.AddCleanup(func(*gc.C) { restore() })
 }
 
 const (
@@ -82,6 +87,17 @@ var sshTests = []struct {
 		[]string{"ssh", "mongodb/1", "ls", "/"},
 		sshArgs + "ubuntu@dummyenv-2.dns ls /\n",
 	},
+	{
+		"connect to unit mongodb/1 through the state server",
+		[]string{"ssh", "--proxy", "mongodb/1"},
+		commonArgs + "-o ProxyCommand ssh -q -o StrictHostKeyChecking=no ubuntu@dummyenv-0.dns nc -q0 %h %p " +
+			"-t -t ubuntu@dummyenv-2.dns\n",
+	},
+	{
+		"connect to unit mysql/0 forwarding the ssh-agent",
+		[]string{"ssh", "--forward-agent", "mysql/0"},
+		sshArgs + "-A ubuntu@dummyenv-0.dns\n",
+	},
 }
 
 func (s *SSHSuite) TestSSHCommand(c *gc.C) {
@@ -199,3 +215,87 @@ func (s *SSHCommonSuite) addUnit(srv *state.Service, m *state.Machine, c *gc.C)
 	err = u.SetPublicAddress(addr)
 	c.Assert(err, gc.IsNil)
 }
+
+// fakeWinRMClient records the scripts run and
+// the files copied through it.
+type fakeWinRMClient struct {
+	host    string
+	scripts []string
+	copies  map[string]string
+	output  string
+	code    int
+}
+
+func (f *fakeWinRMClient) RunPowerShell(script string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	f.scripts = append(f.scripts, script)
+	fmt.Fprint(stdout, f.output)
+	return f.code, nil
+}
+
+func (f *fakeWinRMClient) Copy(r io.Reader, remotePath string) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	f.copies[remotePath] = string(data)
+	return nil
+}
+
+func (s *SSHCommonSuite) patchWinRM() *fakeWinRMClient {
+	client := &fakeWinRMClient{copies: make(map[string]string)}
+	s.PatchValue(&newWinRMClient, func(host string) (winRMClient, error) {
+		client.host = host
+		return client, nil
+	})
+	return client
+}
+
+func (s *SSHCommonSuite) makeWindowsMachine(c *gc.C) *state.Machine {
+	m, err := s.State.AddMachine("win2012r2", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	s.setAddress(m, c)
+	return m
+}
+
+func (s *SSHSuite) TestSSHCommandWindows(c *gc.C) {
+	s.makeWindowsMachine(c)
+	client := s.patchWinRM()
+	client.output = "Running  jujud-machine-0\n"
+
+	ctx, err := coretesting.RunCommand(c, &SSHCommand{}, []string{"0", "Get-Service", "jujud-*"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(coretesting.Stdout(ctx), gc.Equals, "Running  jujud-machine-0\n")
+	c.Assert(client.host, gc.Equals, "dummyenv-0.dns")
+	c.Assert(client.scripts, gc.DeepEquals, []string{"Get-Service jujud-*"})
+
+	// The exit code of the command is passed through.
+	client.code = 3
+	ctx = coretesting.Context(c)
+	jujucmd := cmd.NewSuperCommand(cmd.SuperCommandParams{})
+	jujucmd.Register(&SSHCommand{})
+	code := cmd.Main(jujucmd, ctx, []string{"ssh", "0", "exit", "3"})
+	c.Assert(code, gc.Equals, 3)
+}
+
+func (s *SSHSuite) TestSSHCommandWindowsErrors(c *gc.C) {
+	s.makeWindowsMachine(c)
+	client := s.patchWinRM()
+	_, err := coretesting.RunCommand(c, &SSHCommand{}, []string{"0"})
+	c.Assert(err, gc.ErrorMatches, `cannot open a shell on Windows machine "0": no command specified`)
+	_, err = coretesting.RunCommand(c, &SSHCommand{}, []string{"--proxy", "0", "hostname"})
+	c.Assert(err, gc.ErrorMatches, "--proxy is not supported for Windows machines")
+	_, err = coretesting.RunCommand(c, &SSHCommand{}, []string{"--forward-agent", "0", "hostname"})
+	c.Assert(err, gc.ErrorMatches, "--forward-agent is not supported for Windows machines")
+	c.Assert(client.scripts, gc.HasLen, 0)
+}
+
+func (s *SSHSuite) TestDebugLogWindows(c *gc.C) {
+	s.makeWindowsMachine(c)
+	client := s.patchWinRM()
+	_, err := coretesting.RunCommand(c, &DebugLogCommand{sshCmd: &SSHCommand{}}, []string{"--machine", "0"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(client.host, gc.Equals, "dummyenv-0.dns")
+	c.Assert(client.scripts, gc.DeepEquals, []string{
+		`Get-Content -Path 'C:\Juju\log\machine-0.log' -Tail 10 -Wait`,
+	})
+}
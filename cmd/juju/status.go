@@ -127,6 +127,7 @@ type machineStatus struct {
 	Id             string                   `json:"-" yaml:"-"`
 	Containers     map[string]machineStatus `json:"containers,omitempty" yaml:"containers,omitempty"`
 	Hardware       string                   `json:"hardware,omitempty" yaml:"hardware,omitempty"`
+	Maintenance    bool                     `json:"maintenance,omitempty" yaml:"maintenance,omitempty"`
 }
 
 // A goyaml bug means we can't declare these types
@@ -242,6 +243,7 @@ func formatMachine(machine api.MachineStatus) machineStatus {
 		Id:             machine.Id,
 		Containers:     make(map[string]machineStatus),
 		Hardware:       machine.Hardware,
+		Maintenance:    machine.Maintenance,
 	}
 	for k, m := range machine.Containers {
 		out.Containers[k] = formatMachine(m)
@@ -0,0 +1,70 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/state/api"
+	"launchpad.net/juju-core/utils/webhook"
+)
+
+var webhooksDoc = `
+"juju webhooks" is used to manage the webhooks that are notified when
+the status of machines and units in the Juju environment changes.
+
+Events are sent as JSON in an HTTP POST request. If the webhook has a
+secret, the request has an X-Juju-Signature header holding "sha256="
+followed by the hex-encoded HMAC-SHA256 of the request body, keyed with
+the secret.
+
+Webhooks are held in the "webhooks" environment setting. Their secrets
+are redacted when the environment settings are shown, and redacted
+secrets are kept when the setting is changed.
+`
+
+type WebhooksCommand struct {
+	*cmd.SuperCommand
+}
+
+func NewWebhooksCommand() cmd.Command {
+	webhookscmd := &WebhooksCommand{
+		SuperCommand: cmd.NewSuperCommand(cmd.SuperCommandParams{
+			Name:        "webhooks",
+			Doc:         webhooksDoc,
+			UsagePrefix: "juju",
+			Purpose:     "manage webhooks notified of status changes",
+		}),
+	}
+	webhookscmd.Register(&AddWebhookCommand{})
+	webhookscmd.Register(&ListWebhooksCommand{})
+	webhookscmd.Register(&RemoveWebhooksCommand{})
+	webhookscmd.Register(&TestWebhookCommand{})
+	return webhookscmd
+}
+
+func (c *WebhooksCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.SetCommonFlags(f)
+}
+
+// environWebhooks returns the webhooks in the environment configuration.
+func environWebhooks(client *api.Client) ([]webhook.Hook, error) {
+	attrs, err := client.EnvironmentGet()
+	if err != nil {
+		return nil, err
+	}
+	hooks, _ := attrs["webhooks"].(string)
+	return webhook.Parse(hooks)
+}
+
+// setEnvironWebhooks replaces the webhooks in the environment
+// configuration with the given ones.
+func setEnvironWebhooks(client *api.Client, hooks []webhook.Hook) error {
+	attr, err := webhook.Format(hooks)
+	if err != nil {
+		return err
+	}
+	return client.EnvironmentSet(map[string]interface{}{"webhooks": attr})
+}
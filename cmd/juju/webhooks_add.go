@@ -0,0 +1,103 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/utils"
+	"launchpad.net/juju-core/utils/webhook"
+)
+
+var addWebhookDoc = `
+Add a webhook that is sent an event whenever the status of a machine
+or unit changes to one matching its rules.
+
+Each rule has the form <kind>[:<status>], where kind is "machine" or
+"unit" and either part may be "*" to match anything. By default, events
+are sent when a machine or unit changes to the "error" status.
+
+If no secret is given, one is generated and printed. Events sent to
+the webhook are signed with the secret.
+
+Example:
+
+  juju webhooks add ops https://ops.example.com/juju --on unit:error,machine
+`
+
+var defaultWebhookRules = []string{"machine:error", "unit:error"}
+
+// AddWebhookCommand is used to add a webhook.
+type AddWebhookCommand struct {
+	cmd.EnvCommandBase
+	hook   webhook.Hook
+	rules  []string
+	secret string
+}
+
+func (c *AddWebhookCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "add",
+		Args:    "<name> <url>",
+		Doc:     addWebhookDoc,
+		Purpose: "add a webhook",
+	}
+}
+
+func (c *AddWebhookCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+	f.Var(cmd.NewStringsValue(defaultWebhookRules, &c.rules), "on", "comma-separated rules selecting the events to send")
+	f.StringVar(&c.secret, "secret", "", "the secret used to sign events")
+}
+
+func (c *AddWebhookCommand) Init(args []string) error {
+	switch len(args) {
+	case 0:
+		return fmt.Errorf("no webhook name specified")
+	case 1:
+		return fmt.Errorf("no webhook URL specified")
+	}
+	c.hook.Name, c.hook.URL = args[0], args[1]
+	for _, s := range c.rules {
+		rule, err := webhook.ParseRule(s)
+		if err != nil {
+			return err
+		}
+		c.hook.Rules = append(c.hook.Rules, rule)
+	}
+	if err := c.hook.Validate(); err != nil {
+		return err
+	}
+	return cmd.CheckEmpty(args[2:])
+}
+
+func (c *AddWebhookCommand) Run(context *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	hooks, err := environWebhooks(client)
+	if err != nil {
+		return err
+	}
+	for _, hook := range hooks {
+		if hook.Name == c.hook.Name {
+			return fmt.Errorf("webhook %q already exists", c.hook.Name)
+		}
+	}
+	c.hook.Secret = c.secret
+	if c.hook.Secret == "" {
+		if c.hook.Secret, err = utils.RandomPassword(); err != nil {
+			return err
+		}
+		fmt.Fprintf(context.Stdout, "secret: %s\n", c.hook.Secret)
+	}
+	return setEnvironWebhooks(client, append(hooks, c.hook))
+}
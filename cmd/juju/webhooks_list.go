@@ -0,0 +1,68 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/juju"
+)
+
+var listWebhooksDoc = `
+List the webhooks in the environment, with their URLs and rules.
+Secrets are not shown.
+`
+
+// ListWebhooksCommand is used to list the webhooks.
+type ListWebhooksCommand struct {
+	cmd.EnvCommandBase
+	out cmd.Output
+}
+
+// webhookInfo holds the information about a
+// webhook printed by ListWebhooksCommand.
+type webhookInfo struct {
+	URL    string   `yaml:"url" json:"url"`
+	Rules  []string `yaml:"rules,omitempty" json:"rules,omitempty"`
+	Signed bool     `yaml:"signed" json:"signed"`
+}
+
+func (c *ListWebhooksCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "list",
+		Doc:     listWebhooksDoc,
+		Purpose: "list webhooks",
+	}
+}
+
+func (c *ListWebhooksCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+	c.out.AddFlags(f, "yaml", cmd.DefaultFormatters)
+}
+
+func (c *ListWebhooksCommand) Run(context *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	hooks, err := environWebhooks(client)
+	if err != nil {
+		return err
+	}
+	result := make(map[string]webhookInfo)
+	for _, hook := range hooks {
+		info := webhookInfo{
+			URL:    hook.URL,
+			Signed: hook.Secret != "",
+		}
+		for _, rule := range hook.Rules {
+			info.Rules = append(info.Rules, rule.String())
+		}
+		result[hook.Name] = info
+	}
+	return c.out.Write(context, result)
+}
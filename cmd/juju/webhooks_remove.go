@@ -0,0 +1,67 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/utils/set"
+	"launchpad.net/juju-core/utils/webhook"
+)
+
+var removeWebhooksDoc = `
+Remove the named webhooks from the environment.
+`
+
+// RemoveWebhooksCommand is used to remove webhooks.
+type RemoveWebhooksCommand struct {
+	cmd.EnvCommandBase
+	names []string
+}
+
+func (c *RemoveWebhooksCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "remove",
+		Args:    "<name> [...]",
+		Doc:     removeWebhooksDoc,
+		Purpose: "remove webhooks",
+	}
+}
+
+func (c *RemoveWebhooksCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no webhook name specified")
+	}
+	c.names = args
+	return nil
+}
+
+func (c *RemoveWebhooksCommand) Run(context *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	hooks, err := environWebhooks(client)
+	if err != nil {
+		return err
+	}
+	remove := set.NewStrings(c.names...)
+	var remaining []webhook.Hook
+	for _, hook := range hooks {
+		if remove.Contains(hook.Name) {
+			remove.Remove(hook.Name)
+			continue
+		}
+		remaining = append(remaining, hook)
+	}
+	if !remove.IsEmpty() {
+		return fmt.Errorf("webhook %q not found", remove.SortedValues()[0])
+	}
+	return setEnvironWebhooks(client, remaining)
+}
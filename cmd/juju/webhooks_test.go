@@ -0,0 +1,197 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/juju/osenv"
+	jujutesting "launchpad.net/juju-core/juju/testing"
+	statetesting "launchpad.net/juju-core/state/testing"
+	coretesting "launchpad.net/juju-core/testing"
+	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/utils/webhook"
+)
+
+type WebhooksSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&WebhooksSuite{})
+
+func (s *WebhooksSuite) SetUpSuite(c *gc.C) {
+	s.JujuConnSuite.SetUpSuite(c)
+	s.PatchEnvironment(osenv.JujuEnvEnvKey, "dummyenv")
+}
+
+func (s *WebhooksSuite) setWebhooks(c *gc.C, hooks ...webhook.Hook) {
+	attr, err := webhook.Format(hooks)
+	c.Assert(err, gc.IsNil)
+	err = statetesting.UpdateConfig(s.State, map[string]interface{}{"webhooks": attr})
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *WebhooksSuite) assertWebhooks(c *gc.C, expected ...webhook.Hook) {
+	envConfig, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	c.Assert(envConfig.Webhooks(), jc.DeepEquals, expected)
+}
+
+var opsHook = webhook.Hook{
+	Name:   "ops",
+	URL:    "https://ops.example.com/juju",
+	Secret: "s3cr3t",
+	Rules:  []webhook.Rule{{Kind: "unit", Status: "error"}},
+}
+
+var webhooksCommandNames = []string{
+	"add",
+	"help",
+	"list",
+	"remove",
+	"test",
+}
+
+func (s *WebhooksSuite) TestHelpCommands(c *gc.C) {
+	out := badrun(c, 0, "webhooks", "--help")
+	var names []string
+	subcommandsFound := false
+	for _, line := range strings.Split(out, "\n") {
+		f := strings.Fields(line)
+		if len(f) == 1 && f[0] == "commands:" {
+			subcommandsFound = true
+			continue
+		}
+		if !subcommandsFound || len(f) == 0 || !strings.HasPrefix(line, "    ") {
+			continue
+		}
+		names = append(names, f[0])
+	}
+	c.Assert(names, gc.DeepEquals, webhooksCommandNames)
+}
+
+func (s *WebhooksSuite) TestAdd(c *gc.C) {
+	s.setWebhooks(c, opsHook)
+	context, err := coretesting.RunCommand(c, &AddWebhookCommand{}, []string{
+		"dev", "http://dev.example.com/hook", "--secret", "xyzzy", "--on", "machine,*:error",
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(coretesting.Stdout(context), gc.Equals, "")
+	s.assertWebhooks(c, opsHook, webhook.Hook{
+		Name:   "dev",
+		URL:    "http://dev.example.com/hook",
+		Secret: "xyzzy",
+		Rules:  []webhook.Rule{{Kind: "machine"}, {Status: "error"}},
+	})
+}
+
+func (s *WebhooksSuite) TestAddDefaults(c *gc.C) {
+	context, err := coretesting.RunCommand(c, &AddWebhookCommand{}, []string{"dev", "http://dev.example.com/hook"})
+	c.Assert(err, gc.IsNil)
+	envConfig, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	hooks := envConfig.Webhooks()
+	c.Assert(hooks, gc.HasLen, 1)
+	c.Assert(hooks[0].Rules, jc.DeepEquals, []webhook.Rule{
+		{Kind: "machine", Status: "error"},
+		{Kind: "unit", Status: "error"},
+	})
+	c.Assert(hooks[0].Secret, gc.Not(gc.Equals), "")
+	c.Assert(coretesting.Stdout(context), gc.Equals, "secret: "+hooks[0].Secret+"\n")
+}
+
+func (s *WebhooksSuite) TestAddDuplicate(c *gc.C) {
+	s.setWebhooks(c, opsHook)
+	_, err := coretesting.RunCommand(c, &AddWebhookCommand{}, []string{"ops", "http://dev.example.com/hook"})
+	c.Assert(err, gc.ErrorMatches, `webhook "ops" already exists`)
+	s.assertWebhooks(c, opsHook)
+}
+
+var addWebhookInitErrorTests = []struct {
+	args []string
+	err  string
+}{{
+	err: "no webhook name specified",
+}, {
+	args: []string{"dev"},
+	err:  "no webhook URL specified",
+}, {
+	args: []string{"dev", "dev.example.com"},
+	err:  `webhook "dev": invalid URL "dev.example.com"`,
+}, {
+	args: []string{"dev", "http://dev.example.com", "--on", "service"},
+	err:  `invalid rule: unknown entity kind "service"`,
+}, {
+	args: []string{"dev", "http://dev.example.com", "extra"},
+	err:  `unrecognized args: \["extra"\]`,
+}}
+
+func (s *WebhooksSuite) TestAddInitErrors(c *gc.C) {
+	for i, test := range addWebhookInitErrorTests {
+		c.Logf("test %d: %v", i, test.args)
+		err := coretesting.InitCommand(&AddWebhookCommand{}, test.args)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *WebhooksSuite) TestList(c *gc.C) {
+	s.setWebhooks(c, opsHook, webhook.Hook{
+		Name: "dev",
+		URL:  "http://dev.example.com/hook",
+	})
+	context, err := coretesting.RunCommand(c, &ListWebhooksCommand{}, []string{"--format", "json"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(coretesting.Stdout(context), gc.Equals,
+		`{"dev":{"url":"http://dev.example.com/hook","signed":false},`+
+			`"ops":{"url":"https://ops.example.com/juju","rules":["unit:error"],"signed":true}}`+"\n")
+}
+
+func (s *WebhooksSuite) TestRemove(c *gc.C) {
+	devHook := webhook.Hook{Name: "dev", URL: "http://dev.example.com/hook"}
+	s.setWebhooks(c, opsHook, devHook)
+	_, err := coretesting.RunCommand(c, &RemoveWebhooksCommand{}, []string{"ops"})
+	c.Assert(err, gc.IsNil)
+	s.assertWebhooks(c, devHook)
+
+	_, err = coretesting.RunCommand(c, &RemoveWebhooksCommand{}, []string{"dev", "missing"})
+	c.Assert(err, gc.ErrorMatches, `webhook "missing" not found`)
+	s.assertWebhooks(c, devHook)
+
+	_, err = coretesting.RunCommand(c, &RemoveWebhooksCommand{}, []string{"dev"})
+	c.Assert(err, gc.IsNil)
+	s.assertWebhooks(c)
+}
+
+func (s *WebhooksSuite) TestTest(c *gc.C) {
+	events := make(chan *webhook.Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		c.Check(err, gc.IsNil)
+		c.Check(req.Header.Get(webhook.SignatureHeader), gc.Equals, webhook.Sign("s3cr3t", body))
+		var event webhook.Event
+		c.Check(json.Unmarshal(body, &event), gc.IsNil)
+		events <- &event
+	}))
+	defer server.Close()
+	hook := opsHook
+	hook.URL = server.URL
+	s.setWebhooks(c, hook)
+
+	context, err := coretesting.RunCommand(c, &TestWebhookCommand{}, []string{"ops"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(coretesting.Stdout(context), gc.Equals, "test event sent to webhook \"ops\"\n")
+	event := <-events
+	c.Assert(event.Environment, gc.Equals, "dummyenv")
+	c.Assert(event.Kind, gc.Equals, "test")
+	c.Assert(event.Id, gc.Equals, "ops")
+
+	_, err = coretesting.RunCommand(c, &TestWebhookCommand{}, []string{"missing"})
+	c.Assert(err, gc.ErrorMatches, `webhook "missing" not found`)
+}
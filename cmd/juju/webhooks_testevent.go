@@ -0,0 +1,54 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/juju"
+)
+
+var testWebhookDoc = `
+Send a test event to the named webhook, to check that it can be reached
+and accepts events. The event is sent from the state server, as other
+events are; it has kind "test" and is signed as usual.
+`
+
+// TestWebhookCommand is used to send a test event to a webhook.
+type TestWebhookCommand struct {
+	cmd.EnvCommandBase
+	name string
+}
+
+func (c *TestWebhookCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "test",
+		Args:    "<name>",
+		Doc:     testWebhookDoc,
+		Purpose: "send a test event to a webhook",
+	}
+}
+
+func (c *TestWebhookCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no webhook name specified")
+	}
+	c.name = args[0]
+	return cmd.CheckEmpty(args[1:])
+}
+
+func (c *TestWebhookCommand) Run(context *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.WebhookTest(c.name); err != nil {
+		return err
+	}
+	fmt.Fprintf(context.Stdout, "test event sent to webhook %q\n", c.name)
+	return nil
+}
@@ -25,6 +25,7 @@ import (
 	"launchpad.net/juju-core/worker"
 	"launchpad.net/juju-core/worker/deployer"
 	"launchpad.net/juju-core/worker/rsyslog"
+	"launchpad.net/juju-core/worker/repointer"
 	"launchpad.net/juju-core/worker/upgrader"
 )
 
@@ -128,10 +129,12 @@ type pinger interface {
 // connectionIsFatal returns a function suitable for passing
 // as the isFatal argument to worker.NewRunner,
 // that diagnoses an error as fatal if the connection
-// has failed or if the error is otherwise fatal.
+// has failed or if the error is otherwise fatal. Once
+// the agent has been repointed at another state server,
+// the connection must be reopened too.
 func connectionIsFatal(conn pinger) func(err error) bool {
 	return func(err error) bool {
-		if isFatal(err) {
+		if isFatal(err) || err == repointer.ErrRepointed {
 			return true
 		}
 		if err := conn.Ping(); err != nil {
@@ -180,7 +183,7 @@ func openAPIState(agentConfig apiOpener, a Agent) (*api.State, *apiagent.Entity,
 	// keep on retrying. If we block for ages here,
 	// then the worker that's calling this cannot
 	// be interrupted.
-	st, newPassword, err := agentConfig.OpenAPI(api.DialOpts{})
+	st, newPassword, err := agentConfig.OpenAPI(api.DialOpts{BinaryCodec: true})
 	if err != nil {
 		if params.IsCodeNotProvisioned(err) {
 			err = worker.ErrTerminateAgent
@@ -26,6 +26,7 @@ import (
 	coretools "launchpad.net/juju-core/tools"
 	"launchpad.net/juju-core/version"
 	"launchpad.net/juju-core/worker"
+	"launchpad.net/juju-core/worker/repointer"
 	"launchpad.net/juju-core/worker/upgrader"
 )
 
@@ -114,6 +115,16 @@ func (s *toolSuite) TestConnectionIsFatal(c *gc.C) {
 	}
 }
 
+func (s *toolSuite) TestRepointedConnectionIsFatal(c *gc.C) {
+	var okPinger testPinger = func() error {
+		return nil
+	}
+	// The connection must be reopened to reach the new state
+	// server, but the agent itself keeps running.
+	c.Assert(connectionIsFatal(okPinger)(repointer.ErrRepointed), jc.IsTrue)
+	c.Assert(isFatal(repointer.ErrRepointed), jc.IsFalse)
+}
+
 func mkTools(s string) *coretools.Tools {
 	return &coretools.Tools{
 		Version: version.MustParseBinary(s + "-foo-bar"),
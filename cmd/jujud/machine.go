@@ -31,13 +31,18 @@ import (
 	"launchpad.net/juju-core/upstart"
 	"launchpad.net/juju-core/version"
 	"launchpad.net/juju-core/worker"
+	"launchpad.net/juju-core/worker/charmrevisionworker"
 	"launchpad.net/juju-core/worker/cleaner"
+	"launchpad.net/juju-core/worker/firewaller"
+	"launchpad.net/juju-core/worker/hostedenvironments"
 	"launchpad.net/juju-core/worker/instancepoller"
 	"launchpad.net/juju-core/worker/localstorage"
+	"launchpad.net/juju-core/worker/metricpruner"
 	"launchpad.net/juju-core/worker/minunitsworker"
 	"launchpad.net/juju-core/worker/provisioner"
 	"launchpad.net/juju-core/worker/resumer"
 	"launchpad.net/juju-core/worker/terminationworker"
+	"launchpad.net/juju-core/worker/webhooks"
 )
 
 var logger = loggo.GetLogger("juju.cmd.jujud")
@@ -156,6 +161,17 @@ func (a *MachineAgent) Run(_ *cmd.Context) error {
 	return err
 }
 
+// entityManagesEnviron returns whether the machine
+// agent's entity runs a state server.
+func entityManagesEnviron(entity *apiagent.Entity) bool {
+	for _, job := range entity.Jobs() {
+		if job == params.JobManageEnviron {
+			return true
+		}
+	}
+	return false
+}
+
 // setupContainerSupport determines what containers can be run on this machine and
 // initialises suitable infrastructure to support such containers.
 func (a *MachineAgent) setupContainerSupport(runner worker.Runner, st *api.State, entity *apiagent.Entity) error {
@@ -264,6 +280,17 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 			a.startWorkerAfterUpgrade(runner, "minunitsworker", func() (worker.Worker, error) {
 				return minunitsworker.NewMinUnitsWorker(st), nil
 			})
+			a.startWorkerAfterUpgrade(runner, "webhooks", func() (worker.Worker, error) {
+				return webhooks.NewWorker(st), nil
+			})
+			a.startWorkerAfterUpgrade(runner, "metricpruner", func() (worker.Worker, error) {
+				return metricpruner.NewPruner(st), nil
+			})
+			a.startWorkerAfterUpgrade(runner, "hosted-environments", func() (worker.Worker, error) {
+				return hostedenvironments.NewWorker(st, func(uuid string) (worker.Worker, error) {
+					return a.hostedEnvironWorker(st, uuid)
+				}), nil
+			})
 		case state.JobManageStateDeprecated:
 			// Legacy environments may set this, but we ignore it.
 		default:
@@ -273,6 +300,49 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 	return newCloseWorker(runner, st), nil
 }
 
+// hostedEnvironWorker returns a worker that runs the workers managing
+// the environment with the given UUID hosted by the state server's
+// environment held by st. The workers that use the API log in to the
+// hosted environment on behalf of the agent's machine.
+func (a *MachineAgent) hostedEnvironWorker(st *state.State, uuid string) (worker.Worker, error) {
+	agentConfig := a.Conf.config
+	hst, err := st.OpenHostedEnvironment(uuid)
+	if err != nil {
+		return nil, err
+	}
+	apiSt, err := agentConfig.OpenEnvironAPI(names.EnvironTag(uuid), api.DialOpts{BinaryCodec: true})
+	if err != nil {
+		hst.Close()
+		return nil, err
+	}
+	runner := newRunner(connectionIsFatal(apiSt), moreImportant)
+	runner.StartWorker("cleaner", func() (worker.Worker, error) {
+		return cleaner.NewCleaner(hst), nil
+	})
+	runner.StartWorker("resumer", func() (worker.Worker, error) {
+		return resumer.NewResumer(hst), nil
+	})
+	runner.StartWorker("minunitsworker", func() (worker.Worker, error) {
+		return minunitsworker.NewMinUnitsWorker(hst), nil
+	})
+	runner.StartWorker("webhooks", func() (worker.Worker, error) {
+		return webhooks.NewWorker(hst), nil
+	})
+	runner.StartWorker("metricpruner", func() (worker.Worker, error) {
+		return metricpruner.NewPruner(hst), nil
+	})
+	runner.StartWorker("environ-provisioner", func() (worker.Worker, error) {
+		return provisioner.NewEnvironProvisioner(apiSt.Provisioner(), agentConfig), nil
+	})
+	runner.StartWorker("firewaller", func() (worker.Worker, error) {
+		return firewaller.NewFirewaller(apiSt.Firewaller())
+	})
+	runner.StartWorker("charm-revision-updater", func() (worker.Worker, error) {
+		return charmrevisionworker.NewRevisionUpdateWorker(apiSt.CharmRevisionUpdater()), nil
+	})
+	return newCloseWorker(newCloseWorker(runner, apiSt), hst), nil
+}
+
 // startWorker starts a worker to run the specified child worker but only after waiting for upgrades to complete.
 func (a *MachineAgent) startWorkerAfterUpgrade(runner worker.Runner, name string, start func() (worker.Worker, error)) {
 	runner.StartWorker(name, func() (worker.Worker, error) {
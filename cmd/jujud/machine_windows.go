@@ -14,6 +14,7 @@ import (
     workerlogger "launchpad.net/juju-core/worker/logger"
     // "launchpad.net/juju-core/worker/machineenvironmentworker"
     "launchpad.net/juju-core/worker/machiner"
+    "launchpad.net/juju-core/worker/repointer"
     // "launchpad.net/juju-core/worker/rsyslog"
     "launchpad.net/juju-core/worker/upgrader"
     "launchpad.net/juju-core/state/api/params"
@@ -73,6 +74,13 @@ func (a *MachineAgent) APIWorker(ensureStateWorker func()) (worker.Worker, error
     a.startWorkerAfterUpgrade(runner, "logger", func() (worker.Worker, error) {
         return workerlogger.NewLogger(st.Logger(), agentConfig), nil
     })
+    // State servers stay behind when their environment is
+    // imported into another state server.
+    if !entityManagesEnviron(entity) {
+        a.startWorkerAfterUpgrade(runner, "repointer", func() (worker.Worker, error) {
+            return repointer.NewRepointer(st.Agent(), agentConfig), nil
+        })
+    }
     // TODO: gsamfira: Port machineenvironmentworker to windows. Proxy settings can be written
     // in the registry
     /* 
@@ -6,6 +6,7 @@ import (
     "launchpad.net/juju-core/worker/uniter"
     "launchpad.net/juju-core/worker/upgrader"
     workerlogger "launchpad.net/juju-core/worker/logger"
+    "launchpad.net/juju-core/worker/repointer"
 )
 
 func (a *UnitAgent) APIWorkers() (worker.Worker, error) {
@@ -25,6 +26,9 @@ func (a *UnitAgent) APIWorkers() (worker.Worker, error) {
     runner.StartWorker("uniter", func() (worker.Worker, error) {
         return uniter.NewUniter(st.Uniter(), entity.Tag(), dataDir), nil
     })
+    runner.StartWorker("repointer", func() (worker.Worker, error) {
+        return repointer.NewRepointer(st.Agent(), agentConfig), nil
+    })
     // runner.StartWorker("rsyslog", func() (worker.Worker, error) {
     //     return newRsyslogConfigWorker(st.Rsyslog(), agentConfig, rsyslog.RsyslogModeForwarding)
     // })
@@ -41,6 +41,7 @@ func Main(args []string) {
 	metadatacmd.Register(&ToolsMetadataCommand{})
 	metadatacmd.Register(&ValidateToolsMetadataCommand{})
 	metadatacmd.Register(&SignMetadataCommand{})
+	metadatacmd.Register(&MirrorCommand{})
 
 	os.Exit(cmd.Main(metadatacmd, cmd.DefaultContext(), args[1:]))
 }
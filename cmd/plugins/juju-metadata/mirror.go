@@ -0,0 +1,204 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/juju/loggo"
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/environs"
+	"launchpad.net/juju-core/environs/configstore"
+	"launchpad.net/juju-core/environs/filestorage"
+	"launchpad.net/juju-core/environs/imagemetadata"
+	"launchpad.net/juju-core/environs/simplestreams"
+	"launchpad.net/juju-core/environs/storage"
+	"launchpad.net/juju-core/environs/sync"
+	envtools "launchpad.net/juju-core/environs/tools"
+	"launchpad.net/juju-core/version"
+)
+
+var mirrorDoc = `
+mirror copies juju tools, and the simplestreams metadata describing them,
+from a source location into a local directory or into the storage of an
+environment, so that environments without internet access can use them.
+The image metadata of the selected image stream is mirrored along with the
+tools, unless --no-images is given.
+
+The sources may be URLs or local directories; by default they are the
+official tools and cloud images locations. Only tools and images matching
+the given series, architectures and version are mirrored, and tools already
+in the target with the same checksum are skipped, so mirror may be re-run
+to pick up new tools only.
+
+The metadata written describes every tool and image in the target. If
+--mirror-url is given, mirrors metadata describing the target as a tools
+mirror served at that URL is written too, and only images in the given
+region and endpoint are mirrored. If a keyring file is given, signed
+(.sjson) copies of all the metadata files are written, referring to one
+another.
+
+Examples:
+  juju metadata mirror -d /srv/mirror --series precise,trusty --arches amd64
+  juju metadata mirror -d /srv/mirror --stream daily --series trusty
+  juju metadata mirror -e private --source /media/usb/juju --no-images -k signing.key
+`
+
+// MirrorCommand is used to build a mirror of juju tools and their
+// simplestreams metadata.
+type MirrorCommand struct {
+	cmd.EnvCommandBase
+	source      string
+	imageSource string
+	stream      string
+	noImages    bool
+	localDir    string
+	series      string
+	arches      string
+	versionStr  string
+	dev         bool
+	mirrorURL   string
+	region      string
+	endpoint    string
+	keyFile     string
+	passphrase  string
+	dryRun      bool
+
+	majorVersion int
+	minorVersion int
+}
+
+func (c *MirrorCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "mirror",
+		Purpose: "mirror tools and simplestreams metadata for offline use",
+		Doc:     mirrorDoc,
+	}
+}
+
+func (c *MirrorCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+	f.StringVar(&c.source, "source", "", "URL or local directory from which to mirror tools")
+	f.StringVar(&c.imageSource, "image-source", "", "URL or local directory from which to mirror image metadata")
+	f.StringVar(&c.stream, "stream", imagemetadata.ReleasedStream, "the image stream to mirror, such as released or daily")
+	f.BoolVar(&c.noImages, "no-images", false, "mirror tools only, without image metadata")
+	f.StringVar(&c.localDir, "d", "", "local directory in which to build the mirror, instead of environment storage")
+	f.StringVar(&c.series, "series", "", "comma-separated series of the tools to mirror (default all)")
+	f.StringVar(&c.arches, "arches", "", "comma-separated architectures of the tools to mirror (default all)")
+	f.StringVar(&c.versionStr, "version", "", "mirror tools with a specific major[.minor] version")
+	f.BoolVar(&c.dev, "dev", false, "mirror development versions as well as released ones")
+	f.StringVar(&c.mirrorURL, "mirror-url", "", "URL at which the mirror will be served, for the mirrors metadata")
+	f.StringVar(&c.region, "r", "", "the cloud region to which the mirrors metadata applies")
+	f.StringVar(&c.endpoint, "u", "", "the cloud endpoint to which the mirrors metadata applies")
+	f.StringVar(&c.keyFile, "k", "", "file containing the armored private key used to sign the metadata")
+	f.StringVar(&c.passphrase, "p", "", "passphrase used to decrypt the private key")
+	f.BoolVar(&c.dryRun, "dry-run", false, "don't copy, just print what would be copied")
+}
+
+func (c *MirrorCommand) Init(args []string) error {
+	c.majorVersion, c.minorVersion = version.Current.Major, -1
+	if c.versionStr != "" {
+		var err error
+		if c.majorVersion, c.minorVersion, err = version.ParseMajorMinor(c.versionStr); err != nil {
+			return err
+		}
+	}
+	if c.mirrorURL == "" && (c.region != "" || c.endpoint != "") {
+		return fmt.Errorf("region and endpoint require a mirror URL")
+	}
+	if c.passphrase != "" && c.keyFile == "" {
+		return fmt.Errorf("passphrase requires a keyfile")
+	}
+	if c.noImages && c.imageSource != "" {
+		return fmt.Errorf("image source cannot be used with --no-images")
+	}
+	return cmd.CheckEmpty(args)
+}
+
+// splitList returns the elements of a comma-separated list.
+func splitList(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func (c *MirrorCommand) Run(context *cmd.Context) error {
+	loggo.RegisterWriter("mirror", cmd.NewCommandLogWriter("juju.environs.sync", context.Stdout, context.Stderr), loggo.INFO)
+	defer loggo.RemoveWriter("mirror")
+
+	source := c.source
+	if source == "" {
+		source = envtools.DefaultBaseURL
+	} else if !strings.Contains(source, "://") {
+		source = context.AbsPath(source)
+	}
+	sourceURL, err := envtools.ToolsURL(source)
+	if err != nil {
+		return err
+	}
+	target, err := c.target(context)
+	if err != nil {
+		return err
+	}
+	mctx := &sync.MirrorContext{
+		Source:       simplestreams.NewURLDataSource("mirror source", sourceURL, simplestreams.VerifySSLHostnames),
+		Target:       target,
+		Series:       splitList(c.series),
+		Arches:       splitList(c.arches),
+		MajorVersion: c.majorVersion,
+		MinorVersion: c.minorVersion,
+		Dev:          c.dev,
+		MirrorURL:    c.mirrorURL,
+		CloudSpec:    simplestreams.CloudSpec{Region: c.region, Endpoint: c.endpoint},
+		Passphrase:   c.passphrase,
+		DryRun:       c.dryRun,
+	}
+	if c.keyFile != "" {
+		keyData, err := ioutil.ReadFile(context.AbsPath(c.keyFile))
+		if err != nil {
+			return err
+		}
+		mctx.SigningKey = string(keyData)
+	}
+	if err := sync.MirrorTools(mctx); err != nil || c.noImages {
+		return err
+	}
+	imageSource := c.imageSource
+	if imageSource == "" {
+		imageSource = imagemetadata.DefaultBaseURL
+	} else if !strings.Contains(imageSource, "://") {
+		imageSource = context.AbsPath(imageSource)
+	}
+	imageSourceURL, err := imagemetadata.ImageMetadataURL(imageSource, c.stream)
+	if err != nil {
+		return err
+	}
+	mctx.ImageSource = simplestreams.NewURLDataSource("image mirror source", imageSourceURL, simplestreams.VerifySSLHostnames)
+	mctx.Stream = c.stream
+	return sync.MirrorImageMetadata(mctx)
+}
+
+// target returns the storage in which to build the mirror.
+func (c *MirrorCommand) target(context *cmd.Context) (storage.Storage, error) {
+	if c.localDir != "" {
+		return filestorage.NewFileStorageWriter(context.AbsPath(c.localDir))
+	}
+	store, err := configstore.Default()
+	if err != nil {
+		return nil, err
+	}
+	environ, err := environs.NewFromName(c.EnvName, store)
+	if err != nil {
+		return nil, err
+	}
+	return environ.Storage(), nil
+}
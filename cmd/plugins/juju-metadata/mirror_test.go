@@ -0,0 +1,135 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/juju/loggo"
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/environs/filestorage"
+	"launchpad.net/juju-core/environs/imagemetadata"
+	"launchpad.net/juju-core/environs/simplestreams"
+	sstesting "launchpad.net/juju-core/environs/simplestreams/testing"
+	ttesting "launchpad.net/juju-core/environs/tools/testing"
+	coretesting "launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/testing/testbase"
+	"launchpad.net/juju-core/version"
+)
+
+type MirrorSuite struct {
+	testbase.LoggingSuite
+}
+
+var _ = gc.Suite(&MirrorSuite{})
+
+func (s *MirrorSuite) SetUpTest(c *gc.C) {
+	s.LoggingSuite.SetUpTest(c)
+	loggo.GetLogger("").SetLogLevel(loggo.INFO)
+	s.AddCleanup(func(*gc.C) { loggo.ResetLoggers() })
+}
+
+var mirrorInitErrorTests = []struct {
+	args []string
+	err  string
+}{{
+	args: []string{"--version", "foo"},
+	err:  `invalid major version number foo: .*`,
+}, {
+	args: []string{"-r", "region"},
+	err:  "region and endpoint require a mirror URL",
+}, {
+	args: []string{"-p", "secret"},
+	err:  "passphrase requires a keyfile",
+}, {
+	args: []string{"--no-images", "--image-source", "/srv/images"},
+	err:  "image source cannot be used with --no-images",
+}, {
+	args: []string{"extra"},
+	err:  `unrecognized args: \["extra"\]`,
+}}
+
+func (s *MirrorSuite) TestInitErrors(c *gc.C) {
+	for i, t := range mirrorInitErrorTests {
+		c.Logf("test %d: %v", i, t.args)
+		err := coretesting.InitCommand(&MirrorCommand{}, t.args)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *MirrorSuite) TestMirror(c *gc.C) {
+	sourceDir := c.MkDir()
+	ttesting.MakeToolsWithCheckSum(c, sourceDir, "releases", currentVersionStrings)
+	targetDir := c.MkDir()
+	ctx := coretesting.Context(c)
+	code := cmd.Main(&MirrorCommand{}, ctx, []string{
+		"--source", sourceDir, "-d", targetDir, "--dev", "--series", "quantal", "--arches", "amd64,i386",
+		"--no-images",
+	})
+	c.Assert(code, gc.Equals, 0)
+	output := ctx.Stdout.(*bytes.Buffer).String()
+	c.Assert(output, gc.Matches, `(.|\n)*2 tools to be copied(.|\n)*`)
+
+	metadata := ttesting.ParseMetadataFromDir(c, targetDir, false)
+	c.Assert(metadata, gc.HasLen, 2)
+	for _, md := range metadata {
+		c.Check(md.Version, gc.Equals, version.Current.Number.String())
+		c.Check(md.Release, gc.Equals, "quantal")
+		_, err := os.Stat(filepath.Join(targetDir, "tools", md.Path))
+		c.Check(err, gc.IsNil)
+	}
+}
+
+func (s *MirrorSuite) TestMirrorSigned(c *gc.C) {
+	sourceDir := c.MkDir()
+	ttesting.MakeToolsWithCheckSum(c, sourceDir, "releases", currentVersionStrings)
+	keyFile := filepath.Join(c.MkDir(), "private.asc")
+	err := ioutil.WriteFile(keyFile, []byte(sstesting.SignedMetadataPrivateKey), 0600)
+	c.Assert(err, gc.IsNil)
+	targetDir := c.MkDir()
+	ctx := coretesting.Context(c)
+	code := cmd.Main(&MirrorCommand{}, ctx, []string{
+		"--source", sourceDir, "-d", targetDir, "--dev", "--no-images",
+		"-k", keyFile, "-p", sstesting.PrivateKeyPassphrase,
+	})
+	c.Assert(code, gc.Equals, 0)
+	for _, name := range []string{"index.sjson", "com.ubuntu.juju:released:tools.sjson"} {
+		_, err := os.Stat(filepath.Join(targetDir, "tools", "streams", "v1", name))
+		c.Check(err, gc.IsNil)
+	}
+}
+
+func (s *MirrorSuite) TestMirrorImages(c *gc.C) {
+	sourceDir := c.MkDir()
+	ttesting.MakeToolsWithCheckSum(c, sourceDir, "releases", currentVersionStrings)
+	stor, err := filestorage.NewFileStorageWriter(sourceDir)
+	c.Assert(err, gc.IsNil)
+	images := []*imagemetadata.ImageMetadata{{
+		Id:      "daily-image",
+		Arch:    "amd64",
+		Version: "12.10",
+		Stream:  "daily",
+	}}
+	err = imagemetadata.WriteMetadata(images, []simplestreams.CloudSpec{{}}, stor)
+	c.Assert(err, gc.IsNil)
+	targetDir := c.MkDir()
+	ctx := coretesting.Context(c)
+	code := cmd.Main(&MirrorCommand{}, ctx, []string{
+		"--source", sourceDir, "--image-source", sourceDir, "-d", targetDir,
+		"--dev", "--series", "quantal", "--arches", "amd64", "--stream", "daily",
+	})
+	c.Assert(code, gc.Equals, 0)
+	target, err := filestorage.NewFileStorageReader(targetDir)
+	c.Assert(err, gc.IsNil)
+	mirrored, err := imagemetadata.ReadAllMetadata(target)
+	c.Assert(err, gc.IsNil)
+	c.Assert(mirrored, gc.HasLen, 1)
+	c.Assert(mirrored[0].Id, gc.Equals, "daily-image")
+	c.Assert(mirrored[0].Stream, gc.Equals, "daily")
+}
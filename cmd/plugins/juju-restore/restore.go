@@ -13,12 +13,13 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
 	"text/template"
 
 	"github.com/juju/loggo"
 	"launchpad.net/gnuflag"
-	"launchpad.net/goyaml"
 
+	"launchpad.net/juju-core/agent"
 	"launchpad.net/juju-core/cmd"
 	"launchpad.net/juju-core/constraints"
 	"launchpad.net/juju-core/environs"
@@ -296,32 +297,66 @@ func extractCreds(backupFile string) (credentials, error) {
 	if err != nil {
 		return credentials{}, err
 	}
-	agentConf, err := findFileInTar(outerTar, "var/lib/juju/agents/machine-0/agent.conf")
+	// The agent config may hold its secrets encrypted with a key
+	// kept next to it, so the whole agent directory is extracted
+	// and read as the agent itself would read it.
+	dir, err := ioutil.TempDir("", "juju-restore")
 	if err != nil {
 		return credentials{}, err
 	}
-	data, err := ioutil.ReadAll(agentConf)
-	if err != nil {
-		return credentials{}, fmt.Errorf("failed to read agent config file: %v", err)
-	}
-	var conf interface{}
-	if err := goyaml.Unmarshal(data, &conf); err != nil {
-		return credentials{}, fmt.Errorf("cannot unmarshal agent config file: %v", err)
+	defer os.RemoveAll(dir)
+	if err := extractDirFromTar(outerTar, "var/lib/juju/agents/machine-0", dir); err != nil {
+		return credentials{}, err
 	}
-	m, ok := conf.(map[interface{}]interface{})
-	if !ok {
-		return credentials{}, fmt.Errorf("config file unmarshalled to %T not %T", conf, m)
+	conf, err := agent.ReadConf(filepath.Join(dir, "agent.conf"))
+	if err != nil {
+		return credentials{}, fmt.Errorf("cannot read agent config file: %v", err)
 	}
-	password, ok := m["statepassword"].(string)
-	if !ok || password == "" {
+	password := conf.StatePassword()
+	if password == "" {
 		return credentials{}, fmt.Errorf("agent password not found in configuration")
 	}
 	return credentials{
-		Tag:      "machine-0",
+		Tag:      conf.Tag(),
 		Password: password,
 	}, nil
 }
 
+// extractDirFromTar writes the regular files held directly in the
+// named directory of the tar archive read from r into destDir.
+func extractDirFromTar(r io.Reader, name, destDir string) error {
+	tarr := tar.NewReader(r)
+	found := false
+	for {
+		hdr, err := tarr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("cannot read %q: %v", name, err)
+		}
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			continue
+		}
+		dir, file := path.Split(path.Clean(hdr.Name))
+		if path.Clean(dir) != name {
+			continue
+		}
+		data, err := ioutil.ReadAll(tarr)
+		if err != nil {
+			return fmt.Errorf("cannot read %q: %v", hdr.Name, err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(destDir, file), data, 0600); err != nil {
+			return err
+		}
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("%q not found", name)
+	}
+	return nil
+}
+
 func findFileInTar(r io.Reader, name string) (io.Reader, error) {
 	tarr := tar.NewReader(r)
 	for {
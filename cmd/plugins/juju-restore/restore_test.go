@@ -0,0 +1,101 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	stdtesting "testing"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/agent"
+	"launchpad.net/juju-core/testing/testbase"
+	"launchpad.net/juju-core/version"
+)
+
+func Test(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type restoreSuite struct {
+	testbase.LoggingSuite
+}
+
+var _ = gc.Suite(&restoreSuite{})
+
+// writeBackup writes a backup file holding the files in the given
+// machine agent directory, laid out as the juju-backup plugin does,
+// and returns its path.
+func writeBackup(c *gc.C, agentDir string) string {
+	var root bytes.Buffer
+	rootw := tar.NewWriter(&root)
+	infos, err := ioutil.ReadDir(agentDir)
+	c.Assert(err, gc.IsNil)
+	for _, info := range infos {
+		data, err := ioutil.ReadFile(filepath.Join(agentDir, info.Name()))
+		c.Assert(err, gc.IsNil)
+		writeTarFile(c, rootw, "var/lib/juju/agents/machine-0/"+info.Name(), data)
+	}
+	c.Assert(rootw.Close(), gc.IsNil)
+
+	backupFile := filepath.Join(c.MkDir(), "backup.tgz")
+	f, err := os.Create(backupFile)
+	c.Assert(err, gc.IsNil)
+	defer f.Close()
+	gzw := gzip.NewWriter(f)
+	tarw := tar.NewWriter(gzw)
+	writeTarFile(c, tarw, "juju-backup/root.tar", root.Bytes())
+	c.Assert(tarw.Close(), gc.IsNil)
+	c.Assert(gzw.Close(), gc.IsNil)
+	return backupFile
+}
+
+func writeTarFile(c *gc.C, w *tar.Writer, name string, data []byte) {
+	err := w.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0600,
+		Size:     int64(len(data)),
+		Typeflag: tar.TypeReg,
+	})
+	c.Assert(err, gc.IsNil)
+	_, err = w.Write(data)
+	c.Assert(err, gc.IsNil)
+}
+
+func (*restoreSuite) TestExtractCreds(c *gc.C) {
+	conf, err := agent.NewAgentConfig(agent.AgentConfigParams{
+		DataDir:           c.MkDir(),
+		Tag:               "machine-0",
+		UpgradedToVersion: version.Current.Number,
+		Password:          "sekrit",
+		CACert:            []byte("ca cert"),
+		StateAddresses:    []string{"localhost:1234"},
+		APIAddresses:      []string{"localhost:1235"},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(conf.Write(), gc.IsNil)
+
+	// The password is encrypted in the agent config, so it
+	// cannot be read without the key held alongside it.
+	data, err := ioutil.ReadFile(agent.ConfigPath(conf.DataDir(), conf.Tag()))
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Not(gc.Matches), "(?s).*sekrit.*")
+
+	creds, err := extractCreds(writeBackup(c, conf.Dir()))
+	c.Assert(err, gc.IsNil)
+	c.Assert(creds, gc.Equals, credentials{
+		Tag:      "machine-0",
+		Password: "sekrit",
+	})
+}
+
+func (*restoreSuite) TestExtractCredsNoAgentConfig(c *gc.C) {
+	_, err := extractCreds(writeBackup(c, c.MkDir()))
+	c.Assert(err, gc.ErrorMatches, `"var/lib/juju/agents/machine-0" not found`)
+}
@@ -6,8 +6,10 @@ package environs
 import (
 	"fmt"
 
+	"launchpad.net/juju-core/names"
 	"launchpad.net/juju-core/state"
 	"launchpad.net/juju-core/state/api"
+	"launchpad.net/juju-core/state/api/params"
 	apiprovisioner "launchpad.net/juju-core/state/api/provisioner"
 	"launchpad.net/juju-core/utils"
 )
@@ -49,6 +51,12 @@ func NewAPIAuthenticator(st *apiprovisioner.State) (AuthenticationProvider, erro
 	if err != nil {
 		return nil, err
 	}
+	// Older state servers cannot report the environment, in
+	// which case new machines log in without naming it.
+	uuid, err := st.EnvironUUID()
+	if err != nil && !params.IsCodeNotImplemented(err) {
+		return nil, err
+	}
 	stateInfo := &state.Info{
 		Addrs:  stateAddresses,
 		CACert: caCert,
@@ -57,6 +65,9 @@ func NewAPIAuthenticator(st *apiprovisioner.State) (AuthenticationProvider, erro
 		Addrs:  apiAddresses,
 		CACert: caCert,
 	}
+	if uuid != "" {
+		apiInfo.EnvironTag = names.EnvironTag(uuid)
+	}
 	return &simpleAuth{stateInfo, apiInfo}, nil
 }
 
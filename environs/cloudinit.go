@@ -87,6 +87,7 @@ func PopulateMachineConfig(mcfg *cloudinit.MachineConfig,
 	providerType, authorizedKeys string,
 	sslHostnameVerification bool,
 	proxy, aptProxy osenv.ProxySettings,
+	aptMirror string,
 ) error {
 	if authorizedKeys == "" {
 		return fmt.Errorf("environment configuration has no authorized-keys")
@@ -100,6 +101,7 @@ func PopulateMachineConfig(mcfg *cloudinit.MachineConfig,
 	mcfg.DisableSSLHostnameVerification = !sslHostnameVerification
 	mcfg.ProxySettings = proxy
 	mcfg.AptProxySettings = aptProxy
+	mcfg.AptMirror = aptMirror
 	return nil
 }
 
@@ -123,6 +125,7 @@ func FinishMachineConfig(mcfg *cloudinit.MachineConfig, cfg *config.Config, cons
 		cfg.SSLHostnameVerification(),
 		cfg.ProxySettings(),
 		cfg.AptProxySettings(),
+		cfg.AptMirror(),
 	); err != nil {
 		return err
 	}
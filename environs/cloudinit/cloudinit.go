@@ -157,6 +157,10 @@ type MachineConfig struct {
 	// AptProxySettings define the http, https and ftp proxy settings to use
 	// for apt, which may or may not be the same as the normal ProxySettings.
 	AptProxySettings osenv.ProxySettings
+
+	// AptMirror, if non-empty, holds the URL of the apt mirror to use
+	// instead of the default Ubuntu archive.
+	AptMirror string
 }
 
 func base64yaml(m *config.Config) string {
@@ -177,6 +181,23 @@ func Configure(cfg *MachineConfig, c *cloudinit.Config) error {
 	return ConfigureJuju(cfg, c)
 }
 
+// ConfigureWindowsScript returns a PowerShell script that, when run
+// on an existing Windows machine, initializes a Juju machine agent.
+// As nothing like cloud-init runs on such machines, the script also
+// performs the basic configuration done by the userdata of a new
+// instance.
+func ConfigureWindowsScript(cfg *MachineConfig) (string, error) {
+	c := cloudinit.New()
+	if err := Configure(cfg, c); err != nil {
+		return "", err
+	}
+	script, err := c.RenderWin()
+	if err != nil {
+		return "", err
+	}
+	return string(script), nil
+}
+
 // NonceFile is written by cloud-init as the last thing it does.
 // The file will contain the machine's nonce. The filename is
 // relative to the Juju data-dir.
@@ -999,6 +1020,10 @@ func WinConfigureBasic(cfg *MachineConfig, c *cloudinit.Config) error {
 
 	c.AddPSScripts(
 		fmt.Sprintf(`%s`, winPowershellHelperFunctions),
+	)
+	// The proxy must be in place before anything is downloaded.
+	c.AddPSScripts(winProxyScripts(cfg.ProxySettings)...)
+	c.AddPSScripts(
 		fmt.Sprintf(`icacls "%s" /grant "jujud:(OI)(CI)(F)" /T`, utils.PathToWindows(osenv.WinBaseDir)),
         fmt.Sprintf(`mkdir %s`, utils.PathToWindows(osenv.WinTempDir)),
         fmt.Sprintf(`ExecRetry { (new-object System.Net.WebClient).DownloadFile("%s", "%s") }`, 
@@ -1024,6 +1049,75 @@ func WinConfigureBasic(cfg *MachineConfig, c *cloudinit.Config) error {
 	return nil
 }
 
+// winProxyScripts returns the PowerShell commands that configure the
+// proxy settings machine-wide: for the environment of new processes,
+// for WinHTTP and for the .NET web client used by the userdata itself.
+func winProxyScripts(proxy osenv.ProxySettings) []string {
+	if (proxy == osenv.ProxySettings{}) {
+		return nil
+	}
+	var scripts, servers []string
+	for _, p := range []struct{ name, value string }{
+		{"http_proxy", proxy.Http},
+		{"https_proxy", proxy.Https},
+		{"ftp_proxy", proxy.Ftp},
+		{"no_proxy", proxy.NoProxy},
+	} {
+		if p.value == "" {
+			continue
+		}
+		scripts = append(scripts,
+			fmt.Sprintf(`[Environment]::SetEnvironmentVariable("%s", %s, "Machine")`, p.name, psquote(p.value)),
+			fmt.Sprintf(`$env:%s = %s`, p.name, psquote(p.value)),
+		)
+		if p.name != "no_proxy" {
+			scheme := strings.TrimSuffix(p.name, "_proxy")
+			servers = append(servers, scheme+"="+proxyHostPort(p.value))
+		}
+	}
+	var bypass []string
+	for _, host := range strings.Split(proxy.NoProxy, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			bypass = append(bypass, host)
+		}
+	}
+	if len(servers) > 0 {
+		netsh := fmt.Sprintf(`netsh winhttp set proxy proxy-server="%s"`, strings.Join(servers, ";"))
+		if len(bypass) > 0 {
+			netsh += fmt.Sprintf(` bypass-list="%s"`, strings.Join(bypass, ";"))
+		}
+		scripts = append(scripts, netsh)
+	}
+	if proxy.Http != "" {
+		scripts = append(scripts,
+			fmt.Sprintf(`$webProxy = New-Object System.Net.WebProxy(%s, $true)`, psquote(proxy.Http)))
+		if len(bypass) > 0 {
+			quoted := make([]string, len(bypass))
+			for i, host := range bypass {
+				quoted[i] = psquote(host)
+			}
+			scripts = append(scripts,
+				fmt.Sprintf(`$webProxy.BypassList = @(%s)`, strings.Join(quoted, ", ")))
+		}
+		scripts = append(scripts, `[System.Net.WebRequest]::DefaultWebProxy = $webProxy`)
+	}
+	return scripts
+}
+
+// proxyHostPort returns the host:port part of a proxy URL, which is the
+// form WinHTTP expects.
+func proxyHostPort(proxy string) string {
+	if i := strings.Index(proxy, "://"); i >= 0 {
+		proxy = proxy[i+len("://"):]
+	}
+	return strings.TrimSuffix(proxy, "/")
+}
+
+// psquote returns p quoted as a literal PowerShell string.
+func psquote(p string) string {
+	return "'" + strings.Replace(p, "'", "''", -1) + "'"
+}
+
 func NixConfigureBasic(cfg *MachineConfig, c *cloudinit.Config) error {
 		c.AddScripts(
 		"set -xe", // ensure we run all the scripts or abort.
@@ -1064,8 +1158,8 @@ func ConfigureBasic(cfg *MachineConfig, c *cloudinit.Config) error {
 
 // AddAptCommands update the cloudinit.Config instance with the necessary
 // packages, the request to do the apt-get update/upgrade on boot, and adds
-// the apt proxy settings if there are any.
-func AddAptCommands(proxy osenv.ProxySettings, c *cloudinit.Config) {
+// the apt proxy settings and mirror if there are any.
+func AddAptCommands(proxy osenv.ProxySettings, aptMirror string, c *cloudinit.Config) {
 	// Bring packages up-to-date.
 	c.SetAptUpdate(true)
 	c.SetAptUpgrade(true)
@@ -1076,6 +1170,10 @@ func AddAptCommands(proxy osenv.ProxySettings, c *cloudinit.Config) {
 	c.AddPackage("bridge-utils")
 	c.AddPackage("rsyslog-gnutls")
 
+	if aptMirror != "" {
+		c.SetAptMirror(aptMirror)
+	}
+
 	// Write out the apt proxy settings
 	if (proxy != osenv.ProxySettings{}) {
 		filename := utils.AptConfFile
@@ -1153,7 +1251,7 @@ func NixConfigureJuju(cfg *MachineConfig, c *cloudinit.Config) error {
 	}
 
 	if !cfg.DisablePackageCommands {
-		AddAptCommands(cfg.AptProxySettings, c)
+		AddAptCommands(cfg.AptProxySettings, cfg.AptMirror, c)
 	}
 
 	// Write out the normal proxy settings so that the settings are
@@ -1321,6 +1419,7 @@ func (cfg *MachineConfig) agentConfig(tag string) (agent.Config, error) {
 		LogDir:            cfg.LogDir,
 		Jobs:              cfg.Jobs,
 		Tag:               tag,
+		EnvironTag:        cfg.APIInfo.EnvironTag,
 		UpgradedToVersion: version.Current.Number,
 		Password:          password,
 		Nonce:             cfg.MachineNonce,
@@ -18,6 +18,7 @@ import (
 	"launchpad.net/juju-core/environs"
 	"launchpad.net/juju-core/environs/cloudinit"
 	"launchpad.net/juju-core/environs/config"
+	"launchpad.net/juju-core/juju/osenv"
 	jujutesting "launchpad.net/juju-core/juju/testing"
 	"launchpad.net/juju-core/names"
 	"launchpad.net/juju-core/state"
@@ -131,6 +132,8 @@ tar zxf \$bin/tools.tar.gz -C \$bin
 rm \$bin/tools\.tar\.gz && rm \$bin/juju1\.2\.3-precise-amd64\.sha256
 printf %s '{"version":"1\.2\.3-precise-amd64","url":"http://foo\.com/tools/releases/juju1\.2\.3-precise-amd64\.tgz","sha256":"1234","size":10}' > \$bin/downloaded-tools\.txt
 mkdir -p '/var/lib/juju/agents/machine-0'
+install -m 600 /dev/null '/var/lib/juju/agents/machine-0/agent\.key'
+printf '%s\\n' '[A-Za-z0-9+/=]+' > '/var/lib/juju/agents/machine-0/agent\.key'
 install -m 600 /dev/null '/var/lib/juju/agents/machine-0/agent\.conf'
 printf '%s\\n' '.*' > '/var/lib/juju/agents/machine-0/agent\.conf'
 install -D -m 644 /dev/null '/etc/apt/preferences\.d/50-cloud-tools'
@@ -148,6 +151,8 @@ echo 'Starting MongoDB server \(juju-db\)'.*
 cat >> /etc/init/juju-db\.conf << 'EOF'\\ndescription "juju state database"\\nauthor "Juju Team <juju@lists\.ubuntu\.com>"\\nstart on runlevel \[2345\]\\nstop on runlevel \[!2345\]\\nrespawn\\nnormal exit 0\\n\\nlimit nofile 65000 65000\\nlimit nproc 20000 20000\\n\\nexec ` + mongodPath + ` --auth --dbpath=/var/lib/juju/db --sslOnNormalPorts --sslPEMKeyFile '/var/lib/juju/server\.pem' --sslPEMKeyPassword ignored --bind_ip 0\.0\.0\.0 --port 37017 --noprealloc --syslog --smallfiles\\nEOF\\n
 start juju-db
 mkdir -p '/var/lib/juju/agents/bootstrap'
+install -m 600 /dev/null '/var/lib/juju/agents/bootstrap/agent\.key'
+printf '%s\\n' '[A-Za-z0-9+/=]+' > '/var/lib/juju/agents/bootstrap/agent\.key'
 install -m 600 /dev/null '/var/lib/juju/agents/bootstrap/agent\.conf'
 printf '%s\\n' '.*' > '/var/lib/juju/agents/bootstrap/agent\.conf'
 echo 'Bootstrapping Juju machine agent'.*
@@ -250,6 +255,8 @@ tar zxf \$bin/tools.tar.gz -C \$bin
 rm \$bin/tools\.tar\.gz && rm \$bin/juju1\.2\.3-linux-amd64\.sha256
 printf %s '{"version":"1\.2\.3-linux-amd64","url":"http://foo\.com/tools/releases/juju1\.2\.3-linux-amd64\.tgz","sha256":"1234","size":10}' > \$bin/downloaded-tools\.txt
 mkdir -p '/var/lib/juju/agents/machine-99'
+install -m 600 /dev/null '/var/lib/juju/agents/machine-99/agent\.key'
+printf '%s\\n' '[A-Za-z0-9+/=]+' > '/var/lib/juju/agents/machine-99/agent\.key'
 install -m 600 /dev/null '/var/lib/juju/agents/machine-99/agent\.conf'
 printf '%s\\n' '.*' > '/var/lib/juju/agents/machine-99/agent\.conf'
 ln -s 1\.2\.3-linux-amd64 '/var/lib/juju/tools/machine-99'
@@ -288,6 +295,8 @@ start jujud-machine-99
 		inexactMatch: true,
 		expectScripts: `
 mkdir -p '/var/lib/juju/agents/machine-2-lxc-1'
+install -m 600 /dev/null '/var/lib/juju/agents/machine-2-lxc-1/agent\.key'
+printf '%s\\n' '[A-Za-z0-9+/=]+' > '/var/lib/juju/agents/machine-2-lxc-1/agent\.key'
 install -m 600 /dev/null '/var/lib/juju/agents/machine-2-lxc-1/agent\.conf'
 printf '%s\\n' '.*' > '/var/lib/juju/agents/machine-2-lxc-1/agent\.conf'
 ln -s 1\.2\.3-linux-amd64 '/var/lib/juju/tools/machine-2-lxc-1'
@@ -874,6 +883,55 @@ export NO_PROXY=localhost,10.0.3.1' > /home/ubuntu/.juju-proxy && chown ubuntu:u
 	c.Assert(found, jc.IsTrue)
 }
 
+func (s *cloudinitSuite) TestAptMirrorWritten(c *gc.C) {
+	environConfig := minimalConfig(c)
+	environConfig, err := environConfig.Apply(map[string]interface{}{
+		"apt-mirror": "http://mirror.example.com/ubuntu",
+	})
+	c.Assert(err, gc.IsNil)
+	machineCfg := s.createMachineConfig(c, environConfig)
+	c.Assert(machineCfg.AptMirror, gc.Equals, "http://mirror.example.com/ubuntu")
+	cloudcfg := coreCloudinit.New()
+	err = cloudinit.Configure(machineCfg, cloudcfg)
+	c.Assert(err, gc.IsNil)
+
+	data, err := cloudcfg.Render()
+	c.Assert(err, gc.IsNil)
+	var attrs map[string]interface{}
+	err = goyaml.Unmarshal(data, &attrs)
+	c.Assert(err, gc.IsNil)
+	c.Assert(attrs["apt_mirror"], gc.Equals, "http://mirror.example.com/ubuntu")
+}
+
+func (s *cloudinitSuite) TestWinProxyWritten(c *gc.C) {
+	machineCfg := &cloudinit.MachineConfig{
+		DataDir:      "C:/Juju/lib/juju",
+		MachineNonce: "FAKE_NONCE",
+		ProxySettings: osenv.ProxySettings{
+			Http:    "http://user@10.0.0.1:3128",
+			NoProxy: "localhost,10.0.3.1",
+		},
+	}
+	cloudcfg := coreCloudinit.New()
+	err := cloudinit.WinConfigureBasic(machineCfg, cloudcfg)
+	c.Assert(err, gc.IsNil)
+
+	data, err := cloudcfg.RenderWin()
+	c.Assert(err, gc.IsNil)
+	script := string(data)
+	for _, expected := range []string{
+		`[Environment]::SetEnvironmentVariable("http_proxy", 'http://user@10.0.0.1:3128', "Machine")`,
+		`[Environment]::SetEnvironmentVariable("no_proxy", 'localhost,10.0.3.1', "Machine")`,
+		`netsh winhttp set proxy proxy-server="http=user@10.0.0.1:3128" bypass-list="localhost;10.0.3.1"`,
+		`$webProxy.BypassList = @('localhost', '10.0.3.1')`,
+		`[System.Net.WebRequest]::DefaultWebProxy = $webProxy`,
+	} {
+		c.Check(script, jc.Contains, expected)
+	}
+	// The proxy is set up before anything is downloaded.
+	c.Assert(strings.Index(script, "DefaultWebProxy") < strings.Index(script, "DownloadFile"), jc.IsTrue)
+}
+
 var serverCert = []byte(`
 SERVER CERT
 -----BEGIN CERTIFICATE-----
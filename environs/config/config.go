@@ -6,6 +6,7 @@ package config
 import (
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -20,6 +21,7 @@ import (
 	"launchpad.net/juju-core/juju/osenv"
 	"launchpad.net/juju-core/schema"
 	"launchpad.net/juju-core/utils"
+	"launchpad.net/juju-core/utils/webhook"
 	"launchpad.net/juju-core/version"
 )
 
@@ -290,6 +292,24 @@ func Validate(cfg, old *Config) error {
 			" of key-value pairs, not %q", authToken)
 	}
 
+	// If an apt mirror is set, make sure it is an absolute URL.
+	if v, ok := cfg.defined["apt-mirror"].(string); ok && v != "" {
+		if u, err := url.Parse(v); err != nil || !u.IsAbs() {
+			return fmt.Errorf("invalid apt mirror in environment configuration: %q", v)
+		}
+	}
+
+	// If webhooks are set, make sure they are valid.
+	if v, ok := cfg.defined["webhooks"].(string); ok {
+		if _, err := webhook.Parse(v); err != nil {
+			return fmt.Errorf("invalid webhooks in environment configuration: %v", err)
+		}
+	}
+
+	if v, ok := cfg.defined["presence-grace-period"].(int); ok && v < 0 {
+		return fmt.Errorf("presence-grace-period must not be negative")
+	}
+
 	// Check the immutable config values.  These can't change
 	if old != nil {
 		for _, attr := range immutableAttributes {
@@ -508,6 +528,32 @@ func (c *Config) AptFtpProxy() string {
 	return c.getWithFallback("apt-ftp-proxy", "ftp-proxy")
 }
 
+// AptMirror returns the URL of the apt mirror used instead of the
+// default Ubuntu archive, or "" if none is set.
+func (c *Config) AptMirror() string {
+	return c.asString("apt-mirror")
+}
+
+// Webhooks returns the webhooks that are sent events
+// about the environment.
+func (c *Config) Webhooks() []webhook.Hook {
+	// The webhooks have been validated, so
+	// they can't fail to parse.
+	hooks, _ := webhook.Parse(c.asString("webhooks"))
+	return hooks
+}
+
+// PresenceGracePeriod returns how long an agent is still considered
+// alive after it last reported its presence, or zero if the default
+// period is used. The period is read by the state servers when they
+// connect to the database.
+func (c *Config) PresenceGracePeriod() time.Duration {
+	if v, ok := c.defined["presence-grace-period"].(int); ok {
+		return time.Duration(v) * time.Second
+	}
+	return 0
+}
+
 // BootstrapSSHOpts returns the SSH timeout and retry delays used
 // during bootstrap.
 func (c *Config) BootstrapSSHOpts() SSHTimeoutOpts {
@@ -703,10 +749,13 @@ var fields = schema.Fields{
 	"apt-http-proxy":            schema.String(),
 	"apt-https-proxy":           schema.String(),
 	"apt-ftp-proxy":             schema.String(),
+	"apt-mirror":                schema.String(),
 	"bootstrap-timeout":         schema.ForceInt(),
 	"bootstrap-retry-delay":     schema.ForceInt(),
 	"bootstrap-addresses-delay": schema.ForceInt(),
 	"test-mode":                 schema.Bool(),
+	"webhooks":                  schema.String(),
+	"presence-grace-period":     schema.ForceInt(),
 
 	// Deprecated fields, retain for backwards compatibility.
 	"tools-url": schema.String(),
@@ -733,6 +782,7 @@ var alwaysOptional = schema.Defaults{
 	"bootstrap-retry-delay":     schema.Omit,
 	"bootstrap-addresses-delay": schema.Omit,
 	"rsyslog-ca-cert":           schema.Omit,
+	"presence-grace-period":     schema.Omit,
 
 	// Proxy values default to "", otherwise they can't be set to blank.
 	"http-proxy":      "",
@@ -742,6 +792,10 @@ var alwaysOptional = schema.Defaults{
 	"apt-http-proxy":  "",
 	"apt-https-proxy": "",
 	"apt-ftp-proxy":   "",
+	"apt-mirror":      "",
+
+	// No webhooks are configured by default.
+	"webhooks": "",
 
 	// Deprecated fields, retain for backwards compatibility.
 	"tools-url": "",
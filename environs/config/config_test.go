@@ -19,6 +19,7 @@ import (
 	"launchpad.net/juju-core/testing"
 	jc "launchpad.net/juju-core/testing/checkers"
 	"launchpad.net/juju-core/testing/testbase"
+	"launchpad.net/juju-core/utils/webhook"
 	"launchpad.net/juju-core/version"
 )
 
@@ -404,6 +405,57 @@ var configTests = []configTest{
 			"firewall-mode": "illegal",
 		},
 		err: "invalid firewall mode in environment configuration: .*",
+	}, {
+		about:       "Apt mirror",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":       "my-type",
+			"name":       "my-name",
+			"apt-mirror": "http://mirror.example.com/ubuntu",
+		},
+	}, {
+		about:       "Invalid apt mirror",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":       "my-type",
+			"name":       "my-name",
+			"apt-mirror": "mirror.example.com/ubuntu",
+		},
+		err: `invalid apt mirror in environment configuration: "mirror.example.com/ubuntu"`,
+	}, {
+		about:       "Webhooks",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":     "my-type",
+			"name":     "my-name",
+			"webhooks": `[{"Name": "ops", "URL": "https://ops.example.com/juju", "Rules": [{"Kind": "unit", "Status": "error"}]}]`,
+		},
+	}, {
+		about:       "Invalid webhooks",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":     "my-type",
+			"name":     "my-name",
+			"webhooks": `[{"Name": "ops", "URL": "ops.example.com/juju"}]`,
+		},
+		err: `invalid webhooks in environment configuration: webhook "ops": invalid URL "ops.example.com/juju"`,
+	}, {
+		about:       "Presence grace period",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":                  "my-type",
+			"name":                  "my-name",
+			"presence-grace-period": 90,
+		},
+	}, {
+		about:       "Negative presence grace period",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":                  "my-type",
+			"name":                  "my-name",
+			"presence-grace-period": -1,
+		},
+		err: `presence-grace-period must not be negative`,
 	}, {
 		about:       "ssl-hostname-verification off",
 		useDefaults: config.UseDefaults,
@@ -1033,6 +1085,8 @@ func (s *ConfigSuite) TestConfigAttrs(c *gc.C) {
 	attrs["apt-http-proxy"] = ""
 	attrs["apt-https-proxy"] = ""
 	attrs["apt-ftp-proxy"] = ""
+	attrs["apt-mirror"] = ""
+	attrs["webhooks"] = ""
 
 	// Default firewall mode is instance
 	attrs["firewall-mode"] = string(config.FwInstance)
@@ -1270,6 +1324,34 @@ func (*ConfigSuite) TestProxyValuesNotSet(c *gc.C) {
 	c.Assert(config.FtpProxy(), gc.Equals, "")
 	c.Assert(config.AptFtpProxy(), gc.Equals, "")
 	c.Assert(config.NoProxy(), gc.Equals, "")
+	c.Assert(config.AptMirror(), gc.Equals, "")
+}
+
+func (*ConfigSuite) TestWebhooks(c *gc.C) {
+	defer makeFakeHome(c).Restore()
+
+	config := newTestConfig(c, testing.Attrs{})
+	c.Assert(config.Webhooks(), gc.HasLen, 0)
+
+	config = newTestConfig(c, testing.Attrs{
+		"webhooks": `[{"Name": "ops", "URL": "https://ops.example.com/juju", "Secret": "s3cr3t", "Rules": [{"Kind": "unit", "Status": "error"}]}]`,
+	})
+	c.Assert(config.Webhooks(), jc.DeepEquals, []webhook.Hook{{
+		Name:   "ops",
+		URL:    "https://ops.example.com/juju",
+		Secret: "s3cr3t",
+		Rules:  []webhook.Rule{{Kind: "unit", Status: "error"}},
+	}})
+}
+
+func (*ConfigSuite) TestPresenceGracePeriod(c *gc.C) {
+	defer makeFakeHome(c).Restore()
+
+	config := newTestConfig(c, testing.Attrs{})
+	c.Assert(config.PresenceGracePeriod(), gc.Equals, time.Duration(0))
+
+	config = newTestConfig(c, testing.Attrs{"presence-grace-period": 90})
+	c.Assert(config.PresenceGracePeriod(), gc.Equals, 90*time.Second)
 }
 
 func (*ConfigSuite) TestProxyConfigMap(c *gc.C) {
@@ -42,6 +42,7 @@ type environInfo struct {
 	Password     string
 	StateServers []string               `yaml:"state-servers"`
 	CACert       string                 `yaml:"ca-cert"`
+	EnvironUUID  string                 `yaml:"environ-uuid,omitempty"`
 	Config       map[string]interface{} `yaml:"bootstrap-config,omitempty"`
 }
 
@@ -134,8 +135,9 @@ func (info *environInfo) APICredentials() APICredentials {
 // APIEndpoint implements EnvironInfo.APIEndpoint.
 func (info *environInfo) APIEndpoint() APIEndpoint {
 	return APIEndpoint{
-		Addresses: info.StateServers,
-		CACert:    info.CACert,
+		Addresses:   info.StateServers,
+		CACert:      info.CACert,
+		EnvironUUID: info.EnvironUUID,
 	}
 }
 
@@ -151,6 +153,7 @@ func (info *environInfo) SetBootstrapConfig(attrs map[string]interface{}) {
 func (info *environInfo) SetAPIEndpoint(endpoint APIEndpoint) {
 	info.StateServers = endpoint.Addresses
 	info.CACert = endpoint.CACert
+	info.EnvironUUID = endpoint.EnvironUUID
 }
 
 // SetAPICredentials implements EnvironInfo.SetAPICredentials.
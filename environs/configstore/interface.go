@@ -19,6 +19,10 @@ type APIEndpoint struct {
 	// CACert holds the CA certificate that
 	// signed the API server's key.
 	CACert string
+
+	// EnvironUUID holds the UUID of the environment served
+	// by the API server. It is empty if it is not yet known.
+	EnvironUUID string
 }
 
 // APICredentials hold credentials for connecting to an API endpoint.
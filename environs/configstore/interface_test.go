@@ -46,8 +46,9 @@ func (s *interfaceSuite) TestSetAPIEndpointAndCredentials(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 
 	expectEndpoint := configstore.APIEndpoint{
-		Addresses: []string{"example.com"},
-		CACert:    "a cert",
+		Addresses:   []string{"example.com"},
+		CACert:      "a cert",
+		EnvironUUID: "0fa6ab96-7a5c-4c3b-9d0c-4ea1c8a0e7e2",
 	}
 	info.SetAPIEndpoint(expectEndpoint)
 	c.Assert(info.APIEndpoint(), gc.DeepEquals, expectEndpoint)
@@ -75,8 +76,9 @@ func (s *interfaceSuite) TestWrite(c *gc.C) {
 	info.SetAPICredentials(expectCreds)
 
 	expectEndpoint := configstore.APIEndpoint{
-		Addresses: []string{"example.com"},
-		CACert:    "a cert",
+		Addresses:   []string{"example.com"},
+		CACert:      "a cert",
+		EnvironUUID: "0fa6ab96-7a5c-4c3b-9d0c-4ea1c8a0e7e2",
 	}
 	info.SetAPIEndpoint(expectEndpoint)
 
@@ -6,6 +6,8 @@ package imagemetadata
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
+	"path"
 	"path/filepath"
 	"time"
 
@@ -28,7 +30,7 @@ func MergeAndWriteMetadata(series string, metadata []*ImageMetadata, cloudSpec *
 		return err
 	}
 	toWrite, allCloudSpec := mergeMetadata(seriesVersion, cloudSpec, metadata, existingMetadata)
-	return writeMetadata(toWrite, allCloudSpec, metadataStore)
+	return WriteMetadata(toWrite, allCloudSpec, metadataStore)
 }
 
 // readMetadata reads the image metadata from metadataStore.
@@ -44,6 +46,39 @@ func readMetadata(metadataStore storage.Storage) ([]*ImageMetadata, error) {
 	return existingMetadata, nil
 }
 
+// ReadAllMetadata reads the image metadata of every stream from the
+// products file written to metadataStore by WriteMetadata. It returns
+// no metadata if there is no such file.
+func ReadAllMetadata(metadataStore storage.StorageReader) ([]*ImageMetadata, error) {
+	r, err := storage.Get(metadataStore, path.Join(storage.BaseImagesPath, ProductMetadataPath))
+	if errors.IsNotFoundError(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return nil, err
+	}
+	cloud, err := simplestreams.ParseCloudMetadata(data, "products:1.0", ProductMetadataPath, ImageMetadata{})
+	if err != nil {
+		return nil, err
+	}
+	var metadata []*ImageMetadata
+	for id, catalog := range cloud.Products {
+		stream := productStream(id)
+		for _, collection := range catalog.Items {
+			for _, item := range collection.Items {
+				im := item.(*ImageMetadata)
+				im.Stream = stream
+				metadata = append(metadata, im)
+			}
+		}
+	}
+	return metadata, nil
+}
+
 func mapKey(im *ImageMetadata) string {
 	return fmt.Sprintf("%s-%s", im.productId(), im.RegionName)
 }
@@ -84,9 +119,9 @@ type MetadataFile struct {
 	Data []byte
 }
 
-// writeMetadata generates some basic simplestreams metadata using the specified cloud and image details and writes
+// WriteMetadata generates some basic simplestreams metadata using the specified cloud and image details and writes
 // it to the supplied store.
-func writeMetadata(metadata []*ImageMetadata, cloudSpec []simplestreams.CloudSpec,
+func WriteMetadata(metadata []*ImageMetadata, cloudSpec []simplestreams.CloudSpec,
 	metadataStore storage.Storage) error {
 
 	index, products, err := MarshalImageMetadataJSON(metadata, cloudSpec, time.Now())
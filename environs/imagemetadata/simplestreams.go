@@ -8,8 +8,10 @@ package imagemetadata
 
 import (
 	"fmt"
+	"strings"
 
 	"launchpad.net/juju-core/environs/simplestreams"
+	"launchpad.net/juju-core/version"
 )
 
 func init() {
@@ -120,24 +122,58 @@ func idStream(stream string) string {
 	return idstream
 }
 
+// productStream returns the stream of the image product with the given
+// id, as made by productId. The released stream is returned as "".
+func productStream(id string) string {
+	prefix := strings.SplitN(id, ":", 2)[0]
+	if stream := strings.TrimPrefix(prefix, "com.ubuntu.cloud."); stream != prefix {
+		return stream
+	}
+	return ""
+}
+
 // Generates a string array representing product ids formed similarly to an ISCSI qualified name (IQN).
 func (ic *ImageConstraint) Ids() ([]string, error) {
 	stream := idStream(ic.Stream)
-	nrArches := len(ic.Arches)
-	nrSeries := len(ic.Series)
-	ids := make([]string, nrArches*nrSeries)
-	for i, arch := range ic.Arches {
-		for j, series := range ic.Series {
-			version, err := simplestreams.SeriesVersion(series)
-			if err != nil {
-				return nil, err
+	var ids []string
+	for _, series := range ic.Series {
+		vers, err := simplestreams.SeriesVersion(series)
+		if err != nil {
+			return nil, err
+		}
+		for _, arch := range ic.Arches {
+			ids = append(ids, productId(stream, vers, arch))
+			if version.IsWindowsSeries(vers) {
+				// Windows images were first published under the
+				// server product, and mirrors made then may not
+				// have been updated, so they are looked up there
+				// too.
+				ids = append(ids, legacyProductId(stream, vers, arch))
 			}
-			ids[j*nrArches+i] = fmt.Sprintf("com.ubuntu.cloud%s:server:%s:%s", stream, version, arch)
 		}
 	}
 	return ids, nil
 }
 
+// productId returns the id of the image product for the given
+// id stream, series version and architecture. Windows images are
+// published as a separate product from Ubuntu server images; as
+// Windows series have no version numbers, their versions are the
+// series themselves.
+func productId(stream, seriesVersion, arch string) string {
+	product := "server"
+	if version.IsWindowsSeries(seriesVersion) {
+		product = "windows"
+	}
+	return fmt.Sprintf("com.ubuntu.cloud%s:%s:%s:%s", stream, product, seriesVersion, arch)
+}
+
+// legacyProductId returns the id under which images for the given
+// Windows series were published before they had their own product.
+func legacyProductId(stream, seriesVersion, arch string) string {
+	return fmt.Sprintf("com.ubuntu.cloud%s:server:%s:%s", stream, seriesVersion, arch)
+}
+
 // ImageMetadata holds information about a particular cloud image.
 type ImageMetadata struct {
 	Id          string `json:"id"`
@@ -156,8 +192,7 @@ func (im *ImageMetadata) String() string {
 }
 
 func (im *ImageMetadata) productId() string {
-	stream := idStream(im.Stream)
-	return fmt.Sprintf("com.ubuntu.cloud%s:server:%s:%s", stream, im.Version, im.Arch)
+	return productId(idStream(im.Stream), im.Version, im.Arch)
 }
 
 // Fetch returns a list of images for the specified cloud matching the constraint.
@@ -328,6 +328,19 @@ func (s *productSpecSuite) TestIdMultiArch(c *gc.C) {
 		"com.ubuntu.cloud.daily:server:12.04:i386"})
 }
 
+func (s *productSpecSuite) TestIdWindows(c *gc.C) {
+	imageConstraint := imagemetadata.NewImageConstraint(simplestreams.LookupParams{
+		Series: []string{"precise", "win2012r2"},
+		Arches: []string{"amd64"},
+	})
+	ids, err := imageConstraint.Ids()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ids, gc.DeepEquals, []string{
+		"com.ubuntu.cloud:server:12.04:amd64",
+		"com.ubuntu.cloud:windows:win2012r2:amd64",
+		"com.ubuntu.cloud:server:win2012r2:amd64"})
+}
+
 type signedSuite struct {
 	origKey string
 }
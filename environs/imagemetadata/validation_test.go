@@ -4,14 +4,17 @@
 package imagemetadata_test
 
 import (
+	"io/ioutil"
 	"path"
 	"path/filepath"
+	"strings"
 
 	gc "launchpad.net/gocheck"
 
 	"launchpad.net/juju-core/environs/filestorage"
 	"launchpad.net/juju-core/environs/imagemetadata"
 	"launchpad.net/juju-core/environs/simplestreams"
+	jc "launchpad.net/juju-core/testing/checkers"
 	"launchpad.net/juju-core/testing/testbase"
 )
 
@@ -77,6 +80,39 @@ func (s *ValidateSuite) TestMatch(c *gc.C) {
 	s.assertMatch(c, "daily")
 }
 
+func (s *ValidateSuite) TestMatchWindows(c *gc.C) {
+	s.makeLocalMetadata(c, "1234", "region-2", "win2012r2", "some-auth-url", "")
+	metadataPath := filepath.Join(s.metadataDir, "images")
+	params := &simplestreams.MetadataLookupParams{
+		Region:        "region-2",
+		Series:        "win2012r2",
+		Architectures: []string{"amd64"},
+		Endpoint:      "some-auth-url",
+		Sources: []simplestreams.DataSource{
+			simplestreams.NewURLDataSource("test", "file://"+metadataPath, simplestreams.VerifySSLHostnames)},
+	}
+	imageIds, _, err := imagemetadata.ValidateImageMetadata(params)
+	c.Assert(err, gc.IsNil)
+	c.Assert(imageIds, gc.DeepEquals, []string{"1234"})
+
+	// Windows images published under the server
+	// product, as they once were, are also found.
+	files, err := filepath.Glob(filepath.Join(metadataPath, "streams", "v1", "*.json"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(files, gc.Not(gc.HasLen), 0)
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		c.Assert(err, gc.IsNil)
+		c.Assert(string(data), jc.Contains, "com.ubuntu.cloud:windows:win2012r2:amd64")
+		data = []byte(strings.Replace(string(data), ":windows:", ":server:", -1))
+		err = ioutil.WriteFile(file, data, 0644)
+		c.Assert(err, gc.IsNil)
+	}
+	imageIds, _, err = imagemetadata.ValidateImageMetadata(params)
+	c.Assert(err, gc.IsNil)
+	c.Assert(imageIds, gc.DeepEquals, []string{"1234"})
+}
+
 func (s *ValidateSuite) assertNoMatch(c *gc.C, stream string) {
 	s.makeLocalMetadata(c, "1234", "region-2", "raring", "some-auth-url", stream)
 	params := &simplestreams.MetadataLookupParams{
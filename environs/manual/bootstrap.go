@@ -66,7 +66,7 @@ func Bootstrap(args BootstrapArgs) (err error) {
 		return errors.New("possible tools is empty")
 	}
 
-	provisioned, err := checkProvisioned(args.Host)
+	provisioned, err := checkProvisioned(args.Host, nil)
 	if err != nil {
 		return fmt.Errorf("failed to check provisioned status: %v", err)
 	}
@@ -0,0 +1,118 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package manual
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+
+	"launchpad.net/juju-core/utils/parallel"
+)
+
+// DefaultMaxParallel is the default number of hosts
+// provisioned concurrently by ProvisionMachines.
+const DefaultMaxParallel = 10
+
+// ProvisionResult holds the result of provisioning a single host.
+type ProvisionResult struct {
+	// Host is the host, as specified in the arguments.
+	Host string
+
+	// MachineId holds the id of the machine entered
+	// into state, if provisioning succeeded.
+	MachineId string
+
+	// Error holds the error encountered provisioning the
+	// host, if any. It is ErrProvisioned if the host
+	// already has a machine agent, in which case the
+	// host was left untouched.
+	Error error
+}
+
+// provisionMachine is called by ProvisionMachines
+// to provision each host; it may be replaced in tests.
+var provisionMachine = ProvisionMachine
+
+// ProvisionMachines provisions machine agents to the hosts described
+// by args, running up to maxParallel provisioning attempts at once.
+// A result is returned for each host, in the order of args.
+//
+// Each host is provisioned without a terminal, so there must be no
+// need to respond to sudo prompts. Progress is written to progress,
+// with each line prefixed by the host it relates to.
+func ProvisionMachines(args []ProvisionMachineArgs, maxParallel int, progress io.Writer) []ProvisionResult {
+	if maxParallel < 1 {
+		maxParallel = DefaultMaxParallel
+	}
+	results := make([]ProvisionResult, len(args))
+	var mu sync.Mutex
+	run := parallel.NewRun(maxParallel)
+	for i, hostArgs := range args {
+		i, hostArgs := i, hostArgs
+		run.Do(func() error {
+			_, hostname := splitUserHost(hostArgs.Host)
+			w := &prefixWriter{
+				mu:     &mu,
+				w:      progress,
+				prefix: hostname + ": ",
+			}
+			hostArgs.Stdin = nil
+			hostArgs.Stdout = w
+			hostArgs.Stderr = w
+			machineId, err := provisionMachine(hostArgs)
+			w.Flush()
+			results[i] = ProvisionResult{
+				Host:      hostArgs.Host,
+				MachineId: machineId,
+				Error:     err,
+			}
+			return nil
+		})
+	}
+	run.Wait()
+	return results
+}
+
+// prefixWriter writes complete lines to an underlying writer,
+// prefixing each one. The underlying writer may be shared by
+// several prefixWriters using the same mutex.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix string
+	buf    bytes.Buffer
+}
+
+func (w *prefixWriter) Write(data []byte) (int, error) {
+	w.buf.Write(data)
+	for {
+		i := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if i < 0 {
+			break
+		}
+		if err := w.writeLine(w.buf.Next(i + 1)); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// Flush writes any incomplete final line.
+func (w *prefixWriter) Flush() error {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	line := append(w.buf.Bytes(), '\n')
+	w.buf.Reset()
+	return w.writeLine(line)
+}
+
+func (w *prefixWriter) writeLine(line []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := fmt.Fprintf(w.w, "%s%s", w.prefix, line)
+	return err
+}
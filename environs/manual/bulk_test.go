@@ -0,0 +1,72 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package manual
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/testing/testbase"
+)
+
+type bulkSuite struct {
+	testbase.LoggingSuite
+}
+
+var _ = gc.Suite(&bulkSuite{})
+
+func (s *bulkSuite) TestProvisionMachines(c *gc.C) {
+	var mu sync.Mutex
+	var running, maxRunning int
+	s.PatchValue(&provisionMachine, func(args ProvisionMachineArgs) (string, error) {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+		c.Check(args.Stdin, gc.IsNil)
+		fmt.Fprintf(args.Stderr, "provisioning\nas %s", args.Series)
+		switch args.Host {
+		case "ubuntu@host1":
+			return "", ErrProvisioned
+		case "host3":
+			return "", errors.New("no route to host")
+		}
+		return "machine-" + args.Host, nil
+	})
+	var args []ProvisionMachineArgs
+	for _, host := range []string{"host0", "ubuntu@host1", "host2", "host3", "host4"} {
+		args = append(args, ProvisionMachineArgs{Host: host, Series: "precise"})
+	}
+	var progress bytes.Buffer
+	results := ProvisionMachines(args, 2, &progress)
+	c.Assert(results, gc.DeepEquals, []ProvisionResult{
+		{Host: "host0", MachineId: "machine-host0"},
+		{Host: "ubuntu@host1", Error: ErrProvisioned},
+		{Host: "host2", MachineId: "machine-host2"},
+		{Host: "host3", Error: errors.New("no route to host")},
+		{Host: "host4", MachineId: "machine-host4"},
+	})
+	c.Assert(maxRunning <= 2, gc.Equals, true)
+
+	lines := strings.Split(strings.TrimSpace(progress.String()), "\n")
+	sort.Strings(lines)
+	var expected []string
+	for _, host := range []string{"host0", "host1", "host2", "host3", "host4"} {
+		expected = append(expected, host+": as precise", host+": provisioning")
+	}
+	c.Assert(lines, gc.DeepEquals, expected)
+}
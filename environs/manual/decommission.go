@@ -0,0 +1,151 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package manual
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"launchpad.net/juju-core/instance"
+	"launchpad.net/juju-core/juju/osenv"
+	"launchpad.net/juju-core/utils"
+	"launchpad.net/juju-core/utils/ssh"
+	"launchpad.net/juju-core/version"
+)
+
+// HostFromInstanceId returns the host of a manually provisioned
+// machine, given its instance id. It returns false if the machine
+// was not manually provisioned, or is the bootstrap machine, whose
+// instance id does not record its host.
+func HostFromInstanceId(id instance.Id) (string, bool) {
+	host := string(id)
+	if !strings.HasPrefix(host, manualInstancePrefix) || id == BootstrapInstanceId {
+		return "", false
+	}
+	return host[len(manualInstancePrefix):], true
+}
+
+type DecommissionMachineArgs struct {
+	// Host is the SSH host: [user@]host. If no user
+	// is specified, the ubuntu user is used. The user
+	// is ignored for Windows machines.
+	Host string
+
+	// Series is the series of the machine, which
+	// determines how it is decommissioned.
+	Series string
+
+	// KeepData, if true, leaves the juju data
+	// and log directories on the machine.
+	KeepData bool
+
+	// Stderr is used to present the output of
+	// decommissioning to the user.
+	Stderr io.Writer
+}
+
+// DecommissionMachine removes the juju agents from a manually
+// provisioned machine, so that it can be provisioned again. It
+// connects to the machine via SSH, stops and removes the services
+// of the machine agent, any unit agents and the database, and
+// removes the juju data and log directories, which hold the
+// units' charm directories, unless KeepData is true.
+//
+// Windows machines are instead decommissioned with a PowerShell
+// script run via WinRM, authenticated with the client certificate
+// in $JUJU_HOME/winrm.
+func DecommissionMachine(args DecommissionMachineArgs) error {
+	user, hostname := splitUserHost(args.Host)
+	if version.IsWindowsSeries(args.Series) {
+		logger.Infof("decommissioning %s", hostname)
+		return runWindowsDecommissionScript(hostname, windowsDecommissionScript(args.KeepData), args.Stderr)
+	}
+	host := args.Host
+	if user == "" {
+		host = "ubuntu@" + host
+	}
+	logger.Infof("decommissioning %s", host)
+	return runDecommissionScript(host, []string{"sudo", "/bin/bash"}, decommissionScript(args.KeepData), args.Stderr)
+}
+
+var runDecommissionScript = func(host string, command []string, script string, stderr io.Writer) error {
+	cmd := ssh.Command(host, command, nil)
+	var stderrBuf bytes.Buffer
+	cmd.Stdin = strings.NewReader(script)
+	cmd.Stderr = teeStderr(stderr, &stderrBuf)
+	return annotateStderr(cmd.Run(), &stderrBuf)
+}
+
+var runWindowsDecommissionScript = func(host, script string, stderr io.Writer) error {
+	client, err := newWinRMClient(host)
+	if err != nil {
+		return err
+	}
+	return runPowerShell(client, script, nil, nil, stderr)
+}
+
+// teeStderr returns a writer that writes to buf,
+// and also to stderr if it is not nil.
+func teeStderr(stderr io.Writer, buf *bytes.Buffer) io.Writer {
+	if stderr == nil {
+		return buf
+	}
+	return io.MultiWriter(stderr, buf)
+}
+
+// annotateStderr adds the output captured
+// in stderrBuf to err, if both are non-empty.
+func annotateStderr(err error, stderrBuf *bytes.Buffer) error {
+	if err != nil && stderrBuf.Len() != 0 {
+		err = fmt.Errorf("%v (%v)", err, strings.TrimSpace(stderrBuf.String()))
+	}
+	return err
+}
+
+// The paths of the juju data and log directories, and the
+// juju-run command, on Ubuntu machines.
+const (
+	ubuntuDataDir = "/var/lib/juju"
+	ubuntuLogDir  = "/var/log/juju"
+	ubuntuJujuRun = "/usr/local/bin/juju-run"
+)
+
+// decommissionScript returns a script that stops and removes
+// the upstart jobs of all juju agents and the database, and
+// removes the files installed by provisioning.
+func decommissionScript(keepData bool) string {
+	script := `
+for conf in /etc/init/juju*.conf; do
+    [ -e "$conf" ] || continue
+    job=$(basename "$conf" .conf)
+    stop "$job" || true
+    rm -f "$conf"
+done
+rm -f ` + utils.ShQuote(ubuntuJujuRun) + `
+`
+	if !keepData {
+		script += fmt.Sprintf("rm -rf %s %s\n", utils.ShQuote(ubuntuDataDir), utils.ShQuote(ubuntuLogDir))
+	}
+	return script
+}
+
+// windowsDecommissionScript returns the PowerShell equivalent
+// of decommissionScript.
+func windowsDecommissionScript(keepData bool) string {
+	script := `
+Get-Service -Name 'jujud-*','juju-db' -ErrorAction SilentlyContinue | ForEach-Object {
+    Stop-Service -Name $_.Name -Force -ErrorAction SilentlyContinue
+    & sc.exe delete $_.Name | Out-Null
+}
+Remove-Item -Force -ErrorAction SilentlyContinue '` + utils.PathToWindows(path.Join(osenv.WinBinDir, "juju-run.exe")) + `'
+`
+	if !keepData {
+		script += fmt.Sprintf("Remove-Item -Recurse -Force -ErrorAction SilentlyContinue '%s','%s'\n",
+			utils.PathToWindows(osenv.WinDataDir), utils.PathToWindows(osenv.WinLogDir))
+	}
+	return script
+}
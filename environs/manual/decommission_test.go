@@ -0,0 +1,84 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package manual
+
+import (
+	"io"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/instance"
+	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/testing/testbase"
+	sshtesting "launchpad.net/juju-core/utils/ssh/testing"
+)
+
+type decommissionSuite struct {
+	testbase.LoggingSuite
+}
+
+var _ = gc.Suite(&decommissionSuite{})
+
+func (s *decommissionSuite) TestHostFromInstanceId(c *gc.C) {
+	host, ok := HostFromInstanceId(instance.Id("manual:10.0.0.1"))
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(host, gc.Equals, "10.0.0.1")
+	_, ok = HostFromInstanceId(BootstrapInstanceId)
+	c.Assert(ok, jc.IsFalse)
+	_, ok = HostFromInstanceId(instance.Id("i-1234"))
+	c.Assert(ok, jc.IsFalse)
+}
+
+func (s *decommissionSuite) TestDecommissionMachine(c *gc.C) {
+	defer sshtesting.InstallFakeSSH(c, decommissionScript(false), nil, 0)()
+	err := DecommissionMachine(DecommissionMachineArgs{Host: "10.0.0.1"})
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *decommissionSuite) TestDecommissionMachineError(c *gc.C) {
+	defer sshtesting.InstallFakeSSH(c, nil, []string{"", "oops"}, 1)()
+	err := DecommissionMachine(DecommissionMachineArgs{Host: "10.0.0.1"})
+	c.Assert(err, gc.ErrorMatches, `exit status 1 \(oops\)`)
+}
+
+func (s *decommissionSuite) TestDecommissionScripts(c *gc.C) {
+	type call struct {
+		host    string
+		command []string
+		script  string
+	}
+	var calls []call
+	s.PatchValue(&runDecommissionScript, func(host string, command []string, script string, stderr io.Writer) error {
+		calls = append(calls, call{host, command, script})
+		return nil
+	})
+	s.PatchValue(&runWindowsDecommissionScript, func(host, script string, stderr io.Writer) error {
+		calls = append(calls, call{host, nil, script})
+		return nil
+	})
+	for _, args := range []DecommissionMachineArgs{
+		{Host: "admin@host0", Series: "precise"},
+		{Host: "host1", Series: "precise", KeepData: true},
+		{Host: "admin@host2", Series: "win2012r2"},
+		{Host: "host3", Series: "win2012r2", KeepData: true},
+	} {
+		err := DecommissionMachine(args)
+		c.Assert(err, gc.IsNil)
+	}
+	c.Assert(calls, gc.HasLen, 4)
+
+	c.Check(calls[0].host, gc.Equals, "admin@host0")
+	c.Check(calls[0].command, gc.DeepEquals, []string{"sudo", "/bin/bash"})
+	c.Check(calls[0].script, jc.Contains, `stop "$job"`)
+	c.Check(calls[0].script, jc.Contains, `rm -rf '/var/lib/juju' '/var/log/juju'`)
+	c.Check(calls[1].host, gc.Equals, "ubuntu@host1")
+	c.Check(calls[1].script, jc.Contains, `rm -f '/usr/local/bin/juju-run'`)
+	c.Check(calls[1].script, gc.Not(jc.Contains), "rm -rf")
+
+	c.Check(calls[2].host, gc.Equals, "host2")
+	c.Check(calls[2].command, gc.IsNil)
+	c.Check(calls[2].script, jc.Contains, `sc.exe delete`)
+	c.Check(calls[2].script, jc.Contains, `Remove-Item -Recurse -Force -ErrorAction SilentlyContinue 'C:\Juju\lib\juju','C:\Juju\log'`)
+	c.Check(calls[3].script, gc.Not(jc.Contains), "-Recurse")
+}
@@ -24,10 +24,11 @@ import (
 const checkProvisionedScript = "ls /etc/init/ | grep juju.*\\.conf || exit 0"
 
 // checkProvisioned checks if any juju upstart jobs already
-// exist on the host machine.
-func checkProvisioned(host string) (bool, error) {
+// exist on the host machine, connecting with the given
+// options, which may be nil.
+func checkProvisioned(host string, options *ssh.Options) (bool, error) {
 	logger.Infof("Checking if %s is already provisioned", host)
-	cmd := ssh.Command("ubuntu@"+host, []string{"/bin/bash"}, nil)
+	cmd := ssh.Command("ubuntu@"+host, []string{"/bin/bash"}, options)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -52,8 +53,15 @@ func checkProvisioned(host string) (bool, error) {
 // series and hardware characteristics of the remote machine
 // by connecting to the machine and executing a bash script.
 func DetectSeriesAndHardwareCharacteristics(host string) (hc instance.HardwareCharacteristics, series string, err error) {
+	return detectSeriesAndHardwareCharacteristics(host, nil)
+}
+
+// detectSeriesAndHardwareCharacteristics implements
+// DetectSeriesAndHardwareCharacteristics, connecting
+// with the given options, which may be nil.
+func detectSeriesAndHardwareCharacteristics(host string, options *ssh.Options) (hc instance.HardwareCharacteristics, series string, err error) {
 	logger.Infof("Detecting series and characteristics on %s", host)
-	cmd := ssh.Command("ubuntu@"+host, []string{"/bin/bash"}, nil)
+	cmd := ssh.Command("ubuntu@"+host, []string{"/bin/bash"}, options)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -154,6 +162,13 @@ cat /proc/cpuinfo`
 // stdin and stdout will be used for remote sudo prompts,
 // if the ubuntu user must be created/updated.
 func InitUbuntuUser(host, login, authorizedKeys string, stdin io.Reader, stdout io.Writer) error {
+	return initUbuntuUser(host, login, authorizedKeys, nil, stdin, stdout)
+}
+
+// initUbuntuUser implements InitUbuntuUser, additionally
+// logging in with the given identity files if any are
+// specified.
+func initUbuntuUser(host, login, authorizedKeys string, identities []string, stdin io.Reader, stdout io.Writer) error {
 	logger.Infof("initialising %q, user %q", host, login)
 
 	// To avoid unnecessary prompting for the specified login,
@@ -163,7 +178,9 @@ func InitUbuntuUser(host, login, authorizedKeys string, stdin io.Reader, stdout
 	//
 	// Note that we explicitly do not allocate a PTY, so we
 	// get a failure if sudo prompts.
-	cmd := ssh.Command("ubuntu@"+host, []string{"sudo", "-n", "true"}, nil)
+	var ubuntuOptions ssh.Options
+	ubuntuOptions.SetIdentities(identities...)
+	cmd := ssh.Command("ubuntu@"+host, []string{"sudo", "-n", "true"}, &ubuntuOptions)
 	if cmd.Run() == nil {
 		logger.Infof("ubuntu user is already initialised")
 		return nil
@@ -178,6 +195,7 @@ func InitUbuntuUser(host, login, authorizedKeys string, stdin io.Reader, stdout
 	var options ssh.Options
 	options.AllowPasswordAuthentication()
 	options.EnablePTY()
+	options.SetIdentities(identities...)
 	cmd = ssh.Command(host, []string{"sudo", "/bin/bash -c " + utils.ShQuote(script)}, &options)
 	var stderr bytes.Buffer
 	cmd.Stdin = stdin
@@ -115,25 +115,25 @@ func (s *initialisationSuite) TestDetectHardwareCharacteristics(c *gc.C) {
 
 func (s *initialisationSuite) TestCheckProvisioned(c *gc.C) {
 	defer sshtesting.InstallFakeSSH(c, checkProvisionedScript, "", 0)()
-	provisioned, err := checkProvisioned("example.com")
+	provisioned, err := checkProvisioned("example.com", nil)
 	c.Assert(err, gc.IsNil)
 	c.Assert(provisioned, jc.IsFalse)
 
 	defer sshtesting.InstallFakeSSH(c, checkProvisionedScript, "non-empty", 0)()
-	provisioned, err = checkProvisioned("example.com")
+	provisioned, err = checkProvisioned("example.com", nil)
 	c.Assert(err, gc.IsNil)
 	c.Assert(provisioned, jc.IsTrue)
 
 	// stderr should not affect result.
 	defer sshtesting.InstallFakeSSH(c, checkProvisionedScript, []string{"", "non-empty-stderr"}, 0)()
-	provisioned, err = checkProvisioned("example.com")
+	provisioned, err = checkProvisioned("example.com", nil)
 	c.Assert(err, gc.IsNil)
 	c.Assert(provisioned, jc.IsFalse)
 
 	// if the script fails for whatever reason, then checkProvisioned
 	// will return an error. stderr will be included in the error message.
 	defer sshtesting.InstallFakeSSH(c, checkProvisionedScript, []string{"non-empty-stdout", "non-empty-stderr"}, 255)()
-	_, err = checkProvisioned("example.com")
+	_, err = checkProvisioned("example.com", nil)
 	c.Assert(err, gc.ErrorMatches, "rc: 255 \\(non-empty-stderr\\)")
 }
 
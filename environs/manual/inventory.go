@@ -0,0 +1,124 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package manual
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"launchpad.net/goyaml"
+
+	"launchpad.net/juju-core/utils"
+)
+
+// InventoryHost describes a host to be provisioned.
+type InventoryHost struct {
+	// Host is the SSH host: [user@]host.
+	Host string `yaml:"host,omitempty"`
+
+	// User is the login used to initialise the ubuntu user,
+	// if Host does not specify one.
+	User string `yaml:"user,omitempty"`
+
+	// SSHKey is the path to a private key file to log in with
+	// when initialising the ubuntu user.
+	SSHKey string `yaml:"ssh-key,omitempty"`
+
+	// Series overrides the series detected on the host.
+	Series string `yaml:"series,omitempty"`
+}
+
+// Inventory describes a set of existing hosts to be
+// provisioned in bulk. The settings in Defaults apply
+// to each host that does not specify its own.
+//
+// For example:
+//
+//	defaults:
+//	  user: admin
+//	  ssh-key: ~/.ssh/fleet_rsa
+//	hosts:
+//	  - host: 10.0.0.1
+//	  - host: db1.example.com
+//	    user: root
+//	    series: trusty
+type Inventory struct {
+	Defaults InventoryHost   `yaml:"defaults,omitempty"`
+	Hosts    []InventoryHost `yaml:"hosts"`
+}
+
+// ReadInventory reads an inventory from the named file.
+func ReadInventory(path string) (*Inventory, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	inventory, err := ParseInventory(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse inventory %q: %v", path, err)
+	}
+	return inventory, nil
+}
+
+// ParseInventory parses an inventory from YAML, and
+// checks that every host is specified exactly once.
+func ParseInventory(data []byte) (*Inventory, error) {
+	var inventory Inventory
+	if err := goyaml.Unmarshal(data, &inventory); err != nil {
+		return nil, err
+	}
+	if inventory.Defaults.Host != "" {
+		return nil, fmt.Errorf("host cannot be specified in defaults")
+	}
+	if len(inventory.Hosts) == 0 {
+		return nil, fmt.Errorf("no hosts specified")
+	}
+	seen := make(map[string]bool)
+	for i, host := range inventory.Hosts {
+		if host.Host == "" {
+			return nil, fmt.Errorf("no host specified for entry %d", i)
+		}
+		_, hostname := splitUserHost(host.Host)
+		if seen[hostname] {
+			return nil, fmt.Errorf("host %q specified more than once", hostname)
+		}
+		seen[hostname] = true
+	}
+	return &inventory, nil
+}
+
+// ProvisionMachineArgs returns the arguments for provisioning
+// each host in the inventory, based on the given template.
+// The template's series applies to hosts that specify none.
+func (inventory *Inventory) ProvisionMachineArgs(template ProvisionMachineArgs) ([]ProvisionMachineArgs, error) {
+	args := make([]ProvisionMachineArgs, len(inventory.Hosts))
+	for i, host := range inventory.Hosts {
+		if host.User == "" {
+			host.User = inventory.Defaults.User
+		}
+		if host.SSHKey == "" {
+			host.SSHKey = inventory.Defaults.SSHKey
+		}
+		if host.Series == "" {
+			host.Series = inventory.Defaults.Series
+		}
+		hostArgs := template
+		hostArgs.Host = host.Host
+		if user, _ := splitUserHost(host.Host); user == "" && host.User != "" {
+			hostArgs.Host = host.User + "@" + host.Host
+		}
+		if host.SSHKey != "" {
+			path, err := utils.NormalizePath(host.SSHKey)
+			if err != nil {
+				return nil, err
+			}
+			hostArgs.IdentityFile = path
+		}
+		if host.Series != "" {
+			hostArgs.Series = host.Series
+		}
+		args[i] = hostArgs
+	}
+	return args, nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package manual
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/testing/testbase"
+	"launchpad.net/juju-core/utils"
+)
+
+type inventorySuite struct {
+	testbase.LoggingSuite
+}
+
+var _ = gc.Suite(&inventorySuite{})
+
+const testInventory = `
+defaults:
+  user: admin
+  ssh-key: ~/.ssh/fleet_rsa
+  series: precise
+hosts:
+  - host: 10.0.0.1
+  - host: root@10.0.0.2
+  - host: db1.example.com
+    user: ops
+    ssh-key: /etc/keys/db
+    series: trusty
+`
+
+func (s *inventorySuite) TestReadInventory(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "inventory.yaml")
+	err := ioutil.WriteFile(path, []byte(testInventory), 0644)
+	c.Assert(err, gc.IsNil)
+	inventory, err := ReadInventory(path)
+	c.Assert(err, gc.IsNil)
+
+	args, err := inventory.ProvisionMachineArgs(ProvisionMachineArgs{EnvName: "env"})
+	c.Assert(err, gc.IsNil)
+	fleetKey, err := utils.NormalizePath("~/.ssh/fleet_rsa")
+	c.Assert(err, gc.IsNil)
+	c.Assert(args, gc.DeepEquals, []ProvisionMachineArgs{{
+		Host:         "admin@10.0.0.1",
+		EnvName:      "env",
+		IdentityFile: fleetKey,
+		Series:       "precise",
+	}, {
+		Host:         "root@10.0.0.2",
+		EnvName:      "env",
+		IdentityFile: fleetKey,
+		Series:       "precise",
+	}, {
+		Host:         "ops@db1.example.com",
+		EnvName:      "env",
+		IdentityFile: "/etc/keys/db",
+		Series:       "trusty",
+	}})
+}
+
+func (s *inventorySuite) TestProvisionMachineArgsTemplateSeries(c *gc.C) {
+	inventory, err := ParseInventory([]byte(`
+hosts:
+  - host: 10.0.0.1
+  - host: 10.0.0.2
+    series: trusty
+`))
+	c.Assert(err, gc.IsNil)
+	args, err := inventory.ProvisionMachineArgs(ProvisionMachineArgs{Series: "precise"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(args, gc.HasLen, 2)
+	c.Assert(args[0].Series, gc.Equals, "precise")
+	c.Assert(args[1].Series, gc.Equals, "trusty")
+}
+
+var parseInventoryErrorTests = []struct {
+	yaml string
+	err  string
+}{{
+	yaml: "hosts: []",
+	err:  "no hosts specified",
+}, {
+	yaml: "defaults: {host: foo}\nhosts: [{host: bar}]",
+	err:  "host cannot be specified in defaults",
+}, {
+	yaml: "hosts: [{host: foo}, {user: admin}]",
+	err:  "no host specified for entry 1",
+}, {
+	yaml: "hosts: [{host: foo}, {host: admin@foo}]",
+	err:  `host "foo" specified more than once`,
+}}
+
+func (s *inventorySuite) TestParseInventoryErrors(c *gc.C) {
+	for i, t := range parseInventoryErrorTests {
+		c.Logf("test %d: %s", i, t.yaml)
+		_, err := ParseInventory([]byte(t.yaml))
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
@@ -24,6 +24,8 @@ import (
 	"launchpad.net/juju-core/state/statecmd"
 	"launchpad.net/juju-core/tools"
 	"launchpad.net/juju-core/utils"
+	"launchpad.net/juju-core/utils/ssh"
+	"launchpad.net/juju-core/version"
 )
 
 const manualInstancePrefix = "manual:"
@@ -31,7 +33,8 @@ const manualInstancePrefix = "manual:"
 var logger = loggo.GetLogger("juju.environs.manual")
 
 type ProvisionMachineArgs struct {
-	// Host is the SSH host: [user@]host
+	// Host is the SSH host: [user@]host. The user
+	// is ignored for Windows machines.
 	Host string
 
 	// DataDir is the root directory for juju data.
@@ -45,6 +48,16 @@ type ProvisionMachineArgs struct {
 	// chosen using environs/tools FindInstanceTools.
 	Tools *tools.Tools
 
+	// IdentityFile, if non-empty, is the path to a private key
+	// file to log in with when initialising the ubuntu user.
+	IdentityFile string
+
+	// Series, if non-empty, overrides the series
+	// detected on the host. It must be specified
+	// for Windows machines, which are provisioned
+	// via WinRM rather than SSH.
+	Series string
+
 	// Stdin is required to respond to sudo prompts,
 	// and must be a terminal (except in tests)
 	Stdin io.Reader
@@ -64,6 +77,10 @@ var ErrProvisioned = errors.New("machine is already provisioned")
 // an SSH connection to the specified host. The host may optionally be preceded
 // with a login username, as in [user@]host.
 //
+// Windows machines, identified by args.Series, are instead provisioned
+// with a PowerShell script run via WinRM, authenticated with the client
+// certificate in $JUJU_HOME/winrm.
+//
 // On successful completion, this function will return the id of the state.Machine
 // that was entered into state.
 func ProvisionMachine(args ProvisionMachineArgs) (machineId string, err error) {
@@ -102,20 +119,36 @@ func ProvisionMachine(args ProvisionMachineArgs) (machineId string, err error) {
 		client.Close()
 	}()
 
+	if version.IsWindowsSeries(args.Series) {
+		_, hostname := splitUserHost(args.Host)
+		return provisionWindowsMachine(client, hostname, args)
+	}
+
 	// Create the "ubuntu" user and initialise passwordless sudo. We populate
 	// the ubuntu user's authorized_keys file with the public keys in the current
 	// user's ~/.ssh directory. The authenticationworker will later update the
 	// ubuntu user's authorized_keys.
 	user, hostname := splitUserHost(args.Host)
 	authorizedKeys, err := config.ReadAuthorizedKeys("")
-	if err := InitUbuntuUser(hostname, user, authorizedKeys, args.Stdin, args.Stdout); err != nil {
+	var identities []string
+	if args.IdentityFile != "" {
+		identities = []string{args.IdentityFile}
+	}
+	if err := initUbuntuUser(hostname, user, authorizedKeys, identities, args.Stdin, args.Stdout); err != nil {
 		return "", err
 	}
+	// The identity file may also be needed to log in as the
+	// ubuntu user, so it is used for every later connection.
+	var options ssh.Options
+	options.SetIdentities(identities...)
 
-	machineParams, err := gatherMachineParams(hostname)
+	machineParams, err := gatherMachineParams(hostname, &options)
 	if err != nil {
 		return "", err
 	}
+	if args.Series != "" {
+		machineParams.Series = args.Series
+	}
 
 	// Inform Juju that the machine exists.
 	machineId, err = recordMachineInState(client, *machineParams)
@@ -151,7 +184,7 @@ func ProvisionMachine(args ProvisionMachineArgs) (machineId string, err error) {
 	}
 
 	// Finally, provision the machine agent.
-	err = runProvisionScript(provisioningScript, hostname, args.Stderr)
+	err = runProvisionScript(provisioningScript, hostname, &options, args.Stderr)
 	if err != nil {
 		return machineId, err
 	}
@@ -222,14 +255,35 @@ func recordMachineInState1dot16(
 // we are about to provision. It will SSH into that machine as the ubuntu user.
 // The hostname supplied should not include a username.
 // If we can, we will reverse lookup the hostname by its IP address, and use
-// the DNS resolved name, rather than the name that was supplied
-func gatherMachineParams(hostname string) (*params.AddMachineParams, error) {
+// the DNS resolved name, rather than the name that was supplied.
+// The options, which may be nil, are used to connect to the machine.
+func gatherMachineParams(hostname string, options *ssh.Options) (*params.AddMachineParams, error) {
+	hostname, addrs, err := resolveHost(hostname)
+	if err != nil {
+		return nil, err
+	}
 
-	// Generate a unique nonce for the machine.
-	uuid, err := utils.NewUUID()
+	provisioned, err := checkProvisioned(hostname, options)
 	if err != nil {
+		err = fmt.Errorf("error checking if provisioned: %v", err)
 		return nil, err
 	}
+	if provisioned {
+		return nil, ErrProvisioned
+	}
+
+	hc, series, err := detectSeriesAndHardwareCharacteristics(hostname, options)
+	if err != nil {
+		err = fmt.Errorf("error detecting hardware characteristics: %v", err)
+		return nil, err
+	}
+	return newMachineParams(hostname, addrs, series, hc)
+}
+
+// resolveHost returns the name to use for the given host, and
+// its addresses. If the host is an IP address with a DNS entry,
+// the DNS name is used.
+func resolveHost(hostname string) (string, []instance.Address, error) {
 	// First, gather the parameters needed to inject the existing host into state.
 	if ip := net.ParseIP(hostname); ip != nil {
 		// Do a reverse-lookup on the IP. The IP may not have
@@ -255,22 +309,20 @@ func gatherMachineParams(hostname string) (*params.AddMachineParams, error) {
 	}
 	addrs, err := HostAddresses(hostname)
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 	logger.Infof("addresses for %v: %v", hostname, addrs)
+	return hostname, addrs, nil
+}
 
-	provisioned, err := checkProvisioned(hostname)
-	if err != nil {
-		err = fmt.Errorf("error checking if provisioned: %v", err)
-		return nil, err
-	}
-	if provisioned {
-		return nil, ErrProvisioned
-	}
-
-	hc, series, err := DetectSeriesAndHardwareCharacteristics(hostname)
+// newMachineParams returns the parameters with which
+// to record the given host in state.
+func newMachineParams(
+	hostname string, addrs []instance.Address, series string, hc instance.HardwareCharacteristics,
+) (*params.AddMachineParams, error) {
+	// Generate a unique nonce for the machine.
+	uuid, err := utils.NewUUID()
 	if err != nil {
-		err = fmt.Errorf("error detecting hardware characteristics: %v", err)
 		return nil, err
 	}
 
@@ -298,10 +350,13 @@ var provisionMachineAgent = func(host string, mcfg *cloudinit.MachineConfig, pro
 	if err != nil {
 		return err
 	}
-	return runProvisionScript(script, host, progressWriter)
+	return runProvisionScript(script, host, nil, progressWriter)
 }
 
 func generateProvisioningScript(mcfg *cloudinit.MachineConfig) (string, error) {
+	if version.IsWindowsSeries(mcfg.Tools.Version.Series) {
+		return cloudinit.ConfigureWindowsScript(mcfg)
+	}
 	cloudcfg := coreCloudinit.New()
 	if err := cloudinit.ConfigureJuju(mcfg, cloudcfg); err != nil {
 		return "", err
@@ -312,9 +367,10 @@ func generateProvisioningScript(mcfg *cloudinit.MachineConfig) (string, error) {
 	return sshinit.ConfigureScript(cloudcfg)
 }
 
-func runProvisionScript(script, host string, progressWriter io.Writer) error {
+func runProvisionScript(script, host string, options *ssh.Options, progressWriter io.Writer) error {
 	params := sshinit.ConfigureParams{
 		Host:           "ubuntu@" + host,
+		Options:        options,
 		ProgressWriter: progressWriter,
 	}
 	return sshinit.RunConfigureScript(script, params)
@@ -0,0 +1,195 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package manual
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"launchpad.net/juju-core/instance"
+	"launchpad.net/juju-core/juju/osenv"
+	"launchpad.net/juju-core/state/api"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/utils/winrm"
+)
+
+// winRMClient holds the methods of winrm.Client
+// used to manage Windows machines.
+type winRMClient interface {
+	RunPowerShell(script string, stdin io.Reader, stdout, stderr io.Writer) (int, error)
+}
+
+// newWinRMClient returns a client connected to the WinRM service of
+// the given host, authenticated with the client certificate in
+// $JUJU_HOME/winrm; it may be replaced in tests.
+var newWinRMClient = func(host string) (winRMClient, error) {
+	clientCert, err := winrm.LoadClientCert(osenv.JujuHomePath("winrm"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot load WinRM client certificate: %v", err)
+	}
+	client, err := winrm.NewClient(clientCert.Params(host))
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// runPowerShell runs the given script with the client, feeding it
+// stdin, which may be nil. Its output is written to stdout, and its
+// errors to stderr, either of which may be nil; a non-zero exit
+// code is returned as an error, annotated with the errors.
+func runPowerShell(client winRMClient, script string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var stderrBuf bytes.Buffer
+	code, err := client.RunPowerShell(script, stdin, stdout, teeStderr(stderr, &stderrBuf))
+	if err == nil && code != 0 {
+		err = fmt.Errorf("exit status %d", code)
+	}
+	return annotateStderr(err, &stderrBuf)
+}
+
+// provisionWindowsMachine implements ProvisionMachine for
+// Windows machines, connecting to the host via WinRM.
+func provisionWindowsMachine(client *api.Client, hostname string, args ProvisionMachineArgs) (machineId string, err error) {
+	winrmClient, err := newWinRMClient(hostname)
+	if err != nil {
+		return "", err
+	}
+	hostname, addrs, err := resolveHost(hostname)
+	if err != nil {
+		return "", err
+	}
+	provisioned, err := checkProvisionedWindows(winrmClient, hostname)
+	if err != nil {
+		return "", fmt.Errorf("error checking if provisioned: %v", err)
+	}
+	if provisioned {
+		return "", ErrProvisioned
+	}
+	hc, err := detectWindowsHardwareCharacteristics(winrmClient, hostname)
+	if err != nil {
+		return "", fmt.Errorf("error detecting hardware characteristics: %v", err)
+	}
+	machineParams, err := newMachineParams(hostname, addrs, args.Series, hc)
+	if err != nil {
+		return "", err
+	}
+
+	// Inform Juju that the machine exists. There is no fallback
+	// to direct database access, as API servers that predate
+	// AddMachines cannot provision Windows machines.
+	machineId, err = recordMachineInState(client, *machineParams)
+	if err != nil {
+		return "", err
+	}
+	script, err := client.ProvisioningScript(params.ProvisioningScriptParams{
+		MachineId: machineId,
+		Nonce:     machineParams.Nonce,
+	})
+	if err != nil {
+		return machineId, err
+	}
+	if err := runWindowsProvisionScript(winrmClient, script, args.Stderr); err != nil {
+		return machineId, err
+	}
+	logger.Infof("Provisioned machine %v", machineId)
+	return machineId, nil
+}
+
+// checkProvisionedWindowsScript is the PowerShell script run on
+// a Windows machine to list the services of any juju agents.
+const checkProvisionedWindowsScript = "Get-Service -Name 'jujud-*' -ErrorAction SilentlyContinue | ForEach-Object { $_.Name }"
+
+// checkProvisionedWindows is the Windows equivalent
+// of checkProvisioned, checking for the services of
+// juju agents.
+func checkProvisionedWindows(client winRMClient, host string) (bool, error) {
+	logger.Infof("Checking if %s is already provisioned", host)
+	var stdout bytes.Buffer
+	if err := runPowerShell(client, checkProvisionedWindowsScript, nil, &stdout, nil); err != nil {
+		return false, err
+	}
+	output := strings.TrimSpace(stdout.String())
+	provisioned := len(output) > 0
+	if provisioned {
+		logger.Infof("%s is already provisioned [%q]", host, output)
+	} else {
+		logger.Infof("%s is not provisioned", host)
+	}
+	return provisioned, nil
+}
+
+// windowsDetectionScript outputs the processor architecture, the
+// total memory in bytes and the number of physical cores of a
+// Windows machine, one per line.
+const windowsDetectionScript = `$ErrorActionPreference = 'Stop'
+$env:PROCESSOR_ARCHITECTURE
+(Get-WmiObject Win32_ComputerSystem).TotalPhysicalMemory
+(Get-WmiObject Win32_Processor | Measure-Object -Property NumberOfCores -Sum).Sum`
+
+// windowsArches maps the processor architectures reported
+// by Windows to architectures recognised by Juju.
+var windowsArches = map[string]string{
+	"AMD64": "amd64",
+	"x86":   "i386",
+	"ARM":   "arm",
+}
+
+// detectWindowsHardwareCharacteristics is the Windows equivalent
+// of DetectSeriesAndHardwareCharacteristics. The series cannot be
+// detected, and must be specified by the user.
+func detectWindowsHardwareCharacteristics(client winRMClient, host string) (hc instance.HardwareCharacteristics, err error) {
+	logger.Infof("Detecting characteristics on %s", host)
+	var stdout bytes.Buffer
+	if err := runPowerShell(client, windowsDetectionScript, nil, &stdout, nil); err != nil {
+		return hc, err
+	}
+	lines := strings.Fields(stdout.String())
+	if len(lines) != 3 {
+		return hc, fmt.Errorf("unexpected output: %q", stdout.String())
+	}
+	arch, ok := windowsArches[lines[0]]
+	if !ok {
+		return hc, fmt.Errorf("unrecognised architecture: %s", lines[0])
+	}
+	hc.Arch = &arch
+
+	// HardwareCharacteristics wants memory in megabytes.
+	memBytes, err := strconv.ParseUint(lines[1], 10, 64)
+	if err != nil {
+		return hc, fmt.Errorf("invalid memory size: %v", err)
+	}
+	hc.Mem = new(uint64)
+	*hc.Mem = memBytes / (1024 * 1024)
+
+	hc.CpuCores = new(uint64)
+	if *hc.CpuCores, err = strconv.ParseUint(lines[2], 10, 64); err != nil {
+		return hc, fmt.Errorf("invalid number of cores: %v", err)
+	}
+	if *hc.CpuCores == 0 {
+		*hc.CpuCores = 1
+	}
+	logger.Infof("characteristics: %s", hc)
+	return hc, nil
+}
+
+// windowsProvisionWrapperScript is the PowerShell script that runs the
+// provisioning script read from its standard input. The script is
+// too large to pass on the command line, so it is saved to a file
+// and run from there.
+const windowsProvisionWrapperScript = `$ErrorActionPreference = 'Stop'
+$path = Join-Path $env:TEMP 'juju-provision.ps1'
+Set-Content -Path $path -Value ([Console]::In.ReadToEnd())
+& powershell.exe -NoProfile -NonInteractive -ExecutionPolicy Bypass -File $path
+$code = $LASTEXITCODE
+Remove-Item -Force $path
+exit $code`
+
+// runWindowsProvisionScript runs the given provisioning
+// script on a Windows machine, with the given client.
+var runWindowsProvisionScript = func(client winRMClient, script string, progressWriter io.Writer) error {
+	return runPowerShell(client, windowsProvisionWrapperScript, strings.NewReader(script), progressWriter, progressWriter)
+}
@@ -0,0 +1,157 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package manual
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	gc "launchpad.net/gocheck"
+
+	envtesting "launchpad.net/juju-core/environs/testing"
+	"launchpad.net/juju-core/instance"
+	"launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/version"
+)
+
+// fakeWinRMClient responds to the scripts run through it
+// with the output and exit code recorded for each script.
+type fakeWinRMClient struct {
+	host    string
+	outputs map[string]string
+	codes   map[string]int
+	scripts []string
+	stdins  []string
+}
+
+func newFakeWinRMClient() *fakeWinRMClient {
+	return &fakeWinRMClient{
+		outputs: make(map[string]string),
+		codes:   make(map[string]int),
+	}
+}
+
+func (f *fakeWinRMClient) RunPowerShell(script string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	f.scripts = append(f.scripts, script)
+	var input string
+	if stdin != nil {
+		data, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			return 0, err
+		}
+		input = string(data)
+	}
+	f.stdins = append(f.stdins, input)
+	if stdout != nil {
+		fmt.Fprint(stdout, f.outputs[script])
+	}
+	if code := f.codes[script]; code != 0 {
+		if stderr != nil {
+			fmt.Fprint(stderr, "oops")
+		}
+		return code, nil
+	}
+	return 0, nil
+}
+
+type windowsSuite struct {
+	testing.JujuConnSuite
+	client *fakeWinRMClient
+}
+
+var _ = gc.Suite(&windowsSuite{})
+
+func (s *windowsSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.client = newFakeWinRMClient()
+	s.client.outputs[windowsDetectionScript] = "AMD64\r\n4294967296\r\n2\r\n"
+	s.PatchValue(&instanceHostAddresses, func(host string) ([]instance.Address, error) {
+		return []instance.Address{instance.NewAddress("10.0.0.1")}, nil
+	})
+	s.PatchValue(&newWinRMClient, func(host string) (winRMClient, error) {
+		s.client.host = host
+		return s.client, nil
+	})
+}
+
+func (s *windowsSuite) TestDetectWindowsHardwareCharacteristics(c *gc.C) {
+	hc, err := detectWindowsHardwareCharacteristics(s.client, "winhost")
+	c.Assert(err, gc.IsNil)
+	c.Assert(hc.String(), gc.Equals, "arch=amd64 cpu-cores=2 mem=4096M")
+
+	s.client.outputs[windowsDetectionScript] = "IA64\r\n4294967296\r\n2\r\n"
+	_, err = detectWindowsHardwareCharacteristics(s.client, "winhost")
+	c.Assert(err, gc.ErrorMatches, "unrecognised architecture: IA64")
+
+	s.client.codes[windowsDetectionScript] = 1
+	_, err = detectWindowsHardwareCharacteristics(s.client, "winhost")
+	c.Assert(err, gc.ErrorMatches, `exit status 1 \(oops\)`)
+}
+
+func (s *windowsSuite) TestProvisionWindowsMachine(c *gc.C) {
+	agentVersion, ok := s.Conn.Environ.Config().AgentVersion()
+	c.Assert(ok, jc.IsTrue)
+	envtesting.AssertUploadFakeToolsVersions(
+		c, s.Conn.Environ.Storage(), version.Binary{agentVersion, "win2012r2", "amd64"},
+	)
+	machineId, err := ProvisionMachine(ProvisionMachineArgs{
+		Host:    "Administrator@winhost",
+		EnvName: "dummyenv",
+		Series:  "win2012r2",
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.client.host, gc.Equals, "winhost")
+	c.Assert(s.client.scripts, gc.DeepEquals, []string{
+		checkProvisionedWindowsScript,
+		windowsDetectionScript,
+		windowsProvisionWrapperScript,
+	})
+	// The provisioning script is fed to the wrapper on stdin.
+	c.Assert(s.client.stdins[2], jc.HasPrefix, "#ps1_sysnative")
+	c.Assert(s.client.stdins[2], jc.Contains, "jujud-machine-"+machineId)
+
+	m, err := s.State.Machine(machineId)
+	c.Assert(err, gc.IsNil)
+	c.Assert(m.Series(), gc.Equals, "win2012r2")
+	instanceId, err := m.InstanceId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(instanceId, gc.Equals, instance.Id("manual:winhost"))
+	hc, err := m.HardwareCharacteristics()
+	c.Assert(err, gc.IsNil)
+	c.Assert(hc.String(), gc.Equals, "arch=amd64 cpu-cores=2 mem=4096M")
+}
+
+func (s *windowsSuite) TestProvisionWindowsMachineProvisioned(c *gc.C) {
+	s.client.outputs[checkProvisionedWindowsScript] = "jujud-machine-0\r\n"
+	_, err := ProvisionMachine(ProvisionMachineArgs{
+		Host:    "winhost",
+		EnvName: "dummyenv",
+		Series:  "win2012r2",
+	})
+	c.Assert(err, gc.Equals, ErrProvisioned)
+	c.Assert(s.client.scripts, gc.DeepEquals, []string{checkProvisionedWindowsScript})
+}
+
+func (s *windowsSuite) TestProvisionWindowsMachineFailureRemovesMachine(c *gc.C) {
+	agentVersion, ok := s.Conn.Environ.Config().AgentVersion()
+	c.Assert(ok, jc.IsTrue)
+	envtesting.AssertUploadFakeToolsVersions(
+		c, s.Conn.Environ.Storage(), version.Binary{agentVersion, "win2012r2", "amd64"},
+	)
+	s.client.codes[windowsProvisionWrapperScript] = 1
+	machineId, err := ProvisionMachine(ProvisionMachineArgs{
+		Host:    "winhost",
+		EnvName: "dummyenv",
+		Series:  "win2012r2",
+	})
+	c.Assert(err, gc.ErrorMatches, `exit status 1 \(oops\)`)
+	c.Assert(machineId, gc.Equals, "")
+	// The machine recorded in state is destroyed.
+	m, err := s.State.Machine("0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(m.Life(), gc.Equals, state.Dying)
+}
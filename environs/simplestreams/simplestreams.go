@@ -26,6 +26,7 @@ import (
 	"github.com/juju/loggo"
 
 	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/version"
 )
 
 var logger = loggo.GetLogger("juju.environs.simplestreams")
@@ -105,10 +106,14 @@ var seriesVersions = map[string]string{
 	"raring":  "13.04",
 	"saucy":   "13.10",
 	"trusty":  "14.04",
-	"win2012hv": "win2012hv",
-    "win2012hvr2": "win2012hvr2",
-    "win2012": "win2012",
-    "win2012r2": "win2012r2",
+}
+
+func init() {
+	// Windows series have no version numbers, so
+	// their names are used as their versions.
+	for _, series := range version.WindowsSeries() {
+		seriesVersions[series] = series
+	}
 }
 
 var (
@@ -133,7 +138,8 @@ func SeriesVersion(series string) (string, error) {
 	return "", fmt.Errorf("invalid series %q", series)
 }
 
-// Supported series returns the Ubuntu series for which we expect to find metadata.
+// Supported series returns the Ubuntu and Windows series
+// for which we expect to find metadata.
 func SupportedSeries() []string {
 	seriesVersionsMutex.Lock()
 	defer seriesVersionsMutex.Unlock()
@@ -15,6 +15,8 @@ import (
 	sstesting "launchpad.net/juju-core/environs/simplestreams/testing"
 	coretesting "launchpad.net/juju-core/testing"
 	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/utils/set"
+	"launchpad.net/juju-core/version"
 )
 
 func Test(t *testing.T) {
@@ -413,6 +415,16 @@ func (s *simplestreamsSuite) TestSeriesVersion(c *gc.C) {
 	c.Assert(vers, gc.Equals, "12.04")
 }
 
+func (s *simplestreamsSuite) TestSeriesVersionWindows(c *gc.C) {
+	vers, err := simplestreams.SeriesVersion("win2012r2")
+	c.Assert(err, gc.IsNil)
+	c.Assert(vers, gc.Equals, "win2012r2")
+	supported := set.NewStrings(simplestreams.SupportedSeries()...)
+	for _, series := range version.WindowsSeries() {
+		c.Check(supported.Contains(series), jc.IsTrue)
+	}
+}
+
 func (s *simplestreamsSuite) TestSupportedSeries(c *gc.C) {
 	cleanup := simplestreams.SetSeriesVersions(make(map[string]string))
 	defer cleanup()
@@ -0,0 +1,451 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"launchpad.net/juju-core/environs/imagemetadata"
+	"launchpad.net/juju-core/environs/simplestreams"
+	"launchpad.net/juju-core/environs/storage"
+	envtools "launchpad.net/juju-core/environs/tools"
+	"launchpad.net/juju-core/utils"
+	"launchpad.net/juju-core/version"
+)
+
+// MirrorMetadataPath holds the path, relative to the tools directory,
+// of the mirror metadata written for a mirror with a known URL. The
+// mirrors index written alongside the tools metadata refers to it.
+const MirrorMetadataPath = "streams/v1/cpc-mirrors.json"
+
+// signedSuffix holds the suffix of signed metadata files.
+const signedSuffix = ".sjson"
+
+// MirrorContext describes the context for mirroring tools, image
+// metadata, and their simplestreams metadata.
+type MirrorContext struct {
+	// Source holds the simplestreams data source from which
+	// tools are mirrored.
+	Source simplestreams.DataSource
+
+	// ImageSource holds the simplestreams data source from which
+	// image metadata is mirrored.
+	ImageSource simplestreams.DataSource
+
+	// Stream holds the image stream to mirror, such as "released"
+	// or "daily". If empty, the released stream is mirrored.
+	Stream string
+
+	// Target holds the destination of the mirror.
+	Target storage.Storage
+
+	// Series holds the series of the tools and images to mirror.
+	// If empty, those for all supported series are mirrored.
+	Series []string
+
+	// Arches holds the architectures of the tools and images to
+	// mirror. If empty, those for all architectures are mirrored.
+	Arches []string
+
+	// Mirror tools with major version, if MajorVersion >= 0.
+	MajorVersion int
+
+	// Mirror tools with minor version, if MinorVersion >= 0.
+	MinorVersion int
+
+	// Dev controls the mirroring of development versions as well
+	// as released ones.
+	Dev bool
+
+	// MirrorURL, if non-empty, holds the URL at which the target's
+	// tools directory is served. Mirror metadata describing it is
+	// written along with the tools metadata.
+	MirrorURL string
+
+	// CloudSpec holds the cloud to which the mirror metadata applies.
+	// If it is set, only images in that cloud are mirrored.
+	CloudSpec simplestreams.CloudSpec
+
+	// SigningKey, if non-empty, holds an armored private key with
+	// which signed copies of all the metadata files are written.
+	SigningKey string
+
+	// Passphrase holds the passphrase used to decrypt SigningKey.
+	Passphrase string
+
+	// DryRun controls that nothing is copied. Instead it's logged
+	// what would be copied.
+	DryRun bool
+}
+
+// MirrorTools copies the tools matching the context from the source to
+// the target, and writes simplestreams metadata describing all the
+// tools in the target. Tools already present in the target with the
+// same checksum as in the source are not copied again, so a mirror may
+// be updated incrementally by running MirrorTools repeatedly.
+func MirrorTools(ctx *MirrorContext) error {
+	series := ctx.Series
+	if len(series) == 0 {
+		series = simplestreams.SupportedSeries()
+	}
+	arches := ctx.Arches
+	if len(arches) == 0 {
+		arches = []string{"amd64", "i386", "arm", "arm64", "ppc64"}
+	}
+	cons := envtools.NewGeneralToolsConstraint(ctx.MajorVersion, ctx.MinorVersion, !ctx.Dev,
+		simplestreams.LookupParams{Series: series, Arches: arches})
+
+	logger.Infof("listing available tools in %s", ctx.Source.Description())
+	sourceMetadata, _, err := envtools.Fetch(
+		[]simplestreams.DataSource{ctx.Source}, simplestreams.DefaultIndexPath, cons, false)
+	if err != nil {
+		return err
+	}
+	logger.Infof("found %d tools", len(sourceMetadata))
+
+	logger.Infof("reading target tools metadata")
+	targetMetadata, err := envtools.ReadMetadata(ctx.Target)
+	if err != nil {
+		return err
+	}
+	mirrored := make(map[string]*envtools.ToolsMetadata)
+	for _, md := range targetMetadata {
+		mirrored[metadataKey(md)] = md
+	}
+
+	var missing []*envtools.ToolsMetadata
+	for _, md := range sourceMetadata {
+		existing, ok := mirrored[metadataKey(md)]
+		if ok && existing.Size != 0 && (md.SHA256 == "" || md.SHA256 == existing.SHA256) {
+			continue
+		}
+		missing = append(missing, md)
+	}
+	logger.Infof("found %d tools in target; %d tools to be copied", len(targetMetadata), len(missing))
+	for _, md := range missing {
+		logger.Infof("copying %s", md.Path)
+		if ctx.DryRun {
+			continue
+		}
+		copied, err := mirrorOneToolsPackage(ctx.Source, ctx.Target, md)
+		if err != nil {
+			return err
+		}
+		mirrored[metadataKey(copied)] = copied
+	}
+	if ctx.DryRun {
+		return nil
+	}
+	logger.Infof("copied %d tools", len(missing))
+
+	metadata := make([]*envtools.ToolsMetadata, 0, len(mirrored))
+	for _, md := range mirrored {
+		metadata = append(metadata, md)
+	}
+	sort.Sort(metadataByKey(metadata))
+	logger.Infof("generating tools metadata")
+	if err := envtools.WriteMetadata(ctx.Target, metadata, envtools.DoNotWriteMirrors); err != nil {
+		return err
+	}
+	files := []string{simplestreams.UnsignedIndex, envtools.ProductMetadataPath}
+	if ctx.MirrorURL != "" {
+		if err := writeMirrorMetadata(ctx.Target, ctx.MirrorURL, ctx.CloudSpec, time.Now()); err != nil {
+			return err
+		}
+		files = append(files, simplestreams.UnsignedMirror, MirrorMetadataPath)
+	}
+	if ctx.SigningKey != "" {
+		for _, file := range files {
+			err := signMetadataFile(ctx.Target, storage.BaseToolsPath, file, ctx.SigningKey, ctx.Passphrase)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	logger.Infof("tools metadata written")
+	return nil
+}
+
+// MirrorImageMetadata copies the metadata of the images matching the
+// context from the image source to the target, and writes it together
+// with the image metadata already in the target. Images already in the
+// target are replaced by those from the source, so a mirror may be
+// updated by running MirrorImageMetadata repeatedly. The images
+// themselves are held by the cloud, so only their metadata is copied.
+func MirrorImageMetadata(ctx *MirrorContext) error {
+	cons := imagemetadata.NewImageConstraint(simplestreams.LookupParams{
+		CloudSpec: ctx.CloudSpec,
+		Series:    ctx.Series,
+		Arches:    ctx.Arches,
+		Stream:    ctx.Stream,
+	})
+	logger.Infof("listing available images in %s", ctx.ImageSource.Description())
+	sourceMetadata, _, err := imagemetadata.Fetch(
+		[]simplestreams.DataSource{ctx.ImageSource}, simplestreams.DefaultIndexPath, cons, false)
+	if err != nil {
+		return err
+	}
+	logger.Infof("found %d images", len(sourceMetadata))
+	if ctx.DryRun {
+		for _, im := range sourceMetadata {
+			logger.Infof("copying metadata of image %s in %q", im.Id, im.RegionName)
+		}
+		return nil
+	}
+
+	logger.Infof("reading target image metadata")
+	targetMetadata, err := imagemetadata.ReadAllMetadata(ctx.Target)
+	if err != nil {
+		return err
+	}
+	mirrored := make(map[string]*imagemetadata.ImageMetadata)
+	for _, im := range targetMetadata {
+		mirrored[imageKey(im)] = im
+	}
+	// The stream is not held in the items themselves. The released
+	// stream is recorded as empty, as ReadAllMetadata returns it.
+	stream := ctx.Stream
+	if stream == imagemetadata.ReleasedStream {
+		stream = ""
+	}
+	for _, im := range sourceMetadata {
+		im.Stream = stream
+		mirrored[imageKey(im)] = im
+	}
+	metadata := make([]*imagemetadata.ImageMetadata, 0, len(mirrored))
+	var cloudSpecs []simplestreams.CloudSpec
+	seenClouds := make(map[simplestreams.CloudSpec]bool)
+	for _, im := range mirrored {
+		metadata = append(metadata, im)
+		cloud := simplestreams.CloudSpec{Region: im.RegionName, Endpoint: im.Endpoint}
+		if !seenClouds[cloud] {
+			seenClouds[cloud] = true
+			cloudSpecs = append(cloudSpecs, cloud)
+		}
+	}
+	sort.Sort(imagesByKey(metadata))
+	sort.Sort(cloudSpecsByRegion(cloudSpecs))
+	logger.Infof("generating image metadata")
+	if err := imagemetadata.WriteMetadata(metadata, cloudSpecs, ctx.Target); err != nil {
+		return err
+	}
+	if ctx.SigningKey != "" {
+		for _, file := range []string{simplestreams.UnsignedIndex, imagemetadata.ProductMetadataPath} {
+			err := signMetadataFile(ctx.Target, storage.BaseImagesPath, file, ctx.SigningKey, ctx.Passphrase)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	logger.Infof("image metadata written")
+	return nil
+}
+
+// imageKey returns a key identifying the image described by im.
+func imageKey(im *imagemetadata.ImageMetadata) string {
+	return fmt.Sprintf("%s-%s-%s-%s-%s-%s-%s",
+		im.Stream, im.Version, im.Arch, im.RegionName, im.VType, im.Storage, im.Id)
+}
+
+type imagesByKey []*imagemetadata.ImageMetadata
+
+func (s imagesByKey) Len() int           { return len(s) }
+func (s imagesByKey) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s imagesByKey) Less(i, j int) bool { return imageKey(s[i]) < imageKey(s[j]) }
+
+type cloudSpecsByRegion []simplestreams.CloudSpec
+
+func (s cloudSpecsByRegion) Len() int      { return len(s) }
+func (s cloudSpecsByRegion) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s cloudSpecsByRegion) Less(i, j int) bool {
+	if s[i].Region != s[j].Region {
+		return s[i].Region < s[j].Region
+	}
+	return s[i].Endpoint < s[j].Endpoint
+}
+
+// metadataKey returns a key identifying the tools described by md.
+func metadataKey(md *envtools.ToolsMetadata) string {
+	return fmt.Sprintf("%s-%s-%s", md.Version, md.Release, md.Arch)
+}
+
+type metadataByKey []*envtools.ToolsMetadata
+
+func (s metadataByKey) Len() int           { return len(s) }
+func (s metadataByKey) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s metadataByKey) Less(i, j int) bool { return metadataKey(s[i]) < metadataKey(s[j]) }
+
+// mirrorOneToolsPackage copies the tools described by md from the
+// source to the target, verifying them against any size and checksum
+// held in md. It returns metadata describing the copied tools.
+func mirrorOneToolsPackage(source simplestreams.DataSource, target storage.Storage,
+	md *envtools.ToolsMetadata) (*envtools.ToolsMetadata, error) {
+
+	vers, err := version.ParseBinary(metadataKey(md))
+	if err != nil {
+		return nil, err
+	}
+	rc, url, err := source.Fetch(md.Path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch tools %v: %v", vers, err)
+	}
+	defer rc.Close()
+	// Tools are spooled to a temporary file rather than held in
+	// memory while they are verified.
+	f, err := ioutil.TempFile("", "juju-mirror-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	sha256, size, err := utils.ReadSHA256(io.TeeReader(rc, f))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read tools %v from %q: %v", vers, url, err)
+	}
+	if md.SHA256 != "" && md.SHA256 != sha256 || md.Size != 0 && md.Size != size {
+		return nil, fmt.Errorf("tools %v from %q do not match metadata: size=(%v,%v) sha256=(%v,%v)",
+			vers, url, md.Size, size, md.SHA256, sha256)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	toolsName := envtools.StorageName(vers)
+	if err := target.Put(toolsName, f, size); err != nil {
+		return nil, err
+	}
+	copied := *md
+	copied.Path = strings.TrimPrefix(toolsName, storage.BaseToolsPath+"/")
+	copied.FullPath = ""
+	copied.Size = size
+	copied.SHA256 = sha256
+	return &copied, nil
+}
+
+// writeMirrorMetadata writes a mirrors index to stor, referring to
+// mirror metadata that describes the tools served at mirrorURL.
+func writeMirrorMetadata(stor storage.Storage, mirrorURL string, cloudSpec simplestreams.CloudSpec, updated time.Time) error {
+	mirrorsUpdated := updated.Format(time.RFC1123Z)
+	refs := simplestreams.MirrorRefs{
+		Mirrors: map[string][]simplestreams.MirrorReference{
+			envtools.ToolsContentId: {{
+				Updated:  mirrorsUpdated,
+				Format:   "mirrors:1.0",
+				DataType: envtools.ContentDownload,
+				Path:     MirrorMetadataPath,
+			}},
+		},
+	}
+	mirrors := simplestreams.MirrorMetadata{
+		Updated: mirrorsUpdated,
+		Format:  "mirrors:1.0",
+		Mirrors: map[string][]simplestreams.MirrorInfo{
+			envtools.ToolsContentId: {{
+				Clouds:    []simplestreams.CloudSpec{cloudSpec},
+				MirrorURL: mirrorURL,
+				Path:      envtools.ProductMetadataPath,
+			}},
+		},
+	}
+	for file, value := range map[string]interface{}{
+		simplestreams.UnsignedMirror: &refs,
+		MirrorMetadataPath:           &mirrors,
+	} {
+		data, err := json.MarshalIndent(value, "", "    ")
+		if err != nil {
+			return err
+		}
+		if err := putMetadataFile(stor, storage.BaseToolsPath, file, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// signMetadataFile writes a signed copy of the named metadata file,
+// held under basePath in stor. The paths within the file that refer to
+// other metadata files are changed to refer to their signed copies, so
+// the signed metadata is self-contained.
+func signMetadataFile(stor storage.Storage, basePath, file, key, passphrase string) error {
+	r, err := storage.Get(stor, path.Join(basePath, file))
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return err
+	}
+	if data, err = signedReferences(file, data); err != nil {
+		return fmt.Errorf("cannot sign %q: %v", file, err)
+	}
+	signed, err := simplestreams.Encode(bytes.NewReader(data), key, passphrase)
+	if err != nil {
+		return fmt.Errorf("cannot sign %q: %v", file, err)
+	}
+	return putMetadataFile(stor, basePath, signedPath(file), signed)
+}
+
+// signedReferences returns the contents of the named metadata file
+// with the paths of the metadata files it refers to changed to those
+// of their signed copies. Products files refer to no other metadata,
+// and are returned unchanged.
+func signedReferences(file string, data []byte) ([]byte, error) {
+	var value interface{}
+	switch file {
+	case simplestreams.UnsignedIndex:
+		var indices simplestreams.Indices
+		if err := json.Unmarshal(data, &indices); err != nil {
+			return nil, err
+		}
+		for _, index := range indices.Indexes {
+			index.ProductsFilePath = signedPath(index.ProductsFilePath)
+		}
+		value = &indices
+	case simplestreams.UnsignedMirror:
+		var refs simplestreams.MirrorRefs
+		if err := json.Unmarshal(data, &refs); err != nil {
+			return nil, err
+		}
+		for _, mirrorRefs := range refs.Mirrors {
+			for i := range mirrorRefs {
+				mirrorRefs[i].Path = signedPath(mirrorRefs[i].Path)
+			}
+		}
+		value = &refs
+	case MirrorMetadataPath:
+		var mirrors simplestreams.MirrorMetadata
+		if err := json.Unmarshal(data, &mirrors); err != nil {
+			return nil, err
+		}
+		for _, infos := range mirrors.Mirrors {
+			for i := range infos {
+				infos[i].Path = signedPath(infos[i].Path)
+			}
+		}
+		value = &mirrors
+	default:
+		return data, nil
+	}
+	return json.MarshalIndent(value, "", "    ")
+}
+
+// signedPath returns the path of the signed copy of the metadata file
+// with the given path.
+func signedPath(file string) string {
+	return strings.TrimSuffix(file, simplestreams.UnsignedSuffix) + signedSuffix
+}
+
+func putMetadataFile(stor storage.Storage, basePath, file string, data []byte) error {
+	logger.Infof("writing %s", path.Join(basePath, file))
+	return stor.Put(path.Join(basePath, file), bytes.NewReader(data), int64(len(data)))
+}
@@ -0,0 +1,282 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package sync_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/environs/filestorage"
+	"launchpad.net/juju-core/environs/imagemetadata"
+	"launchpad.net/juju-core/environs/simplestreams"
+	sstesting "launchpad.net/juju-core/environs/simplestreams/testing"
+	"launchpad.net/juju-core/environs/storage"
+	"launchpad.net/juju-core/environs/sync"
+	envtools "launchpad.net/juju-core/environs/tools"
+	ttesting "launchpad.net/juju-core/environs/tools/testing"
+	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/testing/testbase"
+	"launchpad.net/juju-core/version"
+)
+
+type mirrorSuite struct {
+	testbase.LoggingSuite
+	source    simplestreams.DataSource
+	targetDir string
+	target    storage.Storage
+}
+
+var _ = gc.Suite(&mirrorSuite{})
+
+func (s *mirrorSuite) SetUpTest(c *gc.C) {
+	s.LoggingSuite.SetUpTest(c)
+	sourceDir := c.MkDir()
+	versionStrings := make([]string, len(v1all))
+	for i, vers := range v1all {
+		versionStrings[i] = vers.String()
+	}
+	ttesting.MakeToolsWithCheckSum(c, sourceDir, "releases", versionStrings)
+	s.source = simplestreams.NewURLDataSource(
+		"test source", "file://"+filepath.Join(sourceDir, "tools"), simplestreams.VerifySSLHostnames)
+
+	s.targetDir = c.MkDir()
+	var err error
+	s.target, err = filestorage.NewFileStorageWriter(s.targetDir)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *mirrorSuite) mirrorContext() *sync.MirrorContext {
+	return &sync.MirrorContext{
+		Source:       s.source,
+		Target:       s.target,
+		MajorVersion: 1,
+		MinorVersion: -1,
+	}
+}
+
+func (s *mirrorSuite) assertMirrored(c *gc.C, expected ...version.Binary) {
+	metadata := ttesting.ParseMetadataFromStorage(c, s.target, false)
+	c.Assert(metadata, gc.HasLen, len(expected))
+	mirrored := make(map[version.Binary]bool)
+	for _, md := range metadata {
+		vers := version.MustParseBinary(md.Version + "-" + md.Release + "-" + md.Arch)
+		mirrored[vers] = true
+		c.Check(md.Path, gc.Equals, "releases/juju-"+vers.String()+".tgz")
+		size, sha256 := ttesting.SHA256sum(c, filepath.Join(s.targetDir, "tools", md.Path))
+		c.Check(md.Size, gc.Equals, size)
+		c.Check(md.SHA256, gc.Equals, sha256)
+	}
+	for _, vers := range expected {
+		c.Check(mirrored[vers], jc.IsTrue)
+	}
+}
+
+func (s *mirrorSuite) TestMirrorTools(c *gc.C) {
+	ctx := s.mirrorContext()
+	ctx.Series = []string{"precise"}
+	err := sync.MirrorTools(ctx)
+	c.Assert(err, gc.IsNil)
+	s.assertMirrored(c, v100p64, v180p32)
+}
+
+func (s *mirrorSuite) TestMirrorToolsArchesAndDev(c *gc.C) {
+	ctx := s.mirrorContext()
+	ctx.Arches = []string{"i386"}
+	ctx.Dev = true
+	err := sync.MirrorTools(ctx)
+	c.Assert(err, gc.IsNil)
+	s.assertMirrored(c, v100q32, v180p32, v190p32)
+}
+
+func (s *mirrorSuite) TestMirrorToolsIncremental(c *gc.C) {
+	ctx := s.mirrorContext()
+	ctx.Series = []string{"precise"}
+	err := sync.MirrorTools(ctx)
+	c.Assert(err, gc.IsNil)
+
+	// Tools already mirrored are not copied again.
+	path := filepath.Join(s.targetDir, envtools.StorageName(v100p64))
+	err = ioutil.WriteFile(path, []byte("already mirrored"), 0644)
+	c.Assert(err, gc.IsNil)
+	ctx.Series = nil
+	err = sync.MirrorTools(ctx)
+	c.Assert(err, gc.IsNil)
+	data, err := ioutil.ReadFile(path)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, "already mirrored")
+
+	metadata := ttesting.ParseMetadataFromStorage(c, s.target, false)
+	c.Assert(metadata, gc.HasLen, len(v1noDev))
+}
+
+func (s *mirrorSuite) TestMirrorToolsDryRun(c *gc.C) {
+	ctx := s.mirrorContext()
+	ctx.DryRun = true
+	err := sync.MirrorTools(ctx)
+	c.Assert(err, gc.IsNil)
+	list, err := s.target.List("")
+	c.Assert(err, gc.IsNil)
+	c.Assert(list, gc.HasLen, 0)
+}
+
+func (s *mirrorSuite) TestMirrorToolsWritesMirrors(c *gc.C) {
+	mirrorURL, err := s.target.URL(storage.BaseToolsPath)
+	c.Assert(err, gc.IsNil)
+	ctx := s.mirrorContext()
+	ctx.Series = []string{"precise"}
+	ctx.MirrorURL = mirrorURL
+	err = sync.MirrorTools(ctx)
+	c.Assert(err, gc.IsNil)
+
+	// The mirror metadata refers to the mirror itself.
+	source := storage.NewStorageSimpleStreamsDataSource("mirror", s.target, storage.BaseToolsPath)
+	cons := envtools.NewGeneralToolsConstraint(1, -1, true, simplestreams.LookupParams{
+		Series: []string{"precise"},
+		Arches: []string{"amd64", "i386"},
+	})
+	metadata, resolveInfo, err := envtools.Fetch(
+		[]simplestreams.DataSource{source}, simplestreams.DefaultIndexPath, cons, false)
+	c.Assert(err, gc.IsNil)
+	c.Assert(metadata, gc.HasLen, 2)
+	c.Assert(resolveInfo.MirrorURL, gc.Equals, mirrorURL)
+}
+
+func (s *mirrorSuite) TestMirrorToolsSigned(c *gc.C) {
+	ctx := s.mirrorContext()
+	ctx.Series = []string{"precise"}
+	ctx.MirrorURL = "http://mirror.invalid/tools"
+	ctx.SigningKey = sstesting.SignedMetadataPrivateKey
+	ctx.Passphrase = sstesting.PrivateKeyPassphrase
+	err := sync.MirrorTools(ctx)
+	c.Assert(err, gc.IsNil)
+
+	for _, name := range []string{
+		"streams/v1/index.sjson",
+		"streams/v1/com.ubuntu.juju:released:tools.sjson",
+		"streams/v1/mirrors.sjson",
+		"streams/v1/cpc-mirrors.sjson",
+	} {
+		c.Logf("checking %s", name)
+		data, err := ioutil.ReadFile(filepath.Join(s.targetDir, "tools", name))
+		c.Assert(err, gc.IsNil)
+		text, err := simplestreams.DecodeCheckSignature(bytes.NewReader(data), sstesting.SignedMetadataPublicKey)
+		c.Assert(err, gc.IsNil)
+		c.Check(string(text), gc.Not(jc.Contains), `.json"`)
+	}
+
+	// The signed index refers to the signed products.
+	data, err := ioutil.ReadFile(filepath.Join(s.targetDir, "tools", "streams", "v1", "index.sjson"))
+	c.Assert(err, gc.IsNil)
+	text, err := simplestreams.DecodeCheckSignature(bytes.NewReader(data), sstesting.SignedMetadataPublicKey)
+	c.Assert(err, gc.IsNil)
+	var indices simplestreams.Indices
+	err = json.Unmarshal(text, &indices)
+	c.Assert(err, gc.IsNil)
+	c.Assert(indices.Indexes[envtools.ToolsContentId].ProductsFilePath, gc.Equals,
+		"streams/v1/com.ubuntu.juju:released:tools.sjson")
+}
+
+// makeImageSource writes image metadata for the given images to a new
+// directory, and returns a data source reading it.
+func makeImageSource(c *gc.C, images ...*imagemetadata.ImageMetadata) simplestreams.DataSource {
+	dir := c.MkDir()
+	stor, err := filestorage.NewFileStorageWriter(dir)
+	c.Assert(err, gc.IsNil)
+	cloudSpecs := []simplestreams.CloudSpec{{Region: "region", Endpoint: "endpoint"}}
+	err = imagemetadata.WriteMetadata(images, cloudSpecs, stor)
+	c.Assert(err, gc.IsNil)
+	return simplestreams.NewURLDataSource(
+		"test image source", "file://"+filepath.Join(dir, storage.BaseImagesPath), simplestreams.VerifySSLHostnames)
+}
+
+func testImage(id, arch, stream string) *imagemetadata.ImageMetadata {
+	return &imagemetadata.ImageMetadata{
+		Id:         id,
+		Arch:       arch,
+		Version:    "12.04",
+		RegionName: "region",
+		Endpoint:   "endpoint",
+		Stream:     stream,
+	}
+}
+
+func (s *mirrorSuite) TestMirrorImageMetadata(c *gc.C) {
+	ctx := s.mirrorContext()
+	ctx.Series = []string{"precise"}
+	ctx.Arches = []string{"amd64"}
+	ctx.ImageSource = makeImageSource(c, testImage("released-64", "amd64", ""), testImage("released-32", "i386", ""))
+	err := sync.MirrorImageMetadata(ctx)
+	c.Assert(err, gc.IsNil)
+	metadata, err := imagemetadata.ReadAllMetadata(s.target)
+	c.Assert(err, gc.IsNil)
+	c.Assert(metadata, gc.HasLen, 1)
+	c.Assert(metadata[0].Id, gc.Equals, "released-64")
+	c.Assert(metadata[0].Stream, gc.Equals, "")
+}
+
+func (s *mirrorSuite) TestMirrorImageMetadataStreams(c *gc.C) {
+	ctx := s.mirrorContext()
+	ctx.Series = []string{"precise"}
+	ctx.Stream = imagemetadata.ReleasedStream
+	ctx.ImageSource = makeImageSource(c, testImage("released-64", "amd64", ""))
+	err := sync.MirrorImageMetadata(ctx)
+	c.Assert(err, gc.IsNil)
+
+	// Mirroring another stream keeps the images already mirrored.
+	ctx.Stream = "daily"
+	ctx.ImageSource = makeImageSource(c, testImage("daily-64", "amd64", "daily"))
+	err = sync.MirrorImageMetadata(ctx)
+	c.Assert(err, gc.IsNil)
+	metadata, err := imagemetadata.ReadAllMetadata(s.target)
+	c.Assert(err, gc.IsNil)
+	streams := make(map[string]string)
+	for _, im := range metadata {
+		streams[im.Id] = im.Stream
+	}
+	c.Assert(streams, gc.DeepEquals, map[string]string{
+		"released-64": "",
+		"daily-64":    "daily",
+	})
+
+	// Both streams can be found in the mirror.
+	source := storage.NewStorageSimpleStreamsDataSource("mirror", s.target, storage.BaseImagesPath)
+	for _, stream := range []string{"", "daily"} {
+		cons := imagemetadata.NewImageConstraint(simplestreams.LookupParams{
+			Series: []string{"precise"},
+			Arches: []string{"amd64"},
+			Stream: stream,
+		})
+		found, _, err := imagemetadata.Fetch(
+			[]simplestreams.DataSource{source}, simplestreams.DefaultIndexPath, cons, false)
+		c.Assert(err, gc.IsNil)
+		c.Assert(found, gc.HasLen, 1)
+	}
+}
+
+func (s *mirrorSuite) TestMirrorImageMetadataSigned(c *gc.C) {
+	ctx := s.mirrorContext()
+	ctx.Series = []string{"precise"}
+	ctx.ImageSource = makeImageSource(c, testImage("released-64", "amd64", ""))
+	ctx.SigningKey = sstesting.SignedMetadataPrivateKey
+	ctx.Passphrase = sstesting.PrivateKeyPassphrase
+	err := sync.MirrorImageMetadata(ctx)
+	c.Assert(err, gc.IsNil)
+
+	data, err := ioutil.ReadFile(filepath.Join(s.targetDir, "images", "streams", "v1", "index.sjson"))
+	c.Assert(err, gc.IsNil)
+	text, err := simplestreams.DecodeCheckSignature(bytes.NewReader(data), sstesting.SignedMetadataPublicKey)
+	c.Assert(err, gc.IsNil)
+	var indices simplestreams.Indices
+	err = json.Unmarshal(text, &indices)
+	c.Assert(err, gc.IsNil)
+	c.Assert(indices.Indexes[imagemetadata.ImageContentId].ProductsFilePath, gc.Equals,
+		"streams/v1/com.ubuntu.cloud:released:imagemetadata.sjson")
+	_, err = os.Stat(filepath.Join(s.targetDir, "images", "streams", "v1", "com.ubuntu.cloud:released:imagemetadata.sjson"))
+	c.Assert(err, gc.IsNil)
+}
@@ -189,7 +189,9 @@ type UploadFunc func(stor storage.Storage, forceVersion *version.Number, series
 // the given version number; if any fakeSeries are supplied, additional copies
 // of the built tools will be uploaded for use by machines of those series.
 // Juju tools built for one series do not necessarily run on another, but this
-// func exists only for development use cases.
+// func exists only for development use cases. Tools for any Windows series
+// are cross-compiled separately, and copies of those are uploaded for each
+// Windows series supplied.
 var Upload UploadFunc = upload
 
 func upload(stor storage.Storage, forceVersion *version.Number, fakeSeries ...string) (*coretools.Tools, error) {
@@ -224,9 +226,9 @@ func upload(stor storage.Storage, forceVersion *version.Number, fakeSeries ...st
 
 	// Copy the tools to the target storage, recording a Tools struct for each one.
 	var targetTools coretools.List
-	putTools := func(vers version.Binary) (string, error) {
+	putTools := func(vers version.Binary, archive string, size int64, sha256Hash string) (string, error) {
 		name := envtools.StorageName(vers)
-		err = utils.CopyFile(filepath.Join(baseToolsDir, name), f.Name())
+		err = utils.CopyFile(filepath.Join(baseToolsDir, name), archive)
 		if err != nil {
 			return "", err
 		}
@@ -243,20 +245,30 @@ func upload(stor storage.Storage, forceVersion *version.Number, fakeSeries ...st
 		return nil, err
 	}
 	logger.Debugf("generating tarballs for %v", fakeSeries)
+	var windowsSeries []string
 	for _, series := range fakeSeries {
 		_, err := simplestreams.SeriesVersion(series)
 		if err != nil {
 			return nil, err
 		}
+		if version.IsWindowsSeries(series) {
+			windowsSeries = append(windowsSeries, series)
+			continue
+		}
 		if series != toolsVersion.Series {
 			fakeVersion := toolsVersion
 			fakeVersion.Series = series
-			if _, err := putTools(fakeVersion); err != nil {
+			if _, err := putTools(fakeVersion, f.Name(), size, sha256Hash); err != nil {
 				return nil, err
 			}
 		}
 	}
-	name, err := putTools(toolsVersion)
+	if len(windowsSeries) > 0 {
+		if err := putWindowsTools(forceVersion, windowsSeries, putTools); err != nil {
+			return nil, err
+		}
+	}
+	name, err := putTools(toolsVersion, f.Name(), size, sha256Hash)
 	if err != nil {
 		return nil, err
 	}
@@ -296,3 +308,38 @@ func upload(stor storage.Storage, forceVersion *version.Number, fakeSeries ...st
 		SHA256:  sha256Hash,
 	}, nil
 }
+
+// putWindowsTools builds the tools for Windows, and calls
+// putTools to store a copy of them for each of the given series.
+func putWindowsTools(
+	forceVersion *version.Number, series []string,
+	putTools func(vers version.Binary, archive string, size int64, sha256Hash string) (string, error),
+) error {
+	f, err := ioutil.TempFile("", "juju-tgz")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	defer os.Remove(f.Name())
+	toolsVersion, sha256Hash, err := bundleWindowsTools(f, forceVersion, series[0])
+	if err != nil {
+		return err
+	}
+	fileInfo, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("cannot stat newly made tools archive: %v", err)
+	}
+	size := fileInfo.Size()
+	logger.Infof("built tools %v (%dkB)", toolsVersion, (size+512)/1024)
+	for _, s := range series {
+		toolsVersion.Series = s
+		if _, err := putTools(toolsVersion, f.Name(), size, sha256Hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bundleWindowsTools is called to build the tools for
+// Windows; it may be replaced in tests.
+var bundleWindowsTools = envtools.BundleWindowsTools
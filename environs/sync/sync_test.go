@@ -32,6 +32,7 @@ import (
 	jc "launchpad.net/juju-core/testing/checkers"
 	"launchpad.net/juju-core/testing/testbase"
 	coretools "launchpad.net/juju-core/tools"
+	"launchpad.net/juju-core/utils"
 	"launchpad.net/juju-core/version"
 )
 
@@ -315,6 +316,38 @@ func (s *uploadSuite) TestUploadFakeSeries(c *gc.C) {
 	}
 }
 
+func (s *uploadSuite) TestUploadWindowsSeries(c *gc.C) {
+	const windowsTools = "windows tools"
+	var bundled []string
+	s.PatchValue(sync.BundleWindowsTools, func(w io.Writer, forceVersion *version.Number, series string) (version.Binary, string, error) {
+		bundled = append(bundled, series)
+		hash, _, err := utils.ReadSHA256(io.TeeReader(strings.NewReader(windowsTools), w))
+		return version.Binary{
+			Number: version.Current.Number,
+			Series: series,
+			Arch:   "amd64",
+		}, hash, err
+	})
+	t, err := sync.Upload(s.env.Storage(), nil, "win2012r2", "win81")
+	c.Assert(err, gc.IsNil)
+	c.Assert(t.Version, gc.Equals, version.Current)
+	c.Assert(bundled, gc.DeepEquals, []string{"win2012r2"})
+
+	list, err := envtools.ReadList(s.env.Storage(), version.Current.Major, version.Current.Minor)
+	c.Assert(err, gc.IsNil)
+	expectSeries := []string{"win2012r2", "win81", version.Current.Series}
+	sort.Strings(expectSeries)
+	c.Assert(list.AllSeries(), gc.DeepEquals, expectSeries)
+	for _, t := range list {
+		if t.Version.Series == version.Current.Series {
+			continue
+		}
+		c.Logf("checking %s", t.URL)
+		c.Assert(t.Version.Arch, gc.Equals, "amd64")
+		c.Assert(string(downloadToolsRaw(c, t)), gc.Equals, windowsTools)
+	}
+}
+
 func (s *uploadSuite) TestUploadAndForceVersion(c *gc.C) {
 	// This test actually tests three things:
 	//   the writing of the FORCE-VERSION file;
@@ -15,6 +15,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"launchpad.net/juju-core/utils"
 	"launchpad.net/juju-core/version"
 )
 
@@ -241,3 +242,81 @@ func BundleTools(w io.Writer, forceVersion *version.Number) (tvers version.Binar
 	}
 	return tvers, sha256Hash, err
 }
+
+// windowsArch is the architecture of the Windows tools built
+// by BundleWindowsTools.
+const windowsArch = "amd64"
+
+// windowsServiceWrapper is the name of the executable that
+// runs jujud as a Windows service. It is not built from the
+// juju source, so it is bundled from alongside the juju client
+// if it is found there.
+const windowsServiceWrapper = "JujuService.exe"
+
+func buildWindowsJujud(dir string) error {
+	logger.Infof("building jujud for windows/%s", windowsArch)
+	args := []string{"go", "build", "-o", filepath.Join(dir, "jujud.exe"), "launchpad.net/juju-core/cmd/jujud"}
+	env := os.Environ()
+	for _, val := range []string{"GOOS=windows", "GOARCH=" + windowsArch, "CGO_ENABLED=0"} {
+		env = setenv(env, val)
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = env
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("build command %q failed: %v; %s", args[0], err, out)
+	}
+	return nil
+}
+
+func copyWindowsServiceWrapper(dir string) error {
+	jujuLocation, err := findExecutable(os.Args[0])
+	if err != nil {
+		return err
+	}
+	source := filepath.Join(filepath.Dir(jujuLocation), windowsServiceWrapper)
+	if _, err := os.Stat(source); err != nil {
+		return err
+	}
+	return utils.CopyFile(filepath.Join(dir, windowsServiceWrapper), source)
+}
+
+// BundleWindowsTools cross-compiles the juju tools in $GOPATH for
+// Windows, and bundles them in gzipped tar format to the given writer,
+// as BundleTools does. The cross-compiled jujud cannot be run to find
+// its version, so the version returned is that of the client, or
+// forceVersion if it is not nil, with the given Windows series.
+func BundleWindowsTools(w io.Writer, forceVersion *version.Number, series string) (tvers version.Binary, sha256Hash string, err error) {
+	if !version.IsWindowsSeries(series) {
+		return version.Binary{}, "", fmt.Errorf("%q is not a Windows series", series)
+	}
+	dir, err := ioutil.TempDir("", "juju-tools")
+	if err != nil {
+		return version.Binary{}, "", err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := buildWindowsJujud(dir); err != nil {
+		return version.Binary{}, "", err
+	}
+	if err := copyWindowsServiceWrapper(dir); err != nil {
+		logger.Warningf("cannot find %s next to the juju client; Windows agents cannot be started without it", windowsServiceWrapper)
+	}
+	tvers = version.Binary{
+		Number: version.Current.Number,
+		Series: series,
+		Arch:   windowsArch,
+	}
+	if forceVersion != nil {
+		logger.Debugf("forcing version to %s", forceVersion)
+		if err := ioutil.WriteFile(filepath.Join(dir, "FORCE-VERSION"), []byte(forceVersion.String()), 0666); err != nil {
+			return version.Binary{}, "", err
+		}
+		tvers.Number = *forceVersion
+	}
+	sha256Hash, err = archive(w, dir)
+	if err != nil {
+		return version.Binary{}, "", err
+	}
+	return tvers, sha256Hash, nil
+}
@@ -4,15 +4,21 @@
 package tools_test
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	gc "launchpad.net/gocheck"
 
 	"launchpad.net/juju-core/environs/tools"
 	"launchpad.net/juju-core/testing/testbase"
+	"launchpad.net/juju-core/version"
 )
 
 type buildSuite struct {
@@ -91,3 +97,56 @@ func (b *buildSuite) TestFindExecutable(c *gc.C) {
 		}
 	}
 }
+
+// fakeGo records its arguments and the build environment,
+// and creates the file named by the -o argument.
+const fakeGo = `#!/bin/bash
+echo "$@" > "$0.args"
+echo "$GOOS $GOARCH $CGO_ENABLED" > "$0.env"
+echo "fake jujud" > "$3"
+`
+
+func (b *buildSuite) TestBundleWindowsTools(c *gc.C) {
+	dir := c.MkDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "go"), []byte(fakeGo), 0755)
+	c.Assert(err, gc.IsNil)
+	os.Setenv("PATH", dir+":"+os.Getenv("PATH"))
+
+	var buf bytes.Buffer
+	forceVersion := version.MustParse("1.2.3")
+	tvers, hash, err := tools.BundleWindowsTools(&buf, &forceVersion, "win2012r2")
+	c.Assert(err, gc.IsNil)
+	c.Assert(tvers, gc.Equals, version.MustParseBinary("1.2.3-win2012r2-amd64"))
+	c.Assert(hash, gc.Not(gc.Equals), "")
+
+	args, err := ioutil.ReadFile(filepath.Join(dir, "go.args"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(args), gc.Matches, "build -o .*/jujud.exe launchpad.net/juju-core/cmd/jujud\n")
+	env, err := ioutil.ReadFile(filepath.Join(dir, "go.env"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(env), gc.Equals, "windows amd64 0\n")
+
+	files := make(map[string]string)
+	gzr, err := gzip.NewReader(&buf)
+	c.Assert(err, gc.IsNil)
+	tr := tar.NewReader(gzr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, gc.IsNil)
+		data, err := ioutil.ReadAll(tr)
+		c.Assert(err, gc.IsNil)
+		files[h.Name] = strings.TrimSpace(string(data))
+	}
+	c.Assert(files, gc.DeepEquals, map[string]string{
+		"jujud.exe":     "fake jujud",
+		"FORCE-VERSION": "1.2.3",
+	})
+}
+
+func (b *buildSuite) TestBundleWindowsToolsNotWindows(c *gc.C) {
+	_, _, err := tools.BundleWindowsTools(ioutil.Discard, nil, "precise")
+	c.Assert(err, gc.ErrorMatches, `"precise" is not a Windows series`)
+}
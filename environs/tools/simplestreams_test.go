@@ -445,6 +445,18 @@ func (s *productSpecSuite) TestIdMultiSeries(c *gc.C) {
 		"com.ubuntu.juju:13.04:amd64"})
 }
 
+func (s *productSpecSuite) TestIdWindows(c *gc.C) {
+	toolsConstraint := tools.NewVersionedToolsConstraint(version.MustParse("1.17.5"), simplestreams.LookupParams{
+		Series: []string{"win2012r2", "win81"},
+		Arches: []string{"amd64"},
+	})
+	ids, err := toolsConstraint.Ids()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ids, gc.DeepEquals, []string{
+		"com.ubuntu.juju:win2012r2:amd64",
+		"com.ubuntu.juju:win81:amd64"})
+}
+
 func (s *productSpecSuite) TestIdWithMajorVersionOnly(c *gc.C) {
 	toolsConstraint := tools.NewGeneralToolsConstraint(1, -1, false, simplestreams.LookupParams{
 		Series: []string{"precise"},
@@ -28,6 +28,7 @@ var logger = loggo.GetLogger("juju")
 var (
 	apiOpen              = api.Open
 	apiClose             = (*api.State).Close
+	apiEnvironTag        = environTag
 	providerConnectDelay = 2 * time.Second
 )
 
@@ -55,12 +56,17 @@ var errAborted = fmt.Errorf("aborted")
 
 // NewAPIConn returns a new Conn that uses the
 // given environment. The environment must have already
-// been bootstrapped.
+// been bootstrapped. If the UUID of the environment has
+// been cached in the config store, the connection is
+// refused by a server that does not serve it.
 func NewAPIConn(environ environs.Environ, dialOpts api.DialOpts) (*APIConn, error) {
 	info, err := environAPIInfo(environ)
 	if err != nil {
 		return nil, err
 	}
+	if uuid := cachedEnvironUUID(environ.Name()); uuid != "" {
+		info.EnvironTag = names.EnvironTag(uuid)
+	}
 
 	st, err := apiOpen(info, dialOpts)
 	// TODO(rog): handle errUnauthorized when the API handles passwords.
@@ -187,8 +193,9 @@ func newAPIFromName(envName string, store configstore.Storage) (*api.State, erro
 
 	if val.cachedInfo != nil && info != nil {
 		// Cache the connection settings only if we used the
-		// environment config, but any errors are just logged
-		// as warnings, because they're not fatal.
+		// environment config or found out the environment UUID,
+		// but any errors are just logged as warnings, because
+		// they're not fatal.
 		err = cacheAPIInfo(info, val.cachedInfo)
 		if err != nil {
 			logger.Warningf(err.Error())
@@ -235,11 +242,22 @@ func apiInfoConnect(store configstore.Storage, info configstore.EnvironInfo, sto
 		Tag:      names.UserTag(info.APICredentials().User),
 		Password: info.APICredentials().Password,
 	}
+	if endpoint.EnvironUUID != "" {
+		apiInfo.EnvironTag = names.EnvironTag(endpoint.EnvironUUID)
+	}
 	st, err := apiOpen(apiInfo, api.DefaultDialOpts())
 	if err != nil {
 		return apiState{}, &infoConnectError{err}
 	}
-	return apiState{st, nil}, err
+	if apiInfo.EnvironTag != "" {
+		return apiState{st, nil}, nil
+	}
+	// The settings were cached before the environment UUID
+	// was, so find it out and cache it now.
+	if apiInfo.EnvironTag = apiEnvironTag(st); apiInfo.EnvironTag == "" {
+		return apiState{st, nil}, nil
+	}
+	return apiState{st, apiInfo}, nil
 }
 
 // apiConfigConnect looks for configuration info on the given environment,
@@ -278,9 +296,42 @@ func apiConfigConnect(info configstore.EnvironInfo, envs *environs.Environs, env
 	if err != nil {
 		return apiState{}, err
 	}
+	apiInfo.EnvironTag = apiEnvironTag(st)
 	return apiState{st, apiInfo}, nil
 }
 
+// environTag returns the tag of the environment served by st. It
+// returns an empty string if the environment cannot be found out,
+// in which case later connections do not name the environment.
+func environTag(st *api.State) string {
+	env, err := st.Client().EnvironmentInfo()
+	if err != nil {
+		logger.Warningf("cannot get environment UUID: %v", err)
+		return ""
+	}
+	if env.UUID == "" {
+		return ""
+	}
+	return names.EnvironTag(env.UUID)
+}
+
+// cachedEnvironUUID returns the UUID of the named environment
+// held in the config store, or an empty string if none is held.
+func cachedEnvironUUID(envName string) string {
+	if !osenv.IsJujuHomeSet() {
+		return ""
+	}
+	store, err := configstore.NewDisk(osenv.JujuHome())
+	if err != nil {
+		return ""
+	}
+	info, err := store.ReadInfo(envName)
+	if err != nil {
+		return ""
+	}
+	return info.APIEndpoint().EnvironUUID
+}
+
 func environAPIInfo(environ environs.Environ) (*api.Info, error) {
 	_, info, err := environ.StateInfo()
 	if err != nil {
@@ -299,10 +350,18 @@ func environAPIInfo(environ environs.Environ) (*api.Info, error) {
 // with the provided apiInfo, assuming we've just successfully
 // connected to the API server.
 func cacheAPIInfo(info configstore.EnvironInfo, apiInfo *api.Info) error {
-	info.SetAPIEndpoint(configstore.APIEndpoint{
+	endpoint := configstore.APIEndpoint{
 		Addresses: apiInfo.Addrs,
 		CACert:    string(apiInfo.CACert),
-	})
+	}
+	if apiInfo.EnvironTag != "" {
+		_, uuid, err := names.ParseTag(apiInfo.EnvironTag, names.EnvironTagKind)
+		if err != nil {
+			return fmt.Errorf("not caching API connection settings: invalid environment tag: %v", err)
+		}
+		endpoint.EnvironUUID = uuid
+	}
+	info.SetAPIEndpoint(endpoint)
 	_, username, err := names.ParseTag(apiInfo.Tag, names.UserTagKind)
 	if err != nil {
 		return fmt.Errorf("not caching API connection settings: invalid API user tag: %v", err)
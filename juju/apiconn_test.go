@@ -123,8 +123,9 @@ func (*NewAPIClientSuite) TestWithInfoOnly(c *gc.C) {
 			Password: "foopass",
 		},
 		endpoint: configstore.APIEndpoint{
-			Addresses: []string{"foo.invalid"},
-			CACert:    "certificated",
+			Addresses:   []string{"foo.invalid"},
+			CACert:      "certificated",
+			EnvironUUID: testEnvironUUID,
 		},
 	}
 	store := newConfigStore("noconfig", storeConfig)
@@ -135,6 +136,7 @@ func (*NewAPIClientSuite) TestWithInfoOnly(c *gc.C) {
 		c.Check(apiInfo.Tag, gc.Equals, "user-foo")
 		c.Check(string(apiInfo.CACert), gc.Equals, "certificated")
 		c.Check(apiInfo.Password, gc.Equals, "foopass")
+		c.Check(apiInfo.EnvironTag, gc.Equals, "environment-"+testEnvironUUID)
 		c.Check(opts, gc.DeepEquals, api.DefaultDialOpts())
 		called++
 		return expectState, nil
@@ -142,6 +144,7 @@ func (*NewAPIClientSuite) TestWithInfoOnly(c *gc.C) {
 	// Give NewAPIFromName a store interface that can report when the
 	// config was written to, to ensure the cache isn't updated.
 	defer testbase.PatchValue(juju.APIOpen, apiOpen).Restore()
+	defer testbase.PatchValue(juju.APIEnvironTag, panicAPIEnvironTag).Restore()
 	mockStore := &storageWithWriteNotify{store: store}
 	st, err := juju.NewAPIFromName("noconfig", mockStore)
 	c.Assert(err, gc.IsNil)
@@ -150,6 +153,40 @@ func (*NewAPIClientSuite) TestWithInfoOnly(c *gc.C) {
 	c.Assert(mockStore.written, jc.IsFalse)
 }
 
+func (*NewAPIClientSuite) TestWithInfoNoEnvironUUID(c *gc.C) {
+	defer coretesting.MakeEmptyFakeHome(c).Restore()
+	store := newConfigStore("noconfig", &environInfo{
+		creds: configstore.APICredentials{
+			User:     "foo",
+			Password: "foopass",
+		},
+		endpoint: configstore.APIEndpoint{
+			Addresses: []string{"foo.invalid"},
+			CACert:    "certificated",
+		},
+	})
+
+	expectState := new(api.State)
+	apiOpen := func(apiInfo *api.Info, opts api.DialOpts) (*api.State, error) {
+		c.Check(apiInfo.EnvironTag, gc.Equals, "")
+		return expectState, nil
+	}
+	defer testbase.PatchValue(juju.APIOpen, apiOpen).Restore()
+	defer testbase.PatchValue(juju.APIEnvironTag, fakeAPIEnvironTag).Restore()
+	st, err := juju.NewAPIFromName("noconfig", store)
+	c.Assert(err, gc.IsNil)
+	c.Assert(st, gc.Equals, expectState)
+
+	// The environment UUID is found out and cached.
+	info, err := store.ReadInfo("noconfig")
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.APIEndpoint(), jc.DeepEquals, configstore.APIEndpoint{
+		Addresses:   []string{"foo.invalid"},
+		CACert:      "certificated",
+		EnvironUUID: testEnvironUUID,
+	})
+}
+
 func (*NewAPIClientSuite) TestWithConfigAndNoInfo(c *gc.C) {
 	defer coretesting.MakeSampleHome(c).Restore()
 
@@ -181,11 +218,13 @@ func (*NewAPIClientSuite) TestWithConfigAndNoInfo(c *gc.C) {
 		c.Check(apiInfo.Tag, gc.Equals, "user-admin")
 		c.Check(string(apiInfo.CACert), gc.Not(gc.Equals), "")
 		c.Check(apiInfo.Password, gc.Equals, "adminpass")
+		c.Check(apiInfo.EnvironTag, gc.Equals, "")
 		c.Check(opts, gc.DeepEquals, api.DefaultDialOpts())
 		called++
 		return expectState, nil
 	}
 	defer testbase.PatchValue(juju.APIOpen, apiOpen).Restore()
+	defer testbase.PatchValue(juju.APIEnvironTag, fakeAPIEnvironTag).Restore()
 	st, err := juju.NewAPIFromName("myenv", store)
 	c.Assert(err, gc.IsNil)
 	c.Assert(st, gc.Equals, expectState)
@@ -199,6 +238,7 @@ func (*NewAPIClientSuite) TestWithConfigAndNoInfo(c *gc.C) {
 	c.Check(ep.Addresses, gc.HasLen, 1)
 	c.Check(ep.Addresses[0], gc.Matches, `127\.0\.0\.1:\d+`)
 	c.Check(ep.CACert, gc.Not(gc.Equals), "")
+	c.Check(ep.EnvironUUID, gc.Equals, testEnvironUUID)
 	creds := info.APICredentials()
 	c.Check(creds.User, gc.Equals, "admin")
 	c.Check(creds.Password, gc.Equals, "adminpass")
@@ -218,6 +258,16 @@ func panicAPIOpen(apiInfo *api.Info, opts api.DialOpts) (*api.State, error) {
 	panic("api.Open called unexpectedly")
 }
 
+const testEnvironUUID = "0fa6ab96-7a5c-4c3b-9d0c-4ea1c8a0e7e2"
+
+func fakeAPIEnvironTag(st *api.State) string {
+	return "environment-" + testEnvironUUID
+}
+
+func panicAPIEnvironTag(st *api.State) string {
+	panic("environment tag requested unexpectedly")
+}
+
 func (*NewAPIClientSuite) TestWithInfoNoAddresses(c *gc.C) {
 	defer coretesting.MakeEmptyFakeHome(c).Restore()
 	store := newConfigStore("noconfig", &environInfo{
@@ -272,6 +322,7 @@ func (*NewAPIClientSuite) TestWithSlowInfoConnect(c *gc.C) {
 		return cfgOpenedState, nil
 	}
 	defer testbase.PatchValue(juju.APIOpen, apiOpen).Restore()
+	defer testbase.PatchValue(juju.APIEnvironTag, fakeAPIEnvironTag).Restore()
 
 	stateClosed, restoreAPIClose := setAPIClosed()
 	defer restoreAPIClose.Restore()
@@ -336,6 +387,7 @@ func (*NewAPIClientSuite) TestWithSlowConfigConnect(c *gc.C) {
 		return cfgOpenedState, nil
 	}
 	defer testbase.PatchValue(juju.APIOpen, apiOpen).Restore()
+	defer testbase.PatchValue(juju.APIEnvironTag, fakeAPIEnvironTag).Restore()
 
 	stateClosed, restoreAPIClose := setAPIClosed()
 	defer restoreAPIClose.Restore()
@@ -441,6 +441,32 @@ func (s *ConnSuite) TestAddUnits(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, `invalid force machine id ".*"`)
 }
 
+func (s *ConnSuite) TestMigrateUnit(c *gc.C) {
+	curl := coretesting.Charms.ClonedURL(s.repo.Path, "quantal", "riak")
+	sch, err := s.conn.PutCharm(curl, s.repo, false)
+	c.Assert(err, gc.IsNil)
+	svc, err := s.conn.State.AddService("testriak", "user-admin", sch)
+	c.Assert(err, gc.IsNil)
+	units, err := juju.AddUnits(s.conn.State, svc, 1, "")
+	c.Assert(err, gc.IsNil)
+	id0, err := units[0].AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+
+	_, err = juju.MigrateUnit(s.conn.State, units[0], "lxc:bad")
+	c.Assert(err, gc.ErrorMatches, `invalid force machine id "bad"`)
+	_, err = juju.MigrateUnit(s.conn.State, units[0], "42")
+	c.Assert(err, gc.ErrorMatches, `cannot migrate unit "testriak/0": machine 42 not found`)
+
+	target, err := juju.MigrateUnit(s.conn.State, units[0], "lxc:"+id0)
+	c.Assert(err, gc.IsNil)
+	id1, err := target.AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(id1, gc.Equals, id0+"/lxc/0")
+	from, ok := target.MigratedFrom()
+	c.Assert(ok, gc.Equals, true)
+	c.Assert(from, gc.Equals, "testriak/0")
+}
+
 // DeployLocalSuite uses a fresh copy of the same local dummy charm for each
 // test, because DeployService demands that a charm already exists in state,
 // and that's is the simplest way to get one in there.
@@ -90,45 +90,15 @@ func AddUnits(st *state.State, svc *state.Service, n int, machineIdSpec string)
 			if n != 1 {
 				return nil, fmt.Errorf("cannot add multiple units of service %q to a single machine", svc.Name())
 			}
-			// machineIdSpec may be an existing machine or container, eg 3/lxc/2
-			// or a new container on a machine, eg lxc:3
-			mid := machineIdSpec
-			var containerType instance.ContainerType
-			specParts := strings.SplitN(machineIdSpec, ":", 2)
-			if len(specParts) > 1 {
-				firstPart := specParts[0]
-				var err error
-				if containerType, err = instance.ParseContainerType(firstPart); err == nil {
-					mid = specParts[1]
-				} else {
-					mid = machineIdSpec
-				}
-			}
-			if !names.IsMachine(mid) {
-				return nil, fmt.Errorf("invalid force machine id %q", mid)
-			}
-
-			var err error
-			var m *state.Machine
-			// If a container is to be used, create it.
-			if containerType != "" {
-				// Create the new machine marked as dirty so that
-				// nothing else will grab it before we assign the unit to it.
-				template := state.MachineTemplate{
-					Series: unit.Series(),
-					Jobs:   []state.MachineJob{state.JobHostUnits},
-					Dirty:  true,
-				}
-				m, err = st.AddMachineInsideMachine(template, mid, containerType)
-			} else {
-				m, err = st.Machine(mid)
+			mid, containerType, err := parseMachineIdSpec(machineIdSpec)
+			if err != nil {
+				return nil, err
 			}
+			m, err := placementMachine(st, unit.Series(), mid, containerType)
 			if err != nil {
 				return nil, fmt.Errorf("cannot assign unit %q to machine: %v", unit.Name(), err)
 			}
-			err = unit.AssignToMachine(m)
-
-			if err != nil {
+			if err := unit.AssignToMachine(m); err != nil {
 				return nil, err
 			}
 		} else if err := st.AssignUnit(unit, policy); err != nil {
@@ -138,3 +108,58 @@ func AddUnits(st *state.State, svc *state.Service, n int, machineIdSpec string)
 	}
 	return units, nil
 }
+
+// MigrateUnit starts migrating the unit to the machine given by
+// machineIdSpec, which is interpreted as by AddUnits, and returns
+// the unit that replaces it.
+func MigrateUnit(st *state.State, unit *state.Unit, machineIdSpec string) (*state.Unit, error) {
+	mid, containerType, err := parseMachineIdSpec(machineIdSpec)
+	if err != nil {
+		return nil, err
+	}
+	m, err := placementMachine(st, unit.Series(), mid, containerType)
+	if err != nil {
+		return nil, fmt.Errorf("cannot migrate unit %q: %v", unit.Name(), err)
+	}
+	return unit.StartMigration(m)
+}
+
+// parseMachineIdSpec returns the machine id and, if a new container is
+// to be created on that machine, the container type given by
+// machineIdSpec. The spec may name an existing machine or container,
+// eg 3/lxc/2, or a new container on a machine, eg lxc:3.
+func parseMachineIdSpec(machineIdSpec string) (string, instance.ContainerType, error) {
+	mid := machineIdSpec
+	var containerType instance.ContainerType
+	specParts := strings.SplitN(machineIdSpec, ":", 2)
+	if len(specParts) > 1 {
+		firstPart := specParts[0]
+		var err error
+		if containerType, err = instance.ParseContainerType(firstPart); err == nil {
+			mid = specParts[1]
+		} else {
+			mid = machineIdSpec
+		}
+	}
+	if !names.IsMachine(mid) {
+		return "", "", fmt.Errorf("invalid force machine id %q", mid)
+	}
+	return mid, containerType, nil
+}
+
+// placementMachine returns the machine with the given id or, if
+// containerType is not empty, a new container of that type on it,
+// created for units of the given series.
+func placementMachine(st *state.State, series, mid string, containerType instance.ContainerType) (*state.Machine, error) {
+	if containerType == "" {
+		return st.Machine(mid)
+	}
+	// Create the new machine marked as dirty so that
+	// nothing else will grab it before we assign the unit to it.
+	template := state.MachineTemplate{
+		Series: series,
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+		Dirty:  true,
+	}
+	return st.AddMachineInsideMachine(template, mid, containerType)
+}
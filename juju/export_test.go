@@ -3,6 +3,7 @@ package juju
 var (
 	APIOpen              = &apiOpen
 	APIClose             = &apiClose
+	APIEnvironTag        = &apiEnvironTag
 	ProviderConnectDelay = &providerConnectDelay
 	NewAPIFromName       = newAPIFromName
 )
@@ -40,6 +40,13 @@ func JujuHome() string {
 	return jujuHome
 }
 
+// IsJujuHomeSet reports whether juju home has been initialized.
+func IsJujuHomeSet() bool {
+	jujuHomeMu.Lock()
+	defer jujuHomeMu.Unlock()
+	return jujuHome != ""
+}
+
 // JujuHomePath returns the path to a file in the
 // current juju home.
 func JujuHomePath(names ...string) string {
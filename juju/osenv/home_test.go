@@ -31,6 +31,12 @@ func (s *JujuHomeSuite) TestErrorHome(c *gc.C) {
 	c.Assert(f, gc.PanicMatches, "juju home hasn't been initialized")
 }
 
+func (s *JujuHomeSuite) TestIsJujuHomeSet(c *gc.C) {
+	c.Assert(osenv.IsJujuHomeSet(), gc.Equals, false)
+	defer osenv.SetJujuHome(osenv.SetJujuHome(c.MkDir()))
+	c.Assert(osenv.IsJujuHomeSet(), gc.Equals, true)
+}
+
 func (s *JujuHomeSuite) TestHomePath(c *gc.C) {
 	testJujuHome := c.MkDir()
 	defer osenv.SetJujuHome(osenv.SetJujuHome(testJujuHome))
@@ -0,0 +1,197 @@
+// The bsoncodec package provides a binary BSON codec for the rpc
+// package. It is a more compact and cheaper to encode alternative to
+// the JSON codec, negotiated by API clients and servers that both
+// support it.
+package bsoncodec
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/juju/loggo"
+	"labix.org/v2/mgo/bson"
+
+	"launchpad.net/juju-core/rpc"
+)
+
+var logger = loggo.GetLogger("juju.rpc.bsoncodec")
+
+// Protocol holds the websocket subprotocol name that identifies
+// connections using this codec.
+const Protocol = "juju-bson"
+
+// BSONConn sends and receives messages to an underlying connection
+// in BSON format.
+type BSONConn interface {
+	// Send sends a message.
+	Send(msg interface{}) error
+	// Receive receives a message into msg.
+	Receive(msg interface{}) error
+	Close() error
+}
+
+// Codec implements rpc.Codec for a connection.
+type Codec struct {
+	// msg holds the message that's just been read by ReadHeader, so
+	// that the body can be read by ReadBody.
+	msg         inMsg
+	conn        BSONConn
+	logMessages int32
+	mu          sync.Mutex
+	closing     bool
+}
+
+// New returns an rpc codec that uses conn to send and receive
+// messages.
+func New(conn BSONConn) *Codec {
+	return &Codec{
+		conn: conn,
+	}
+}
+
+// SetLogging sets whether messages will be logged
+// by the codec.
+func (c *Codec) SetLogging(on bool) {
+	val := int32(0)
+	if on {
+		val = 1
+	}
+	atomic.StoreInt32(&c.logMessages, val)
+}
+
+func (c *Codec) isLogging() bool {
+	return atomic.LoadInt32(&c.logMessages) != 0
+}
+
+// inMsg holds an incoming message.  We don't know the type of the
+// parameters or response yet, so we delay parsing by storing them
+// as raw BSON.
+type inMsg struct {
+	RequestId uint64
+	Type      string
+	Id        string
+	Request   string
+	Params    bson.Raw
+	Error     string
+	ErrorCode string
+	Response  bson.Raw
+}
+
+// outMsg holds an outgoing message.
+type outMsg struct {
+	RequestId uint64
+	Type      string      `bson:",omitempty"`
+	Id        string      `bson:",omitempty"`
+	Request   string      `bson:",omitempty"`
+	Params    interface{} `bson:",omitempty"`
+	Error     string      `bson:",omitempty"`
+	ErrorCode string      `bson:",omitempty"`
+	Response  interface{} `bson:",omitempty"`
+}
+
+func (c *Codec) Close() error {
+	c.mu.Lock()
+	c.closing = true
+	c.mu.Unlock()
+	return c.conn.Close()
+}
+
+func (c *Codec) isClosing() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closing
+}
+
+func (c *Codec) ReadHeader(hdr *rpc.Header) error {
+	c.msg = inMsg{} // avoid any potential cross-message contamination.
+	var err error
+	if c.isLogging() {
+		var m bson.Raw
+		err = c.conn.Receive(&m)
+		if err == nil {
+			logger.Tracef("<- %s", dump(m))
+			err = m.Unmarshal(&c.msg)
+		} else {
+			logger.Tracef("<- error: %v (closing %v)", err, c.isClosing())
+		}
+	} else {
+		err = c.conn.Receive(&c.msg)
+	}
+	if err != nil {
+		// If we've closed the connection, we may get a spurious error,
+		// so ignore it.
+		if c.isClosing() || err == io.EOF {
+			return io.EOF
+		}
+		return fmt.Errorf("error receiving message: %v", err)
+	}
+	hdr.RequestId = c.msg.RequestId
+	hdr.Request = rpc.Request{
+		Type:   c.msg.Type,
+		Id:     c.msg.Id,
+		Action: c.msg.Request,
+	}
+	hdr.Error = c.msg.Error
+	hdr.ErrorCode = c.msg.ErrorCode
+	return nil
+}
+
+func (c *Codec) ReadBody(body interface{}, isRequest bool) error {
+	if body == nil {
+		return nil
+	}
+	var rawBody bson.Raw
+	if isRequest {
+		rawBody = c.msg.Params
+	} else {
+		rawBody = c.msg.Response
+	}
+	if rawBody.Kind == 0 {
+		// If the response or params are omitted, it's
+		// equivalent to an empty object.
+		return nil
+	}
+	return rawBody.Unmarshal(body)
+}
+
+func (c *Codec) WriteMessage(hdr *rpc.Header, body interface{}) error {
+	var m outMsg
+	m.init(hdr, body)
+	if c.isLogging() {
+		data, err := bson.Marshal(&m)
+		if err != nil {
+			logger.Tracef("-> marshal error: %v", err)
+			return err
+		}
+		logger.Tracef("-> %s", dump(bson.Raw{Kind: 3, Data: data}))
+	}
+	return c.conn.Send(&m)
+}
+
+// dump returns a printable representation of the BSON document
+// held in m, for logging.
+func dump(m bson.Raw) string {
+	var doc bson.M
+	if err := m.Unmarshal(&doc); err != nil {
+		return fmt.Sprintf("<invalid BSON: %v>", err)
+	}
+	return fmt.Sprintf("%v", doc)
+}
+
+// init fills out the receiving outMsg with information from the given
+// header and body.
+func (m *outMsg) init(hdr *rpc.Header, body interface{}) {
+	m.RequestId = hdr.RequestId
+	m.Type = hdr.Request.Type
+	m.Id = hdr.Request.Id
+	m.Request = hdr.Request.Action
+	m.Error = hdr.Error
+	m.ErrorCode = hdr.ErrorCode
+	if hdr.IsRequest() {
+		m.Params = body
+	} else {
+		m.Response = body
+	}
+}
@@ -0,0 +1,240 @@
+package bsoncodec_test
+
+import (
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	stdtesting "testing"
+
+	"github.com/juju/loggo"
+	"labix.org/v2/mgo/bson"
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/rpc"
+	"launchpad.net/juju-core/rpc/bsoncodec"
+	"launchpad.net/juju-core/testing/testbase"
+)
+
+type suite struct {
+	testbase.LoggingSuite
+}
+
+var _ = gc.Suite(&suite{})
+
+func TestPackage(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type value struct {
+	X string
+}
+
+var readTests = []struct {
+	msg        bson.M
+	expectHdr  rpc.Header
+	expectBody interface{}
+}{{
+	msg: bson.M{"requestid": 1, "type": "foo", "id": "id", "request": "frob", "params": bson.M{"x": "param"}},
+	expectHdr: rpc.Header{
+		RequestId: 1,
+		Request: rpc.Request{
+			Type:   "foo",
+			Id:     "id",
+			Action: "frob",
+		},
+	},
+	expectBody: &value{X: "param"},
+}, {
+	msg: bson.M{"requestid": 2, "error": "an error", "errorcode": "a code"},
+	expectHdr: rpc.Header{
+		RequestId: 2,
+		Error:     "an error",
+		ErrorCode: "a code",
+	},
+	expectBody: new(map[string]interface{}),
+}, {
+	msg: bson.M{"requestid": 3, "response": bson.M{"x": "result"}},
+	expectHdr: rpc.Header{
+		RequestId: 3,
+	},
+	expectBody: &value{X: "result"},
+}}
+
+func (*suite) TestRead(c *gc.C) {
+	for i, test := range readTests {
+		c.Logf("test %d", i)
+		codec := bsoncodec.New(&testConn{
+			readMsgs: []bson.M{test.msg},
+		})
+		var hdr rpc.Header
+		err := codec.ReadHeader(&hdr)
+		c.Assert(err, gc.IsNil)
+		c.Assert(hdr, gc.DeepEquals, test.expectHdr)
+
+		c.Assert(hdr.IsRequest(), gc.Equals, test.expectHdr.IsRequest())
+
+		body := reflect.New(reflect.ValueOf(test.expectBody).Type().Elem()).Interface()
+		err = codec.ReadBody(body, test.expectHdr.IsRequest())
+		c.Assert(err, gc.IsNil)
+		c.Assert(body, gc.DeepEquals, test.expectBody)
+
+		err = codec.ReadHeader(&hdr)
+		c.Assert(err, gc.Equals, io.EOF)
+	}
+}
+
+func (*suite) TestReadHeaderLogsRequests(c *gc.C) {
+	codecLogger := loggo.GetLogger("juju.rpc.bsoncodec")
+	defer codecLogger.SetLogLevel(codecLogger.LogLevel())
+	codecLogger.SetLogLevel(loggo.TRACE)
+	msg := bson.M{"requestid": 1, "type": "foo", "id": "id", "request": "frob"}
+	codec := bsoncodec.New(&testConn{
+		readMsgs: []bson.M{msg, msg},
+	})
+	// Check that logging is off by default
+	var h rpc.Header
+	err := codec.ReadHeader(&h)
+	c.Assert(err, gc.IsNil)
+	c.Assert(c.GetTestLog(), gc.Matches, "")
+
+	// Check that we see a log message when we switch logging on.
+	codec.SetLogging(true)
+	err = codec.ReadHeader(&h)
+	c.Assert(err, gc.IsNil)
+	c.Assert(c.GetTestLog(), gc.Matches, `.*TRACE juju.rpc.bsoncodec <- map\[.*request:frob.*\]\n`)
+}
+
+func (*suite) TestErrorAfterClose(c *gc.C) {
+	conn := &testConn{
+		err: errors.New("some error"),
+	}
+	codec := bsoncodec.New(conn)
+	var hdr rpc.Header
+	err := codec.ReadHeader(&hdr)
+	c.Assert(err, gc.ErrorMatches, "error receiving message: some error")
+
+	err = codec.Close()
+	c.Assert(err, gc.IsNil)
+	c.Assert(conn.closed, gc.Equals, true)
+
+	err = codec.ReadHeader(&hdr)
+	c.Assert(err, gc.Equals, io.EOF)
+}
+
+var writeTests = []struct {
+	hdr    *rpc.Header
+	body   interface{}
+	expect bson.M
+}{{
+	hdr: &rpc.Header{
+		RequestId: 1,
+		Request: rpc.Request{
+			Type:   "foo",
+			Id:     "id",
+			Action: "frob",
+		},
+	},
+	body:   &value{X: "param"},
+	expect: bson.M{"requestid": int64(1), "type": "foo", "id": "id", "request": "frob", "params": bson.M{"x": "param"}},
+}, {
+	hdr: &rpc.Header{
+		RequestId: 2,
+		Error:     "an error",
+		ErrorCode: "a code",
+	},
+	expect: bson.M{"requestid": int64(2), "error": "an error", "errorcode": "a code"},
+}, {
+	hdr: &rpc.Header{
+		RequestId: 3,
+	},
+	body:   &value{X: "result"},
+	expect: bson.M{"requestid": int64(3), "response": bson.M{"x": "result"}},
+}}
+
+func (*suite) TestWrite(c *gc.C) {
+	for i, test := range writeTests {
+		c.Logf("test %d", i)
+		var conn testConn
+		codec := bsoncodec.New(&conn)
+		err := codec.WriteMessage(test.hdr, test.body)
+		c.Assert(err, gc.IsNil)
+		c.Assert(conn.writeMsgs, gc.HasLen, 1)
+		c.Assert(conn.writeMsgs[0], gc.DeepEquals, test.expect)
+	}
+}
+
+func (*suite) TestNetRoundTrip(c *gc.C) {
+	client, server := net.Pipe()
+	clientCodec := bsoncodec.NewNet(client)
+	serverCodec := bsoncodec.NewNet(server)
+	defer clientCodec.Close()
+	defer serverCodec.Close()
+
+	hdr := &rpc.Header{
+		RequestId: 42,
+		Request: rpc.Request{
+			Type:   "foo",
+			Id:     "id",
+			Action: "frob",
+		},
+	}
+	done := make(chan error)
+	go func() {
+		done <- clientCodec.WriteMessage(hdr, &value{X: "param"})
+	}()
+	var got rpc.Header
+	err := serverCodec.ReadHeader(&got)
+	c.Assert(err, gc.IsNil)
+	c.Assert(got, gc.DeepEquals, *hdr)
+	var body value
+	err = serverCodec.ReadBody(&body, true)
+	c.Assert(err, gc.IsNil)
+	c.Assert(body, gc.Equals, value{X: "param"})
+	c.Assert(<-done, gc.IsNil)
+
+	client.Close()
+	err = serverCodec.ReadHeader(&got)
+	c.Assert(err, gc.Equals, io.EOF)
+}
+
+type testConn struct {
+	readMsgs  []bson.M
+	err       error
+	writeMsgs []bson.M
+	closed    bool
+}
+
+func (c *testConn) Receive(msg interface{}) error {
+	if len(c.readMsgs) > 0 {
+		m := c.readMsgs[0]
+		c.readMsgs = c.readMsgs[1:]
+		data, err := bson.Marshal(m)
+		if err != nil {
+			return err
+		}
+		return bson.Unmarshal(data, msg)
+	}
+	if c.err != nil {
+		return c.err
+	}
+	return io.EOF
+}
+
+func (c *testConn) Send(msg interface{}) error {
+	data, err := bson.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	var m bson.M
+	if err := bson.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	c.writeMsgs = append(c.writeMsgs, m)
+	return nil
+}
+
+func (c *testConn) Close() error {
+	c.closed = true
+	return nil
+}
@@ -15,6 +15,11 @@ import (
 
 var logger = loggo.GetLogger("juju.rpc.jsoncodec")
 
+// Protocol holds the websocket subprotocol name that identifies
+// connections using this codec. Connections that name no
+// subprotocol use it too.
+const Protocol = "juju-json"
+
 // JSONConn sends and receives messages to an underlying connection
 // in JSON format.
 type JSONConn interface {
@@ -4,11 +4,14 @@
 package rpc_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net"
 	"reflect"
 	"regexp"
+	"strconv"
+	"strings"
 	"sync"
 	stdtesting "testing"
 	"time"
@@ -19,6 +22,7 @@ import (
 	"launchpad.net/juju-core/rpc"
 	"launchpad.net/juju-core/rpc/jsoncodec"
 	"launchpad.net/juju-core/testing/testbase"
+	"launchpad.net/juju-core/utils/metrics"
 )
 
 type rpcSuite struct {
@@ -582,6 +586,54 @@ func (*rpcSuite) TestTransformErrors(c *gc.C) {
 
 }
 
+// metricValue returns the value of the sample with the given
+// name and labels in the metrics written by the process.
+func metricValue(c *gc.C, sample string) float64 {
+	var buf bytes.Buffer
+	err := metrics.WriteText(&buf)
+	c.Assert(err, gc.IsNil)
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, sample+" ") {
+			v, err := strconv.ParseFloat(line[len(sample)+1:], 64)
+			c.Assert(err, gc.IsNil)
+			return v
+		}
+	}
+	return 0
+}
+
+func (*rpcSuite) TestMetrics(c *gc.C) {
+	root := &Root{
+		errorInst: &ErrorMethods{&codedError{"message", "code"}},
+	}
+	client, srvDone, _, _ := newRPCClientServer(c, root, nil, false)
+	defer closeClient(c, client, srvDone)
+
+	const (
+		okSample       = `juju_rpc_requests_total{facade="ErrorMethods",method="Call",result="ok"}`
+		errorSample    = `juju_rpc_requests_total{facade="ErrorMethods",method="Call",result="error"}`
+		durationSample = `juju_rpc_request_duration_seconds_count{facade="ErrorMethods",method="Call"}`
+		rejectedSample = `juju_rpc_requests_rejected_total`
+	)
+	ok0 := metricValue(c, okSample)
+	error0 := metricValue(c, errorSample)
+	duration0 := metricValue(c, durationSample)
+	rejected0 := metricValue(c, rejectedSample)
+
+	err := client.Call(rpc.Request{"ErrorMethods", "", "Call"}, nil, nil)
+	c.Assert(err, gc.NotNil)
+	root.errorInst.err = nil
+	err = client.Call(rpc.Request{"ErrorMethods", "", "Call"}, nil, nil)
+	c.Assert(err, gc.IsNil)
+	err = client.Call(rpc.Request{"BadSomething", "", "Call"}, nil, nil)
+	c.Assert(err, gc.NotNil)
+
+	c.Assert(metricValue(c, okSample)-ok0, gc.Equals, 1.0)
+	c.Assert(metricValue(c, errorSample)-error0, gc.Equals, 1.0)
+	c.Assert(metricValue(c, durationSample)-duration0, gc.Equals, 2.0)
+	c.Assert(metricValue(c, rejectedSample)-rejected0, gc.Equals, 1.0)
+}
+
 func (*rpcSuite) TestServerWaitsForOutstandingCalls(c *gc.C) {
 	ready := make(chan struct{})
 	start := make(chan string)
@@ -13,12 +13,35 @@ import (
 	"github.com/juju/loggo"
 
 	"launchpad.net/juju-core/rpc/rpcreflect"
+	"launchpad.net/juju-core/utils/metrics"
 )
 
 const CodeNotImplemented = "not implemented"
 
 var logger = loggo.GetLogger("juju.rpc")
 
+var (
+	requestsTotal = metrics.NewCounter(
+		"juju_rpc_requests_total",
+		"Number of RPC requests served, by facade, method and result.",
+		"facade", "method", "result",
+	)
+	requestDuration = metrics.NewHistogram(
+		"juju_rpc_request_duration_seconds",
+		"Time taken to serve RPC requests, by facade and method.",
+		nil,
+		"facade", "method",
+	)
+	requestsInFlight = metrics.NewGauge(
+		"juju_rpc_requests_in_flight",
+		"Number of RPC requests being served.",
+	)
+	requestsRejected = metrics.NewCounter(
+		"juju_rpc_requests_rejected_total",
+		"Number of RPC requests for unknown facades or methods, or with invalid parameters.",
+	)
+)
+
 // A Codec implements reading and writing of messages in an RPC
 // session.  The RPC code calls WriteMessage to write a message to the
 // connection and calls ReadHeader and ReadBody in pairs to read
@@ -367,6 +390,7 @@ func (conn *Conn) handleRequest(hdr *Header) error {
 	startTime := time.Now()
 	req, err := conn.bindRequest(hdr)
 	if err != nil {
+		requestsRejected.Inc()
 		if conn.notifier != nil {
 			conn.notifier.ServerRequest(hdr, nil)
 		}
@@ -393,6 +417,7 @@ func (conn *Conn) handleRequest(hdr *Header) error {
 		if err == io.EOF || err == io.ErrUnexpectedEOF {
 			return err
 		}
+		requestsRejected.Inc()
 		// An error reading the body often indicates bad
 		// request parameters rather than an issue with
 		// the connection itself, so we reply with an
@@ -482,7 +507,10 @@ func (conn *Conn) bindRequest(hdr *Header) (boundRequest, error) {
 // runRequest runs the given request and sends the reply.
 func (conn *Conn) runRequest(req boundRequest, arg reflect.Value, startTime time.Time) {
 	defer conn.srvPending.Done()
+	requestsInFlight.Inc()
 	rv, err := req.Call(req.hdr.Request.Id, arg)
+	requestsInFlight.Dec()
+	recordRequest(req.hdr.Request, err, time.Since(startTime))
 	if err != nil {
 		err = conn.writeErrorResponse(&req.hdr, req.transformErrors(err), startTime)
 	} else {
@@ -507,6 +535,18 @@ func (conn *Conn) runRequest(req boundRequest, arg reflect.Value, startTime time
 	}
 }
 
+// recordRequest records the outcome of a request in the RPC metrics.
+// Only requests that were bound to a method are recorded, so that
+// clients cannot create arbitrary label values.
+func recordRequest(req Request, err error, timeSpent time.Duration) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	requestsTotal.Inc(req.Type, req.Action, result)
+	requestDuration.Observe(timeSpent.Seconds(), req.Type, req.Action)
+}
+
 type serverError RequestError
 
 func (e *serverError) Error() string {
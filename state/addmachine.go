@@ -293,6 +293,9 @@ func (st *State) addMachineInsideMachineOps(template MachineTemplate, parentId s
 	if !parent.supportsContainerType(containerType) {
 		return nil, nil, fmt.Errorf("machine %s cannot host %s containers", parentId, containerType)
 	}
+	if parent.InMaintenance() {
+		return nil, nil, fmt.Errorf("machine %s is in maintenance mode", parentId)
+	}
 	newId, err := st.newContainerId(parentId, containerType)
 	if err != nil {
 		return nil, nil, err
@@ -302,6 +305,12 @@ func (st *State) addMachineInsideMachineOps(template MachineTemplate, parentId s
 	var ops []txn.Op
 	ops = append(ops, st.insertNewMachineOps(mdoc, template.Constraints)...)
 	ops = append(ops,
+		// Ensure the host machine has not entered maintenance mode.
+		txn.Op{
+			C:      st.machines.Name,
+			Id:     parentId,
+			Assert: notInMaintenanceDoc,
+		},
 		// Update containers record for host machine.
 		st.addChildToContainerRefOp(parentId, mdoc.Id),
 		// Create a containers reference document for the container itself.
@@ -409,7 +418,10 @@ func hasJob(jobs []MachineJob, job MachineJob) bool {
 	return false
 }
 
-var errStateServerNotAllowed = fmt.Errorf("state server jobs specified without calling EnsureAvailability")
+var (
+	errStateServerNotAllowed = fmt.Errorf("state server jobs specified without calling EnsureAvailability")
+	errHostedStateServer     = fmt.Errorf("hosted environments cannot have state servers")
+)
 
 // maintainStateServersOps returns a set of operations that will maintain
 // the state server information when the given machine documents
@@ -430,6 +442,9 @@ func (st *State) maintainStateServersOps(mdocs []*machineDoc, currentInfo *State
 	if len(newIds) == 0 {
 		return nil, nil
 	}
+	if st.IsHosted() {
+		return nil, errHostedStateServer
+	}
 	if currentInfo == nil {
 		// Allow bootstrap machine only.
 		if len(mdocs) != 1 || mdocs[0].Id != "0" {
@@ -473,6 +488,9 @@ func (st *State) maintainStateServersOps(mdocs []*machineDoc, currentInfo *State
 // Once a machine's voting status has been removed,
 // the machine itself may be removed.
 func (st *State) EnsureAvailability(numStateServers int, cons constraints.Value, series string) error {
+	if st.IsHosted() {
+		return errHostedStateServer
+	}
 	if numStateServers%2 != 1 || numStateServers <= 0 {
 		return fmt.Errorf("number of state servers must be odd and greater than zero")
 	}
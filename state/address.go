@@ -55,14 +55,15 @@ func instanceAddressesToAddresses(instanceAddrs []instance.Address) []address {
 }
 
 // stateServerAddresses returns the list of internal addresses of the state
-// server machines.
+// server machines. Hosted environments are served by the state server
+// machines of the environment hosting them.
 func (st *State) stateServerAddresses() ([]string, error) {
 	type addressMachine struct {
 		Addresses []address
 	}
 	var allAddresses []addressMachine
 	// TODO(rog) 2013/10/14 index machines on jobs.
-	err := st.machines.Find(D{{"jobs", JobManageEnviron}}).All(&allAddresses)
+	err := st.serverMachines.Find(D{{"jobs", JobManageEnviron}}).All(&allAddresses)
 	if err != nil {
 		return nil, err
 	}
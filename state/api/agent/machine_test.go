@@ -14,7 +14,9 @@ import (
 	"launchpad.net/juju-core/juju/testing"
 	"launchpad.net/juju-core/state"
 	"launchpad.net/juju-core/state/api"
+	"launchpad.net/juju-core/state/api/agent"
 	"launchpad.net/juju-core/state/api/params"
+	statetesting "launchpad.net/juju-core/state/testing"
 	coretesting "launchpad.net/juju-core/testing"
 	jc "launchpad.net/juju-core/testing/checkers"
 )
@@ -90,3 +92,33 @@ func tryOpenState(info *state.Info) error {
 	}
 	return err
 }
+
+func (s *machineSuite) TestMigrationTarget(c *gc.C) {
+	target, err := s.st.Agent().MigrationTarget("machine-42")
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+	c.Assert(target, gc.IsNil)
+
+	target, err = s.st.Agent().MigrationTarget(s.machine.Tag())
+	c.Assert(err, gc.IsNil)
+	c.Assert(target, gc.IsNil)
+
+	w, err := s.st.Agent().WatchMigrationTarget(s.machine.Tag())
+	c.Assert(err, gc.IsNil)
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewNotifyWatcherC(c, s.BackingState, w)
+	wc.AssertOneChange()
+
+	env, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	err = env.SetMigrationTarget([]string{"target.example:17070"}, "target-ca-cert")
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+
+	target, err = s.st.Agent().MigrationTarget(s.machine.Tag())
+	c.Assert(err, gc.IsNil)
+	c.Assert(target, gc.DeepEquals, &agent.MigrationTarget{
+		Addrs:      []string{"target.example:17070"},
+		CACert:     []byte("target-ca-cert"),
+		EnvironTag: env.Tag(),
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"launchpad.net/juju-core/instance"
 	"launchpad.net/juju-core/state/api/base"
 	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/state/api/watcher"
 )
 
 // State provides access to an agent's view of the state.
@@ -97,3 +98,62 @@ func (m *Entity) SetPassword(password string) error {
 	}
 	return results.OneError()
 }
+
+// MigrationTarget holds the details an agent needs to connect to the
+// state server its environment has been imported into.
+type MigrationTarget struct {
+	Addrs      []string
+	CACert     []byte
+	EnvironTag string
+}
+
+// MigrationTarget returns the state server the environment of the
+// agent with the given tag has been imported into, or nil if it has
+// not been imported.
+func (st *State) MigrationTarget(agentTag string) (*MigrationTarget, error) {
+	var results params.MigrationTargetResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: agentTag}},
+	}
+	err := st.caller.Call("Agent", "", "MigrationTarget", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected one result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if len(result.Addrs) == 0 {
+		return nil, nil
+	}
+	return &MigrationTarget{
+		Addrs:      result.Addrs,
+		CACert:     []byte(result.CACert),
+		EnvironTag: result.EnvironTag,
+	}, nil
+}
+
+// WatchMigrationTarget returns a watcher that notifies when the
+// migration target of the environment of the agent with the given tag
+// may have changed.
+func (st *State) WatchMigrationTarget(agentTag string) (watcher.NotifyWatcher, error) {
+	var results params.NotifyWatchResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: agentTag}},
+	}
+	err := st.caller.Call("Agent", "", "WatchMigrationTarget", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected one result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return watcher.NewNotifyWatcher(st.caller, result), nil
+}
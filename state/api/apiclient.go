@@ -13,6 +13,7 @@ import (
 	"launchpad.net/juju-core/cert"
 	"launchpad.net/juju-core/log"
 	"launchpad.net/juju-core/rpc"
+	"launchpad.net/juju-core/rpc/bsoncodec"
 	"launchpad.net/juju-core/rpc/jsoncodec"
 	"launchpad.net/juju-core/state/api/params"
 	"launchpad.net/juju-core/utils"
@@ -33,9 +34,11 @@ type State struct {
 	// broken.
 	broken chan struct{}
 
-	// tag and password hold the cached login credentials.
-	tag      string
-	password string
+	// tag and password hold the cached login credentials,
+	// and environTag the environment logged in to.
+	tag        string
+	password   string
+	environTag string
 	// serverRoot holds the cached API server address and port we used
 	// to login, with a https:// prefix.
 	serverRoot string
@@ -63,6 +66,10 @@ type Info struct {
 	// Nonce holds the nonce used when provisioning the machine. Used
 	// only by the machine agent.
 	Nonce string `yaml:",omitempty"`
+
+	// EnvironTag holds the tag of the environment to connect to.
+	// If it is empty, the server's environment is used.
+	EnvironTag string `yaml:",omitempty"`
 }
 
 // DialOpts holds configuration parameters that control the
@@ -75,6 +82,10 @@ type DialOpts struct {
 	// RetryDelay is the amount of time to wait between
 	// unsucssful connection attempts.
 	RetryDelay time.Duration
+
+	// BinaryCodec specifies that the connection should use the
+	// binary RPC codec if the state server supports it.
+	BinaryCodec bool
 }
 
 // DefaultDialOpts returns a DialOpts representing the default
@@ -104,6 +115,12 @@ func Open(info *Info, opts DialOpts) (*State, error) {
 		RootCAs:    pool,
 		ServerName: "anything",
 	}
+	if opts.BinaryCodec {
+		// Offering more than one protocol makes servers that
+		// know nothing of codec negotiation reject the
+		// connection, rather than echo an unknown protocol.
+		cfg.Protocol = []string{bsoncodec.Protocol, jsoncodec.Protocol}
+	}
 	var conn *websocket.Conn
 	openAttempt := utils.AttemptStrategy{
 		Total: opts.Timeout,
@@ -115,6 +132,13 @@ func Open(info *Info, opts DialOpts) (*State, error) {
 		if err == nil {
 			break
 		}
+		if cfg.Protocol != nil && isBadStatus(err) {
+			log.Infof("state/api: codec negotiation not supported by server, using JSON")
+			cfg.Protocol = nil
+			if conn, err = websocket.DialConfig(cfg); err == nil {
+				break
+			}
+		}
 		log.Errorf("state/api: %v", err)
 	}
 	if err != nil {
@@ -122,7 +146,13 @@ func Open(info *Info, opts DialOpts) (*State, error) {
 	}
 	log.Infof("state/api: connection established")
 
-	client := rpc.NewConn(jsoncodec.NewWebsocket(conn), nil)
+	var codec rpc.Codec
+	if len(cfg.Protocol) == 1 && cfg.Protocol[0] == bsoncodec.Protocol {
+		codec = bsoncodec.NewWebsocket(conn)
+	} else {
+		codec = jsoncodec.NewWebsocket(conn)
+	}
+	client := rpc.NewConn(codec, nil)
 	client.Start()
 	st := &State{
 		client:     client,
@@ -130,9 +160,10 @@ func Open(info *Info, opts DialOpts) (*State, error) {
 		serverRoot: "https://" + cfg.Location.Host,
 		tag:        info.Tag,
 		password:   info.Password,
+		environTag: info.EnvironTag,
 	}
 	if info.Tag != "" || info.Password != "" {
-		if err := st.Login(info.Tag, info.Password, info.Nonce); err != nil {
+		if err := st.login(info.Tag, info.Password, info.Nonce, info.EnvironTag); err != nil {
 			conn.Close()
 			return nil, err
 		}
@@ -142,6 +173,13 @@ func Open(info *Info, opts DialOpts) (*State, error) {
 	return st, nil
 }
 
+// isBadStatus reports whether err is the error returned when dialing
+// a websocket server that refuses the handshake.
+func isBadStatus(err error) bool {
+	dialErr, ok := err.(*websocket.DialError)
+	return ok && dialErr.Err == websocket.ErrBadStatus
+}
+
 func (s *State) heartbeatMonitor() {
 	for {
 		if err := s.Ping(); err != nil {
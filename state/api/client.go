@@ -38,6 +38,7 @@ type MachineStatus struct {
 	Id             string
 	Containers     map[string]MachineStatus
 	Hardware       string
+	Maintenance    bool
 }
 
 // ServiceStatus holds status info about a service.
@@ -114,6 +115,15 @@ func (c *Client) ServiceSet(service string, options map[string]string) error {
 	return c.st.Call("Client", "", "NewServiceSetForClientAPI", p, nil)
 }
 
+// ServicesSet sets configuration options on several services at once.
+// The results hold an error for each service, in order.
+func (c *Client) ServicesSet(services []params.ServiceSet) ([]params.ErrorResult, error) {
+	args := params.ServicesSet{Services: services}
+	var results params.ErrorResults
+	err := c.st.Call("Client", "", "ServicesSet", args, &results)
+	return results.Results, err
+}
+
 // ServiceUnset resets configuration options on a service.
 func (c *Client) ServiceUnset(service string, options []string) error {
 	p := params.ServiceUnset{
@@ -159,6 +169,102 @@ func (c *Client) ServiceGet(service string) (*params.ServiceGetResults, error) {
 	return &results, err
 }
 
+// ServiceConfigHistory returns the recorded revisions of the named
+// service's configuration, oldest first.
+func (c *Client) ServiceConfigHistory(service string) ([]params.ConfigRevision, error) {
+	var results params.ServiceConfigHistoryResults
+	params := params.ServiceConfigHistory{ServiceName: service}
+	err := c.st.Call("Client", "", "ServiceConfigHistory", params, &results)
+	return results.Revisions, err
+}
+
+// ServiceConfigDiff returns the changes made to the named service's
+// configuration between the given revisions. If from is zero, the
+// changes made by the "to" revision are returned.
+func (c *Client) ServiceConfigDiff(service string, from, to int) (params.ServiceConfigDiffResults, error) {
+	var results params.ServiceConfigDiffResults
+	params := params.ServiceConfigDiff{
+		ServiceName: service,
+		From:        from,
+		To:          to,
+	}
+	err := c.st.Call("Client", "", "ServiceConfigDiff", params, &results)
+	return results, err
+}
+
+// ServiceConfigRollback restores the configuration of the named
+// service to that recorded in the given revision.
+func (c *Client) ServiceConfigRollback(service string, revision int) error {
+	params := params.ServiceConfigRollback{
+		ServiceName: service,
+		Revision:    revision,
+	}
+	return c.st.Call("Client", "", "ServiceConfigRollback", params, nil)
+}
+
+// HookStats returns statistics on the hooks recently executed by the
+// units of the named services, or of all services if none are named.
+func (c *Client) HookStats(services ...string) ([]params.ServiceHookStats, error) {
+	var results params.HookStatsResults
+	params := params.HookStats{ServiceNames: services}
+	err := c.st.Call("Client", "", "HookStats", params, &results)
+	return results.Services, err
+}
+
+// ServiceMetrics returns the batches of metrics collected from the
+// units of the named service, oldest first.
+func (c *Client) ServiceMetrics(service string) ([]params.UnitMetricBatch, error) {
+	var results params.ServiceMetricsResults
+	params := params.ServiceMetrics{ServiceName: service}
+	err := c.st.Call("Client", "", "ServiceMetrics", params, &results)
+	return results.Batches, err
+}
+
+// EnvironmentExport returns a description of the complete
+// environment model, suitable for recreating the environment
+// on another state server.
+func (c *Client) EnvironmentExport() (*params.EnvironmentModel, error) {
+	var model params.EnvironmentModel
+	if err := c.st.Call("Client", "", "EnvironmentExport", nil, &model); err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+// ImportEnvironment recreates the environment described by the given
+// model as an environment hosted by the state server, and returns its
+// tag. The charms of the environment are copied from the storage of
+// the environment the model was exported from.
+func (c *Client) ImportEnvironment(model *params.EnvironmentModel) (string, error) {
+	args := params.ImportEnvironment{Model: *model}
+	var result params.ImportEnvironmentResult
+	if err := c.st.Call("Client", "", "ImportEnvironment", args, &result); err != nil {
+		return "", err
+	}
+	return result.EnvironTag, nil
+}
+
+// SetMigrationTarget records that the environment has been imported
+// into the state server with the given API addresses and CA
+// certificate, so that its agents point themselves at it.
+func (c *Client) SetMigrationTarget(addrs []string, caCert string) error {
+	args := params.SetMigrationTarget{
+		Addrs:  addrs,
+		CACert: caCert,
+	}
+	return c.st.Call("Client", "", "SetMigrationTarget", args, nil)
+}
+
+// RelationData returns the settings and scope of the units in the
+// relations identified by relation, which holds a relation id, a
+// service endpoint or a service name.
+func (c *Client) RelationData(relation string) ([]params.RelationDataResult, error) {
+	var results params.RelationDataResults
+	params := params.RelationData{Relation: relation}
+	err := c.st.Call("Client", "", "RelationData", params, &results)
+	return results.Relations, err
+}
+
 // AddRelation adds a relation between the specified endpoints and returns the relation info.
 func (c *Client) AddRelation(endpoints ...string) (*params.AddRelationResults, error) {
 	var addRelRes params.AddRelationResults
@@ -167,6 +273,16 @@ func (c *Client) AddRelation(endpoints ...string) (*params.AddRelationResults, e
 	return &addRelRes, err
 }
 
+// AddRelations adds several relations at once, each between the
+// endpoints given in its AddRelation. The results hold the endpoints
+// or an error for each relation, in order.
+func (c *Client) AddRelations(relations []params.AddRelation) ([]params.AddRelationsResult, error) {
+	args := params.AddRelations{Relations: relations}
+	var results params.AddRelationsResults
+	err := c.st.Call("Client", "", "AddRelations", args, &results)
+	return results.Results, err
+}
+
 // DestroyRelation removes the relation between the specified endpoints.
 func (c *Client) DestroyRelation(endpoints ...string) error {
 	params := params.DestroyRelation{Endpoints: endpoints}
@@ -213,6 +329,14 @@ func (c *Client) ForceDestroyMachines(machines ...string) error {
 	return c.st.Call("Client", "", "DestroyMachines", params, nil)
 }
 
+// MachineSetMaintenance puts a machine into maintenance mode, so that
+// no new units or containers are placed on it, or takes it out of
+// maintenance mode.
+func (c *Client) MachineSetMaintenance(machineId string, maintenance bool) error {
+	params := params.MachineSetMaintenance{MachineId: machineId, Maintenance: maintenance}
+	return c.st.Call("Client", "", "MachineSetMaintenance", params, nil)
+}
+
 // ServiceExpose changes the juju-managed firewall to expose any ports that
 // were also explicitly marked by units as open.
 func (c *Client) ServiceExpose(service string) error {
@@ -227,6 +351,17 @@ func (c *Client) ServiceUnexpose(service string) error {
 	return c.st.Call("Client", "", "ServiceUnexpose", params, nil)
 }
 
+// ServiceSetHookTimeout sets the maximum time any hook run by the
+// service's units may take. A zero timeout restores the timeouts given
+// in the charm metadata.
+func (c *Client) ServiceSetHookTimeout(service string, timeout time.Duration) error {
+	params := params.ServiceSetHookTimeout{
+		ServiceName: service,
+		Timeout:     timeout,
+	}
+	return c.st.Call("Client", "", "ServiceSetHookTimeout", params, nil)
+}
+
 // ServiceDeploy obtains the charm, either locally or from the charm store,
 // and deploys it.
 func (c *Client) ServiceDeploy(charmUrl string, serviceName string, numUnits int, configYAML string, cons constraints.Value, toMachineSpec string) error {
@@ -241,6 +376,16 @@ func (c *Client) ServiceDeploy(charmUrl string, serviceName string, numUnits int
 	return c.st.Call("Client", "", "ServiceDeploy", params, nil)
 }
 
+// ServicesDeploy deploys several services at once. The charms must
+// already have been added. The results hold an error for each
+// service, in order.
+func (c *Client) ServicesDeploy(services []params.ServiceDeploy) ([]params.ErrorResult, error) {
+	args := params.ServicesDeploy{Services: services}
+	var results params.ErrorResults
+	err := c.st.Call("Client", "", "ServicesDeploy", args, &results)
+	return results.Results, err
+}
+
 // ServiceUpdate updates the service attributes, including charm URL,
 // minimum number of units, settings and constraints.
 // TODO(frankban) deprecate redundant API calls that this supercedes.
@@ -282,6 +427,18 @@ func (c *Client) AddServiceUnits(service string, numUnits int, machineSpec strin
 	return results.Units, err
 }
 
+// MigrateUnit starts moving the named unit to the machine given by
+// machineSpec, and returns the name of the unit that replaces it.
+func (c *Client) MigrateUnit(unitName, machineSpec string) (string, error) {
+	args := params.MigrateUnit{
+		UnitName:      unitName,
+		ToMachineSpec: machineSpec,
+	}
+	var result params.MigrateUnitResult
+	err := c.st.Call("Client", "", "MigrateUnit", args, &result)
+	return result.Unit, err
+}
+
 // DestroyServiceUnits decreases the number of units dedicated to a service.
 func (c *Client) DestroyServiceUnits(unitNames ...string) error {
 	params := params.DestroyServiceUnits{unitNames}
@@ -319,6 +476,16 @@ func (c *Client) SetServiceConstraints(service string, constraints constraints.V
 	return c.st.Call("Client", "", "SetServiceConstraints", params, nil)
 }
 
+// SetServicesConstraints specifies the constraints for several
+// services at once. The results hold an error for each service,
+// in order.
+func (c *Client) SetServicesConstraints(services []params.SetConstraints) ([]params.ErrorResult, error) {
+	args := params.SetServicesConstraints{Services: services}
+	var results params.ErrorResults
+	err := c.st.Call("Client", "", "SetServicesConstraints", args, &results)
+	return results.Results, err
+}
+
 // SetEnvironmentConstraints specifies the constraints for the environment.
 func (c *Client) SetEnvironmentConstraints(constraints constraints.Value) error {
 	params := params.SetConstraints{
@@ -375,6 +542,18 @@ func (c *Client) WatchAll() (*AllWatcher, error) {
 	return newAllWatcher(c, &info.AllWatcherId), nil
 }
 
+// WatchAllFiltered is like WatchAll, but the returned AllWatcher
+// only sends Deltas for entities that match the given filter,
+// including in its first collection of Deltas. Older API servers
+// ignore the filter, and send Deltas for all entities.
+func (c *Client) WatchAllFiltered(filter params.WatchAllFilter) (*AllWatcher, error) {
+	info := new(WatchAll)
+	if err := c.st.Call("Client", "", "WatchAll", filter, info); err != nil {
+		return nil, err
+	}
+	return newAllWatcher(c, &info.AllWatcherId), nil
+}
+
 // GetAnnotations returns annotations that have been set on the given entity.
 func (c *Client) GetAnnotations(tag string) (map[string]string, error) {
 	args := params.GetAnnotations{tag}
@@ -412,6 +591,13 @@ func (c *Client) EnvironmentSet(config map[string]interface{}) error {
 	return c.st.Call("Client", "", "EnvironmentSet", args, nil)
 }
 
+// WebhookTest sends a test event from the state server
+// to the named webhook.
+func (c *Client) WebhookTest(name string) error {
+	args := params.WebhookTest{Name: name}
+	return c.st.Call("Client", "", "WebhookTest", args, nil)
+}
+
 // SetEnvironAgentVersion sets the environment agent-version setting
 // to the given value.
 func (c *Client) SetEnvironAgentVersion(version version.Number) error {
@@ -453,11 +639,29 @@ func (c *Client) Run(run params.RunParams) ([]params.RunResult, error) {
 // DestroyEnvironment puts the environment into a "dying" state,
 // and removes all non-manager machine instances. DestroyEnvironment
 // will fail if there are any manually-provisioned non-manager machines
-// in state.
+// in state. Hosted environments are removed altogether.
 func (c *Client) DestroyEnvironment() error {
 	return c.st.Call("Client", "", "DestroyEnvironment", nil, nil)
 }
 
+// CreateEnvironment creates an environment with the given name, hosted
+// by the state server of the environment, and returns its tag. The new
+// environment's configuration is that of the state server's environment
+// changed by the given attributes, and its admin user has the given
+// password.
+func (c *Client) CreateEnvironment(name string, config map[string]interface{}, adminPassword string) (string, error) {
+	args := params.CreateEnvironment{
+		Name:          name,
+		Config:        config,
+		AdminPassword: adminPassword,
+	}
+	var result params.CreateEnvironmentResult
+	if err := c.st.Call("Client", "", "CreateEnvironment", args, &result); err != nil {
+		return "", err
+	}
+	return result.EnvironTag, nil
+}
+
 // AddLocalCharm prepares the given charm with a local: schema in its
 // URL, and uploads it via the API server, returning the assigned
 // charm URL. If the API server does not support charm uploads, an
@@ -494,6 +698,9 @@ func (c *Client) AddLocalCharm(curl *charm.URL, ch charm.Charm) (*charm.URL, err
 
 	// Prepare the upload request.
 	url := fmt.Sprintf("%s/charms?series=%s", c.st.serverRoot, curl.Series)
+	if c.st.environTag != "" {
+		url += "&environ=" + c.st.environTag
+	}
 	req, err := http.NewRequest("POST", url, archive)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create upload request: %v", err)
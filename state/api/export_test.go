@@ -8,3 +8,9 @@ package api
 func SetServerRoot(c *Client, root string) {
 	c.st.serverRoot = root
 }
+
+// CodecProtocol returns the websocket subprotocol negotiated for the
+// given connection, which names the RPC codec it uses.
+func CodecProtocol(st *State) []string {
+	return st.conn.Config().Protocol
+}
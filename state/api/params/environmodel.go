@@ -0,0 +1,122 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package params
+
+// EnvironmentModelVersion holds the version of the environment model
+// format written by the EnvironmentExport call. It is incremented
+// whenever the format changes incompatibly.
+const EnvironmentModelVersion = 1
+
+// EnvironmentModel describes the complete model of an environment, as
+// recorded by its state server, so that it can be recreated on another
+// state server. The format is described in doc/environment-export.txt.
+type EnvironmentModel struct {
+	// Version holds the version of the format; see
+	// EnvironmentModelVersion.
+	Version int `json:"version" yaml:"version"`
+
+	UUID        string                 `json:"uuid" yaml:"uuid"`
+	Name        string                 `json:"name" yaml:"name"`
+	Config      map[string]interface{} `json:"config" yaml:"config"`
+	Constraints string                 `json:"constraints,omitempty" yaml:"constraints,omitempty"`
+	Annotations map[string]string      `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+
+	Users     []UserModel     `json:"users" yaml:"users"`
+	Machines  []MachineModel  `json:"machines" yaml:"machines"`
+	Charms    []CharmModel    `json:"charms" yaml:"charms"`
+	Services  []ServiceModel  `json:"services" yaml:"services"`
+	Relations []RelationModel `json:"relations" yaml:"relations"`
+}
+
+// UserModel describes a user of the environment.
+type UserModel struct {
+	Name         string `json:"name" yaml:"name"`
+	PasswordHash string `json:"password-hash" yaml:"password-hash"`
+	PasswordSalt string `json:"password-salt" yaml:"password-salt"`
+}
+
+// MachineModel describes a machine. Containers are described as
+// machines in their own right, identified by their nested ids.
+type MachineModel struct {
+	Id          string            `json:"id" yaml:"id"`
+	Life        string            `json:"life" yaml:"life"`
+	Series      string            `json:"series" yaml:"series"`
+	Jobs        []string          `json:"jobs" yaml:"jobs"`
+	Constraints string            `json:"constraints,omitempty" yaml:"constraints,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	Maintenance bool              `json:"maintenance,omitempty" yaml:"maintenance,omitempty"`
+
+	// InstanceId, Nonce and Hardware are empty
+	// if the machine has not been provisioned.
+	InstanceId string   `json:"instance-id,omitempty" yaml:"instance-id,omitempty"`
+	Nonce      string   `json:"nonce,omitempty" yaml:"nonce,omitempty"`
+	Hardware   string   `json:"hardware,omitempty" yaml:"hardware,omitempty"`
+	Addresses  []string `json:"addresses,omitempty" yaml:"addresses,omitempty"`
+
+	// PasswordHash holds the hash of the machine agent's password,
+	// so that the agent can log in to a state server the
+	// environment is imported into.
+	PasswordHash string `json:"password-hash,omitempty" yaml:"password-hash,omitempty"`
+}
+
+// CharmModel describes a charm stored in the environment.
+type CharmModel struct {
+	URL          string `json:"url" yaml:"url"`
+	BundleURL    string `json:"bundle-url,omitempty" yaml:"bundle-url,omitempty"`
+	BundleSha256 string `json:"bundle-sha256,omitempty" yaml:"bundle-sha256,omitempty"`
+}
+
+// ServiceModel describes a service and its units.
+type ServiceModel struct {
+	Name        string                 `json:"name" yaml:"name"`
+	Life        string                 `json:"life" yaml:"life"`
+	Charm       string                 `json:"charm" yaml:"charm"`
+	Owner       string                 `json:"owner,omitempty" yaml:"owner,omitempty"`
+	Exposed     bool                   `json:"exposed" yaml:"exposed"`
+	MinUnits    int                    `json:"min-units,omitempty" yaml:"min-units,omitempty"`
+	Settings    map[string]interface{} `json:"settings,omitempty" yaml:"settings,omitempty"`
+	Constraints string                 `json:"constraints,omitempty" yaml:"constraints,omitempty"`
+	Annotations map[string]string      `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	Units       []UnitModel            `json:"units" yaml:"units"`
+}
+
+// UnitModel describes a unit of a service.
+type UnitModel struct {
+	Name        string            `json:"name" yaml:"name"`
+	Life        string            `json:"life" yaml:"life"`
+	Machine     string            `json:"machine,omitempty" yaml:"machine,omitempty"`
+	Principal   string            `json:"principal,omitempty" yaml:"principal,omitempty"`
+	Charm       string            `json:"charm,omitempty" yaml:"charm,omitempty"`
+	Ports       []string          `json:"ports,omitempty" yaml:"ports,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+
+	// PasswordHash holds the hash of the unit agent's password.
+	PasswordHash string `json:"password-hash,omitempty" yaml:"password-hash,omitempty"`
+}
+
+// RelationModel describes a relation and the settings
+// of the units that have joined it.
+type RelationModel struct {
+	Id        int                 `json:"id" yaml:"id"`
+	Key       string              `json:"key" yaml:"key"`
+	Life      string              `json:"life" yaml:"life"`
+	Endpoints []EndpointModel     `json:"endpoints" yaml:"endpoints"`
+	Units     []RelationUnitModel `json:"units,omitempty" yaml:"units,omitempty"`
+}
+
+// EndpointModel describes one endpoint of a relation.
+type EndpointModel struct {
+	Service   string `json:"service" yaml:"service"`
+	Name      string `json:"name" yaml:"name"`
+	Role      string `json:"role" yaml:"role"`
+	Interface string `json:"interface" yaml:"interface"`
+	Scope     string `json:"scope" yaml:"scope"`
+}
+
+// RelationUnitModel describes the settings of a unit in a relation.
+type RelationUnitModel struct {
+	Unit     string                 `json:"unit" yaml:"unit"`
+	InScope  bool                   `json:"in-scope" yaml:"in-scope"`
+	Settings map[string]interface{} `json:"settings" yaml:"settings"`
+}
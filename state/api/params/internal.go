@@ -145,6 +145,18 @@ type StringBoolResults struct {
 	Results []StringBoolResult
 }
 
+// DurationResult holds the result of an API call that returns a
+// time.Duration.
+type DurationResult struct {
+	Error  *Error
+	Result time.Duration
+}
+
+// DurationResults holds multiple results with a time.Duration each.
+type DurationResults struct {
+	Results []DurationResult
+}
+
 // BoolResult holds the result of an API call that returns a
 // a boolean or an error.
 type BoolResult struct {
@@ -240,6 +252,34 @@ type RelationUnitsSettings struct {
 	RelationUnits []RelationUnitSettings
 }
 
+// UnitMigrationSettings holds a unit tag and the changes to make to
+// its migration settings.
+type UnitMigrationSettings struct {
+	Unit     string
+	Settings RelationSettings
+}
+
+// UnitsMigrationSettings holds the arguments for making an
+// UpdateMigrationSettings API call.
+type UnitsMigrationSettings struct {
+	Units []UnitMigrationSettings
+}
+
+// MigrationStatusResult holds the migration status of a unit, or an
+// error. MigratingTo and MigratedFrom hold unit names.
+type MigrationStatusResult struct {
+	Error        *Error
+	MigratingTo  string
+	MigratedFrom string
+	Ready        bool
+}
+
+// MigrationStatusResults holds the bulk operation result of an API
+// call that returns the migration status of units.
+type MigrationStatusResults struct {
+	Results []MigrationStatusResult
+}
+
 // RelationResult returns information about a single relation,
 // or an error.
 type RelationResult struct {
@@ -269,6 +309,63 @@ type EntitiesPorts struct {
 	Entities []EntityPort
 }
 
+// HookExecution describes a single execution of a hook by a unit
+// agent. Finished is zero if the hook is still running. Skipped is
+// true if the charm does not define the hook, in which case the hook
+// is no longer running but no execution is recorded. Queued holds the
+// number of hooks the unit had queued to run when the execution was
+// reported.
+type HookExecution struct {
+	Hook     string
+	Started  time.Time
+	Finished time.Time
+	ExitCode int
+	Error    string
+	Skipped  bool
+	Queued   int
+}
+
+// EntityHookExecution holds an entity's tag and a hook execution.
+type EntityHookExecution struct {
+	Tag       string
+	Execution HookExecution
+}
+
+// EntitiesHookExecutions holds the parameters for making a
+// RecordHookExecutions API call.
+type EntitiesHookExecutions struct {
+	Entities []EntityHookExecution
+}
+
+// Metric holds a single value of a metric declared by a charm.
+type Metric struct {
+	Key   string
+	Value string
+	Time  time.Time
+}
+
+// MetricBatch holds the metrics collected by a single run of a
+// unit's collect-metrics hook. UUID identifies the batch, so that
+// a batch sent more than once is only recorded once.
+type MetricBatch struct {
+	UUID     string
+	CharmURL string
+	Created  time.Time
+	Metrics  []Metric
+}
+
+// MetricBatchParam holds an entity's tag and a metric batch.
+type MetricBatchParam struct {
+	Tag   string
+	Batch MetricBatch
+}
+
+// MetricBatchParams holds the parameters for making an
+// AddMetricBatches API call.
+type MetricBatchParams struct {
+	Batches []MetricBatchParam
+}
+
 // EntityCharmURL holds an entity's tag and a charm URL.
 type EntityCharmURL struct {
 	Tag      string
@@ -389,6 +486,23 @@ type AgentGetEntitiesResult struct {
 	Error         *Error
 }
 
+// MigrationTargetResults holds the results of an
+// agent.API.MigrationTarget call.
+type MigrationTargetResults struct {
+	Results []MigrationTargetResult
+}
+
+// MigrationTargetResult holds the API addresses and CA certificate
+// of the state server an agent's environment has been imported into,
+// and the tag the agent names the environment by there. Addrs is
+// empty if the environment has not been imported.
+type MigrationTargetResult struct {
+	Addrs      []string
+	CACert     string
+	EnvironTag string
+	Error      *Error
+}
+
 // VersionResult holds the version and possibly error for a given
 // DesiredVersion() API call.
 type VersionResult struct {
@@ -7,6 +7,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"time"
+
+	"labix.org/v2/mgo/bson"
 
 	"launchpad.net/juju-core/charm"
 	"launchpad.net/juju-core/constraints"
@@ -56,12 +59,68 @@ type AddRelationResults struct {
 	Endpoints map[string]charm.Relation
 }
 
+// AddRelations holds the parameters for making the AddRelations call.
+type AddRelations struct {
+	Relations []AddRelation
+}
+
+// AddRelationsResult holds the result of adding a single relation
+// in an AddRelations call.
+type AddRelationsResult struct {
+	Endpoints map[string]charm.Relation
+	Error     *Error
+}
+
+// AddRelationsResults holds the results of an AddRelations call. The
+// order and number of elements matches the relations in the request.
+type AddRelationsResults struct {
+	Results []AddRelationsResult
+}
+
 // DestroyRelation holds the parameters for making the DestroyRelation call.
 // The endpoints specified are unordered.
 type DestroyRelation struct {
 	Endpoints []string
 }
 
+// RelationData holds the parameters for making the RelationData call.
+// Relation identifies the relations to report on: a relation id, either
+// alone or prefixed by an endpoint name as in "db:2"; a service endpoint,
+// as in "mysql:db"; or a service name, for all its relations.
+type RelationData struct {
+	Relation string
+}
+
+// RelationUnitData describes the settings and scope of a single unit
+// within a relation.
+type RelationUnitData struct {
+	UnitName string
+	InScope  bool
+	Settings map[string]interface{}
+	Version  int64
+}
+
+// RelationEndpointData holds the data of the units on one side of
+// a relation.
+type RelationEndpointData struct {
+	ServiceName string
+	Endpoint    string
+	Role        string
+	Units       []RelationUnitData
+}
+
+// RelationDataResult holds the data of the units in a single relation.
+type RelationDataResult struct {
+	RelationId int
+	Key        string
+	Endpoints  []RelationEndpointData
+}
+
+// RelationDataResults holds the results of a RelationData call.
+type RelationDataResults struct {
+	Relations []RelationDataResult
+}
+
 // AddMachineParams encapsulates the parameters used to create a new machine.
 type AddMachineParams struct {
 	// The following fields hold attributes that will be given to the
@@ -118,6 +177,13 @@ type DestroyMachines struct {
 	Force        bool
 }
 
+// MachineSetMaintenance holds parameters for the
+// MachineSetMaintenance call.
+type MachineSetMaintenance struct {
+	MachineId   string
+	Maintenance bool
+}
+
 // ServiceDeploy holds the parameters for making the ServiceDeploy call.
 type ServiceDeploy struct {
 	ServiceName   string
@@ -129,6 +195,11 @@ type ServiceDeploy struct {
 	ToMachineSpec string
 }
 
+// ServicesDeploy holds the parameters for making the ServicesDeploy call.
+type ServicesDeploy struct {
+	Services []ServiceDeploy
+}
+
 // ServiceUpdate holds the parameters for making the ServiceUpdate call.
 type ServiceUpdate struct {
 	ServiceName     string
@@ -159,6 +230,11 @@ type ServiceSet struct {
 	Options     map[string]string
 }
 
+// ServicesSet holds the parameters for making the ServicesSet call.
+type ServicesSet struct {
+	Services []ServiceSet
+}
+
 // ServiceSetYAML holds the parameters for
 // a ServiceSetYAML command. Config contains the
 // configuration data in YAML format.
@@ -204,6 +280,13 @@ type ServiceUnexpose struct {
 	ServiceName string
 }
 
+// ServiceSetHookTimeout holds parameters for the ServiceSetHookTimeout
+// call. A zero Timeout restores the timeouts given in the charm metadata.
+type ServiceSetHookTimeout struct {
+	ServiceName string
+	Timeout     time.Duration
+}
+
 // PublicAddress holds parameters for the PublicAddress call.
 type PublicAddress struct {
 	Target string
@@ -214,6 +297,135 @@ type PublicAddressResults struct {
 	PublicAddress string
 }
 
+// ServiceConfigHistory holds parameters for the
+// ServiceConfigHistory call.
+type ServiceConfigHistory struct {
+	ServiceName string
+}
+
+// ConfigRevision describes a single revision of a service's
+// charm config settings. The values of secret options are redacted.
+type ConfigRevision struct {
+	Revision int
+	CharmURL string
+	Author   string
+	Time     time.Time
+	Settings map[string]interface{}
+}
+
+// ServiceConfigHistoryResults holds results of the
+// ServiceConfigHistory call.
+type ServiceConfigHistoryResults struct {
+	Revisions []ConfigRevision
+}
+
+// ServiceConfigDiff holds parameters for the ServiceConfigDiff
+// call. If From is zero, the revision before To is used.
+type ServiceConfigDiff struct {
+	ServiceName string
+	From        int
+	To          int
+}
+
+// ConfigChange describes how the value of a single option differs
+// between two revisions of a service's charm config settings. Old or
+// New is nil where the option is unset. The values of secret options
+// are redacted.
+type ConfigChange struct {
+	Old interface{} `json:",omitempty"`
+	New interface{} `json:",omitempty"`
+}
+
+// ServiceConfigDiffResults holds results of the ServiceConfigDiff
+// call. From holds the revision the changes were taken from, which is
+// zero if they are relative to no settings at all.
+type ServiceConfigDiffResults struct {
+	From    int
+	Changes map[string]ConfigChange
+}
+
+// ServiceConfigRollback holds parameters for the
+// ServiceConfigRollback call.
+type ServiceConfigRollback struct {
+	ServiceName string
+	Revision    int
+}
+
+// HookStats holds parameters for the HookStats call.
+type HookStats struct {
+	// ServiceNames holds the services to report on. If it is
+	// empty, all services are reported.
+	ServiceNames []string
+}
+
+// HookTiming summarizes the recorded executions of a single hook
+// by the units of a service.
+type HookTiming struct {
+	Hook         string
+	Runs         int
+	Failures     int
+	MeanDuration time.Duration
+	MaxDuration  time.Duration
+}
+
+// UnitHookExecution describes the execution of a hook by a unit.
+// Duration is zero if the hook is still running.
+type UnitHookExecution struct {
+	UnitName string
+	Hook     string
+	Started  time.Time
+	Duration time.Duration
+	ExitCode int
+	Error    string
+}
+
+// ServiceHookStats holds the hook statistics of a single service.
+type ServiceHookStats struct {
+	ServiceName string
+
+	// UnitsRunning holds the number of the service's units
+	// currently executing a hook.
+	UnitsRunning int
+
+	// QueueDepth holds the number of hooks the service's units
+	// have queued to run, as last reported by each unit.
+	QueueDepth int
+
+	// Running holds the hooks currently executing.
+	Running []UnitHookExecution
+
+	// Hooks summarizes the recorded executions of each hook,
+	// ordered by hook name.
+	Hooks []HookTiming
+
+	// Slowest holds the longest recorded hook executions,
+	// slowest first.
+	Slowest []UnitHookExecution
+}
+
+// HookStatsResults holds results of the HookStats call.
+type HookStatsResults struct {
+	Services []ServiceHookStats
+}
+
+// ServiceMetrics holds parameters for the ServiceMetrics call.
+type ServiceMetrics struct {
+	ServiceName string
+}
+
+// UnitMetricBatch holds a batch of metrics collected from a unit.
+type UnitMetricBatch struct {
+	UnitName string
+	CharmURL string
+	Created  time.Time
+	Metrics  []Metric
+}
+
+// ServiceMetricsResults holds results of the ServiceMetrics call.
+type ServiceMetricsResults struct {
+	Batches []UnitMetricBatch
+}
+
 // Resolved holds parameters for the Resolved call.
 type Resolved struct {
 	UnitName string
@@ -240,6 +452,18 @@ type AddServiceUnits struct {
 	ToMachineSpec string
 }
 
+// MigrateUnit holds parameters for the MigrateUnit call.
+type MigrateUnit struct {
+	UnitName      string
+	ToMachineSpec string
+}
+
+// MigrateUnitResult holds the name of the unit that replaces
+// the migrated unit.
+type MigrateUnitResult struct {
+	Unit string
+}
+
 // DestroyServiceUnits holds parameters for the DestroyUnits call.
 type DestroyServiceUnits struct {
 	UnitNames []string
@@ -255,6 +479,10 @@ type Creds struct {
 	AuthTag  string
 	Password string
 	Nonce    string
+
+	// EnvironTag, if set, names the environment the client
+	// expects the server to be serving.
+	EnvironTag string `json:",omitempty"`
 }
 
 // GetAnnotationsResults holds annotations associated with an entity.
@@ -289,6 +517,12 @@ type SetConstraints struct {
 	Constraints constraints.Value
 }
 
+// SetServicesConstraints holds the parameters for making the
+// SetServicesConstraints call.
+type SetServicesConstraints struct {
+	Services []SetConstraints
+}
+
 // CharmInfo stores parameters for a CharmInfo call.
 type CharmInfo struct {
 	CharmURL string
@@ -299,6 +533,31 @@ type AllWatcherId struct {
 	AllWatcherId string
 }
 
+// WatchAllFilter restricts the deltas sent by an AllWatcher.
+// An entity is sent only if its kind is in Kinds and, if any of
+// Services, Machines or Tags are specified, it relates to one
+// of the given services or machines, or has one of the given
+// tags. Empty fields impose no restriction, so the zero
+// WatchAllFilter matches everything.
+type WatchAllFilter struct {
+	// Kinds holds entity kinds, as returned by EntityId,
+	// such as "machine" or "unit".
+	Kinds []string
+
+	// Services holds service names. Units and relations
+	// of those services, and annotations on the services
+	// and units, are also sent.
+	Services []string
+
+	// Machines holds machine ids. Units assigned to those
+	// machines, and annotations on the machines, are also
+	// sent.
+	Machines []string
+
+	// Tags holds the tags of individual entities.
+	Tags []string
+}
+
 // AllWatcherNextResults holds deltas returned from calling AllWatcher.Next().
 type AllWatcherNextResults struct {
 	Deltas []Delta
@@ -366,24 +625,67 @@ func (d *Delta) UnmarshalJSON(data []byte) error {
 	} else if operation != "change" {
 		return fmt.Errorf("Unexpected operation %q", operation)
 	}
-	switch entityKind {
+	entity, err := newEntityInfo(entityKind)
+	if err != nil {
+		return err
+	}
+	d.Entity = entity
+	if err := json.Unmarshal(elements[2], &d.Entity); err != nil {
+		return err
+	}
+	return nil
+}
+
+// deltaDoc holds the BSON representation of a Delta.
+type deltaDoc struct {
+	Kind    string
+	Removed bool
+	Entity  bson.Raw
+}
+
+// GetBSON implements bson.Getter, so that deltas can be sent with
+// the binary API codec.
+func (d Delta) GetBSON() (interface{}, error) {
+	return bson.D{
+		{"kind", d.Entity.EntityId().Kind},
+		{"removed", d.Removed},
+		{"entity", d.Entity},
+	}, nil
+}
+
+// SetBSON implements bson.Setter.
+func (d *Delta) SetBSON(raw bson.Raw) error {
+	var doc deltaDoc
+	if err := raw.Unmarshal(&doc); err != nil {
+		return err
+	}
+	entity, err := newEntityInfo(doc.Kind)
+	if err != nil {
+		return err
+	}
+	if err := doc.Entity.Unmarshal(entity); err != nil {
+		return err
+	}
+	d.Removed = doc.Removed
+	d.Entity = entity
+	return nil
+}
+
+// newEntityInfo returns a new, empty EntityInfo of the given kind.
+func newEntityInfo(kind string) (EntityInfo, error) {
+	switch kind {
 	case "machine":
-		d.Entity = new(MachineInfo)
+		return new(MachineInfo), nil
 	case "service":
-		d.Entity = new(ServiceInfo)
+		return new(ServiceInfo), nil
 	case "unit":
-		d.Entity = new(UnitInfo)
+		return new(UnitInfo), nil
 	case "relation":
-		d.Entity = new(RelationInfo)
+		return new(RelationInfo), nil
 	case "annotation":
-		d.Entity = new(AnnotationInfo)
-	default:
-		return fmt.Errorf("Unexpected entity name %q", entityKind)
-	}
-	if err := json.Unmarshal(elements[2], &d.Entity); err != nil {
-		return err
+		return new(AnnotationInfo), nil
 	}
-	return nil
+	return nil, fmt.Errorf("Unexpected entity name %q", kind)
 }
 
 // EntityInfo is implemented by all entity Info types.
@@ -515,6 +817,7 @@ type ContainerConfig struct {
 	SSLHostnameVerification bool
 	Proxy                   osenv.ProxySettings
 	AptProxy                osenv.ProxySettings
+	AptMirror               string
 }
 
 // ProvisioningScriptParams contains the parameters for the
@@ -550,6 +853,53 @@ type EnvironmentSet struct {
 	Config map[string]interface{}
 }
 
+// CreateEnvironment holds the arguments for the CreateEnvironment
+// client API call.
+type CreateEnvironment struct {
+	// Name holds the name of the new environment.
+	Name string
+	// Config holds the attributes that the new environment's
+	// configuration changes from the state server's environment.
+	Config map[string]interface{}
+	// AdminPassword holds the password of the new
+	// environment's admin user.
+	AdminPassword string
+}
+
+// CreateEnvironmentResult holds the result of the CreateEnvironment
+// client API call.
+type CreateEnvironmentResult struct {
+	EnvironTag string
+}
+
+// ImportEnvironment holds the arguments for the ImportEnvironment
+// client API call.
+type ImportEnvironment struct {
+	// Model holds the environment model, as returned
+	// by the EnvironmentExport call.
+	Model EnvironmentModel
+}
+
+// ImportEnvironmentResult holds the result of the ImportEnvironment
+// client API call.
+type ImportEnvironmentResult struct {
+	EnvironTag string
+}
+
+// SetMigrationTarget holds the arguments for the SetMigrationTarget
+// client API call.
+type SetMigrationTarget struct {
+	// Addrs and CACert hold the API addresses and CA certificate
+	// of the state server the environment has been imported into.
+	Addrs  []string
+	CACert string
+}
+
+// WebhookTest holds the arguments for the WebhookTest call.
+type WebhookTest struct {
+	Name string
+}
+
 // SetEnvironAgentVersion contains the arguments for
 // SetEnvironAgentVersion client API call.
 type SetEnvironAgentVersion struct {
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"testing"
 
+	"labix.org/v2/mgo/bson"
 	gc "launchpad.net/gocheck"
 
 	"launchpad.net/juju-core/charm"
@@ -144,6 +145,38 @@ func (s *MarshalSuite) TestDeltaUnmarshalJSON(c *gc.C) {
 	}
 }
 
+func (s *MarshalSuite) TestDeltaBSONRoundTrip(c *gc.C) {
+	for i, t := range marshalTestCases {
+		c.Logf("test %d. %s", i, t.about)
+		data, err := bson.Marshal(params.AllWatcherNextResults{
+			Deltas: []params.Delta{t.value},
+		})
+		c.Assert(err, gc.IsNil)
+		var unmarshalled params.AllWatcherNextResults
+		err = bson.Unmarshal(data, &unmarshalled)
+		c.Assert(err, gc.IsNil)
+		c.Assert(unmarshalled.Deltas, gc.HasLen, 1)
+		delta := unmarshalled.Deltas[0]
+		c.Check(delta.Removed, gc.Equals, t.value.Removed)
+		c.Check(delta.Entity.EntityId(), gc.Equals, t.value.Entity.EntityId())
+		// Nil maps and slices come back empty, so compare the
+		// encoded forms rather than the values themselves.
+		remarshalled, err := bson.Marshal(unmarshalled)
+		c.Assert(err, gc.IsNil)
+		var expected, obtained bson.M
+		c.Assert(bson.Unmarshal(data, &expected), gc.IsNil)
+		c.Assert(bson.Unmarshal(remarshalled, &obtained), gc.IsNil)
+		c.Check(obtained, gc.DeepEquals, expected)
+	}
+}
+
+func (s *MarshalSuite) TestDeltaUnmarshalBSONUnknownEntity(c *gc.C) {
+	data, err := bson.Marshal(bson.M{"kind": "qwan", "entity": bson.M{}})
+	c.Assert(err, gc.IsNil)
+	err = bson.Unmarshal(data, new(params.Delta))
+	c.Check(err, gc.ErrorMatches, `Unexpected entity name "qwan"`)
+}
+
 func (s *MarshalSuite) TestDeltaMarshalJSONCardinality(c *gc.C) {
 	err := json.Unmarshal([]byte(`[1,2]`), new(params.Delta))
 	c.Check(err, gc.ErrorMatches, "Expected 3 elements in top-level of JSON but got 2")
@@ -94,6 +94,19 @@ func (st *State) CACert() ([]byte, error) {
 	return result.Result, nil
 }
 
+// EnvironUUID returns the UUID of the environment.
+func (st *State) EnvironUUID() (string, error) {
+	var result params.StringResult
+	err := st.caller.Call(provisioner, "", "EnvironUUID", nil, &result)
+	if err != nil {
+		return "", err
+	}
+	if err := result.Error; err != nil {
+		return "", err
+	}
+	return result.Result, nil
+}
+
 // Tools returns the agent tools for the given entity.
 func (st *State) Tools(tag string) (*tools.Tools, error) {
 	var results params.ToolsResults
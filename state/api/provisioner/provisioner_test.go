@@ -366,6 +366,14 @@ func (s *provisionerSuite) TestCACert(c *gc.C) {
 	c.Assert(caCert, gc.DeepEquals, s.State.CACert())
 }
 
+func (s *provisionerSuite) TestEnvironUUID(c *gc.C) {
+	env, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	uuid, err := s.provisioner.EnvironUUID()
+	c.Assert(err, gc.IsNil)
+	c.Assert(uuid, gc.Equals, env.UUID())
+}
+
 func (s *provisionerSuite) TestToolsWrongMachine(c *gc.C) {
 	tools, err := s.provisioner.Tools("42")
 	c.Assert(err, gc.ErrorMatches, "permission denied")
@@ -24,10 +24,15 @@ import (
 // method is usually called automatically by Open. The machine nonce
 // should be empty unless logging in as a machine agent.
 func (st *State) Login(tag, password, nonce string) error {
+	return st.login(tag, password, nonce, "")
+}
+
+func (st *State) login(tag, password, nonce, environTag string) error {
 	err := st.Call("Admin", "", "Login", &params.Creds{
-		AuthTag:  tag,
-		Password: password,
-		Nonce:    nonce,
+		AuthTag:    tag,
+		Password:   password,
+		Nonce:      nonce,
+		EnvironTag: environTag,
 	}, nil)
 	if err == nil {
 		st.authTag = tag
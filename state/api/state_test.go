@@ -9,6 +9,10 @@ import (
 	gc "launchpad.net/gocheck"
 
 	jujutesting "launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/rpc/bsoncodec"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api"
+	"launchpad.net/juju-core/state/api/params"
 	coretesting "launchpad.net/juju-core/testing"
 )
 
@@ -27,3 +31,26 @@ func (s *stateSuite) TestCloseMultipleOk(c *gc.C) {
 	c.Assert(s.APIState.Close(), gc.IsNil)
 	c.Assert(s.APIState.Close(), gc.IsNil)
 }
+
+func (s *stateSuite) TestDefaultCodecIsJSON(c *gc.C) {
+	c.Assert(api.CodecProtocol(s.APIState), gc.HasLen, 0)
+}
+
+func (s *stateSuite) TestBinaryCodec(c *gc.C) {
+	st, err := api.Open(s.APIInfo(c), api.DialOpts{BinaryCodec: true})
+	c.Assert(err, gc.IsNil)
+	defer st.Close()
+	c.Assert(api.CodecProtocol(st), gc.DeepEquals, []string{bsoncodec.Protocol})
+
+	_, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	watcher, err := st.Client().WatchAll()
+	c.Assert(err, gc.IsNil)
+	defer watcher.Stop()
+	deltas, err := watcher.Next()
+	c.Assert(err, gc.IsNil)
+	c.Assert(deltas, gc.HasLen, 1)
+	machine, ok := deltas[0].Entity.(*params.MachineInfo)
+	c.Assert(ok, gc.Equals, true)
+	c.Assert(machine.Id, gc.Equals, "0")
+}
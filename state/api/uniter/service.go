@@ -5,6 +5,7 @@ package uniter
 
 import (
 	"fmt"
+	"time"
 
 	"launchpad.net/juju-core/charm"
 	"launchpad.net/juju-core/names"
@@ -127,6 +128,28 @@ func (s *Service) CharmURL() (*charm.URL, bool, error) {
 	return nil, false, fmt.Errorf("%q has no charm url set", s.tag)
 }
 
+// HookTimeout returns the maximum time any hook run by the service's
+// units may take, or zero if the timeouts given in the charm metadata
+// apply.
+func (s *Service) HookTimeout() (time.Duration, error) {
+	var results params.DurationResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: s.tag}},
+	}
+	err := s.st.caller.Call("Uniter", "", "HookTimeout", args, &results)
+	if err != nil {
+		return 0, err
+	}
+	if len(results.Results) != 1 {
+		return 0, fmt.Errorf("expected one result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.Result, nil
+}
+
 // TODO(dimitern) bug #1270795 2014-01-20
 // Add a doc comment here.
 func (s *Service) GetOwnerTag() (string, error) {
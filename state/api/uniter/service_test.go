@@ -4,6 +4,8 @@
 package uniter_test
 
 import (
+	"time"
+
 	gc "launchpad.net/gocheck"
 
 	"launchpad.net/juju-core/state/api/params"
@@ -119,6 +121,18 @@ func (s *serviceSuite) TestCharmURL(c *gc.C) {
 	c.Assert(force, jc.IsFalse)
 }
 
+func (s *serviceSuite) TestHookTimeout(c *gc.C) {
+	timeout, err := s.apiService.HookTimeout()
+	c.Assert(err, gc.IsNil)
+	c.Assert(timeout, gc.Equals, time.Duration(0))
+
+	err = s.wordpressService.SetHookTimeout(2 * time.Minute)
+	c.Assert(err, gc.IsNil)
+	timeout, err = s.apiService.HookTimeout()
+	c.Assert(err, gc.IsNil)
+	c.Assert(timeout, gc.Equals, 2*time.Minute)
+}
+
 func (s *serviceSuite) TestGetOwnerTag(c *gc.C) {
 	tag, err := s.apiService.GetOwnerTag()
 	c.Assert(err, gc.IsNil)
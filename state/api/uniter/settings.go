@@ -10,7 +10,8 @@ import (
 // This module implements a subset of the interface provided by
 // state.Settings, as needed by the uniter API.
 
-// Settings manages changes to unit settings in a relation.
+// Settings manages changes to unit settings in a relation or, when it
+// has no relation, to the unit's migration settings.
 type Settings struct {
 	st          *State
 	relationTag string
@@ -81,6 +82,19 @@ func (s *Settings) Write() error {
 	}
 
 	var result params.ErrorResults
+	if s.relationTag == "" {
+		args := params.UnitsMigrationSettings{
+			Units: []params.UnitMigrationSettings{{
+				Unit:     s.unitTag,
+				Settings: settingsCopy,
+			}},
+		}
+		err := s.st.caller.Call("Uniter", "", "UpdateMigrationSettings", args, &result)
+		if err != nil {
+			return err
+		}
+		return result.OneError()
+	}
 	args := params.RelationUnitsSettings{
 		RelationUnits: []params.RelationUnitSettings{{
 			Relation: s.relationTag,
@@ -379,6 +379,62 @@ func (u *Unit) ClosePort(protocol string, number int) error {
 	return result.OneError()
 }
 
+// RecordHookExecutions records the given executions of hooks by the
+// unit, in order. An execution whose Finished time is zero is recorded
+// as still running. It returns the error encountered recording each
+// execution, or nil for those that were recorded.
+func (u *Unit) RecordHookExecutions(execs []params.HookExecution) ([]error, error) {
+	var result params.ErrorResults
+	args := params.EntitiesHookExecutions{
+		Entities: make([]params.EntityHookExecution, len(execs)),
+	}
+	for i, exec := range execs {
+		args.Entities[i] = params.EntityHookExecution{Tag: u.tag, Execution: exec}
+	}
+	err := u.st.caller.Call("Uniter", "", "RecordHookExecutions", args, &result)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Results) != len(execs) {
+		return nil, fmt.Errorf("expected %d results, got %d", len(execs), len(result.Results))
+	}
+	errs := make([]error, len(execs))
+	for i, r := range result.Results {
+		if r.Error != nil {
+			errs[i] = r.Error
+		}
+	}
+	return errs, nil
+}
+
+// AddMetricBatches sends the given metric batches, collected by the
+// unit, to the state server. It returns the error encountered
+// recording each batch, keyed by batch UUID; a batch that was
+// recorded has no entry.
+func (u *Unit) AddMetricBatches(batches []params.MetricBatch) (map[string]error, error) {
+	var result params.ErrorResults
+	args := params.MetricBatchParams{
+		Batches: make([]params.MetricBatchParam, len(batches)),
+	}
+	for i, batch := range batches {
+		args.Batches[i] = params.MetricBatchParam{Tag: u.tag, Batch: batch}
+	}
+	err := u.st.caller.Call("Uniter", "", "AddMetricBatches", args, &result)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Results) != len(batches) {
+		return nil, fmt.Errorf("expected %d results, got %d", len(batches), len(result.Results))
+	}
+	errs := make(map[string]error)
+	for i, r := range result.Results {
+		if r.Error != nil {
+			errs[batches[i].UUID] = r.Error
+		}
+	}
+	return errs, nil
+}
+
 var ErrNoCharmURLSet = errors.New("unit has no charm url set")
 
 // CharmURL returns the charm URL this unit is currently using.
@@ -443,6 +499,121 @@ func (u *Unit) ClearResolved() error {
 	return result.OneError()
 }
 
+// MigrationStatus returns the migration status of the unit: the names
+// of the units it is being migrated to and replaces, if any, and
+// whether the migrated unit's data is ready to be taken over.
+func (u *Unit) MigrationStatus() (params.MigrationStatusResult, error) {
+	var results params.MigrationStatusResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: u.tag}},
+	}
+	err := u.st.caller.Call("Uniter", "", "MigrationStatus", args, &results)
+	if err != nil {
+		return params.MigrationStatusResult{}, err
+	}
+	if len(results.Results) != 1 {
+		return params.MigrationStatusResult{}, fmt.Errorf("expected one result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return params.MigrationStatusResult{}, result.Error
+	}
+	return result, nil
+}
+
+// MigrationSettings returns the settings through which the data of a
+// migrated unit is passed to the unit replacing it.
+func (u *Unit) MigrationSettings() (*Settings, error) {
+	var results params.RelationSettingsResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: u.tag}},
+	}
+	err := u.st.caller.Call("Uniter", "", "MigrationSettings", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected one result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return newSettings(u.st, "", u.tag, result.Settings), nil
+}
+
+// SetMigrationReady records that the unit, which is being migrated,
+// has written its data to its migration settings.
+func (u *Unit) SetMigrationReady() error {
+	var result params.ErrorResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: u.tag}},
+	}
+	err := u.st.caller.Call("Uniter", "", "SetMigrationReady", args, &result)
+	if err != nil {
+		return err
+	}
+	return result.OneError()
+}
+
+// FinishMigration destroys the unit that the unit replaces, once its
+// data has been taken over.
+func (u *Unit) FinishMigration() error {
+	var result params.ErrorResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: u.tag}},
+	}
+	err := u.st.caller.Call("Uniter", "", "FinishMigration", args, &result)
+	if err != nil {
+		return err
+	}
+	return result.OneError()
+}
+
+// InMaintenance returns whether the machine the unit is assigned to
+// is in maintenance mode.
+func (u *Unit) InMaintenance() (bool, error) {
+	var results params.BoolResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: u.tag}},
+	}
+	err := u.st.caller.Call("Uniter", "", "InMaintenance", args, &results)
+	if err != nil {
+		return false, err
+	}
+	if len(results.Results) != 1 {
+		return false, fmt.Errorf("expected one result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.Result, nil
+}
+
+// WatchMaintenance returns a watcher for observing changes to the
+// machine the unit is assigned to, including changes to its
+// maintenance mode.
+func (u *Unit) WatchMaintenance() (watcher.NotifyWatcher, error) {
+	var results params.NotifyWatchResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: u.tag}},
+	}
+	err := u.st.caller.Call("Uniter", "", "WatchMaintenance", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected one result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	w := watcher.NewNotifyWatcher(u.st.caller, result)
+	return w, nil
+}
+
 // WatchConfigSettings returns a watcher for observing changes to the
 // unit's service configuration settings. The unit must have a charm URL
 // set before this method is called, and the returned watcher will be
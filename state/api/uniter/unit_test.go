@@ -4,6 +4,8 @@
 package uniter_test
 
 import (
+	"time"
+
 	gc "launchpad.net/gocheck"
 
 	"launchpad.net/juju-core/charm"
@@ -259,6 +261,62 @@ func (s *unitSuite) TestOpenClosePort(c *gc.C) {
 	c.Assert(ports, gc.HasLen, 0)
 }
 
+func (s *unitSuite) TestRecordHookExecutions(c *gc.C) {
+	errs, err := s.apiUnit.RecordHookExecutions([]params.HookExecution{{
+		Hook:    "install",
+		Started: time.Unix(100, 0),
+	}})
+	c.Assert(err, gc.IsNil)
+	c.Assert(errs, gc.DeepEquals, []error{nil})
+	stats, err := s.wordpressUnit.HookStats()
+	c.Assert(err, gc.IsNil)
+	c.Assert(stats.Running, gc.DeepEquals, &state.HookExecution{
+		Hook:    "install",
+		Started: time.Unix(100, 0),
+	})
+
+	errs, err = s.apiUnit.RecordHookExecutions([]params.HookExecution{{
+		Hook:     "install",
+		Started:  time.Unix(100, 0),
+		Finished: time.Unix(105, 0),
+	}, {
+		Hook:    "config-changed",
+		Started: time.Unix(106, 0),
+	}, {
+		Hook:     "config-changed",
+		Started:  time.Unix(106, 0),
+		Finished: time.Unix(106, 0),
+		Skipped:  true,
+	}})
+	c.Assert(err, gc.IsNil)
+	c.Assert(errs, gc.DeepEquals, []error{nil, nil, nil})
+	stats, err = s.wordpressUnit.HookStats()
+	c.Assert(err, gc.IsNil)
+	c.Assert(stats.Running, gc.IsNil)
+	c.Assert(stats.Executions, gc.HasLen, 1)
+	c.Assert(stats.Executions[0].Duration(), gc.Equals, 5*time.Second)
+}
+
+func (s *unitSuite) TestAddMetricBatches(c *gc.C) {
+	good := params.MetricBatch{
+		UUID:     "a3d5a9a8-3e8d-4b3c-8c1e-3cce5c0e0d6f",
+		CharmURL: s.wordpressCharm.URL().String(),
+		Created:  time.Unix(100, 0),
+		Metrics:  []params.Metric{{Key: "users", Value: "5", Time: time.Unix(99, 0)}},
+	}
+	bad := good
+	bad.UUID = "not-a-uuid"
+	errs, err := s.apiUnit.AddMetricBatches([]params.MetricBatch{good, bad})
+	c.Assert(err, gc.IsNil)
+	c.Assert(errs, gc.HasLen, 1)
+	c.Assert(errs["not-a-uuid"], gc.ErrorMatches, `cannot add metrics for unit "wordpress/0": invalid batch UUID "not-a-uuid"`)
+
+	batches, err := s.wordpressService.MetricBatches()
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 1)
+	c.Assert(batches[0].UUID(), gc.Equals, good.UUID)
+}
+
 func (s *unitSuite) TestGetSetCharmURL(c *gc.C) {
 	// No charm URL set yet.
 	curl, ok := s.wordpressUnit.CharmURL()
@@ -351,7 +409,82 @@ func (s *unitSuite) TestWatchConfigSettings(c *gc.C) {
 	wc.AssertClosed()
 }
 
+func (s *unitSuite) TestInMaintenance(c *gc.C) {
+	inMaintenance, err := s.apiUnit.InMaintenance()
+	c.Assert(err, gc.IsNil)
+	c.Assert(inMaintenance, jc.IsFalse)
+
+	err = s.wordpressMachine.SetMaintenance(true)
+	c.Assert(err, gc.IsNil)
+	inMaintenance, err = s.apiUnit.InMaintenance()
+	c.Assert(err, gc.IsNil)
+	c.Assert(inMaintenance, jc.IsTrue)
+}
+
+func (s *unitSuite) TestWatchMaintenance(c *gc.C) {
+	w, err := s.apiUnit.WatchMaintenance()
+	c.Assert(err, gc.IsNil)
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewNotifyWatcherC(c, s.BackingState, w)
+
+	// Initial event.
+	wc.AssertOneChange()
+
+	// Put the machine into maintenance mode, check an event.
+	err = s.wordpressMachine.SetMaintenance(true)
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+
+	// And take it out again.
+	err = s.wordpressMachine.SetMaintenance(false)
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+
+	statetesting.AssertStop(c, w)
+	wc.AssertClosed()
+}
+
 func (s *unitSuite) TestServiceNameAndTag(c *gc.C) {
 	c.Assert(s.apiUnit.ServiceName(), gc.Equals, "wordpress")
 	c.Assert(s.apiUnit.ServiceTag(), gc.Equals, "service-wordpress")
 }
+
+func (s *unitSuite) TestMigration(c *gc.C) {
+	status, err := s.apiUnit.MigrationStatus()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.DeepEquals, params.MigrationStatusResult{})
+
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	target, err := s.wordpressUnit.StartMigration(machine)
+	c.Assert(err, gc.IsNil)
+	status, err = s.apiUnit.MigrationStatus()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.DeepEquals, params.MigrationStatusResult{MigratingTo: target.Name()})
+
+	settings, err := s.apiUnit.MigrationSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings.Map(), gc.HasLen, 0)
+	settings.Set("data", "/srv/data")
+	err = settings.Write()
+	c.Assert(err, gc.IsNil)
+	err = s.apiUnit.SetMigrationReady()
+	c.Assert(err, gc.IsNil)
+
+	status, err = s.apiUnit.MigrationStatus()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status.Ready, gc.Equals, true)
+	err = target.Refresh()
+	c.Assert(err, gc.IsNil)
+	stateSettings, err := target.MigrationSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(stateSettings.Map(), gc.DeepEquals, map[string]interface{}{"data": "/srv/data"})
+
+	// Finishing the migration does nothing on the migrated unit;
+	// only the unit replacing it finishes it.
+	err = s.apiUnit.FinishMigration()
+	c.Assert(err, gc.IsNil)
+	err = s.wordpressUnit.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.wordpressUnit.Life(), gc.Equals, state.Alive)
+}
@@ -6,6 +6,7 @@ package apiserver
 import (
 	stderrors "errors"
 	"sync"
+	"time"
 
 	"launchpad.net/juju-core/errors"
 	"launchpad.net/juju-core/rpc"
@@ -63,31 +64,67 @@ var errAlreadyLoggedIn = stderrors.New("already logged in")
 // Login logs in with the provided credentials.
 // All subsequent requests on the connection will
 // act as the authenticated user.
-func (a *srvAdmin) Login(c params.Creds) error {
+func (a *srvAdmin) Login(c params.Creds) (err error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 	if a.loggedIn {
 		// This can only happen if Login is called concurrently.
 		return errAlreadyLoggedIn
 	}
-	entity, err := checkCreds(a.root.srv.state, c)
+	defer func(start time.Time) {
+		recordLogin(err, start)
+	}(time.Now())
+	st, err := a.root.srv.environState(c.EnvironTag)
+	if errors.IsNotFoundError(err) {
+		// Unknown environments are only reported to clients that
+		// can authenticate to the state server's own environment,
+		// so that unauthenticated clients cannot use Login to find
+		// out which environments the server serves.
+		if _, err := checkCreds(a.root.srv.state, c); err != nil {
+			return err
+		}
+		return errors.NotFoundf("environment %q", c.EnvironTag)
+	} else if err != nil {
+		return err
+	}
+	entity, delegated, err := checkEnvironCreds(a.root.srv.state, st, c)
 	if err != nil {
 		return err
 	}
 	if a.reqNotifier != nil {
-		a.reqNotifier.login(entity.Tag())
+		a.reqNotifier.login(st, entity.Tag())
 	}
 	// We have authenticated the user; now choose an appropriate API
 	// to serve to them.
-	newRoot, err := a.apiRootForEntity(entity, c)
+	newRoot, err := a.apiRootForEntity(st, entity, delegated, c)
 	if err != nil {
 		return err
 	}
 
 	a.root.rpcConn.Serve(newRoot, serverError)
+	if a.reqNotifier != nil {
+		a.reqNotifier.loginSucceeded()
+	}
 	return nil
 }
 
+// checkEnvironCreds checks the credentials of a client logging in to
+// the environment held by st, served by the state server whose own
+// environment is held by serverSt. The state server's machines that
+// manage environments may log in to the environments it hosts in
+// order to manage them; such logins are reported as delegated.
+func checkEnvironCreds(serverSt, st *state.State, c params.Creds) (entity taggedAuthenticator, delegated bool, err error) {
+	entity, err = checkCreds(st, c)
+	if err == nil || st == serverSt {
+		return entity, false, err
+	}
+	manager, err1 := checkCreds(serverSt, c)
+	if err1 != nil || !isMachineWithJob(manager, state.JobManageEnviron) {
+		return nil, false, err
+	}
+	return manager, true, nil
+}
+
 func checkCreds(st *state.State, c params.Creds) (taggedAuthenticator, error) {
 	entity0, err := st.FindEntity(c.AuthTag)
 	if err != nil && !errors.IsNotFoundError(err) {
@@ -121,9 +158,9 @@ func (p *machinePinger) Stop() error {
 	return p.Pinger.Kill()
 }
 
-func (a *srvAdmin) apiRootForEntity(entity taggedAuthenticator, c params.Creds) (interface{}, error) {
+func (a *srvAdmin) apiRootForEntity(st *state.State, entity taggedAuthenticator, delegated bool, c params.Creds) (interface{}, error) {
 	// TODO(rog) choose appropriate object to serve.
-	newRoot := newSrvRoot(a.root, entity)
+	newRoot := newSrvRoot(a.root, st, entity, delegated)
 
 	// If this is a machine agent connecting, we need to check the
 	// nonce matches, otherwise the wrong agent might be trying to
@@ -137,7 +174,10 @@ func (a *srvAdmin) apiRootForEntity(entity taggedAuthenticator, c params.Creds)
 	setAgentAliver, ok := entity.(interface {
 		SetAgentAlive() (*presence.Pinger, error)
 	})
-	if ok {
+	// A delegated login does not show the state server machine's
+	// agent as alive: its own connection to the state server's
+	// environment does that.
+	if ok && !delegated {
 		// A machine or unit agent has connected, so start a pinger to
 		// announce it's now alive, and set up the API pinger
 		// so that the connection will be terminated if a sufficient
@@ -9,19 +9,21 @@ import (
 	"launchpad.net/juju-core/state"
 	"launchpad.net/juju-core/state/api/params"
 	"launchpad.net/juju-core/state/apiserver/common"
+	"launchpad.net/juju-core/state/watcher"
 )
 
 // API implements the API provided to an agent.
 type API struct {
 	*common.PasswordChanger
 
-	st   *state.State
-	auth common.Authorizer
+	st        *state.State
+	resources *common.Resources
+	auth      common.Authorizer
 }
 
 // NewAPI returns an object implementing an agent API
 // with the given authorizer representing the currently logged in client.
-func NewAPI(st *state.State, auth common.Authorizer) (*API, error) {
+func NewAPI(st *state.State, resources *common.Resources, auth common.Authorizer) (*API, error) {
 	// Agents are defined to be any user that's not a client user.
 	if !auth.AuthMachineAgent() && !auth.AuthUnitAgent() {
 		return nil, common.ErrPerm
@@ -32,6 +34,7 @@ func NewAPI(st *state.State, auth common.Authorizer) (*API, error) {
 	return &API{
 		PasswordChanger: common.NewPasswordChanger(st, getCanChange),
 		st:              st,
+		resources:       resources,
 		auth:            auth,
 	}, nil
 }
@@ -73,6 +76,109 @@ func (api *API) getEntity(tag string) (result params.AgentGetEntitiesResult, err
 	return
 }
 
+// MigrationTarget returns the state server the environment of each
+// given agent has been imported into, if it has been.
+func (api *API) MigrationTarget(args params.Entities) params.MigrationTargetResults {
+	results := params.MigrationTargetResults{
+		Results: make([]params.MigrationTargetResult, len(args.Entities)),
+	}
+	for i, entity := range args.Entities {
+		result, err := api.migrationTarget(entity.Tag)
+		result.Error = common.ServerError(err)
+		results.Results[i] = result
+	}
+	return results
+}
+
+func (api *API) migrationTarget(tag string) (result params.MigrationTargetResult, err error) {
+	if !api.auth.AuthOwner(tag) {
+		return result, common.ErrPerm
+	}
+	env, err := api.st.Environment()
+	if err != nil {
+		return result, err
+	}
+	addrs, caCert, ok := env.MigrationTarget()
+	if !ok {
+		return result, nil
+	}
+	// State servers are not imported with the environment, so
+	// their agents, and those of units placed on them, stay.
+	if onStateServer, err := api.onStateServer(tag); err != nil {
+		return result, err
+	} else if onStateServer {
+		return result, nil
+	}
+	// The environment keeps its UUID when imported, but
+	// its agents must name it when logging in to the
+	// state server hosting it.
+	result.Addrs = addrs
+	result.CACert = caCert
+	result.EnvironTag = env.Tag()
+	return result, nil
+}
+
+// onStateServer returns whether the agent with the given tag runs
+// on a state server machine, or in a container on one.
+func (api *API) onStateServer(tag string) (bool, error) {
+	entity, err := api.st.FindEntity(tag)
+	if err != nil {
+		return false, err
+	}
+	var machineId string
+	switch entity := entity.(type) {
+	case *state.Machine:
+		machineId = entity.Id()
+	case *state.Unit:
+		machineId, err = entity.AssignedMachineId()
+		if state.IsNotAssigned(err) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+	default:
+		return false, common.NotSupportedError(tag, "migration")
+	}
+	machine, err := api.st.Machine(state.TopParentId(machineId))
+	if err != nil {
+		return false, err
+	}
+	return machine.IsManager(), nil
+}
+
+// WatchMigrationTarget returns a NotifyWatcher for each given agent,
+// notifying when the migration target of its environment may have
+// been set.
+func (api *API) WatchMigrationTarget(args params.Entities) params.NotifyWatchResults {
+	results := params.NotifyWatchResults{
+		Results: make([]params.NotifyWatchResult, len(args.Entities)),
+	}
+	for i, entity := range args.Entities {
+		id, err := api.watchMigrationTarget(entity.Tag)
+		results.Results[i].NotifyWatcherId = id
+		results.Results[i].Error = common.ServerError(err)
+	}
+	return results
+}
+
+func (api *API) watchMigrationTarget(tag string) (string, error) {
+	if !api.auth.AuthOwner(tag) {
+		return "", common.ErrPerm
+	}
+	env, err := api.st.Environment()
+	if err != nil {
+		return "", err
+	}
+	watch := env.Watch()
+	// Consume the initial event. Technically, API calls to Watch
+	// 'transmit' the initial event in the Watch response. But
+	// NotifyWatchers have no state to transmit.
+	if _, ok := <-watch.Changes(); ok {
+		return api.resources.Register(watch), nil
+	}
+	return "", watcher.MustErr(watch)
+}
+
 func stateJobsToAPIParamsJobs(jobs []state.MachineJob) []params.MachineJob {
 	pjobs := make([]params.MachineJob, len(jobs))
 	for i, job := range jobs {
@@ -10,7 +10,9 @@ import (
 	"launchpad.net/juju-core/state"
 	"launchpad.net/juju-core/state/api/params"
 	"launchpad.net/juju-core/state/apiserver/agent"
+	"launchpad.net/juju-core/state/apiserver/common"
 	apiservertesting "launchpad.net/juju-core/state/apiserver/testing"
+	statetesting "launchpad.net/juju-core/state/testing"
 	coretesting "launchpad.net/juju-core/testing"
 )
 
@@ -22,6 +24,7 @@ type agentSuite struct {
 	jujutesting.JujuConnSuite
 
 	authorizer apiservertesting.FakeAuthorizer
+	resources  *common.Resources
 
 	machine0  *state.Machine
 	machine1  *state.Machine
@@ -56,8 +59,11 @@ func (s *agentSuite) SetUpTest(c *gc.C) {
 		MachineAgent: true,
 	}
 
+	s.resources = common.NewResources()
+	s.AddCleanup(func(_ *gc.C) { s.resources.StopAll() })
+
 	// Create a machiner API for machine 1.
-	s.agent, err = agent.NewAPI(s.State, s.authorizer)
+	s.agent, err = agent.NewAPI(s.State, s.resources, s.authorizer)
 	c.Assert(err, gc.IsNil)
 }
 
@@ -65,7 +71,7 @@ func (s *agentSuite) TestAgentFailsWithNonAgent(c *gc.C) {
 	auth := s.authorizer
 	auth.MachineAgent = false
 	auth.UnitAgent = false
-	api, err := agent.NewAPI(s.State, auth)
+	api, err := agent.NewAPI(s.State, s.resources, auth)
 	c.Assert(err, gc.NotNil)
 	c.Assert(api, gc.IsNil)
 	c.Assert(err, gc.ErrorMatches, "permission denied")
@@ -75,7 +81,7 @@ func (s *agentSuite) TestAgentSucceedsWithUnitAgent(c *gc.C) {
 	auth := s.authorizer
 	auth.MachineAgent = false
 	auth.UnitAgent = true
-	_, err := agent.NewAPI(s.State, auth)
+	_, err := agent.NewAPI(s.State, s.resources, auth)
 	c.Assert(err, gc.IsNil)
 }
 
@@ -108,7 +114,7 @@ func (s *agentSuite) TestGetEntities(c *gc.C) {
 	auth.MachineAgent = true
 	auth.UnitAgent = false
 	auth.Tag = s.container.Tag()
-	containerAgent, err := agent.NewAPI(s.State, auth)
+	containerAgent, err := agent.NewAPI(s.State, s.resources, auth)
 	c.Assert(err, gc.IsNil)
 
 	results = containerAgent.GetEntities(args)
@@ -188,3 +194,93 @@ func (s *agentSuite) TestShortSetPasswords(c *gc.C) {
 	c.Assert(results.Results[0].Error, gc.ErrorMatches,
 		"password is only 3 bytes long, and is not a valid Agent password")
 }
+
+func (s *agentSuite) TestMigrationTarget(c *gc.C) {
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: "machine-1"}, {Tag: "machine-0"}},
+	}
+	results := s.agent.MigrationTarget(args)
+	c.Assert(results, gc.DeepEquals, params.MigrationTargetResults{
+		Results: []params.MigrationTargetResult{
+			{},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	env, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	err = env.SetMigrationTarget([]string{"target.example:17070"}, "target-ca-cert")
+	c.Assert(err, gc.IsNil)
+	results = s.agent.MigrationTarget(args)
+	c.Assert(results, gc.DeepEquals, params.MigrationTargetResults{
+		Results: []params.MigrationTargetResult{
+			{
+				Addrs:      []string{"target.example:17070"},
+				CACert:     "target-ca-cert",
+				EnvironTag: env.Tag(),
+			},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+}
+
+func (s *agentSuite) TestWatchMigrationTarget(c *gc.C) {
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: "machine-1"}, {Tag: "machine-0"}},
+	}
+	results := s.agent.WatchMigrationTarget(args)
+	c.Assert(results.Results, gc.HasLen, 2)
+	c.Assert(results.Results[0].Error, gc.IsNil)
+	c.Assert(results.Results[1], gc.DeepEquals, params.NotifyWatchResult{
+		Error: apiservertesting.ErrUnauthorized,
+	})
+	resource := s.resources.Get(results.Results[0].NotifyWatcherId)
+	c.Assert(resource, gc.NotNil)
+
+	w := resource.(state.NotifyWatcher)
+	wc := statetesting.NewNotifyWatcherC(c, s.State, w)
+	wc.AssertNoChange()
+
+	env, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	err = env.SetMigrationTarget([]string{"target.example:17070"}, "target-ca-cert")
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+	statetesting.AssertStop(c, w)
+	wc.AssertClosed()
+}
+
+func (s *agentSuite) TestMigrationTargetOnStateServer(c *gc.C) {
+	env, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	err = env.SetMigrationTarget([]string{"target.example:17070"}, "target-ca-cert")
+	c.Assert(err, gc.IsNil)
+
+	// The agents of the state server machine, and of the machines
+	// and units in containers on it, are not repointed.
+	template := state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}
+	container, err := s.State.AddMachineInsideMachine(template, s.machine0.Id(), instance.LXC)
+	c.Assert(err, gc.IsNil)
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	unit, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(container)
+	c.Assert(err, gc.IsNil)
+	for _, tag := range []string{s.machine0.Tag(), container.Tag(), unit.Tag()} {
+		auth := s.authorizer
+		auth.Tag = tag
+		auth.MachineAgent = tag != unit.Tag()
+		auth.UnitAgent = tag == unit.Tag()
+		api, err := agent.NewAPI(s.State, s.resources, auth)
+		c.Assert(err, gc.IsNil)
+		results := api.MigrationTarget(params.Entities{
+			Entities: []params.Entity{{Tag: tag}},
+		})
+		c.Assert(results, gc.DeepEquals, params.MigrationTargetResults{
+			Results: []params.MigrationTargetResult{{}},
+		})
+	}
+}
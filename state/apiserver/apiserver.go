@@ -15,7 +15,10 @@ import (
 	"github.com/juju/loggo"
 	"launchpad.net/tomb"
 
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/names"
 	"launchpad.net/juju-core/rpc"
+	"launchpad.net/juju-core/rpc/bsoncodec"
 	"launchpad.net/juju-core/rpc/jsoncodec"
 	"launchpad.net/juju-core/state"
 	"launchpad.net/juju-core/state/apiserver/common"
@@ -30,6 +33,12 @@ type Server struct {
 	state   *state.State
 	addr    net.Addr
 	dataDir string
+
+	// mu guards hosted.
+	mu sync.Mutex
+	// hosted holds the states of the hosted environments that
+	// have been logged in to, by environment UUID.
+	hosted map[string]*state.State
 }
 
 // Serve serves the given state by accepting requests on the given
@@ -49,6 +58,7 @@ func NewServer(s *state.State, addr string, cert, key []byte, datadir string) (*
 		state:   s,
 		addr:    lis.Addr(),
 		dataDir: datadir,
+		hosted:  make(map[string]*state.State),
 	}
 	// TODO(rog) check that *srvRoot is a valid type for using
 	// as an RPC server.
@@ -71,6 +81,60 @@ func (srv *Server) Stop() error {
 	return srv.tomb.Wait()
 }
 
+// environState returns the state of the environment with the given
+// tag. The state server's own environment is returned if the tag
+// is empty.
+func (srv *Server) environState(environTag string) (*state.State, error) {
+	if environTag == "" {
+		return srv.state, nil
+	}
+	_, uuid, err := names.ParseTag(environTag, names.EnvironTagKind)
+	if err != nil {
+		return nil, errors.NotFoundf("environment %q", environTag)
+	}
+	env, err := srv.state.Environment()
+	if err != nil {
+		return nil, err
+	}
+	if env.UUID() == uuid {
+		return srv.state, nil
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	// The registry is checked even if the environment's state is
+	// already open, so that removed environments are not served.
+	if _, err := srv.state.HostedEnvironmentByUUID(uuid); errors.IsNotFoundError(err) {
+		if st, ok := srv.hosted[uuid]; ok {
+			st.Close()
+			delete(srv.hosted, uuid)
+		}
+		return nil, errors.NotFoundf("environment %q", environTag)
+	} else if err != nil {
+		return nil, err
+	}
+	if st, ok := srv.hosted[uuid]; ok {
+		return st, nil
+	}
+	st, err := srv.state.OpenHostedEnvironment(uuid)
+	if err != nil {
+		return nil, err
+	}
+	srv.hosted[uuid] = st
+	return st, nil
+}
+
+// closeHosted closes the states of the hosted environments.
+func (srv *Server) closeHosted() {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	for uuid, st := range srv.hosted {
+		if err := st.Close(); err != nil {
+			logger.Errorf("cannot close state of environment %q: %v", uuid, err)
+		}
+		delete(srv.hosted, uuid)
+	}
+}
+
 // Kill implements worker.Worker.Kill.
 func (srv *Server) Kill() {
 	srv.tomb.Kill(nil)
@@ -85,26 +149,43 @@ type requestNotifier struct {
 	id    int64
 	start time.Time
 
-	mu   sync.Mutex
-	tag_ string
+	mu       sync.Mutex
+	st_      *state.State
+	tag_     string
+	loggedIn bool
 }
 
 var globalCounter int64
 
-func newRequestNotifier() *requestNotifier {
+func newRequestNotifier(st *state.State) *requestNotifier {
 	return &requestNotifier{
 		id:    atomic.AddInt64(&globalCounter, 1),
 		tag_:  "<unknown>",
 		start: time.Now(),
+		st_:   st,
 	}
 }
 
-func (n *requestNotifier) login(tag string) {
+// login records the entity logging in, and the state of
+// the environment it is logging in to.
+func (n *requestNotifier) login(st *state.State, tag string) {
 	n.mu.Lock()
+	n.st_ = st
 	n.tag_ = tag
 	n.mu.Unlock()
 }
 
+// loginSucceeded records that the connection has
+// logged in as the entity passed to login.
+func (n *requestNotifier) loginSucceeded() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.loggedIn {
+		n.loggedIn = true
+		loggedInGauge.Inc(tagKind(n.tag_))
+	}
+}
+
 func (n *requestNotifier) tag() (tag string) {
 	n.mu.Lock()
 	tag = n.tag_
@@ -112,11 +193,18 @@ func (n *requestNotifier) tag() (tag string) {
 	return
 }
 
+func (n *requestNotifier) state() (st *state.State) {
+	n.mu.Lock()
+	st = n.st_
+	n.mu.Unlock()
+	return
+}
+
 func (n *requestNotifier) ServerRequest(hdr *rpc.Header, body interface{}) {
 	if hdr.Request.Type == "Pinger" && hdr.Request.Action == "Ping" {
 		return
 	}
-	// TODO(rog) 2013-10-11 remove secrets from some requests.
+	body = redactSecrets(n.state(), body)
 	logger.Debugf("<- [%X] %s %s", n.id, n.tag(), jsoncodec.DumpRequest(hdr, body))
 }
 
@@ -124,15 +212,24 @@ func (n *requestNotifier) ServerReply(req rpc.Request, hdr *rpc.Header, body int
 	if req.Type == "Pinger" && req.Action == "Ping" {
 		return
 	}
+	body = redactSecrets(n.state(), body)
 	logger.Debugf("-> [%X] %s %s %s %s[%q].%s", n.id, n.tag(), timeSpent, jsoncodec.DumpRequest(hdr, body), req.Type, req.Id, req.Action)
 }
 
 func (n *requestNotifier) join(req *http.Request) {
+	connectionsGauge.Inc()
 	logger.Infof("[%X] API connection from %s", n.id, req.RemoteAddr)
 }
 
 func (n *requestNotifier) leave() {
-	logger.Infof("[%X] %s API connection terminated after %v", n.id, n.tag(), time.Since(n.start))
+	connectionsGauge.Dec()
+	n.mu.Lock()
+	tag, loggedIn := n.tag_, n.loggedIn
+	n.mu.Unlock()
+	if loggedIn {
+		loggedInGauge.Dec(tagKind(tag))
+	}
+	logger.Infof("[%X] %s API connection terminated after %v", n.id, tag, time.Since(n.start))
 }
 
 func (n requestNotifier) ClientRequest(hdr *rpc.Header, body interface{}) {
@@ -143,6 +240,7 @@ func (n requestNotifier) ClientReply(req rpc.Request, hdr *rpc.Header, body inte
 
 func (srv *Server) run(lis net.Listener) {
 	defer srv.tomb.Done()
+	defer srv.closeHosted()
 	defer srv.wg.Wait() // wait for any outstanding requests to complete.
 	srv.wg.Add(1)
 	go func() {
@@ -152,16 +250,18 @@ func (srv *Server) run(lis net.Listener) {
 	}()
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", srv.apiHandler)
-	mux.Handle("/charms", &charmsHandler{state: srv.state, dataDir: srv.dataDir})
+	mux.Handle("/charms", &charmsHandler{srv: srv, dataDir: srv.dataDir})
+	mux.Handle("/metrics", &metricsHandler{state: srv.state})
 	// The error from http.Serve is not interesting.
 	http.Serve(lis, mux)
 }
 
 func (srv *Server) apiHandler(w http.ResponseWriter, req *http.Request) {
-	reqNotifier := newRequestNotifier()
+	reqNotifier := newRequestNotifier(srv.state)
 	reqNotifier.join(req)
 	defer reqNotifier.leave()
 	wsServer := websocket.Server{
+		Handshake: negotiateCodec,
 		Handler: func(conn *websocket.Conn) {
 			srv.wg.Add(1)
 			defer srv.wg.Done()
@@ -185,11 +285,37 @@ func (srv *Server) Addr() string {
 	return srv.addr.String()
 }
 
-func (srv *Server) serveConn(wsConn *websocket.Conn, reqNotifier *requestNotifier) error {
-	codec := jsoncodec.NewWebsocket(wsConn)
-	if loggo.GetLogger("juju.rpc.jsoncodec").EffectiveLogLevel() <= loggo.TRACE {
-		codec.SetLogging(true)
+// negotiateCodec chooses the RPC codec for a new connection from the
+// websocket subprotocols offered by the client, preferring the binary
+// codec. Clients that offer no subprotocol get the JSON codec.
+func negotiateCodec(config *websocket.Config, req *http.Request) error {
+	offered := config.Protocol
+	config.Protocol = nil
+	for _, protocol := range []string{bsoncodec.Protocol, jsoncodec.Protocol} {
+		for _, p := range offered {
+			if p == protocol {
+				config.Protocol = []string{protocol}
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// newCodec returns the RPC codec negotiated for the given connection.
+// Codec logging is never enabled: it would log message bodies before
+// secrets can be redacted from them. The request notifier logs
+// requests and replies with secrets redacted instead.
+func newCodec(wsConn *websocket.Conn) rpc.Codec {
+	protocol := wsConn.Config().Protocol
+	if len(protocol) == 1 && protocol[0] == bsoncodec.Protocol {
+		return bsoncodec.NewWebsocket(wsConn)
 	}
+	return jsoncodec.NewWebsocket(wsConn)
+}
+
+func (srv *Server) serveConn(wsConn *websocket.Conn, reqNotifier *requestNotifier) error {
+	codec := newCodec(wsConn)
 	var notifier rpc.RequestNotifier
 	if logger.EffectiveLogLevel() <= loggo.DEBUG {
 		// Incur request monitoring overhead only if we
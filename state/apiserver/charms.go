@@ -6,7 +6,6 @@ package apiserver
 import (
 	"archive/zip"
 	"crypto/sha256"
-	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -26,15 +25,18 @@ import (
 
 	"launchpad.net/juju-core/charm"
 	envtesting "launchpad.net/juju-core/environs/testing"
-	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/errors"
 	"launchpad.net/juju-core/state"
 	"launchpad.net/juju-core/state/api/params"
-	"launchpad.net/juju-core/state/apiserver/common"
 	ziputil "launchpad.net/juju-core/utils/zip"
 )
 
 // charmsHandler handles charm upload through HTTPS in the API server.
+// The environment is named by the "environ" query parameter, which
+// holds its tag; the state server's own environment is used if it
+// is not given.
 type charmsHandler struct {
+	srv     *Server
 	state   *state.State
 	dataDir string
 }
@@ -44,6 +46,16 @@ type charmsHandler struct {
 type bundleContentSenderFunc func(w http.ResponseWriter, r *http.Request, bundle *charm.Bundle)
 
 func (h *charmsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	st, err := h.srv.environState(r.URL.Query().Get("environ"))
+	if errors.IsNotFoundError(err) {
+		// Unknown environments are not revealed.
+		h.authError(w)
+		return
+	} else if err != nil {
+		h.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h = &charmsHandler{srv: h.srv, state: st, dataDir: h.dataDir}
 	if err := h.authenticate(r); err != nil {
 		h.authError(w)
 		return
@@ -173,34 +185,7 @@ func (h *charmsHandler) sendError(w http.ResponseWriter, statusCode int, message
 // authenticate parses HTTP basic authentication and authorizes the
 // request by looking up the provided tag and password against state.
 func (h *charmsHandler) authenticate(r *http.Request) error {
-	parts := strings.Fields(r.Header.Get("Authorization"))
-	if len(parts) != 2 || parts[0] != "Basic" {
-		// Invalid header format or no header provided.
-		return fmt.Errorf("invalid request format")
-	}
-	// Challenge is a base64-encoded "tag:pass" string.
-	// See RFC 2617, Section 2.
-	challenge, err := base64.StdEncoding.DecodeString(parts[1])
-	if err != nil {
-		return fmt.Errorf("invalid request format")
-	}
-	tagPass := strings.SplitN(string(challenge), ":", 2)
-	if len(tagPass) != 2 {
-		return fmt.Errorf("invalid request format")
-	}
-	entity, err := checkCreds(h.state, params.Creds{
-		AuthTag:  tagPass[0],
-		Password: tagPass[1],
-	})
-	if err != nil {
-		return err
-	}
-	// Only allow users, not agents.
-	_, _, err = names.ParseTag(entity.Tag(), names.UserTagKind)
-	if err != nil {
-		return common.ErrBadCreds
-	}
-	return err
+	return authenticateUser(h.state, r)
 }
 
 // authError sends an unauthorized error.
@@ -396,7 +381,10 @@ func (h *charmsHandler) repackageAndUploadCharm(archive *charm.Bundle, curl *cha
 	if err != nil {
 		return errgo.Annotate(err, "cannot access provider storage")
 	}
-	name := charm.Quote(curl.String())
+	name, err := h.charmStorageName(curl.String())
+	if err != nil {
+		return errgo.Annotate(err, "cannot get storage name for charm")
+	}
 	if err := storage.Put(name, repackagedArchive, size); err != nil {
 		return errgo.Annotate(err, "cannot upload charm to provider storage")
 	}
@@ -436,7 +424,10 @@ func (h *charmsHandler) processGet(r *http.Request) (string, string, error) {
 	}
 
 	// Prepare the bundle directories.
-	name := charm.Quote(curl)
+	name, err := h.charmStorageName(curl)
+	if err != nil {
+		return "", "", err
+	}
 	charmArchivePath := filepath.Join(h.dataDir, "charm-get-cache", name+".zip")
 
 	// Check if the charm archive is already in the cache.
@@ -451,6 +442,22 @@ func (h *charmsHandler) processGet(r *http.Request) (string, string, error) {
 	return charmArchivePath, filePath, nil
 }
 
+// charmStorageName returns the name under which the charm with the
+// given URL is held in the provider storage. The storage is shared
+// by the environments hosted by a state server, so the charms of a
+// hosted environment are named within a directory of its own.
+func (h *charmsHandler) charmStorageName(curl string) (string, error) {
+	name := charm.Quote(curl)
+	if !h.state.IsHosted() {
+		return name, nil
+	}
+	env, err := h.state.Environment()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(env.UUID(), name), nil
+}
+
 // downloadCharm downloads the given charm name from the provider storage and
 // saves the corresponding zip archive to the given charmArchivePath.
 func (h *charmsHandler) downloadCharm(name, charmArchivePath string) error {
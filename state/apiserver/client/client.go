@@ -30,6 +30,7 @@ import (
 	"launchpad.net/juju-core/state/statecmd"
 	coretools "launchpad.net/juju-core/tools"
 	"launchpad.net/juju-core/utils"
+	"launchpad.net/juju-core/version"
 )
 
 var logger = loggo.GetLogger("juju.state.apiserver.client")
@@ -74,8 +75,25 @@ func (r *API) Client(id string) (*Client, error) {
 	return r.client, nil
 }
 
-func (c *Client) WatchAll() (params.AllWatcherId, error) {
-	w := c.api.state.Watch()
+// watchAllKinds holds the entity kinds sent by an AllWatcher.
+var watchAllKinds = map[string]bool{
+	"machine":    true,
+	"service":    true,
+	"unit":       true,
+	"relation":   true,
+	"annotation": true,
+}
+
+// WatchAll returns an AllWatcher that sends deltas for the entities
+// matching the given filter. Clients that send no filter receive
+// deltas for all entities.
+func (c *Client) WatchAll(filter params.WatchAllFilter) (params.AllWatcherId, error) {
+	for _, kind := range filter.Kinds {
+		if !watchAllKinds[kind] {
+			return params.AllWatcherId{}, fmt.Errorf("unknown entity kind %q", kind)
+		}
+	}
+	w := c.api.state.WatchFiltered(filter)
 	return params.AllWatcherId{
 		AllWatcherId: c.api.resources.Register(w),
 	}, nil
@@ -91,7 +109,7 @@ func (c *Client) ServiceSet(p params.ServiceSet) error {
 	if err != nil {
 		return err
 	}
-	return serviceSetSettingsStrings(svc, p.Options)
+	return serviceSetSettingsStrings(svc, c.api.auth.GetAuthTag(), p.Options)
 }
 
 // NewServiceSetForClientAPI implements the server side of
@@ -106,7 +124,21 @@ func (c *Client) NewServiceSetForClientAPI(p params.ServiceSet) error {
 	if err != nil {
 		return err
 	}
-	return newServiceSetSettingsStringsForClientAPI(svc, p.Options)
+	return newServiceSetSettingsStringsForClientAPI(svc, c.api.auth.GetAuthTag(), p.Options)
+}
+
+// ServicesSet implements the server side of Client.ServicesSet. Each
+// service is updated as by NewServiceSetForClientAPI, and an error
+// result is returned for each.
+func (c *Client) ServicesSet(args params.ServicesSet) (params.ErrorResults, error) {
+	results := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Services)),
+	}
+	for i, arg := range args.Services {
+		err := c.NewServiceSetForClientAPI(arg)
+		results.Results[i].Error = common.ServerError(err)
+	}
+	return results, nil
 }
 
 // ServiceUnset implements the server side of Client.ServiceUnset.
@@ -119,7 +151,7 @@ func (c *Client) ServiceUnset(p params.ServiceUnset) error {
 	for _, option := range p.Options {
 		settings[option] = nil
 	}
-	return svc.UpdateConfigSettings(settings)
+	return svc.UpdateConfigSettingsBy(c.api.auth.GetAuthTag(), settings)
 }
 
 // ServiceSetYAML implements the server side of Client.ServerSetYAML.
@@ -128,7 +160,7 @@ func (c *Client) ServiceSetYAML(p params.ServiceSetYAML) error {
 	if err != nil {
 		return err
 	}
-	return serviceSetSettingsYAML(svc, p.Config)
+	return serviceSetSettingsYAML(svc, c.api.auth.GetAuthTag(), p.Config)
 }
 
 // ServiceCharmRelations implements the server side of Client.ServiceCharmRelations.
@@ -206,6 +238,16 @@ func (c *Client) ServiceUnexpose(args params.ServiceUnexpose) error {
 	return svc.ClearExposed()
 }
 
+// ServiceSetHookTimeout sets the maximum time any hook run by the
+// service's units may take.
+func (c *Client) ServiceSetHookTimeout(args params.ServiceSetHookTimeout) error {
+	svc, err := c.api.state.Service(args.ServiceName)
+	if err != nil {
+		return err
+	}
+	return svc.SetHookTimeout(args.Timeout)
+}
+
 var CharmStore charm.Repository = charm.Store
 
 // ServiceDeploy fetches the charm from the charm store and deploys it.
@@ -263,6 +305,19 @@ func (c *Client) ServiceDeploy(args params.ServiceDeploy) error {
 	return err
 }
 
+// ServicesDeploy deploys each of the given services as ServiceDeploy
+// does, returning an error result for each.
+func (c *Client) ServicesDeploy(args params.ServicesDeploy) (params.ErrorResults, error) {
+	results := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Services)),
+	}
+	for i, arg := range args.Services {
+		err := c.ServiceDeploy(arg)
+		results.Results[i].Error = common.ServerError(err)
+	}
+	return results, nil
+}
+
 // ServiceUpdate updates the service attributes, including charm URL,
 // minimum number of units, settings and constraints.
 // All parameters in params.ServiceUpdate except the service name are optional.
@@ -285,11 +340,11 @@ func (c *Client) ServiceUpdate(args params.ServiceUpdate) error {
 	}
 	// Set up service's settings.
 	if args.SettingsYAML != "" {
-		if err = serviceSetSettingsYAML(service, args.SettingsYAML); err != nil {
+		if err = serviceSetSettingsYAML(service, c.api.auth.GetAuthTag(), args.SettingsYAML); err != nil {
 			return err
 		}
 	} else if len(args.SettingsStrings) > 0 {
-		if err = serviceSetSettingsStrings(service, args.SettingsStrings); err != nil {
+		if err = serviceSetSettingsStrings(service, c.api.auth.GetAuthTag(), args.SettingsStrings); err != nil {
 			return err
 		}
 	}
@@ -339,9 +394,9 @@ func (c *Client) serviceSetCharm1dot16(service *state.Service, curl *charm.URL,
 	return service.SetCharm(ch, force)
 }
 
-// serviceSetSettingsYAML updates the settings for the given service,
-// taking the configuration from a YAML string.
-func serviceSetSettingsYAML(service *state.Service, settings string) error {
+// serviceSetSettingsYAML updates the settings for the given service
+// on behalf of author, taking the configuration from a YAML string.
+func serviceSetSettingsYAML(service *state.Service, author, settings string) error {
 	ch, _, err := service.Charm()
 	if err != nil {
 		return err
@@ -350,12 +405,12 @@ func serviceSetSettingsYAML(service *state.Service, settings string) error {
 	if err != nil {
 		return err
 	}
-	return service.UpdateConfigSettings(changes)
+	return service.UpdateConfigSettingsBy(author, changes)
 }
 
-// serviceSetSettingsStrings updates the settings for the given service,
-// taking the configuration from a map of strings.
-func serviceSetSettingsStrings(service *state.Service, settings map[string]string) error {
+// serviceSetSettingsStrings updates the settings for the given service
+// on behalf of author, taking the configuration from a map of strings.
+func serviceSetSettingsStrings(service *state.Service, author string, settings map[string]string) error {
 	ch, _, err := service.Charm()
 	if err != nil {
 		return err
@@ -365,15 +420,15 @@ func serviceSetSettingsStrings(service *state.Service, settings map[string]strin
 	if err != nil {
 		return err
 	}
-	return service.UpdateConfigSettings(changes)
+	return service.UpdateConfigSettingsBy(author, changes)
 }
 
 // newServiceSetSettingsStringsForClientAPI updates the settings for the given
-// service, taking the configuration from a map of strings.
+// service on behalf of author, taking the configuration from a map of strings.
 //
 // TODO(Nate): replace serviceSetSettingsStrings with this onces the GUI no
 // longer expects to be able to unset values by sending an empty string.
-func newServiceSetSettingsStringsForClientAPI(service *state.Service, settings map[string]string) error {
+func newServiceSetSettingsStringsForClientAPI(service *state.Service, author string, settings map[string]string) error {
 	ch, _, err := service.Charm()
 	if err != nil {
 		return err
@@ -385,7 +440,7 @@ func newServiceSetSettingsStringsForClientAPI(service *state.Service, settings m
 		return err
 	}
 
-	return service.UpdateConfigSettings(changes)
+	return service.UpdateConfigSettingsBy(author, changes)
 }
 
 // ServiceSetCharm sets the charm for a given service.
@@ -425,6 +480,23 @@ func (c *Client) AddServiceUnits(args params.AddServiceUnits) (params.AddService
 	return params.AddServiceUnitsResults{Units: unitNames}, nil
 }
 
+// MigrateUnit starts moving a principal unit to another machine by
+// adding a unit to replace it there.
+func (c *Client) MigrateUnit(args params.MigrateUnit) (params.MigrateUnitResult, error) {
+	unit, err := c.api.state.Unit(args.UnitName)
+	if err != nil {
+		return params.MigrateUnitResult{}, err
+	}
+	if args.ToMachineSpec == "" {
+		return params.MigrateUnitResult{}, fmt.Errorf("no machine specified")
+	}
+	target, err := juju.MigrateUnit(c.api.state, unit, args.ToMachineSpec)
+	if err != nil {
+		return params.MigrateUnitResult{}, err
+	}
+	return params.MigrateUnitResult{Unit: target.Name()}, nil
+}
+
 // DestroyServiceUnits removes a given set of service units.
 func (c *Client) DestroyServiceUnits(args params.DestroyServiceUnits) error {
 	var errs []string
@@ -485,6 +557,24 @@ func (c *Client) SetServiceConstraints(args params.SetConstraints) error {
 	return svc.SetConstraints(args.Constraints)
 }
 
+// SetServicesConstraints sets the constraints for each of the given
+// services, returning an error result for each.
+func (c *Client) SetServicesConstraints(args params.SetServicesConstraints) (params.ErrorResults, error) {
+	results := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Services)),
+	}
+	for i, arg := range args.Services {
+		var err error
+		if arg.ServiceName == "" {
+			err = fmt.Errorf("no service name specified")
+		} else {
+			err = c.SetServiceConstraints(arg)
+		}
+		results.Results[i].Error = common.ServerError(err)
+	}
+	return results, nil
+}
+
 // SetEnvironmentConstraints sets the constraints for the environment.
 func (c *Client) SetEnvironmentConstraints(args params.SetConstraints) error {
 	return c.api.state.SetEnvironConstraints(args.Constraints)
@@ -511,6 +601,22 @@ func (c *Client) AddRelation(args params.AddRelation) (params.AddRelationResults
 	return params.AddRelationResults{Endpoints: outEps}, nil
 }
 
+// AddRelations adds each of the given relations, returning the
+// endpoints or an error for each.
+func (c *Client) AddRelations(args params.AddRelations) (params.AddRelationsResults, error) {
+	results := params.AddRelationsResults{
+		Results: make([]params.AddRelationsResult, len(args.Relations)),
+	}
+	for i, arg := range args.Relations {
+		result, err := c.AddRelation(arg)
+		results.Results[i].Error = common.ServerError(err)
+		if err == nil {
+			results.Results[i].Endpoints = result.Endpoints
+		}
+	}
+	return results, nil
+}
+
 // DestroyRelation removes the relation between the specified endpoints.
 func (c *Client) DestroyRelation(args params.DestroyRelation) error {
 	eps, err := c.api.state.InferEndpoints(args.Endpoints)
@@ -604,6 +710,7 @@ func stateJobs(jobs []params.MachineJob) ([]state.MachineJob, error) {
 
 // ProvisioningScript returns a shell script that, when run,
 // provisions a machine agent on the machine executing the script.
+// For Windows machines, the script is a PowerShell script.
 func (c *Client) ProvisioningScript(args params.ProvisioningScriptParams) (params.ProvisioningScriptResult, error) {
 	var result params.ProvisioningScriptResult
 	mcfg, err := statecmd.MachineConfig(c.api.state, args.MachineId, args.Nonce, args.DataDir)
@@ -611,6 +718,12 @@ func (c *Client) ProvisioningScript(args params.ProvisioningScriptParams) (param
 		return result, err
 	}
 	mcfg.DisablePackageCommands = args.DisablePackageCommands
+	if version.IsWindowsSeries(mcfg.Tools.Version.Series) {
+		// Windows machines are provisioned via WinRM,
+		// with a PowerShell script.
+		result.Script, err = cloudinit.ConfigureWindowsScript(mcfg)
+		return result, err
+	}
 	cloudcfg := coreCloudinit.New()
 	if err := cloudinit.ConfigureJuju(mcfg, cloudcfg); err != nil {
 		return result, err
@@ -646,6 +759,16 @@ func (c *Client) DestroyMachines(args params.DestroyMachines) error {
 	return destroyErr("machines", args.MachineNames, errs)
 }
 
+// MachineSetMaintenance puts a machine into maintenance mode,
+// or takes it out of maintenance mode.
+func (c *Client) MachineSetMaintenance(args params.MachineSetMaintenance) error {
+	machine, err := c.api.state.Machine(args.MachineId)
+	if err != nil {
+		return err
+	}
+	return machine.SetMaintenance(args.Maintenance)
+}
+
 // CharmInfo returns information about the requested charm.
 func (c *Client) CharmInfo(args params.CharmInfo) (api.CharmInfo, error) {
 	curl, err := charm.ParseURL(args.CharmURL)
@@ -765,6 +888,9 @@ func (c *Client) EnvironmentGet() (params.EnvironmentGetResults, error) {
 		return result, err
 	}
 	result.Config = config.AllAttrs()
+	if err := redactWebhooks(config, result.Config); err != nil {
+		return result, err
+	}
 	return result, nil
 }
 
@@ -786,6 +912,9 @@ func (c *Client) EnvironmentSet(args params.EnvironmentSet) error {
 			return fmt.Errorf("agent-version cannot be changed")
 		}
 	}
+	if err := restoreWebhookSecrets(oldConfig, args.Config); err != nil {
+		return err
+	}
 	// Apply the attributes specified for the command to the state config.
 	newConfig, err := oldConfig.Apply(args.Config)
 	if err != nil {
@@ -106,6 +106,34 @@ func (s *clientSuite) TestClientServiceSet(c *gc.C) {
 	})
 }
 
+func (s *clientSuite) TestClientServicesSet(c *gc.C) {
+	dummy := s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
+	other := s.AddTestingService(c, "other", s.AddTestingCharm(c, "dummy"))
+
+	results, err := s.APIState.Client().ServicesSet([]params.ServiceSet{{
+		ServiceName: "dummy",
+		Options:     map[string]string{"title": "foobar"},
+	}, {
+		ServiceName: "nosuch",
+		Options:     map[string]string{"title": "foobar"},
+	}, {
+		ServiceName: "other",
+		Options:     map[string]string{"username": "user name"},
+	}})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 3)
+	c.Assert(results[0].Error, gc.IsNil)
+	c.Assert(results[1].Error, gc.ErrorMatches, `service "nosuch" not found`)
+	c.Assert(results[2].Error, gc.IsNil)
+
+	settings, err := dummy.ConfigSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, gc.DeepEquals, charm.Settings{"title": "foobar"})
+	settings, err = other.ConfigSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, gc.DeepEquals, charm.Settings{"username": "user name"})
+}
+
 func (s *clientSuite) TestClientServerUnset(c *gc.C) {
 	dummy := s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
 
@@ -210,6 +238,32 @@ func (s *clientSuite) TestClientAddServiceUnits(c *gc.C) {
 	c.Assert(assignedMachine, gc.Equals, "0")
 }
 
+func (s *clientSuite) TestClientMigrateUnit(c *gc.C) {
+	svc := s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
+	unit, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	m0, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(m0)
+	c.Assert(err, gc.IsNil)
+	m1, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	_, err = s.APIState.Client().MigrateUnit("dummy/0", "")
+	c.Assert(err, gc.ErrorMatches, "no machine specified")
+	_, err = s.APIState.Client().MigrateUnit("dummy/42", m1.Id())
+	c.Assert(err, gc.ErrorMatches, `unit "dummy/42" not found`)
+
+	name, err := s.APIState.Client().MigrateUnit("dummy/0", m1.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(name, gc.Equals, "dummy/1")
+	target, err := s.State.Unit(name)
+	c.Assert(err, gc.IsNil)
+	machineId, err := target.AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machineId, gc.Equals, m1.Id())
+}
+
 var clientCharmInfoTests = []struct {
 	about string
 	url   string
@@ -449,6 +503,18 @@ func (s *clientSuite) TestClientServiceUnexpose(c *gc.C) {
 	}
 }
 
+func (s *clientSuite) TestClientServiceSetHookTimeout(c *gc.C) {
+	svc := s.AddTestingService(c, "dummy-service", s.AddTestingCharm(c, "dummy"))
+	err := s.APIState.Client().ServiceSetHookTimeout("dummy-service", 90*time.Second)
+	c.Assert(err, gc.IsNil)
+	err = svc.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.HookTimeout(), gc.Equals, 90*time.Second)
+
+	err = s.APIState.Client().ServiceSetHookTimeout("unknown-service", time.Minute)
+	c.Assert(err, gc.ErrorMatches, `service "unknown-service" not found`)
+}
+
 var serviceDestroyTests = []struct {
 	about   string
 	service string
@@ -561,6 +627,26 @@ func (s *clientSuite) TestForceDestroyMachines(c *gc.C) {
 	assertRemoved(c, u)
 }
 
+func (s *clientSuite) TestMachineSetMaintenance(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	err = s.APIState.Client().MachineSetMaintenance(m.Id(), true)
+	c.Assert(err, gc.IsNil)
+	err = m.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(m.InMaintenance(), gc.Equals, true)
+
+	err = s.APIState.Client().MachineSetMaintenance(m.Id(), false)
+	c.Assert(err, gc.IsNil)
+	err = m.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(m.InMaintenance(), gc.Equals, false)
+
+	err = s.APIState.Client().MachineSetMaintenance("42", true)
+	c.Assert(err, gc.ErrorMatches, `machine 42 not found`)
+}
+
 func (s *clientSuite) TestDestroyPrincipalUnits(c *gc.C) {
 	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
 	units := make([]*state.Unit, 5)
@@ -714,6 +800,41 @@ func (s *clientSuite) TestClientServiceDeployPrincipal(c *gc.C) {
 	}
 }
 
+func (s *clientSuite) TestClientServicesDeploy(c *gc.C) {
+	store, restore := makeMockCharmStore()
+	defer restore()
+	curl, _ := addCharm(c, store, "dummy")
+	err := s.APIState.Client().AddCharm(curl)
+	c.Assert(err, gc.IsNil)
+	results, err := s.APIState.Client().ServicesDeploy([]params.ServiceDeploy{{
+		ServiceName: "service1",
+		CharmUrl:    curl.String(),
+		NumUnits:    1,
+	}, {
+		ServiceName: "service2",
+		CharmUrl:    "cs:precise/nosuch",
+	}, {
+		ServiceName: "service3",
+		CharmUrl:    curl.String(),
+		NumUnits:    2,
+	}})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 3)
+	c.Assert(results[0].Error, gc.IsNil)
+	c.Assert(results[1].Error, gc.ErrorMatches, "charm url must include revision")
+	c.Assert(results[2].Error, gc.IsNil)
+
+	for name, count := range map[string]int{"service1": 1, "service3": 2} {
+		service, err := s.State.Service(name)
+		c.Assert(err, gc.IsNil)
+		units, err := service.AllUnits()
+		c.Assert(err, gc.IsNil)
+		c.Assert(units, gc.HasLen, count)
+	}
+	_, err = s.State.Service("service2")
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+}
+
 func (s *clientSuite) TestClientServiceDeploySubordinate(c *gc.C) {
 	store, restore := makeMockCharmStore()
 	defer restore()
@@ -1181,6 +1302,26 @@ func (s *clientSuite) TestAddAlreadyAddedRelation(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, `cannot add relation "wordpress:db mysql:server": relation already exists`)
 }
 
+func (s *clientSuite) TestAddRelations(c *gc.C) {
+	s.setUpScenario(c)
+	results, err := s.APIState.Client().AddRelations([]params.AddRelation{
+		{Endpoints: []string{"wordpress", "mysql"}},
+		{Endpoints: []string{"wordpress", "nosuch"}},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 2)
+	c.Assert(results[0].Error, gc.IsNil)
+	s.checkEndpoints(c, results[0].Endpoints)
+	c.Assert(results[1].Error, gc.ErrorMatches, `service "nosuch" not found`)
+	c.Assert(results[1].Endpoints, gc.IsNil)
+
+	mySvc, err := s.State.Service("mysql")
+	c.Assert(err, gc.IsNil)
+	rels, err := mySvc.Relations()
+	c.Assert(err, gc.IsNil)
+	c.Assert(rels, gc.HasLen, 1)
+}
+
 func (s *clientSuite) assertDestroyRelation(c *gc.C, endpoints []string) {
 	s.setUpScenario(c)
 	// Add a relation between the endpoints.
@@ -1287,6 +1428,38 @@ func (s *clientSuite) TestClientWatchAll(c *gc.C) {
 	}
 }
 
+func (s *clientSuite) TestClientWatchAllFiltered(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+	err = m.SetProvisioned("i-0", state.BootstrapNonce, nil)
+	c.Assert(err, gc.IsNil)
+	s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
+	watcher, err := s.APIState.Client().WatchAllFiltered(params.WatchAllFilter{
+		Kinds: []string{"machine"},
+	})
+	c.Assert(err, gc.IsNil)
+	defer func() {
+		err := watcher.Stop()
+		c.Assert(err, gc.IsNil)
+	}()
+	deltas, err := watcher.Next()
+	c.Assert(err, gc.IsNil)
+	c.Assert(deltas, gc.DeepEquals, []params.Delta{{
+		Entity: &params.MachineInfo{
+			Id:         m.Id(),
+			InstanceId: "i-0",
+			Status:     params.StatusPending,
+		},
+	}})
+}
+
+func (s *clientSuite) TestClientWatchAllUnknownKind(c *gc.C) {
+	_, err := s.APIState.Client().WatchAllFiltered(params.WatchAllFilter{
+		Kinds: []string{"machine", "bogus"},
+	})
+	c.Assert(err, gc.ErrorMatches, `unknown entity kind "bogus"`)
+}
+
 func (s *clientSuite) TestClientSetServiceConstraints(c *gc.C) {
 	service := s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
 
@@ -1302,6 +1475,35 @@ func (s *clientSuite) TestClientSetServiceConstraints(c *gc.C) {
 	c.Assert(obtained, gc.DeepEquals, cons)
 }
 
+func (s *clientSuite) TestClientSetServicesConstraints(c *gc.C) {
+	dummy := s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
+	other := s.AddTestingService(c, "other", s.AddTestingCharm(c, "dummy"))
+
+	mem4g := constraints.MustParse("mem=4G")
+	cores2 := constraints.MustParse("cpu-cores=2")
+	results, err := s.APIState.Client().SetServicesConstraints([]params.SetConstraints{
+		{ServiceName: "dummy", Constraints: mem4g},
+		{ServiceName: "", Constraints: mem4g},
+		{ServiceName: "other", Constraints: cores2},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 3)
+	c.Assert(results[0].Error, gc.IsNil)
+	c.Assert(results[1].Error, gc.ErrorMatches, "no service name specified")
+	c.Assert(results[2].Error, gc.IsNil)
+
+	obtained, err := dummy.Constraints()
+	c.Assert(err, gc.IsNil)
+	c.Assert(obtained, gc.DeepEquals, mem4g)
+	obtained, err = other.Constraints()
+	c.Assert(err, gc.IsNil)
+	c.Assert(obtained, gc.DeepEquals, cores2)
+	// Environment constraints are never set by SetServicesConstraints.
+	envCons, err := s.State.EnvironConstraints()
+	c.Assert(err, gc.IsNil)
+	c.Assert(envCons, gc.DeepEquals, constraints.Value{})
+}
+
 func (s *clientSuite) TestClientGetServiceConstraints(c *gc.C) {
 	service := s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
 
@@ -1716,6 +1918,34 @@ func (s *clientSuite) TestProvisioningScript(c *gc.C) {
 	}
 }
 
+func (s *clientSuite) TestProvisioningScriptWindows(c *gc.C) {
+	agentVersion, ok := s.Conn.Environ.Config().AgentVersion()
+	c.Assert(ok, jc.IsTrue)
+	envtesting.AssertUploadFakeToolsVersions(
+		c, s.Conn.Environ.Storage(), version.Binary{agentVersion, "win2012r2", "amd64"},
+	)
+	apiParams := params.AddMachineParams{
+		Series:     "win2012r2",
+		Jobs:       []params.MachineJob{params.JobHostUnits},
+		InstanceId: instance.Id("1234"),
+		Nonce:      "foo",
+		HardwareCharacteristics: instance.MustParseHardware("arch=amd64"),
+	}
+	machines, err := s.APIState.Client().AddMachines([]params.AddMachineParams{apiParams})
+	c.Assert(err, gc.IsNil)
+	c.Assert(machines, gc.HasLen, 1)
+	script, err := s.APIState.Client().ProvisioningScript(params.ProvisioningScriptParams{
+		MachineId: machines[0].Machine,
+		Nonce:     apiParams.Nonce,
+	})
+	c.Assert(err, gc.IsNil)
+	// Windows machines get the PowerShell userdata script,
+	// which installs the machine agent as a service.
+	c.Assert(script, jc.HasPrefix, "#ps1_sysnative\r\n")
+	c.Assert(script, jc.Contains, "jujud-machine-"+machines[0].Machine)
+	c.Assert(script, gc.Not(jc.Contains), "apt-get")
+}
+
 func (s *clientSuite) TestProvisioningScriptDisablePackageCommands(c *gc.C) {
 	apiParams := params.AddMachineParams{
 		Jobs:       []params.MachineJob{params.JobHostUnits},
@@ -0,0 +1,167 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"fmt"
+	"reflect"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// ServiceConfigHistory returns the recorded revisions of a service's
+// charm config settings, oldest first.
+func (c *Client) ServiceConfigHistory(args params.ServiceConfigHistory) (params.ServiceConfigHistoryResults, error) {
+	var results params.ServiceConfigHistoryResults
+	service, err := c.api.state.Service(args.ServiceName)
+	if err != nil {
+		return results, err
+	}
+	revisions, err := service.ConfigHistory()
+	if err != nil {
+		return results, err
+	}
+	results.Revisions = make([]params.ConfigRevision, len(revisions))
+	for i, rev := range revisions {
+		settings, err := c.redactSecrets(rev)
+		if err != nil {
+			return results, err
+		}
+		results.Revisions[i] = params.ConfigRevision{
+			Revision: rev.Revision,
+			CharmURL: rev.CharmURL.String(),
+			Author:   rev.Author,
+			Time:     rev.Time,
+			Settings: settings,
+		}
+	}
+	return results, nil
+}
+
+// redactSecrets returns the settings of the given revision with the
+// values of any secret options replaced.
+func (c *Client) redactSecrets(rev state.ConfigRevision) (charm.Settings, error) {
+	ch, err := c.api.state.Charm(rev.CharmURL)
+	if err != nil {
+		return nil, err
+	}
+	return ch.Config().RedactSettings(rev.Settings), nil
+}
+
+// ServiceConfigDiff returns the changes made to a service's charm
+// config settings between two revisions. The settings are compared
+// before the values of secret options are redacted, so that changes
+// to those options are reported too.
+func (c *Client) ServiceConfigDiff(args params.ServiceConfigDiff) (params.ServiceConfigDiffResults, error) {
+	var results params.ServiceConfigDiffResults
+	service, err := c.api.state.Service(args.ServiceName)
+	if err != nil {
+		return results, err
+	}
+	to, err := service.ConfigRevision(args.To)
+	if err != nil {
+		return results, err
+	}
+	ch, err := c.api.state.Charm(to.CharmURL)
+	if err != nil {
+		return results, err
+	}
+	configs := []*charm.Config{ch.Config()}
+	results.From = args.From
+	if results.From == 0 {
+		results.From = args.To - 1
+	}
+	var old charm.Settings
+	if results.From != 0 {
+		from, err := service.ConfigRevision(results.From)
+		if err != nil {
+			return results, err
+		}
+		ch, err := c.api.state.Charm(from.CharmURL)
+		if err != nil {
+			return results, err
+		}
+		configs = append(configs, ch.Config())
+		old = from.Settings
+	}
+	results.Changes = diffSettings(old, to.Settings)
+	for name, change := range results.Changes {
+		if !isSecret(configs, name) {
+			continue
+		}
+		if change.Old != nil {
+			change.Old = charm.RedactedValue
+		}
+		if change.New != nil {
+			change.New = charm.RedactedValue
+		}
+		results.Changes[name] = change
+	}
+	return results, nil
+}
+
+// isSecret reports whether the named option is secret in any of
+// the given configs.
+func isSecret(configs []*charm.Config, name string) bool {
+	for _, config := range configs {
+		if config.Options[name].Secret() {
+			return true
+		}
+	}
+	return false
+}
+
+// diffSettings returns the changes to the options whose values differ
+// between the old and new settings.
+func diffSettings(old, new charm.Settings) map[string]params.ConfigChange {
+	changes := make(map[string]params.ConfigChange)
+	for name, oldValue := range old {
+		if newValue := new[name]; !reflect.DeepEqual(oldValue, newValue) {
+			changes[name] = params.ConfigChange{Old: oldValue, New: newValue}
+		}
+	}
+	for name, newValue := range new {
+		if _, ok := old[name]; !ok {
+			changes[name] = params.ConfigChange{New: newValue}
+		}
+	}
+	return changes
+}
+
+// ServiceConfigRollback restores a service's charm config settings to
+// those recorded in an earlier revision. The restored settings are
+// applied as an ordinary settings change, and so are themselves
+// recorded as a new revision.
+func (c *Client) ServiceConfigRollback(args params.ServiceConfigRollback) error {
+	service, err := c.api.state.Service(args.ServiceName)
+	if err != nil {
+		return err
+	}
+	rev, err := service.ConfigRevision(args.Revision)
+	if err != nil {
+		return err
+	}
+	// The recorded settings are only meaningful for the charm
+	// they were recorded against.
+	if curl, _ := service.CharmURL(); rev.CharmURL.String() != curl.String() {
+		return fmt.Errorf("cannot roll back to revision %d: it holds settings for charm %q, but the service uses %q", args.Revision, rev.CharmURL, curl)
+	}
+	current, err := service.ConfigSettings()
+	if err != nil {
+		return err
+	}
+	changes := make(charm.Settings)
+	for name := range current {
+		changes[name] = nil
+	}
+	for name, value := range rev.Settings {
+		changes[name] = value
+	}
+	if err := service.UpdateConfigSettingsBy(c.api.auth.GetAuthTag(), changes); err != nil {
+		return fmt.Errorf("cannot roll back to revision %d: %v", args.Revision, err)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/testing"
+)
+
+type configHistorySuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&configHistorySuite{})
+
+func (s *configHistorySuite) TestServiceConfigHistory(c *gc.C) {
+	svc := s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
+	client := s.APIState.Client()
+	err := client.ServiceSet("dummy", map[string]string{"title": "one"})
+	c.Assert(err, gc.IsNil)
+	err = client.ServiceUnset("dummy", []string{"title"})
+	c.Assert(err, gc.IsNil)
+
+	revisions, err := client.ServiceConfigHistory("dummy")
+	c.Assert(err, gc.IsNil)
+	c.Assert(revisions, gc.HasLen, 3)
+	curl, _ := svc.CharmURL()
+	for i, rev := range revisions {
+		c.Check(rev.Revision, gc.Equals, i+1)
+		c.Check(rev.Author, gc.Equals, "user-admin")
+		c.Check(rev.CharmURL, gc.Equals, curl.String())
+	}
+	c.Assert(revisions[0].Settings, gc.DeepEquals, map[string]interface{}{})
+	c.Assert(revisions[1].Settings, gc.DeepEquals, map[string]interface{}{"title": "one"})
+	c.Assert(revisions[2].Settings, gc.DeepEquals, map[string]interface{}{})
+}
+
+const secretConfig = `
+options:
+  title: {default: My Title, description: Desc, type: string}
+  password: {description: Desc, type: secret}
+`
+
+// addSecretCharm adds a charm with a secret option to the state,
+// with the given revision.
+func (s *configHistorySuite) addSecretCharm(c *gc.C, revision int) *state.Charm {
+	path := testing.Charms.ClonedDirPath(c.MkDir(), "dummy")
+	err := ioutil.WriteFile(filepath.Join(path, "config.yaml"), []byte(secretConfig), 0644)
+	c.Assert(err, gc.IsNil)
+	dir, err := charm.ReadDir(path)
+	c.Assert(err, gc.IsNil)
+	curl := charm.MustParseURL(fmt.Sprintf("local:quantal/dummy-%d", revision))
+	bundleURL, err := url.Parse("http://bundles.testing.invalid/" + curl.Path())
+	c.Assert(err, gc.IsNil)
+	ch, err := s.State.AddCharm(dir, curl, bundleURL, "sha256")
+	c.Assert(err, gc.IsNil)
+	return ch
+}
+
+func (s *configHistorySuite) TestServiceConfigDiff(c *gc.C) {
+	svc := s.AddTestingService(c, "dummy", s.addSecretCharm(c, 10))
+	err := svc.UpdateConfigSettings(charm.Settings{"title": "one", "password": "sekrit"})
+	c.Assert(err, gc.IsNil)
+	err = svc.UpdateConfigSettings(charm.Settings{"password": "sekrit2"})
+	c.Assert(err, gc.IsNil)
+	err = svc.UpdateConfigSettings(charm.Settings{"title": nil})
+	c.Assert(err, gc.IsNil)
+
+	client := s.APIState.Client()
+	diff, err := client.ServiceConfigDiff("dummy", 0, 2)
+	c.Assert(err, gc.IsNil)
+	c.Assert(diff, gc.DeepEquals, params.ServiceConfigDiffResults{
+		From: 1,
+		Changes: map[string]params.ConfigChange{
+			"title":    {New: "one"},
+			"password": {New: charm.RedactedValue},
+		},
+	})
+
+	// Changes to secret values are reported without revealing them.
+	diff, err = client.ServiceConfigDiff("dummy", 0, 3)
+	c.Assert(err, gc.IsNil)
+	c.Assert(diff.Changes, gc.DeepEquals, map[string]params.ConfigChange{
+		"password": {Old: charm.RedactedValue, New: charm.RedactedValue},
+	})
+
+	diff, err = client.ServiceConfigDiff("dummy", 2, 4)
+	c.Assert(err, gc.IsNil)
+	c.Assert(diff.Changes, gc.DeepEquals, map[string]params.ConfigChange{
+		"title":    {Old: "one"},
+		"password": {Old: charm.RedactedValue, New: charm.RedactedValue},
+	})
+
+	_, err = client.ServiceConfigDiff("dummy", 0, 7)
+	c.Assert(err, gc.ErrorMatches, `settings revision 7 for service "dummy" not found`)
+}
+
+func (s *configHistorySuite) TestServiceConfigHistoryUnknownService(c *gc.C) {
+	_, err := s.APIState.Client().ServiceConfigHistory("unknown")
+	c.Assert(err, gc.ErrorMatches, `service "unknown" not found`)
+}
+
+func (s *configHistorySuite) TestServiceConfigRollback(c *gc.C) {
+	svc := s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
+	err := svc.UpdateConfigSettings(charm.Settings{"title": "one", "username": "bob"})
+	c.Assert(err, gc.IsNil)
+	err = svc.UpdateConfigSettings(charm.Settings{"title": "two", "username": nil, "outlook": "fine"})
+	c.Assert(err, gc.IsNil)
+
+	err = s.APIState.Client().ServiceConfigRollback("dummy", 2)
+	c.Assert(err, gc.IsNil)
+	settings, err := svc.ConfigSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, gc.DeepEquals, charm.Settings{"title": "one", "username": "bob"})
+
+	revisions, err := svc.ConfigHistory()
+	c.Assert(err, gc.IsNil)
+	c.Assert(revisions, gc.HasLen, 4)
+	c.Assert(revisions[3].Author, gc.Equals, "user-admin")
+
+	err = s.APIState.Client().ServiceConfigRollback("dummy", 7)
+	c.Assert(err, gc.ErrorMatches, `settings revision 7 for service "dummy" not found`)
+}
+
+func (s *configHistorySuite) TestServiceConfigRollbackOtherCharm(c *gc.C) {
+	svc := s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
+	err := svc.UpdateConfigSettings(charm.Settings{"title": "one"})
+	c.Assert(err, gc.IsNil)
+	err = svc.SetCharm(s.addSecretCharm(c, 10), false)
+	c.Assert(err, gc.IsNil)
+
+	err = s.APIState.Client().ServiceConfigRollback("dummy", 2)
+	c.Assert(err, gc.ErrorMatches, `cannot roll back to revision 2: it holds settings for charm "local:quantal/dummy-1", but the service uses "local:quantal/dummy-10"`)
+}
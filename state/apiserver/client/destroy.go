@@ -13,7 +13,8 @@ import (
 )
 
 // DestroyEnvironment destroys all services and non-manager machine
-// instances in the environment.
+// instances in the environment. Hosted environments are then removed
+// from the state server hosting them.
 func (c *Client) DestroyEnvironment() error {
 	// TODO(axw) 2013-08-30 bug 1218688
 	//
@@ -66,6 +67,13 @@ func (c *Client) DestroyEnvironment() error {
 		return err
 	}
 
+	// Hosted environments have no state servers or other resources
+	// of their own for the provider to destroy, so they are removed
+	// here once their instances have been stopped.
+	if c.api.state.IsHosted() {
+		return c.removeHostedEnvironment(env.UUID())
+	}
+
 	// Return to the caller. If it's the CLI, it will finish up
 	// by calling the provider's Destroy method, which will
 	// destroy the state servers, any straggler instances, and
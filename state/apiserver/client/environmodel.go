@@ -0,0 +1,217 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+
+	"github.com/errgo/errgo"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/environs"
+	"launchpad.net/juju-core/environs/config"
+	"launchpad.net/juju-core/environs/storage"
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/state/apiserver/common"
+	"launchpad.net/juju-core/utils"
+)
+
+// EnvironmentExport returns a description of the complete
+// environment model, suitable for recreating the environment
+// on another state server. Since the model holds the provider
+// credentials and the password hashes of all users and agents,
+// only the admin user may export it.
+func (c *Client) EnvironmentExport() (params.EnvironmentModel, error) {
+	if c.api.auth.GetAuthTag() != "user-admin" {
+		return params.EnvironmentModel{}, common.ErrPerm
+	}
+	model, err := c.api.state.Export()
+	if err != nil {
+		return params.EnvironmentModel{}, err
+	}
+	return *model, nil
+}
+
+// openCharmBundle opens the charm bundle held at the given URL in
+// the storage of the environment a model was exported from.
+var openCharmBundle = func(bundleURL string) (io.ReadCloser, error) {
+	resp, err := http.Get(bundleURL)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("bad HTTP response: %v", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// ImportEnvironment recreates the environment described by the
+// given model as an environment hosted by the state server, keeping
+// its UUID so that its agents can be pointed at the state server.
+// The charms of the environment are copied from the storage of the
+// environment the model was exported from. Only the admin user may
+// import an environment.
+func (c *Client) ImportEnvironment(args params.ImportEnvironment) (result params.ImportEnvironmentResult, err error) {
+	if c.api.auth.GetAuthTag() != "user-admin" {
+		return result, common.ErrPerm
+	}
+	st := c.api.state
+	if st.IsHosted() {
+		return result, fmt.Errorf("environments can only be imported into the state server's environment")
+	}
+	model := args.Model
+	stateServerConfig, err := st.EnvironConfig()
+	if err != nil {
+		return result, err
+	}
+	if model.Config["type"] != stateServerConfig.Type() {
+		return result, fmt.Errorf("cannot import %v environment into %s state server", model.Config["type"], stateServerConfig.Type())
+	}
+	attrs := make(map[string]interface{})
+	for name, value := range model.Config {
+		attrs[name] = value
+	}
+	serverAttrs := stateServerConfig.AllAttrs()
+	for _, name := range stateServerAttrs {
+		if value, ok := serverAttrs[name]; ok {
+			attrs[name] = value
+		} else {
+			delete(attrs, name)
+		}
+	}
+	cfg, err := config.New(config.NoDefaults, attrs)
+	if err != nil {
+		return result, err
+	}
+	provider, err := environs.Provider(cfg.Type())
+	if err != nil {
+		return result, err
+	}
+	if cfg, err = provider.Validate(cfg, nil); err != nil {
+		return result, err
+	}
+	model.Config = cfg.AllAttrs()
+	// The charms are stored under the environment's UUID, so they
+	// must not be copied over those of an existing environment.
+	serverEnv, err := st.Environment()
+	if err != nil {
+		return result, err
+	}
+	if model.UUID == serverEnv.UUID() {
+		return result, fmt.Errorf("environment %q already exists", model.Name)
+	}
+	if _, err := st.HostedEnvironmentByUUID(model.UUID); err == nil {
+		return result, fmt.Errorf("environment %q already exists", model.Name)
+	} else if !errors.IsNotFoundError(err) {
+		return result, err
+	}
+
+	env, err := environs.New(stateServerConfig)
+	if err != nil {
+		return result, errgo.Annotate(err, "cannot access environment")
+	}
+	stor := env.Storage()
+	var uploaded []string
+	defer func() {
+		if err == nil {
+			return
+		}
+		for _, name := range uploaded {
+			if err := stor.Remove(name); err != nil {
+				logger.Warningf("cannot remove charm %q from storage: %v", name, err)
+			}
+		}
+	}()
+	charms := make(map[string]state.ImportedCharm)
+	for _, m := range model.Charms {
+		name := path.Join(model.UUID, charm.Quote(m.URL))
+		imported, err := importCharm(stor, name, m)
+		if err != nil {
+			return result, errgo.Annotatef(err, "cannot import charm %q", m.URL)
+		}
+		uploaded = append(uploaded, name)
+		charms[m.URL] = *imported
+	}
+	hosted, err := st.ImportEnvironment(&model, charms, c.api.auth.GetAuthTag())
+	if err != nil {
+		return result, err
+	}
+	result.EnvironTag = hosted.Tag()
+	return result, nil
+}
+
+// importCharm copies the bundle of the given charm into the storage
+// under the given name, checking it against the hash in the model.
+func importCharm(stor storage.Storage, name string, m params.CharmModel) (*state.ImportedCharm, error) {
+	r, err := openCharmBundle(m.BundleURL)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	f, err := ioutil.TempFile("", "charm")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if _, err := io.Copy(f, r); err != nil {
+		return nil, errgo.Annotate(err, "cannot download charm")
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	bundleSHA256, size, err := utils.ReadSHA256(f)
+	if err != nil {
+		return nil, errgo.Annotate(err, "cannot calculate SHA256 hash of charm")
+	}
+	if bundleSHA256 != m.BundleSha256 {
+		return nil, fmt.Errorf("SHA256 hash mismatch: expected %s, got %s", m.BundleSha256, bundleSHA256)
+	}
+	bundle, err := charm.ReadBundle(f.Name())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	if err := stor.Put(name, f, size); err != nil {
+		return nil, errgo.Annotate(err, "cannot upload charm to provider storage")
+	}
+	storageURL, err := stor.URL(name)
+	if err != nil {
+		return nil, errgo.Annotate(err, "cannot get storage URL for charm")
+	}
+	bundleURL, err := url.Parse(storageURL)
+	if err != nil {
+		return nil, errgo.Annotate(err, "cannot parse storage URL")
+	}
+	return &state.ImportedCharm{
+		Charm:     bundle,
+		BundleURL: bundleURL,
+	}, nil
+}
+
+// SetMigrationTarget records that the environment has been imported
+// into the state server at the given API addresses, so that its
+// agents point themselves at that state server. Only the admin user
+// may set it.
+func (c *Client) SetMigrationTarget(args params.SetMigrationTarget) error {
+	if c.api.auth.GetAuthTag() != "user-admin" {
+		return common.ErrPerm
+	}
+	env, err := c.api.state.Environment()
+	if err != nil {
+		return err
+	}
+	return env.SetMigrationTarget(args.Addrs, args.CACert)
+}
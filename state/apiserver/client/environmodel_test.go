@@ -0,0 +1,137 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+
+	gc "launchpad.net/gocheck"
+
+	coreerrors "launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/state/apiserver/client"
+	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/utils"
+)
+
+type environModelSuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&environModelSuite{})
+
+func (s *environModelSuite) TestEnvironmentExport(c *gc.C) {
+	s.setUpScenario(c)
+	expected, err := s.State.Export()
+	c.Assert(err, gc.IsNil)
+
+	model, err := s.APIState.Client().EnvironmentExport()
+	c.Assert(err, gc.IsNil)
+	c.Assert(model.Version, gc.Equals, params.EnvironmentModelVersion)
+	c.Assert(model.UUID, gc.Equals, expected.UUID)
+	c.Assert(model.Machines, gc.HasLen, len(expected.Machines))
+	c.Assert(model.Services, gc.HasLen, len(expected.Services))
+	c.Assert(model.Relations, gc.HasLen, len(expected.Relations))
+	for i, m := range model.Machines {
+		c.Check(m.Id, gc.Equals, expected.Machines[i].Id)
+		c.Check(m.InstanceId, gc.Equals, expected.Machines[i].InstanceId)
+	}
+}
+
+// importableModel returns the environment exported through the API,
+// renamed and with a new UUID so that it can be imported next to the
+// environment it was exported from.
+func (s *environModelSuite) importableModel(c *gc.C) *params.EnvironmentModel {
+	model, err := s.APIState.Client().EnvironmentExport()
+	c.Assert(err, gc.IsNil)
+	uuid, err := utils.NewUUID()
+	c.Assert(err, gc.IsNil)
+	model.Name = "imported"
+	model.UUID = uuid.String()
+	model.Config["name"] = "imported"
+	return model
+}
+
+func (s *environModelSuite) TestImportEnvironment(c *gc.C) {
+	s.setUpScenario(c)
+	model := s.importableModel(c)
+
+	tag, err := s.APIState.Client().ImportEnvironment(model)
+	c.Assert(err, gc.IsNil)
+	_, uuid, err := names.ParseTag(tag, names.EnvironTagKind)
+	c.Assert(err, gc.IsNil)
+	c.Assert(uuid, gc.Equals, model.UUID)
+	st, err := s.State.OpenHostedEnvironment(uuid)
+	c.Assert(err, gc.IsNil)
+	defer st.Close()
+
+	imported, err := st.Export()
+	c.Assert(err, gc.IsNil)
+	c.Assert(imported.Name, gc.Equals, "imported")
+	c.Assert(imported.Services, jc.DeepEquals, model.Services)
+	c.Assert(imported.Relations, jc.DeepEquals, model.Relations)
+	// The state server machine is left behind.
+	c.Assert(imported.Machines, jc.DeepEquals, model.Machines[1:])
+
+	// The charms are copied into the environment's own storage.
+	c.Assert(imported.Charms, gc.HasLen, len(model.Charms))
+	for i, ch := range imported.Charms {
+		c.Check(ch.URL, gc.Equals, model.Charms[i].URL)
+		c.Check(ch.BundleSha256, gc.Equals, model.Charms[i].BundleSha256)
+		c.Check(ch.BundleURL, gc.Not(gc.Equals), model.Charms[i].BundleURL)
+		c.Check(ch.BundleURL, jc.Contains, uuid)
+	}
+}
+
+func (s *environModelSuite) TestImportEnvironmentBadCharm(c *gc.C) {
+	s.setUpScenario(c)
+	model := s.importableModel(c)
+	s.PatchValue(client.OpenCharmBundle, func(string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader("not a charm")), nil
+	})
+
+	_, err := s.APIState.Client().ImportEnvironment(model)
+	c.Assert(err, gc.ErrorMatches, `cannot import charm ".*": SHA256 hash mismatch: .*`)
+	_, err = s.State.HostedEnvironment("imported")
+	c.Assert(err, jc.Satisfies, coreerrors.IsNotFoundError)
+}
+
+func (s *environModelSuite) TestImportEnvironmentTypeMismatch(c *gc.C) {
+	model := s.importableModel(c)
+	model.Config["type"] = "ec2"
+	_, err := s.APIState.Client().ImportEnvironment(model)
+	c.Assert(err, gc.ErrorMatches, "cannot import ec2 environment into dummy state server")
+}
+
+func (s *environModelSuite) TestImportEnvironmentPerm(c *gc.C) {
+	s.setUpScenario(c)
+	model := s.importableModel(c)
+	st := s.openAs(c, "user-other")
+	defer st.Close()
+	_, err := st.Client().ImportEnvironment(model)
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
+
+func (s *environModelSuite) TestSetMigrationTarget(c *gc.C) {
+	err := s.APIState.Client().SetMigrationTarget([]string{"10.0.0.1:17070"}, "ca-cert")
+	c.Assert(err, gc.IsNil)
+	env, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	addrs, caCert, ok := env.MigrationTarget()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(addrs, gc.DeepEquals, []string{"10.0.0.1:17070"})
+	c.Assert(caCert, gc.Equals, "ca-cert")
+}
+
+func (s *environModelSuite) TestImportEnvironmentUUIDInUse(c *gc.C) {
+	model, err := s.APIState.Client().EnvironmentExport()
+	c.Assert(err, gc.IsNil)
+	model.Name = "imported"
+	model.Config["name"] = "imported"
+	_, err = s.APIState.Client().ImportEnvironment(model)
+	c.Assert(err, gc.ErrorMatches, `environment "imported" already exists`)
+}
@@ -46,6 +46,15 @@ func describe(settings charm.Settings, config *charm.Config) map[string]interfac
 			"description": option.Description,
 			"type":        option.Type,
 		}
+		if option.Values != nil {
+			info["values"] = option.Values
+		}
+		if option.Min != nil {
+			info["min"] = option.Min
+		}
+		if option.Max != nil {
+			info["max"] = option.Max
+		}
 		if value := settings[name]; value != nil {
 			info["value"] = value
 		} else {
@@ -54,6 +63,9 @@ func describe(settings charm.Settings, config *charm.Config) map[string]interfac
 			}
 			info["default"] = true
 		}
+		if _, ok := info["value"]; ok && option.Secret() {
+			info["value"] = charm.RedactedValue
+		}
 		results[name] = info
 	}
 	return results
@@ -5,12 +5,14 @@ package client_test
 
 import (
 	"fmt"
+	"strings"
 
 	gc "launchpad.net/gocheck"
 
 	"launchpad.net/juju-core/charm"
 	"launchpad.net/juju-core/constraints"
 	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/state/apiserver/client"
 )
 
 type getSuite struct {
@@ -195,3 +197,53 @@ func (s *getSuite) TestServiceGetCharmURL(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	c.Assert(charmURL.String(), gc.Equals, "local:quantal/wordpress-3")
 }
+
+func (s *getSuite) TestDescribeTypedOptions(c *gc.C) {
+	config, err := charm.ReadConfig(strings.NewReader(`
+options:
+  flavour:
+    description: The flavour of the service.
+    type: enum
+    values: [vanilla, chocolate]
+    default: vanilla
+  workers:
+    description: Number of worker processes.
+    type: int
+    min: 1
+    max: 16
+  password:
+    description: The administrator password.
+    type: secret
+  token:
+    description: An unset secret.
+    type: secret
+`))
+	c.Assert(err, gc.IsNil)
+	info := client.Describe(charm.Settings{"workers": int64(4), "password": "sekrit"}, config)
+	c.Assert(info, gc.DeepEquals, map[string]interface{}{
+		"flavour": map[string]interface{}{
+			"description": "The flavour of the service.",
+			"type":        "enum",
+			"values":      []string{"vanilla", "chocolate"},
+			"value":       "vanilla",
+			"default":     true,
+		},
+		"workers": map[string]interface{}{
+			"description": "Number of worker processes.",
+			"type":        "int",
+			"min":         int64(1),
+			"max":         int64(16),
+			"value":       int64(4),
+		},
+		"password": map[string]interface{}{
+			"description": "The administrator password.",
+			"type":        "secret",
+			"value":       charm.RedactedValue,
+		},
+		"token": map[string]interface{}{
+			"description": "An unset secret.",
+			"type":        "secret",
+			"default":     true,
+		},
+	})
+}
@@ -0,0 +1,131 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"sort"
+	"time"
+
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// maxSlowestHooks holds the number of slowest hook executions
+// reported for each service.
+const maxSlowestHooks = 5
+
+// HookStats returns statistics on the hooks recently executed by the
+// units of the given services, or of all services if none are given.
+func (c *Client) HookStats(args params.HookStats) (params.HookStatsResults, error) {
+	var results params.HookStatsResults
+	var services []*state.Service
+	if len(args.ServiceNames) == 0 {
+		var err error
+		if services, err = c.api.state.AllServices(); err != nil {
+			return results, err
+		}
+	} else {
+		for _, name := range args.ServiceNames {
+			service, err := c.api.state.Service(name)
+			if err != nil {
+				return results, err
+			}
+			services = append(services, service)
+		}
+	}
+	sort.Sort(servicesByName(services))
+	results.Services = make([]params.ServiceHookStats, len(services))
+	for i, service := range services {
+		units, err := service.HookStats()
+		if err != nil {
+			return results, err
+		}
+		results.Services[i] = serviceHookStats(service.Name(), units)
+	}
+	return results, nil
+}
+
+// serviceHookStats summarizes the hook executions of a service's units.
+func serviceHookStats(serviceName string, units []state.UnitHookStats) params.ServiceHookStats {
+	stats := params.ServiceHookStats{
+		ServiceName: serviceName,
+		Running:     []params.UnitHookExecution{},
+		Hooks:       []params.HookTiming{},
+	}
+	timings := make(map[string]*params.HookTiming)
+	var executions []params.UnitHookExecution
+	for _, unit := range units {
+		stats.QueueDepth += unit.Queued()
+		if unit.Running != nil {
+			stats.UnitsRunning++
+			stats.Running = append(stats.Running, unitHookExecution(unit.UnitName, *unit.Running))
+		}
+		for _, exec := range unit.Executions {
+			timing, ok := timings[exec.Hook]
+			if !ok {
+				timing = &params.HookTiming{Hook: exec.Hook}
+				timings[exec.Hook] = timing
+			}
+			duration := exec.Duration()
+			timing.Runs++
+			if exec.Failed() {
+				timing.Failures++
+			}
+			// MeanDuration accumulates the total until all
+			// executions have been seen.
+			timing.MeanDuration += duration
+			if duration > timing.MaxDuration {
+				timing.MaxDuration = duration
+			}
+			executions = append(executions, unitHookExecution(unit.UnitName, exec))
+		}
+	}
+	for _, timing := range timings {
+		timing.MeanDuration /= time.Duration(timing.Runs)
+		stats.Hooks = append(stats.Hooks, *timing)
+	}
+	sort.Sort(hookTimingsByName(stats.Hooks))
+	sort.Sort(executionsByDuration(executions))
+	if len(executions) > maxSlowestHooks {
+		executions = executions[:maxSlowestHooks]
+	}
+	stats.Slowest = append([]params.UnitHookExecution{}, executions...)
+	return stats
+}
+
+func unitHookExecution(unitName string, exec state.HookExecution) params.UnitHookExecution {
+	return params.UnitHookExecution{
+		UnitName: unitName,
+		Hook:     exec.Hook,
+		Started:  exec.Started,
+		Duration: exec.Duration(),
+		ExitCode: exec.ExitCode,
+		Error:    exec.Error,
+	}
+}
+
+type servicesByName []*state.Service
+
+func (s servicesByName) Len() int           { return len(s) }
+func (s servicesByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s servicesByName) Less(i, j int) bool { return s[i].Name() < s[j].Name() }
+
+type hookTimingsByName []params.HookTiming
+
+func (s hookTimingsByName) Len() int           { return len(s) }
+func (s hookTimingsByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s hookTimingsByName) Less(i, j int) bool { return s[i].Hook < s[j].Hook }
+
+// executionsByDuration sorts hook executions slowest first; executions
+// of equal duration are ordered by start time.
+type executionsByDuration []params.UnitHookExecution
+
+func (s executionsByDuration) Len() int      { return len(s) }
+func (s executionsByDuration) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s executionsByDuration) Less(i, j int) bool {
+	if s[i].Duration != s[j].Duration {
+		return s[i].Duration > s[j].Duration
+	}
+	return s[i].Started.Before(s[j].Started)
+}
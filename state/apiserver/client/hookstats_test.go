@@ -0,0 +1,114 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	jc "launchpad.net/juju-core/testing/checkers"
+)
+
+type hookStatsSuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&hookStatsSuite{})
+
+func (s *hookStatsSuite) recordExecutions(c *gc.C, unit *state.Unit, execs ...state.HookExecution) {
+	for _, exec := range execs {
+		err := unit.RecordHookExecution(exec)
+		c.Assert(err, gc.IsNil)
+	}
+}
+
+func hookRun(hook string, started, seconds int64, code int) state.HookExecution {
+	exec := state.HookExecution{
+		Hook:     hook,
+		Started:  time.Unix(started, 0),
+		ExitCode: code,
+	}
+	if seconds >= 0 {
+		exec.Finished = time.Unix(started+seconds, 0)
+	}
+	return exec
+}
+
+func (s *hookStatsSuite) TestHookStats(c *gc.C) {
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	unit0, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	unit1, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+
+	queued := func(exec state.HookExecution, n int) state.HookExecution {
+		exec.Queued = n
+		return exec
+	}
+	s.recordExecutions(c, unit0,
+		hookRun("install", 100, 10, 0),
+		hookRun("config-changed", 110, 4, 1),
+		queued(hookRun("config-changed", 120, 2, 0), 2),
+	)
+	s.recordExecutions(c, unit1,
+		hookRun("install", 100, 30, 0),
+		queued(hookRun("config-changed", 130, -1, 0), 3),
+	)
+
+	results, err := s.APIState.Client().HookStats()
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 2)
+	c.Assert(results[0].ServiceName, gc.Equals, "mysql")
+	c.Assert(results[0].UnitsRunning, gc.Equals, 0)
+	c.Assert(results[0].QueueDepth, gc.Equals, 0)
+	c.Assert(results[0].Hooks, gc.HasLen, 0)
+
+	stats := results[1]
+	c.Assert(stats.ServiceName, gc.Equals, "wordpress")
+	c.Assert(stats.UnitsRunning, gc.Equals, 1)
+	c.Assert(stats.QueueDepth, gc.Equals, 5)
+	c.Assert(stats.Running, gc.HasLen, 1)
+	c.Assert(stats.Running[0].UnitName, gc.Equals, "wordpress/1")
+	c.Assert(stats.Running[0].Hook, gc.Equals, "config-changed")
+	c.Assert(stats.Hooks, gc.DeepEquals, []params.HookTiming{{
+		Hook:         "config-changed",
+		Runs:         2,
+		Failures:     1,
+		MeanDuration: 3 * time.Second,
+		MaxDuration:  4 * time.Second,
+	}, {
+		Hook:         "install",
+		Runs:         2,
+		MeanDuration: 20 * time.Second,
+		MaxDuration:  30 * time.Second,
+	}})
+	c.Assert(stats.Slowest, gc.HasLen, 4)
+	var units, hooks []string
+	for _, exec := range stats.Slowest {
+		units = append(units, exec.UnitName)
+		hooks = append(hooks, exec.Hook)
+	}
+	c.Assert(units, gc.DeepEquals, []string{"wordpress/1", "wordpress/0", "wordpress/0", "wordpress/0"})
+	c.Assert(hooks, gc.DeepEquals, []string{"install", "install", "config-changed", "config-changed"})
+	c.Assert(stats.Slowest[0].Started.Equal(time.Unix(100, 0)), jc.IsTrue)
+	c.Assert(stats.Slowest[2].ExitCode, gc.Equals, 1)
+}
+
+func (s *hookStatsSuite) TestHookStatsNamedServices(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	results, err := s.APIState.Client().HookStats("wordpress")
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 1)
+	c.Assert(results[0].ServiceName, gc.Equals, "wordpress")
+}
+
+func (s *hookStatsSuite) TestHookStatsUnknownService(c *gc.C) {
+	_, err := s.APIState.Client().HookStats("unknown")
+	c.Assert(err, gc.ErrorMatches, `service "unknown" not found`)
+}
@@ -0,0 +1,77 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"fmt"
+
+	"launchpad.net/juju-core/environs"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// stateServerAttrs holds the configuration attributes that hosted
+// environments must share with the state server's environment,
+// whose state server they are managed by.
+var stateServerAttrs = []string{
+	"type",
+	"ca-cert",
+	"state-port",
+	"api-port",
+	"agent-version",
+}
+
+// CreateEnvironment creates an environment hosted by the state server
+// of the environment, with no machines. Its configuration is that of
+// the state server's environment, changed by the given attributes;
+// webhooks are not inherited.
+func (c *Client) CreateEnvironment(args params.CreateEnvironment) (params.CreateEnvironmentResult, error) {
+	var result params.CreateEnvironmentResult
+	st := c.api.state
+	if st.IsHosted() {
+		return result, fmt.Errorf("environments can only be created in the state server's environment")
+	}
+	for _, name := range stateServerAttrs {
+		if _, ok := args.Config[name]; ok {
+			return result, fmt.Errorf("%s cannot be set for a hosted environment", name)
+		}
+	}
+	oldConfig, err := st.EnvironConfig()
+	if err != nil {
+		return result, err
+	}
+	attrs := map[string]interface{}{
+		"webhooks": "",
+	}
+	for name, value := range args.Config {
+		attrs[name] = value
+	}
+	attrs["name"] = args.Name
+	cfg, err := oldConfig.Apply(attrs)
+	if err != nil {
+		return result, err
+	}
+	provider, err := environs.Provider(cfg.Type())
+	if err != nil {
+		return result, err
+	}
+	if cfg, err = provider.Validate(cfg, nil); err != nil {
+		return result, err
+	}
+	env, err := st.AddHostedEnvironment(cfg, c.api.auth.GetAuthTag(), args.AdminPassword)
+	if err != nil {
+		return result, err
+	}
+	result.EnvironTag = env.Tag()
+	return result, nil
+}
+
+// removeHostedEnvironment removes the destroyed hosted environment
+// with the given UUID, once its instances have been stopped.
+func (c *Client) removeHostedEnvironment(uuid string) error {
+	env, err := c.api.state.HostedEnvironmentByUUID(uuid)
+	if err != nil {
+		return err
+	}
+	return env.Remove()
+}
@@ -0,0 +1,123 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	coreerrors "launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/instance"
+	"launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api"
+	jc "launchpad.net/juju-core/testing/checkers"
+)
+
+type hostedEnvironmentSuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&hostedEnvironmentSuite{})
+
+func (s *hostedEnvironmentSuite) createEnvironment(c *gc.C, name string) (*state.HostedEnvironment, *api.State) {
+	tag, err := s.APIState.Client().CreateEnvironment(name, map[string]interface{}{"default-series": "precise"}, "hosted-secret")
+	c.Assert(err, gc.IsNil)
+	_, uuid, err := names.ParseTag(tag, names.EnvironTagKind)
+	c.Assert(err, gc.IsNil)
+	env, err := s.State.HostedEnvironmentByUUID(uuid)
+	c.Assert(err, gc.IsNil)
+	c.Assert(env.Name(), gc.Equals, name)
+	c.Assert(env.Owner(), gc.Equals, "user-admin")
+
+	info := s.APIInfo(c)
+	info.Password = "hosted-secret"
+	info.EnvironTag = tag
+	st, err := api.Open(info, api.DialOpts{})
+	c.Assert(err, gc.IsNil)
+	s.AddCleanup(func(*gc.C) { st.Close() })
+	return env, st
+}
+
+func (s *hostedEnvironmentSuite) TestCreateEnvironment(c *gc.C) {
+	env, st := s.createEnvironment(c, "hosted")
+
+	info, err := st.Client().EnvironmentInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Name, gc.Equals, "hosted")
+	c.Assert(info.UUID, gc.Equals, env.UUID())
+	attrs, err := st.Client().EnvironmentGet()
+	c.Assert(err, gc.IsNil)
+	c.Assert(attrs["default-series"], gc.Equals, "precise")
+	cfg, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	c.Assert(attrs["type"], gc.Equals, cfg.Type())
+
+	// No machines are started for the new environment.
+	status, err := st.Client().Status(nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(status.Machines, gc.HasLen, 0)
+}
+
+func (s *hostedEnvironmentSuite) TestCreateEnvironmentStateServerAttrs(c *gc.C) {
+	_, err := s.APIState.Client().CreateEnvironment("hosted", map[string]interface{}{"api-port": 1234}, "secret")
+	c.Assert(err, gc.ErrorMatches, "api-port cannot be set for a hosted environment")
+}
+
+func (s *hostedEnvironmentSuite) TestCreateEnvironmentFromHosted(c *gc.C) {
+	_, st := s.createEnvironment(c, "hosted")
+	_, err := st.Client().CreateEnvironment("nested", nil, "secret")
+	c.Assert(err, gc.ErrorMatches, "environments can only be created in the state server's environment")
+}
+
+func (s *hostedEnvironmentSuite) TestHostedEnvironmentIsolation(c *gc.C) {
+	env, st := s.createEnvironment(c, "hosted")
+	_, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+
+	status, err := st.Client().Status(nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(status.Machines, gc.HasLen, 0)
+	c.Assert(status.Services, gc.HasLen, 0)
+	_, err = st.Client().ServiceGet("wordpress")
+	c.Assert(err, gc.ErrorMatches, `service "wordpress" not found`)
+
+	// The state server environment's admin cannot log in to the
+	// hosted environment with their own password.
+	info := s.APIInfo(c)
+	info.EnvironTag = env.Tag()
+	_, err = api.Open(info, api.DialOpts{})
+	c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+}
+
+func (s *hostedEnvironmentSuite) TestDestroyHostedEnvironment(c *gc.C) {
+	env, st := s.createEnvironment(c, "hosted")
+	hst, err := s.State.OpenHostedEnvironment(env.UUID())
+	c.Assert(err, gc.IsNil)
+	defer hst.Close()
+	m, err := hst.AddMachine("precise", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	inst, _ := testing.AssertStartInstance(c, s.APIConn.Environ, m.Id())
+	err = m.SetProvisioned(inst.Id(), "fake_nonce", nil)
+	c.Assert(err, gc.IsNil)
+
+	err = st.Client().DestroyEnvironment()
+	c.Assert(err, gc.IsNil)
+
+	_, err = s.State.HostedEnvironment("hosted")
+	c.Assert(err, jc.Satisfies, coreerrors.IsNotFoundError)
+	_, err = s.APIConn.Environ.Instances([]instance.Id{inst.Id()})
+	c.Assert(err, gc.NotNil)
+	// The state server's environment is untouched.
+	environ, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	c.Assert(environ.Life(), gc.Equals, state.Alive)
+}
+
+func (s *hostedEnvironmentSuite) TestDestroyStateServerEnvironment(c *gc.C) {
+	s.createEnvironment(c, "hosted")
+	err := s.APIState.Client().DestroyEnvironment()
+	c.Assert(err, gc.ErrorMatches, `environment hosts 1 other environment\(s\); destroy them first`)
+}
@@ -0,0 +1,37 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// ServiceMetrics returns the batches of metrics collected from the
+// units of the given service, oldest first.
+func (c *Client) ServiceMetrics(args params.ServiceMetrics) (params.ServiceMetricsResults, error) {
+	var results params.ServiceMetricsResults
+	service, err := c.api.state.Service(args.ServiceName)
+	if err != nil {
+		return results, err
+	}
+	batches, err := service.MetricBatches()
+	if err != nil {
+		return results, err
+	}
+	results.Batches = make([]params.UnitMetricBatch, len(batches))
+	for i, batch := range batches {
+		metrics := batch.Metrics()
+		result := params.UnitMetricBatch{
+			UnitName: batch.Unit(),
+			CharmURL: batch.CharmURL(),
+			Created:  batch.Created(),
+			Metrics:  make([]params.Metric, len(metrics)),
+		}
+		for j, m := range metrics {
+			result.Metrics[j] = params.Metric{Key: m.Key, Value: m.Value, Time: m.Time}
+		}
+		results.Batches[i] = result
+	}
+	return results, nil
+}
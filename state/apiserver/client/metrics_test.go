@@ -0,0 +1,55 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	jc "launchpad.net/juju-core/testing/checkers"
+)
+
+type metricsSuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&metricsSuite{})
+
+func (s *metricsSuite) TestServiceMetrics(c *gc.C) {
+	ch := s.AddTestingCharm(c, "metered")
+	svc := s.AddTestingService(c, "metered", ch)
+	unit0, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	unit1, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+
+	_, err = unit0.AddMetrics("a3d5a9a8-3e8d-4b3c-8c1e-3cce5c0e0d6f", time.Unix(200, 0), ch.URL().String(),
+		[]state.Metric{{Key: "users", Value: "5", Time: time.Unix(199, 0)}})
+	c.Assert(err, gc.IsNil)
+	_, err = unit1.AddMetrics("0f7e3ab6-4f5c-4d2e-9b7a-6a1c2d3e4f50", time.Unix(100, 0), ch.URL().String(),
+		[]state.Metric{{Key: "requests", Value: "42", Time: time.Unix(99, 0)}})
+	c.Assert(err, gc.IsNil)
+
+	batches, err := s.APIState.Client().ServiceMetrics("metered")
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, jc.DeepEquals, []params.UnitMetricBatch{{
+		UnitName: "metered/1",
+		CharmURL: ch.URL().String(),
+		Created:  time.Unix(100, 0),
+		Metrics:  []params.Metric{{Key: "requests", Value: "42", Time: time.Unix(99, 0)}},
+	}, {
+		UnitName: "metered/0",
+		CharmURL: ch.URL().String(),
+		Created:  time.Unix(200, 0),
+		Metrics:  []params.Metric{{Key: "users", Value: "5", Time: time.Unix(199, 0)}},
+	}})
+}
+
+func (s *metricsSuite) TestServiceMetricsUnknownService(c *gc.C) {
+	_, err := s.APIState.Client().ServiceMetrics("unknown")
+	c.Assert(err, gc.ErrorMatches, `service "unknown" not found`)
+}
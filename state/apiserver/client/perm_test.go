@@ -92,6 +92,10 @@ var operationPermTests = []struct {
 	about: "Client.DestroyServiceUnits",
 	op:    opClientDestroyServiceUnits,
 	allow: []string{"user-admin", "user-other"},
+}, {
+	about: "Client.MigrateUnit",
+	op:    opClientMigrateUnit,
+	allow: []string{"user-admin", "user-other"},
 }, {
 	about: "Client.ServiceDestroy",
 	op:    opClientServiceDestroy,
@@ -136,6 +140,14 @@ var operationPermTests = []struct {
 	about: "Client.DestroyRelation",
 	op:    opClientDestroyRelation,
 	allow: []string{"user-admin", "user-other"},
+}, {
+	about: "Client.EnvironmentExport",
+	op:    opClientEnvironmentExport,
+	allow: []string{"user-admin"},
+}, {
+	about: "Client.SetMigrationTarget",
+	op:    opClientSetMigrationTarget,
+	allow: []string{"user-admin"},
 }}
 
 // allowed returns the set of allowed entities given an allow list and a
@@ -352,6 +364,14 @@ func opClientAddServiceUnits(c *gc.C, st *api.State, mst *state.State) (func(),
 	return func() {}, err
 }
 
+func opClientMigrateUnit(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, err := st.Client().MigrateUnit("wordpress/99", "0")
+	if params.IsCodeNotFound(err) {
+		err = nil
+	}
+	return func() {}, err
+}
+
 func opClientDestroyServiceUnits(c *gc.C, st *api.State, mst *state.State) (func(), error) {
 	err := st.Client().DestroyServiceUnits("wordpress/99")
 	if err != nil && strings.HasPrefix(err.Error(), "no units were destroyed") {
@@ -411,6 +431,22 @@ func opClientEnvironmentSet(c *gc.C, st *api.State, mst *state.State) (func(), e
 	}, nil
 }
 
+func opClientEnvironmentExport(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, err := st.Client().EnvironmentExport()
+	if err != nil {
+		return func() {}, err
+	}
+	return func() {}, nil
+}
+
+func opClientSetMigrationTarget(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	err := st.Client().SetMigrationTarget([]string{"10.0.0.1:17070"}, "ca-cert")
+	if err != nil {
+		return func() {}, err
+	}
+	return func() {}, nil
+}
+
 func opClientSetEnvironAgentVersion(c *gc.C, st *api.State, mst *state.State) (func(), error) {
 	attrs, err := st.Client().EnvironmentGet()
 	if err != nil {
@@ -0,0 +1,112 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// RelationData returns the settings and scope of every unit in the
+// given relations, read directly from the relation settings in state.
+func (c *Client) RelationData(args params.RelationData) (params.RelationDataResults, error) {
+	var results params.RelationDataResults
+	relations, err := c.findRelations(args.Relation)
+	if err != nil {
+		return results, err
+	}
+	results.Relations = make([]params.RelationDataResult, len(relations))
+	for i, rel := range relations {
+		if results.Relations[i], err = relationData(rel); err != nil {
+			return params.RelationDataResults{}, err
+		}
+	}
+	return results, nil
+}
+
+// findRelations returns the relations identified by spec, which holds
+// a relation id, optionally prefixed by an endpoint name; a service
+// endpoint; or a service name.
+func (c *Client) findRelations(spec string) ([]*state.Relation, error) {
+	idSpec, serviceName, endpointName := spec, spec, ""
+	if i := strings.Index(spec, ":"); i != -1 {
+		serviceName, endpointName = spec[:i], spec[i+1:]
+		idSpec = endpointName
+	}
+	if id, err := strconv.Atoi(idSpec); err == nil {
+		rel, err := c.api.state.Relation(id)
+		if err != nil {
+			return nil, err
+		}
+		return []*state.Relation{rel}, nil
+	}
+	if !names.IsService(serviceName) {
+		return nil, fmt.Errorf("invalid relation %q", spec)
+	}
+	service, err := c.api.state.Service(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	all, err := service.Relations()
+	if err != nil {
+		return nil, err
+	}
+	if endpointName == "" {
+		return all, nil
+	}
+	var relations []*state.Relation
+	for _, rel := range all {
+		ep, err := rel.Endpoint(serviceName)
+		if err != nil {
+			return nil, err
+		}
+		if ep.Name == endpointName {
+			relations = append(relations, rel)
+		}
+	}
+	if len(relations) == 0 {
+		return nil, fmt.Errorf("service %q has no relations on endpoint %q", serviceName, endpointName)
+	}
+	return relations, nil
+}
+
+// relationData returns the unit data of the given relation, grouped
+// by endpoint.
+func relationData(rel *state.Relation) (params.RelationDataResult, error) {
+	result := params.RelationDataResult{
+		RelationId: rel.Id(),
+		Key:        rel.String(),
+	}
+	units, err := rel.UnitData()
+	if err != nil {
+		return result, err
+	}
+	for _, ep := range rel.Endpoints() {
+		epData := params.RelationEndpointData{
+			ServiceName: ep.ServiceName,
+			Endpoint:    ep.Name,
+			Role:        string(ep.Role),
+			Units:       []params.RelationUnitData{},
+		}
+		for _, unit := range units {
+			serviceName := names.UnitService(unit.UnitName)
+			if serviceName != ep.ServiceName || unit.Role != ep.Role {
+				continue
+			}
+			epData.Units = append(epData.Units, params.RelationUnitData{
+				UnitName: unit.UnitName,
+				InScope:  unit.InScope,
+				Settings: unit.Settings,
+				Version:  unit.Version,
+			})
+		}
+		result.Endpoints = append(result.Endpoints, epData)
+	}
+	return result, nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+type relationDataSuite struct {
+	baseSuite
+	rel *state.Relation
+}
+
+var _ = gc.Suite(&relationDataSuite{})
+
+func (s *relationDataSuite) SetUpTest(c *gc.C) {
+	s.baseSuite.SetUpTest(c)
+	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	eps, err := s.State.InferEndpoints([]string{"wordpress", "mysql"})
+	c.Assert(err, gc.IsNil)
+	s.rel, err = s.State.AddRelation(eps...)
+	c.Assert(err, gc.IsNil)
+	s.enterScope(c, mysql, map[string]interface{}{"user": "admin"})
+	s.enterScope(c, wordpress, map[string]interface{}{"url": "http://example.com"})
+}
+
+func (s *relationDataSuite) enterScope(c *gc.C, svc *state.Service, settings map[string]interface{}) {
+	unit, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	ru, err := s.rel.Unit(unit)
+	c.Assert(err, gc.IsNil)
+	err = ru.EnterScope(settings)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *relationDataSuite) TestRelationData(c *gc.C) {
+	expected := []params.RelationEndpointData{{
+		ServiceName: "wordpress",
+		Endpoint:    "db",
+		Role:        "requirer",
+		Units: []params.RelationUnitData{{
+			UnitName: "wordpress/0",
+			InScope:  true,
+			Settings: map[string]interface{}{"url": "http://example.com"},
+		}},
+	}, {
+		ServiceName: "mysql",
+		Endpoint:    "server",
+		Role:        "provider",
+		Units: []params.RelationUnitData{{
+			UnitName: "mysql/0",
+			InScope:  true,
+			Settings: map[string]interface{}{"user": "admin"},
+		}},
+	}}
+	for i, spec := range []string{"0", "db:0", "mysql:server", "wordpress"} {
+		c.Logf("test %d: %s", i, spec)
+		results, err := s.APIState.Client().RelationData(spec)
+		c.Assert(err, gc.IsNil)
+		c.Assert(results, gc.HasLen, 1)
+		c.Assert(results[0].RelationId, gc.Equals, s.rel.Id())
+		c.Assert(results[0].Key, gc.Equals, s.rel.String())
+		endpoints := results[0].Endpoints
+		c.Assert(endpoints, gc.HasLen, 2)
+		// The order of a relation's endpoints is not defined.
+		if endpoints[0].ServiceName != "wordpress" {
+			endpoints[0], endpoints[1] = endpoints[1], endpoints[0]
+		}
+		for j := range endpoints {
+			c.Assert(endpoints[j].Units, gc.HasLen, 1)
+			c.Check(endpoints[j].Units[0].Version > 0, gc.Equals, true)
+			endpoints[j].Units[0].Version = 0
+		}
+		c.Assert(endpoints, gc.DeepEquals, expected)
+	}
+}
+
+var relationDataErrorTests = []struct {
+	spec string
+	err  string
+}{{
+	spec: "42",
+	err:  `relation 42 not found`,
+}, {
+	spec: "db:42",
+	err:  `relation 42 not found`,
+}, {
+	spec: "unknown:db",
+	err:  `service "unknown" not found`,
+}, {
+	spec: "mysql:nonsense",
+	err:  `service "mysql" has no relations on endpoint "nonsense"`,
+}, {
+	spec: "bad/0",
+	err:  `invalid relation "bad/0"`,
+}}
+
+func (s *relationDataSuite) TestRelationDataErrors(c *gc.C) {
+	for i, t := range relationDataErrorTests {
+		c.Logf("test %d: %s", i, t.spec)
+		_, err := s.APIState.Client().RelationData(t.spec)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
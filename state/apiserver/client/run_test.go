@@ -19,6 +19,7 @@ import (
 	jc "launchpad.net/juju-core/testing/checkers"
 	"launchpad.net/juju-core/utils/exec"
 	"launchpad.net/juju-core/utils/ssh"
+	sshtesting "launchpad.net/juju-core/utils/ssh/testing"
 )
 
 type runSuite struct {
@@ -146,6 +147,8 @@ func (s *runSuite) mockSSH(c *gc.C, cmd string) {
 	s.PatchEnvPathPrepend(testbin)
 	err := ioutil.WriteFile(fakessh, []byte(cmd), 0755)
 	c.Assert(err, gc.IsNil)
+	restore := sshtesting.PatchOpenSSHClient(c)
+	s.AddCleanup(func(*gc.C) { restore() })
 }
 
 func (s *runSuite) TestParallelExecuteErrorsOnBlankHost(c *gc.C) {
@@ -0,0 +1,77 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"launchpad.net/juju-core/environs/config"
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/utils/webhook"
+)
+
+// redactWebhooks replaces the secrets of the webhooks in the given
+// environment attributes, so that they are not shown to users.
+func redactWebhooks(cfg *config.Config, attrs map[string]interface{}) error {
+	hooks := cfg.Webhooks()
+	if len(hooks) == 0 {
+		return nil
+	}
+	attr, err := webhook.Format(webhook.Redact(hooks))
+	if err != nil {
+		return err
+	}
+	attrs["webhooks"] = attr
+	return nil
+}
+
+// restoreWebhookSecrets restores the redacted secrets of the webhooks
+// in the given environment attributes from the old configuration, so
+// that attributes obtained from EnvironmentGet can be set unchanged.
+func restoreWebhookSecrets(oldConfig *config.Config, attrs map[string]interface{}) error {
+	attr, ok := attrs["webhooks"].(string)
+	if !ok {
+		return nil
+	}
+	hooks, err := webhook.Parse(attr)
+	if err != nil {
+		return err
+	}
+	if hooks, err = webhook.RestoreSecrets(hooks, oldConfig.Webhooks()); err != nil {
+		return err
+	}
+	if attr, err = webhook.Format(hooks); err != nil {
+		return err
+	}
+	attrs["webhooks"] = attr
+	return nil
+}
+
+// WebhookTest sends a test event to the named webhook.
+func (c *Client) WebhookTest(args params.WebhookTest) error {
+	cfg, err := c.api.state.EnvironConfig()
+	if err != nil {
+		return err
+	}
+	for _, hook := range cfg.Webhooks() {
+		if hook.Name != args.Name {
+			continue
+		}
+		event := &webhook.Event{
+			Environment: cfg.Name(),
+			Kind:        "test",
+			Id:          hook.Name,
+			Status:      "test",
+			Time:        time.Now().UTC(),
+		}
+		client := webhook.NewClient(webhook.DefaultRetry.Timeout)
+		if err := webhook.Post(client, hook, event); err != nil {
+			return fmt.Errorf("cannot send test event: %v", err)
+		}
+		return nil
+	}
+	return errors.NotFoundf("webhook %q", args.Name)
+}
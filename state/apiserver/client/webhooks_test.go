@@ -0,0 +1,82 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/state/api/params"
+	statetesting "launchpad.net/juju-core/state/testing"
+	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/utils/webhook"
+)
+
+type webhooksSuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&webhooksSuite{})
+
+var opsHook = webhook.Hook{
+	Name:   "ops",
+	URL:    "https://ops.example.com/juju",
+	Secret: "s3cr3t",
+}
+
+func (s *webhooksSuite) setWebhooks(c *gc.C, hooks ...webhook.Hook) {
+	attr, err := webhook.Format(hooks)
+	c.Assert(err, gc.IsNil)
+	err = statetesting.UpdateConfig(s.State, map[string]interface{}{"webhooks": attr})
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *webhooksSuite) TestEnvironmentGetRedactsSecrets(c *gc.C) {
+	devHook := webhook.Hook{Name: "dev", URL: "http://dev.example.com/hook"}
+	s.setWebhooks(c, opsHook, devHook)
+	attrs, err := s.APIState.Client().EnvironmentGet()
+	c.Assert(err, gc.IsNil)
+	hooks, err := webhook.Parse(attrs["webhooks"].(string))
+	c.Assert(err, gc.IsNil)
+	c.Assert(hooks, jc.DeepEquals, webhook.Redact([]webhook.Hook{opsHook, devHook}))
+}
+
+func (s *webhooksSuite) TestEnvironmentSetRestoresSecrets(c *gc.C) {
+	s.setWebhooks(c, opsHook)
+	attrs, err := s.APIState.Client().EnvironmentGet()
+	c.Assert(err, gc.IsNil)
+	err = s.APIState.Client().EnvironmentSet(map[string]interface{}{"webhooks": attrs["webhooks"]})
+	c.Assert(err, gc.IsNil)
+	envConfig, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	c.Assert(envConfig.Webhooks(), jc.DeepEquals, []webhook.Hook{opsHook})
+}
+
+func (s *webhooksSuite) TestEnvironmentSetUnknownRedactedSecret(c *gc.C) {
+	attr, err := webhook.Format(webhook.Redact([]webhook.Hook{opsHook}))
+	c.Assert(err, gc.IsNil)
+	err = s.APIState.Client().EnvironmentSet(map[string]interface{}{"webhooks": attr})
+	c.Assert(err, gc.ErrorMatches, `webhook "ops": no secret to restore`)
+}
+
+func (s *webhooksSuite) TestWebhookTest(c *gc.C) {
+	events := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		events <- req.Header.Get(webhook.SignatureHeader)
+	}))
+	defer server.Close()
+	hook := opsHook
+	hook.URL = server.URL
+	s.setWebhooks(c, hook)
+
+	err := s.APIState.Client().WebhookTest("ops")
+	c.Assert(err, gc.IsNil)
+	c.Assert(<-events, gc.Not(gc.Equals), "")
+
+	err = s.APIState.Client().WebhookTest("missing")
+	c.Assert(err, gc.ErrorMatches, `webhook "missing" not found`)
+	c.Assert(err, jc.Satisfies, params.IsCodeNotFound)
+}
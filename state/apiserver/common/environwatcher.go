@@ -8,6 +8,7 @@ import (
 	"launchpad.net/juju-core/state"
 	"launchpad.net/juju-core/state/api/params"
 	"launchpad.net/juju-core/state/watcher"
+	"launchpad.net/juju-core/utils/webhook"
 )
 
 // EnvironWatcher implements two common methods for use by various
@@ -97,6 +98,25 @@ func (e *EnvironWatcher) EnvironConfig() (params.EnvironConfigResult, error) {
 			allAttrs[k] = "not available"
 		}
 	}
+	// Webhook events are sent by the state server straight
+	// from state, so no agent needs the secrets that sign
+	// them, even one allowed to read the provider's secrets.
+	if attr, ok := allAttrs["webhooks"].(string); ok && attr != "" {
+		allAttrs["webhooks"], err = redactWebhooks(attr)
+		if err != nil {
+			return result, err
+		}
+	}
 	result.Config = allAttrs
 	return result, nil
 }
+
+// redactWebhooks redacts the secrets of the webhooks
+// held in the "webhooks" environment setting.
+func redactWebhooks(attr string) (string, error) {
+	hooks, err := webhook.Parse(attr)
+	if err != nil {
+		return "", err
+	}
+	return webhook.Format(webhook.Redact(hooks))
+}
@@ -19,6 +19,7 @@ import (
 	"launchpad.net/juju-core/testing"
 	jc "launchpad.net/juju-core/testing/checkers"
 	"launchpad.net/juju-core/testing/testbase"
+	"launchpad.net/juju-core/utils/webhook"
 )
 
 type environWatcherSuite struct {
@@ -185,6 +186,42 @@ func (*environWatcherSuite) TestEnvironConfigReadSecretsFalse(c *gc.C) {
 	c.Check(map[string]interface{}(result.Config), jc.DeepEquals, testingEnvConfig.AllAttrs())
 }
 
+func (*environWatcherSuite) TestEnvironConfigRedactsWebhookSecrets(c *gc.C) {
+	attr, err := webhook.Format([]webhook.Hook{
+		{Name: "ops", URL: "http://ops.invalid", Secret: "sekrit"},
+		{Name: "audit", URL: "https://audit.invalid"},
+	})
+	c.Assert(err, gc.IsNil)
+	envConfig, err := testingEnvConfig(c).Apply(map[string]interface{}{"webhooks": attr})
+	c.Assert(err, gc.IsNil)
+	for _, canRead := range []bool{true, false} {
+		c.Logf("can read secrets: %v", canRead)
+		canRead := canRead
+		getCanReadSecrets := func() (common.AuthFunc, error) {
+			return func(tag string) bool {
+				return canRead
+			}, nil
+		}
+		e := common.NewEnvironWatcher(
+			&fakeEnvironAccessor{envConfig: envConfig},
+			nil,
+			nil,
+			getCanReadSecrets,
+		)
+		result, err := e.EnvironConfig()
+		c.Assert(err, gc.IsNil)
+		// The secrets are redacted even for agents that
+		// can read the provider's secrets.
+		hooks, err := webhook.Parse(result.Config["webhooks"].(string))
+		c.Assert(err, gc.IsNil)
+		c.Assert(hooks, jc.DeepEquals, []webhook.Hook{
+			{Name: "ops", URL: "http://ops.invalid", Secret: webhook.RedactedSecret},
+			{Name: "audit", URL: "https://audit.invalid"},
+		})
+		c.Assert(result.Config["webhooks"], gc.Not(jc.Contains), "sekrit")
+	}
+}
+
 func testingEnvConfig(c *gc.C) *config.Config {
 	cfg, err := config.New(config.NoDefaults, dummy.SampleConfig())
 	c.Assert(err, gc.IsNil)
@@ -8,6 +8,12 @@ import (
 	"sync"
 
 	"launchpad.net/juju-core/log"
+	"launchpad.net/juju-core/utils/metrics"
+)
+
+var resourcesGauge = metrics.NewGauge(
+	"juju_apiserver_resources",
+	"Number of resources, such as watchers, held by API connections.",
 )
 
 // Resource represents any resource that should be cleaned up when an
@@ -49,6 +55,7 @@ func (rs *Resources) Register(r Resource) string {
 	rs.maxId++
 	id := strconv.FormatUint(rs.maxId, 10)
 	rs.resources[id] = r
+	resourcesGauge.Inc()
 	return id
 }
 
@@ -70,7 +77,10 @@ func (rs *Resources) Stop(id string) error {
 	err := r.Stop()
 	rs.mu.Lock()
 	defer rs.mu.Unlock()
-	delete(rs.resources, id)
+	if _, ok := rs.resources[id]; ok {
+		delete(rs.resources, id)
+		resourcesGauge.Dec()
+	}
 	return err
 }
 
@@ -83,6 +93,7 @@ func (rs *Resources) StopAll() {
 			log.Errorf("state/api: error stopping %T resource: %v", r, err)
 		}
 	}
+	resourcesGauge.Add(float64(-len(rs.resources)))
 	rs.resources = make(map[string]Resource)
 }
 
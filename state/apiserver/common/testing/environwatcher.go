@@ -12,6 +12,7 @@ import (
 	"launchpad.net/juju-core/state/apiserver/common"
 	statetesting "launchpad.net/juju-core/state/testing"
 	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/utils/webhook"
 )
 
 const (
@@ -70,6 +71,25 @@ func (s *EnvironWatcherTest) TestEnvironConfig(c *gc.C) {
 	s.AssertEnvironConfig(c, s.envWatcher, s.hasSecrets)
 }
 
+func (s *EnvironWatcherTest) TestEnvironConfigRedactsWebhookSecrets(c *gc.C) {
+	attr, err := webhook.Format([]webhook.Hook{{Name: "ops", URL: "http://ops.invalid", Secret: "sekrit"}})
+	c.Assert(err, gc.IsNil)
+	oldConfig, err := s.st.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	newConfig, err := oldConfig.Apply(map[string]interface{}{"webhooks": attr})
+	c.Assert(err, gc.IsNil)
+	err = s.st.SetEnvironConfig(newConfig, oldConfig)
+	c.Assert(err, gc.IsNil)
+
+	result, err := s.envWatcher.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	hooks, err := webhook.Parse(result.Config["webhooks"].(string))
+	c.Assert(err, gc.IsNil)
+	c.Assert(hooks, jc.DeepEquals, []webhook.Hook{
+		{Name: "ops", URL: "http://ops.invalid", Secret: webhook.RedactedSecret},
+	})
+}
+
 func (s *EnvironWatcherTest) TestWatchForEnvironConfigChanges(c *gc.C) {
 	c.Assert(s.resources.Count(), gc.Equals, 0)
 
@@ -140,3 +140,121 @@ func (s *loginSuite) TestLoginSetsLogIdentifier(c *gc.C) {
 		`-> \[[0-9A-F]+\] machine-0 [0-9.umns]+ {"RequestId":2,"Response":{"Results":\[{"Life":"alive","Error":null}\]}} Machiner\[""\]\.Life`,
 	})
 }
+
+func (s *loginSuite) TestLoginToEnviron(c *gc.C) {
+	info, cleanup := s.setupServer(c)
+	defer cleanup()
+
+	env, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	info.Tag = "user-admin"
+	info.Password = "dummy-secret"
+	info.EnvironTag = env.Tag()
+	st, err := api.Open(info, fastDialOpts)
+	c.Assert(err, gc.IsNil)
+	st.Close()
+
+	info.EnvironTag = "environment-0fa6ab96-7a5c-4c3b-9d0c-4ea1c8a0e7e2"
+	_, err = api.Open(info, fastDialOpts)
+	c.Assert(err, gc.ErrorMatches, `environment "environment-0fa6ab96-7a5c-4c3b-9d0c-4ea1c8a0e7e2" not found`)
+	c.Assert(params.ErrCode(err), gc.Equals, params.CodeNotFound)
+}
+
+func (s *loginSuite) TestLoginToEnvironBadCreds(c *gc.C) {
+	info, cleanup := s.setupServer(c)
+	defer cleanup()
+
+	// The environment is not revealed to a client that
+	// cannot authenticate.
+	info.Tag = "user-admin"
+	info.Password = "wrong"
+	info.EnvironTag = "environment-0fa6ab96-7a5c-4c3b-9d0c-4ea1c8a0e7e2"
+	_, err := api.Open(info, fastDialOpts)
+	c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+	c.Assert(params.ErrCode(err), gc.Equals, params.CodeUnauthorized)
+}
+
+func (s *loginSuite) TestLoginToHostedEnviron(c *gc.C) {
+	info, cleanup := s.setupServer(c)
+	defer cleanup()
+
+	cfg := coretesting.CustomEnvironConfig(c, coretesting.Attrs{"name": "hosted"})
+	env, err := s.State.AddHostedEnvironment(cfg, "user-admin", "hosted-secret")
+	c.Assert(err, gc.IsNil)
+	info.Tag = "user-admin"
+	info.Password = "hosted-secret"
+	info.EnvironTag = env.Tag()
+	st, err := api.Open(info, fastDialOpts)
+	c.Assert(err, gc.IsNil)
+	envInfo, err := st.Client().EnvironmentInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(envInfo.UUID, gc.Equals, env.UUID())
+	st.Close()
+
+	// The hosted environment's users cannot log in to the
+	// state server's environment.
+	info.EnvironTag = ""
+	_, err = api.Open(info, fastDialOpts)
+	c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+
+	// Once the environment has been removed, it cannot be logged in to.
+	hst, err := s.State.OpenHostedEnvironment(env.UUID())
+	c.Assert(err, gc.IsNil)
+	defer hst.Close()
+	environ, err := hst.Environment()
+	c.Assert(err, gc.IsNil)
+	c.Assert(environ.Destroy(), gc.IsNil)
+	c.Assert(env.Remove(), gc.IsNil)
+	info.EnvironTag = env.Tag()
+	info.Password = "dummy-secret"
+	_, err = api.Open(info, fastDialOpts)
+	c.Assert(err, gc.ErrorMatches, `environment ".*" not found`)
+}
+
+func (s *loginSuite) TestDelegatedLoginToHostedEnviron(c *gc.C) {
+	info, cleanup := s.setupServer(c)
+	defer cleanup()
+
+	cfg := coretesting.CustomEnvironConfig(c, coretesting.Attrs{"name": "hosted"})
+	env, err := s.State.AddHostedEnvironment(cfg, "user-admin", "hosted-secret")
+	c.Assert(err, gc.IsNil)
+	hst, err := s.State.OpenHostedEnvironment(env.UUID())
+	c.Assert(err, gc.IsNil)
+	defer hst.Close()
+	hosted, err := hst.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	for i, job := range []state.MachineJob{state.JobManageEnviron, state.JobHostUnits} {
+		c.Logf("test %d: %v", i, job)
+		m, err := s.State.AddMachine("quantal", job)
+		c.Assert(err, gc.IsNil)
+		err = m.SetProvisioned("foo", "fake_nonce", nil)
+		c.Assert(err, gc.IsNil)
+		password, err := utils.RandomPassword()
+		c.Assert(err, gc.IsNil)
+		err = m.SetPassword(password)
+		c.Assert(err, gc.IsNil)
+
+		info.Tag = m.Tag()
+		info.Password = password
+		info.Nonce = "fake_nonce"
+		info.EnvironTag = env.Tag()
+		st, err := api.Open(info, fastDialOpts)
+		if job != state.JobManageEnviron {
+			// Only the state server's managers can manage
+			// the environments it hosts.
+			c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+			continue
+		}
+		c.Assert(err, gc.IsNil)
+		// The state server machine can manage the hosted
+		// environment's machines, but does not own the hosted
+		// machine that shares its tag.
+		machine, err := st.Provisioner().Machine(hosted.Tag())
+		c.Assert(err, gc.IsNil)
+		c.Assert(machine.Id(), gc.Equals, hosted.Id())
+		_, err = st.Machiner().Machine(m.Tag())
+		c.Assert(err, gc.ErrorMatches, "permission denied")
+		st.Close()
+	}
+}
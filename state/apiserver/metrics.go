@@ -0,0 +1,79 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/utils/metrics"
+)
+
+var (
+	connectionsGauge = metrics.NewGauge(
+		"juju_apiserver_connections",
+		"Number of open API connections.",
+	)
+	loggedInGauge = metrics.NewGauge(
+		"juju_apiserver_logged_in_connections",
+		"Number of API connections that have logged in, by kind of entity (machine, unit or user).",
+		"kind",
+	)
+	loginsTotal = metrics.NewCounter(
+		"juju_apiserver_logins_total",
+		"Number of API login attempts, by result.",
+		"result",
+	)
+	loginDuration = metrics.NewHistogram(
+		"juju_apiserver_login_duration_seconds",
+		"Time taken to process API logins.",
+		nil,
+	)
+)
+
+// recordLogin records the outcome of a login attempt
+// that started at the given time.
+func recordLogin(err error, start time.Time) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	loginsTotal.Inc(result)
+	loginDuration.Observe(time.Since(start).Seconds())
+}
+
+// tagKind returns the kind of entity with the given tag,
+// as used in the logged in connections metric.
+func tagKind(tag string) string {
+	kind, err := names.TagKind(tag)
+	if err != nil {
+		return "unknown"
+	}
+	return kind
+}
+
+// metricsHandler serves the metrics of the API server process
+// in the Prometheus text exposition format.
+type metricsHandler struct {
+	state *state.State
+}
+
+func (h *metricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := authenticateUser(h.state, r); err != nil {
+		w.Header().Set("WWW-Authenticate", `Basic realm="juju"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != "GET" {
+		http.Error(w, fmt.Sprintf("unsupported method: %q", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", metrics.ContentType)
+	if err := metrics.WriteText(w); err != nil {
+		logger.Errorf("cannot write metrics: %v", err)
+	}
+}
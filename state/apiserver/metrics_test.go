@@ -0,0 +1,106 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"io/ioutil"
+	"net/http"
+
+	gc "launchpad.net/gocheck"
+
+	jujutesting "launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/utils"
+	"launchpad.net/juju-core/utils/metrics"
+)
+
+type metricsSuite struct {
+	jujutesting.JujuConnSuite
+	userTag  string
+	password string
+}
+
+var _ = gc.Suite(&metricsSuite{})
+
+func (s *metricsSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	password, err := utils.RandomPassword()
+	c.Assert(err, gc.IsNil)
+	user, err := s.State.AddUser("joe", password)
+	c.Assert(err, gc.IsNil)
+	s.userTag = user.Tag()
+	s.password = password
+}
+
+func (s *metricsSuite) metricsURI(c *gc.C) string {
+	_, info, err := s.APIConn.Environ.StateInfo()
+	c.Assert(err, gc.IsNil)
+	return "https://" + info.Addrs[0] + "/metrics"
+}
+
+func (s *metricsSuite) sendRequest(c *gc.C, tag, password, method string) (*http.Response, string) {
+	req, err := http.NewRequest(method, s.metricsURI(c), nil)
+	c.Assert(err, gc.IsNil)
+	if tag != "" && password != "" {
+		req.SetBasicAuth(tag, password)
+	}
+	resp, err := utils.GetNonValidatingHTTPClient().Do(req)
+	c.Assert(err, gc.IsNil)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, gc.IsNil)
+	return resp, string(body)
+}
+
+func (s *metricsSuite) TestRequiresAuth(c *gc.C) {
+	resp, _ := s.sendRequest(c, "", "", "GET")
+	c.Assert(resp.StatusCode, gc.Equals, http.StatusUnauthorized)
+	c.Assert(resp.Header.Get("WWW-Authenticate"), gc.Equals, `Basic realm="juju"`)
+
+	resp, _ = s.sendRequest(c, s.userTag, "wrong", "GET")
+	c.Assert(resp.StatusCode, gc.Equals, http.StatusUnauthorized)
+}
+
+func (s *metricsSuite) TestAuthRequiresUser(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetProvisioned("foo", "fake_nonce", nil)
+	c.Assert(err, gc.IsNil)
+	password, err := utils.RandomPassword()
+	c.Assert(err, gc.IsNil)
+	err = machine.SetPassword(password)
+	c.Assert(err, gc.IsNil)
+
+	resp, _ := s.sendRequest(c, machine.Tag(), password, "GET")
+	c.Assert(resp.StatusCode, gc.Equals, http.StatusUnauthorized)
+}
+
+func (s *metricsSuite) TestRequiresGET(c *gc.C) {
+	resp, body := s.sendRequest(c, s.userTag, s.password, "POST")
+	c.Assert(resp.StatusCode, gc.Equals, http.StatusMethodNotAllowed)
+	c.Assert(body, gc.Equals, `unsupported method: "POST"`+"\n")
+}
+
+func (s *metricsSuite) TestMetrics(c *gc.C) {
+	// Make an API request so that the RPC metrics are populated.
+	_, err := s.APIState.Client().EnvironmentGet()
+	c.Assert(err, gc.IsNil)
+
+	resp, body := s.sendRequest(c, s.userTag, s.password, "GET")
+	c.Assert(resp.StatusCode, gc.Equals, http.StatusOK)
+	c.Assert(resp.Header.Get("Content-Type"), gc.Equals, metrics.ContentType)
+	for _, expect := range []string{
+		"# TYPE juju_apiserver_connections gauge\n",
+		`juju_apiserver_logins_total{result="success"} `,
+		`juju_apiserver_logged_in_connections{kind="user"} `,
+		`juju_rpc_requests_total{facade="Client",method="EnvironmentGet",result="ok"} `,
+		`juju_rpc_request_duration_seconds_count{facade="Client",method="EnvironmentGet"} `,
+		"# TYPE juju_state_transactions_total counter\n",
+		"# TYPE juju_state_watcher_watches gauge\n",
+		"# TYPE juju_presence_pingers gauge\n",
+	} {
+		c.Check(body, jc.Contains, expect)
+	}
+}
@@ -198,9 +198,21 @@ func (p *ProvisionerAPI) ContainerConfig() (params.ContainerConfig, error) {
 	result.SSLHostnameVerification = config.SSLHostnameVerification()
 	result.Proxy = config.ProxySettings()
 	result.AptProxy = config.AptProxySettings()
+	result.AptMirror = config.AptMirror()
 	return result, nil
 }
 
+// EnvironUUID returns the UUID of the environment, so that the
+// agents of new machines can name it when they log in.
+func (p *ProvisionerAPI) EnvironUUID() (params.StringResult, error) {
+	result := params.StringResult{}
+	env, err := p.st.Environment()
+	if err == nil {
+		result.Result = env.UUID()
+	}
+	return result, err
+}
+
 // Status returns the status of each given machine entity.
 func (p *ProvisionerAPI) Status(args params.Entities) (params.StatusResults, error) {
 	result := params.StatusResults{
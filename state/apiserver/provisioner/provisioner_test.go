@@ -687,6 +687,7 @@ func (s *withoutStateServerSuite) TestContainerConfig(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	newCfg, err := cfg.Apply(map[string]interface{}{
 		"http-proxy": "http://proxy.example.com:9000",
+		"apt-mirror": "http://mirror.example.com/ubuntu",
 	})
 	c.Assert(err, gc.IsNil)
 	err = s.State.SetEnvironConfig(newCfg, cfg)
@@ -702,6 +703,15 @@ func (s *withoutStateServerSuite) TestContainerConfig(c *gc.C) {
 	c.Check(results.SSLHostnameVerification, jc.IsTrue)
 	c.Check(results.Proxy, gc.DeepEquals, expectedProxy)
 	c.Check(results.AptProxy, gc.DeepEquals, expectedProxy)
+	c.Check(results.AptMirror, gc.Equals, "http://mirror.example.com/ubuntu")
+}
+
+func (s *withoutStateServerSuite) TestEnvironUUID(c *gc.C) {
+	env, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	result, err := s.provisioner.EnvironUUID()
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Result, gc.Equals, env.UUID())
 }
 
 func (s *withoutStateServerSuite) TestToolsRefusesWrongAgent(c *gc.C) {
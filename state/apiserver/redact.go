@@ -0,0 +1,228 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"launchpad.net/goyaml"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/utils/webhook"
+)
+
+// redactSecrets returns a copy of the given request or reply body in
+// which the values of secret charm config options, the secrets of
+// webhooks, the secrets in exported and imported environment models
+// and the configuration and password of created environments are
+// replaced, so that they are not written to the log. Other bodies are
+// returned unchanged.
+func redactSecrets(st *state.State, body interface{}) interface{} {
+	switch body := body.(type) {
+	case params.ServiceSet:
+		config := loggedConfig(st, body.ServiceName, "")
+		body.Options = redactStrings(config, body.Options)
+		return body
+	case params.ServicesSet:
+		services := make([]params.ServiceSet, len(body.Services))
+		for i, args := range body.Services {
+			services[i] = redactSecrets(st, args).(params.ServiceSet)
+		}
+		body.Services = services
+		return body
+	case params.ServiceSetYAML:
+		config := loggedConfig(st, body.ServiceName, "")
+		body.Config = redactYAML(config, body.Config)
+		return body
+	case params.ServiceDeploy:
+		config := loggedConfig(st, "", body.CharmUrl)
+		body.Config = redactStrings(config, body.Config)
+		body.ConfigYAML = redactYAML(config, body.ConfigYAML)
+		return body
+	case params.ServicesDeploy:
+		services := make([]params.ServiceDeploy, len(body.Services))
+		for i, args := range body.Services {
+			services[i] = redactSecrets(st, args).(params.ServiceDeploy)
+		}
+		body.Services = services
+		return body
+	case params.ServiceUpdate:
+		config := loggedConfig(st, body.ServiceName, body.CharmUrl)
+		body.SettingsStrings = redactStrings(config, body.SettingsStrings)
+		body.SettingsYAML = redactYAML(config, body.SettingsYAML)
+		return body
+	case params.EnvironmentSet:
+		attr, ok := body.Config["webhooks"].(string)
+		if !ok {
+			return body
+		}
+		attrs := make(map[string]interface{})
+		for name, value := range body.Config {
+			attrs[name] = value
+		}
+		attrs["webhooks"] = redactWebhooks(attr)
+		body.Config = attrs
+		return body
+	case params.EnvironmentModel:
+		return redactEnvironmentModel(body)
+	case params.ImportEnvironment:
+		body.Model = redactEnvironmentModel(body.Model)
+		return body
+	case params.CreateEnvironment:
+		// The configuration may hold provider credentials.
+		body.Config = redactSettings(nil, body.Config)
+		body.AdminPassword = charm.RedactedValue
+		return body
+	case params.ConfigSettingsResults:
+		// The units the settings belong to are not known
+		// here, so none of their values are logged.
+		results := make([]params.ConfigSettingsResult, len(body.Results))
+		for i, result := range body.Results {
+			result.Settings = params.ConfigSettings(redactSettings(nil, result.Settings))
+			results[i] = result
+		}
+		body.Results = results
+		return body
+	}
+	return body
+}
+
+// loggedConfig returns the config of the charm with the given URL or,
+// if that is empty, of the given service's charm. It returns nil if
+// the config cannot be found, in which case every value is redacted.
+func loggedConfig(st *state.State, serviceName, curlStr string) *charm.Config {
+	var curl *charm.URL
+	if curlStr != "" {
+		var err error
+		if curl, err = charm.ParseURL(curlStr); err != nil {
+			return nil
+		}
+	} else {
+		service, err := st.Service(serviceName)
+		if err != nil {
+			return nil
+		}
+		curl, _ = service.CharmURL()
+	}
+	ch, err := st.Charm(curl)
+	if err != nil {
+		return nil
+	}
+	return ch.Config()
+}
+
+// isSecret reports whether the value of the named option must not be
+// logged. All values are secret when the config is not known.
+func isSecret(config *charm.Config, name string) bool {
+	return config == nil || config.Options[name].Secret()
+}
+
+func redactStrings(config *charm.Config, values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	out := make(map[string]string)
+	for name, value := range values {
+		if isSecret(config, name) {
+			value = charm.RedactedValue
+		}
+		out[name] = value
+	}
+	return out
+}
+
+func redactSettings(config *charm.Config, settings map[string]interface{}) map[string]interface{} {
+	if settings == nil {
+		return nil
+	}
+	out := make(map[string]interface{})
+	for name, value := range settings {
+		if value != nil && isSecret(config, name) {
+			value = charm.RedactedValue
+		}
+		out[name] = value
+	}
+	return out
+}
+
+// redactWebhooks redacts the secrets of the webhooks
+// held in the "webhooks" environment setting.
+func redactWebhooks(attr string) string {
+	hooks, err := webhook.Parse(attr)
+	if err != nil {
+		return charm.RedactedValue
+	}
+	out, err := webhook.Format(webhook.Redact(hooks))
+	if err != nil {
+		return charm.RedactedValue
+	}
+	return out
+}
+
+// redactEnvironmentModel returns a copy of the exported model in
+// which the environment configuration, the password hashes and the
+// service and relation settings are redacted.
+func redactEnvironmentModel(model params.EnvironmentModel) params.EnvironmentModel {
+	model.Config = redactSettings(nil, model.Config)
+	users := make([]params.UserModel, len(model.Users))
+	for i, u := range model.Users {
+		u.PasswordHash, u.PasswordSalt = charm.RedactedValue, charm.RedactedValue
+		users[i] = u
+	}
+	model.Users = users
+	machines := make([]params.MachineModel, len(model.Machines))
+	for i, m := range model.Machines {
+		if m.PasswordHash != "" {
+			m.PasswordHash = charm.RedactedValue
+		}
+		machines[i] = m
+	}
+	model.Machines = machines
+	services := make([]params.ServiceModel, len(model.Services))
+	for i, svc := range model.Services {
+		svc.Settings = redactSettings(nil, svc.Settings)
+		units := make([]params.UnitModel, len(svc.Units))
+		for j, u := range svc.Units {
+			if u.PasswordHash != "" {
+				u.PasswordHash = charm.RedactedValue
+			}
+			units[j] = u
+		}
+		svc.Units = units
+		services[i] = svc
+	}
+	model.Services = services
+	relations := make([]params.RelationModel, len(model.Relations))
+	for i, rel := range model.Relations {
+		runits := make([]params.RelationUnitModel, len(rel.Units))
+		for j, ru := range rel.Units {
+			ru.Settings = redactSettings(nil, ru.Settings)
+			runits[j] = ru
+		}
+		rel.Units = runits
+		relations[i] = rel
+	}
+	model.Relations = relations
+	return model
+}
+
+// redactYAML redacts settings held in the YAML format accepted by
+// ServiceSetYAML, which maps a service name to its settings.
+func redactYAML(config *charm.Config, data string) string {
+	if data == "" {
+		return ""
+	}
+	var all map[string]map[string]interface{}
+	if err := goyaml.Unmarshal([]byte(data), &all); err != nil {
+		return charm.RedactedValue
+	}
+	for key, settings := range all {
+		all[key] = redactSettings(config, settings)
+	}
+	out, err := goyaml.Marshal(all)
+	if err != nil {
+		return charm.RedactedValue
+	}
+	return string(out)
+}
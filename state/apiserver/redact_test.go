@@ -0,0 +1,180 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/charm"
+	jujutesting "launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/state/apiserver"
+	"launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/utils/webhook"
+)
+
+type redactSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&redactSuite{})
+
+const secretConfig = `
+options:
+  title: {default: My Title, description: Desc, type: string}
+  password: {description: Desc, type: secret}
+`
+
+func (s *redactSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	path := testing.Charms.ClonedDirPath(c.MkDir(), "wordpress")
+	err := ioutil.WriteFile(filepath.Join(path, "config.yaml"), []byte(secretConfig), 0644)
+	c.Assert(err, gc.IsNil)
+	dir, err := charm.ReadDir(path)
+	c.Assert(err, gc.IsNil)
+	bundleURL, err := url.Parse("http://bundles.testing.invalid/secret-wordpress")
+	c.Assert(err, gc.IsNil)
+	ch, err := s.State.AddCharm(dir, charm.MustParseURL("local:quantal/wordpress-42"), bundleURL, "sha256")
+	c.Assert(err, gc.IsNil)
+	s.AddTestingService(c, "wordpress", ch)
+}
+
+func (s *redactSuite) TestRedactServiceSet(c *gc.C) {
+	body := apiserver.RedactSecrets(s.State, params.ServiceSet{
+		ServiceName: "wordpress",
+		Options:     map[string]string{"title": "foo", "password": "sekrit"},
+	})
+	c.Assert(body, gc.DeepEquals, params.ServiceSet{
+		ServiceName: "wordpress",
+		Options:     map[string]string{"title": "foo", "password": charm.RedactedValue},
+	})
+}
+
+func (s *redactSuite) TestRedactServiceDeploy(c *gc.C) {
+	body := apiserver.RedactSecrets(s.State, params.ServicesDeploy{
+		Services: []params.ServiceDeploy{{
+			ServiceName: "other",
+			CharmUrl:    "local:quantal/wordpress-42",
+			ConfigYAML:  "other:\n  title: foo\n  password: sekrit\n",
+		}},
+	})
+	c.Assert(body, gc.DeepEquals, params.ServicesDeploy{
+		Services: []params.ServiceDeploy{{
+			ServiceName: "other",
+			CharmUrl:    "local:quantal/wordpress-42",
+			ConfigYAML:  "other:\n  password: <redacted>\n  title: foo\n",
+		}},
+	})
+}
+
+func (s *redactSuite) TestRedactUnknownService(c *gc.C) {
+	body := apiserver.RedactSecrets(s.State, params.ServiceSet{
+		ServiceName: "unknown",
+		Options:     map[string]string{"title": "foo"},
+	})
+	c.Assert(body, gc.DeepEquals, params.ServiceSet{
+		ServiceName: "unknown",
+		Options:     map[string]string{"title": charm.RedactedValue},
+	})
+}
+
+func (s *redactSuite) TestRedactConfigSettingsResults(c *gc.C) {
+	body := apiserver.RedactSecrets(s.State, params.ConfigSettingsResults{
+		Results: []params.ConfigSettingsResult{{
+			Settings: params.ConfigSettings{"title": "foo", "unset": nil},
+		}},
+	})
+	c.Assert(body, gc.DeepEquals, params.ConfigSettingsResults{
+		Results: []params.ConfigSettingsResult{{
+			Settings: params.ConfigSettings{"title": charm.RedactedValue, "unset": nil},
+		}},
+	})
+}
+
+func (s *redactSuite) TestOtherBodiesUnchanged(c *gc.C) {
+	body := params.Entities{Entities: []params.Entity{{Tag: "unit-wordpress-0"}}}
+	c.Assert(apiserver.RedactSecrets(s.State, body), gc.DeepEquals, body)
+}
+
+func (s *redactSuite) TestRedactEnvironmentSet(c *gc.C) {
+	attr, err := webhook.Format([]webhook.Hook{{Name: "ops", URL: "http://ops.invalid", Secret: "sekrit"}})
+	c.Assert(err, gc.IsNil)
+	args := params.EnvironmentSet{Config: map[string]interface{}{"webhooks": attr, "foo": "bar"}}
+	body := apiserver.RedactSecrets(s.State, args).(params.EnvironmentSet)
+	c.Assert(body.Config["foo"], gc.Equals, "bar")
+	hooks, err := webhook.Parse(body.Config["webhooks"].(string))
+	c.Assert(err, gc.IsNil)
+	c.Assert(hooks[0].Secret, gc.Equals, webhook.RedactedSecret)
+	// The request itself is unchanged.
+	c.Assert(args.Config["webhooks"], gc.Equals, attr)
+}
+
+func (s *redactSuite) TestRedactEnvironmentModel(c *gc.C) {
+	model := params.EnvironmentModel{
+		Config: map[string]interface{}{"admin-secret": "sekrit"},
+		Users:  []params.UserModel{{Name: "admin", PasswordHash: "hash", PasswordSalt: "salt"}},
+		Machines: []params.MachineModel{{
+			Id:           "0",
+			InstanceId:   "i-0",
+			PasswordHash: "hash",
+		}},
+		Services: []params.ServiceModel{{
+			Name:     "wordpress",
+			Settings: map[string]interface{}{"password": "sekrit"},
+			Units:    []params.UnitModel{{Name: "wordpress/0", PasswordHash: "hash"}},
+		}},
+		Relations: []params.RelationModel{{
+			Key:   "wordpress:db mysql:server",
+			Units: []params.RelationUnitModel{{Unit: "wordpress/0", Settings: map[string]interface{}{"password": "sekrit"}}},
+		}},
+	}
+	body := apiserver.RedactSecrets(s.State, model).(params.EnvironmentModel)
+	c.Assert(body.Config["admin-secret"], gc.Equals, charm.RedactedValue)
+	c.Assert(body.Users[0].PasswordHash, gc.Equals, charm.RedactedValue)
+	c.Assert(body.Users[0].PasswordSalt, gc.Equals, charm.RedactedValue)
+	c.Assert(body.Machines[0].InstanceId, gc.Equals, "i-0")
+	c.Assert(body.Machines[0].PasswordHash, gc.Equals, charm.RedactedValue)
+	c.Assert(body.Services[0].Settings["password"], gc.Equals, charm.RedactedValue)
+	c.Assert(body.Services[0].Units[0].PasswordHash, gc.Equals, charm.RedactedValue)
+	c.Assert(body.Relations[0].Units[0].Settings["password"], gc.Equals, charm.RedactedValue)
+	// The reply itself is unchanged.
+	c.Assert(model.Users[0].PasswordHash, gc.Equals, "hash")
+	c.Assert(model.Machines[0].PasswordHash, gc.Equals, "hash")
+	c.Assert(model.Services[0].Units[0].PasswordHash, gc.Equals, "hash")
+	c.Assert(model.Relations[0].Units[0].Settings["password"], gc.Equals, "sekrit")
+}
+
+func (s *redactSuite) TestRedactImportEnvironment(c *gc.C) {
+	args := params.ImportEnvironment{
+		Model: params.EnvironmentModel{
+			Name:   "imported",
+			Config: map[string]interface{}{"admin-secret": "sekrit"},
+			Users:  []params.UserModel{{Name: "admin", PasswordHash: "hash", PasswordSalt: "salt"}},
+		},
+	}
+	body := apiserver.RedactSecrets(s.State, args).(params.ImportEnvironment)
+	c.Assert(body.Model.Name, gc.Equals, "imported")
+	c.Assert(body.Model.Config["admin-secret"], gc.Equals, charm.RedactedValue)
+	c.Assert(body.Model.Users[0].PasswordHash, gc.Equals, charm.RedactedValue)
+	// The request itself is unchanged.
+	c.Assert(args.Model.Config["admin-secret"], gc.Equals, "sekrit")
+}
+
+func (s *redactSuite) TestRedactCreateEnvironment(c *gc.C) {
+	args := params.CreateEnvironment{
+		Name:          "hosted",
+		Config:        map[string]interface{}{"secret-key": "sekrit"},
+		AdminPassword: "password",
+	}
+	body := apiserver.RedactSecrets(s.State, args).(params.CreateEnvironment)
+	c.Assert(body.Name, gc.Equals, "hosted")
+	c.Assert(body.Config["secret-key"], gc.Equals, charm.RedactedValue)
+	c.Assert(body.AdminPassword, gc.Equals, charm.RedactedValue)
+	// The request itself is unchanged.
+	c.Assert(args.Config["secret-key"], gc.Equals, "sekrit")
+}
@@ -52,20 +52,28 @@ type srvRoot struct {
 	resources   *common.Resources
 	pingTimeout *pingTimeout
 
+	// state holds the environment the client logged in to.
+	state  *state.State
 	entity taggedAuthenticator
+	// delegated holds whether entity is a machine of the state
+	// server's environment managing the hosted environment held
+	// by state.
+	delegated bool
 }
 
 // newSrvRoot creates the client's connection representation
 // and starts a ping timeout for the monitoring of this
 // connection.
-func newSrvRoot(root *initialRoot, entity taggedAuthenticator) *srvRoot {
+func newSrvRoot(root *initialRoot, st *state.State, entity taggedAuthenticator, delegated bool) *srvRoot {
 	r := &srvRoot{
 		srv:       root.srv,
 		rpcConn:   root.rpcConn,
 		resources: common.NewResources(),
+		state:     st,
 		entity:    entity,
+		delegated: delegated,
 	}
-	r.clientAPI.API = client.NewAPI(r.srv.state, r.resources, r, r.srv.dataDir)
+	r.clientAPI.API = client.NewAPI(r.state, r.resources, r, r.srv.dataDir)
 	return r
 }
 
@@ -105,7 +113,7 @@ func (r *srvRoot) KeyManager(id string) (*keymanager.KeyManagerAPI, error) {
 	if id != "" {
 		return nil, common.ErrBadId
 	}
-	return keymanager.NewKeyManagerAPI(r.srv.state, r.resources, r)
+	return keymanager.NewKeyManagerAPI(r.state, r.resources, r)
 }
 
 // Machiner returns an object that provides access to the Machiner API
@@ -116,7 +124,7 @@ func (r *srvRoot) Machiner(id string) (*machine.MachinerAPI, error) {
 		// Safeguard id for possible future use.
 		return nil, common.ErrBadId
 	}
-	return machine.NewMachinerAPI(r.srv.state, r.resources, r)
+	return machine.NewMachinerAPI(r.state, r.resources, r)
 }
 
 // Provisioner returns an object that provides access to the
@@ -127,7 +135,7 @@ func (r *srvRoot) Provisioner(id string) (*provisioner.ProvisionerAPI, error) {
 		// Safeguard id for possible future use.
 		return nil, common.ErrBadId
 	}
-	return provisioner.NewProvisionerAPI(r.srv.state, r.resources, r)
+	return provisioner.NewProvisionerAPI(r.state, r.resources, r)
 }
 
 // Uniter returns an object that provides access to the Uniter API
@@ -138,7 +146,7 @@ func (r *srvRoot) Uniter(id string) (*uniter.UniterAPI, error) {
 		// Safeguard id for possible future use.
 		return nil, common.ErrBadId
 	}
-	return uniter.NewUniterAPI(r.srv.state, r.resources, r)
+	return uniter.NewUniterAPI(r.state, r.resources, r)
 }
 
 // Firewaller returns an object that provides access to the Firewaller
@@ -149,7 +157,7 @@ func (r *srvRoot) Firewaller(id string) (*firewaller.FirewallerAPI, error) {
 		// Safeguard id for possible future use.
 		return nil, common.ErrBadId
 	}
-	return firewaller.NewFirewallerAPI(r.srv.state, r.resources, r)
+	return firewaller.NewFirewallerAPI(r.state, r.resources, r)
 }
 
 // Agent returns an object that provides access to the
@@ -159,7 +167,7 @@ func (r *srvRoot) Agent(id string) (*agent.API, error) {
 	if id != "" {
 		return nil, common.ErrBadId
 	}
-	return agent.NewAPI(r.srv.state, r)
+	return agent.NewAPI(r.state, r.resources, r)
 }
 
 // Deployer returns an object that provides access to the Deployer API facade.
@@ -169,7 +177,7 @@ func (r *srvRoot) Deployer(id string) (*deployer.DeployerAPI, error) {
 		// TODO(dimitern): There is no direct test for this
 		return nil, common.ErrBadId
 	}
-	return deployer.NewDeployerAPI(r.srv.state, r.resources, r)
+	return deployer.NewDeployerAPI(r.state, r.resources, r)
 }
 
 // Environment returns an object that provides access to the Environment API
@@ -180,7 +188,7 @@ func (r *srvRoot) Environment(id string) (*environment.EnvironmentAPI, error) {
 		// Safeguard id for possible future use.
 		return nil, common.ErrBadId
 	}
-	return environment.NewEnvironmentAPI(r.srv.state, r.resources, r)
+	return environment.NewEnvironmentAPI(r.state, r.resources, r)
 }
 
 // Rsyslog returns an object that provides access to the Rsyslog API
@@ -191,7 +199,7 @@ func (r *srvRoot) Rsyslog(id string) (*rsyslog.RsyslogAPI, error) {
 		// Safeguard id for possible future use.
 		return nil, common.ErrBadId
 	}
-	return rsyslog.NewRsyslogAPI(r.srv.state, r.resources, r)
+	return rsyslog.NewRsyslogAPI(r.state, r.resources, r)
 }
 
 // Logger returns an object that provides access to the Logger API facade.
@@ -201,7 +209,7 @@ func (r *srvRoot) Logger(id string) (*loggerapi.LoggerAPI, error) {
 		// TODO: There is no direct test for this
 		return nil, common.ErrBadId
 	}
-	return loggerapi.NewLoggerAPI(r.srv.state, r.resources, r)
+	return loggerapi.NewLoggerAPI(r.state, r.resources, r)
 }
 
 // Upgrader returns an object that provides access to the Upgrader API facade.
@@ -221,9 +229,9 @@ func (r *srvRoot) Upgrader(id string) (upgrader.Upgrader, error) {
 	}
 	switch tagKind {
 	case names.MachineTagKind:
-		return upgrader.NewUpgraderAPI(r.srv.state, r.resources, r)
+		return upgrader.NewUpgraderAPI(r.state, r.resources, r)
 	case names.UnitTagKind:
-		return upgrader.NewUnitUpgraderAPI(r.srv.state, r.resources, r, r.srv.dataDir)
+		return upgrader.NewUnitUpgraderAPI(r.state, r.resources, r, r.srv.dataDir)
 	}
 	// Not a machine or unit.
 	return nil, common.ErrPerm
@@ -236,7 +244,7 @@ func (r *srvRoot) KeyUpdater(id string) (*keyupdater.KeyUpdaterAPI, error) {
 		// TODO: There is no direct test for this
 		return nil, common.ErrBadId
 	}
-	return keyupdater.NewKeyUpdaterAPI(r.srv.state, r.resources, r)
+	return keyupdater.NewKeyUpdaterAPI(r.state, r.resources, r)
 }
 
 // CharmRevisionUpdater returns an object that provides access to the CharmRevisionUpdater API facade.
@@ -246,7 +254,7 @@ func (r *srvRoot) CharmRevisionUpdater(id string) (*charmrevisionupdater.CharmRe
 		// TODO: There is no direct test for this
 		return nil, common.ErrBadId
 	}
-	return charmrevisionupdater.NewCharmRevisionUpdaterAPI(r.srv.state, r.resources, r)
+	return charmrevisionupdater.NewCharmRevisionUpdaterAPI(r.state, r.resources, r)
 }
 
 // NotifyWatcher returns an object that provides
@@ -351,9 +359,10 @@ func (r *srvRoot) AuthUnitAgent() bool {
 }
 
 // AuthOwner returns whether the authenticated user's tag matches the
-// given entity tag.
+// given entity tag. Delegated machines own nothing in the hosted
+// environment, whose entities may share their tags.
 func (r *srvRoot) AuthOwner(tag string) bool {
-	return r.entity.Tag() == tag
+	return !r.delegated && r.entity.Tag() == tag
 }
 
 // AuthEnvironManager returns whether the authenticated user is a
@@ -235,6 +235,142 @@ func (u *UniterAPI) ClearResolved(args params.Entities) (params.ErrorResults, er
 	return result, nil
 }
 
+// MigrationStatus returns the migration status of each given unit.
+func (u *UniterAPI) MigrationStatus(args params.Entities) (params.MigrationStatusResults, error) {
+	result := params.MigrationStatusResults{
+		Results: make([]params.MigrationStatusResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.MigrationStatusResults{}, err
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		if canAccess(entity.Tag) {
+			var unit *state.Unit
+			unit, err = u.getUnit(entity.Tag)
+			if err == nil {
+				result.Results[i].MigratingTo, _ = unit.MigratingTo()
+				result.Results[i].MigratedFrom, _ = unit.MigratedFrom()
+				result.Results[i].Ready = unit.MigrationReady()
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// SetMigrationReady records that each given unit being migrated has
+// written its data to its migration settings.
+func (u *UniterAPI) SetMigrationReady(args params.Entities) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.ErrorResults{}, err
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		if canAccess(entity.Tag) {
+			var unit *state.Unit
+			unit, err = u.getUnit(entity.Tag)
+			if err == nil {
+				err = unit.SetMigrationReady()
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// FinishMigration destroys the units replaced by each given unit,
+// once their data has been taken over.
+func (u *UniterAPI) FinishMigration(args params.Entities) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.ErrorResults{}, err
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		if canAccess(entity.Tag) {
+			var unit *state.Unit
+			unit, err = u.getUnit(entity.Tag)
+			if err == nil {
+				err = unit.FinishMigration()
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// MigrationSettings returns the migration settings of each given unit.
+func (u *UniterAPI) MigrationSettings(args params.Entities) (params.RelationSettingsResults, error) {
+	result := params.RelationSettingsResults{
+		Results: make([]params.RelationSettingsResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.RelationSettingsResults{}, err
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		if canAccess(entity.Tag) {
+			var unit *state.Unit
+			unit, err = u.getUnit(entity.Tag)
+			if err == nil {
+				var settings *state.Settings
+				settings, err = unit.MigrationSettings()
+				if err == nil {
+					result.Results[i].Settings, err = convertRelationSettings(settings.Map())
+				}
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// UpdateMigrationSettings persists the changes made to the migration
+// settings of each given unit. Keys with empty values are considered
+// a signal to delete these values.
+func (u *UniterAPI) UpdateMigrationSettings(args params.UnitsMigrationSettings) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Units)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.ErrorResults{}, err
+	}
+	for i, arg := range args.Units {
+		err := common.ErrPerm
+		if canAccess(arg.Unit) {
+			var unit *state.Unit
+			unit, err = u.getUnit(arg.Unit)
+			if err == nil {
+				var settings *state.Settings
+				settings, err = unit.MigrationSettings()
+				if err == nil {
+					for k, v := range arg.Settings {
+						if v == "" {
+							settings.Delete(k)
+						} else {
+							settings.Set(k, v)
+						}
+					}
+					_, err = settings.Write()
+				}
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
 // GetPrincipal returns the result of calling PrincipalName() and
 // converting it to a tag, on each given unit.
 func (u *UniterAPI) GetPrincipal(args params.Entities) (params.StringBoolResults, error) {
@@ -455,6 +591,70 @@ func (u *UniterAPI) ClosePort(args params.EntitiesPorts) (params.ErrorResults, e
 	return result, nil
 }
 
+// RecordHookExecutions records the given hook executions for
+// all given units.
+func (u *UniterAPI) RecordHookExecutions(args params.EntitiesHookExecutions) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.ErrorResults{}, err
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		if canAccess(entity.Tag) {
+			var unit *state.Unit
+			unit, err = u.getUnit(entity.Tag)
+			if err == nil {
+				exec := entity.Execution
+				if exec.Skipped {
+					err = unit.ClearRunningHook()
+				} else {
+					err = unit.RecordHookExecution(state.HookExecution{
+						Hook:     exec.Hook,
+						Started:  exec.Started,
+						Finished: exec.Finished,
+						ExitCode: exec.ExitCode,
+						Error:    exec.Error,
+						Queued:   exec.Queued,
+					})
+				}
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// AddMetricBatches records the given batches of metrics,
+// collected by the units that sent them.
+func (u *UniterAPI) AddMetricBatches(args params.MetricBatchParams) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Batches)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.ErrorResults{}, err
+	}
+	for i, arg := range args.Batches {
+		err := common.ErrPerm
+		if canAccess(arg.Tag) {
+			var unit *state.Unit
+			unit, err = u.getUnit(arg.Tag)
+			if err == nil {
+				metrics := make([]state.Metric, len(arg.Batch.Metrics))
+				for j, m := range arg.Batch.Metrics {
+					metrics[j] = state.Metric{Key: m.Key, Value: m.Value, Time: m.Time}
+				}
+				_, err = unit.AddMetrics(arg.Batch.UUID, arg.Batch.Created, arg.Batch.CharmURL, metrics)
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
 func (u *UniterAPI) watchOneUnitConfigSettings(tag string) (string, error) {
 	unit, err := u.getUnit(tag)
 	if err != nil {
@@ -525,6 +725,82 @@ func (u *UniterAPI) ConfigSettings(args params.Entities) (params.ConfigSettingsR
 	return result, nil
 }
 
+// getUnitMachine returns the machine the given unit is assigned to.
+func (u *UniterAPI) getUnitMachine(tag string) (*state.Machine, error) {
+	unit, err := u.getUnit(tag)
+	if err != nil {
+		return nil, err
+	}
+	machineId, err := unit.AssignedMachineId()
+	if err != nil {
+		return nil, err
+	}
+	return u.st.Machine(machineId)
+}
+
+// InMaintenance returns whether the machine each given unit is
+// assigned to is in maintenance mode.
+func (u *UniterAPI) InMaintenance(args params.Entities) (params.BoolResults, error) {
+	result := params.BoolResults{
+		Results: make([]params.BoolResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.BoolResults{}, err
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		if canAccess(entity.Tag) {
+			var machine *state.Machine
+			machine, err = u.getUnitMachine(entity.Tag)
+			if err == nil {
+				result.Results[i].Result = machine.InMaintenance()
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+func (u *UniterAPI) watchOneUnitMaintenance(tag string) (string, error) {
+	machine, err := u.getUnitMachine(tag)
+	if err != nil {
+		return "", err
+	}
+	watch := machine.Watch()
+	// Consume the initial event. Technically, API
+	// calls to Watch 'transmit' the initial event
+	// in the Watch response. But NotifyWatchers
+	// have no state to transmit.
+	if _, ok := <-watch.Changes(); ok {
+		return u.resources.Register(watch), nil
+	}
+	return "", watcher.MustErr(watch)
+}
+
+// WatchMaintenance returns a NotifyWatcher for observing changes
+// to the machine each given unit is assigned to, including changes
+// to its maintenance mode.
+func (u *UniterAPI) WatchMaintenance(args params.Entities) (params.NotifyWatchResults, error) {
+	result := params.NotifyWatchResults{
+		Results: make([]params.NotifyWatchResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.NotifyWatchResults{}, err
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		watcherId := ""
+		if canAccess(entity.Tag) {
+			watcherId, err = u.watchOneUnitMaintenance(entity.Tag)
+		}
+		result.Results[i].NotifyWatcherId = watcherId
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
 func (u *UniterAPI) watchOneServiceRelations(tag string) (params.StringsWatchResult, error) {
 	nothing := params.StringsWatchResult{}
 	service, err := u.getService(tag)
@@ -563,6 +839,31 @@ func (u *UniterAPI) WatchServiceRelations(args params.Entities) (params.StringsW
 	return result, nil
 }
 
+// HookTimeout returns, for each given service, the maximum time any
+// hook run by its units may take, or zero if the timeouts given in
+// the charm metadata apply.
+func (u *UniterAPI) HookTimeout(args params.Entities) (params.DurationResults, error) {
+	result := params.DurationResults{
+		Results: make([]params.DurationResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessService()
+	if err != nil {
+		return params.DurationResults{}, err
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		if canAccess(entity.Tag) {
+			var service *state.Service
+			service, err = u.getService(entity.Tag)
+			if err == nil {
+				result.Results[i].Result = service.HookTimeout()
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
 // CharmArchiveURL returns the URL, corresponding to the charm archive
 // (bundle) in the provider storage for each given charm URL, along
 // with the DisableSSLHostnameVerification flag.
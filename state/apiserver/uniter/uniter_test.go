@@ -5,6 +5,7 @@ package uniter_test
 
 import (
 	stdtesting "testing"
+	"time"
 
 	gc "launchpad.net/gocheck"
 
@@ -485,6 +486,71 @@ func (s *uniterSuite) TestClearResolved(c *gc.C) {
 	c.Assert(mode, gc.Equals, state.ResolvedNone)
 }
 
+func (s *uniterSuite) TestMigration(c *gc.C) {
+	target, err := s.wordpressUnit.StartMigration(s.machine1)
+	c.Assert(err, gc.IsNil)
+
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: "unit-mysql-0"},
+		{Tag: "unit-wordpress-0"},
+		{Tag: "unit-foo-42"},
+	}}
+	status, err := s.uniter.MigrationStatus(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.DeepEquals, params.MigrationStatusResults{
+		Results: []params.MigrationStatusResult{
+			{Error: apiservertesting.ErrUnauthorized},
+			{MigratingTo: target.Name()},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	update, err := s.uniter.UpdateMigrationSettings(params.UnitsMigrationSettings{
+		Units: []params.UnitMigrationSettings{
+			{Unit: "unit-mysql-0", Settings: params.RelationSettings{"data": "x"}},
+			{Unit: "unit-wordpress-0", Settings: params.RelationSettings{"data": "/srv/data"}},
+		},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(update, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{apiservertesting.ErrUnauthorized},
+			{nil},
+		},
+	})
+	settings, err := s.uniter.MigrationSettings(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, gc.DeepEquals, params.RelationSettingsResults{
+		Results: []params.RelationSettingsResult{
+			{Error: apiservertesting.ErrUnauthorized},
+			{Settings: params.RelationSettings{"data": "/srv/data"}},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	ready, err := s.uniter.SetMigrationReady(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(ready, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{apiservertesting.ErrUnauthorized},
+			{nil},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+	err = target.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(target.MigrationReady(), gc.Equals, true)
+
+	// Only the unit replacing wordpress/0 may finish the migration.
+	finish, err := s.uniter.FinishMigration(params.Entities{Entities: []params.Entity{
+		{Tag: target.Tag()},
+	}})
+	c.Assert(err, gc.IsNil)
+	c.Assert(finish, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{{apiservertesting.ErrUnauthorized}},
+	})
+}
+
 func (s *uniterSuite) TestGetPrincipal(c *gc.C) {
 	// Add a subordinate to wordpressUnit.
 	_, _, subordinate := s.addRelatedService(c, "wordpress", "logging", s.wordpressUnit)
@@ -762,6 +828,98 @@ func (s *uniterSuite) TestClosePort(c *gc.C) {
 	c.Assert(openedPorts, gc.HasLen, 0)
 }
 
+func (s *uniterSuite) TestRecordHookExecutions(c *gc.C) {
+	exec := params.HookExecution{
+		Hook:     "install",
+		Started:  time.Unix(100, 0),
+		Finished: time.Unix(110, 0),
+		ExitCode: 1,
+		Error:    "exit status 1",
+	}
+	args := params.EntitiesHookExecutions{Entities: []params.EntityHookExecution{
+		{Tag: "unit-mysql-0", Execution: exec},
+		{Tag: "unit-wordpress-0", Execution: exec},
+		{Tag: "unit-foo-42", Execution: exec},
+	}}
+	result, err := s.uniter.RecordHookExecutions(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{apiservertesting.ErrUnauthorized},
+			{nil},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	stats, err := s.wordpressUnit.HookStats()
+	c.Assert(err, gc.IsNil)
+	c.Assert(stats.Executions, gc.DeepEquals, []state.HookExecution{{
+		Hook:     "install",
+		Started:  time.Unix(100, 0),
+		Finished: time.Unix(110, 0),
+		ExitCode: 1,
+		Error:    "exit status 1",
+	}})
+}
+
+func (s *uniterSuite) TestRecordSkippedHookExecution(c *gc.C) {
+	args := params.EntitiesHookExecutions{Entities: []params.EntityHookExecution{
+		{Tag: "unit-wordpress-0", Execution: params.HookExecution{
+			Hook:    "config-changed",
+			Started: time.Unix(100, 0),
+		}},
+		{Tag: "unit-wordpress-0", Execution: params.HookExecution{
+			Hook:     "config-changed",
+			Started:  time.Unix(100, 0),
+			Finished: time.Unix(101, 0),
+			Skipped:  true,
+		}},
+	}}
+	result, err := s.uniter.RecordHookExecutions(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{{nil}, {nil}},
+	})
+
+	stats, err := s.wordpressUnit.HookStats()
+	c.Assert(err, gc.IsNil)
+	c.Assert(stats.Running, gc.IsNil)
+	c.Assert(stats.Executions, gc.HasLen, 0)
+}
+
+func (s *uniterSuite) TestAddMetricBatches(c *gc.C) {
+	batch := params.MetricBatch{
+		UUID:     "a3d5a9a8-3e8d-4b3c-8c1e-3cce5c0e0d6f",
+		CharmURL: s.wpCharm.URL().String(),
+		Created:  time.Unix(100, 0),
+		Metrics:  []params.Metric{{Key: "users", Value: "5", Time: time.Unix(99, 0)}},
+	}
+	args := params.MetricBatchParams{Batches: []params.MetricBatchParam{
+		{Tag: "unit-mysql-0", Batch: batch},
+		{Tag: "unit-wordpress-0", Batch: batch},
+		{Tag: "unit-foo-42", Batch: batch},
+	}}
+	result, err := s.uniter.AddMetricBatches(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{apiservertesting.ErrUnauthorized},
+			{nil},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	batches, err := s.wordpress.MetricBatches()
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 1)
+	c.Assert(batches[0].UUID(), gc.Equals, batch.UUID)
+	c.Assert(batches[0].Unit(), gc.Equals, "wordpress/0")
+	c.Assert(batches[0].Created(), gc.DeepEquals, batch.Created)
+	c.Assert(batches[0].Metrics(), gc.DeepEquals, []state.Metric{
+		{Key: "users", Value: "5", Time: time.Unix(99, 0)},
+	})
+}
+
 func (s *uniterSuite) TestWatchConfigSettings(c *gc.C) {
 	err := s.wordpressUnit.SetCharmURL(s.wpCharm.URL())
 	c.Assert(err, gc.IsNil)
@@ -817,6 +975,68 @@ func (s *uniterSuite) TestConfigSettings(c *gc.C) {
 	})
 }
 
+func (s *uniterSuite) TestInMaintenance(c *gc.C) {
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: "unit-mysql-0"},
+		{Tag: "unit-wordpress-0"},
+		{Tag: "unit-foo-42"},
+	}}
+	result, err := s.uniter.InMaintenance(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.BoolResults{
+		Results: []params.BoolResult{
+			{Error: apiservertesting.ErrUnauthorized},
+			{Result: false},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	err = s.machine0.SetMaintenance(true)
+	c.Assert(err, gc.IsNil)
+	result, err = s.uniter.InMaintenance(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.BoolResults{
+		Results: []params.BoolResult{
+			{Error: apiservertesting.ErrUnauthorized},
+			{Result: true},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+}
+
+func (s *uniterSuite) TestWatchMaintenance(c *gc.C) {
+	c.Assert(s.resources.Count(), gc.Equals, 0)
+
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: "unit-mysql-0"},
+		{Tag: "unit-wordpress-0"},
+		{Tag: "unit-foo-42"},
+	}}
+	result, err := s.uniter.WatchMaintenance(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.NotifyWatchResults{
+		Results: []params.NotifyWatchResult{
+			{Error: apiservertesting.ErrUnauthorized},
+			{NotifyWatcherId: "1"},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	// Verify the resource was registered and stop when done
+	c.Assert(s.resources.Count(), gc.Equals, 1)
+	resource := s.resources.Get("1")
+	defer statetesting.AssertStop(c, resource)
+
+	// Check that the Watch has consumed the initial event ("returned" in
+	// the Watch call), and that it fires when the machine changes
+	// maintenance mode.
+	wc := statetesting.NewNotifyWatcherC(c, s.State, resource.(state.NotifyWatcher))
+	wc.AssertNoChange()
+	err = s.machine0.SetMaintenance(true)
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+}
+
 func (s *uniterSuite) TestWatchServiceRelations(c *gc.C) {
 	c.Assert(s.resources.Count(), gc.Equals, 0)
 
@@ -829,6 +1049,26 @@ func (s *uniterSuite) TestWatchServiceRelations(c *gc.C) {
 	s.assertOneStringsWatcher(c, result, err)
 }
 
+func (s *uniterSuite) TestHookTimeout(c *gc.C) {
+	err := s.wordpress.SetHookTimeout(time.Minute)
+	c.Assert(err, gc.IsNil)
+
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: "service-mysql"},
+		{Tag: "service-wordpress"},
+		{Tag: "service-foo"},
+	}}
+	result, err := s.uniter.HookTimeout(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.DurationResults{
+		Results: []params.DurationResult{
+			{Error: apiservertesting.ErrUnauthorized},
+			{Result: time.Minute},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+}
+
 func (s *uniterSuite) TestCharmArchiveURL(c *gc.C) {
 	dummyCharm := s.AddTestingCharm(c, "dummy")
 
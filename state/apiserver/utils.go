@@ -4,9 +4,15 @@
 package apiserver
 
 import (
+	"encoding/base64"
 	"fmt"
+	"net/http"
+	"strings"
 
+	"launchpad.net/juju-core/names"
 	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/state/apiserver/common"
 )
 
 // isMachineWithJob returns whether the given entity is a machine that
@@ -38,3 +44,37 @@ func setPassword(e state.Authenticator, password string) error {
 	}
 	return e.SetPassword(password)
 }
+
+// authenticateUser parses HTTP basic authentication and authorizes
+// the request by looking up the provided tag and password against
+// state. Only users, not agents, are authorized.
+func authenticateUser(st *state.State, r *http.Request) error {
+	parts := strings.Fields(r.Header.Get("Authorization"))
+	if len(parts) != 2 || parts[0] != "Basic" {
+		// Invalid header format or no header provided.
+		return fmt.Errorf("invalid request format")
+	}
+	// Challenge is a base64-encoded "tag:pass" string.
+	// See RFC 2617, Section 2.
+	challenge, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return fmt.Errorf("invalid request format")
+	}
+	tagPass := strings.SplitN(string(challenge), ":", 2)
+	if len(tagPass) != 2 {
+		return fmt.Errorf("invalid request format")
+	}
+	entity, err := checkCreds(st, params.Creds{
+		AuthTag:  tagPass[0],
+		Password: tagPass[1],
+	})
+	if err != nil {
+		return err
+	}
+	// Only allow users, not agents.
+	_, _, err = names.ParseTag(entity.Tag(), names.UserTagKind)
+	if err != nil {
+		return common.ErrBadCreds
+	}
+	return err
+}
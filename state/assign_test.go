@@ -273,6 +273,35 @@ func (s *AssignSuite) TestAssignMachineWhenDying(c *gc.C) {
 	testWhenDying(c, machine, expect, expect, assignTest)
 }
 
+func (s *AssignSuite) TestAssignMachineInMaintenance(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetMaintenance(true)
+	c.Assert(err, gc.IsNil)
+
+	unit, err := s.wordpress.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(machine)
+	c.Assert(err, gc.ErrorMatches, `cannot assign unit "wordpress/0" to machine 0: machine is in maintenance mode`)
+
+	// A stale machine value does not get around it.
+	stale, err := s.State.Machine(machine.Id())
+	c.Assert(err, gc.IsNil)
+	err = stale.SetMaintenance(false)
+	c.Assert(err, gc.IsNil)
+	err = machine.Refresh()
+	c.Assert(err, gc.IsNil)
+	err = stale.SetMaintenance(true)
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(machine)
+	c.Assert(err, gc.ErrorMatches, `cannot assign unit "wordpress/0" to machine 0: machine is in maintenance mode`)
+
+	err = machine.SetMaintenance(false)
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(machine)
+	c.Assert(err, gc.IsNil)
+}
+
 func (s *AssignSuite) TestAssignMachinePrincipalsChange(c *gc.C) {
 	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
 	c.Assert(err, gc.IsNil)
@@ -807,6 +836,15 @@ func (s *assignCleanSuite) TestAssignToMachineNoneAvailable(c *gc.C) {
 	m, err = s.assignUnit(unit)
 	c.Assert(m, gc.IsNil)
 	c.Assert(err, gc.ErrorMatches, eligibleMachinesInUse)
+
+	// Add a machine in maintenance mode and check it is not chosen.
+	m, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = m.SetMaintenance(true)
+	c.Assert(err, gc.IsNil)
+	m, err = s.assignUnit(unit)
+	c.Assert(m, gc.IsNil)
+	c.Assert(err, gc.ErrorMatches, eligibleMachinesInUse)
 }
 
 var assignUsingConstraintsTests = []struct {
@@ -2,6 +2,7 @@ package state
 
 import (
 	"fmt"
+	"time"
 
 	"labix.org/v2/mgo/bson"
 	"labix.org/v2/mgo/txn"
@@ -15,6 +16,9 @@ type cleanupDoc struct {
 	Id     bson.ObjectId `bson:"_id"`
 	Kind   string
 	Prefix string
+	// Before, if set, limits the cleanup to documents
+	// created before that time.
+	Before time.Time `bson:",omitempty"`
 }
 
 // newCleanupOp returns a txn.Op that creates a cleanup document with a unique
@@ -59,6 +63,8 @@ func (st *State) Cleanup() error {
 			err = st.cleanupServices()
 		case "machine":
 			err = st.cleanupMachine(doc.Prefix)
+		case "metrics":
+			err = st.cleanupMetrics(doc.Prefix, doc.Before)
 		default:
 			err = fmt.Errorf("unknown cleanup kind %q", doc.Kind)
 		}
@@ -92,6 +92,13 @@ func (s *CleanupSuite) TestCleanupEnvironmentServices(c *gc.C) {
 		err = unit.Refresh()
 		c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
 	}
+
+	// Removing the last unit removes the service, which schedules
+	// the removal of its metrics; that cleanup may not have been
+	// seen by the run that scheduled it.
+	err = mysql.Refresh()
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+	s.assertCleanupRuns(c)
 	s.assertDoesNotNeedCleanup(c)
 }
 
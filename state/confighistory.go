@@ -0,0 +1,167 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"time"
+
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/txn"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/errors"
+)
+
+// maxConfigRevisions holds the number of config revisions
+// retained for each service; older revisions are discarded.
+var maxConfigRevisions = 100
+
+// ConfigRevision holds a service's charm config settings as they
+// were immediately after a single change.
+type ConfigRevision struct {
+	// Revision numbers the change; revisions of a service's
+	// settings are numbered consecutively from 1.
+	Revision int
+
+	// CharmURL identifies the charm the settings apply to.
+	CharmURL *charm.URL
+
+	// Author holds the tag of the entity that made the change,
+	// or is empty if it is not known.
+	Author string
+
+	// Time holds when the change was made.
+	Time time.Time
+
+	// Settings holds the complete set of settings after the change.
+	// Unset values are omitted.
+	Settings charm.Settings
+}
+
+// settingsHistoryDoc records the recent revisions of a service's
+// charm config settings.
+type settingsHistoryDoc struct {
+	ServiceName string `bson:"_id"`
+	Revno       int
+	Revisions   []configRevisionDoc
+}
+
+// configRevisionDoc represents a single ConfigRevision in MongoDB.
+// Setting keys are escaped as in the settings collection.
+type configRevisionDoc struct {
+	Revision int
+	CharmURL *charm.URL
+	Author   string
+	Time     time.Time
+	Settings map[string]interface{}
+}
+
+func (doc *configRevisionDoc) revision() ConfigRevision {
+	return ConfigRevision{
+		Revision: doc.Revision,
+		CharmURL: doc.CharmURL,
+		Author:   doc.Author,
+		Time:     doc.Time,
+		Settings: copyMap(doc.Settings, unescapeReplacer.Replace),
+	}
+}
+
+// removeSettingsHistoryOp returns the operation required to remove
+// the settings history of the named service.
+func removeSettingsHistoryOp(st *State, serviceName string) txn.Op {
+	return txn.Op{
+		C:      st.settingsHistory.Name,
+		Id:     serviceName,
+		Remove: true,
+	}
+}
+
+// configRevisionOps returns the operations required to add a new
+// revision holding the supplied settings, for the given charm, to the
+// service's settings history. The operations assert that the history
+// has not changed since it was read.
+func (s *Service) configRevisionOps(author string, curl *charm.URL, settings map[string]interface{}) ([]txn.Op, error) {
+	var doc settingsHistoryDoc
+	err := s.st.settingsHistory.FindId(s.doc.Name).Select(D{{"revno", 1}}).One(&doc)
+	if err != nil && err != mgo.ErrNotFound {
+		return nil, fmt.Errorf("cannot read settings history for service %q: %v", s, err)
+	}
+	rev := newConfigRevisionDoc(doc.Revno+1, author, curl, settings)
+	if err == mgo.ErrNotFound {
+		return []txn.Op{createSettingsHistoryOp(s.st, s.doc.Name, rev)}, nil
+	}
+	return []txn.Op{{
+		C:      s.st.settingsHistory.Name,
+		Id:     s.doc.Name,
+		Assert: D{{"revno", doc.Revno}},
+		Update: D{
+			{"$set", D{{"revno", rev.Revision}}},
+			{"$push", D{{"revisions", D{
+				{"$each", []configRevisionDoc{rev}},
+				{"$slice", -maxConfigRevisions},
+			}}}},
+		},
+	}}, nil
+}
+
+// newConfigRevisionDoc returns a revision, made now, holding the
+// supplied settings.
+func newConfigRevisionDoc(revision int, author string, curl *charm.URL, settings map[string]interface{}) configRevisionDoc {
+	return configRevisionDoc{
+		Revision: revision,
+		CharmURL: curl,
+		Author:   author,
+		Time:     time.Now(),
+		Settings: copyMap(settings, escapeReplacer.Replace),
+	}
+}
+
+// createSettingsHistoryOp returns the operation required to create
+// the settings history of the named service, starting with the
+// given revision.
+func createSettingsHistoryOp(st *State, serviceName string, rev configRevisionDoc) txn.Op {
+	return txn.Op{
+		C:      st.settingsHistory.Name,
+		Id:     serviceName,
+		Assert: txn.DocMissing,
+		Insert: &settingsHistoryDoc{
+			ServiceName: serviceName,
+			Revno:       rev.Revision,
+			Revisions:   []configRevisionDoc{rev},
+		},
+	}
+}
+
+// ConfigHistory returns the retained revisions of the service's
+// charm config settings, oldest first.
+func (s *Service) ConfigHistory() ([]ConfigRevision, error) {
+	var doc settingsHistoryDoc
+	err := s.st.settingsHistory.FindId(s.doc.Name).One(&doc)
+	if err == mgo.ErrNotFound {
+		return []ConfigRevision{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot get settings history for service %q: %v", s, err)
+	}
+	revisions := make([]ConfigRevision, len(doc.Revisions))
+	for i := range doc.Revisions {
+		revisions[i] = doc.Revisions[i].revision()
+	}
+	return revisions, nil
+}
+
+// ConfigRevision returns the given revision of the service's
+// charm config settings.
+func (s *Service) ConfigRevision(revision int) (ConfigRevision, error) {
+	revisions, err := s.ConfigHistory()
+	if err != nil {
+		return ConfigRevision{}, err
+	}
+	for _, rev := range revisions {
+		if rev.Revision == revision {
+			return rev, nil
+		}
+	}
+	return ConfigRevision{}, errors.NotFoundf("settings revision %d for service %q", revision, s)
+}
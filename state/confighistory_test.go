@@ -0,0 +1,135 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/state"
+	jc "launchpad.net/juju-core/testing/checkers"
+)
+
+type ConfigHistorySuite struct {
+	ConnSuite
+	service *state.Service
+}
+
+var _ = gc.Suite(&ConfigHistorySuite{})
+
+func (s *ConfigHistorySuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.service = s.AddTestingService(c, "dummy-service", s.AddTestingCharm(c, "dummy"))
+}
+
+func (s *ConfigHistorySuite) TestInitialRevision(c *gc.C) {
+	revisions, err := s.service.ConfigHistory()
+	c.Assert(err, gc.IsNil)
+	c.Assert(revisions, gc.HasLen, 1)
+	curl, _ := s.service.CharmURL()
+	c.Assert(revisions[0].Revision, gc.Equals, 1)
+	c.Assert(revisions[0].CharmURL, gc.DeepEquals, curl)
+	c.Assert(revisions[0].Author, gc.Equals, "user-admin")
+	c.Assert(revisions[0].Settings, gc.DeepEquals, charm.Settings{})
+	_, err = s.service.ConfigRevision(2)
+	c.Assert(err, gc.ErrorMatches, `settings revision 2 for service "dummy-service" not found`)
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+}
+
+func (s *ConfigHistorySuite) TestUpdatesAreRecorded(c *gc.C) {
+	before := time.Now().Add(-time.Second)
+	err := s.service.UpdateConfigSettings(charm.Settings{"title": "one", "username": "bob"})
+	c.Assert(err, gc.IsNil)
+	err = s.service.UpdateConfigSettingsBy("user-admin", charm.Settings{"title": "two", "username": nil})
+	c.Assert(err, gc.IsNil)
+	// Changes that do not alter the settings are not recorded.
+	err = s.service.UpdateConfigSettingsBy("user-other", charm.Settings{"title": "two"})
+	c.Assert(err, gc.IsNil)
+
+	revisions, err := s.service.ConfigHistory()
+	c.Assert(err, gc.IsNil)
+	c.Assert(revisions, gc.HasLen, 3)
+	curl, _ := s.service.CharmURL()
+	for i, rev := range revisions {
+		c.Check(rev.Revision, gc.Equals, i+1)
+		c.Check(rev.CharmURL, gc.DeepEquals, curl)
+		c.Check(rev.Time.After(before), jc.IsTrue)
+	}
+	c.Check(revisions[1].Author, gc.Equals, "")
+	c.Check(revisions[1].Settings, gc.DeepEquals, charm.Settings{"title": "one", "username": "bob"})
+	c.Check(revisions[2].Author, gc.Equals, "user-admin")
+	c.Check(revisions[2].Settings, gc.DeepEquals, charm.Settings{"title": "two"})
+
+	rev, err := s.service.ConfigRevision(2)
+	c.Assert(err, gc.IsNil)
+	c.Assert(rev, gc.DeepEquals, revisions[1])
+}
+
+func (s *ConfigHistorySuite) TestConcurrentUpdatesAreRecorded(c *gc.C) {
+	defer state.SetBeforeHooks(c, s.State, func() {
+		err := s.service.UpdateConfigSettingsBy("user-other", charm.Settings{"username": "bob"})
+		c.Assert(err, gc.IsNil)
+	}).Check()
+
+	err := s.service.UpdateConfigSettingsBy("user-admin", charm.Settings{"title": "one"})
+	c.Assert(err, gc.IsNil)
+
+	settings, err := s.service.ConfigSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, gc.DeepEquals, charm.Settings{"title": "one", "username": "bob"})
+	revisions, err := s.service.ConfigHistory()
+	c.Assert(err, gc.IsNil)
+	c.Assert(revisions, gc.HasLen, 3)
+	c.Assert(revisions[1].Author, gc.Equals, "user-other")
+	c.Assert(revisions[1].Settings, gc.DeepEquals, charm.Settings{"username": "bob"})
+	c.Assert(revisions[2].Author, gc.Equals, "user-admin")
+	c.Assert(revisions[2].Settings, gc.DeepEquals, charm.Settings{"title": "one", "username": "bob"})
+}
+
+func (s *ConfigHistorySuite) TestSetCharmIsRecorded(c *gc.C) {
+	err := s.service.UpdateConfigSettings(charm.Settings{"title": "one", "username": "bob"})
+	c.Assert(err, gc.IsNil)
+	ch := s.AddConfigCharm(c, "dummy", "options:\n  title: {default: My Title, description: Desc, type: string}\n", 2)
+	err = s.service.SetCharm(ch, false)
+	c.Assert(err, gc.IsNil)
+
+	revisions, err := s.service.ConfigHistory()
+	c.Assert(err, gc.IsNil)
+	c.Assert(revisions, gc.HasLen, 3)
+	c.Assert(revisions[2].Revision, gc.Equals, 3)
+	c.Assert(revisions[2].CharmURL, gc.DeepEquals, ch.URL())
+	c.Assert(revisions[2].Settings, gc.DeepEquals, charm.Settings{"title": "one"})
+}
+
+func (s *ConfigHistorySuite) TestOldRevisionsDiscarded(c *gc.C) {
+	defer state.SetMaxConfigRevisions(2)()
+	for _, title := range []string{"one", "two", "three"} {
+		err := s.service.UpdateConfigSettings(charm.Settings{"title": title})
+		c.Assert(err, gc.IsNil)
+	}
+	revisions, err := s.service.ConfigHistory()
+	c.Assert(err, gc.IsNil)
+	c.Assert(revisions, gc.HasLen, 2)
+	c.Assert(revisions[0].Revision, gc.Equals, 3)
+	c.Assert(revisions[1].Revision, gc.Equals, 4)
+	c.Assert(revisions[1].Settings, gc.DeepEquals, charm.Settings{"title": "three"})
+	_, err = s.service.ConfigRevision(2)
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+}
+
+func (s *ConfigHistorySuite) TestHistoryRemovedWithService(c *gc.C) {
+	err := s.service.UpdateConfigSettings(charm.Settings{"title": "one"})
+	c.Assert(err, gc.IsNil)
+	err = s.service.Destroy()
+	c.Assert(err, gc.IsNil)
+
+	s.service = s.AddTestingService(c, "dummy-service", s.AddTestingCharm(c, "dummy"))
+	revisions, err := s.service.ConfigHistory()
+	c.Assert(err, gc.IsNil)
+	c.Assert(revisions, gc.HasLen, 1)
+	c.Assert(revisions[0].Settings, gc.DeepEquals, charm.Settings{})
+}
@@ -4,11 +4,14 @@
 package state
 
 import (
+	"fmt"
+
 	"labix.org/v2/mgo"
 	"labix.org/v2/mgo/txn"
 
 	"launchpad.net/juju-core/errors"
 	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/utils"
 )
 
 // environGlobalKey is the key for the environment, its
@@ -27,6 +30,16 @@ type environmentDoc struct {
 	UUID string `bson:"_id"`
 	Name string
 	Life Life
+	// MigrationTarget is set once the environment has been
+	// imported into another state server.
+	MigrationTarget *migrationTargetDoc `bson:",omitempty"`
+}
+
+// migrationTargetDoc records the API addresses and CA certificate
+// of the state server an environment has been imported into.
+type migrationTargetDoc struct {
+	Addrs  []string
+	CACert string
 }
 
 // Environment returns the environment entity.
@@ -65,6 +78,48 @@ func (e *Environment) Life() Life {
 	return e.doc.Life
 }
 
+// MigrationTarget returns the API addresses and CA certificate of the
+// state server the environment has been imported into, and whether the
+// environment has been imported at all.
+func (e *Environment) MigrationTarget() (addrs []string, caCert string, ok bool) {
+	if e.doc.MigrationTarget == nil {
+		return nil, "", false
+	}
+	target := e.doc.MigrationTarget
+	return append([]string{}, target.Addrs...), target.CACert, true
+}
+
+// SetMigrationTarget records that the environment has been imported
+// into the state server with the given API addresses and CA
+// certificate. The environment's agents then connect to that state
+// server instead.
+func (e *Environment) SetMigrationTarget(addrs []string, caCert string) (err error) {
+	defer utils.ErrorContextf(&err, "cannot set migration target of environment %q", e.doc.Name)
+	if len(addrs) == 0 {
+		return fmt.Errorf("no API addresses given")
+	}
+	if caCert == "" {
+		return fmt.Errorf("no CA certificate given")
+	}
+	target := &migrationTargetDoc{
+		Addrs:  addrs,
+		CACert: caCert,
+	}
+	ops := []txn.Op{{
+		C:      e.st.environments.Name,
+		Id:     e.doc.UUID,
+		Assert: isEnvAliveDoc,
+		Update: D{{"$set", D{{"migrationtarget", target}}}},
+	}}
+	if err := e.st.runTransaction(ops); err == txn.ErrAborted {
+		return fmt.Errorf("environment is no longer alive")
+	} else if err != nil {
+		return err
+	}
+	e.doc.MigrationTarget = target
+	return nil
+}
+
 // globalKey returns the global database key for the environment.
 func (e *Environment) globalKey() string {
 	return environGlobalKey
@@ -88,6 +143,12 @@ func (e *Environment) Destroy() error {
 	if e.Life() != Alive {
 		return nil
 	}
+	// Hosted environments would be left without a state server.
+	if hosted, err := e.st.AllHostedEnvironments(); err != nil {
+		return err
+	} else if len(hosted) > 0 {
+		return fmt.Errorf("environment hosts %d other environment(s); destroy them first", len(hosted))
+	}
 	// TODO(axw) 2013-12-11 #1218688
 	// Resolve the race between checking for manual machines and
 	// destroying the environment. We can set Environment to Dying
@@ -121,7 +182,7 @@ func (e *Environment) Destroy() error {
 // createEnvironmentOp returns the operation needed to create
 // an environment document with the given name and UUID.
 func createEnvironmentOp(st *State, name, uuid string) txn.Op {
-	doc := &environmentDoc{uuid, name, Alive}
+	doc := &environmentDoc{UUID: uuid, Name: name, Life: Alive}
 	return txn.Op{
 		C:      st.environments.Name,
 		Id:     uuid,
@@ -7,6 +7,7 @@ import (
 	gc "launchpad.net/gocheck"
 
 	"launchpad.net/juju-core/state"
+	jc "launchpad.net/juju-core/testing/checkers"
 )
 
 type EnvironSuite struct {
@@ -52,3 +53,29 @@ func (s *EnvironSuite) TestAnnotatorForEnvironment(c *gc.C) {
 		return s.State.Environment()
 	})
 }
+
+func (s *EnvironSuite) TestMigrationTarget(c *gc.C) {
+	_, _, ok := s.env.MigrationTarget()
+	c.Assert(ok, jc.IsFalse)
+
+	err := s.env.SetMigrationTarget(nil, "ca-cert")
+	c.Assert(err, gc.ErrorMatches, `cannot set migration target of environment "testenv": no API addresses given`)
+	err = s.env.SetMigrationTarget([]string{"target.example:17070"}, "ca-cert")
+	c.Assert(err, gc.IsNil)
+
+	env, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	for _, e := range []*state.Environment{s.env, env} {
+		addrs, caCert, ok := e.MigrationTarget()
+		c.Assert(ok, jc.IsTrue)
+		c.Assert(addrs, gc.DeepEquals, []string{"target.example:17070"})
+		c.Assert(caCert, gc.Equals, "ca-cert")
+	}
+}
+
+func (s *EnvironSuite) TestSetMigrationTargetDying(c *gc.C) {
+	err := s.env.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = s.env.SetMigrationTarget([]string{"target.example:17070"}, "ca-cert")
+	c.Assert(err, gc.ErrorMatches, `cannot set migration target of environment "testenv": environment is no longer alive`)
+}
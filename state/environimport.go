@@ -0,0 +1,595 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"labix.org/v2/mgo/txn"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/environs/config"
+	"launchpad.net/juju-core/instance"
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/utils"
+)
+
+// ImportedCharm holds a charm of an environment being imported,
+// whose bundle has already been stored for the new environment.
+type ImportedCharm struct {
+	Charm     charm.Charm
+	BundleURL *url.URL
+}
+
+// ImportEnvironment recreates the environment described by model,
+// as written by Export, as an environment hosted by the state server
+// of the environment held by st. The imported environment keeps its
+// UUID, so that its agents can log in to it once they are told about
+// the new state server, and its users and agents keep their
+// passwords. Machines with the JobManageEnviron job are left out,
+// as hosted environments have no state servers of their own. The
+// charms map holds the charm used by each charm URL in the model;
+// owner holds the tag of the user importing the environment.
+func (st *State) ImportEnvironment(model *params.EnvironmentModel, charms map[string]ImportedCharm, owner string) (_ *HostedEnvironment, err error) {
+	defer utils.ErrorContextf(&err, "cannot import environment %q", model.Name)
+	if model.Version != params.EnvironmentModelVersion {
+		return nil, fmt.Errorf("unsupported model version %d", model.Version)
+	}
+	cfg, err := config.New(config.NoDefaults, model.Config)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Name() != model.Name {
+		return nil, fmt.Errorf("environment name %q does not match configuration", model.Name)
+	}
+	cons, err := constraints.Parse(model.Constraints)
+	if err != nil {
+		return nil, err
+	}
+	doc := hostedEnvironmentDoc{
+		Name:  model.Name,
+		UUID:  model.UUID,
+		Owner: owner,
+	}
+	return st.addHostedEnvironment(doc, cfg, cons, func(hst *State) error {
+		imp := &importer{
+			st:     hst,
+			model:  model,
+			charms: charms,
+			cons:   cons,
+		}
+		return imp.run()
+	})
+}
+
+// importer populates a new hosted environment from a model.
+type importer struct {
+	st     *State
+	model  *params.EnvironmentModel
+	charms map[string]ImportedCharm
+	cons   constraints.Value
+
+	// stateServers holds the ids of the machines left out of
+	// the imported environment.
+	stateServers map[string]bool
+}
+
+func (imp *importer) run() error {
+	steps := []struct {
+		what string
+		ops  func() ([]txn.Op, error)
+	}{
+		{"environment", imp.environmentOps},
+		{"machines", imp.machineOps},
+		{"charms", imp.charmOps},
+		{"services", imp.serviceOps},
+		// Relations are imported once their services
+		// exist, as their endpoints are found from the
+		// services' charms.
+		{"relations", imp.relationOps},
+	}
+	for _, step := range steps {
+		ops, err := step.ops()
+		if err != nil {
+			return fmt.Errorf("cannot import %s: %v", step.what, err)
+		}
+		if err := imp.st.runTransaction(ops); err != nil {
+			return fmt.Errorf("cannot import %s: %v", step.what, err)
+		}
+	}
+	return imp.setSequences()
+}
+
+func (imp *importer) environmentOps() ([]txn.Op, error) {
+	var ops []txn.Op
+	for _, u := range imp.model.Users {
+		if !validUser.MatchString(u.Name) {
+			return nil, fmt.Errorf("invalid user name %q", u.Name)
+		}
+		ops = append(ops, txn.Op{
+			C:      imp.st.users.Name,
+			Id:     u.Name,
+			Assert: txn.DocMissing,
+			Insert: &userDoc{
+				Name:         u.Name,
+				PasswordHash: u.PasswordHash,
+				PasswordSalt: u.PasswordSalt,
+			},
+		})
+	}
+	a := annotator{
+		globalKey: environGlobalKey,
+		tag:       names.EnvironTag(imp.model.UUID),
+		st:        imp.st,
+	}
+	annotationOps, err := imp.annotationOps(a, imp.model.Annotations)
+	if err != nil {
+		return nil, err
+	}
+	return append(ops, annotationOps...), nil
+}
+
+func (imp *importer) machineOps() ([]txn.Op, error) {
+	imp.stateServers = make(map[string]bool)
+	for _, m := range imp.model.Machines {
+		for _, job := range m.Jobs {
+			if job == string(params.JobManageEnviron) {
+				imp.stateServers[m.Id] = true
+			}
+		}
+	}
+	principals := make(map[string][]string)
+	for _, svc := range imp.model.Services {
+		for _, u := range svc.Units {
+			if u.Machine == "" || u.Principal != "" {
+				continue
+			}
+			if imp.stateServers[u.Machine] {
+				return nil, fmt.Errorf("unit %q is assigned to state server machine %s", u.Name, u.Machine)
+			}
+			principals[u.Machine] = append(principals[u.Machine], u.Name)
+		}
+	}
+	children := make(map[string][]string)
+	for _, m := range imp.model.Machines {
+		if parentId := ParentId(m.Id); parentId != "" {
+			if topId := TopParentId(m.Id); imp.stateServers[topId] {
+				return nil, fmt.Errorf("machine %s is a container on state server machine %s", m.Id, topId)
+			}
+			children[parentId] = append(children[parentId], m.Id)
+		}
+	}
+	var ops []txn.Op
+	for _, m := range imp.model.Machines {
+		if imp.stateServers[m.Id] {
+			continue
+		}
+		machineOps, err := imp.importMachineOps(m, principals[m.Id], children[m.Id])
+		if err != nil {
+			return nil, fmt.Errorf("machine %s: %v", m.Id, err)
+		}
+		ops = append(ops, machineOps...)
+	}
+	return ops, nil
+}
+
+func (imp *importer) importMachineOps(m params.MachineModel, principals, children []string) ([]txn.Op, error) {
+	if !names.IsMachine(m.Id) {
+		return nil, fmt.Errorf("invalid machine id")
+	}
+	life, err := parseLife(m.Life)
+	if err != nil {
+		return nil, err
+	}
+	cons, err := constraints.Parse(m.Constraints)
+	if err != nil {
+		return nil, err
+	}
+	mdoc := &machineDoc{
+		Id:            m.Id,
+		Nonce:         m.Nonce,
+		Series:        m.Series,
+		ContainerType: string(ContainerTypeFromId(m.Id)),
+		Principals:    principals,
+		Life:          life,
+		PasswordHash:  m.PasswordHash,
+		Clean:         len(principals) == 0 && len(children) == 0,
+		Maintenance:   m.Maintenance,
+	}
+	for _, job := range m.Jobs {
+		machineJob, err := MachineJobFromParams(params.MachineJob(job))
+		if err != nil {
+			return nil, err
+		}
+		mdoc.Jobs = append(mdoc.Jobs, machineJob)
+	}
+	var addrs []instance.Address
+	for _, value := range m.Addresses {
+		addrs = append(addrs, instance.NewAddress(value))
+	}
+	mdoc.Addresses = instanceAddressesToAddresses(addrs)
+	ops := imp.st.insertNewMachineOps(mdoc, cons)
+	ops = append(ops, imp.st.insertNewContainerRefOp(mdoc.Id, children...))
+	if m.InstanceId != "" {
+		hc, err := instance.ParseHardware(m.Hardware)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, txn.Op{
+			C:      imp.st.instanceData.Name,
+			Id:     mdoc.Id,
+			Assert: txn.DocMissing,
+			Insert: &instanceData{
+				Id:         mdoc.Id,
+				InstanceId: instance.Id(m.InstanceId),
+				Arch:       hc.Arch,
+				Mem:        hc.Mem,
+				RootDisk:   hc.RootDisk,
+				CpuCores:   hc.CpuCores,
+				CpuPower:   hc.CpuPower,
+				Tags:       hc.Tags,
+			},
+		})
+	}
+	machine := newMachine(imp.st, mdoc)
+	annotationOps, err := imp.annotationOps(machine.annotator, m.Annotations)
+	if err != nil {
+		return nil, err
+	}
+	return append(ops, annotationOps...), nil
+}
+
+func (imp *importer) charmOps() ([]txn.Op, error) {
+	var ops []txn.Op
+	for _, m := range imp.model.Charms {
+		curl, err := charm.ParseURL(m.URL)
+		if err != nil {
+			return nil, err
+		}
+		ch, ok := imp.charms[m.URL]
+		if !ok {
+			return nil, fmt.Errorf("charm %q not supplied", m.URL)
+		}
+		ops = append(ops, txn.Op{
+			C:      imp.st.charms.Name,
+			Id:     curl,
+			Assert: txn.DocMissing,
+			Insert: &charmDoc{
+				URL:          curl,
+				Meta:         ch.Charm.Meta(),
+				Config:       ch.Charm.Config(),
+				BundleURL:    ch.BundleURL,
+				BundleSha256: m.BundleSha256,
+			},
+		})
+	}
+	return ops, nil
+}
+
+func (imp *importer) serviceOps() ([]txn.Op, error) {
+	relationCounts := make(map[string]int)
+	for _, r := range imp.model.Relations {
+		for _, ep := range r.Endpoints {
+			relationCounts[ep.Service]++
+		}
+	}
+	var ops []txn.Op
+	for _, svc := range imp.model.Services {
+		serviceOps, err := imp.importServiceOps(svc, relationCounts[svc.Name])
+		if err != nil {
+			return nil, fmt.Errorf("service %q: %v", svc.Name, err)
+		}
+		ops = append(ops, serviceOps...)
+	}
+	return ops, nil
+}
+
+func (imp *importer) importServiceOps(m params.ServiceModel, relationCount int) ([]txn.Op, error) {
+	if !names.IsService(m.Name) {
+		return nil, fmt.Errorf("invalid name")
+	}
+	life, err := parseLife(m.Life)
+	if err != nil {
+		return nil, err
+	}
+	curl, err := charm.ParseURL(m.Charm)
+	if err != nil {
+		return nil, err
+	}
+	ch, ok := imp.charms[m.Charm]
+	if !ok {
+		return nil, fmt.Errorf("charm %q not supplied", m.Charm)
+	}
+	cons, err := constraints.Parse(m.Constraints)
+	if err != nil {
+		return nil, err
+	}
+	owner := m.Owner
+	if owner == "" {
+		owner = "user-admin"
+	}
+	sdoc := &serviceDoc{
+		Name:          m.Name,
+		Series:        curl.Series,
+		Subordinate:   ch.Charm.Meta().Subordinate,
+		CharmURL:      curl,
+		Life:          life,
+		UnitCount:     len(m.Units),
+		RelationCount: relationCount,
+		Exposed:       m.Exposed,
+		MinUnits:      m.MinUnits,
+		OwnerTag:      owner,
+	}
+	svc := newService(imp.st, sdoc)
+	// Each charm URL in use by the service or its units
+	// has settings counting the references to them.
+	settingsRefs := map[string]int{curl.String(): 1}
+	subordinates := make(map[string][]string)
+	for _, u := range m.Units {
+		if u.Principal != "" {
+			subordinates[u.Principal] = append(subordinates[u.Principal], u.Name)
+		}
+	}
+	var unitOps []txn.Op
+	for _, u := range m.Units {
+		if !strings.HasPrefix(u.Name, m.Name+"/") || !names.IsUnit(u.Name) {
+			return nil, fmt.Errorf("invalid unit name %q", u.Name)
+		}
+		n, _ := strconv.Atoi(u.Name[len(m.Name)+1:])
+		if n >= sdoc.UnitSeq {
+			sdoc.UnitSeq = n + 1
+		}
+		ops, err := imp.importUnitOps(svc, u, subordinates[u.Name], cons)
+		if err != nil {
+			return nil, fmt.Errorf("unit %q: %v", u.Name, err)
+		}
+		unitOps = append(unitOps, ops...)
+		if u.Charm != "" {
+			settingsRefs[u.Charm]++
+		}
+	}
+	ops := []txn.Op{{
+		C:      imp.st.services.Name,
+		Id:     m.Name,
+		Assert: txn.DocMissing,
+		Insert: sdoc,
+	},
+		createConstraintsOp(imp.st, svc.globalKey(), cons),
+		createSettingsHistoryOp(imp.st, m.Name, newConfigRevisionDoc(1, owner, curl, m.Settings)),
+	}
+	curls := make([]string, 0, len(settingsRefs))
+	for curl := range settingsRefs {
+		curls = append(curls, curl)
+	}
+	sort.Strings(curls)
+	for _, s := range curls {
+		refCurl, err := charm.ParseURL(s)
+		if err != nil {
+			return nil, err
+		}
+		key := serviceSettingsKey(m.Name, refCurl)
+		ops = append(ops, createSettingsOp(imp.st, key, m.Settings), txn.Op{
+			C:      imp.st.settingsrefs.Name,
+			Id:     key,
+			Assert: txn.DocMissing,
+			Insert: settingsRefsDoc{settingsRefs[s]},
+		})
+	}
+	if m.MinUnits > 0 {
+		ops = append(ops, txn.Op{
+			C:      imp.st.minUnits.Name,
+			Id:     m.Name,
+			Assert: txn.DocMissing,
+			Insert: &minUnitsDoc{ServiceName: m.Name},
+		})
+	}
+	annotationOps, err := imp.annotationOps(svc.annotator, m.Annotations)
+	if err != nil {
+		return nil, err
+	}
+	ops = append(ops, annotationOps...)
+	return append(ops, unitOps...), nil
+}
+
+func (imp *importer) importUnitOps(svc *Service, m params.UnitModel, subordinates []string, scons constraints.Value) ([]txn.Op, error) {
+	life, err := parseLife(m.Life)
+	if err != nil {
+		return nil, err
+	}
+	udoc := &unitDoc{
+		Name:         m.Name,
+		Service:      svc.doc.Name,
+		Series:       svc.doc.Series,
+		Principal:    m.Principal,
+		Subordinates: subordinates,
+		MachineId:    m.Machine,
+		Life:         life,
+		PasswordHash: m.PasswordHash,
+	}
+	if m.Charm != "" {
+		if udoc.CharmURL, err = charm.ParseURL(m.Charm); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range m.Ports {
+		port, err := parsePort(p)
+		if err != nil {
+			return nil, err
+		}
+		udoc.Ports = append(udoc.Ports, port)
+	}
+	ops := []txn.Op{{
+		C:      imp.st.units.Name,
+		Id:     m.Name,
+		Assert: txn.DocMissing,
+		Insert: udoc,
+	},
+		createStatusOp(imp.st, unitGlobalKey(m.Name), statusDoc{
+			Status: params.StatusPending,
+		}),
+	}
+	if m.Principal == "" {
+		ops = append(ops, createConstraintsOp(imp.st, unitGlobalKey(m.Name), scons.WithFallbacks(imp.cons)))
+	}
+	unit := newUnit(imp.st, udoc)
+	annotationOps, err := imp.annotationOps(unit.annotator, m.Annotations)
+	if err != nil {
+		return nil, err
+	}
+	return append(ops, annotationOps...), nil
+}
+
+func (imp *importer) relationOps() ([]txn.Op, error) {
+	var ops []txn.Op
+	for _, r := range imp.model.Relations {
+		relationOps, err := imp.importRelationOps(r)
+		if err != nil {
+			return nil, fmt.Errorf("relation %q: %v", r.Key, err)
+		}
+		ops = append(ops, relationOps...)
+	}
+	return ops, nil
+}
+
+func (imp *importer) importRelationOps(m params.RelationModel) ([]txn.Op, error) {
+	life, err := parseLife(m.Life)
+	if err != nil {
+		return nil, err
+	}
+	var eps []Endpoint
+	for _, epm := range m.Endpoints {
+		svc, err := imp.st.Service(epm.Service)
+		if err != nil {
+			return nil, err
+		}
+		ep, err := svc.Endpoint(epm.Name)
+		if err != nil {
+			return nil, err
+		}
+		ep.Scope = charm.RelationScope(epm.Scope)
+		eps = append(eps, ep)
+	}
+	rdoc := &relationDoc{
+		Key:       relationKey(eps),
+		Id:        m.Id,
+		Endpoints: eps,
+		Life:      life,
+	}
+	if rdoc.Key != m.Key {
+		return nil, fmt.Errorf("endpoints do not match key")
+	}
+	rel := newRelation(imp.st, rdoc)
+	var ops []txn.Op
+	for _, ru := range m.Units {
+		key, err := imp.relationUnitKey(rel, ru.Unit)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, createSettingsOp(imp.st, key, ru.Settings))
+		if ru.InScope {
+			rdoc.UnitCount++
+			ops = append(ops, txn.Op{
+				C:      imp.st.relationScopes.Name,
+				Id:     key,
+				Assert: txn.DocMissing,
+				Insert: relationScopeDoc{key},
+			})
+		}
+	}
+	return append([]txn.Op{{
+		C:      imp.st.relations.Name,
+		Id:     rdoc.Key,
+		Assert: txn.DocMissing,
+		Insert: rdoc,
+	}}, ops...), nil
+}
+
+// relationUnitKey returns the key of the named unit in the relation,
+// as found by RelationUnit.
+func (imp *importer) relationUnitKey(rel *Relation, unitName string) (string, error) {
+	u, err := imp.st.Unit(unitName)
+	if err != nil {
+		return "", err
+	}
+	ru, err := rel.Unit(u)
+	if err != nil {
+		return "", err
+	}
+	return ru.key(unitName)
+}
+
+// setSequences sets the sequences used to name new machines,
+// containers and relations beyond the ones imported.
+func (imp *importer) setSequences() error {
+	counters := make(map[string]int)
+	next := func(name, id string) {
+		n, _ := strconv.Atoi(id)
+		if n+1 > counters[name] {
+			counters[name] = n + 1
+		}
+	}
+	for _, m := range imp.model.Machines {
+		parentId := ParentId(m.Id)
+		if parentId == "" {
+			next("machine", m.Id)
+			continue
+		}
+		parts := strings.Split(m.Id, "/")
+		next(fmt.Sprintf("machine%s%sContainer", parentId, ContainerTypeFromId(m.Id)), parts[len(parts)-1])
+	}
+	for _, r := range imp.model.Relations {
+		next("relation", strconv.Itoa(r.Id))
+	}
+	// Sequences are incremented outside transactions, so they
+	// are created outside them too.
+	for name, counter := range counters {
+		if err := imp.st.sequences.Insert(&sequenceDoc{name, counter}); err != nil {
+			return fmt.Errorf("cannot set %q sequence number: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// annotationOps returns the operations to insert the given annotations
+// of an imported entity, if there are any.
+func (imp *importer) annotationOps(a annotator, annotations map[string]string) ([]txn.Op, error) {
+	if len(annotations) == 0 {
+		return nil, nil
+	}
+	ops, err := a.insertOps(annotations)
+	if err != nil {
+		return nil, err
+	}
+	// The entity is inserted in the same transaction, so
+	// its existence cannot be asserted.
+	return ops[:1], nil
+}
+
+func parseLife(s string) (Life, error) {
+	for life, str := range lifeStrings {
+		if string(str) == s {
+			return Life(life), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid life %q", s)
+}
+
+// parsePort parses a port as formatted by instance.Port.String.
+func parsePort(s string) (instance.Port, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 {
+		return instance.Port{}, fmt.Errorf("invalid port %q", s)
+	}
+	n, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return instance.Port{}, fmt.Errorf("invalid port %q", s)
+	}
+	return instance.Port{Protocol: parts[1], Number: n}, nil
+}
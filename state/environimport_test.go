@@ -0,0 +1,227 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/instance"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/utils"
+)
+
+type EnvironImportSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&EnvironImportSuite{})
+
+// addEnvironment fills the environment with machines, services,
+// units and relations.
+func (s *EnvironImportSuite) addEnvironment(c *gc.C) {
+	_, err := s.State.AddUser("bob", "bob-secret")
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetEnvironConstraints(constraints.MustParse("mem=2G"))
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddMachine("quantal", state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+
+	m1, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	hc := instance.MustParseHardware("arch=amd64 mem=4096M")
+	err = m1.SetProvisioned("inst-1", "fake_nonce", &hc)
+	c.Assert(err, gc.IsNil)
+	err = m1.SetPassword("machine-1-password")
+	c.Assert(err, gc.IsNil)
+	err = m1.SetAnnotations(map[string]string{"rack": "r1"})
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddMachineInsideMachine(state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}, m1.Id(), instance.LXC)
+	c.Assert(err, gc.IsNil)
+
+	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	err = wordpress.SetExposed()
+	c.Assert(err, gc.IsNil)
+	err = wordpress.SetMinUnits(1)
+	c.Assert(err, gc.IsNil)
+	wu, err := wordpress.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = wu.AssignToMachine(m1)
+	c.Assert(err, gc.IsNil)
+	err = wu.OpenPort("tcp", 80)
+	c.Assert(err, gc.IsNil)
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	mu, err := mysql.AddUnit()
+	c.Assert(err, gc.IsNil)
+	s.AddTestingService(c, "logging", s.AddTestingCharm(c, "logging"))
+
+	eps, err := s.State.InferEndpoints([]string{"wordpress", "mysql"})
+	c.Assert(err, gc.IsNil)
+	rel, err := s.State.AddRelation(eps...)
+	c.Assert(err, gc.IsNil)
+	ru, err := rel.Unit(mu)
+	c.Assert(err, gc.IsNil)
+	err = ru.EnterScope(map[string]interface{}{"user": "admin"})
+	c.Assert(err, gc.IsNil)
+
+	eps, err = s.State.InferEndpoints([]string{"logging", "wordpress"})
+	c.Assert(err, gc.IsNil)
+	rel, err = s.State.AddRelation(eps...)
+	c.Assert(err, gc.IsNil)
+	ru, err = rel.Unit(wu)
+	c.Assert(err, gc.IsNil)
+	err = ru.EnterScope(nil)
+	c.Assert(err, gc.IsNil)
+}
+
+// importedCharms returns the charms used by the model, as they are
+// stored in the state server's environment.
+func (s *EnvironImportSuite) importedCharms(c *gc.C, model *params.EnvironmentModel) map[string]state.ImportedCharm {
+	charms := make(map[string]state.ImportedCharm)
+	for _, m := range model.Charms {
+		curl := charm.MustParseURL(m.URL)
+		ch, err := s.State.Charm(curl)
+		c.Assert(err, gc.IsNil)
+		charms[m.URL] = state.ImportedCharm{
+			Charm:     ch,
+			BundleURL: ch.BundleURL(),
+		}
+	}
+	return charms
+}
+
+// renamed returns the model renamed, with a new UUID, so that it can
+// be imported next to the environment it was exported from.
+func renamed(c *gc.C, model *params.EnvironmentModel, name string) *params.EnvironmentModel {
+	uuid, err := utils.NewUUID()
+	c.Assert(err, gc.IsNil)
+	copied := *model
+	copied.Name = name
+	copied.UUID = uuid.String()
+	copied.Config = make(map[string]interface{})
+	for k, v := range model.Config {
+		copied.Config[k] = v
+	}
+	copied.Config["name"] = name
+	return &copied
+}
+
+func (s *EnvironImportSuite) TestImportRoundTrip(c *gc.C) {
+	s.addEnvironment(c)
+	exported, err := s.State.Export()
+	c.Assert(err, gc.IsNil)
+	model := renamed(c, exported, "imported")
+
+	env, err := s.State.ImportEnvironment(model, s.importedCharms(c, model), "user-admin")
+	c.Assert(err, gc.IsNil)
+	c.Assert(env.Name(), gc.Equals, "imported")
+	c.Assert(env.UUID(), gc.Equals, model.UUID)
+	st, err := s.State.OpenHostedEnvironment(env.UUID())
+	c.Assert(err, gc.IsNil)
+	defer st.Close()
+
+	// Exporting the imported environment gives back the model,
+	// without the state server machine.
+	reexported, err := st.Export()
+	c.Assert(err, gc.IsNil)
+	c.Assert(model.Machines[0].Id, gc.Equals, "0")
+	expected := *model
+	expected.Machines = model.Machines[1:]
+	c.Assert(reexported, jc.DeepEquals, &expected)
+
+	// Users and agents keep their passwords.
+	bob, err := st.User("bob")
+	c.Assert(err, gc.IsNil)
+	c.Assert(bob.PasswordValid("bob-secret"), jc.IsTrue)
+	m1, err := st.Machine("1")
+	c.Assert(err, gc.IsNil)
+	c.Assert(m1.PasswordValid("machine-1-password"), jc.IsTrue)
+	c.Assert(m1.Clean(), jc.IsFalse)
+	wu, err := st.Unit("wordpress/0")
+	c.Assert(err, gc.IsNil)
+	subordinates := wu.SubordinateNames()
+	c.Assert(subordinates, gc.DeepEquals, []string{"logging/0"})
+
+	// New entities do not reuse the names of imported ones.
+	m, err := st.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	c.Assert(m.Id(), gc.Equals, "2")
+	m, err = st.AddMachineInsideMachine(state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}, "1", instance.LXC)
+	c.Assert(err, gc.IsNil)
+	c.Assert(m.Id(), gc.Equals, "1/lxc/1")
+	wordpress, err := st.Service("wordpress")
+	c.Assert(err, gc.IsNil)
+	u, err := wordpress.AddUnit()
+	c.Assert(err, gc.IsNil)
+	c.Assert(u.Name(), gc.Equals, "wordpress/1")
+	err = u.AssignToMachine(m)
+	c.Assert(err, gc.IsNil)
+
+	// The environment it was exported from is unchanged.
+	unchanged, err := s.State.Export()
+	c.Assert(err, gc.IsNil)
+	c.Assert(unchanged, jc.DeepEquals, exported)
+}
+
+func (s *EnvironImportSuite) TestImportUUIDInUse(c *gc.C) {
+	model, err := s.State.Export()
+	c.Assert(err, gc.IsNil)
+	model.Name = "imported"
+	model.Config["name"] = "imported"
+	_, err = s.State.ImportEnvironment(model, nil, "user-admin")
+	c.Assert(err, gc.ErrorMatches, `cannot import environment "imported": environment already exists`)
+	_, err = s.State.HostedEnvironment("imported")
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+}
+
+func (s *EnvironImportSuite) TestImportFailureRemovesEnvironment(c *gc.C) {
+	s.addEnvironment(c)
+	exported, err := s.State.Export()
+	c.Assert(err, gc.IsNil)
+	model := renamed(c, exported, "imported")
+
+	_, err = s.State.ImportEnvironment(model, nil, "user-admin")
+	c.Assert(err, gc.ErrorMatches, `cannot import environment "imported": cannot import charms: charm ".*" not supplied`)
+	_, err = s.State.HostedEnvironment("imported")
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+
+	// Nothing is left behind to get in the way of another attempt.
+	_, err = s.State.ImportEnvironment(model, s.importedCharms(c, model), "user-admin")
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *EnvironImportSuite) TestImportUnitOnStateServer(c *gc.C) {
+	m0, err := s.State.AddMachine("quantal", state.JobManageEnviron, state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	wu, err := wordpress.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = wu.AssignToMachine(m0)
+	c.Assert(err, gc.IsNil)
+	exported, err := s.State.Export()
+	c.Assert(err, gc.IsNil)
+	model := renamed(c, exported, "imported")
+
+	_, err = s.State.ImportEnvironment(model, s.importedCharms(c, model), "user-admin")
+	c.Assert(err, gc.ErrorMatches, `cannot import environment "imported": cannot import machines: unit "wordpress/0" is assigned to state server machine 0`)
+}
+
+func (s *EnvironImportSuite) TestImportBadVersion(c *gc.C) {
+	model, err := s.State.Export()
+	c.Assert(err, gc.IsNil)
+	model = renamed(c, model, "imported")
+	model.Version = params.EnvironmentModelVersion + 1
+	_, err = s.State.ImportEnvironment(model, nil, "user-admin")
+	c.Assert(err, gc.ErrorMatches, `cannot import environment "imported": unsupported model version 2`)
+}
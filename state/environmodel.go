@@ -0,0 +1,249 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// Export returns a description of the complete environment model,
+// suitable for recreating the environment on another state server.
+// See params.EnvironmentModel for the format.
+func (st *State) Export() (*params.EnvironmentModel, error) {
+	env, err := st.Environment()
+	if err != nil {
+		return nil, fmt.Errorf("cannot export environment: %v", err)
+	}
+	cfg, err := st.EnvironConfig()
+	if err != nil {
+		return nil, fmt.Errorf("cannot export environment: %v", err)
+	}
+	cons, err := st.EnvironConstraints()
+	if err != nil {
+		return nil, fmt.Errorf("cannot export environment: %v", err)
+	}
+	annotations, err := env.Annotations()
+	if err != nil {
+		return nil, fmt.Errorf("cannot export environment: %v", err)
+	}
+	model := &params.EnvironmentModel{
+		Version:     params.EnvironmentModelVersion,
+		UUID:        env.UUID(),
+		Name:        env.Name(),
+		Config:      cfg.AllAttrs(),
+		Constraints: cons.String(),
+		Annotations: annotations,
+	}
+	if model.Users, err = st.exportUsers(); err != nil {
+		return nil, err
+	}
+	if model.Machines, err = st.exportMachines(); err != nil {
+		return nil, err
+	}
+	if model.Charms, err = st.exportCharms(); err != nil {
+		return nil, err
+	}
+	if model.Services, err = st.exportServices(); err != nil {
+		return nil, err
+	}
+	if model.Relations, err = st.exportRelations(); err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+func (st *State) exportUsers() ([]params.UserModel, error) {
+	var docs []userDoc
+	if err := st.users.Find(nil).Sort("_id_").All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot export users: %v", err)
+	}
+	users := make([]params.UserModel, len(docs))
+	for i, doc := range docs {
+		users[i] = params.UserModel{
+			Name:         doc.Name,
+			PasswordHash: doc.PasswordHash,
+			PasswordSalt: doc.PasswordSalt,
+		}
+	}
+	return users, nil
+}
+
+func (st *State) exportMachines() ([]params.MachineModel, error) {
+	machines, err := st.AllMachines()
+	if err != nil {
+		return nil, fmt.Errorf("cannot export machines: %v", err)
+	}
+	result := make([]params.MachineModel, len(machines))
+	for i, m := range machines {
+		if result[i], err = exportMachine(m); err != nil {
+			return nil, fmt.Errorf("cannot export machine %s: %v", m, err)
+		}
+	}
+	return result, nil
+}
+
+func exportMachine(m *Machine) (params.MachineModel, error) {
+	result := params.MachineModel{
+		Id:           m.Id(),
+		Life:         m.Life().String(),
+		Series:       m.Series(),
+		Maintenance:  m.doc.Maintenance,
+		Nonce:        m.doc.Nonce,
+		PasswordHash: m.doc.PasswordHash,
+	}
+	for _, job := range m.Jobs() {
+		result.Jobs = append(result.Jobs, string(job.ToParams()))
+	}
+	cons, err := m.Constraints()
+	if err != nil {
+		return result, err
+	}
+	result.Constraints = cons.String()
+	if result.Annotations, err = m.Annotations(); err != nil {
+		return result, err
+	}
+	instId, err := m.InstanceId()
+	if IsNotProvisionedError(err) {
+		return result, nil
+	} else if err != nil {
+		return result, err
+	}
+	result.InstanceId = string(instId)
+	hc, err := m.HardwareCharacteristics()
+	if err != nil {
+		return result, err
+	}
+	result.Hardware = hc.String()
+	for _, addr := range m.Addresses() {
+		result.Addresses = append(result.Addresses, addr.Value)
+	}
+	return result, nil
+}
+
+func (st *State) exportCharms() ([]params.CharmModel, error) {
+	var docs []charmDoc
+	if err := st.charms.Find(nil).Sort("_id").All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot export charms: %v", err)
+	}
+	charms := make([]params.CharmModel, 0, len(docs))
+	for _, doc := range docs {
+		if doc.Placeholder || doc.PendingUpload {
+			continue
+		}
+		ch := params.CharmModel{
+			URL:          doc.URL.String(),
+			BundleSha256: doc.BundleSha256,
+		}
+		if doc.BundleURL != nil {
+			ch.BundleURL = doc.BundleURL.String()
+		}
+		charms = append(charms, ch)
+	}
+	return charms, nil
+}
+
+func (st *State) exportServices() ([]params.ServiceModel, error) {
+	services, err := st.AllServices()
+	if err != nil {
+		return nil, fmt.Errorf("cannot export services: %v", err)
+	}
+	result := make([]params.ServiceModel, len(services))
+	for i, s := range services {
+		if result[i], err = exportService(s); err != nil {
+			return nil, fmt.Errorf("cannot export service %q: %v", s, err)
+		}
+	}
+	return result, nil
+}
+
+func exportService(s *Service) (params.ServiceModel, error) {
+	curl, _ := s.CharmURL()
+	result := params.ServiceModel{
+		Name:     s.Name(),
+		Life:     s.Life().String(),
+		Charm:    curl.String(),
+		Owner:    s.doc.OwnerTag,
+		Exposed:  s.IsExposed(),
+		MinUnits: s.MinUnits(),
+	}
+	settings, err := s.ConfigSettings()
+	if err != nil {
+		return result, err
+	}
+	result.Settings = settings
+	cons, err := s.Constraints()
+	if err != nil {
+		return result, err
+	}
+	result.Constraints = cons.String()
+	if result.Annotations, err = s.Annotations(); err != nil {
+		return result, err
+	}
+	units, err := s.AllUnits()
+	if err != nil {
+		return result, err
+	}
+	result.Units = make([]params.UnitModel, len(units))
+	for i, u := range units {
+		annotations, err := u.Annotations()
+		if err != nil {
+			return result, err
+		}
+		result.Units[i] = params.UnitModel{
+			Name:         u.Name(),
+			Life:         u.Life().String(),
+			Machine:      u.doc.MachineId,
+			Principal:    u.doc.Principal,
+			Annotations:  annotations,
+			PasswordHash: u.doc.PasswordHash,
+		}
+		if u.doc.CharmURL != nil {
+			result.Units[i].Charm = u.doc.CharmURL.String()
+		}
+		for _, port := range u.doc.Ports {
+			result.Units[i].Ports = append(result.Units[i].Ports, port.String())
+		}
+	}
+	return result, nil
+}
+
+func (st *State) exportRelations() ([]params.RelationModel, error) {
+	var docs []relationDoc
+	if err := st.relations.Find(nil).Sort("id").All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot export relations: %v", err)
+	}
+	relations := make([]params.RelationModel, len(docs))
+	for i := range docs {
+		r := newRelation(st, &docs[i])
+		result := params.RelationModel{
+			Id:   r.Id(),
+			Key:  r.String(),
+			Life: r.Life().String(),
+		}
+		for _, ep := range r.Endpoints() {
+			result.Endpoints = append(result.Endpoints, params.EndpointModel{
+				Service:   ep.ServiceName,
+				Name:      ep.Name,
+				Role:      string(ep.Role),
+				Interface: ep.Interface,
+				Scope:     string(ep.Scope),
+			})
+		}
+		data, err := r.UnitData()
+		if err != nil {
+			return nil, fmt.Errorf("cannot export relation %q: %v", r, err)
+		}
+		for _, d := range data {
+			result.Units = append(result.Units, params.RelationUnitModel{
+				Unit:     d.UnitName,
+				InScope:  d.InScope,
+				Settings: d.Settings,
+			})
+		}
+		relations[i] = result
+	}
+	return relations, nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/instance"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	jc "launchpad.net/juju-core/testing/checkers"
+)
+
+type EnvironModelSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&EnvironModelSuite{})
+
+func (s *EnvironModelSuite) TestExportEmpty(c *gc.C) {
+	model, err := s.State.Export()
+	c.Assert(err, gc.IsNil)
+	env, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	c.Assert(model.Version, gc.Equals, params.EnvironmentModelVersion)
+	c.Assert(model.UUID, gc.Equals, env.UUID())
+	c.Assert(model.Name, gc.Equals, env.Name())
+	c.Assert(model.Config["name"], gc.Equals, env.Name())
+	c.Assert(model.Machines, gc.HasLen, 0)
+	c.Assert(model.Services, gc.HasLen, 0)
+	c.Assert(model.Relations, gc.HasLen, 0)
+}
+
+func (s *EnvironModelSuite) TestExport(c *gc.C) {
+	_, err := s.State.AddUser("bob", "secret")
+	c.Assert(err, gc.IsNil)
+
+	m0, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = m0.SetConstraints(constraints.MustParse("mem=4G"))
+	c.Assert(err, gc.IsNil)
+	hc := instance.MustParseHardware("arch=amd64 mem=4096M")
+	err = m0.SetProvisioned("inst-0", "fake_nonce", &hc)
+	c.Assert(err, gc.IsNil)
+	err = m0.SetAnnotations(map[string]string{"rack": "r1"})
+	c.Assert(err, gc.IsNil)
+
+	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	err = wordpress.SetExposed()
+	c.Assert(err, gc.IsNil)
+	wu, err := wordpress.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = wu.AssignToMachine(m0)
+	c.Assert(err, gc.IsNil)
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	mu, err := mysql.AddUnit()
+	c.Assert(err, gc.IsNil)
+
+	eps, err := s.State.InferEndpoints([]string{"wordpress", "mysql"})
+	c.Assert(err, gc.IsNil)
+	rel, err := s.State.AddRelation(eps...)
+	c.Assert(err, gc.IsNil)
+	ru, err := rel.Unit(mu)
+	c.Assert(err, gc.IsNil)
+	err = ru.EnterScope(map[string]interface{}{"user": "admin"})
+	c.Assert(err, gc.IsNil)
+
+	model, err := s.State.Export()
+	c.Assert(err, gc.IsNil)
+
+	var bob *params.UserModel
+	for i := range model.Users {
+		if model.Users[i].Name == "bob" {
+			bob = &model.Users[i]
+		}
+	}
+	c.Assert(bob, gc.NotNil)
+	c.Assert(bob.PasswordHash, gc.Not(gc.Equals), "")
+
+	c.Assert(model.Machines, gc.HasLen, 1)
+	machine := model.Machines[0]
+	c.Assert(machine.PasswordHash, gc.Equals, "")
+	c.Assert(machine, jc.DeepEquals, params.MachineModel{
+		Id:          "0",
+		Life:        "alive",
+		Series:      "quantal",
+		Jobs:        []string{"JobHostUnits"},
+		Constraints: "mem=4096M",
+		Annotations: map[string]string{"rack": "r1"},
+		InstanceId:  "inst-0",
+		Nonce:       "fake_nonce",
+		Hardware:    "arch=amd64 mem=4096M",
+	})
+
+	c.Assert(model.Charms, gc.HasLen, 2)
+	c.Assert(model.Services, gc.HasLen, 2)
+	services := make(map[string]params.ServiceModel)
+	for _, svc := range model.Services {
+		services[svc.Name] = svc
+	}
+	c.Assert(services["wordpress"].Exposed, gc.Equals, true)
+	c.Assert(services["wordpress"].Charm, gc.Equals, "local:quantal/quantal-wordpress-3")
+	c.Assert(services["wordpress"].Units, gc.HasLen, 1)
+	c.Assert(services["wordpress"].Units[0].Name, gc.Equals, "wordpress/0")
+	c.Assert(services["wordpress"].Units[0].Machine, gc.Equals, "0")
+	c.Assert(services["mysql"].Exposed, gc.Equals, false)
+	c.Assert(services["mysql"].Units, gc.HasLen, 1)
+	c.Assert(services["mysql"].Units[0].Machine, gc.Equals, "")
+
+	c.Assert(model.Relations, gc.HasLen, 1)
+	relation := model.Relations[0]
+	c.Assert(relation.Key, gc.Equals, rel.String())
+	c.Assert(relation.Endpoints, gc.HasLen, 2)
+	c.Assert(relation.Units, jc.DeepEquals, []params.RelationUnitModel{{
+		Unit:     "mysql/0",
+		InScope:  true,
+		Settings: map[string]interface{}{"user": "admin"},
+	}})
+}
@@ -254,3 +254,19 @@ var NewAddress = newAddress
 func CheckUserExists(st *State, name string) (bool, error) {
 	return st.checkUserExists(name)
 }
+
+// SetMaxConfigRevisions changes the number of config revisions retained
+// for each service, and returns a function that restores the original value.
+func SetMaxConfigRevisions(n int) (restore func()) {
+	original := maxConfigRevisions
+	maxConfigRevisions = n
+	return func() { maxConfigRevisions = original }
+}
+
+// SetMaxHookExecutions changes the number of hook executions retained
+// for each unit, and returns a function that restores the original value.
+func SetMaxHookExecutions(n int) (restore func()) {
+	original := maxHookExecutions
+	maxHookExecutions = n
+	return func() { maxHookExecutions = original }
+}
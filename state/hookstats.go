@@ -0,0 +1,221 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"time"
+
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/txn"
+
+	"launchpad.net/juju-core/utils"
+)
+
+// maxHookExecutions holds the number of hook executions
+// retained for each unit; older executions are discarded.
+var maxHookExecutions = 50
+
+// HookExecution describes a single execution of a hook by a unit agent.
+type HookExecution struct {
+	// Hook holds the name of the hook, as passed to the hook
+	// tools in JUJU_HOOK_NAME.
+	Hook string
+
+	// Started and Finished hold when the hook started and finished
+	// executing. Finished is zero if the hook is still running.
+	Started  time.Time
+	Finished time.Time
+
+	// ExitCode holds the exit code of the hook process.
+	ExitCode int
+
+	// Error holds a description of the hook's failure, if any.
+	Error string `bson:",omitempty"`
+
+	// Queued holds the number of hooks the unit had queued to run
+	// when the execution was recorded.
+	Queued int `bson:",omitempty"`
+}
+
+// Duration returns how long the hook took to run, or zero if it is
+// still running.
+func (e HookExecution) Duration() time.Duration {
+	if e.Finished.IsZero() {
+		return 0
+	}
+	return e.Finished.Sub(e.Started)
+}
+
+// Failed reports whether the hook execution did not succeed.
+func (e HookExecution) Failed() bool {
+	return e.ExitCode != 0 || e.Error != ""
+}
+
+// hookStatsDoc records the hook executions of a unit.
+type hookStatsDoc struct {
+	UnitName string `bson:"_id"`
+	Service  string
+
+	// Running holds the hook currently executing, if any.
+	Running *HookExecution
+
+	// Executions holds the most recently completed hook
+	// executions, oldest first.
+	Executions []HookExecution
+}
+
+// removeHookStatsOp returns the operation required to remove the hook
+// statistics of the named unit.
+func removeHookStatsOp(st *State, unitName string) txn.Op {
+	return txn.Op{
+		C:      st.hookStats.Name,
+		Id:     unitName,
+		Remove: true,
+	}
+}
+
+// RecordHookExecution records the supplied hook execution for the unit.
+// If the execution has not finished, it is recorded as the currently
+// running hook; otherwise it is added to the unit's recent executions.
+func (u *Unit) RecordHookExecution(exec HookExecution) (err error) {
+	defer utils.ErrorContextf(&err, "cannot record hook execution for unit %q", u)
+	var update D
+	if exec.Finished.IsZero() {
+		update = D{{"$set", D{{"running", &exec}}}}
+	} else {
+		update = D{
+			{"$unset", D{{"running", 1}}},
+			{"$push", D{{"executions", D{
+				{"$each", []HookExecution{exec}},
+				{"$slice", -maxHookExecutions},
+			}}}},
+		}
+	}
+	// The document is created the first time the unit records a hook.
+	// Racing agents are not expected, but if a second attempt is needed
+	// the document will exist.
+	for i := 0; i < 2; i++ {
+		ops := []txn.Op{{
+			C:      u.st.units.Name,
+			Id:     u.doc.Name,
+			Assert: notDeadDoc,
+		}}
+		count, err := u.st.hookStats.FindId(u.doc.Name).Count()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			doc := &hookStatsDoc{
+				UnitName: u.doc.Name,
+				Service:  u.doc.Service,
+			}
+			if exec.Finished.IsZero() {
+				doc.Running = &exec
+			} else {
+				doc.Executions = []HookExecution{exec}
+			}
+			ops = append(ops, txn.Op{
+				C:      u.st.hookStats.Name,
+				Id:     u.doc.Name,
+				Assert: txn.DocMissing,
+				Insert: doc,
+			})
+		} else {
+			ops = append(ops, txn.Op{
+				C:      u.st.hookStats.Name,
+				Id:     u.doc.Name,
+				Assert: txn.DocExists,
+				Update: update,
+			})
+		}
+		if err := u.st.runTransaction(ops); err != txn.ErrAborted {
+			return err
+		}
+		if notDead, err := isNotDead(u.st.units, u.doc.Name); err != nil {
+			return err
+		} else if !notDead {
+			return fmt.Errorf("unit is dead")
+		}
+	}
+	return ErrExcessiveContention
+}
+
+// ClearRunningHook records that the unit is no longer running the hook
+// it last reported as started, without recording an execution. It is
+// used when the hook turns out not to exist.
+func (u *Unit) ClearRunningHook() (err error) {
+	defer utils.ErrorContextf(&err, "cannot clear running hook for unit %q", u)
+	ops := []txn.Op{{
+		C:      u.st.hookStats.Name,
+		Id:     u.doc.Name,
+		Assert: txn.DocExists,
+		Update: D{{"$unset", D{{"running", 1}}}},
+	}}
+	if err := u.st.runTransaction(ops); err != txn.ErrAborted {
+		return err
+	}
+	// Nothing has been recorded, so nothing is running.
+	return nil
+}
+
+// UnitHookStats holds the recorded hook executions of a unit.
+type UnitHookStats struct {
+	UnitName string
+
+	// Running holds the hook the unit is currently executing,
+	// if any.
+	Running *HookExecution
+
+	// Executions holds the unit's most recently completed hook
+	// executions, oldest first.
+	Executions []HookExecution
+}
+
+// Queued returns the number of hooks the unit had queued to run when
+// it last recorded a hook execution.
+func (s UnitHookStats) Queued() int {
+	if s.Running != nil {
+		return s.Running.Queued
+	}
+	if n := len(s.Executions); n > 0 {
+		return s.Executions[n-1].Queued
+	}
+	return 0
+}
+
+// HookStats returns the recorded hook executions of the unit.
+func (u *Unit) HookStats() (UnitHookStats, error) {
+	var doc hookStatsDoc
+	err := u.st.hookStats.FindId(u.doc.Name).One(&doc)
+	if err == mgo.ErrNotFound {
+		return UnitHookStats{UnitName: u.doc.Name}, nil
+	} else if err != nil {
+		return UnitHookStats{}, fmt.Errorf("cannot get hook statistics for unit %q: %v", u, err)
+	}
+	return doc.stats(), nil
+}
+
+// HookStats returns the recorded hook executions of every unit of
+// the service that has recorded any, ordered by unit name.
+func (s *Service) HookStats() ([]UnitHookStats, error) {
+	var docs []hookStatsDoc
+	err := s.st.hookStats.Find(D{{"service", s.doc.Name}}).Sort("_id").All(&docs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get hook statistics for service %q: %v", s, err)
+	}
+	stats := make([]UnitHookStats, len(docs))
+	for i := range docs {
+		stats[i] = docs[i].stats()
+	}
+	return stats, nil
+}
+
+func (doc *hookStatsDoc) stats() UnitHookStats {
+	return UnitHookStats{
+		UnitName:   doc.UnitName,
+		Running:    doc.Running,
+		Executions: doc.Executions,
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/state"
+)
+
+type HookStatsSuite struct {
+	ConnSuite
+	service *state.Service
+	unit    *state.Unit
+}
+
+var _ = gc.Suite(&HookStatsSuite{})
+
+func (s *HookStatsSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.service = s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	var err error
+	s.unit, err = s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+}
+
+func execution(hook string, started, finished int64, code int) state.HookExecution {
+	exec := state.HookExecution{
+		Hook:     hook,
+		Started:  time.Unix(started, 0),
+		ExitCode: code,
+	}
+	if finished != 0 {
+		exec.Finished = time.Unix(finished, 0)
+	}
+	return exec
+}
+
+func (s *HookStatsSuite) TestNoStats(c *gc.C) {
+	stats, err := s.unit.HookStats()
+	c.Assert(err, gc.IsNil)
+	c.Assert(stats, gc.DeepEquals, state.UnitHookStats{UnitName: "wordpress/0"})
+	all, err := s.service.HookStats()
+	c.Assert(err, gc.IsNil)
+	c.Assert(all, gc.HasLen, 0)
+}
+
+func (s *HookStatsSuite) TestRecordHookExecution(c *gc.C) {
+	running := execution("install", 100, 0, 0)
+	err := s.unit.RecordHookExecution(running)
+	c.Assert(err, gc.IsNil)
+	stats, err := s.unit.HookStats()
+	c.Assert(err, gc.IsNil)
+	c.Assert(stats.Running, gc.DeepEquals, &running)
+	c.Assert(stats.Executions, gc.HasLen, 0)
+
+	finished := execution("install", 100, 130, 1)
+	finished.Error = "exit status 1"
+	err = s.unit.RecordHookExecution(finished)
+	c.Assert(err, gc.IsNil)
+	stats, err = s.unit.HookStats()
+	c.Assert(err, gc.IsNil)
+	c.Assert(stats.Running, gc.IsNil)
+	c.Assert(stats.Executions, gc.DeepEquals, []state.HookExecution{finished})
+	c.Assert(stats.Executions[0].Duration(), gc.Equals, 30*time.Second)
+	c.Assert(stats.Executions[0].Failed(), gc.Equals, true)
+
+	all, err := s.service.HookStats()
+	c.Assert(err, gc.IsNil)
+	c.Assert(all, gc.DeepEquals, []state.UnitHookStats{stats})
+}
+
+func (s *HookStatsSuite) TestQueued(c *gc.C) {
+	stats, err := s.unit.HookStats()
+	c.Assert(err, gc.IsNil)
+	c.Assert(stats.Queued(), gc.Equals, 0)
+
+	finished := execution("install", 100, 110, 0)
+	finished.Queued = 3
+	err = s.unit.RecordHookExecution(finished)
+	c.Assert(err, gc.IsNil)
+	stats, err = s.unit.HookStats()
+	c.Assert(err, gc.IsNil)
+	c.Assert(stats.Queued(), gc.Equals, 3)
+
+	// A running hook reports the hooks queued after it.
+	running := execution("config-changed", 120, 0, 0)
+	running.Queued = 2
+	err = s.unit.RecordHookExecution(running)
+	c.Assert(err, gc.IsNil)
+	stats, err = s.unit.HookStats()
+	c.Assert(err, gc.IsNil)
+	c.Assert(stats.Queued(), gc.Equals, 2)
+}
+
+func (s *HookStatsSuite) TestClearRunningHook(c *gc.C) {
+	// Clearing before anything is recorded is a no-op.
+	err := s.unit.ClearRunningHook()
+	c.Assert(err, gc.IsNil)
+
+	finished := execution("install", 100, 110, 0)
+	err = s.unit.RecordHookExecution(finished)
+	c.Assert(err, gc.IsNil)
+	err = s.unit.RecordHookExecution(execution("config-changed", 120, 0, 0))
+	c.Assert(err, gc.IsNil)
+	err = s.unit.ClearRunningHook()
+	c.Assert(err, gc.IsNil)
+	stats, err := s.unit.HookStats()
+	c.Assert(err, gc.IsNil)
+	c.Assert(stats.Running, gc.IsNil)
+	c.Assert(stats.Executions, gc.DeepEquals, []state.HookExecution{finished})
+}
+
+func (s *HookStatsSuite) TestOldExecutionsDiscarded(c *gc.C) {
+	defer state.SetMaxHookExecutions(2)()
+	for i, hook := range []string{"install", "config-changed", "start"} {
+		err := s.unit.RecordHookExecution(execution(hook, int64(i*10), int64(i*10+5), 0))
+		c.Assert(err, gc.IsNil)
+	}
+	stats, err := s.unit.HookStats()
+	c.Assert(err, gc.IsNil)
+	c.Assert(stats.Executions, gc.HasLen, 2)
+	c.Assert(stats.Executions[0].Hook, gc.Equals, "config-changed")
+	c.Assert(stats.Executions[1].Hook, gc.Equals, "start")
+}
+
+func (s *HookStatsSuite) TestRecordDeadUnit(c *gc.C) {
+	err := s.unit.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.unit.RecordHookExecution(execution("stop", 100, 110, 0))
+	c.Assert(err, gc.ErrorMatches, `cannot record hook execution for unit "wordpress/0": unit is dead`)
+}
+
+func (s *HookStatsSuite) TestStatsRemovedWithUnit(c *gc.C) {
+	err := s.unit.RecordHookExecution(execution("install", 100, 110, 0))
+	c.Assert(err, gc.IsNil)
+	err = s.unit.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.unit.Remove()
+	c.Assert(err, gc.IsNil)
+	all, err := s.service.HookStats()
+	c.Assert(err, gc.IsNil)
+	c.Assert(all, gc.HasLen, 0)
+}
@@ -0,0 +1,267 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"strings"
+
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/txn"
+
+	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/environs/config"
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/utils"
+)
+
+// HostedEnvironment represents an environment hosted by the state
+// server of another environment. A hosted environment has no state
+// server machines of its own, and its entities are kept apart from
+// those of every other environment.
+type HostedEnvironment struct {
+	st  *State
+	doc hostedEnvironmentDoc
+}
+
+// hostedEnvironmentDoc registers a hosted environment with the
+// state server hosting it.
+type hostedEnvironmentDoc struct {
+	Name  string `bson:"_id"`
+	UUID  string
+	Owner string
+}
+
+// Name returns the name of the hosted environment.
+func (e *HostedEnvironment) Name() string {
+	return e.doc.Name
+}
+
+// UUID returns the universally unique identifier of the hosted
+// environment.
+func (e *HostedEnvironment) UUID() string {
+	return e.doc.UUID
+}
+
+// Tag returns the tag of the hosted environment, which API clients
+// name when logging in to it.
+func (e *HostedEnvironment) Tag() string {
+	return names.EnvironTag(e.doc.UUID)
+}
+
+// Owner returns the tag of the user that created the hosted
+// environment.
+func (e *HostedEnvironment) Owner() string {
+	return e.doc.Owner
+}
+
+// IsHosted returns whether the state holds an environment hosted by
+// the state server of another environment.
+func (st *State) IsHosted() bool {
+	return st.hostedUUID != ""
+}
+
+var errNotStateServerEnviron = fmt.Errorf("only the state server's environment can host environments")
+
+// AddHostedEnvironment creates a new environment with the given
+// configuration, hosted by the state server of the environment held
+// by st. The new environment gets an admin user with the given
+// password; owner holds the tag of the user creating it.
+func (st *State) AddHostedEnvironment(cfg *config.Config, owner, adminPassword string) (_ *HostedEnvironment, err error) {
+	defer utils.ErrorContextf(&err, "cannot add hosted environment %q", cfg.Name())
+	uuid, err := utils.NewUUID()
+	if err != nil {
+		return nil, fmt.Errorf("environment UUID cannot be created: %v", err)
+	}
+	doc := hostedEnvironmentDoc{
+		Name:  cfg.Name(),
+		UUID:  uuid.String(),
+		Owner: owner,
+	}
+	return st.addHostedEnvironment(doc, cfg, constraints.Value{}, func(hst *State) error {
+		_, err := hst.AddUser("admin", adminPassword)
+		return err
+	})
+}
+
+// addHostedEnvironment creates the hosted environment described by
+// doc, with the given configuration and constraints, and registers it
+// once populate has filled it in. If anything fails after the
+// environment document has been created, the collections of the
+// environment are dropped again.
+func (st *State) addHostedEnvironment(doc hostedEnvironmentDoc, cfg *config.Config, cons constraints.Value, populate func(hst *State) error) (_ *HostedEnvironment, err error) {
+	if st.IsHosted() {
+		return nil, errNotStateServerEnviron
+	}
+	if err := checkEnvironConfig(cfg); err != nil {
+		return nil, err
+	}
+	env, err := st.Environment()
+	if err != nil {
+		return nil, err
+	}
+	if env.Life() != Alive {
+		return nil, fmt.Errorf("environment is no longer alive")
+	}
+	if env.Name() == doc.Name || env.UUID() == doc.UUID {
+		return nil, fmt.Errorf("environment already exists")
+	}
+	for _, sel := range []D{{{"_id", doc.Name}}, {{"uuid", doc.UUID}}} {
+		if count, err := st.hostedEnvironments.Find(sel).Count(); err != nil {
+			return nil, err
+		} else if count > 0 {
+			return nil, fmt.Errorf("environment already exists")
+		}
+	}
+	hst, err := st.openHostedState(doc.UUID)
+	if err != nil {
+		return nil, err
+	}
+	defer hst.Close()
+	ops := []txn.Op{
+		createConstraintsOp(hst, environGlobalKey, cons),
+		createSettingsOp(hst, environGlobalKey, cfg.AllAttrs()),
+		createEnvironmentOp(hst, doc.Name, doc.UUID),
+	}
+	// If the environment document already exists, another
+	// environment with the same UUID is being added concurrently,
+	// and its collections must be left alone.
+	if err := hst.runTransaction(ops); err == txn.ErrAborted {
+		return nil, fmt.Errorf("environment already exists")
+	} else if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			if err := hst.dropCollections(); err != nil {
+				logger.Errorf("cannot remove collections of hosted environment %q: %v", doc.Name, err)
+			}
+		}
+	}()
+	if err := populate(hst); err != nil {
+		return nil, err
+	}
+	// The environment is only registered once it is complete, so
+	// that it cannot be opened half-made. The registry is shared
+	// by the state server's environment and the environments it
+	// hosts, whose transactions are run separately, so it is not
+	// changed in transactions.
+	if err := st.hostedEnvironments.Insert(&doc); mgo.IsDup(err) {
+		return nil, fmt.Errorf("environment already exists")
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot register environment: %v", err)
+	}
+	return &HostedEnvironment{st: st, doc: doc}, nil
+}
+
+// HostedEnvironment returns the environment with the given name hosted
+// by the same state server as the environment held by st.
+func (st *State) HostedEnvironment(name string) (*HostedEnvironment, error) {
+	return st.hostedEnvironment(D{{"_id", name}}, name)
+}
+
+// HostedEnvironmentByUUID is like HostedEnvironment but finds the
+// environment by its UUID.
+func (st *State) HostedEnvironmentByUUID(uuid string) (*HostedEnvironment, error) {
+	return st.hostedEnvironment(D{{"uuid", uuid}}, uuid)
+}
+
+func (st *State) hostedEnvironment(sel D, id string) (*HostedEnvironment, error) {
+	e := &HostedEnvironment{st: st}
+	err := st.hostedEnvironments.Find(sel).One(&e.doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("hosted environment %q", id)
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot get hosted environment %q: %v", id, err)
+	}
+	return e, nil
+}
+
+// AllHostedEnvironments returns all the environments hosted by the
+// environment held by st. Hosted environments host none.
+func (st *State) AllHostedEnvironments() ([]*HostedEnvironment, error) {
+	if st.IsHosted() {
+		return nil, nil
+	}
+	var docs []hostedEnvironmentDoc
+	if err := st.hostedEnvironments.Find(nil).Sort("_id").All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot get all hosted environments: %v", err)
+	}
+	envs := make([]*HostedEnvironment, len(docs))
+	for i, doc := range docs {
+		envs[i] = &HostedEnvironment{st: st, doc: doc}
+	}
+	return envs, nil
+}
+
+// OpenHostedEnvironment returns a State for the hosted environment
+// with the given UUID, sharing the database connection of st. The
+// returned State must be closed independently of st.
+func (st *State) OpenHostedEnvironment(uuid string) (*State, error) {
+	if _, err := st.HostedEnvironmentByUUID(uuid); err != nil {
+		return nil, err
+	}
+	return st.openHostedState(uuid)
+}
+
+func (st *State) openHostedState(uuid string) (*State, error) {
+	session := st.db.Session.Copy()
+	hst, err := openState(session, st.info, st.policy, uuid)
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	return hst, nil
+}
+
+// Remove removes the hosted environment and everything in it. The
+// environment must have been destroyed first; its machines' instances
+// are not stopped by Remove.
+func (e *HostedEnvironment) Remove() (err error) {
+	defer utils.ErrorContextf(&err, "cannot remove hosted environment %q", e.doc.Name)
+	hst, err := e.st.openHostedState(e.doc.UUID)
+	if err != nil {
+		return err
+	}
+	defer hst.Close()
+	env, err := hst.Environment()
+	if err != nil {
+		return err
+	}
+	if env.Life() == Alive {
+		return fmt.Errorf("environment is still alive")
+	}
+	err = e.st.hostedEnvironments.Remove(D{{"_id", e.doc.Name}, {"uuid", e.doc.UUID}})
+	if err == mgo.ErrNotFound {
+		return errors.NotFoundf("hosted environment")
+	} else if err != nil {
+		return fmt.Errorf("cannot unregister environment: %v", err)
+	}
+	return hst.dropCollections()
+}
+
+// dropCollections drops all the collections holding the hosted
+// environment held by st.
+func (st *State) dropCollections() error {
+	if !st.IsHosted() {
+		return fmt.Errorf("cannot drop the collections of the state server's environment")
+	}
+	prefix := hostedPrefix(st.hostedUUID)
+	for _, db := range []*mgo.Database{st.db, st.presence.Database} {
+		names, err := db.CollectionNames()
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if !strings.HasPrefix(name, prefix) {
+				continue
+			}
+			if err := db.C(name).DropCollection(); err != nil {
+				return fmt.Errorf("cannot drop %q: %v", name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"fmt"
+	"strings"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/instance"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/testing"
+	jc "launchpad.net/juju-core/testing/checkers"
+)
+
+type HostedEnvironmentSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&HostedEnvironmentSuite{})
+
+func (s *HostedEnvironmentSuite) addHosted(c *gc.C, name string) *state.HostedEnvironment {
+	cfg := testing.CustomEnvironConfig(c, testing.Attrs{"name": name})
+	env, err := s.State.AddHostedEnvironment(cfg, "user-admin", "hosted-secret")
+	c.Assert(err, gc.IsNil)
+	return env
+}
+
+func (s *HostedEnvironmentSuite) openHosted(c *gc.C, env *state.HostedEnvironment) *state.State {
+	st, err := s.State.OpenHostedEnvironment(env.UUID())
+	c.Assert(err, gc.IsNil)
+	s.AddCleanup(func(*gc.C) { st.Close() })
+	return st
+}
+
+func (s *HostedEnvironmentSuite) TestAddHostedEnvironment(c *gc.C) {
+	env := s.addHosted(c, "hosted")
+	c.Assert(env.Name(), gc.Equals, "hosted")
+	c.Assert(env.UUID(), gc.HasLen, 36)
+	c.Assert(env.Tag(), gc.Equals, "environment-"+env.UUID())
+	c.Assert(env.Owner(), gc.Equals, "user-admin")
+
+	st := s.openHosted(c, env)
+	c.Assert(st.IsHosted(), jc.IsTrue)
+	c.Assert(s.State.IsHosted(), jc.IsFalse)
+	environ, err := st.Environment()
+	c.Assert(err, gc.IsNil)
+	c.Assert(environ.Name(), gc.Equals, "hosted")
+	c.Assert(environ.UUID(), gc.Equals, env.UUID())
+	cfg, err := st.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	c.Assert(cfg.Name(), gc.Equals, "hosted")
+	cons, err := st.EnvironConstraints()
+	c.Assert(err, gc.IsNil)
+	c.Assert(cons, gc.DeepEquals, constraints.Value{})
+
+	admin, err := st.User("admin")
+	c.Assert(err, gc.IsNil)
+	c.Assert(admin.PasswordValid("hosted-secret"), jc.IsTrue)
+	admin, err = s.State.User("admin")
+	c.Assert(err, gc.IsNil)
+	c.Assert(admin.PasswordValid("hosted-secret"), jc.IsFalse)
+
+	got, err := s.State.HostedEnvironment("hosted")
+	c.Assert(err, gc.IsNil)
+	c.Assert(got.UUID(), gc.Equals, env.UUID())
+	got, err = s.State.HostedEnvironmentByUUID(env.UUID())
+	c.Assert(err, gc.IsNil)
+	c.Assert(got.Name(), gc.Equals, "hosted")
+}
+
+func (s *HostedEnvironmentSuite) TestAddHostedEnvironmentNameInUse(c *gc.C) {
+	s.addHosted(c, "hosted")
+	for _, name := range []string{"hosted", "testenv"} {
+		cfg := testing.CustomEnvironConfig(c, testing.Attrs{"name": name})
+		_, err := s.State.AddHostedEnvironment(cfg, "user-admin", "secret")
+		c.Assert(err, gc.ErrorMatches, fmt.Sprintf("cannot add hosted environment %q: environment already exists", name))
+	}
+	envs, err := s.State.AllHostedEnvironments()
+	c.Assert(err, gc.IsNil)
+	c.Assert(envs, gc.HasLen, 1)
+	s.assertCollectionCount(c, 1)
+}
+
+func (s *HostedEnvironmentSuite) TestHostedEnvironmentsCannotHost(c *gc.C) {
+	st := s.openHosted(c, s.addHosted(c, "hosted"))
+	cfg := testing.CustomEnvironConfig(c, testing.Attrs{"name": "nested"})
+	_, err := st.AddHostedEnvironment(cfg, "user-admin", "secret")
+	c.Assert(err, gc.ErrorMatches, `cannot add hosted environment "nested": only the state server's environment can host environments`)
+	envs, err := st.AllHostedEnvironments()
+	c.Assert(err, gc.IsNil)
+	c.Assert(envs, gc.HasLen, 0)
+}
+
+func (s *HostedEnvironmentSuite) TestAllHostedEnvironments(c *gc.C) {
+	s.addHosted(c, "second")
+	s.addHosted(c, "first")
+	envs, err := s.State.AllHostedEnvironments()
+	c.Assert(err, gc.IsNil)
+	c.Assert(envs, gc.HasLen, 2)
+	c.Assert(envs[0].Name(), gc.Equals, "first")
+	c.Assert(envs[1].Name(), gc.Equals, "second")
+}
+
+func (s *HostedEnvironmentSuite) TestOpenUnknownHostedEnvironment(c *gc.C) {
+	_, err := s.State.OpenHostedEnvironment("d2d3a8a4-0c2b-4f5c-8a0e-7a52b3a2e0c1")
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+}
+
+func (s *HostedEnvironmentSuite) TestHostedEntitiesAreIsolated(c *gc.C) {
+	st := s.openHosted(c, s.addHosted(c, "hosted"))
+
+	m, err := st.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	c.Assert(m.Id(), gc.Equals, "0")
+	_, err = s.State.Machine("0")
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+
+	ch := state.AddTestingCharm(c, st, "wordpress")
+	_, err = st.AddService("wordpress", "user-admin", ch)
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.Service("wordpress")
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+	_, err = s.State.Charm(ch.URL())
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+
+	// The same names can be used in both environments.
+	_, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+
+	_, err = st.FindEntity("user-bob")
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+	_, err = s.State.AddUser("bob", "password")
+	c.Assert(err, gc.IsNil)
+	_, err = st.User("bob")
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+}
+
+func (s *HostedEnvironmentSuite) TestHostedEnvironmentsHaveNoStateServers(c *gc.C) {
+	st := s.openHosted(c, s.addHosted(c, "hosted"))
+	_, err := st.AddMachine("quantal", state.JobManageEnviron)
+	c.Assert(err, gc.ErrorMatches, "cannot add a new machine: hosted environments cannot have state servers")
+	err = st.EnsureAvailability(3, constraints.Value{}, "quantal")
+	c.Assert(err, gc.ErrorMatches, "hosted environments cannot have state servers")
+	m, err := st.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = m.SetMongoPassword("foo")
+	c.Assert(err, gc.ErrorMatches, `cannot set mongo password for "machine-0": hosted environments have no database users`)
+}
+
+func (s *HostedEnvironmentSuite) TestHostedEnvironmentAddresses(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+	err = m.SetAddresses([]instance.Address{{
+		Type:         instance.Ipv4Address,
+		NetworkScope: instance.NetworkCloudLocal,
+		Value:        "10.0.0.1",
+	}})
+	c.Assert(err, gc.IsNil)
+	st := s.openHosted(c, s.addHosted(c, "hosted"))
+
+	addrs, err := st.APIAddresses()
+	c.Assert(err, gc.IsNil)
+	expected, err := s.State.APIAddresses()
+	c.Assert(err, gc.IsNil)
+	c.Assert(addrs, gc.DeepEquals, expected)
+	info, err := st.StateServerInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.MachineIds, gc.DeepEquals, []string{"0"})
+}
+
+func (s *HostedEnvironmentSuite) TestRemoveHostedEnvironment(c *gc.C) {
+	env := s.addHosted(c, "hosted")
+	st := s.openHosted(c, env)
+	_, err := st.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	err = env.Remove()
+	c.Assert(err, gc.ErrorMatches, `cannot remove hosted environment "hosted": environment is still alive`)
+
+	environ, err := st.Environment()
+	c.Assert(err, gc.IsNil)
+	err = environ.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = env.Remove()
+	c.Assert(err, gc.IsNil)
+
+	_, err = s.State.HostedEnvironment("hosted")
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+	_, err = s.State.OpenHostedEnvironment(env.UUID())
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+	s.assertCollectionCount(c, 0)
+
+	// The name can be reused once the environment has gone.
+	s.addHosted(c, "hosted")
+}
+
+func (s *HostedEnvironmentSuite) TestDestroyStateServerEnvironment(c *gc.C) {
+	s.addHosted(c, "hosted")
+	env, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	err = env.Destroy()
+	c.Assert(err, gc.ErrorMatches, `environment hosts 1 other environment\(s\); destroy them first`)
+	c.Assert(env.Refresh(), gc.IsNil)
+	c.Assert(env.Life(), gc.Equals, state.Alive)
+}
+
+// assertCollectionCount checks the number of hosted environments
+// with collections in the juju database.
+func (s *HostedEnvironmentSuite) assertCollectionCount(c *gc.C, expected int) {
+	names, err := s.MgoSuite.Session.DB("juju").CollectionNames()
+	c.Assert(err, gc.IsNil)
+	prefixes := make(map[string]bool)
+	for _, name := range names {
+		if i := strings.Index(name, "."); i == 36 {
+			prefixes[name[:i]] = true
+		}
+	}
+	c.Assert(prefixes, gc.HasLen, expected)
+}
@@ -33,6 +33,7 @@ func (l Life) String() string {
 var isAliveDoc = D{{"life", Alive}}
 var isDeadDoc = D{{"life", Dead}}
 var notDeadDoc = D{{"life", D{{"$ne", Dead}}}}
+var notInMaintenanceDoc = D{{"maintenance", D{{"$ne", true}}}}
 
 // Living describes state entities with a lifecycle.
 type Living interface {
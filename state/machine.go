@@ -92,6 +92,7 @@ type machineDoc struct {
 	HasVote       bool
 	PasswordHash  string
 	Clean         bool
+	Maintenance   bool `bson:",omitempty"`
 	// We store 2 different sets of addresses for the machine, obtained
 	// from different sources.
 	// Addresses is the set of addresses obtained by asking the provider.
@@ -234,6 +235,29 @@ func (m *Machine) SetHasVote(hasVote bool) error {
 	return nil
 }
 
+// InMaintenance returns whether the machine is in maintenance mode.
+// No new units or containers are placed on a machine in maintenance
+// mode; those already on it are unaffected.
+func (m *Machine) InMaintenance() bool {
+	return m.doc.Maintenance
+}
+
+// SetMaintenance puts the machine into maintenance mode,
+// or takes it out of maintenance mode.
+func (m *Machine) SetMaintenance(maintenance bool) error {
+	ops := []txn.Op{{
+		C:      m.st.machines.Name,
+		Id:     m.doc.Id,
+		Assert: notDeadDoc,
+		Update: D{{"$set", D{{"maintenance", maintenance}}}},
+	}}
+	if err := m.st.runTransaction(ops); err != nil {
+		return fmt.Errorf("cannot set maintenance mode of machine %v: %v", m, onAbort(err, errDead))
+	}
+	m.doc.Maintenance = maintenance
+	return nil
+}
+
 // IsManager returns true if the machine has JobManageEnviron.
 func (m *Machine) IsManager() bool {
 	return hasJob(m.doc.Jobs, JobManageEnviron)
@@ -620,6 +644,18 @@ func (m *Machine) WaitAgentAlive(timeout time.Duration) (err error) {
 	panic(fmt.Sprintf("presence reported dead status twice in a row for machine %v", m))
 }
 
+// WatchAgentPresence starts sending changes in the presence of the
+// machine's agent on ch, beginning with its current presence. The
+// changes must be received until UnwatchAgentPresence is called.
+func (m *Machine) WatchAgentPresence(ch chan<- presence.Change) {
+	m.st.pwatcher.Watch(m.globalKey(), ch)
+}
+
+// UnwatchAgentPresence stops sending changes on ch.
+func (m *Machine) UnwatchAgentPresence(ch chan<- presence.Change) {
+	m.st.pwatcher.Unwatch(m.globalKey(), ch)
+}
+
 // SetAgentAlive signals that the agent for machine m is alive.
 // It returns the started pinger.
 func (m *Machine) SetAgentAlive() (*presence.Pinger, error) {
@@ -5,6 +5,7 @@ package state_test
 
 import (
 	"sort"
+	"time"
 
 	gc "launchpad.net/gocheck"
 
@@ -13,6 +14,7 @@ import (
 	"launchpad.net/juju-core/instance"
 	"launchpad.net/juju-core/state"
 	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/state/presence"
 	"launchpad.net/juju-core/state/testing"
 	coretesting "launchpad.net/juju-core/testing"
 	jc "launchpad.net/juju-core/testing/checkers"
@@ -254,6 +256,45 @@ func (s *MachineSuite) TestHasVote(c *gc.C) {
 	c.Assert(s.machine.HasVote(), jc.IsFalse)
 }
 
+func (s *MachineSuite) TestMaintenance(c *gc.C) {
+	c.Assert(s.machine.InMaintenance(), jc.IsFalse)
+
+	err := s.machine.SetMaintenance(true)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.machine.InMaintenance(), jc.IsTrue)
+	m, err := s.State.Machine(s.machine.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(m.InMaintenance(), jc.IsTrue)
+
+	err = m.SetMaintenance(false)
+	c.Assert(err, gc.IsNil)
+	c.Assert(m.InMaintenance(), jc.IsFalse)
+	err = s.machine.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.machine.InMaintenance(), jc.IsFalse)
+
+	err = s.machine.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.machine.SetMaintenance(true)
+	c.Assert(err, gc.ErrorMatches, "cannot set maintenance mode of machine 1: not found or dead")
+}
+
+func (s *MachineSuite) TestAddContainerToMachineInMaintenance(c *gc.C) {
+	err := s.machine.SetMaintenance(true)
+	c.Assert(err, gc.IsNil)
+	template := state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}
+	_, err = s.State.AddMachineInsideMachine(template, s.machine.Id(), instance.LXC)
+	c.Assert(err, gc.ErrorMatches, "cannot add a new machine: machine 1 is in maintenance mode")
+
+	err = s.machine.SetMaintenance(false)
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddMachineInsideMachine(template, s.machine.Id(), instance.LXC)
+	c.Assert(err, gc.IsNil)
+}
+
 func (s *MachineSuite) TestCannotDestroyMachineWithVote(c *gc.C) {
 	err := s.machine.SetHasVote(true)
 	c.Assert(err, gc.IsNil)
@@ -348,6 +389,30 @@ func (s *MachineSuite) TestMachineWaitAgentAlive(c *gc.C) {
 	c.Assert(alive, gc.Equals, false)
 }
 
+func (s *MachineSuite) TestMachineWatchAgentPresence(c *gc.C) {
+	ch := make(chan presence.Change)
+	s.machine.WatchAgentPresence(ch)
+	defer s.machine.UnwatchAgentPresence(ch)
+	assertChange := func(alive bool) {
+		s.State.StartSync()
+		select {
+		case change := <-ch:
+			c.Assert(change.Alive, gc.Equals, alive)
+		case <-time.After(coretesting.LongWait):
+			c.Fatalf("timed out waiting for presence change")
+		}
+	}
+	assertChange(false)
+
+	pinger, err := s.machine.SetAgentAlive()
+	c.Assert(err, gc.IsNil)
+	assertChange(true)
+
+	err = pinger.Kill()
+	c.Assert(err, gc.IsNil)
+	assertChange(false)
+}
+
 func (s *MachineSuite) TestMachineInstanceId(c *gc.C) {
 	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
 	c.Assert(err, gc.IsNil)
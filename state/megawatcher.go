@@ -10,6 +10,7 @@ import (
 
 	"labix.org/v2/mgo"
 
+	"launchpad.net/juju-core/charm"
 	"launchpad.net/juju-core/errors"
 	"launchpad.net/juju-core/state/api/params"
 	"launchpad.net/juju-core/state/multiwatcher"
@@ -157,16 +158,37 @@ func (svc *backingService) updated(st *State, store *multiwatcher.Store, id inte
 		}
 	}
 	if needConfig {
-		var err error
-		info.Config, _, err = readSettingsDoc(st, serviceSettingsKey(svc.Name, svc.CharmURL))
+		config, _, err := readSettingsDoc(st, serviceSettingsKey(svc.Name, svc.CharmURL))
 		if err != nil {
 			return err
 		}
+		info.Config = redactServiceConfig(st, svc.CharmURL, config)
 	}
 	store.Update(info)
 	return nil
 }
 
+// redactServiceConfig returns the given settings of a service running
+// the given charm, with the values of secret options replaced so that
+// they are not revealed to watchers. If the charm cannot be read, as
+// when it is being upgraded or has been removed, every value is
+// replaced.
+func redactServiceConfig(st *State, curl *charm.URL, settings map[string]interface{}) map[string]interface{} {
+	ch, err := st.Charm(curl)
+	if err == nil {
+		return ch.Config().RedactSettings(settings)
+	}
+	logger.Warningf("cannot read charm %q, redacting all config of its service: %v", curl, err)
+	out := make(map[string]interface{})
+	for name, value := range settings {
+		if value != nil {
+			value = charm.RedactedValue
+		}
+		out[name] = value
+	}
+	return out
+}
+
 func (svc *backingService) removed(st *State, store *multiwatcher.Store, id interface{}) error {
 	store.Remove(params.EntityId{
 		Kind: "service",
@@ -342,9 +364,13 @@ func (s *backingSettings) updated(st *State, store *multiwatcher.Store, id inter
 		if info.CharmURL != url {
 			break
 		}
+		curl, err := charm.ParseURL(url)
+		if err != nil {
+			return err
+		}
 		newInfo := *info
 		cleanSettingsMap(*s)
-		newInfo.Config = *s
+		newInfo.Config = redactServiceConfig(st, curl, *s)
 		info0 = &newInfo
 	default:
 		return nil
@@ -28,6 +28,12 @@ options:
   key.dotted: {default: My Key, description: Desc, type: string}
 `
 
+var secretConfig = `
+options:
+  title: {default: My Title, description: Desc, type: string}
+  password: {description: Desc, type: secret}
+`
+
 type storeManagerStateSuite struct {
 	testbase.LoggingSuite
 	testing.MgoSuite
@@ -846,6 +852,60 @@ var allWatcherChangedTests = []struct {
 				Config:   charm.Settings{"key.dotted": "foo"},
 			},
 		},
+	}, {
+		about: "service config secrets are redacted",
+		add: []params.EntityInfo{&params.ServiceInfo{
+			Name:     "wordpress",
+			CharmURL: "local:quantal/quantal-wordpress-3",
+		}},
+		setUp: func(c *gc.C, st *State) {
+			testCharm := AddCustomCharm(
+				c, st, "wordpress",
+				"config.yaml", secretConfig,
+				"quantal", 3)
+			svc := AddTestingService(c, st, "wordpress", testCharm)
+			setServiceConfigAttr(c, svc, "title", "foo")
+			setServiceConfigAttr(c, svc, "password", "sekrit")
+		},
+		change: watcher.Change{
+			C:  "settings",
+			Id: "s#wordpress#local:quantal/quantal-wordpress-3",
+		},
+		expectContents: []params.EntityInfo{
+			&params.ServiceInfo{
+				Name:     "wordpress",
+				CharmURL: "local:quantal/quantal-wordpress-3",
+				Config:   charm.Settings{"title": "foo", "password": charm.RedactedValue},
+			},
+		},
+	}, {
+		about: "service config is redacted entirely if the charm cannot be read",
+		add: []params.EntityInfo{&params.ServiceInfo{
+			Name:     "wordpress",
+			CharmURL: "local:quantal/quantal-wordpress-3",
+		}},
+		setUp: func(c *gc.C, st *State) {
+			testCharm := AddCustomCharm(
+				c, st, "wordpress",
+				"config.yaml", secretConfig,
+				"quantal", 3)
+			svc := AddTestingService(c, st, "wordpress", testCharm)
+			setServiceConfigAttr(c, svc, "title", "foo")
+			setServiceConfigAttr(c, svc, "password", "sekrit")
+			err := st.charms.RemoveId(testCharm.URL())
+			c.Assert(err, gc.IsNil)
+		},
+		change: watcher.Change{
+			C:  "settings",
+			Id: "s#wordpress#local:quantal/quantal-wordpress-3",
+		},
+		expectContents: []params.EntityInfo{
+			&params.ServiceInfo{
+				Name:     "wordpress",
+				CharmURL: "local:quantal/quantal-wordpress-3",
+				Config:   charm.Settings{"title": charm.RedactedValue, "password": charm.RedactedValue},
+			},
+		},
 	}, {
 		about: "service config is unchanged if service exists in the store with a different URL",
 		add: []params.EntityInfo{&params.ServiceInfo{
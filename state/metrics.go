@@ -0,0 +1,198 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"time"
+
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
+	"labix.org/v2/mgo/txn"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/utils"
+)
+
+// Metric holds a single value of a metric declared by a charm.
+type Metric struct {
+	Key   string
+	Value string
+	Time  time.Time
+}
+
+// metricBatchDoc records a batch of metrics collected from a unit.
+type metricBatchDoc struct {
+	UUID     string `bson:"_id"`
+	Unit     string
+	Service  string
+	CharmURL string
+	Created  time.Time
+	Received time.Time
+	Metrics  []Metric
+}
+
+// MetricBatch represents a batch of metrics collected by a single run
+// of a unit's collect-metrics hook.
+type MetricBatch struct {
+	st  *State
+	doc metricBatchDoc
+}
+
+// UUID returns the identifier of the batch.
+func (m *MetricBatch) UUID() string {
+	return m.doc.UUID
+}
+
+// Unit returns the name of the unit the metrics were collected from.
+func (m *MetricBatch) Unit() string {
+	return m.doc.Unit
+}
+
+// CharmURL returns the URL of the charm that declared the metrics.
+func (m *MetricBatch) CharmURL() string {
+	return m.doc.CharmURL
+}
+
+// Created returns when the metrics were collected.
+func (m *MetricBatch) Created() time.Time {
+	return m.doc.Created
+}
+
+// Received returns when the state server recorded the batch.
+func (m *MetricBatch) Received() time.Time {
+	return m.doc.Received
+}
+
+// Metrics returns the metric values in the batch.
+func (m *MetricBatch) Metrics() []Metric {
+	result := make([]Metric, len(m.doc.Metrics))
+	copy(result, m.doc.Metrics)
+	return result
+}
+
+// AddMetrics records a batch of metrics collected from the unit. The
+// batch is identified by uuid; adding a batch that has already been
+// recorded has no effect, so that a unit agent may safely send a batch
+// again if it did not learn that the first attempt succeeded.
+func (u *Unit) AddMetrics(uuid string, created time.Time, charmURL string, metrics []Metric) (_ *MetricBatch, err error) {
+	defer utils.ErrorContextf(&err, "cannot add metrics for unit %q", u)
+	if !utils.IsValidUUIDString(uuid) {
+		return nil, fmt.Errorf("invalid batch UUID %q", uuid)
+	}
+	if _, err := charm.ParseURL(charmURL); err != nil {
+		return nil, err
+	}
+	doc := metricBatchDoc{
+		UUID:     uuid,
+		Unit:     u.doc.Name,
+		Service:  u.doc.Service,
+		CharmURL: charmURL,
+		Created:  created,
+		Received: time.Now(),
+		Metrics:  metrics,
+	}
+	ops := []txn.Op{{
+		C:      u.st.units.Name,
+		Id:     u.doc.Name,
+		Assert: notDeadDoc,
+	}, {
+		C:      u.st.metrics.Name,
+		Id:     uuid,
+		Assert: txn.DocMissing,
+		Insert: &doc,
+	}}
+	if err := u.st.runTransaction(ops); err != txn.ErrAborted {
+		if err != nil {
+			return nil, err
+		}
+		return &MetricBatch{st: u.st, doc: doc}, nil
+	}
+	var existing metricBatchDoc
+	if err := u.st.metrics.FindId(uuid).One(&existing); err == nil {
+		if existing.Unit != u.doc.Name {
+			return nil, fmt.Errorf("batch %q was added by unit %q", uuid, existing.Unit)
+		}
+		return &MetricBatch{st: u.st, doc: existing}, nil
+	}
+	if notDead, err := isNotDead(u.st.units, u.doc.Name); err != nil {
+		return nil, err
+	} else if !notDead {
+		return nil, fmt.Errorf("unit is dead")
+	}
+	return nil, ErrExcessiveContention
+}
+
+// MetricBatches returns the batches of metrics collected from the
+// units of the service, oldest first. Batches collected from the
+// units of an earlier service with the same name are not returned,
+// even if they have not yet been removed.
+func (s *Service) MetricBatches() ([]*MetricBatch, error) {
+	sel := D{{"service", s.doc.Name}}
+	removed, err := s.st.metricsRemovedAt(s.doc.Name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get metrics for service %q: %v", s, err)
+	}
+	if !removed.IsZero() {
+		sel = append(sel, bson.DocElem{"received", D{{"$gte", removed}}})
+	}
+	var docs []metricBatchDoc
+	err = s.st.metrics.Find(sel).Sort("created", "_id").All(&docs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get metrics for service %q: %v", s, err)
+	}
+	batches := make([]*MetricBatch, len(docs))
+	for i, doc := range docs {
+		batches[i] = &MetricBatch{st: s.st, doc: doc}
+	}
+	return batches, nil
+}
+
+// removeMetricsOp returns an operation that schedules the removal of
+// the batches of metrics collected from the units of the named
+// service, which is being removed. Only the batches received before
+// now are removed, so that the metrics of a new service with the same
+// name are kept.
+func removeMetricsOp(st *State, serviceName string) txn.Op {
+	op := st.newCleanupOp("metrics", serviceName)
+	op.Insert.(*cleanupDoc).Before = time.Now()
+	return op
+}
+
+// metricsRemovedAt returns the latest time before which the metrics
+// of the named service are scheduled for removal, or the zero time
+// if none are.
+func (st *State) metricsRemovedAt(serviceName string) (time.Time, error) {
+	var doc cleanupDoc
+	err := st.cleanups.Find(D{{"kind", "metrics"}, {"prefix", serviceName}}).Sort("-before").One(&doc)
+	if err == mgo.ErrNotFound {
+		return time.Time{}, nil
+	} else if err != nil {
+		return time.Time{}, err
+	}
+	return doc.Before, nil
+}
+
+// cleanupMetrics removes the batches of metrics collected from the
+// units of the named service before the given time.
+func (st *State) cleanupMetrics(serviceName string, before time.Time) error {
+	sel := D{{"service", serviceName}, {"received", D{{"$lt", before}}}}
+	if _, err := st.metrics.RemoveAll(sel); err != nil {
+		return fmt.Errorf("cannot remove metrics of service %q: %v", serviceName, err)
+	}
+	return nil
+}
+
+// PruneMetrics removes the batches of metrics received before the
+// given time, so that metrics are kept only for a limited period.
+func (st *State) PruneMetrics(before time.Time) error {
+	info, err := st.metrics.RemoveAll(D{{"received", D{{"$lt", before}}}})
+	if err != nil {
+		return fmt.Errorf("cannot prune metrics: %v", err)
+	}
+	if info.Removed > 0 {
+		logger.Debugf("pruned %d batches of metrics received before %v", info.Removed, before)
+	}
+	return nil
+}
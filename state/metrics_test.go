@@ -0,0 +1,192 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/state"
+	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/utils"
+)
+
+type MetricsSuite struct {
+	ConnSuite
+	charm    *state.Charm
+	service  *state.Service
+	unit     *state.Unit
+	charmURL string
+}
+
+var _ = gc.Suite(&MetricsSuite{})
+
+func (s *MetricsSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.charm = s.AddTestingCharm(c, "metered")
+	s.charmURL = s.charm.URL().String()
+	s.service = s.AddTestingService(c, "metered", s.charm)
+	var err error
+	s.unit, err = s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+}
+
+func newUUID(c *gc.C) string {
+	uuid, err := utils.NewUUID()
+	c.Assert(err, gc.IsNil)
+	return uuid.String()
+}
+
+func (s *MetricsSuite) TestAddMetrics(c *gc.C) {
+	created := time.Unix(1000, 0)
+	metrics := []state.Metric{
+		{Key: "users", Value: "5", Time: time.Unix(999, 0)},
+		{Key: "requests", Value: "42", Time: time.Unix(999, 0)},
+	}
+	uuid := newUUID(c)
+	batch, err := s.unit.AddMetrics(uuid, created, s.charmURL, metrics)
+	c.Assert(err, gc.IsNil)
+	c.Assert(batch.UUID(), gc.Equals, uuid)
+	c.Assert(batch.Unit(), gc.Equals, "metered/0")
+	c.Assert(batch.CharmURL(), gc.Equals, s.charmURL)
+	c.Assert(batch.Created(), gc.DeepEquals, created)
+	c.Assert(batch.Metrics(), gc.DeepEquals, metrics)
+
+	batches, err := s.service.MetricBatches()
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 1)
+	c.Assert(batches[0].UUID(), gc.Equals, uuid)
+	c.Assert(batches[0].Unit(), gc.Equals, "metered/0")
+	c.Assert(batches[0].Created(), gc.DeepEquals, created)
+	c.Assert(batches[0].Metrics(), gc.DeepEquals, metrics)
+}
+
+func (s *MetricsSuite) TestAddMetricsTwice(c *gc.C) {
+	uuid := newUUID(c)
+	metrics := []state.Metric{{Key: "users", Value: "5", Time: time.Unix(999, 0)}}
+	_, err := s.unit.AddMetrics(uuid, time.Unix(1000, 0), s.charmURL, metrics)
+	c.Assert(err, gc.IsNil)
+	_, err = s.unit.AddMetrics(uuid, time.Unix(1000, 0), s.charmURL, metrics)
+	c.Assert(err, gc.IsNil)
+	batches, err := s.service.MetricBatches()
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 1)
+
+	// Another unit may not reuse the batch's identifier.
+	other, err := s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	_, err = other.AddMetrics(uuid, time.Unix(1000, 0), s.charmURL, metrics)
+	c.Assert(err, gc.ErrorMatches, `cannot add metrics for unit "metered/1": batch ".*" was added by unit "metered/0"`)
+}
+
+func (s *MetricsSuite) TestMetricBatchesOrder(c *gc.C) {
+	other, err := s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	metrics := []state.Metric{{Key: "users", Value: "5", Time: time.Unix(999, 0)}}
+	_, err = s.unit.AddMetrics(newUUID(c), time.Unix(2000, 0), s.charmURL, metrics)
+	c.Assert(err, gc.IsNil)
+	_, err = other.AddMetrics(newUUID(c), time.Unix(1000, 0), s.charmURL, metrics)
+	c.Assert(err, gc.IsNil)
+	batches, err := s.service.MetricBatches()
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 2)
+	c.Assert(batches[0].Unit(), gc.Equals, "metered/1")
+	c.Assert(batches[1].Unit(), gc.Equals, "metered/0")
+}
+
+func (s *MetricsSuite) TestAddMetricsErrors(c *gc.C) {
+	metrics := []state.Metric{{Key: "users", Value: "5", Time: time.Unix(999, 0)}}
+	_, err := s.unit.AddMetrics("not-a-uuid", time.Unix(1000, 0), s.charmURL, metrics)
+	c.Assert(err, gc.ErrorMatches, `cannot add metrics for unit "metered/0": invalid batch UUID "not-a-uuid"`)
+	_, err = s.unit.AddMetrics(newUUID(c), time.Unix(1000, 0), "bad url", metrics)
+	c.Assert(err, gc.ErrorMatches, `cannot add metrics for unit "metered/0": .*`)
+
+	err = s.unit.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	_, err = s.unit.AddMetrics(newUUID(c), time.Unix(1000, 0), s.charmURL, metrics)
+	c.Assert(err, gc.ErrorMatches, `cannot add metrics for unit "metered/0": unit is dead`)
+}
+
+func (s *MetricsSuite) countMetricBatches(c *gc.C) int {
+	count, err := s.MgoSuite.Session.DB("juju").C("metrics").Count()
+	c.Assert(err, gc.IsNil)
+	return count
+}
+
+func (s *MetricsSuite) removeService(c *gc.C) {
+	err := s.unit.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.unit.Remove()
+	c.Assert(err, gc.IsNil)
+	err = s.service.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = s.service.Refresh()
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+}
+
+func (s *MetricsSuite) TestMetricsRemovedWithService(c *gc.C) {
+	metrics := []state.Metric{{Key: "users", Value: "5", Time: time.Unix(999, 0)}}
+	_, err := s.unit.AddMetrics(newUUID(c), time.Unix(1000, 0), s.charmURL, metrics)
+	c.Assert(err, gc.IsNil)
+	s.removeService(c)
+	c.Assert(s.countMetricBatches(c), gc.Equals, 1)
+
+	err = s.State.Cleanup()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.countMetricBatches(c), gc.Equals, 0)
+	dirty, err := s.State.NeedsCleanup()
+	c.Assert(err, gc.IsNil)
+	c.Assert(dirty, jc.IsFalse)
+}
+
+func (s *MetricsSuite) TestMetricBatchesOfRedeployedService(c *gc.C) {
+	metrics := []state.Metric{{Key: "users", Value: "5", Time: time.Unix(999, 0)}}
+	_, err := s.unit.AddMetrics(newUUID(c), time.Unix(1000, 0), s.charmURL, metrics)
+	c.Assert(err, gc.IsNil)
+	s.removeService(c)
+
+	// Deploy the service again before the old metrics are cleaned up.
+	service := s.AddTestingService(c, "metered", s.charm)
+	unit, err := service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	uuid := newUUID(c)
+	_, err = unit.AddMetrics(uuid, time.Unix(2000, 0), s.charmURL, metrics)
+	c.Assert(err, gc.IsNil)
+
+	// Only the new service's metrics are visible, before
+	// and after the old ones are removed.
+	assertBatches := func() {
+		batches, err := service.MetricBatches()
+		c.Assert(err, gc.IsNil)
+		c.Assert(batches, gc.HasLen, 1)
+		c.Assert(batches[0].UUID(), gc.Equals, uuid)
+	}
+	assertBatches()
+	c.Assert(s.countMetricBatches(c), gc.Equals, 2)
+
+	err = s.State.Cleanup()
+	c.Assert(err, gc.IsNil)
+	assertBatches()
+	c.Assert(s.countMetricBatches(c), gc.Equals, 1)
+}
+
+func (s *MetricsSuite) TestPruneMetrics(c *gc.C) {
+	metrics := []state.Metric{{Key: "users", Value: "5", Time: time.Unix(999, 0)}}
+	_, err := s.unit.AddMetrics(newUUID(c), time.Unix(1000, 0), s.charmURL, metrics)
+	c.Assert(err, gc.IsNil)
+
+	// Batches received after the given time are kept.
+	err = s.State.PruneMetrics(time.Now().Add(-time.Hour))
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.countMetricBatches(c), gc.Equals, 1)
+
+	err = s.State.PruneMetrics(time.Now().Add(time.Second))
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.countMetricBatches(c), gc.Equals, 0)
+	batches, err := s.service.MetricBatches()
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 0)
+}
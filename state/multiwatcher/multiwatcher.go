@@ -10,6 +10,7 @@ import (
 
 	"launchpad.net/tomb"
 
+	"launchpad.net/juju-core/names"
 	"launchpad.net/juju-core/state/api/params"
 	"launchpad.net/juju-core/state/watcher"
 )
@@ -18,6 +19,9 @@ import (
 type Watcher struct {
 	all *StoreManager
 
+	// filter restricts the changes sent to the watcher.
+	filter params.WatchAllFilter
+
 	// The following fields are maintained by the StoreManager
 	// goroutine.
 	revno   int64
@@ -32,6 +36,16 @@ func NewWatcher(all *StoreManager) *Watcher {
 	}
 }
 
+// NewFilteredWatcher is like NewWatcher, but the returned
+// watcher only observes changes to entities that match
+// the given filter.
+func NewFilteredWatcher(all *StoreManager, filter params.WatchAllFilter) *Watcher {
+	return &Watcher{
+		all:    all,
+		filter: filter,
+	}
+}
+
 // Stop stops the watcher.
 func (w *Watcher) Stop() error {
 	select {
@@ -227,8 +241,16 @@ func (sm *StoreManager) respond() {
 		if len(changes) == 0 {
 			continue
 		}
-		req.changes = changes
+		// The watcher is treated as having seen all the changes,
+		// even those it filters out, so that entities are
+		// reference counted in the same way for all watchers.
 		w.revno = sm.all.latestRevno
+		sm.seen(revno)
+		changes = filterChanges(w.filter, changes)
+		if len(changes) == 0 {
+			continue
+		}
+		req.changes = changes
 		req.reply <- true
 		if req := req.next; req == nil {
 			// Last request for this watcher.
@@ -236,10 +258,95 @@ func (sm *StoreManager) respond() {
 		} else {
 			sm.waiting[w] = req
 		}
-		sm.seen(revno)
 	}
 }
 
+// filterChanges returns the changes to entities
+// that match the given filter.
+func filterChanges(filter params.WatchAllFilter, changes []params.Delta) []params.Delta {
+	if len(filter.Kinds) == 0 && !isScoped(filter) {
+		return changes
+	}
+	filtered := changes[:0]
+	for _, change := range changes {
+		if filterMatches(filter, change.Entity) {
+			filtered = append(filtered, change)
+		}
+	}
+	return filtered
+}
+
+// isScoped reports whether the filter restricts
+// entities to particular services, machines or tags.
+func isScoped(filter params.WatchAllFilter) bool {
+	return len(filter.Services) > 0 || len(filter.Machines) > 0 || len(filter.Tags) > 0
+}
+
+// filterMatches reports whether the given entity matches the filter.
+func filterMatches(filter params.WatchAllFilter, info params.EntityInfo) bool {
+	if len(filter.Kinds) > 0 && !contains(filter.Kinds, info.EntityId().Kind) {
+		return false
+	}
+	if !isScoped(filter) {
+		return true
+	}
+	tag, services, machine := entityScope(info)
+	if contains(filter.Tags, tag) {
+		return true
+	}
+	if machine != "" && contains(filter.Machines, machine) {
+		return true
+	}
+	for _, service := range services {
+		if contains(filter.Services, service) {
+			return true
+		}
+	}
+	return false
+}
+
+// entityScope returns the tag of the given entity, and the
+// services and machine that it relates to, if any.
+func entityScope(info params.EntityInfo) (tag string, services []string, machine string) {
+	switch info := info.(type) {
+	case *params.MachineInfo:
+		return names.MachineTag(info.Id), nil, info.Id
+	case *params.ServiceInfo:
+		return names.ServiceTag(info.Name), []string{info.Name}, ""
+	case *params.UnitInfo:
+		return names.UnitTag(info.Name), []string{info.Service}, info.MachineId
+	case *params.RelationInfo:
+		for _, ep := range info.Endpoints {
+			services = append(services, ep.ServiceName)
+		}
+		return names.RelationTag(info.Key), services, ""
+	case *params.AnnotationInfo:
+		kind, id, err := names.ParseTag(info.Tag, "")
+		if err != nil {
+			return info.Tag, nil, ""
+		}
+		switch kind {
+		case names.MachineTagKind:
+			machine = id
+		case names.ServiceTagKind:
+			services = []string{id}
+		case names.UnitTagKind:
+			services = []string{names.UnitService(id)}
+		}
+		return info.Tag, services, machine
+	}
+	return "", nil, ""
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 // seen states that a Watcher has just been given information about
 // all entities newer than the given revno.  We assume it has already
 // seen all the older entities.
@@ -643,6 +643,160 @@ func (*storeManagerSuite) TestRun(c *gc.C) {
 	}, "")
 }
 
+func (*storeManagerSuite) TestRunFiltered(c *gc.C) {
+	b := newTestBacking([]params.EntityInfo{
+		&params.MachineInfo{Id: "0"},
+		&params.ServiceInfo{Name: "logging"},
+		&params.ServiceInfo{Name: "wordpress"},
+		&params.UnitInfo{Name: "wordpress/0", Service: "wordpress", MachineId: "0"},
+	})
+	sm := NewStoreManager(b)
+	defer func() {
+		c.Check(sm.Stop(), gc.IsNil)
+	}()
+	w := NewFilteredWatcher(sm, params.WatchAllFilter{Services: []string{"wordpress"}})
+	checkNext(c, w, []params.Delta{
+		{Entity: &params.ServiceInfo{Name: "wordpress"}},
+		{Entity: &params.UnitInfo{Name: "wordpress/0", Service: "wordpress", MachineId: "0"}},
+	}, "")
+	// An unfiltered watcher is used to check that
+	// changes to other entities have been processed.
+	all := NewWatcher(sm)
+	d, err := getNext(c, all, 1*time.Second)
+	c.Assert(err, gc.IsNil)
+	c.Assert(d, gc.HasLen, 4)
+
+	// Changes to other entities are not sent.
+	b.updateEntity(&params.ServiceInfo{Name: "logging", Exposed: true})
+	checkNext(c, all, []params.Delta{
+		{Entity: &params.ServiceInfo{Name: "logging", Exposed: true}},
+	}, "")
+	b.updateEntity(&params.ServiceInfo{Name: "wordpress", Exposed: true})
+	checkNext(c, w, []params.Delta{
+		{Entity: &params.ServiceInfo{Name: "wordpress", Exposed: true}},
+	}, "")
+	b.deleteEntity(params.EntityId{"unit", "wordpress/0"})
+	checkNext(c, w, []params.Delta{
+		{Removed: true, Entity: &params.UnitInfo{Name: "wordpress/0", Service: "wordpress", MachineId: "0"}},
+	}, "")
+}
+
+func (*storeManagerSuite) TestRespondFiltered(c *gc.C) {
+	sm := newStoreManagerNoRun(newTestBacking(nil))
+	w := &Watcher{all: sm, filter: params.WatchAllFilter{Kinds: []string{"service"}}}
+	sm.all.Update(&params.MachineInfo{Id: "0"})
+	req := &request{w: w, reply: make(chan bool, 1)}
+	sm.handle(req)
+	sm.respond()
+
+	// The watcher has seen the change, but is
+	// still waiting for a change that it matches.
+	assertNotReplied(c, req)
+	assertWaitingRequests(c, sm, map[*Watcher][]*request{w: {req}})
+	c.Assert(w.revno, gc.Equals, sm.all.latestRevno)
+	assertStoreContents(c, sm.all, 1, []entityEntry{{
+		creationRevno: 1,
+		revno:         1,
+		refCount:      1,
+		info:          &params.MachineInfo{Id: "0"},
+	}})
+
+	sm.all.Update(&params.ServiceInfo{Name: "wordpress"})
+	sm.respond()
+	assertReplied(c, true, req)
+	c.Assert(req.changes, gc.DeepEquals, []params.Delta{{Entity: &params.ServiceInfo{Name: "wordpress"}}})
+}
+
+var filterMatchesTests = []struct {
+	about  string
+	filter params.WatchAllFilter
+	info   params.EntityInfo
+	match  bool
+}{{
+	about: "zero filter matches everything",
+	info:  &params.MachineInfo{Id: "0"},
+	match: true,
+}, {
+	about:  "kind matches",
+	filter: params.WatchAllFilter{Kinds: []string{"service", "machine"}},
+	info:   &params.MachineInfo{Id: "0"},
+	match:  true,
+}, {
+	about:  "kind does not match",
+	filter: params.WatchAllFilter{Kinds: []string{"service"}},
+	info:   &params.MachineInfo{Id: "0"},
+}, {
+	about:  "machine matches",
+	filter: params.WatchAllFilter{Machines: []string{"0"}},
+	info:   &params.MachineInfo{Id: "0"},
+	match:  true,
+}, {
+	about:  "unit on machine matches",
+	filter: params.WatchAllFilter{Machines: []string{"0"}},
+	info:   &params.UnitInfo{Name: "wordpress/0", Service: "wordpress", MachineId: "0"},
+	match:  true,
+}, {
+	about:  "unit on other machine does not match",
+	filter: params.WatchAllFilter{Machines: []string{"0"}},
+	info:   &params.UnitInfo{Name: "wordpress/1", Service: "wordpress", MachineId: "1"},
+}, {
+	about:  "unassigned unit does not match machine",
+	filter: params.WatchAllFilter{Machines: []string{""}},
+	info:   &params.UnitInfo{Name: "wordpress/1", Service: "wordpress"},
+}, {
+	about:  "relation of service matches",
+	filter: params.WatchAllFilter{Services: []string{"mysql"}},
+	info: &params.RelationInfo{
+		Key:       "wordpress:db mysql:server",
+		Endpoints: []params.Endpoint{{ServiceName: "wordpress"}, {ServiceName: "mysql"}},
+	},
+	match: true,
+}, {
+	about:  "relation of other services does not match",
+	filter: params.WatchAllFilter{Services: []string{"logging"}},
+	info: &params.RelationInfo{
+		Key:       "wordpress:db mysql:server",
+		Endpoints: []params.Endpoint{{ServiceName: "wordpress"}, {ServiceName: "mysql"}},
+	},
+}, {
+	about:  "annotation on unit of service matches",
+	filter: params.WatchAllFilter{Services: []string{"wordpress"}},
+	info:   &params.AnnotationInfo{Tag: "unit-wordpress-0"},
+	match:  true,
+}, {
+	about:  "annotation on machine matches",
+	filter: params.WatchAllFilter{Machines: []string{"1"}},
+	info:   &params.AnnotationInfo{Tag: "machine-1"},
+	match:  true,
+}, {
+	about:  "annotation on environment does not match service",
+	filter: params.WatchAllFilter{Services: []string{"wordpress"}},
+	info:   &params.AnnotationInfo{Tag: "environment-foo"},
+}, {
+	about:  "tag matches",
+	filter: params.WatchAllFilter{Tags: []string{"service-mysql"}},
+	info:   &params.ServiceInfo{Name: "mysql"},
+	match:  true,
+}, {
+	about:  "tag of other entity does not match",
+	filter: params.WatchAllFilter{Tags: []string{"service-mysql"}},
+	info:   &params.UnitInfo{Name: "mysql/0", Service: "mysql"},
+}, {
+	about: "kind and scope must both match",
+	filter: params.WatchAllFilter{
+		Kinds:    []string{"unit"},
+		Services: []string{"wordpress"},
+	},
+	info: &params.ServiceInfo{Name: "wordpress"},
+}}
+
+func (*storeManagerSuite) TestFilterMatches(c *gc.C) {
+	for i, test := range filterMatchesTests {
+		c.Logf("test %d: %s", i, test.about)
+		c.Check(filterMatches(test.filter, test.info), gc.Equals, test.match)
+	}
+}
+
 func (*storeManagerSuite) TestWatcherStop(c *gc.C) {
 	sm := NewStoreManager(newTestBacking(nil))
 	defer func() {
@@ -185,6 +185,7 @@ var indexes = []struct {
 	{"units", []string{"principal"}},
 	{"units", []string{"machineid"}},
 	{"users", []string{"name"}},
+	{"metrics", []string{"service", "created"}},
 }
 
 // The capped collection used for transaction logs defaults to 10MB.
@@ -238,31 +239,72 @@ func newState(session *mgo.Session, info *Info, policy Policy) (*State, error) {
 			return nil, maybeUnauthorized(err, "cannot log in to admin database")
 		}
 	}
+	st, err := openState(session, info, policy, "")
+	if err != nil {
+		return nil, err
+	}
+	// TODO(rog) delete this when we can assume there are no
+	// pre-1.18 environments running.
+	if err := st.createStateServersDoc(); err != nil {
+		return nil, fmt.Errorf("cannot create state servers document: %v", err)
+	}
+	return st, nil
+}
+
+// hostedPrefix returns the prefix of the names of the collections
+// holding the hosted environment with the given UUID.
+func hostedPrefix(uuid string) string {
+	return uuid + "."
+}
+
+// openState returns a State for the environment with the given UUID,
+// using the given logged-in session. The environment's collections
+// are named with hostedPrefix(uuid); an empty uuid opens the state
+// server's own environment, whose collections are not prefixed.
+// The state server machines and the registry of hosted environments
+// are always those of the state server's environment.
+func openState(session *mgo.Session, info *Info, policy Policy, uuid string) (*State, error) {
+	db := session.DB("juju")
+	pdb := session.DB("presence")
+	prefix := ""
+	if uuid != "" {
+		prefix = hostedPrefix(uuid)
+	}
+	c := func(name string) *mgo.Collection {
+		return db.C(prefix + name)
+	}
 	st := &State{
-		info:           info,
-		policy:         policy,
-		db:             db,
-		environments:   db.C("environments"),
-		charms:         db.C("charms"),
-		machines:       db.C("machines"),
-		containerRefs:  db.C("containerRefs"),
-		instanceData:   db.C("instanceData"),
-		relations:      db.C("relations"),
-		relationScopes: db.C("relationscopes"),
-		services:       db.C("services"),
-		minUnits:       db.C("minunits"),
-		settings:       db.C("settings"),
-		settingsrefs:   db.C("settingsrefs"),
-		constraints:    db.C("constraints"),
-		units:          db.C("units"),
-		users:          db.C("users"),
-		presence:       pdb.C("presence"),
-		cleanups:       db.C("cleanups"),
-		annotations:    db.C("annotations"),
-		statuses:       db.C("statuses"),
-		stateServers:   db.C("stateServers"),
-	}
-	log := db.C("txns.log")
+		info:               info,
+		policy:             policy,
+		hostedUUID:         uuid,
+		db:                 db,
+		environments:       c("environments"),
+		charms:             c("charms"),
+		machines:           c("machines"),
+		containerRefs:      c("containerRefs"),
+		instanceData:       c("instanceData"),
+		relations:          c("relations"),
+		relationScopes:     c("relationscopes"),
+		services:           c("services"),
+		minUnits:           c("minunits"),
+		settings:           c("settings"),
+		settingsrefs:       c("settingsrefs"),
+		settingsHistory:    c("settingshistory"),
+		constraints:        c("constraints"),
+		units:              c("units"),
+		users:              c("users"),
+		presence:           pdb.C(prefix + "presence"),
+		cleanups:           c("cleanups"),
+		annotations:        c("annotations"),
+		statuses:           c("statuses"),
+		hookStats:          c("hookstats"),
+		metrics:            c("metrics"),
+		sequences:          c("sequence"),
+		stateServers:       db.C("stateServers"),
+		serverMachines:     db.C("machines"),
+		hostedEnvironments: db.C("hostedenvironments"),
+	}
+	log := c("txns.log")
 	logInfo := mgo.CollectionInfo{Capped: true, MaxBytes: logSize}
 	// The lack of error code for this error was reported upstream:
 	//     https://jira.klmongodb.org/browse/SERVER-6992
@@ -270,25 +312,37 @@ func newState(session *mgo.Session, info *Info, policy Policy) (*State, error) {
 	if err != nil && err.Error() != "collection already exists" {
 		return nil, maybeUnauthorized(err, "cannot create log collection")
 	}
-	st.runner = txn.NewRunner(db.C("txns"))
-	st.runner.ChangeLog(db.C("txns.log"))
-	st.watcher = watcher.New(db.C("txns.log"))
-	st.pwatcher = presence.NewWatcher(pdb.C("presence"))
+	st.runner = txn.NewRunner(c("txns"))
+	st.runner.ChangeLog(log)
+	grace, err := presenceGracePeriod(st)
+	if err != nil {
+		return nil, err
+	}
+	st.watcher = watcher.New(log)
+	st.pwatcher = presence.NewWatcherWithGrace(st.presence, grace)
 	for _, item := range indexes {
 		index := mgo.Index{Key: item.key}
-		if err := db.C(item.collection).EnsureIndex(index); err != nil {
+		if err := c(item.collection).EnsureIndex(index); err != nil {
 			return nil, fmt.Errorf("cannot create database index: %v", err)
 		}
 	}
 	st.transactionHooks = make(chan ([]transactionHook), 1)
 	st.transactionHooks <- nil
+	return st, nil
+}
 
-	// TODO(rog) delete this when we can assume there are no
-	// pre-1.18 environments running.
-	if err := st.createStateServersDoc(); err != nil {
-		return nil, fmt.Errorf("cannot create state servers document: %v", err)
+// presenceGracePeriod returns the presence grace period held in the
+// environment configuration. It returns zero, which the presence
+// watcher takes as its default period, if the environment has not
+// been initialized yet or does not set the period.
+func presenceGracePeriod(st *State) (time.Duration, error) {
+	cfg, err := st.EnvironConfig()
+	if errors.IsNotFoundError(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("cannot read environment configuration: %v", err)
 	}
-	return st, nil
+	return cfg.PresenceGracePeriod(), nil
 }
 
 // createStateServersDoc creates the state servers document
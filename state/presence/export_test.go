@@ -3,6 +3,13 @@
 
 package presence
 
+import (
+	"time"
+
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
+)
+
 func FakeTimeSlot(offset int) {
 	fakeTimeSlot(offset)
 }
@@ -24,3 +31,27 @@ func RealPeriod() {
 func FindAllBeings(w *Watcher) (map[int64]beingInfo, error) {
 	return w.findAllBeings()
 }
+
+func NewSlotClock(base *mgo.Collection) *slotClock {
+	return newSlotClock(base)
+}
+
+func AdvanceSlotClock(c *slotClock) error {
+	return c.advance()
+}
+
+func Ping(p *Pinger) error {
+	return p.ping()
+}
+
+// LegacyPing records a ping for a new sequence of p in the wall-clock
+// time slot holding the given time, as pingers that predate the time
+// slot counter do.
+func LegacyPing(p *Pinger, now time.Time) error {
+	if err := p.prepare(); err != nil {
+		return err
+	}
+	slot := legacyTimeSlot(now)
+	_, err := p.pings.UpsertId(slot, bson.D{{"$inc", bson.D{{"alive." + p.fieldKey, p.fieldBit}}}})
+	return err
+}
@@ -9,6 +9,7 @@ package presence
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"sync"
 	"time"
@@ -18,6 +19,7 @@ import (
 	"launchpad.net/tomb"
 
 	"launchpad.net/juju-core/log"
+	"launchpad.net/juju-core/utils/metrics"
 )
 
 // Debug specifies whether the package will log debug
@@ -25,11 +27,42 @@ import (
 // TODO(rog) allow debug level setting in the log package.
 var Debug = false
 
+var (
+	pingersGauge = metrics.NewGauge(
+		"juju_presence_pingers",
+		"Number of running presence pingers.",
+	)
+	pingsTotal = metrics.NewCounter(
+		"juju_presence_pings_total",
+		"Number of pings written by presence pingers.",
+	)
+	presenceSyncsTotal = metrics.NewCounter(
+		"juju_presence_watcher_syncs_total",
+		"Number of times presence watchers have read the pings.",
+	)
+)
+
 // The implementation works by assigning a unique sequence number to each
 // pinger that is alive, and the pinger is then responsible for
 // periodically updating the current time slot document with its
 // sequence number so that watchers can tell it is alive.
 //
+// Time slots are not derived from any machine's clock. The current
+// slot is a counter held in a document of the helper collection:
+//
+// { "_id": "slot", "slot": <current time slot> }
+//
+// Every watcher attempts to advance the counter once per period, but
+// only does so if the counter has not moved since the watcher last
+// looked at it, so the counter advances at most once per period no
+// matter how many watchers are running, and keeps advancing as long
+// as any of them is. Pingers record their sequence under whatever
+// slot the counter holds when they ping. Clock skew between agents
+// and state servers, or a state server clock jumping, therefore has
+// no effect on liveness, and a replica set primary change can at
+// worst lose the last increment of the counter, which the grace
+// period absorbs.
+//
 // The internal implementation of the time slot document is as follows:
 //
 // {
@@ -41,8 +74,9 @@ var Debug = false
 // All pingers that have their sequence number under "alive" and not
 // under "dead" are currently alive. This design enables implementing
 // a ping with a single update operation, a kill with another operation,
-// and obtaining liveness data with a single query that returns two
-// documents (the last two time slots).
+// and obtaining liveness data with a single query that returns the
+// documents for the current time slot and the slots in the grace
+// period before it.
 //
 // A new pinger sequence is obtained every time a pinger starts by
 // atomically incrementing a counter in a globally used document in a
@@ -59,9 +93,12 @@ type Watcher struct {
 	pings  *mgo.Collection
 	beings *mgo.Collection
 
-	// delta is an approximate clock skew between the local system
-	// clock and the database clock.
-	delta time.Duration
+	// clock advances the time slot counter shared by all watchers.
+	clock *slotClock
+
+	// grace is how long a key is still considered alive after
+	// its pinger last reported.
+	grace time.Duration
 
 	// beingKey and beingSeq are the pinger seq <=> key mappings.
 	// Entries in these maps are considered alive.
@@ -87,6 +124,10 @@ type Watcher struct {
 	// knowledge. It's maintained here so that ForceRefresh
 	// can manipulate it to force a sync sooner.
 	next <-chan time.Time
+
+	// tick will dispatch when it's time to advance the
+	// time slot counter.
+	tick <-chan time.Time
 }
 
 type event struct {
@@ -101,12 +142,23 @@ type Change struct {
 	Alive bool
 }
 
-// NewWatcher returns a new Watcher.
+// NewWatcher returns a new Watcher with a grace period of
+// a single time slot.
 func NewWatcher(base *mgo.Collection) *Watcher {
+	return NewWatcherWithGrace(base, time.Duration(period)*time.Second)
+}
+
+// NewWatcherWithGrace returns a new Watcher that considers a key
+// alive until its pinger has failed to report for longer than the
+// given grace period, rounded up to a whole number of time slots.
+// A zero grace period is a single time slot, as for NewWatcher.
+func NewWatcherWithGrace(base *mgo.Collection, grace time.Duration) *Watcher {
 	w := &Watcher{
 		base:     base,
 		pings:    pingsC(base),
 		beings:   beingsC(base),
+		clock:    newSlotClock(base),
+		grace:    grace,
 		beingKey: make(map[int64]string),
 		beingSeq: make(map[string]int64),
 		watches:  make(map[string][]chan<- Change),
@@ -208,25 +260,31 @@ func (w *Watcher) Alive(key string) (bool, error) {
 	return alive, nil
 }
 
-// period is the length of each time slot in seconds.
+// period is the length of each time slot in seconds, which is
+// how often watchers attempt to advance the time slot counter.
 // It's not a time.Duration because the code is more convenient like
-// this and also because sub-second timings don't work as the slot
-// identifier is an int64 in seconds.
+// this.
 var period int64 = 30
 
 // loop implements the main watcher loop.
 func (w *Watcher) loop() error {
-	var err error
-	if w.delta, err = clockDelta(w.base); err != nil {
+	if err := w.clock.observe(); err != nil {
 		return err
 	}
 	w.next = time.After(0)
+	w.tick = time.After(time.Duration(period) * time.Second)
 	for {
 		select {
 		case <-w.tomb.Dying():
 			return tomb.ErrDying
+		case <-w.tick:
+			w.tick = time.After(time.Duration(period) * time.Second)
+			if err := w.clock.advance(); err != nil {
+				return err
+			}
+			w.next = time.After(0)
 		case <-w.next:
-			w.next = time.After(time.Duration(period) * time.Second)
+			w.next = nil
 			syncDone := w.syncDone
 			w.syncDone = nil
 			if err := w.sync(); err != nil {
@@ -330,10 +388,22 @@ func (w *Watcher) findAllBeings() (map[int64]beingInfo, error) {
 	return beingInfos, nil
 }
 
+// graceSlots returns the number of time slots before the current
+// one in which a ping still keeps a key alive.
+func (w *Watcher) graceSlots() int64 {
+	slots := int64(w.grace/time.Second) / period
+	if int64(w.grace/time.Second)%period != 0 || slots == 0 {
+		slots++
+	}
+	return slots
+}
+
 // sync updates the watcher knowledge from the database, and
-// queues events to observing channels. It fetches the last two time
-// slots and compares the union of both to the in-memory state.
+// queues events to observing channels. It fetches the current
+// time slot and those in the grace period before it, and compares
+// the union of them to the in-memory state.
 func (w *Watcher) sync() error {
+	presenceSyncsTotal.Inc()
 	var allBeings map[int64]beingInfo
 	if len(w.beingKey) == 0 {
 		// The very first time we sync, we grab all ever-known beings,
@@ -343,10 +413,30 @@ func (w *Watcher) sync() error {
 			return err
 		}
 	}
-	slot := timeSlot(time.Now(), w.delta)
+	slot, err := timeSlot(w.base)
+	if err != nil {
+		return err
+	}
+	// Pingers of agents that predate the time slot counter record
+	// their pings in wall-clock time slots instead, which are far
+	// above any value of the counter, so both are read. The
+	// wall-clock window is widened by a slot on either side to
+	// allow for skew between this machine's clock and theirs.
+	// This can be removed when we no longer maintain 1.18
+	// compatibility.
+	legacySlot := legacyTimeSlot(time.Now())
 	var ping []pingInfo
-	err := w.pings.Find(bson.D{{"$or", []pingInfo{{Slot: slot}, {Slot: slot - period}}}}).All(&ping)
-	if err != nil && err == mgo.ErrNotFound {
+	err = w.pings.Find(bson.D{{"$or", []bson.D{
+		{{"_id", bson.D{
+			{"$gte", slot - w.graceSlots()},
+			{"$lte", slot},
+		}}},
+		{{"_id", bson.D{
+			{"$gte", legacySlot - (w.graceSlots()+1)*period},
+			{"$lte", legacySlot + period},
+		}}},
+	}}}).All(&ping)
+	if err != nil {
 		return err
 	}
 
@@ -428,7 +518,7 @@ func (w *Watcher) sync() error {
 	}
 
 	// Pingers that were known to be alive and haven't reported
-	// in the last two slots are now considered dead. Dispatch
+	// in the grace period are now considered dead. Dispatch
 	// the respective events and forget their sequences.
 	for seq, key := range w.beingKey {
 		if dead[seq] || !alive[seq] {
@@ -455,7 +545,6 @@ type Pinger struct {
 	fieldKey string // hex(beingKey / 63)
 	fieldBit uint64 // 1 << (beingKey%63)
 	lastSlot int64
-	delta    time.Duration
 }
 
 // NewPinger returns a new Pinger to report that key is alive.
@@ -480,6 +569,7 @@ func (p *Pinger) Start() error {
 		return err
 	}
 	p.started = true
+	pingersGauge.Inc()
 	go func() {
 		p.tomb.Kill(p.loop())
 		p.tomb.Done()
@@ -495,6 +585,7 @@ func (p *Pinger) Stop() error {
 	defer p.mu.Unlock()
 	if p.started {
 		debugf("state/presence: stopping pinger for %q with seq=%d", p.beingKey, p.beingSeq)
+		pingersGauge.Dec()
 	}
 	p.tomb.Kill(nil)
 	err := p.tomb.Wait()
@@ -523,6 +614,7 @@ func (p *Pinger) killStarted() error {
 	p.tomb.Kill(nil)
 	killErr := p.tomb.Wait()
 	p.started = false
+	pingersGauge.Dec()
 
 	slot := p.lastSlot
 	udoc := bson.D{{"$inc", bson.D{{"dead." + p.fieldKey, p.fieldBit}}}}
@@ -539,12 +631,15 @@ func (p *Pinger) killStopped() error {
 	if err := p.prepare(); err != nil {
 		return err
 	}
-	slot := timeSlot(time.Now(), p.delta)
+	slot, err := timeSlot(p.base)
+	if err != nil {
+		return err
+	}
 	udoc := bson.D{{"$inc", bson.D{
 		{"dead." + p.fieldKey, p.fieldBit},
 		{"alive." + p.fieldKey, p.fieldBit},
 	}}}
-	_, err := p.pings.UpsertId(slot, udoc)
+	_, err = p.pings.UpsertId(slot, udoc)
 	return err
 }
 
@@ -579,7 +674,7 @@ func (p *Pinger) prepare() error {
 	p.beingSeq = seq.Seq
 	p.fieldKey = fmt.Sprintf("%x", p.beingSeq/63)
 	p.fieldBit = 1 << uint64(p.beingSeq%63)
-	p.lastSlot = 0
+	p.lastSlot = math.MinInt64
 	beings := beingsC(p.base)
 	return beings.Insert(beingInfo{p.beingSeq, p.beingKey})
 }
@@ -588,129 +683,133 @@ func (p *Pinger) prepare() error {
 // sequence in use by the pinger.
 func (p *Pinger) ping() error {
 	debugf("state/presence: pinging %q with seq=%d", p.beingKey, p.beingSeq)
-	if p.delta == 0 {
-		delta, err := clockDelta(p.base)
-		if err != nil {
-			return err
-		}
-		p.delta = delta
+	slot, err := timeSlot(p.base)
+	if err != nil {
+		return err
 	}
-	slot := timeSlot(time.Now(), p.delta)
-	if slot == p.lastSlot {
+	if slot <= p.lastSlot {
 		// Never, ever, ping the same slot twice.
 		// The increment below would corrupt the slot.
-		return nil
+		// The slot may however go back if a replica set
+		// primary change lost its last increment, and the
+		// ping recorded in it may have been lost with it,
+		// so the slot is pinged again if it does not hold
+		// the ping.
+		pinged, err := p.pinged(slot)
+		if err != nil || pinged {
+			return err
+		}
+	} else {
+		p.lastSlot = slot
 	}
-	p.lastSlot = slot
 	if _, err := p.pings.UpsertId(slot, bson.D{{"$inc", bson.D{{"alive." + p.fieldKey, p.fieldBit}}}}); err != nil {
 		return err
 	}
+	pingsTotal.Inc()
 	return nil
 }
 
-// clockDelta returns the approximate skew between
-// the local clock and the database clock.
-func clockDelta(c *mgo.Collection) (time.Duration, error) {
-	var server struct {
-		time.Time "retval"
-	}
-	var isMaster struct {
-		LocalTime time.Time "localTime"
-	}
-	var after time.Time
-	var before time.Time
-	var serverDelay time.Duration
-	supportsMasterLocalTime := true
-	for i := 0; i < 10; i++ {
-		if supportsMasterLocalTime {
-			// Try isMaster.localTime, which is present since MongoDB 2.2
-			// and does not require admin privileges.
-			before = time.Now()
-			err := c.Database.Run("isMaster", &isMaster)
-			after = time.Now()
-			if err != nil {
-				return 0, err
-			}
-			if isMaster.LocalTime.IsZero() {
-				supportsMasterLocalTime = false
-				continue
-			} else {
-				serverDelay = isMaster.LocalTime.Sub(before)
-			}
-		} else {
-			// If MongoDB doesn't have localTime as part of
-			// isMaster result, it means that the server is likely
-			// a MongoDB older than 2.2.
-			//
-			// Fallback to 'eval' works fine on versions older than
-			// 2.4 where it does not require admin privileges.
-			//
-			// NOTE: 'eval' takes a global write lock unless you
-			// specify 'nolock' (which we are not doing below, for
-			// no apparent reason), so it is quite likely that the
-			// eval could take a relatively long time to acquire
-			// the lock and thus cause a retry on the callDelay
-			// check below on a busy server.
-			before = time.Now()
-			err := c.Database.Run(bson.D{{"$eval", "function() { return new Date(); }"}}, &server)
-			after = time.Now()
-			if err != nil {
-				return 0, err
-			}
-			serverDelay = server.Sub(before)
-		}
-		// If the call to the server takes longer than a few seconds we
-		// retry it a couple more times before giving up. It is unclear
-		// why the retry would help at all here.
-		//
-		// If the server takes longer than the specified amount of time
-		// on every single try, then we simply give up.
-		callDelay := after.Sub(before)
-		if callDelay > 5*time.Second {
-			continue
-		}
-		return serverDelay, nil
+// pinged reports whether the given time slot
+// holds the sequence in use by the pinger.
+func (p *Pinger) pinged(slot int64) (bool, error) {
+	var ping pingInfo
+	err := p.pings.FindId(slot).One(&ping)
+	if err == mgo.ErrNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return uint64(ping.Alive[p.fieldKey])&p.fieldBit != 0, nil
+}
+
+// slotClock advances the time slot counter on behalf of a watcher.
+type slotClock struct {
+	seqs *mgo.Collection
+
+	// observed holds the counter value seen when
+	// the clock was last observed or advanced.
+	observed int64
+}
+
+func newSlotClock(base *mgo.Collection) *slotClock {
+	return &slotClock{seqs: seqsC(base)}
+}
+
+// observe records the current value of the counter.
+func (c *slotClock) observe() error {
+	slot, err := readSlot(c.seqs)
+	if err != nil {
+		return err
 	}
-	return 0, fmt.Errorf("cannot synchronize clock with database server")
+	c.observed = slot
+	return nil
+}
+
+// advance increments the counter if it still holds the value last
+// observed, and then observes it again. If another watcher has moved
+// the counter in the meantime, or it went back because a replica set
+// primary change lost the last increment, it is left alone and
+// advanced on a later call instead.
+func (c *slotClock) advance() error {
+	_, err := c.seqs.Upsert(
+		bson.D{{"_id", "slot"}, {"slot", c.observed}},
+		bson.D{{"$inc", bson.D{{"slot", int64(1)}}}},
+	)
+	if err != nil && !mgo.IsDup(err) {
+		return err
+	}
+	return c.observe()
+}
+
+// readSlot returns the value of the time slot counter.
+func readSlot(seqs *mgo.Collection) (int64, error) {
+	var doc struct{ Slot int64 }
+	err := seqs.FindId("slot").One(&doc)
+	if err == mgo.ErrNotFound {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return doc.Slot, nil
 }
 
-// timeSlot returns the current time slot, in seconds since the
-// epoch, for the provided now time. The delta skew is applied
-// to the now time to improve the synchronization with a
-// centrally agreed time.
+// timeSlot returns the current time slot, as held by the counter
+// in the helper collection of base.
 //
 // The result of this method may be manipulated for test purposes
 // by fakeTimeSlot and realTimeSlot.
-func timeSlot(now time.Time, delta time.Duration) int64 {
+func timeSlot(base *mgo.Collection) (int64, error) {
 	fakeMutex.Lock()
-	fake := !fakeNow.IsZero()
-	if fake {
-		now = fakeNow
-	}
-	slot := now.Add(delta).Unix()
-	slot -= slot % period
+	fake, offset := fakeSlot, fakeOffset
+	fakeMutex.Unlock()
 	if fake {
-		slot += int64(fakeOffset) * period
+		return int64(offset), nil
 	}
-	fakeMutex.Unlock()
-	return slot
+	return readSlot(seqsC(base))
+}
+
+// legacyTimeSlot returns the wall-clock time slot, as used by the
+// pingers of agents that predate the time slot counter, that holds
+// the given time: the number of seconds since the epoch, rounded
+// down to a multiple of the period.
+func legacyTimeSlot(now time.Time) int64 {
+	slot := now.Unix()
+	return slot - slot%period
 }
 
 var (
-	fakeMutex  sync.Mutex // protects fakeOffset, fakeNow
-	fakeNow    time.Time
+	fakeMutex  sync.Mutex // protects fakeSlot, fakeOffset
+	fakeSlot   bool
 	fakeOffset int
 )
 
-// fakeTimeSlot hardcodes the slot time returned by the timeSlot
+// fakeTimeSlot hardcodes the slot returned by the timeSlot
 // function for testing purposes. The offset parameter is the slot
-// position to return: offsets +1 and -1 are +period and -period
-// seconds from slot 0, respectively.
+// to return: offsets +1 and -1 are the slots after and before
+// slot 0, respectively.
 func fakeTimeSlot(offset int) {
 	fakeMutex.Lock()
-	if fakeNow.IsZero() {
-		fakeNow = time.Now()
-	}
+	fakeSlot = true
 	fakeOffset = offset
 	fakeMutex.Unlock()
 	log.Infof("state/presence: Faking presence to time slot %d", offset)
@@ -719,7 +818,7 @@ func fakeTimeSlot(offset int) {
 // realTimeSlot disables the hardcoding introduced by fakeTimeSlot.
 func realTimeSlot() {
 	fakeMutex.Lock()
-	fakeNow = time.Time{}
+	fakeSlot = false
 	fakeOffset = 0
 	fakeMutex.Unlock()
 	log.Infof("state/presence: Not faking presence time. Real time slot in use.")
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
 	gc "launchpad.net/gocheck"
 	"launchpad.net/tomb"
 
@@ -78,10 +79,10 @@ func assertNoChange(c *gc.C, watch <-chan presence.Change) {
 	}
 }
 
-func assertAlive(c *gc.C, w *presence.Watcher, key string, alive bool) {
-	alive, err := w.Alive("a")
+func assertAlive(c *gc.C, w *presence.Watcher, key string, expected bool) {
+	alive, err := w.Alive(key)
 	c.Assert(err, gc.IsNil)
-	c.Assert(alive, gc.Equals, alive)
+	c.Assert(alive, gc.Equals, expected)
 }
 
 func (s *PresenceSuite) TestErrAndDead(c *gc.C) {
@@ -154,7 +155,7 @@ func (s *PresenceSuite) TestWorkflow(c *gc.C) {
 	w.Sync()
 	pa = presence.NewPinger(s.presence, "a")
 	pa.Start()
-	w.StartSync()
+	w.Sync()
 	assertNoChange(c, cha)
 
 	// We can still query it manually, though.
@@ -256,6 +257,32 @@ func (s *PresenceSuite) TestExpiry(c *gc.C) {
 	assertNoChange(c, ch)
 }
 
+func (s *PresenceSuite) TestGracePeriod(c *gc.C) {
+	w := presence.NewWatcherWithGrace(s.presence, 90*time.Second)
+	p := presence.NewPinger(s.presence, "a")
+	defer w.Stop()
+	defer p.Stop()
+
+	ch := make(chan presence.Change)
+	w.Watch("a", ch)
+	assertChange(c, ch, presence.Change{"a", false})
+
+	c.Assert(p.Start(), gc.IsNil)
+	w.StartSync()
+	assertChange(c, ch, presence.Change{"a", true})
+
+	// Still alive while the ping is within the grace period.
+	for i := 1; i <= 3; i++ {
+		presence.FakeTimeSlot(i)
+		w.StartSync()
+		assertNoChange(c, ch)
+	}
+
+	presence.FakeTimeSlot(4)
+	w.StartSync()
+	assertChange(c, ch, presence.Change{"a", false})
+}
+
 func (s *PresenceSuite) TestWatchPeriod(c *gc.C) {
 	presence.FakePeriod(1)
 	presence.RealTimeSlot()
@@ -481,3 +508,177 @@ func (s *PresenceSuite) TestFindAllBeings(c *gc.C) {
 		c.Fatalf("Sync failed to returned")
 	}
 }
+
+func (s *PresenceSuite) currentSlot(c *gc.C) int64 {
+	var doc struct{ Slot int64 }
+	err := s.presence.Database.C("presence.seqs").FindId("slot").One(&doc)
+	if err == mgo.ErrNotFound {
+		return 0
+	}
+	c.Assert(err, gc.IsNil)
+	return doc.Slot
+}
+
+func (s *PresenceSuite) TestSlotClockAdvancesOncePerPeriod(c *gc.C) {
+	presence.RealTimeSlot()
+
+	// Two state servers whose timers fire at different times
+	// only advance the slot once per period between them.
+	a := presence.NewSlotClock(s.presence)
+	b := presence.NewSlotClock(s.presence)
+	c.Assert(presence.AdvanceSlotClock(a), gc.IsNil)
+	c.Assert(s.currentSlot(c), gc.Equals, int64(1))
+	c.Assert(presence.AdvanceSlotClock(b), gc.IsNil)
+	c.Assert(s.currentSlot(c), gc.Equals, int64(1))
+
+	for i := int64(2); i < 10; i++ {
+		c.Assert(presence.AdvanceSlotClock(b), gc.IsNil)
+		c.Assert(s.currentSlot(c), gc.Equals, i)
+		c.Assert(presence.AdvanceSlotClock(a), gc.IsNil)
+		c.Assert(s.currentSlot(c), gc.Equals, i)
+	}
+
+	// When one of them goes away the other keeps the slot moving.
+	c.Assert(presence.AdvanceSlotClock(a), gc.IsNil)
+	c.Assert(s.currentSlot(c), gc.Equals, int64(10))
+	c.Assert(presence.AdvanceSlotClock(a), gc.IsNil)
+	c.Assert(s.currentSlot(c), gc.Equals, int64(11))
+}
+
+func (s *PresenceSuite) TestClockSkew(c *gc.C) {
+	presence.RealTimeSlot()
+
+	w := presence.NewWatcher(s.presence)
+	p := presence.NewPinger(s.presence, "a")
+	defer w.Stop()
+	defer p.Stop()
+	c.Assert(p.Start(), gc.IsNil)
+
+	// Liveness depends only on the shared slot counter, so a pinger
+	// keeps the key alive however far apart the state servers'
+	// clocks are, as long as it pings once per slot.
+	a := presence.NewSlotClock(s.presence)
+	b := presence.NewSlotClock(s.presence)
+	for i := 0; i < 10; i++ {
+		c.Assert(presence.AdvanceSlotClock(a), gc.IsNil)
+		c.Assert(presence.AdvanceSlotClock(b), gc.IsNil)
+		w.Sync()
+		assertAlive(c, w, "a", true)
+		c.Assert(presence.Ping(p), gc.IsNil)
+	}
+
+	// Without pings, the key is dead once the grace period is over.
+	for i := 0; i < 2; i++ {
+		c.Assert(presence.AdvanceSlotClock(a), gc.IsNil)
+		c.Assert(presence.AdvanceSlotClock(b), gc.IsNil)
+	}
+	w.Sync()
+	assertAlive(c, w, "a", false)
+}
+
+func (s *PresenceSuite) TestPrimaryChange(c *gc.C) {
+	presence.RealTimeSlot()
+
+	p := presence.NewPinger(s.presence, "a")
+	defer p.Stop()
+	c.Assert(p.Start(), gc.IsNil)
+
+	a := presence.NewSlotClock(s.presence)
+	c.Assert(presence.AdvanceSlotClock(a), gc.IsNil)
+	c.Assert(presence.Ping(p), gc.IsNil)
+	c.Assert(s.currentSlot(c), gc.Equals, int64(1))
+
+	// Simulate a primary change that loses the last increment
+	// of the slot, and the ping recorded in that slot.
+	seqs := s.presence.Database.C("presence.seqs")
+	err := seqs.UpdateId("slot", bson.D{{"$set", bson.D{{"slot", int64(0)}}}})
+	c.Assert(err, gc.IsNil)
+	err = s.pings.RemoveId(int64(1))
+	c.Assert(err, gc.IsNil)
+
+	// A watcher on the new primary still sees the key alive.
+	w := presence.NewWatcher(s.presence)
+	defer w.Stop()
+	w.Sync()
+	assertAlive(c, w, "a", true)
+
+	// The clock that had seen the lost increment recovers,
+	// and the slot moves on again.
+	c.Assert(presence.AdvanceSlotClock(a), gc.IsNil)
+	c.Assert(s.currentSlot(c), gc.Equals, int64(0))
+	c.Assert(presence.AdvanceSlotClock(a), gc.IsNil)
+	c.Assert(s.currentSlot(c), gc.Equals, int64(1))
+	c.Assert(presence.Ping(p), gc.IsNil)
+	c.Assert(presence.AdvanceSlotClock(a), gc.IsNil)
+	c.Assert(presence.Ping(p), gc.IsNil)
+	w.Sync()
+	assertAlive(c, w, "a", true)
+}
+
+func (s *PresenceSuite) TestPrimaryChangeSyncBeforePing(c *gc.C) {
+	presence.RealTimeSlot()
+
+	p := presence.NewPinger(s.presence, "a")
+	defer p.Stop()
+	c.Assert(p.Start(), gc.IsNil)
+
+	a := presence.NewSlotClock(s.presence)
+	c.Assert(presence.AdvanceSlotClock(a), gc.IsNil)
+	c.Assert(presence.Ping(p), gc.IsNil)
+
+	// Lose the last increment of the slot and the ping in it.
+	seqs := s.presence.Database.C("presence.seqs")
+	err := seqs.UpdateId("slot", bson.D{{"$set", bson.D{{"slot", int64(0)}}}})
+	c.Assert(err, gc.IsNil)
+	err = s.pings.RemoveId(int64(1))
+	c.Assert(err, gc.IsNil)
+
+	w := presence.NewWatcher(s.presence)
+	defer w.Stop()
+
+	// When the slot reaches the lost one again, the pinger
+	// pings it again even though it had pinged it before.
+	c.Assert(presence.AdvanceSlotClock(a), gc.IsNil)
+	c.Assert(presence.AdvanceSlotClock(a), gc.IsNil)
+	c.Assert(s.currentSlot(c), gc.Equals, int64(1))
+	c.Assert(presence.Ping(p), gc.IsNil)
+
+	// The key is still alive when the watcher syncs after
+	// the slot moves on, before the pinger pings the new slot.
+	c.Assert(presence.AdvanceSlotClock(a), gc.IsNil)
+	c.Assert(s.currentSlot(c), gc.Equals, int64(2))
+	w.Sync()
+	assertAlive(c, w, "a", true)
+
+	// Pinging a slot that holds the ping again leaves it intact.
+	c.Assert(presence.Ping(p), gc.IsNil)
+	c.Assert(presence.Ping(p), gc.IsNil)
+	var ping struct{ Alive map[string]int64 }
+	err = s.pings.FindId(int64(2)).One(&ping)
+	c.Assert(err, gc.IsNil)
+	c.Assert(ping.Alive, gc.HasLen, 1)
+	for _, bits := range ping.Alive {
+		c.Assert(bits&(bits-1), gc.Equals, int64(0))
+	}
+}
+
+func (s *PresenceSuite) TestLegacyPings(c *gc.C) {
+	presence.RealTimeSlot()
+
+	// A pinger of an agent that predates the time slot counter
+	// keeps its key alive by pinging wall-clock time slots.
+	p := presence.NewPinger(s.presence, "a")
+	c.Assert(presence.LegacyPing(p, time.Now()), gc.IsNil)
+	w := presence.NewWatcher(s.presence)
+	defer w.Stop()
+	w.Sync()
+	assertAlive(c, w, "a", true)
+	assertAlive(c, w, "b", false)
+
+	// Pings older than the grace period are ignored.
+	q := presence.NewPinger(s.presence, "b")
+	c.Assert(presence.LegacyPing(q, time.Now().Add(-10*time.Minute)), gc.IsNil)
+	w.Sync()
+	assertAlive(c, w, "a", true)
+	assertAlive(c, w, "b", false)
+}
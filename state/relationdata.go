@@ -0,0 +1,77 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"launchpad.net/juju-core/charm"
+)
+
+// RelationUnitData describes the settings and scope of a single unit
+// within a relation.
+type RelationUnitData struct {
+	UnitName string
+
+	// Role holds the role of the unit's endpoint in the relation.
+	Role charm.RelationRole
+
+	// InScope holds whether the unit is currently in the relation's
+	// scope. A unit's settings remain after it leaves scope, for as
+	// long as the relation exists.
+	InScope bool
+
+	// Settings holds the unit's settings within the relation.
+	Settings map[string]interface{}
+
+	// Version holds the revision of the unit's settings; it changes
+	// whenever the settings are written.
+	Version int64
+}
+
+// UnitData returns the settings and scope of every unit that has
+// joined the relation, ordered by unit name.
+func (r *Relation) UnitData() ([]RelationUnitData, error) {
+	prefix := fmt.Sprintf("^r#%d#", r.doc.Id)
+	var scopeDocs []relationScopeDoc
+	err := r.st.relationScopes.Find(D{{"_id", D{{"$regex", prefix}}}}).All(&scopeDocs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read scope of relation %q: %v", r, err)
+	}
+	inScope := make(map[string]bool)
+	for _, doc := range scopeDocs {
+		inScope[doc.unitName()] = true
+	}
+	var settingsDocs []map[string]interface{}
+	err = r.st.settings.Find(D{{"_id", D{{"$regex", prefix}}}}).All(&settingsDocs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read settings of relation %q: %v", r, err)
+	}
+	data := make([]RelationUnitData, 0, len(settingsDocs))
+	for _, doc := range settingsDocs {
+		// The key is the relation unit's scope, followed by
+		// the role and name of the unit.
+		parts := strings.Split(doc["_id"].(string), "#")
+		unitName := parts[len(parts)-1]
+		version, _ := doc["txn-revno"].(int64)
+		cleanSettingsMap(doc)
+		data = append(data, RelationUnitData{
+			UnitName: unitName,
+			Role:     charm.RelationRole(parts[len(parts)-2]),
+			InScope:  inScope[unitName],
+			Settings: doc,
+			Version:  version,
+		})
+	}
+	sort.Sort(relationUnitDataByName(data))
+	return data, nil
+}
+
+type relationUnitDataByName []RelationUnitData
+
+func (s relationUnitDataByName) Len() int           { return len(s) }
+func (s relationUnitDataByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s relationUnitDataByName) Less(i, j int) bool { return s[i].UnitName < s[j].UnitName }
@@ -0,0 +1,77 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/state"
+)
+
+type RelationDataSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&RelationDataSuite{})
+
+func (s *RelationDataSuite) TestUnitDataEmpty(c *gc.C) {
+	prr := NewProReqRelation(c, &s.ConnSuite, charm.ScopeGlobal)
+	c.Assert(prr.rel.Endpoints(), gc.HasLen, 2)
+	data, err := prr.rel.UnitData()
+	c.Assert(err, gc.IsNil)
+	c.Assert(data, gc.HasLen, 0)
+}
+
+func (s *RelationDataSuite) TestUnitData(c *gc.C) {
+	prr := NewProReqRelation(c, &s.ConnSuite, charm.ScopeGlobal)
+	err := prr.pru0.EnterScope(map[string]interface{}{"user": "admin"})
+	c.Assert(err, gc.IsNil)
+	err = prr.rru0.EnterScope(map[string]interface{}{"url": "http://example.com"})
+	c.Assert(err, gc.IsNil)
+	err = prr.rru0.LeaveScope()
+	c.Assert(err, gc.IsNil)
+
+	data, err := prr.rel.UnitData()
+	c.Assert(err, gc.IsNil)
+	c.Assert(data, gc.HasLen, 2)
+	c.Assert(data[0].UnitName, gc.Equals, "mysql/0")
+	c.Assert(data[0].Role, gc.Equals, charm.RoleProvider)
+	c.Assert(data[0].InScope, gc.Equals, true)
+	c.Assert(data[0].Settings, gc.DeepEquals, map[string]interface{}{"user": "admin"})
+	c.Assert(data[1].UnitName, gc.Equals, "wordpress/0")
+	c.Assert(data[1].Role, gc.Equals, charm.RoleRequirer)
+	c.Assert(data[1].InScope, gc.Equals, false)
+	c.Assert(data[1].Settings, gc.DeepEquals, map[string]interface{}{"url": "http://example.com"})
+
+	// Writing a unit's settings changes their version.
+	version := data[0].Version
+	node, err := prr.pru0.Settings()
+	c.Assert(err, gc.IsNil)
+	node.Set("password", "secret")
+	_, err = node.Write()
+	c.Assert(err, gc.IsNil)
+	data, err = prr.rel.UnitData()
+	c.Assert(err, gc.IsNil)
+	c.Assert(data[0].Version > version, gc.Equals, true)
+	c.Assert(data[0].Settings, gc.DeepEquals, map[string]interface{}{"user": "admin", "password": "secret"})
+}
+
+func (s *RelationDataSuite) TestUnitDataContainerScope(c *gc.C) {
+	prr := NewProReqRelation(c, &s.ConnSuite, charm.ScopeContainer)
+	err := prr.rru1.EnterScope(map[string]interface{}{"path": "/var/log"})
+	c.Assert(err, gc.IsNil)
+	data, err := prr.rel.UnitData()
+	c.Assert(err, gc.IsNil)
+	var found *state.RelationUnitData
+	for i := range data {
+		if data[i].UnitName == "logging/1" {
+			found = &data[i]
+		}
+	}
+	c.Assert(found, gc.NotNil)
+	c.Assert(found.Role, gc.Equals, charm.RoleRequirer)
+	c.Assert(found.InScope, gc.Equals, true)
+	c.Assert(found.Settings, gc.DeepEquals, map[string]interface{}{"path": "/var/log"})
+}
@@ -102,10 +102,22 @@ func (ru *RelationUnit) EnterScope(settings map[string]interface{}) error {
 	//   exist; or completely overwrite them if they do. This must happen
 	//   before we create the scope doc, because the existence of a scope doc
 	//   is considered to be a guarantee of the existence of a settings doc.
+	//   A unit that replaces a migrated unit starts with that unit's
+	//   settings, updated with those supplied.
 	settingsChanged := func() (bool, error) { return false, nil }
 	if count, err := ru.st.settings.FindId(ruKey).Count(); err != nil {
 		return err
 	} else if count == 0 {
+		migrated, err := ru.migratedSettings()
+		if err != nil {
+			return err
+		}
+		if migrated != nil {
+			for key, value := range settings {
+				migrated[key] = value
+			}
+			settings = migrated
+		}
 		ops = append(ops, createSettingsOp(ru.st, ruKey, settings))
 	} else {
 		var rop txn.Op
@@ -16,7 +16,7 @@ type sequenceDoc struct {
 }
 
 func (s *State) sequence(name string) (int, error) {
-	query := s.db.C("sequence").Find(D{{"_id", name}})
+	query := s.sequences.Find(D{{"_id", name}})
 	inc := mgo.Change{
 		Update: bson.M{"$inc": bson.M{"counter": 1}},
 		Upsert: true,
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"time"
 
 	"labix.org/v2/mgo"
 	"labix.org/v2/mgo/bson"
@@ -42,6 +43,7 @@ type serviceDoc struct {
 	RelationCount int
 	Exposed       bool
 	MinUnits      int
+	HookTimeout   time.Duration
 	OwnerTag      string
 	TxnRevno      int64 `bson:"txn-revno"`
 }
@@ -225,6 +227,8 @@ func (s *Service) removeOps(asserts D) []txn.Op {
 		Remove: true,
 	}}
 	ops = append(ops, removeConstraintsOp(s.st, s.globalKey()))
+	ops = append(ops, removeSettingsHistoryOp(s.st, s.doc.Name))
+	ops = append(ops, removeMetricsOp(s.st, s.doc.Name))
 	return append(ops, annotationRemoveOp(s.st, s.globalKey()))
 }
 
@@ -261,6 +265,33 @@ func (s *Service) setExposed(exposed bool) (err error) {
 	return nil
 }
 
+// HookTimeout returns the maximum time any hook run by the service's
+// units may take, overriding the timeouts in the charm metadata.
+// It returns zero if the charm's timeouts apply.
+func (s *Service) HookTimeout() time.Duration {
+	return s.doc.HookTimeout
+}
+
+// SetHookTimeout sets the maximum time any hook run by the service's
+// units may take. A zero timeout restores the timeouts given in the
+// charm metadata.
+func (s *Service) SetHookTimeout(timeout time.Duration) (err error) {
+	if timeout < 0 {
+		return fmt.Errorf("cannot set hook timeout for service %q: timeout must not be negative", s)
+	}
+	ops := []txn.Op{{
+		C:      s.st.services.Name,
+		Id:     s.doc.Name,
+		Assert: isAliveDoc,
+		Update: D{{"$set", D{{"hooktimeout", timeout}}}},
+	}}
+	if err := s.st.runTransaction(ops); err != nil {
+		return fmt.Errorf("cannot set hook timeout for service %q: %v", s, onAbort(err, errNotAlive))
+	}
+	s.doc.HookTimeout = timeout
+	return nil
+}
+
 // Charm returns the service's charm and whether units should upgrade to that
 // charm even if they are in an error state.
 func (s *Service) Charm() (ch *Charm, force bool, err error) {
@@ -405,6 +436,12 @@ func (s *Service) changeCharmOps(ch *Charm, force bool) ([]txn.Op, error) {
 		return nil, err
 	}
 
+	// Record the new settings in the service's settings history.
+	historyOps, err := s.configRevisionOps("", ch.URL(), newSettings)
+	if err != nil {
+		return nil, err
+	}
+
 	// Build the transaction.
 	differentCharm := D{{"charmurl", D{{"$ne", ch.URL()}}}}
 	ops := []txn.Op{
@@ -438,6 +475,7 @@ func (s *Service) changeCharmOps(ch *Charm, force bool) ([]txn.Op, error) {
 	sameRelCount := D{{"relationcount", len(relations)}}
 
 	ops = append(ops, peerOps...)
+	ops = append(ops, historyOps...)
 	// Update the relation count as well.
 	ops = append(ops, txn.Op{
 		C:      s.st.services.Name,
@@ -546,6 +584,14 @@ func (s *Service) newUnitName() (string, error) {
 // service will be assigned to a given principal. The asserts param can be used
 // to include additional assertions for the service document.
 func (s *Service) addUnitOps(principalName string, asserts D) (string, []txn.Op, error) {
+	return s.addUnitOpsMigratedFrom(principalName, "", "", asserts)
+}
+
+// addUnitOpsMigratedFrom is like addUnitOps, but records that the new
+// unit replaces the named unit, if any, which is being migrated. If
+// machineId is not empty, the new unit is assigned to that machine
+// in the same transaction.
+func (s *Service) addUnitOpsMigratedFrom(principalName, migratedFrom, machineId string, asserts D) (string, []txn.Op, error) {
 	if s.doc.Subordinate && principalName == "" {
 		return "", nil, fmt.Errorf("service is a subordinate")
 	} else if !s.doc.Subordinate && principalName != "" {
@@ -557,11 +603,13 @@ func (s *Service) addUnitOps(principalName string, asserts D) (string, []txn.Op,
 	}
 	globalKey := unitGlobalKey(name)
 	udoc := &unitDoc{
-		Name:      name,
-		Service:   s.doc.Name,
-		Series:    s.doc.Series,
-		Life:      Alive,
-		Principal: principalName,
+		Name:         name,
+		Service:      s.doc.Name,
+		Series:       s.doc.Series,
+		Life:         Alive,
+		Principal:    principalName,
+		MachineId:    machineId,
+		MigratedFrom: migratedFrom,
 	}
 	sdoc := statusDoc{
 		Status: params.StatusPending,
@@ -601,6 +649,14 @@ func (s *Service) addUnitOps(principalName string, asserts D) (string, []txn.Op,
 		cons := scons.WithFallbacks(econs)
 		ops = append(ops, createConstraintsOp(s.st, globalKey, cons))
 	}
+	if machineId != "" {
+		ops = append(ops, txn.Op{
+			C:      s.st.machines.Name,
+			Id:     machineId,
+			Assert: append(isAliveDoc, notInMaintenanceDoc...),
+			Update: D{{"$addToSet", D{{"principals", name}}}, {"$set", D{{"clean", false}}}},
+		})
+	}
 	return name, ops, nil
 }
 
@@ -674,7 +730,25 @@ func (s *Service) removeUnitOps(u *Unit, asserts D) ([]txn.Op, error) {
 		removeConstraintsOp(s.st, u.globalKey()),
 		removeStatusOp(s.st, u.globalKey()),
 		annotationRemoveOp(s.st, u.globalKey()),
+		removeHookStatsOp(s.st, u.doc.Name),
 	)
+	if u.doc.MigratedFrom != "" {
+		// The migration is abandoned if its target is removed.
+		ops = append(ops, abandonMigrationOps(s.st, u.doc.MigratedFrom)...)
+	}
+	if u.doc.MigratingTo != "" {
+		// Once the migrated unit is removed, its
+		// replacement no longer has anything to take over.
+		ops = append(ops, txn.Op{
+			C:      s.st.units.Name,
+			Id:     u.doc.MigratingTo,
+			Update: D{{"$unset", D{{"migratedfrom", ""}, {"migrationready", ""}}}},
+		}, txn.Op{
+			C:      s.st.settings.Name,
+			Id:     migrationSettingsKey(u.doc.Name),
+			Remove: true,
+		})
+	}
 	if u.doc.CharmURL != nil {
 		decOps, err := settingsDecRefOps(s.st, s.doc.Name, u.doc.CharmURL)
 		if errors.IsNotFoundError(err) {
@@ -763,7 +837,15 @@ func (s *Service) ConfigSettings() (charm.Settings, error) {
 
 // UpdateConfigSettings changes a service's charm config settings. Values set
 // to nil will be deleted; unknown and invalid values will return an error.
+// The change is recorded in the service's settings history without an
+// author; see UpdateConfigSettingsBy.
 func (s *Service) UpdateConfigSettings(changes charm.Settings) error {
+	return s.UpdateConfigSettingsBy("", changes)
+}
+
+// UpdateConfigSettingsBy is like UpdateConfigSettings, but records
+// the entity with the given tag as the author of the change.
+func (s *Service) UpdateConfigSettingsBy(author string, changes charm.Settings) error {
 	charm, _, err := s.Charm()
 	if err != nil {
 		return err
@@ -772,23 +854,45 @@ func (s *Service) UpdateConfigSettings(changes charm.Settings) error {
 	if err != nil {
 		return err
 	}
-	// TODO(fwereade) state.Settings is itself really problematic in just
-	// about every use case. This needs to be resolved some time; but at
-	// least the settings docs are keyed by charm url as well as service
-	// name, so the actual impact of a race is non-threatening.
-	node, err := readSettings(s.st, s.settingsKey())
-	if err != nil {
-		return err
-	}
-	for name, value := range changes {
-		if value == nil {
-			node.Delete(name)
-		} else {
-			node.Set(name, value)
+	for i := 0; i < 5; i++ {
+		// TODO(fwereade) state.Settings is itself really problematic in just
+		// about every use case. This needs to be resolved some time; but at
+		// least the settings docs are keyed by charm url as well as service
+		// name, so the actual impact of a race is non-threatening.
+		node, err := readSettings(s.st, s.settingsKey())
+		if err != nil {
+			return err
+		}
+		for name, value := range changes {
+			if value == nil {
+				node.Delete(name)
+			} else {
+				node.Set(name, value)
+			}
+		}
+		written, ops := node.writeOps()
+		if len(written) == 0 {
+			return nil
+		}
+		// The settings are written together with the revision that
+		// records them, so that the history never misses a change.
+		historyOps, err := s.configRevisionOps(author, s.doc.CharmURL, node.Map())
+		if err != nil {
+			return err
+		}
+		ops = append(ops, historyOps...)
+		if err := s.st.runTransaction(ops); err != txn.ErrAborted {
+			return err
+		}
+		// Either the settings have been removed, or
+		// another change was recorded first; retry.
+		if count, err := s.st.settings.FindId(s.settingsKey()).Count(); err != nil {
+			return err
+		} else if count == 0 {
+			return errors.NotFoundf("settings")
 		}
 	}
-	_, err = node.Write()
-	return err
+	return ErrExcessiveContention
 }
 
 var ErrSubordinateConstraints = stderrors.New("constraints do not apply to subordinate services")
@@ -6,6 +6,7 @@ package state_test
 import (
 	"fmt"
 	"sort"
+	"time"
 
 	"labix.org/v2/mgo"
 	gc "launchpad.net/gocheck"
@@ -759,6 +760,28 @@ func (s *ServiceSuite) TestServiceExposed(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, notAliveErr)
 }
 
+func (s *ServiceSuite) TestHookTimeout(c *gc.C) {
+	c.Assert(s.mysql.HookTimeout(), gc.Equals, time.Duration(0))
+	err := s.mysql.SetHookTimeout(5 * time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.mysql.HookTimeout(), gc.Equals, 5*time.Minute)
+	err = s.mysql.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.mysql.HookTimeout(), gc.Equals, 5*time.Minute)
+
+	err = s.mysql.SetHookTimeout(-time.Second)
+	c.Assert(err, gc.ErrorMatches, `cannot set hook timeout for service "mysql": timeout must not be negative`)
+
+	err = s.mysql.SetHookTimeout(0)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.mysql.HookTimeout(), gc.Equals, time.Duration(0))
+
+	err = s.mysql.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = s.mysql.SetHookTimeout(time.Minute)
+	c.Assert(err, gc.ErrorMatches, notAliveErr)
+}
+
 func (s *ServiceSuite) TestAddUnit(c *gc.C) {
 	// Check that principal units can be added on their own.
 	unitZero, err := s.mysql.AddUnit()
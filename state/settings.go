@@ -133,6 +133,25 @@ func cacheKeys(caches ...map[string]interface{}) map[string]bool {
 // as a delta applied on top of the latest version of the node, to prevent
 // overwriting unrelated changes made to the node since it was last read.
 func (c *Settings) Write() ([]ItemChange, error) {
+	changes, ops := c.writeOps()
+	if len(changes) == 0 {
+		return []ItemChange{}, nil
+	}
+	err := c.st.runTransaction(ops)
+	if err == txn.ErrAborted {
+		return nil, errors.NotFoundf("settings")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot write settings: %v", err)
+	}
+	c.disk = copyMap(c.core, nil)
+	return changes, nil
+}
+
+// writeOps returns the changes made to the settings since they were
+// last read or written, and the operations that write them. If there
+// are no changes, no operations are returned.
+func (c *Settings) writeOps() ([]ItemChange, []txn.Op) {
 	changes := []ItemChange{}
 	updates := map[string]interface{}{}
 	deletions := map[string]int{}
@@ -160,7 +179,7 @@ func (c *Settings) Write() ([]ItemChange, error) {
 		changes = append(changes, change)
 	}
 	if len(changes) == 0 {
-		return []ItemChange{}, nil
+		return changes, nil
 	}
 	sort.Sort(itemChangeSlice(changes))
 	ops := []txn.Op{{
@@ -172,15 +191,7 @@ func (c *Settings) Write() ([]ItemChange, error) {
 			{"$unset", deletions},
 		},
 	}}
-	err := c.st.runTransaction(ops)
-	if err == txn.ErrAborted {
-		return nil, errors.NotFoundf("settings")
-	}
-	if err != nil {
-		return nil, fmt.Errorf("cannot write settings: %v", err)
-	}
-	c.disk = copyMap(c.core, nil)
-	return changes, nil
+	return changes, ops
 }
 
 func newSettings(st *State, key string) *Settings {
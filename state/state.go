@@ -25,10 +25,12 @@ import (
 	"launchpad.net/juju-core/environs/config"
 	"launchpad.net/juju-core/errors"
 	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/state/api/params"
 	"launchpad.net/juju-core/state/multiwatcher"
 	"launchpad.net/juju-core/state/presence"
 	"launchpad.net/juju-core/state/watcher"
 	"launchpad.net/juju-core/utils"
+	"launchpad.net/juju-core/utils/metrics"
 	"launchpad.net/juju-core/version"
 )
 
@@ -57,6 +59,7 @@ type State struct {
 	minUnits         *mgo.Collection
 	settings         *mgo.Collection
 	settingsrefs     *mgo.Collection
+	settingsHistory  *mgo.Collection
 	constraints      *mgo.Collection
 	units            *mgo.Collection
 	users            *mgo.Collection
@@ -64,11 +67,22 @@ type State struct {
 	cleanups         *mgo.Collection
 	annotations      *mgo.Collection
 	statuses         *mgo.Collection
+	hookStats        *mgo.Collection
+	metrics          *mgo.Collection
+	sequences        *mgo.Collection
 	stateServers     *mgo.Collection
 	runner           *txn.Runner
 	transactionHooks chan ([]transactionHook)
 	watcher          *watcher.Watcher
 	pwatcher         *presence.Watcher
+	// hostedUUID holds the UUID of the environment if it is hosted
+	// by the state server of another environment, and is empty
+	// for the state server's own environment.
+	hostedUUID string
+	// serverMachines holds the state server environment's machines,
+	// and hostedEnvironments its registry of hosted environments.
+	serverMachines     *mgo.Collection
+	hostedEnvironments *mgo.Collection
 	// mu guards allManager.
 	mu         sync.Mutex
 	allManager *multiwatcher.StoreManager
@@ -81,6 +95,24 @@ type transactionHook struct {
 	After  func()
 }
 
+var transactionsTotal = metrics.NewCounter(
+	"juju_state_transactions_total",
+	"Number of mgo/txn transactions run, by result. Aborted transactions are usually retried.",
+	"result",
+)
+
+// transactionResult returns the result label recorded
+// for a transaction that returned the given error.
+func transactionResult(err error) string {
+	switch err {
+	case nil:
+		return "applied"
+	case txn.ErrAborted:
+		return "aborted"
+	}
+	return "error"
+}
+
 // runTransaction runs the supplied operations as a single mgo/txn transaction,
 // and includes a mechanism whereby tests can use SetTransactionHooks to induce
 // arbitrary state mutations before and after particular transactions.
@@ -108,7 +140,9 @@ func (st *State) runTransaction(ops []txn.Op) error {
 			logger.Infof("transaction 'before' hook end")
 		}
 	}
-	return st.runner.Run(ops, "", nil)
+	err := st.runner.Run(ops, "", nil)
+	transactionsTotal.Inc(transactionResult(err))
+	return err
 }
 
 // Ping probes the state's database connection to ensure
@@ -126,12 +160,24 @@ func (st *State) MongoSession() *mgo.Session {
 }
 
 func (st *State) Watch() *multiwatcher.Watcher {
+	return multiwatcher.NewWatcher(st.storeManager())
+}
+
+// WatchFiltered is like Watch, but the returned watcher only
+// observes changes to entities that match the given filter.
+func (st *State) WatchFiltered(filter params.WatchAllFilter) *multiwatcher.Watcher {
+	return multiwatcher.NewFilteredWatcher(st.storeManager(), filter)
+}
+
+// storeManager returns the StoreManager shared by all
+// the watchers returned by Watch and WatchFiltered.
+func (st *State) storeManager() *multiwatcher.StoreManager {
 	st.mu.Lock()
+	defer st.mu.Unlock()
 	if st.allManager == nil {
 		st.allManager = multiwatcher.NewStoreManager(newAllWatcherStateBacking(st))
 	}
-	st.mu.Unlock()
-	return multiwatcher.NewWatcher(st.allManager)
+	return st.allManager
 }
 
 func (st *State) EnvironConfig() (*config.Config, error) {
@@ -198,10 +244,10 @@ func (st *State) checkCanUpgrade(currentVersion, newVersion string) error {
 		}
 		iter := collection.Find(sel).Select(D{{"_id", 1}}).Iter()
 		for iter.Next(&doc) {
-			switch collection.Name {
-			case "machines":
+			switch collection {
+			case st.machines:
 				agentTags = append(agentTags, names.MachineTag(doc.Id))
-			case "units":
+			case st.units:
 				agentTags = append(agentTags, names.UnitTag(doc.Id))
 			}
 		}
@@ -913,6 +959,9 @@ func (st *State) AddService(name, ownerTag string, ch *Charm) (service *Service,
 		env.assertAliveOp(),
 		createConstraintsOp(st, svc.globalKey(), constraints.Value{}),
 		createSettingsOp(st, svc.settingsKey(), nil),
+		// The initial settings are the first revision in
+		// the service's settings history.
+		createSettingsHistoryOp(st, name, newConfigRevisionDoc(1, ownerTag, ch.URL(), nil)),
 		{
 			C:      st.users.Name,
 			Id:     ownerId,
@@ -1314,6 +1363,9 @@ func (st *State) SetAdminMongoPassword(password string) error {
 }
 
 func (st *State) setMongoPassword(name, password string) error {
+	if st.IsHosted() {
+		return fmt.Errorf("cannot set mongo password for %q: hosted environments have no database users", name)
+	}
 	if err := st.db.AddUser(name, password, false); err != nil {
 		return fmt.Errorf("cannot set password in juju db for %q: %v", name, err)
 	}
@@ -290,6 +290,7 @@ func (context *statusContext) makeMachineStatus(machine *state.Machine) (status
 		status.AgentStateInfo,
 		status.Err = processAgent(machine)
 	status.Series = machine.Series()
+	status.Maintenance = machine.InMaintenance()
 	instid, err := machine.InstanceId()
 	if err == nil {
 		status.InstanceId = instid
@@ -78,6 +78,9 @@ type unitDoc struct {
 	Life           Life
 	TxnRevno       int64 `bson:"txn-revno"`
 	PasswordHash   string
+	MigratingTo    string `bson:",omitempty"`
+	MigratedFrom   string `bson:",omitempty"`
+	MigrationReady bool   `bson:",omitempty"`
 }
 
 // Unit represents the state of a service unit.
@@ -738,6 +741,18 @@ func (u *Unit) WaitAgentAlive(timeout time.Duration) (err error) {
 	panic(fmt.Sprintf("presence reported dead status twice in a row for unit %q", u))
 }
 
+// WatchAgentPresence starts sending changes in the presence of the
+// unit's agent on ch, beginning with its current presence. The
+// changes must be received until UnwatchAgentPresence is called.
+func (u *Unit) WatchAgentPresence(ch chan<- presence.Change) {
+	u.st.pwatcher.Watch(u.globalKey(), ch)
+}
+
+// UnwatchAgentPresence stops sending changes on ch.
+func (u *Unit) UnwatchAgentPresence(ch chan<- presence.Change) {
+	u.st.pwatcher.Unwatch(u.globalKey(), ch)
+}
+
 // SetAgentAlive signals that the agent for unit u is alive.
 // It returns the started pinger.
 func (u *Unit) SetAgentAlive() (*presence.Pinger, error) {
@@ -789,12 +804,15 @@ var (
 	unitNotAliveErr    = stderrors.New("unit is not alive")
 	alreadyAssignedErr = stderrors.New("unit is already assigned to a machine")
 	inUseErr           = stderrors.New("machine is not unused")
+
+	machineInMaintenanceErr = stderrors.New("machine is in maintenance mode")
 )
 
 // assignToMachine is the internal version of AssignToMachine,
 // also used by AssignToUnusedMachine. It returns specific errors
 // in some cases:
 // - machineNotAliveErr when the machine is not alive.
+// - machineInMaintenanceErr when the machine is in maintenance mode.
 // - unitNotAliveErr when the unit is not alive.
 // - alreadyAssignedErr when the unit has already been assigned
 // - inUseErr when the machine already has a unit assigned (if unused is true)
@@ -821,13 +839,16 @@ func (u *Unit) assignToMachine(m *Machine, unused bool) (err error) {
 	if !canHost {
 		return fmt.Errorf("machine %q cannot host units", m)
 	}
+	if m.doc.Maintenance {
+		return machineInMaintenanceErr
+	}
 	assert := append(isAliveDoc, D{
 		{"$or", []D{
 			{{"machineid", ""}},
 			{{"machineid", m.Id()}},
 		}},
 	}...)
-	massert := isAliveDoc
+	massert := append(isAliveDoc, notInMaintenanceDoc...)
 	if unused {
 		massert = append(massert, D{{"clean", D{{"$ne", false}}}}...)
 	}
@@ -864,6 +885,8 @@ func (u *Unit) assignToMachine(m *Machine, unused bool) (err error) {
 		return unitNotAliveErr
 	case m0.Life() != Alive:
 		return machineNotAliveErr
+	case m0.doc.Maintenance:
+		return machineInMaintenanceErr
 	case u0.doc.MachineId != "" || !unused:
 		return alreadyAssignedErr
 	}
@@ -1123,6 +1146,7 @@ func (u *Unit) findCleanMachineQuery(requireEmpty bool, cons *constraints.Value)
 		{"series", u.doc.Series},
 		{"jobs", []MachineJob{JobHostUnits}},
 		{"clean", true},
+		{"maintenance", D{{"$ne", true}}},
 		{"_id", D{{"$nin", machinesWithContainers}}},
 	}
 	// Add the container filter term if necessary.
@@ -1216,7 +1240,7 @@ func (u *Unit) assignToCleanMaybeEmptyMachine(requireEmpty bool) (m *Machine, er
 		if err == nil {
 			return m, nil
 		}
-		if err != inUseErr && err != machineNotAliveErr {
+		if err != inUseErr && err != machineNotAliveErr && err != machineInMaintenanceErr {
 			assignContextf(&err, u, context)
 			return nil, err
 		}
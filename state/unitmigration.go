@@ -0,0 +1,247 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"strings"
+
+	"labix.org/v2/mgo/txn"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/utils"
+)
+
+// MigratingTo returns the name of the unit that is replacing u on
+// another machine, and whether u is being migrated at all.
+func (u *Unit) MigratingTo() (string, bool) {
+	return u.doc.MigratingTo, u.doc.MigratingTo != ""
+}
+
+// MigratedFrom returns the name of the unit that u replaces, and
+// whether u was added to replace a migrated unit at all.
+func (u *Unit) MigratedFrom() (string, bool) {
+	return u.doc.MigratedFrom, u.doc.MigratedFrom != ""
+}
+
+// MigrationReady returns whether the data of the unit being migrated
+// has been made ready for the unit replacing it. It is reported by
+// both units.
+func (u *Unit) MigrationReady() bool {
+	return u.doc.MigrationReady
+}
+
+// migrationSettingsKey returns the key of the settings through which
+// the data of the named unit is passed to the unit replacing it.
+func migrationSettingsKey(from string) string {
+	return unitGlobalKey(from) + "#migration"
+}
+
+// StartMigration starts moving the unit to the given machine, by adding
+// a new unit of the same service, assigned to that machine, to replace
+// it. When the new unit enters the scope of a relation, its settings
+// in the relation start as a copy of those of the unit it replaces.
+// The unit itself is left untouched, so that its data can be moved to
+// the new unit, through the settings returned by MigrationSettings,
+// before FinishMigration destroys it. Removing the new unit abandons
+// the migration.
+func (u *Unit) StartMigration(m *Machine) (_ *Unit, err error) {
+	defer utils.ErrorContextf(&err, "cannot migrate unit %q to machine %s", u, m)
+	if u.doc.Principal != "" {
+		return nil, fmt.Errorf("unit is a subordinate")
+	}
+	if u.doc.MigratingTo != "" {
+		return nil, fmt.Errorf("unit is already migrating to %q", u.doc.MigratingTo)
+	}
+	if u.doc.MachineId == m.Id() {
+		return nil, fmt.Errorf("unit is already assigned to the machine")
+	}
+	if u.doc.Series != m.doc.Series {
+		return nil, fmt.Errorf("series does not match")
+	}
+	canHost := false
+	for _, j := range m.doc.Jobs {
+		if j == JobHostUnits {
+			canHost = true
+			break
+		}
+	}
+	if !canHost {
+		return nil, fmt.Errorf("machine %q cannot host units", m)
+	}
+	if m.doc.Life != Alive {
+		return nil, machineNotAliveErr
+	}
+	if m.doc.Maintenance {
+		return nil, machineInMaintenanceErr
+	}
+	svc, err := u.Service()
+	if err != nil {
+		return nil, err
+	}
+	name, ops, err := svc.addUnitOpsMigratedFrom("", u.doc.Name, m.Id(), nil)
+	if err != nil {
+		return nil, err
+	}
+	ops = append(ops, txn.Op{
+		C:      u.st.units.Name,
+		Id:     u.doc.Name,
+		Assert: append(isAliveDoc, D{{"migratingto", D{{"$exists", false}}}}...),
+		Update: D{{"$set", D{{"migratingto", name}}}},
+	}, createSettingsOp(u.st, migrationSettingsKey(u.doc.Name), nil))
+	if err := u.st.runTransaction(ops); err == txn.ErrAborted {
+		if err := u.Refresh(); errors.IsNotFoundError(err) {
+			return nil, fmt.Errorf("unit has been removed")
+		} else if err != nil {
+			return nil, err
+		} else if u.doc.Life != Alive {
+			return nil, unitNotAliveErr
+		} else if u.doc.MigratingTo != "" {
+			return nil, fmt.Errorf("unit is already migrating to %q", u.doc.MigratingTo)
+		}
+		if alive, err := isAlive(u.st.services, u.doc.Service); err != nil {
+			return nil, err
+		} else if !alive {
+			return nil, fmt.Errorf("service is not alive")
+		}
+		if err := m.Refresh(); errors.IsNotFoundError(err) {
+			return nil, fmt.Errorf("machine has been removed")
+		} else if err != nil {
+			return nil, err
+		} else if m.doc.Life != Alive {
+			return nil, machineNotAliveErr
+		} else if m.doc.Maintenance {
+			return nil, machineInMaintenanceErr
+		}
+		return nil, ErrExcessiveContention
+	} else if err != nil {
+		return nil, err
+	}
+	u.doc.MigratingTo = name
+	m.doc.Clean = false
+	return u.st.Unit(name)
+}
+
+// MigrationSettings returns the settings through which the data of the
+// unit being migrated is passed to the unit replacing it. It may be
+// called on either unit.
+func (u *Unit) MigrationSettings() (*Settings, error) {
+	from := u.doc.Name
+	if u.doc.MigratedFrom != "" {
+		from = u.doc.MigratedFrom
+	} else if u.doc.MigratingTo == "" {
+		return nil, fmt.Errorf("unit %q is not being migrated", u)
+	}
+	return readSettings(u.st, migrationSettingsKey(from))
+}
+
+// SetMigrationReady records that the data of the unit being migrated
+// has been written to its migration settings, so that the unit
+// replacing it can read them.
+func (u *Unit) SetMigrationReady() (err error) {
+	defer utils.ErrorContextf(&err, "cannot set migration of unit %q ready", u)
+	to := u.doc.MigratingTo
+	if to == "" {
+		return fmt.Errorf("unit is not being migrated")
+	}
+	ops := []txn.Op{{
+		C:      u.st.units.Name,
+		Id:     u.doc.Name,
+		Assert: D{{"migratingto", to}},
+		Update: D{{"$set", D{{"migrationready", true}}}},
+	}, {
+		C:      u.st.units.Name,
+		Id:     to,
+		Assert: D{{"migratedfrom", u.doc.Name}},
+		Update: D{{"$set", D{{"migrationready", true}}}},
+	}}
+	if err := u.st.runTransaction(ops); err == txn.ErrAborted {
+		return fmt.Errorf("migration has been abandoned")
+	} else if err != nil {
+		return err
+	}
+	u.doc.MigrationReady = true
+	return nil
+}
+
+// FinishMigration is called on the unit replacing a migrated unit once
+// it has read the migrated unit's data. It destroys the migrated unit
+// and records that the migration is complete. It does nothing if the
+// unit does not replace a migrated unit.
+func (u *Unit) FinishMigration() (err error) {
+	defer utils.ErrorContextf(&err, "cannot finish migration to unit %q", u)
+	from := u.doc.MigratedFrom
+	if from == "" {
+		return nil
+	}
+	if !u.doc.MigrationReady {
+		return fmt.Errorf("migration is not ready")
+	}
+	// The migrated unit is destroyed first, so that the
+	// migration can be finished again if it is interrupted.
+	old, err := u.st.Unit(from)
+	if err == nil {
+		err = old.Destroy()
+	}
+	if err != nil && !errors.IsNotFoundError(err) {
+		return err
+	}
+	ops := []txn.Op{{
+		C:      u.st.units.Name,
+		Id:     u.doc.Name,
+		Assert: D{{"migratedfrom", from}},
+		Update: D{{"$unset", D{{"migratedfrom", ""}, {"migrationready", ""}}}},
+	}}
+	ops = append(ops, abandonMigrationOps(u.st, from)...)
+	if err := u.st.runTransaction(ops); err == txn.ErrAborted {
+		if err := u.Refresh(); err != nil {
+			return err
+		}
+		return nil
+	} else if err != nil {
+		return err
+	}
+	u.doc.MigratedFrom = ""
+	u.doc.MigrationReady = false
+	return nil
+}
+
+// abandonMigrationOps returns the operations that record that the
+// named unit is no longer being migrated, and remove its migration
+// settings.
+func abandonMigrationOps(st *State, from string) []txn.Op {
+	return []txn.Op{{
+		C:      st.units.Name,
+		Id:     from,
+		Update: D{{"$unset", D{{"migratingto", ""}, {"migrationready", ""}}}},
+	}, {
+		C:      st.settings.Name,
+		Id:     migrationSettingsKey(from),
+		Remove: true,
+	}}
+}
+
+// migratedSettings returns the settings in the relation of the unit
+// that ru's unit replaces, or nil if there are none.
+func (ru *RelationUnit) migratedSettings() (map[string]interface{}, error) {
+	from := ru.unit.doc.MigratedFrom
+	if from == "" {
+		return nil, nil
+	}
+	scope := ru.scope
+	if ru.endpoint.Scope == charm.ScopeContainer {
+		// Only principal units are migrated, and
+		// they define their own container scope.
+		scope = strings.TrimSuffix(scope, "#"+ru.unit.doc.Name) + "#" + from
+	}
+	key := strings.Join([]string{scope, string(ru.endpoint.Role), from}, "#")
+	settings, err := readSettings(ru.st, key)
+	if errors.IsNotFoundError(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return settings.Map(), nil
+}
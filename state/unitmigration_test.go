@@ -0,0 +1,247 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+type UnitMigrationSuite struct {
+	ConnSuite
+	wordpress *state.Service
+	unit      *state.Unit
+	machine   *state.Machine
+}
+
+var _ = gc.Suite(&UnitMigrationSuite{})
+
+func (s *UnitMigrationSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.wordpress = s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	var err error
+	s.unit, err = s.wordpress.AddUnit()
+	c.Assert(err, gc.IsNil)
+	m0, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = s.unit.AssignToMachine(m0)
+	c.Assert(err, gc.IsNil)
+	s.machine, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *UnitMigrationSuite) TestStartMigration(c *gc.C) {
+	_, migrating := s.unit.MigratingTo()
+	c.Assert(migrating, gc.Equals, false)
+
+	target, err := s.unit.StartMigration(s.machine)
+	c.Assert(err, gc.IsNil)
+	c.Assert(target.Name(), gc.Equals, "wordpress/1")
+	from, ok := target.MigratedFrom()
+	c.Assert(ok, gc.Equals, true)
+	c.Assert(from, gc.Equals, "wordpress/0")
+	machineId, err := target.AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machineId, gc.Equals, s.machine.Id())
+
+	to, ok := s.unit.MigratingTo()
+	c.Assert(ok, gc.Equals, true)
+	c.Assert(to, gc.Equals, "wordpress/1")
+	err = s.unit.Refresh()
+	c.Assert(err, gc.IsNil)
+	to, ok = s.unit.MigratingTo()
+	c.Assert(ok, gc.Equals, true)
+	c.Assert(to, gc.Equals, "wordpress/1")
+	c.Assert(s.unit.Life(), gc.Equals, state.Alive)
+
+	_, err = s.unit.StartMigration(s.machine)
+	c.Assert(err, gc.ErrorMatches, `cannot migrate unit "wordpress/0" to machine 1: unit is already migrating to "wordpress/1"`)
+}
+
+func (s *UnitMigrationSuite) TestStartMigrationErrors(c *gc.C) {
+	m0, err := s.State.Machine("0")
+	c.Assert(err, gc.IsNil)
+	_, err = s.unit.StartMigration(m0)
+	c.Assert(err, gc.ErrorMatches, `cannot migrate unit "wordpress/0" to machine 0: unit is already assigned to the machine`)
+
+	precise, err := s.State.AddMachine("precise", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	_, err = s.unit.StartMigration(precise)
+	c.Assert(err, gc.ErrorMatches, `cannot migrate unit "wordpress/0" to machine 2: series does not match`)
+
+	err = s.unit.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	_, err = s.unit.StartMigration(s.machine)
+	c.Assert(err, gc.ErrorMatches, `cannot migrate unit "wordpress/0" to machine 1: unit is not alive`)
+}
+
+func (s *UnitMigrationSuite) assertNotMigrating(c *gc.C) {
+	err := s.unit.Refresh()
+	c.Assert(err, gc.IsNil)
+	_, migrating := s.unit.MigratingTo()
+	c.Assert(migrating, gc.Equals, false)
+	units, err := s.wordpress.AllUnits()
+	c.Assert(err, gc.IsNil)
+	c.Assert(units, gc.HasLen, 1)
+}
+
+func (s *UnitMigrationSuite) TestStartMigrationMachineErrors(c *gc.C) {
+	manager, err := s.State.AddMachine("quantal", state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+	_, err = s.unit.StartMigration(manager)
+	c.Assert(err, gc.ErrorMatches, `cannot migrate unit "wordpress/0" to machine 2: machine "2" cannot host units`)
+	s.assertNotMigrating(c)
+
+	err = s.machine.SetMaintenance(true)
+	c.Assert(err, gc.IsNil)
+	_, err = s.unit.StartMigration(s.machine)
+	c.Assert(err, gc.ErrorMatches, `cannot migrate unit "wordpress/0" to machine 1: machine is in maintenance mode`)
+	s.assertNotMigrating(c)
+	err = s.machine.SetMaintenance(false)
+	c.Assert(err, gc.IsNil)
+
+	err = s.machine.Destroy()
+	c.Assert(err, gc.IsNil)
+	_, err = s.unit.StartMigration(s.machine)
+	c.Assert(err, gc.ErrorMatches, `cannot migrate unit "wordpress/0" to machine 1: machine is not alive`)
+	s.assertNotMigrating(c)
+}
+
+func (s *UnitMigrationSuite) TestStartMigrationMachineChanged(c *gc.C) {
+	// The machine is put into maintenance mode after it was read.
+	m, err := s.State.Machine(s.machine.Id())
+	c.Assert(err, gc.IsNil)
+	err = m.SetMaintenance(true)
+	c.Assert(err, gc.IsNil)
+	_, err = s.unit.StartMigration(s.machine)
+	c.Assert(err, gc.ErrorMatches, `cannot migrate unit "wordpress/0" to machine 1: machine is in maintenance mode`)
+	s.assertNotMigrating(c)
+}
+
+func (s *UnitMigrationSuite) TestFinishMigration(c *gc.C) {
+	// The unit has started, so it is not removed as soon as it is destroyed.
+	err := s.unit.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+	target, err := s.unit.StartMigration(s.machine)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.unit.MigrationReady(), gc.Equals, false)
+	err = target.FinishMigration()
+	c.Assert(err, gc.ErrorMatches, `cannot finish migration to unit "wordpress/1": migration is not ready`)
+
+	// The data is passed on through the migration settings.
+	settings, err := s.unit.MigrationSettings()
+	c.Assert(err, gc.IsNil)
+	settings.Set("data", "/srv/data")
+	_, err = settings.Write()
+	c.Assert(err, gc.IsNil)
+	err = s.unit.SetMigrationReady()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.unit.MigrationReady(), gc.Equals, true)
+
+	err = target.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(target.MigrationReady(), gc.Equals, true)
+	settings, err = target.MigrationSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings.Map(), gc.DeepEquals, map[string]interface{}{"data": "/srv/data"})
+
+	err = target.FinishMigration()
+	c.Assert(err, gc.IsNil)
+	_, migrated := target.MigratedFrom()
+	c.Assert(migrated, gc.Equals, false)
+	c.Assert(target.MigrationReady(), gc.Equals, false)
+	_, err = target.MigrationSettings()
+	c.Assert(err, gc.ErrorMatches, `unit "wordpress/1" is not being migrated`)
+
+	// The migrated unit is destroyed.
+	err = s.unit.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.unit.Life(), gc.Equals, state.Dying)
+	_, migrating := s.unit.MigratingTo()
+	c.Assert(migrating, gc.Equals, false)
+	c.Assert(s.unit.MigrationReady(), gc.Equals, false)
+
+	// Finishing again does nothing.
+	err = target.FinishMigration()
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *UnitMigrationSuite) TestSetMigrationReadyAbandoned(c *gc.C) {
+	err := s.unit.SetMigrationReady()
+	c.Assert(err, gc.ErrorMatches, `cannot set migration of unit "wordpress/0" ready: unit is not being migrated`)
+
+	target, err := s.unit.StartMigration(s.machine)
+	c.Assert(err, gc.IsNil)
+	err = target.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = target.Remove()
+	c.Assert(err, gc.IsNil)
+	err = s.unit.SetMigrationReady()
+	c.Assert(err, gc.ErrorMatches, `cannot set migration of unit "wordpress/0" ready: migration has been abandoned`)
+}
+
+func (s *UnitMigrationSuite) TestRemovingTargetAbandonsMigration(c *gc.C) {
+	target, err := s.unit.StartMigration(s.machine)
+	c.Assert(err, gc.IsNil)
+	err = target.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = target.Remove()
+	c.Assert(err, gc.IsNil)
+
+	err = s.unit.Refresh()
+	c.Assert(err, gc.IsNil)
+	_, migrating := s.unit.MigratingTo()
+	c.Assert(migrating, gc.Equals, false)
+
+	// The migration may be started again.
+	_, err = s.unit.StartMigration(s.machine)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *UnitMigrationSuite) TestRelationSettingsPreserved(c *gc.C) {
+	s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	eps, err := s.State.InferEndpoints([]string{"wordpress", "mysql"})
+	c.Assert(err, gc.IsNil)
+	rel, err := s.State.AddRelation(eps...)
+	c.Assert(err, gc.IsNil)
+	ru, err := rel.Unit(s.unit)
+	c.Assert(err, gc.IsNil)
+	err = ru.EnterScope(map[string]interface{}{
+		"private-address": "10.0.0.1",
+		"database":        "wp",
+	})
+	c.Assert(err, gc.IsNil)
+
+	target, err := s.unit.StartMigration(s.machine)
+	c.Assert(err, gc.IsNil)
+	tru, err := rel.Unit(target)
+	c.Assert(err, gc.IsNil)
+	err = tru.EnterScope(map[string]interface{}{"private-address": "10.0.0.2"})
+	c.Assert(err, gc.IsNil)
+
+	settings, err := tru.ReadSettings("wordpress/1")
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, gc.DeepEquals, map[string]interface{}{
+		"private-address": "10.0.0.2",
+		"database":        "wp",
+	})
+}
+
+func (s *UnitMigrationSuite) TestRemovingMigratedUnitEndsMigration(c *gc.C) {
+	target, err := s.unit.StartMigration(s.machine)
+	c.Assert(err, gc.IsNil)
+	err = s.unit.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.unit.Remove()
+	c.Assert(err, gc.IsNil)
+
+	err = target.Refresh()
+	c.Assert(err, gc.IsNil)
+	_, migrated := target.MigratedFrom()
+	c.Assert(migrated, gc.Equals, false)
+	_, err = target.MigrationSettings()
+	c.Assert(err, gc.ErrorMatches, `unit "wordpress/1" is not being migrated`)
+}
@@ -15,6 +15,7 @@ import (
 	"launchpad.net/tomb"
 
 	"launchpad.net/juju-core/log"
+	"launchpad.net/juju-core/utils/metrics"
 )
 
 // Debug specifies whether the package will log debug
@@ -22,6 +23,26 @@ import (
 // TODO(rog) allow debug level setting in the log package.
 var Debug = false
 
+var (
+	watchesGauge = metrics.NewGauge(
+		"juju_state_watcher_watches",
+		"Number of documents and collections being watched for changes.",
+	)
+	syncsTotal = metrics.NewCounter(
+		"juju_state_watcher_syncs_total",
+		"Number of times the transaction log has been read for changes.",
+	)
+	syncDuration = metrics.NewHistogram(
+		"juju_state_watcher_sync_duration_seconds",
+		"Time taken to read the transaction log for changes.",
+		nil,
+	)
+	changesTotal = metrics.NewCounter(
+		"juju_state_watcher_changes_total",
+		"Number of changes sent to watchers.",
+	)
+)
+
 // A Watcher can watch any number of collections and documents for changes.
 type Watcher struct {
 	tomb tomb.Tomb
@@ -210,6 +231,7 @@ var Period time.Duration = 5 * time.Second
 
 // loop implements the main watcher loop.
 func (w *Watcher) loop() error {
+	defer w.forgetWatches()
 	next := time.After(Period)
 	w.needSync = true
 	if err := w.initLastId(); err != nil {
@@ -236,6 +258,16 @@ func (w *Watcher) loop() error {
 	}
 }
 
+// forgetWatches removes the watches that are still registered
+// when the watcher stops from the watches metric.
+func (w *Watcher) forgetWatches() {
+	n := 0
+	for _, infos := range w.watches {
+		n += len(infos)
+	}
+	watchesGauge.Add(float64(-n))
+}
+
 // flush sends all pending events to their respective channels.
 func (w *Watcher) flush() {
 	// refreshEvents are stored newest first.
@@ -249,6 +281,7 @@ func (w *Watcher) flush() {
 				w.handle(req)
 				continue
 			case e.ch <- Change{e.key.c, e.key.id, e.revno}:
+				changesTotal.Inc()
 			}
 			break
 		}
@@ -265,6 +298,7 @@ func (w *Watcher) flush() {
 				w.handle(req)
 				continue
 			case e.ch <- Change{e.key.c, e.key.id, e.revno}:
+				changesTotal.Inc()
 			}
 			break
 		}
@@ -291,6 +325,7 @@ func (w *Watcher) handle(req interface{}) {
 			w.requestEvents = append(w.requestEvents, event{r.info.ch, r.key, revno})
 		}
 		w.watches[r.key] = append(w.watches[r.key], r.info)
+		watchesGauge.Inc()
 	case reqUnwatch:
 		watches := w.watches[r.key]
 		removed := false
@@ -305,6 +340,7 @@ func (w *Watcher) handle(req interface{}) {
 		if !removed {
 			panic(fmt.Errorf("tried to remove missing channel %v for %s", r.ch, r.key))
 		}
+		watchesGauge.Dec()
 		for i := range w.requestEvents {
 			e := &w.requestEvents[i]
 			if r.key.match(e.key) && e.ch == r.ch {
@@ -346,6 +382,10 @@ func (w *Watcher) initLastId() error {
 // queues events to observing channels.
 func (w *Watcher) sync() error {
 	w.needSync = false
+	syncsTotal.Inc()
+	defer func(start time.Time) {
+		syncDuration.Observe(time.Since(start).Seconds())
+	}(time.Now())
 	// Iterate through log events in reverse insertion order (newest first).
 	iter := w.log.Find(nil).Batch(10).Sort("-$natural").Iter()
 	seen := make(map[watchKey]bool)
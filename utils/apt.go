@@ -23,6 +23,11 @@ var (
 	// AptConfFile is the full file path for the proxy settings that are
 	// written by cloud-init and the machine environ worker.
 	AptConfFile = "/etc/apt/apt.conf.d/42-juju-proxy-settings"
+
+	// AptSourcesFile is the full file path for the apt sources list that
+	// is rewritten by the machine environ worker when the apt mirror
+	// changes.
+	AptSourcesFile = "/etc/apt/sources.list"
 )
 
 // Some helpful functions for running apt in a sane way
@@ -116,6 +121,49 @@ func AptProxyContent(proxy osenv.ProxySettings) string {
 	return strings.Join(lines, "\n")
 }
 
+// AptSourcesWithMirror returns the given apt sources list with the
+// primary archive replaced by mirror. The primary archive is the
+// archive of the first entry in the list; entries for other archives,
+// such as the security archive, are left alone.
+func AptSourcesWithMirror(sources, mirror string) string {
+	lines := strings.Split(sources, "\n")
+	primary := ""
+	for i, line := range lines {
+		archive := aptSourceArchive(line)
+		if archive == "" {
+			continue
+		}
+		if primary == "" {
+			primary = archive
+		}
+		if archive == primary {
+			lines[i] = strings.Replace(line, archive, mirror, 1)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// aptSourceArchive returns the archive URI of the given apt sources
+// line, or "" if the line is not a source entry.
+func aptSourceArchive(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || (fields[0] != "deb" && fields[0] != "deb-src") {
+		return ""
+	}
+	fields = fields[1:]
+	// Skip any options, such as [arch=amd64].
+	if strings.HasPrefix(fields[0], "[") {
+		for len(fields) > 0 && !strings.HasSuffix(fields[0], "]") {
+			fields = fields[1:]
+		}
+		if len(fields) < 2 {
+			return ""
+		}
+		fields = fields[1:]
+	}
+	return fields[0]
+}
+
 // IsUbuntu executes lxb_release to see if the host OS is Ubuntu.
 func IsUbuntu() bool {
 	out, err := RunCommand("lsb_release", "-i", "-s")
@@ -5,6 +5,7 @@ package utils_test
 
 import (
 	"fmt"
+	"strings"
 
 	gc "launchpad.net/gocheck"
 
@@ -183,6 +184,33 @@ func (s *AptSuite) patchLsbRelease(c *gc.C, name string) {
 	patchExecutable(s, c.MkDir(), "lsb_release", content)
 }
 
+const aptSources = `# See http://help.ubuntu.com/community/UpgradeNotes
+deb http://archive.ubuntu.com/ubuntu/ precise main restricted
+deb-src http://archive.ubuntu.com/ubuntu/ precise main restricted
+
+deb [arch=amd64] http://archive.ubuntu.com/ubuntu/ precise-updates main
+deb http://security.ubuntu.com/ubuntu precise-security main
+`
+
+func (s *AptSuite) TestAptSourcesWithMirror(c *gc.C) {
+	output := utils.AptSourcesWithMirror(aptSources, "http://mirror.example.com/ubuntu/")
+	c.Assert(output, gc.Equals, `# See http://help.ubuntu.com/community/UpgradeNotes
+deb http://mirror.example.com/ubuntu/ precise main restricted
+deb-src http://mirror.example.com/ubuntu/ precise main restricted
+
+deb [arch=amd64] http://mirror.example.com/ubuntu/ precise-updates main
+deb http://security.ubuntu.com/ubuntu precise-security main
+`)
+	// Changing the mirror again replaces the previous mirror.
+	output = utils.AptSourcesWithMirror(output, "http://other.example.com/ubuntu/")
+	c.Assert(output, gc.Equals, strings.Replace(aptSources, "archive.ubuntu.com", "other.example.com", -1))
+}
+
+func (s *AptSuite) TestAptSourcesWithMirrorNoSources(c *gc.C) {
+	output := utils.AptSourcesWithMirror("# nothing here\n", "http://mirror.example.com/ubuntu/")
+	c.Assert(output, gc.Equals, "# nothing here\n")
+}
+
 func (s *AptSuite) TestIsUbuntu(c *gc.C) {
 	s.patchLsbRelease(c, "Ubuntu")
 	c.Assert(utils.IsUbuntu(), jc.IsTrue)
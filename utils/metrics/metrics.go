@@ -0,0 +1,360 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The metrics package implements counters, gauges and histograms that
+// describe the running process, and writes them in the Prometheus text
+// exposition format.
+//
+// Metrics are usually created once, in a package-level variable, and
+// registered with DefaultRegistry. Creating a metric with a name that
+// is already registered, or using a metric with the wrong number of
+// label values, is a programming error and panics.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of the text written by WriteText.
+const ContentType = "text/plain; version=0.0.4"
+
+var validName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// Registry holds a set of metrics.
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]*metric
+}
+
+// NewRegistry returns a new, empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		metrics: make(map[string]*metric),
+	}
+}
+
+// DefaultRegistry holds the metrics created by the package-level
+// New functions.
+var DefaultRegistry = NewRegistry()
+
+// metricType holds the type of a metric, as written
+// in its TYPE line.
+type metricType string
+
+const (
+	counterType   metricType = "counter"
+	gaugeType     metricType = "gauge"
+	histogramType metricType = "histogram"
+)
+
+// metric holds the parts common to all metric types.
+type metric struct {
+	name       string
+	help       string
+	typ        metricType
+	labelNames []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// series holds the value of a metric for a given set of label values.
+type series struct {
+	labelValues []string
+
+	// value holds the value of a counter or gauge,
+	// and the sum of the observations of a histogram.
+	value float64
+
+	// counts holds the number of observations in each
+	// bucket of a histogram, and count the total number
+	// of observations.
+	counts []uint64
+	count  uint64
+}
+
+func (r *Registry) register(name, help string, typ metricType, labelNames []string, buckets []float64) *metric {
+	if !validName.MatchString(name) {
+		panic(fmt.Errorf("invalid metric name %q", name))
+	}
+	for _, label := range labelNames {
+		if !validName.MatchString(label) || strings.HasPrefix(label, "__") || label == "le" {
+			panic(fmt.Errorf("invalid label name %q for metric %q", label, name))
+		}
+	}
+	m := &metric{
+		name:       name,
+		help:       help,
+		typ:        typ,
+		labelNames: labelNames,
+		buckets:    buckets,
+		series:     make(map[string]*series),
+	}
+	if len(labelNames) == 0 {
+		// Metrics without labels are always reported,
+		// even if they have never been changed.
+		m.get(nil)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.metrics[name]; ok {
+		panic(fmt.Errorf("metric %q already registered", name))
+	}
+	r.metrics[name] = m
+	return m
+}
+
+// get returns the series for the given label values, creating it
+// if necessary. It must be called with m.mu held, except when
+// the metric is being created.
+func (m *metric) get(labelValues []string) *series {
+	if len(labelValues) != len(m.labelNames) {
+		panic(fmt.Errorf("metric %q has %d labels, got %d values", m.name, len(m.labelNames), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := m.series[key]
+	if !ok {
+		s = &series{
+			labelValues: append([]string(nil), labelValues...),
+		}
+		if m.typ == histogramType {
+			s.counts = make([]uint64, len(m.buckets))
+		}
+		m.series[key] = s
+	}
+	return s
+}
+
+func (m *metric) add(v float64, labelValues []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.get(labelValues).value += v
+}
+
+// Counter is a metric whose value only ever increases,
+// such as the number of requests served.
+type Counter struct {
+	m *metric
+}
+
+// NewCounter returns a new counter registered with r. The values for
+// the given label names must be passed when the counter is changed.
+func (r *Registry) NewCounter(name, help string, labelNames ...string) *Counter {
+	return &Counter{r.register(name, help, counterType, labelNames, nil)}
+}
+
+// NewCounter returns a new counter registered with DefaultRegistry.
+func NewCounter(name, help string, labelNames ...string) *Counter {
+	return DefaultRegistry.NewCounter(name, help, labelNames...)
+}
+
+// Inc adds one to the counter with the given label values.
+func (c *Counter) Inc(labelValues ...string) {
+	c.m.add(1, labelValues)
+}
+
+// Add adds v, which must not be negative, to the
+// counter with the given label values.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic(fmt.Errorf("counter %q cannot be decreased", c.m.name))
+	}
+	c.m.add(v, labelValues)
+}
+
+// Gauge is a metric whose value can go up and down,
+// such as the number of open connections.
+type Gauge struct {
+	m *metric
+}
+
+// NewGauge returns a new gauge registered with r. The values for
+// the given label names must be passed when the gauge is changed.
+func (r *Registry) NewGauge(name, help string, labelNames ...string) *Gauge {
+	return &Gauge{r.register(name, help, gaugeType, labelNames, nil)}
+}
+
+// NewGauge returns a new gauge registered with DefaultRegistry.
+func NewGauge(name, help string, labelNames ...string) *Gauge {
+	return DefaultRegistry.NewGauge(name, help, labelNames...)
+}
+
+// Set sets the gauge with the given label values to v.
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.m.mu.Lock()
+	defer g.m.mu.Unlock()
+	g.m.get(labelValues).value = v
+}
+
+// Add adds v, which may be negative, to the gauge
+// with the given label values.
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.m.add(v, labelValues)
+}
+
+// Inc adds one to the gauge with the given label values.
+func (g *Gauge) Inc(labelValues ...string) {
+	g.m.add(1, labelValues)
+}
+
+// Dec subtracts one from the gauge with the given label values.
+func (g *Gauge) Dec(labelValues ...string) {
+	g.m.add(-1, labelValues)
+}
+
+// DefaultBuckets holds the default histogram buckets,
+// suitable for durations measured in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Histogram is a metric that counts observations,
+// such as request durations, in buckets.
+type Histogram struct {
+	m *metric
+}
+
+// NewHistogram returns a new histogram registered with r. Observations
+// are counted in buckets with the given upper bounds, which must
+// be sorted in increasing order; if buckets is nil, DefaultBuckets
+// is used. The values for the given label names must be passed
+// to Observe.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	if !sort.Float64sAreSorted(buckets) {
+		panic(fmt.Errorf("buckets for histogram %q are not sorted", name))
+	}
+	return &Histogram{r.register(name, help, histogramType, labelNames, buckets)}
+}
+
+// NewHistogram returns a new histogram registered with DefaultRegistry.
+func NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	return DefaultRegistry.NewHistogram(name, help, buckets, labelNames...)
+}
+
+// Observe records the observation v in the histogram
+// with the given label values.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.m.mu.Lock()
+	defer h.m.mu.Unlock()
+	s := h.m.get(labelValues)
+	for i, upper := range h.m.buckets {
+		if v <= upper {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.value += v
+}
+
+// WriteText writes all the metrics in r to w in
+// the Prometheus text exposition format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	metrics := make([]*metric, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		metrics = append(metrics, m)
+	}
+	r.mu.Unlock()
+	sort.Sort(byName(metrics))
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.writeText(bw)
+	}
+	return bw.Flush()
+}
+
+type byName []*metric
+
+func (ms byName) Len() int           { return len(ms) }
+func (ms byName) Swap(i, j int)      { ms[i], ms[j] = ms[j], ms[i] }
+func (ms byName) Less(i, j int) bool { return ms[i].name < ms[j].name }
+
+// WriteText writes all the metrics in DefaultRegistry to w.
+func WriteText(w io.Writer) error {
+	return DefaultRegistry.WriteText(w)
+}
+
+func (m *metric) writeText(w *bufio.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n", m.name, escapeHelp(m.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", m.name, m.typ)
+	keys := make([]string, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := m.series[key]
+		if m.typ != histogramType {
+			writeSample(w, m.name, m.labelNames, s.labelValues, "", s.value)
+			continue
+		}
+		for i, upper := range m.buckets {
+			writeSample(w, m.name+"_bucket", m.labelNames, s.labelValues, formatFloat(upper), float64(s.counts[i]))
+		}
+		writeSample(w, m.name+"_bucket", m.labelNames, s.labelValues, "+Inf", float64(s.count))
+		writeSample(w, m.name+"_sum", m.labelNames, s.labelValues, "", s.value)
+		writeSample(w, m.name+"_count", m.labelNames, s.labelValues, "", float64(s.count))
+	}
+}
+
+// writeSample writes a single sample line. If le is not
+// empty, it is added as the value of the "le" label.
+func writeSample(w *bufio.Writer, name string, labelNames, labelValues []string, le string, v float64) {
+	w.WriteString(name)
+	if len(labelNames) > 0 || le != "" {
+		w.WriteByte('{')
+		for i, label := range labelNames {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", label, escapeLabelValue(labelValues[i]))
+		}
+		if le != "" {
+			if len(labelNames) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "le=\"%s\"", le)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
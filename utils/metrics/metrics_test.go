@@ -0,0 +1,149 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package metrics_test
+
+import (
+	"bytes"
+	"math"
+	"sync"
+	stdtesting "testing"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/testing/testbase"
+	"launchpad.net/juju-core/utils/metrics"
+)
+
+func Test(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type metricsSuite struct {
+	testbase.LoggingSuite
+}
+
+var _ = gc.Suite(&metricsSuite{})
+
+func (*metricsSuite) TestPackageDependencies(c *gc.C) {
+	// This test is to ensure we don't bring in dependencies without thinking.
+	c.Assert(testbase.FindJujuCoreImports(c, "launchpad.net/juju-core/utils/metrics"),
+		gc.HasLen, 0)
+}
+
+func writeText(c *gc.C, r *metrics.Registry) string {
+	var buf bytes.Buffer
+	err := r.WriteText(&buf)
+	c.Assert(err, gc.IsNil)
+	return buf.String()
+}
+
+func (*metricsSuite) TestCounter(c *gc.C) {
+	r := metrics.NewRegistry()
+	requests := r.NewCounter("requests_total", "Number of requests.", "method", "result")
+	plain := r.NewCounter("events_total", "Number of events.")
+	requests.Inc("GET", "ok")
+	requests.Inc("GET", "ok")
+	requests.Add(3, "POST", "error")
+	c.Assert(writeText(c, r), gc.Equals, `
+# HELP events_total Number of events.
+# TYPE events_total counter
+events_total 0
+# HELP requests_total Number of requests.
+# TYPE requests_total counter
+requests_total{method="GET",result="ok"} 2
+requests_total{method="POST",result="error"} 3
+`[1:])
+
+	plain.Inc()
+	c.Assert(writeText(c, r), gc.Matches, "(?s).*\nevents_total 1\n.*")
+	c.Assert(func() { plain.Add(-1) }, gc.PanicMatches, `counter "events_total" cannot be decreased`)
+}
+
+func (*metricsSuite) TestGauge(c *gc.C) {
+	r := metrics.NewRegistry()
+	conns := r.NewGauge("connections", "Open connections.", "kind")
+	conns.Inc("machine")
+	conns.Inc("machine")
+	conns.Dec("machine")
+	conns.Add(5, "unit")
+	conns.Set(0.5, "user")
+	c.Assert(writeText(c, r), gc.Equals, `
+# HELP connections Open connections.
+# TYPE connections gauge
+connections{kind="machine"} 1
+connections{kind="unit"} 5
+connections{kind="user"} 0.5
+`[1:])
+}
+
+func (*metricsSuite) TestHistogram(c *gc.C) {
+	r := metrics.NewRegistry()
+	latency := r.NewHistogram("latency_seconds", "Request latency.", []float64{0.1, 1}, "method")
+	latency.Observe(0.05, "Get")
+	latency.Observe(0.5, "Get")
+	latency.Observe(2, "Get")
+	c.Assert(writeText(c, r), gc.Equals, `
+# HELP latency_seconds Request latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{method="Get",le="0.1"} 1
+latency_seconds_bucket{method="Get",le="1"} 2
+latency_seconds_bucket{method="Get",le="+Inf"} 3
+latency_seconds_sum{method="Get"} 2.55
+latency_seconds_count{method="Get"} 3
+`[1:])
+}
+
+func (*metricsSuite) TestHistogramWithoutLabels(c *gc.C) {
+	r := metrics.NewRegistry()
+	h := r.NewHistogram("duration_seconds", "Duration.", []float64{1})
+	h.Observe(math.Inf(1))
+	c.Assert(writeText(c, r), gc.Equals, `
+# HELP duration_seconds Duration.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{le="1"} 0
+duration_seconds_bucket{le="+Inf"} 1
+duration_seconds_sum +Inf
+duration_seconds_count 1
+`[1:])
+}
+
+func (*metricsSuite) TestEscaping(c *gc.C) {
+	r := metrics.NewRegistry()
+	g := r.NewGauge("escaped", "Help with \\ and\nnewline.", "label")
+	g.Set(1, "quote \" backslash \\ newline \n")
+	c.Assert(writeText(c, r), gc.Equals, `
+# HELP escaped Help with \\ and\nnewline.
+# TYPE escaped gauge
+escaped{label="quote \" backslash \\ newline \n"} 1
+`[1:])
+}
+
+func (*metricsSuite) TestRegistrationErrors(c *gc.C) {
+	r := metrics.NewRegistry()
+	r.NewCounter("foo", "Foo.")
+	c.Assert(func() { r.NewGauge("foo", "Foo again.") }, gc.PanicMatches, `metric "foo" already registered`)
+	c.Assert(func() { r.NewGauge("foo-bar", "Foo.") }, gc.PanicMatches, `invalid metric name "foo-bar"`)
+	c.Assert(func() { r.NewGauge("bar", "Bar.", "le") }, gc.PanicMatches, `invalid label name "le" for metric "bar"`)
+	c.Assert(func() { r.NewHistogram("baz", "Baz.", []float64{2, 1}) }, gc.PanicMatches, `buckets for histogram "baz" are not sorted`)
+
+	g := r.NewGauge("qux", "Qux.", "a", "b")
+	c.Assert(func() { g.Inc("x") }, gc.PanicMatches, `metric "qux" has 2 labels, got 1 values`)
+}
+
+func (*metricsSuite) TestConcurrentUpdates(c *gc.C) {
+	r := metrics.NewRegistry()
+	counter := r.NewCounter("count", "Count.", "n")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				counter.Inc("x")
+			}
+		}()
+	}
+	wg.Wait()
+	c.Assert(writeText(c, r), gc.Matches, `(?s).*count{n="x"} 1000\n`)
+}
@@ -43,11 +43,11 @@ type AuthorisedKey struct {
 // authorized_keys file and returns the constituent parts.
 // Based on description in "man sshd".
 func ParseAuthorisedKey(line string) (*AuthorisedKey, error) {
-	key, comment, _, _, ok := ssh.ParseAuthorizedKey([]byte(line))
-	if !ok {
+	key, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+	if err != nil {
 		return nil, fmt.Errorf("invalid authorized_key %q", line)
 	}
-	keyBytes := ssh.MarshalPublicKey(key)
+	keyBytes := key.Marshal()
 	return &AuthorisedKey{
 		Key:     keyBytes,
 		Comment: comment,
@@ -9,4 +9,12 @@ var (
 	InitDefaultClient   = initDefaultClient
 	DefaultIdentities   = &defaultIdentities
 	SSHDial             = &sshDial
+	SCPSend             = scpSend
+	SCPReceive          = scpReceive
+	SplitSCPTarget      = splitSCPTarget
+	ParseSCPArgs        = parseSCPArgs
 )
+
+func NewSCPOptions(recursive, preserve bool) scpOptions {
+	return scpOptions{recursive: recursive, preserve: preserve}
+}
@@ -13,6 +13,7 @@ import (
 	jc "launchpad.net/juju-core/testing/checkers"
 	"launchpad.net/juju-core/testing/testbase"
 	"launchpad.net/juju-core/utils/ssh"
+	sshtesting "launchpad.net/juju-core/utils/ssh/testing"
 )
 
 type ExecuteSSHCommandSuite struct {
@@ -33,6 +34,8 @@ func (s *ExecuteSSHCommandSuite) SetUpTest(c *gc.C) {
 func (s *ExecuteSSHCommandSuite) fakeSSH(c *gc.C, cmd string) {
 	err := ioutil.WriteFile(s.fakessh, []byte(cmd), 0755)
 	c.Assert(err, gc.IsNil)
+	restore := sshtesting.PatchOpenSSHClient(c)
+	s.AddCleanup(func(*gc.C) { restore() })
 }
 
 func (s *ExecuteSSHCommandSuite) TestCaptureOutput(c *gc.C) {
@@ -0,0 +1,416 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package ssh
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scpOptions holds the scp flags understood by the
+// embedded scp implementation.
+type scpOptions struct {
+	// recursive corresponds to scp -r.
+	recursive bool
+	// preserve corresponds to scp -p.
+	preserve bool
+}
+
+// parseSCPArgs parses the extra arguments passed to Client.Copy.
+// Only the flags that make sense for the embedded implementation
+// are accepted; -q and -v are accepted and ignored.
+func parseSCPArgs(extraArgs []string) (scpOptions, error) {
+	var opts scpOptions
+	for _, arg := range extraArgs {
+		if len(arg) < 2 || arg[0] != '-' {
+			return opts, fmt.Errorf("unsupported scp argument %q", arg)
+		}
+		for _, flag := range arg[1:] {
+			switch flag {
+			case 'r':
+				opts.recursive = true
+			case 'p':
+				opts.preserve = true
+			case 'q', 'v':
+			default:
+				return opts, fmt.Errorf("unsupported scp argument %q", arg)
+			}
+		}
+	}
+	return opts, nil
+}
+
+// args returns the flags to pass to the remote scp
+// to reproduce the options.
+func (opts scpOptions) args() []string {
+	var args []string
+	if opts.recursive {
+		args = append(args, "-r")
+	}
+	if opts.preserve {
+		args = append(args, "-p")
+	}
+	return args
+}
+
+// splitSCPTarget splits a target in the scp format,
+// [[user@]host:]path, into its host and path. If the target
+// refers to a local path, host will be empty. IPv6 addresses
+// must be enclosed in (possibly escaped) square brackets.
+func splitSCPTarget(target string) (host, path string) {
+	s := strings.Replace(target, `\[`, "[", 1)
+	s = strings.Replace(s, `\]`, "]", 1)
+	var i int
+	if open := strings.Index(s, "["); open >= 0 && !strings.Contains(s[:open], "/") {
+		end := strings.Index(s, "]:")
+		if end < open {
+			return "", target
+		}
+		host = s[:open] + s[open+1:end]
+		i = end + 1
+	} else {
+		i = strings.Index(s, ":")
+		if i <= 0 || strings.Contains(s[:i], "/") {
+			return "", target
+		}
+		host = s[:i]
+	}
+	path = s[i+1:]
+	if path == "" {
+		path = "."
+	}
+	return host, path
+}
+
+// readSCPAck reads a response from the remote end of an scp session.
+func readSCPAck(r *bufio.Reader) error {
+	b, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	switch b {
+	case 0:
+		return nil
+	case 1, 2:
+		msg, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("scp: %s", strings.TrimSuffix(msg, "\n"))
+	}
+	return fmt.Errorf("scp: unexpected response %q", b)
+}
+
+// scpSend implements the source side of the scp protocol, as
+// spoken by "scp -f". It sends the files and directories named
+// in sources on w to the sink, which acknowledges them on r.
+func scpSend(w io.Writer, r io.Reader, sources []string, opts scpOptions) error {
+	s := &scpSource{
+		w:    w,
+		r:    bufio.NewReader(r),
+		opts: opts,
+	}
+	// The sink acknowledges that it is ready to receive.
+	if err := readSCPAck(s.r); err != nil {
+		return err
+	}
+	for _, source := range sources {
+		if err := s.send(source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type scpSource struct {
+	w    io.Writer
+	r    *bufio.Reader
+	opts scpOptions
+}
+
+// command sends a single protocol line and waits for it
+// to be acknowledged.
+func (s *scpSource) command(format string, args ...interface{}) error {
+	if _, err := fmt.Fprintf(s.w, format+"\n", args...); err != nil {
+		return err
+	}
+	return readSCPAck(s.r)
+}
+
+func (s *scpSource) send(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if s.opts.preserve {
+		mtime := info.ModTime().Unix()
+		if err := s.command("T%d 0 %d 0", mtime, mtime); err != nil {
+			return err
+		}
+	}
+	if info.IsDir() {
+		return s.sendDir(path, info)
+	}
+	return s.sendFile(path, info)
+}
+
+func (s *scpSource) sendFile(path string, info os.FileInfo) error {
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s: not a regular file", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := s.command("C%04o %d %s", info.Mode().Perm(), info.Size(), info.Name()); err != nil {
+		return err
+	}
+	if _, err := io.CopyN(s.w, f, info.Size()); err != nil {
+		return err
+	}
+	if _, err := s.w.Write([]byte{0}); err != nil {
+		return err
+	}
+	return readSCPAck(s.r)
+}
+
+func (s *scpSource) sendDir(path string, info os.FileInfo) error {
+	if !s.opts.recursive {
+		return fmt.Errorf("%s: is a directory (use -r)", path)
+	}
+	if err := s.command("D%04o 0 %s", info.Mode().Perm(), info.Name()); err != nil {
+		return err
+	}
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	names, err := dir.Readdirnames(-1)
+	dir.Close()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := s.send(filepath.Join(path, name)); err != nil {
+			return err
+		}
+	}
+	return s.command("E")
+}
+
+// scpReceive implements the sink side of the scp protocol, as
+// spoken by "scp -t". It writes the files and directories sent
+// by the source on r into target, acknowledging them on w.
+func scpReceive(w io.Writer, r io.Reader, target string, opts scpOptions) error {
+	s := &scpSink{
+		w:    w,
+		r:    bufio.NewReader(r),
+		opts: opts,
+	}
+	if info, err := os.Stat(target); err == nil && info.IsDir() {
+		s.dirs = []string{target}
+		s.base = 1
+	} else {
+		s.target = target
+	}
+	return s.receive()
+}
+
+type scpSink struct {
+	w    io.Writer
+	r    *bufio.Reader
+	opts scpOptions
+	// target holds the path to write the first
+	// file or directory to, if it is not an
+	// existing directory.
+	target string
+	// dirs holds the stack of directories
+	// being written to.
+	dirs []string
+	// base holds the number of entries in dirs
+	// that were not created by the source.
+	base int
+	// mtime and atime hold the times sent with
+	// the last T command, if any.
+	mtime, atime time.Time
+}
+
+func (s *scpSink) ack() error {
+	_, err := s.w.Write([]byte{0})
+	return err
+}
+
+func (s *scpSink) receive() error {
+	if err := s.ack(); err != nil {
+		return err
+	}
+	for {
+		line, err := s.r.ReadString('\n')
+		if err == io.EOF && line == "" {
+			if len(s.dirs) > s.base {
+				return io.ErrUnexpectedEOF
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return fmt.Errorf("scp: unexpected empty command")
+		}
+		switch line[0] {
+		case 1, 2:
+			return fmt.Errorf("scp: %s", line[1:])
+		case 'T':
+			err = s.times(line[1:])
+		case 'C':
+			err = s.file(line[1:])
+		case 'D':
+			err = s.dir(line[1:])
+		case 'E':
+			err = s.endDir()
+		default:
+			err = fmt.Errorf("scp: unexpected command %q", line)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *scpSink) times(args string) error {
+	var mtime, atime int64
+	var mtimeUsec, atimeUsec int
+	if _, err := fmt.Sscanf(args, "%d %d %d %d", &mtime, &mtimeUsec, &atime, &atimeUsec); err != nil {
+		return fmt.Errorf("scp: invalid times %q", args)
+	}
+	s.mtime = time.Unix(mtime, 0)
+	s.atime = time.Unix(atime, 0)
+	return s.ack()
+}
+
+// parseEntry parses the mode, size and name sent
+// with C and D commands.
+func parseEntry(args string) (mode os.FileMode, size int64, name string, err error) {
+	fields := strings.SplitN(args, " ", 3)
+	if len(fields) != 3 {
+		return 0, 0, "", fmt.Errorf("scp: invalid entry %q", args)
+	}
+	perm, err := strconv.ParseUint(fields[0], 8, 32)
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("scp: invalid mode %q", fields[0])
+	}
+	size, err = strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || size < 0 {
+		return 0, 0, "", fmt.Errorf("scp: invalid size %q", fields[1])
+	}
+	name = fields[2]
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return 0, 0, "", fmt.Errorf("scp: invalid file name %q", name)
+	}
+	return os.FileMode(perm) & os.ModePerm, size, name, nil
+}
+
+// path returns the path to write the named entry to.
+func (s *scpSink) path(name string) (string, error) {
+	if len(s.dirs) > 0 {
+		return filepath.Join(s.dirs[len(s.dirs)-1], name), nil
+	}
+	if s.target == "" {
+		return "", fmt.Errorf("scp: target is not a directory")
+	}
+	path := s.target
+	s.target = ""
+	return path, nil
+}
+
+func (s *scpSink) file(args string) error {
+	mode, size, name, err := parseEntry(args)
+	if err != nil {
+		return err
+	}
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := s.ack(); err != nil {
+		return err
+	}
+	if _, err := io.CopyN(f, s.r, size); err != nil {
+		return err
+	}
+	if err := readSCPAck(s.r); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := s.finish(path, mode); err != nil {
+		return err
+	}
+	return s.ack()
+}
+
+func (s *scpSink) dir(args string) error {
+	if !s.opts.recursive {
+		return fmt.Errorf("scp: received directory without -r")
+	}
+	mode, _, name, err := parseEntry(args)
+	if err != nil {
+		return err
+	}
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Mkdir(path, mode|0700); err != nil {
+		if info, statErr := os.Stat(path); statErr != nil || !info.IsDir() {
+			return err
+		}
+	}
+	if err := s.finish(path, mode); err != nil {
+		return err
+	}
+	s.dirs = append(s.dirs, path)
+	return s.ack()
+}
+
+func (s *scpSink) endDir() error {
+	if len(s.dirs) <= s.base {
+		return fmt.Errorf("scp: unexpected end of directory")
+	}
+	s.dirs = s.dirs[:len(s.dirs)-1]
+	return s.ack()
+}
+
+// finish applies the times and, if requested, the
+// mode sent for the entry at path.
+func (s *scpSink) finish(path string, mode os.FileMode) error {
+	if !s.opts.preserve {
+		return nil
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		return err
+	}
+	if !s.mtime.IsZero() {
+		if err := os.Chtimes(path, s.atime, s.mtime); err != nil {
+			return err
+		}
+		s.mtime, s.atime = time.Time{}, time.Time{}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package ssh_test
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/testing/testbase"
+	"launchpad.net/juju-core/utils/ssh"
+)
+
+type SCPSuite struct {
+	testbase.LoggingSuite
+}
+
+var _ = gc.Suite(&SCPSuite{})
+
+var splitSCPTargetTests = []struct {
+	target string
+	host   string
+	path   string
+}{
+	{"/tmp/foo", "", "/tmp/foo"},
+	{"foo", "", "foo"},
+	{"./a:b", "", "./a:b"},
+	{"host:/tmp/foo", "host", "/tmp/foo"},
+	{"ubuntu@host:foo", "ubuntu@host", "foo"},
+	{"ubuntu@host:", "ubuntu@host", "."},
+	{`ubuntu@\[::1\]:/tmp`, "ubuntu@::1", "/tmp"},
+	{"[fe80::1]:/tmp", "fe80::1", "/tmp"},
+}
+
+func (s *SCPSuite) TestSplitSCPTarget(c *gc.C) {
+	for i, t := range splitSCPTargetTests {
+		c.Logf("test %d: %s", i, t.target)
+		host, path := ssh.SplitSCPTarget(t.target)
+		c.Check(host, gc.Equals, t.host)
+		c.Check(path, gc.Equals, t.path)
+	}
+}
+
+func (s *SCPSuite) TestParseSCPArgs(c *gc.C) {
+	opts, err := ssh.ParseSCPArgs([]string{"-rq", "-p"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(opts, gc.Equals, ssh.NewSCPOptions(true, true))
+	_, err = ssh.ParseSCPArgs([]string{"-o", "Compression yes"})
+	c.Assert(err, gc.ErrorMatches, `unsupported scp argument "-o"`)
+}
+
+// transfer connects the source and sink sides of the
+// scp protocol, and returns the errors from each.
+func transfer(sources []string, target string, recursive, preserve bool) (sendErr, receiveErr error) {
+	opts := ssh.NewSCPOptions(recursive, preserve)
+	sourceR, sinkW := io.Pipe()
+	sinkR, sourceW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := ssh.SCPReceive(sinkW, sinkR, target, opts)
+		sinkR.CloseWithError(io.ErrClosedPipe)
+		sinkW.Close()
+		done <- err
+	}()
+	sendErr = ssh.SCPSend(sourceW, sourceR, sources, opts)
+	sourceW.Close()
+	return sendErr, <-done
+}
+
+func writeFile(c *gc.C, path, content string, perm os.FileMode) {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	c.Assert(err, gc.IsNil)
+	err = ioutil.WriteFile(path, []byte(content), perm)
+	c.Assert(err, gc.IsNil)
+}
+
+func checkFile(c *gc.C, path, content string) {
+	data, err := ioutil.ReadFile(path)
+	c.Assert(err, gc.IsNil)
+	c.Check(string(data), gc.Equals, content)
+}
+
+func (s *SCPSuite) TestCopyFile(c *gc.C) {
+	source := filepath.Join(c.MkDir(), "a")
+	writeFile(c, source, "hello", 0644)
+	target := filepath.Join(c.MkDir(), "b")
+	sendErr, receiveErr := transfer([]string{source}, target, false, false)
+	c.Assert(sendErr, gc.IsNil)
+	c.Assert(receiveErr, gc.IsNil)
+	checkFile(c, target, "hello")
+}
+
+func (s *SCPSuite) TestCopyFilesToDirectory(c *gc.C) {
+	sourceDir := c.MkDir()
+	writeFile(c, filepath.Join(sourceDir, "a"), "a contents", 0644)
+	writeFile(c, filepath.Join(sourceDir, "b"), "", 0600)
+	target := c.MkDir()
+	sendErr, receiveErr := transfer([]string{
+		filepath.Join(sourceDir, "a"),
+		filepath.Join(sourceDir, "b"),
+	}, target, false, true)
+	c.Assert(sendErr, gc.IsNil)
+	c.Assert(receiveErr, gc.IsNil)
+	checkFile(c, filepath.Join(target, "a"), "a contents")
+	checkFile(c, filepath.Join(target, "b"), "")
+	info, err := os.Stat(filepath.Join(target, "b"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Mode().Perm(), gc.Equals, os.FileMode(0600))
+}
+
+func (s *SCPSuite) TestCopyDirectoryRecursive(c *gc.C) {
+	source := filepath.Join(c.MkDir(), "dir")
+	writeFile(c, filepath.Join(source, "a"), "a contents", 0644)
+	writeFile(c, filepath.Join(source, "sub", "b"), "b contents", 0644)
+
+	// Copying to a new path creates the directory there.
+	target := filepath.Join(c.MkDir(), "copy")
+	sendErr, receiveErr := transfer([]string{source}, target, true, false)
+	c.Assert(sendErr, gc.IsNil)
+	c.Assert(receiveErr, gc.IsNil)
+	checkFile(c, filepath.Join(target, "a"), "a contents")
+	checkFile(c, filepath.Join(target, "sub", "b"), "b contents")
+
+	// Copying to an existing directory creates it within.
+	target = c.MkDir()
+	sendErr, receiveErr = transfer([]string{source}, target, true, false)
+	c.Assert(sendErr, gc.IsNil)
+	c.Assert(receiveErr, gc.IsNil)
+	checkFile(c, filepath.Join(target, "dir", "a"), "a contents")
+	checkFile(c, filepath.Join(target, "dir", "sub", "b"), "b contents")
+}
+
+func (s *SCPSuite) TestCopyDirectoryNotRecursive(c *gc.C) {
+	source := c.MkDir()
+	sendErr, _ := transfer([]string{source}, c.MkDir(), false, false)
+	c.Assert(sendErr, gc.ErrorMatches, `.*: is a directory \(use -r\)`)
+}
+
+func (s *SCPSuite) TestCopyFilesToFile(c *gc.C) {
+	sourceDir := c.MkDir()
+	writeFile(c, filepath.Join(sourceDir, "a"), "a", 0644)
+	writeFile(c, filepath.Join(sourceDir, "b"), "b", 0644)
+	target := filepath.Join(c.MkDir(), "target")
+	_, receiveErr := transfer([]string{
+		filepath.Join(sourceDir, "a"),
+		filepath.Join(sourceDir, "b"),
+	}, target, false, false)
+	c.Assert(receiveErr, gc.ErrorMatches, "scp: target is not a directory")
+	checkFile(c, target, "a")
+}
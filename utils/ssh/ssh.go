@@ -11,6 +11,7 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"os"
 	"os/exec"
 	"syscall"
 
@@ -25,10 +26,15 @@ type Options struct {
 	allocatePTY bool
 	// password authentication is disallowed by default
 	passwordAuthAllowed bool
+	// the local ssh-agent is not forwarded by default
+	forwardAgent bool
 	// identities is a sequence of paths to private key/identity files
 	// to use when attempting to login. A client implementaton may attempt
 	// with additional identities, but must give preference to these
 	identities []string
+	// proxyHost is a host, in [user@]host format, through
+	// which connections to the target host are tunnelled.
+	proxyHost string
 }
 
 // SetPort sets the SSH server port to connect to.
@@ -52,6 +58,13 @@ func (o *Options) AllowPasswordAuthentication() {
 	o.passwordAuthAllowed = true
 }
 
+// EnableAgentForwarding forwards connections to the
+// authentication agent from the target host to the
+// ssh-agent on the local host, if there is one.
+func (o *Options) EnableAgentForwarding() {
+	o.forwardAgent = true
+}
+
 // SetIdentities sets a sequence of paths to private key/identity files
 // to use when attempting login. Client implementations may attempt to
 // use additional identities, but must give preference to the ones
@@ -60,6 +73,14 @@ func (o *Options) SetIdentities(identityFiles ...string) {
 	o.identities = append([]string{}, identityFiles...)
 }
 
+// SetProxyHost sets a jump host, in the format [user@]host,
+// through which the connection to the target host will be
+// tunnelled. This allows connecting to hosts that are only
+// reachable from the jump host, such as the state server.
+func (o *Options) SetProxyHost(host string) {
+	o.proxyHost = host
+}
+
 // Client is an interface for SSH clients to implement
 type Client interface {
 	// Command returns a Command for executing a command
@@ -196,11 +217,16 @@ type command interface {
 	StderrPipe() (io.ReadCloser, io.Writer, error)
 }
 
+// ClientEnvKey is the environment variable that selects
+// the SSH client implementation used for DefaultClient.
+const ClientEnvKey = "JUJU_SSH_CLIENT"
+
 // DefaultClient is the default SSH client for the process.
 //
-// If the OpenSSH client is found in $PATH, then it will be
-// used for DefaultClient; otherwise, DefaultClient will use
-// an embedded client based on go.crypto/ssh.
+// DefaultClient uses an embedded client based on go.crypto/ssh,
+// so that no external ssh binary is needed. If $JUJU_SSH_CLIENT
+// is "openssh" and the OpenSSH client is found in $PATH, then
+// it will be used for DefaultClient instead.
 var DefaultClient Client
 
 // chosenClient holds the type of SSH client created for
@@ -212,10 +238,16 @@ func init() {
 }
 
 func initDefaultClient() {
-	if client, err := NewOpenSSHClient(); err == nil {
-		DefaultClient = client
-		chosenClient = "OpenSSH"
-	} else if client, err := NewGoCryptoClient(); err == nil {
+	if os.Getenv(ClientEnvKey) == "openssh" {
+		client, err := NewOpenSSHClient()
+		if err == nil {
+			DefaultClient = client
+			chosenClient = "OpenSSH"
+			return
+		}
+		logger.Warningf("cannot use OpenSSH client: %v", err)
+	}
+	if client, err := NewGoCryptoClient(); err == nil {
 		DefaultClient = client
 		chosenClient = "go.crypto (embedded)"
 	}
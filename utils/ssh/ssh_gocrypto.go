@@ -4,13 +4,17 @@
 package ssh
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
+	"os"
 	"os/user"
 	"strings"
 
 	"code.google.com/p/go.crypto/ssh"
+	"code.google.com/p/go.crypto/ssh/agent"
 
 	"launchpad.net/juju-core/utils"
 )
@@ -20,10 +24,10 @@ const sshDefaultPort = 22
 // GoCryptoClient is an implementation of Client that
 // uses the embedded go.crypto/ssh SSH client.
 //
-// GoCryptoClient is intentionally limited in the
-// functionality that it enables, as it is currently
-// intended to be used only for non-interactive command
-// execution.
+// GoCryptoClient supports interactive sessions with a
+// pseudo-terminal, scp-compatible copying, tunnelling
+// through a proxy host and forwarding of the ssh-agent
+// at $SSH_AUTH_SOCK. It does not read ssh_config files.
 type GoCryptoClient struct {
 	signers []ssh.Signer
 }
@@ -38,47 +42,189 @@ func NewGoCryptoClient(signers ...ssh.Signer) (*GoCryptoClient, error) {
 
 // Command implements Client.Command.
 func (c *GoCryptoClient) Command(host string, command []string, options *Options) *Cmd {
+	return &Cmd{impl: c.command(host, command, options)}
+}
+
+func (c *GoCryptoClient) command(host string, command []string, options *Options) *goCryptoCommand {
 	shellCommand := utils.CommandString(command...)
 	signers := c.signers
 	if len(signers) == 0 {
 		signers = privateKeys()
 	}
+	if options != nil && len(options.identities) > 0 {
+		// Identities take preference over the client's keys.
+		signers = append(identitySigners(options.identities), signers...)
+	}
 	user, host := splitUserHost(host)
 	port := sshDefaultPort
+	var pty, forwardAgent bool
+	var proxyHost string
 	if options != nil {
 		if options.port != 0 {
 			port = options.port
 		}
+		pty = options.allocatePTY
+		forwardAgent = options.forwardAgent
+		proxyHost = options.proxyHost
 	}
 	logger.Debugf(`running (equivalent of): ssh "%s@%s" -p %d '%s'`, user, host, port, shellCommand)
-	return &Cmd{impl: &goCryptoCommand{
-		signers: signers,
-		user:    user,
-		addr:    fmt.Sprintf("%s:%d", host, port),
-		command: shellCommand,
-	}}
+	return &goCryptoCommand{
+		signers:      signers,
+		user:         user,
+		addr:         fmt.Sprintf("%s:%d", host, port),
+		command:      shellCommand,
+		pty:          pty,
+		forwardAgent: forwardAgent,
+		proxyHost:    proxyHost,
+	}
 }
 
 // Copy implements Client.Copy.
 //
-// Copy is currently unimplemented, and will always return an error.
-func (c *GoCryptoClient) Copy(targets, extraArgs []string, options *Options) error {
-	return fmt.Errorf("scp command is not implemented (OpenSSH scp not available in PATH)")
+// Copy speaks the scp protocol to the scp program on the
+// remote host, so only copies between the local host and
+// remote hosts are supported. Of the scp flags, only -r, -p,
+// -q and -v may be specified in extraArgs.
+func (c *GoCryptoClient) Copy(targets, extraArgs []string, userOptions *Options) error {
+	var options Options
+	if userOptions != nil {
+		options = *userOptions
+		options.allocatePTY = false // doesn't make sense for scp
+		options.forwardAgent = false
+	}
+	opts, err := parseSCPArgs(extraArgs)
+	if err != nil {
+		return err
+	}
+	if len(targets) < 2 {
+		return fmt.Errorf("at least two targets must be specified")
+	}
+	sources := targets[:len(targets)-1]
+	destHost, destPath := splitSCPTarget(targets[len(targets)-1])
+	if destHost != "" {
+		// Upload local sources to the remote host.
+		for _, source := range sources {
+			if host, _ := splitSCPTarget(source); host != "" {
+				return fmt.Errorf("copying between remote hosts is not supported")
+			}
+		}
+		args := append([]string{"scp", "-t"}, opts.args()...)
+		if len(sources) > 1 {
+			args = append(args, "-d")
+		}
+		args = append(args, destPath)
+		return c.scp(destHost, args, &options, func(w io.Writer, r io.Reader) error {
+			return scpSend(w, r, sources, opts)
+		})
+	}
+	// Download remote sources to the local host.
+	for _, source := range sources {
+		host, path := splitSCPTarget(source)
+		if host == "" {
+			return fmt.Errorf("copying between local paths is not supported")
+		}
+		args := append([]string{"scp", "-f"}, opts.args()...)
+		args = append(args, path)
+		err := c.scp(host, args, &options, func(w io.Writer, r io.Reader) error {
+			return scpReceive(w, r, destPath, opts)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scp runs the scp command on the given host, and calls
+// transfer to speak the scp protocol with it.
+func (c *GoCryptoClient) scp(host string, command []string, options *Options, transfer func(w io.Writer, r io.Reader) error) error {
+	cmd := c.command(host, command, options)
+	var stderr bytes.Buffer
+	cmd.SetStdio(nil, nil, &stderr)
+	stdin, _, err := cmd.StdinPipe()
+	if err != nil {
+		cmd.Close()
+		return err
+	}
+	stdout, _, err := cmd.StdoutPipe()
+	if err != nil {
+		cmd.Close()
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		cmd.Close()
+		return err
+	}
+	err = transfer(stdin, stdout)
+	// Closing stdin tells the remote scp that we are done,
+	// or aborts it if the transfer failed.
+	stdin.Close()
+	if waitErr := cmd.Wait(); err == nil {
+		err = waitErr
+	}
+	if err != nil {
+		stderr := strings.TrimSpace(stderr.String())
+		if len(stderr) > 0 {
+			err = fmt.Errorf("%v (%v)", err, stderr)
+		}
+		return err
+	}
+	return nil
 }
 
 type goCryptoCommand struct {
-	signers []ssh.Signer
-	user    string
-	addr    string
-	command string
-	stdin   io.Reader
-	stdout  io.Writer
-	stderr  io.Writer
-	conn    *ssh.ClientConn
-	sess    *ssh.Session
+	signers      []ssh.Signer
+	user         string
+	addr         string
+	command      string
+	pty          bool
+	forwardAgent bool
+	proxyHost    string
+	stdin        io.Reader
+	stdout       io.Writer
+	stderr       io.Writer
+	proxyConn    *ssh.Client
+	conn         *ssh.Client
+	sess         *ssh.Session
+	// restoreTerminal, if non-nil, restores the
+	// local terminal after the session ends.
+	restoreTerminal func()
 }
 
-var sshDial = ssh.Dial
+var (
+	sshDial          = ssh.Dial
+	sshNewClientConn = ssh.NewClientConn
+)
+
+// dial connects to the target host, tunnelling through
+// the proxy host if one was specified.
+func (c *goCryptoCommand) dial(config *ssh.ClientConfig) (*ssh.Client, error) {
+	if c.proxyHost == "" {
+		return sshDial("tcp", c.addr, config)
+	}
+	proxyConfig := *config
+	proxyUser, proxyHost := splitUserHost(c.proxyHost)
+	if proxyUser != "" {
+		proxyConfig.User = proxyUser
+	}
+	proxyConn, err := sshDial("tcp", fmt.Sprintf("%s:%d", proxyHost, sshDefaultPort), &proxyConfig)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to proxy host %q: %v", proxyHost, err)
+	}
+	tunnel, err := proxyConn.Dial("tcp", c.addr)
+	if err != nil {
+		proxyConn.Close()
+		return nil, fmt.Errorf("cannot connect to %q through proxy host %q: %v", c.addr, proxyHost, err)
+	}
+	conn, chans, reqs, err := sshNewClientConn(tunnel, c.addr, config)
+	if err != nil {
+		tunnel.Close()
+		proxyConn.Close()
+		return nil, err
+	}
+	c.proxyConn = proxyConn
+	return ssh.NewClient(conn, chans, reqs), nil
+}
 
 func (c *goCryptoCommand) ensureSession() (*ssh.Session, error) {
 	if c.sess != nil {
@@ -96,17 +242,34 @@ func (c *goCryptoCommand) ensureSession() (*ssh.Session, error) {
 	}
 	config := &ssh.ClientConfig{
 		User: c.user,
-		Auth: []ssh.ClientAuth{
-			ssh.ClientAuthKeyring(keyring{c.signers}),
+		Auth: []ssh.AuthMethod{
+			ssh.PublicKeys(c.signers...),
 		},
+		// Host keys are not checked, as with the
+		// "StrictHostKeyChecking no" given to OpenSSH.
+		HostKeyCallback: acceptHostKey,
 	}
-	conn, err := sshDial("tcp", c.addr, config)
+	conn, err := c.dial(config)
 	if err != nil {
 		return nil, err
 	}
 	sess, err := conn.NewSession()
+	if err == nil && c.forwardAgent {
+		if err = requestAgentForwarding(conn, sess); err != nil {
+			sess.Close()
+		}
+	}
+	if err == nil && c.pty {
+		if err = requestPty(sess, c.stdin); err != nil {
+			sess.Close()
+		}
+	}
 	if err != nil {
 		conn.Close()
+		if c.proxyConn != nil {
+			c.proxyConn.Close()
+			c.proxyConn = nil
+		}
 		return nil, err
 	}
 	c.conn = conn
@@ -122,6 +285,12 @@ func (c *goCryptoCommand) Start() error {
 	if err != nil {
 		return err
 	}
+	if c.pty {
+		c.restoreTerminal, err = setupTerminal(sess, c.stdin)
+		if err != nil {
+			return err
+		}
+	}
 	if c.command == "" {
 		return sess.Shell()
 	}
@@ -129,6 +298,10 @@ func (c *goCryptoCommand) Start() error {
 }
 
 func (c *goCryptoCommand) Close() error {
+	if c.restoreTerminal != nil {
+		c.restoreTerminal()
+		c.restoreTerminal = nil
+	}
 	if c.sess == nil {
 		return nil
 	}
@@ -137,8 +310,14 @@ func (c *goCryptoCommand) Close() error {
 	if err0 == nil {
 		err0 = err1
 	}
+	if c.proxyConn != nil {
+		if err := c.proxyConn.Close(); err0 == nil {
+			err0 = err
+		}
+	}
 	c.sess = nil
 	c.conn = nil
+	c.proxyConn = nil
 	return err0
 }
 
@@ -191,24 +370,54 @@ func (c *goCryptoCommand) StderrPipe() (io.ReadCloser, io.Writer, error) {
 	return ioutil.NopCloser(wc), sess.Stderr, err
 }
 
-// keyring implements ssh.ClientKeyring
-type keyring struct {
-	signers []ssh.Signer
+// identitySigners returns signers for the private keys
+// in the given identity files, skipping any that cannot
+// be read, as OpenSSH does.
+func identitySigners(identities []string) []ssh.Signer {
+	var signers []ssh.Signer
+	for _, identity := range identities {
+		path, err := utils.NormalizePath(identity)
+		if err != nil {
+			logger.Warningf("failed to normalize path %q: %v", identity, err)
+			continue
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			logger.Warningf("cannot read identity file: %v", err)
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			logger.Warningf("cannot parse identity file %q: %v", path, err)
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	return signers
 }
 
-func (k keyring) Key(i int) (ssh.PublicKey, error) {
-	if i < 0 || i >= len(k.signers) {
-		// nil key marks the end of the keyring; must not return an error.
-		return nil, nil
-	}
-	return k.signers[i].PublicKey(), nil
+func acceptHostKey(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	return nil
 }
 
-func (k keyring) Sign(i int, rand io.Reader, data []byte) ([]byte, error) {
-	if i < 0 || i >= len(k.signers) {
-		return nil, fmt.Errorf("no key at position %d", i)
+// requestAgentForwarding asks the remote host to forward
+// agent connections for the session, and relays those the
+// remote host opens to the ssh-agent listening at
+// $SSH_AUTH_SOCK. If there is no agent, the session
+// goes ahead without forwarding, as OpenSSH's would.
+func requestAgentForwarding(client *ssh.Client, sess *ssh.Session) error {
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	if sock == "" {
+		logger.Warningf("not forwarding ssh-agent: SSH_AUTH_SOCK is not set")
+		return nil
+	}
+	if err := agent.ForwardToRemote(client, sock); err != nil {
+		return fmt.Errorf("cannot forward ssh-agent: %v", err)
+	}
+	if err := agent.RequestAgentForwarding(sess); err != nil {
+		return fmt.Errorf("cannot forward ssh-agent: %v", err)
 	}
-	return k.signers[i].Sign(rand, data)
+	return nil
 }
 
 func splitUserHost(s string) (user, host string) {
@@ -218,3 +427,24 @@ func splitUserHost(s string) (user, host string) {
 	}
 	return "", userHost[0]
 }
+
+const (
+	defaultTerminalWidth  = 80
+	defaultTerminalHeight = 24
+)
+
+// requestPty requests a pseudo-terminal for the session,
+// sized to match the terminal attached to stdin, if any.
+func requestPty(sess *ssh.Session, stdin io.Reader) error {
+	term := os.Getenv("TERM")
+	if term == "" {
+		term = "xterm"
+	}
+	width, height := terminalSize(stdin)
+	modes := ssh.TerminalModes{
+		ssh.ECHO:          1,
+		ssh.TTY_OP_ISPEED: 14400,
+		ssh.TTY_OP_OSPEED: 14400,
+	}
+	return sess.RequestPty(term, height, width, modes)
+}
@@ -4,12 +4,15 @@
 package ssh_test
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
+	"io/ioutil"
 	"net"
-	"sync"
+	"path/filepath"
 
 	cryptossh "code.google.com/p/go.crypto/ssh"
+	"code.google.com/p/go.crypto/ssh/agent"
 	gc "launchpad.net/gocheck"
 
 	jc "launchpad.net/juju-core/testing/checkers"
@@ -24,7 +27,10 @@ var (
 
 type sshServer struct {
 	cfg *cryptossh.ServerConfig
-	*cryptossh.Listener
+	net.Listener
+	// agentKeys holds the keys listed through the
+	// agent connection forwarded by the client, if any.
+	agentKeys []*agent.Key
 }
 
 func newServer(c *gc.C) *sshServer {
@@ -36,41 +42,47 @@ func newServer(c *gc.C) *sshServer {
 		cfg: &cryptossh.ServerConfig{},
 	}
 	server.cfg.AddHostKey(key)
-	server.Listener, err = cryptossh.Listen("tcp", "127.0.0.1:0", server.cfg)
+	server.Listener, err = net.Listen("tcp", "127.0.0.1:0")
 	c.Assert(err, gc.IsNil)
 	return server
 }
 
+// run serves a single session, which must run testCommand.
 func (s *sshServer) run(c *gc.C) {
-	conn, err := s.Accept()
+	netConn, err := s.Accept()
 	c.Assert(err, gc.IsNil)
-	defer func() {
-		err = conn.Close()
-		c.Assert(err, gc.IsNil)
-	}()
-	err = conn.Handshake()
-	c.Assert(err, gc.IsNil)
-	var wg sync.WaitGroup
-	defer wg.Wait()
-	for {
-		channel, err := conn.Accept()
-		c.Assert(err, gc.IsNil)
-		c.Assert(channel.ChannelType(), gc.Equals, "session")
-		channel.Accept()
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			defer channel.Close()
-			_, err := channel.Read(nil)
-			c.Assert(err, gc.FitsTypeOf, cryptossh.ChannelRequest{})
-			req := err.(cryptossh.ChannelRequest)
-			c.Assert(req.Request, gc.Equals, "exec")
-			c.Assert(req.WantReply, jc.IsTrue)
+	defer netConn.Close()
+	conn, chans, reqs, err := cryptossh.NewServerConn(netConn, s.cfg)
+	c.Assert(err, gc.IsNil)
+	go cryptossh.DiscardRequests(reqs)
+	newChannel := <-chans
+	c.Assert(newChannel.ChannelType(), gc.Equals, "session")
+	channel, reqs, err := newChannel.Accept()
+	c.Assert(err, gc.IsNil)
+	defer channel.Close()
+	for req := range reqs {
+		switch req.Type {
+		case "auth-agent-req@openssh.com":
+			req.Reply(true, nil)
+			agentChannel, agentReqs, err := conn.OpenChannel("auth-agent@openssh.com", nil)
+			c.Assert(err, gc.IsNil)
+			go cryptossh.DiscardRequests(agentReqs)
+			s.agentKeys, err = agent.NewClient(agentChannel).List()
+			c.Check(err, gc.IsNil)
+			agentChannel.Close()
+		case "exec":
+			req.Reply(true, nil)
 			n := binary.BigEndian.Uint32(req.Payload[:4])
 			command := string(req.Payload[4 : n+4])
-			c.Assert(command, gc.Equals, testCommandFlat)
-			// TODO(axw) when gosshnew is ready, send reply to client.
-		}()
+			c.Check(command, gc.Equals, testCommandFlat)
+			_, err := channel.Write([]byte("abc value\n"))
+			c.Check(err, gc.IsNil)
+			_, err = channel.SendRequest("exit-status", false, []byte{0, 0, 0, 0})
+			c.Check(err, gc.IsNil)
+			return
+		default:
+			req.Reply(false, nil)
+		}
 	}
 }
 
@@ -109,7 +121,7 @@ func (s *SSHGoCryptoCommandSuite) TestClientNoKeys(c *gc.C) {
 	err = ssh.LoadClientKeys(c.MkDir())
 	c.Assert(err, gc.IsNil)
 
-	s.PatchValue(ssh.SSHDial, func(network, address string, cfg *cryptossh.ClientConfig) (*cryptossh.ClientConn, error) {
+	s.PatchValue(ssh.SSHDial, func(network, address string, cfg *cryptossh.ClientConfig) (*cryptossh.Client, error) {
 		return nil, errors.New("ssh.Dial failed")
 	})
 	cmd = client.Command("0.1.2.3", []string{"echo", "123"}, nil)
@@ -129,22 +141,150 @@ func (s *SSHGoCryptoCommandSuite) TestCommand(c *gc.C) {
 	opts.SetPort(server.Addr().(*net.TCPAddr).Port)
 	cmd := client.Command("127.0.0.1", testCommand, &opts)
 	checkedKey := false
-	server.cfg.PublicKeyCallback = func(conn *cryptossh.ServerConn, user, algo string, pubkey []byte) bool {
-		c.Check(pubkey, gc.DeepEquals, cryptossh.MarshalPublicKey(key.PublicKey()))
+	server.cfg.PublicKeyCallback = func(conn cryptossh.ConnMetadata, pubkey cryptossh.PublicKey) (*cryptossh.Permissions, error) {
+		c.Check(pubkey.Marshal(), gc.DeepEquals, key.PublicKey().Marshal())
 		checkedKey = true
-		return true
+		return nil, nil
 	}
 	go server.run(c)
 	out, err := cmd.Output()
-	c.Assert(err, gc.ErrorMatches, "ssh: could not execute command.*")
-	// TODO(axw) when gosshnew is ready, expect reply from server.
-	c.Assert(out, gc.IsNil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(out), gc.Equals, "abc value\n")
 	c.Assert(checkedKey, jc.IsTrue)
+	c.Assert(server.agentKeys, gc.IsNil)
+}
+
+func (s *SSHGoCryptoCommandSuite) TestCommandIdentities(c *gc.C) {
+	private, _, err := ssh.GenerateKey("test-client")
+	c.Assert(err, gc.IsNil)
+	key, err := cryptossh.ParsePrivateKey([]byte(private))
+	c.Assert(err, gc.IsNil)
+	client, err := ssh.NewGoCryptoClient(key)
+	c.Assert(err, gc.IsNil)
+	identity, _, err := ssh.GenerateKey("test-identity")
+	c.Assert(err, gc.IsNil)
+	identityKey, err := cryptossh.ParsePrivateKey([]byte(identity))
+	c.Assert(err, gc.IsNil)
+	identityFile := filepath.Join(c.MkDir(), "id_rsa")
+	err = ioutil.WriteFile(identityFile, []byte(identity), 0600)
+	c.Assert(err, gc.IsNil)
+
+	server := newServer(c)
+	var offered [][]byte
+	server.cfg.PublicKeyCallback = func(conn cryptossh.ConnMetadata, pubkey cryptossh.PublicKey) (*cryptossh.Permissions, error) {
+		offered = append(offered, pubkey.Marshal())
+		if !bytes.Equal(pubkey.Marshal(), identityKey.PublicKey().Marshal()) {
+			return nil, errors.New("unknown key")
+		}
+		return nil, nil
+	}
+	var opts ssh.Options
+	opts.SetPort(server.Addr().(*net.TCPAddr).Port)
+	opts.SetIdentities("/does/not/exist", identityFile)
+	cmd := client.Command("127.0.0.1", testCommand, &opts)
+	go server.run(c)
+	out, err := cmd.Output()
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(out), gc.Equals, "abc value\n")
+	// The identity is offered before the client's own key.
+	c.Assert(offered, gc.HasLen, 1)
+}
+
+func (s *SSHGoCryptoCommandSuite) TestCommandAgentForwarding(c *gc.C) {
+	private, _, err := ssh.GenerateKey("test-client")
+	c.Assert(err, gc.IsNil)
+	key, err := cryptossh.ParsePrivateKey([]byte(private))
+	c.Assert(err, gc.IsNil)
+	client, err := ssh.NewGoCryptoClient(key)
+	c.Assert(err, gc.IsNil)
+
+	// Serve a keyring holding the client key as the local ssh-agent.
+	rawKey, err := cryptossh.ParseRawPrivateKey([]byte(private))
+	c.Assert(err, gc.IsNil)
+	keyring := agent.NewKeyring()
+	err = keyring.Add(agent.AddedKey{PrivateKey: rawKey, Comment: "test-client"})
+	c.Assert(err, gc.IsNil)
+	sock := filepath.Join(c.MkDir(), "agent.sock")
+	listener, err := net.Listen("unix", sock)
+	c.Assert(err, gc.IsNil)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go agent.ServeAgent(keyring, conn)
+		}
+	}()
+	s.PatchEnvironment("SSH_AUTH_SOCK", sock)
+
+	server := newServer(c)
+	server.cfg.PublicKeyCallback = func(conn cryptossh.ConnMetadata, pubkey cryptossh.PublicKey) (*cryptossh.Permissions, error) {
+		return nil, nil
+	}
+	var opts ssh.Options
+	opts.SetPort(server.Addr().(*net.TCPAddr).Port)
+	opts.EnableAgentForwarding()
+	cmd := client.Command("127.0.0.1", testCommand, &opts)
+	go server.run(c)
+	out, err := cmd.Output()
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(out), gc.Equals, "abc value\n")
+	c.Assert(server.agentKeys, gc.HasLen, 1)
+	c.Assert(server.agentKeys[0].Comment, gc.Equals, "test-client")
+	c.Assert(server.agentKeys[0].Marshal(), gc.DeepEquals, key.PublicKey().Marshal())
+}
+
+func (s *SSHGoCryptoCommandSuite) TestProxyHost(c *gc.C) {
+	defer ssh.ClearClientKeys()
+	err := ssh.LoadClientKeys(c.MkDir())
+	c.Assert(err, gc.IsNil)
+
+	var dialed []string
+	s.PatchValue(ssh.SSHDial, func(network, address string, cfg *cryptossh.ClientConfig) (*cryptossh.Client, error) {
+		dialed = append(dialed, cfg.User+"@"+address)
+		return nil, errors.New("ssh.Dial failed")
+	})
+	var opts ssh.Options
+	opts.SetPort(2022)
+	opts.SetProxyHost("ubuntu@jumphost")
+	cmd := s.client.Command("admin@10.0.0.1", []string{"echo", "123"}, &opts)
+	_, err = cmd.Output()
+	c.Assert(err, gc.ErrorMatches, `cannot connect to proxy host "jumphost": ssh.Dial failed`)
+	c.Assert(dialed, gc.DeepEquals, []string{"ubuntu@jumphost:22"})
 }
 
 func (s *SSHGoCryptoCommandSuite) TestCopy(c *gc.C) {
 	client, err := ssh.NewGoCryptoClient()
 	c.Assert(err, gc.IsNil)
 	err = client.Copy([]string{"0.1.2.3:b", c.MkDir()}, nil, nil)
-	c.Assert(err, gc.ErrorMatches, `scp command is not implemented \(OpenSSH scp not available in PATH\)`)
+	c.Assert(err, gc.ErrorMatches, "no private keys available")
+}
+
+var copyErrorTests = []struct {
+	targets   []string
+	extraArgs []string
+	err       string
+}{{
+	targets: []string{"a"},
+	err:     "at least two targets must be specified",
+}, {
+	targets: []string{"a", "b"},
+	err:     "copying between local paths is not supported",
+}, {
+	targets: []string{"host1:a", "host2:b"},
+	err:     "copying between remote hosts is not supported",
+}, {
+	targets:   []string{"a", "host:b"},
+	extraArgs: []string{"-l", "100"},
+	err:       `unsupported scp argument "-l"`,
+}}
+
+func (s *SSHGoCryptoCommandSuite) TestCopyErrors(c *gc.C) {
+	for i, t := range copyErrorTests {
+		c.Logf("test %d: %v %v", i, t.targets, t.extraArgs)
+		err := s.client.Copy(t.targets, t.extraArgs, nil)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
 }
@@ -77,6 +77,17 @@ func opensshOptions(options *Options, commandKind opensshCommandKind) map[string
 	if options.allocatePTY {
 		args["-t"] = []string{}
 	}
+	if options.forwardAgent {
+		args["-A"] = []string{}
+	}
+	if options.proxyHost != "" {
+		// Tunnel through the proxy host using an ssh client
+		// running netcat on it, as OpenSSH clients from before
+		// 5.4 do not support -W.
+		args["-o"] = append(args["-o"], fmt.Sprintf(
+			"ProxyCommand ssh -q -o StrictHostKeyChecking=no %s nc -q0 %%h %%p", options.proxyHost,
+		))
+	}
 	identities := append([]string{}, options.identities...)
 	if pk := PrivateKeyFiles(); len(pk) > 0 {
 		// Add client keys as implicit identities
@@ -154,6 +165,7 @@ func (c *OpenSSHClient) Copy(targets, extraArgs []string, userOptions *Options)
 	if userOptions != nil {
 		options = *userOptions
 		options.allocatePTY = false // doesn't make sense for scp
+		options.forwardAgent = false
 	}
 	opts := opensshOptions(&options, scpKind)
 	args := expandArgs(opts, false)
@@ -58,6 +58,10 @@ func (s *SSHCommandSuite) assertCommandArgs(c *gc.C, cmd *ssh.Cmd, expected stri
 }
 
 func (s *SSHCommandSuite) TestDefaultClient(c *gc.C) {
+	s.PatchEnvironment(ssh.ClientEnvKey, "")
+	ssh.InitDefaultClient()
+	c.Assert(ssh.DefaultClient, gc.FitsTypeOf, &ssh.GoCryptoClient{})
+	s.PatchEnvironment(ssh.ClientEnvKey, "openssh")
 	ssh.InitDefaultClient()
 	c.Assert(ssh.DefaultClient, gc.FitsTypeOf, &ssh.OpenSSHClient{})
 	s.PatchEnvironment("PATH", "")
@@ -99,6 +103,14 @@ func (s *SSHCommandSuite) TestCommandEnablePTY(c *gc.C) {
 	)
 }
 
+func (s *SSHCommandSuite) TestCommandEnableAgentForwarding(c *gc.C) {
+	var opts ssh.Options
+	opts.EnableAgentForwarding()
+	s.assertCommandArgs(c, s.commandOptions([]string{"echo", "123"}, &opts),
+		s.fakessh+" -o StrictHostKeyChecking no -o PasswordAuthentication no -A localhost echo 123",
+	)
+}
+
 func (s *SSHCommandSuite) TestCommandAllowPasswordAuthentication(c *gc.C) {
 	var opts ssh.Options
 	opts.AllowPasswordAuthentication()
@@ -123,6 +135,15 @@ func (s *SSHCommandSuite) TestCommandPort(c *gc.C) {
 	)
 }
 
+func (s *SSHCommandSuite) TestCommandProxyHost(c *gc.C) {
+	var opts ssh.Options
+	opts.SetProxyHost("ubuntu@jumphost")
+	s.assertCommandArgs(c, s.commandOptions([]string{"echo", "123"}, &opts),
+		s.fakessh+" -o StrictHostKeyChecking no -o PasswordAuthentication no"+
+			" -o ProxyCommand ssh -q -o StrictHostKeyChecking=no ubuntu@jumphost nc -q0 %h %p localhost echo 123",
+	)
+}
+
 func (s *SSHCommandSuite) TestCopy(c *gc.C) {
 	var opts ssh.Options
 	opts.EnablePTY()
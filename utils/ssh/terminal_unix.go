@@ -0,0 +1,73 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// +build !windows
+
+package ssh
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"code.google.com/p/go.crypto/ssh"
+	"code.google.com/p/go.crypto/ssh/terminal"
+)
+
+// terminalFd returns the file descriptor of stdin
+// if it is a terminal.
+func terminalFd(stdin io.Reader) (int, bool) {
+	f, ok := stdin.(*os.File)
+	if !ok || !terminal.IsTerminal(int(f.Fd())) {
+		return 0, false
+	}
+	return int(f.Fd()), true
+}
+
+// terminalSize returns the size of the terminal
+// attached to stdin, or a default size if there is none.
+func terminalSize(stdin io.Reader) (width, height int) {
+	if fd, ok := terminalFd(stdin); ok {
+		if width, height, err := terminal.GetSize(fd); err == nil {
+			return width, height
+		}
+	}
+	return defaultTerminalWidth, defaultTerminalHeight
+}
+
+// setupTerminal puts the terminal attached to stdin, if any,
+// into raw mode so that keystrokes are passed straight through
+// to the remote pseudo-terminal, and forwards changes in its
+// size to the session. The returned function undoes this.
+func setupTerminal(sess *ssh.Session, stdin io.Reader) (func(), error) {
+	fd, ok := terminalFd(stdin)
+	if !ok {
+		return func() {}, nil
+	}
+	state, err := terminal.MakeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	sigwinch := make(chan os.Signal, 1)
+	signal.Notify(sigwinch, syscall.SIGWINCH)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-sigwinch:
+				width, height := terminalSize(stdin)
+				if err := sess.WindowChange(height, width); err != nil {
+					logger.Debugf("cannot change remote window size: %v", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(sigwinch)
+		close(done)
+		terminal.Restore(fd, state)
+	}, nil
+}
@@ -0,0 +1,22 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package ssh
+
+import (
+	"io"
+
+	"code.google.com/p/go.crypto/ssh"
+)
+
+// terminalSize returns the default terminal size, as
+// the console size is not available on Windows.
+func terminalSize(stdin io.Reader) (width, height int) {
+	return defaultTerminalWidth, defaultTerminalHeight
+}
+
+// setupTerminal does nothing on Windows, where the console
+// cannot be put into raw mode; input is sent a line at a time.
+func setupTerminal(sess *ssh.Session, stdin io.Reader) (func(), error) {
+	return func() {}, nil
+}
@@ -11,6 +11,7 @@ import (
 	gc "launchpad.net/gocheck"
 
 	"launchpad.net/juju-core/testing/testbase"
+	"launchpad.net/juju-core/utils/ssh"
 )
 
 // sshscript should only print the result on the first execution,
@@ -40,9 +41,19 @@ else
     exec ssh $*
 fi`
 
+// PatchOpenSSHClient sets ssh.DefaultClient to an OpenSSH
+// client, so that fake "ssh" and "scp" commands in $PATH are
+// run in place of the embedded client, and returns a function
+// to restore the original client when called.
+func PatchOpenSSHClient(c *gc.C) testbase.Restorer {
+	client, err := ssh.NewOpenSSHClient()
+	c.Assert(err, gc.IsNil)
+	return testbase.PatchValue(&ssh.DefaultClient, client)
+}
+
 // InstallFakeSSH creates a fake "ssh" command in a new $PATH,
-// updates $PATH, and returns a function to reset $PATH to its
-// original value when called.
+// updates $PATH and ssh.DefaultClient to use it, and returns
+// a function to reset them to their original values when called.
 //
 // input may be:
 //    - nil (ignore input)
@@ -53,11 +64,11 @@ fi`
 //    - a slice of strings, of length two (stdout, stderr)
 func InstallFakeSSH(c *gc.C, input, output interface{}, rc int) testbase.Restorer {
 	fakebin := c.MkDir()
-	ssh := filepath.Join(fakebin, "ssh")
+	fakessh := filepath.Join(fakebin, "ssh")
 	switch input := input.(type) {
 	case nil:
 	case string:
-		sshexpectedinput := ssh + ".expected-input"
+		sshexpectedinput := fakessh + ".expected-input"
 		err := ioutil.WriteFile(sshexpectedinput, []byte(input), 0644)
 		c.Assert(err, gc.IsNil)
 	default:
@@ -74,7 +85,8 @@ func InstallFakeSSH(c *gc.C, input, output interface{}, rc int) testbase.Restore
 		stderr = fmt.Sprintf("cat>&2<<EOF\n%s\nEOF", output[1])
 	}
 	script := fmt.Sprintf(sshscript, stdout, stderr, rc)
-	err := ioutil.WriteFile(ssh, []byte(script), 0777)
+	err := ioutil.WriteFile(fakessh, []byte(script), 0777)
 	c.Assert(err, gc.IsNil)
-	return testbase.PatchEnvPathPrepend(fakebin)
+	restore := testbase.PatchEnvPathPrepend(fakebin)
+	return restore.Add(PatchOpenSSHClient(c))
 }
@@ -0,0 +1,290 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/juju/loggo"
+	"launchpad.net/tomb"
+)
+
+var logger = loggo.GetLogger("juju.utils.webhook")
+
+// Post sends the event to the webhook once, returning
+// an error if it could not be delivered.
+func Post(client *http.Client, hook Hook, event *Event) error {
+	req, err := newRequest(hook, event)
+	if err != nil {
+		return err
+	}
+	return do(client, hook, req)
+}
+
+// newRequest returns the request that sends the event to the webhook.
+func newRequest(hook Hook, event *Event) (*http.Request, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if hook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(hook.Secret, body))
+	}
+	return req, nil
+}
+
+func do(client *http.Client, hook Hook, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %q returned %s", hook.Name, resp.Status)
+	}
+	return nil
+}
+
+// NewClient returns an HTTP client suitable for sending events
+// to webhooks. Unlike http.DefaultClient, it gives up on webhooks
+// that cannot be reached or do not respond within the given time.
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			Dial:                  (&net.Dialer{Timeout: timeout}).Dial,
+			ResponseHeaderTimeout: timeout,
+		},
+	}
+}
+
+// Retry holds the strategy used by a Sender
+// when events cannot be delivered.
+type Retry struct {
+	// Attempts holds the number of attempts to
+	// deliver each event.
+	Attempts int
+
+	// Delay holds the delay after the first failed attempt;
+	// the delay doubles after each subsequent one.
+	Delay time.Duration
+
+	// Timeout holds the time allowed for each attempt, after
+	// which the request is cancelled. If it is zero, attempts
+	// are not timed out.
+	Timeout time.Duration
+}
+
+// DefaultRetry is the Retry used by NewSender.
+var DefaultRetry = Retry{
+	Attempts: 6,
+	Delay:    time.Second,
+	Timeout:  30 * time.Second,
+}
+
+// maxQueued holds the number of events that may be
+// waiting to be sent to a given webhook.
+const maxQueued = 100
+
+type delivery struct {
+	hook  Hook
+	event *Event
+}
+
+// queue holds the events waiting to be sent to a webhook.
+type queue struct {
+	deliveries chan delivery
+	// removed is closed when the webhook is removed.
+	removed chan struct{}
+}
+
+// Sender sends events to webhooks asynchronously, retrying
+// with exponential back-off if they cannot be delivered. The
+// events for each webhook are sent in order, one at a time.
+type Sender struct {
+	tomb   tomb.Tomb
+	client *http.Client
+	retry  Retry
+
+	mu     sync.Mutex
+	queues map[string]*queue
+	wg     sync.WaitGroup
+}
+
+// NewSender returns a new Sender that uses the given
+// client to send events, and DefaultRetry.
+func NewSender(client *http.Client) *Sender {
+	return NewSenderWithRetry(client, DefaultRetry)
+}
+
+// NewSenderWithRetry returns a new Sender that uses the given
+// client to send events, and the given retry strategy.
+func NewSenderWithRetry(client *http.Client, retry Retry) *Sender {
+	s := &Sender{
+		client: client,
+		retry:  retry,
+		queues: make(map[string]*queue),
+	}
+	go func() {
+		defer s.tomb.Done()
+		<-s.tomb.Dying()
+		s.wg.Wait()
+	}()
+	return s
+}
+
+// Send queues the event to be sent to the webhook. If too many
+// events are already waiting to be sent to it, the event is dropped.
+func (s *Sender) Send(hook Hook, event *Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.tomb.Dying():
+		return
+	default:
+	}
+	q, ok := s.queues[hook.Name]
+	if !ok {
+		q = &queue{
+			deliveries: make(chan delivery, maxQueued),
+			removed:    make(chan struct{}),
+		}
+		s.queues[hook.Name] = q
+		s.wg.Add(1)
+		go s.run(q)
+	}
+	select {
+	case q.deliveries <- delivery{hook, event}:
+	default:
+		logger.Warningf("dropping event for %s %s: too many events waiting for webhook %q", event.Kind, event.Id, hook.Name)
+	}
+}
+
+// Remove discards the events waiting to be sent to the named
+// webhook, cancelling any that is being sent, and frees the
+// resources used to send events to it.
+func (s *Sender) Remove(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if q, ok := s.queues[name]; ok {
+		close(q.removed)
+		delete(s.queues, name)
+	}
+}
+
+// run sends the events queued on q until the Sender
+// is stopped or the webhook is removed.
+func (s *Sender) run(q *queue) {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.tomb.Dying():
+			return
+		case <-q.removed:
+			return
+		case d := <-q.deliveries:
+			s.deliver(q, d)
+		}
+	}
+}
+
+// deliver sends an event to a webhook, retrying if it fails.
+func (s *Sender) deliver(q *queue, d delivery) {
+	delay := s.retry.Delay
+	for attempt := 1; ; attempt++ {
+		err := s.post(q, d)
+		if err == nil || err == errCancelled {
+			return
+		}
+		if attempt >= s.retry.Attempts {
+			logger.Errorf("cannot send event for %s %s to webhook %q: %v", d.event.Kind, d.event.Id, d.hook.Name, err)
+			return
+		}
+		logger.Warningf("cannot send event to webhook %q (retrying in %v): %v", d.hook.Name, delay, err)
+		select {
+		case <-s.tomb.Dying():
+			return
+		case <-q.removed:
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+var errCancelled = errors.New("cancelled")
+
+// post sends an event to a webhook once. The request is cancelled
+// if it does not complete within the retry timeout, or if the
+// Sender is stopped or the webhook removed while it is in flight.
+func (s *Sender) post(q *queue, d delivery) error {
+	req, err := newRequest(d.hook, d.event)
+	if err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- do(s.client, d.hook, req)
+	}()
+	var timeout <-chan time.Time
+	if s.retry.Timeout > 0 {
+		timeout = time.After(s.retry.Timeout)
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-timeout:
+		s.cancel(req)
+		return fmt.Errorf("webhook %q did not respond within %v", d.hook.Name, s.retry.Timeout)
+	case <-s.tomb.Dying():
+	case <-q.removed:
+	}
+	s.cancel(req)
+	return errCancelled
+}
+
+// cancel cancels the request, if the client's transport is able to.
+func (s *Sender) cancel(req *http.Request) {
+	transport := s.client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	canceler, ok := transport.(interface {
+		CancelRequest(*http.Request)
+	})
+	if !ok {
+		logger.Warningf("cannot cancel request to %s", req.URL)
+		return
+	}
+	canceler.CancelRequest(req)
+}
+
+// Kill implements worker.Worker.Kill. Events that
+// have not yet been sent are discarded.
+func (s *Sender) Kill() {
+	s.tomb.Kill(nil)
+}
+
+// Wait implements worker.Worker.Wait.
+func (s *Sender) Wait() error {
+	return s.tomb.Wait()
+}
+
+// Stop stops the sender.
+func (s *Sender) Stop() error {
+	s.Kill()
+	return s.Wait()
+}
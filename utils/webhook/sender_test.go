@@ -0,0 +1,234 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package webhook_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/testing"
+	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/testing/testbase"
+	"launchpad.net/juju-core/utils/webhook"
+)
+
+type senderSuite struct {
+	testbase.LoggingSuite
+	server   *httptest.Server
+	requests chan *request
+	failures int
+	// release, if not nil, is waited on before
+	// each request is answered.
+	release chan struct{}
+}
+
+var _ = gc.Suite(&senderSuite{})
+
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+func (s *senderSuite) SetUpTest(c *gc.C) {
+	s.LoggingSuite.SetUpTest(c)
+	s.requests = make(chan *request, 10)
+	s.failures = 0
+	s.release = nil
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+}
+
+func (s *senderSuite) TearDownTest(c *gc.C) {
+	s.server.Close()
+	s.LoggingSuite.TearDownTest(c)
+}
+
+func (s *senderSuite) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+	s.requests <- &request{req.Header, body}
+	if s.release != nil {
+		<-s.release
+	}
+	if s.failures > 0 {
+		s.failures--
+		http.Error(w, "try again", http.StatusServiceUnavailable)
+	}
+}
+
+func (s *senderSuite) hook() webhook.Hook {
+	return webhook.Hook{
+		Name:   "test",
+		URL:    s.server.URL,
+		Secret: "secret",
+	}
+}
+
+var testEvent = &webhook.Event{
+	Environment: "env",
+	Kind:        "unit",
+	Id:          "mysql/0",
+	Status:      "error",
+	StatusInfo:  "hook failed",
+	Time:        time.Date(2014, 3, 1, 12, 0, 0, 0, time.UTC),
+}
+
+func (s *senderSuite) nextRequest(c *gc.C) *request {
+	select {
+	case req := <-s.requests:
+		return req
+	case <-time.After(testing.LongWait):
+		c.Fatalf("timed out waiting for webhook request")
+	}
+	panic("unreachable")
+}
+
+func (s *senderSuite) assertNoRequest(c *gc.C) {
+	select {
+	case <-s.requests:
+		c.Fatalf("unexpected webhook request")
+	case <-time.After(testing.ShortWait):
+	}
+}
+
+func (s *senderSuite) TestPost(c *gc.C) {
+	err := webhook.Post(http.DefaultClient, s.hook(), testEvent)
+	c.Assert(err, gc.IsNil)
+	req := s.nextRequest(c)
+	c.Assert(req.header.Get("Content-Type"), gc.Equals, "application/json")
+	c.Assert(req.header.Get(webhook.SignatureHeader), gc.Equals, webhook.Sign("secret", req.body))
+
+	var event webhook.Event
+	err = json.Unmarshal(req.body, &event)
+	c.Assert(err, gc.IsNil)
+	c.Assert(&event, jc.DeepEquals, testEvent)
+}
+
+func (s *senderSuite) TestPostWithoutSecret(c *gc.C) {
+	hook := s.hook()
+	hook.Secret = ""
+	err := webhook.Post(http.DefaultClient, hook, testEvent)
+	c.Assert(err, gc.IsNil)
+	req := s.nextRequest(c)
+	c.Assert(req.header.Get(webhook.SignatureHeader), gc.Equals, "")
+}
+
+func (s *senderSuite) TestPostError(c *gc.C) {
+	s.failures = 1
+	err := webhook.Post(http.DefaultClient, s.hook(), testEvent)
+	c.Assert(err, gc.ErrorMatches, `webhook "test" returned 503 Service Unavailable`)
+}
+
+func (s *senderSuite) TestSenderRetries(c *gc.C) {
+	s.failures = 2
+	sender := webhook.NewSenderWithRetry(http.DefaultClient, webhook.Retry{
+		Attempts: 3,
+		Delay:    time.Millisecond,
+	})
+	defer func() { c.Assert(sender.Stop(), gc.IsNil) }()
+
+	sender.Send(s.hook(), testEvent)
+	for i := 0; i < 3; i++ {
+		s.nextRequest(c)
+	}
+	s.assertNoRequest(c)
+}
+
+func (s *senderSuite) TestSenderGivesUp(c *gc.C) {
+	s.failures = 10
+	sender := webhook.NewSenderWithRetry(http.DefaultClient, webhook.Retry{
+		Attempts: 2,
+		Delay:    time.Millisecond,
+	})
+	defer func() { c.Assert(sender.Stop(), gc.IsNil) }()
+
+	sender.Send(s.hook(), testEvent)
+	s.nextRequest(c)
+	s.nextRequest(c)
+	s.assertNoRequest(c)
+	c.Assert(c.GetTestLog(), jc.Contains, `cannot send event for unit mysql/0 to webhook "test"`)
+}
+
+func (s *senderSuite) TestSenderSendsInOrder(c *gc.C) {
+	sender := webhook.NewSender(http.DefaultClient)
+	defer func() { c.Assert(sender.Stop(), gc.IsNil) }()
+
+	for _, status := range []string{"pending", "started", "error"} {
+		event := *testEvent
+		event.Status = status
+		sender.Send(s.hook(), &event)
+	}
+	for _, status := range []string{"pending", "started", "error"} {
+		var event webhook.Event
+		err := json.Unmarshal(s.nextRequest(c).body, &event)
+		c.Assert(err, gc.IsNil)
+		c.Assert(event.Status, gc.Equals, status)
+	}
+}
+
+func (s *senderSuite) TestSenderTimesOut(c *gc.C) {
+	s.release = make(chan struct{})
+	defer close(s.release)
+	sender := webhook.NewSenderWithRetry(http.DefaultClient, webhook.Retry{
+		Attempts: 1,
+		Timeout:  10 * time.Millisecond,
+	})
+	defer func() { c.Assert(sender.Stop(), gc.IsNil) }()
+
+	sender.Send(s.hook(), testEvent)
+	s.nextRequest(c)
+	for a := testing.LongAttempt.Start(); a.Next(); {
+		if strings.Contains(c.GetTestLog(), `webhook "test" did not respond within 10ms`) {
+			return
+		}
+	}
+	c.Fatalf("request not timed out")
+}
+
+func (s *senderSuite) TestSenderStopCancelsRequest(c *gc.C) {
+	s.release = make(chan struct{})
+	defer close(s.release)
+	sender := webhook.NewSender(http.DefaultClient)
+
+	sender.Send(s.hook(), testEvent)
+	s.nextRequest(c)
+	stopped := make(chan error)
+	go func() {
+		stopped <- sender.Stop()
+	}()
+	select {
+	case err := <-stopped:
+		c.Assert(err, gc.IsNil)
+	case <-time.After(testing.LongWait):
+		c.Fatalf("sender did not stop")
+	}
+}
+
+func (s *senderSuite) TestSenderRemove(c *gc.C) {
+	s.failures = 10
+	sender := webhook.NewSenderWithRetry(http.DefaultClient, webhook.Retry{
+		Attempts: 3,
+		Delay:    testing.ShortWait,
+	})
+	defer func() { c.Assert(sender.Stop(), gc.IsNil) }()
+
+	sender.Send(s.hook(), testEvent)
+	s.nextRequest(c)
+	sender.Remove("test")
+	// The failed event is not retried once the webhook is removed.
+	select {
+	case <-s.requests:
+		c.Fatalf("event retried after webhook was removed")
+	case <-time.After(2 * testing.ShortWait):
+	}
+
+	// Events sent to a webhook with the same name are delivered.
+	s.failures = 0
+	sender.Send(s.hook(), testEvent)
+	s.nextRequest(c)
+}
@@ -0,0 +1,236 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The webhook package implements the sending of events about
+// an environment to webhooks: HTTP endpoints to which the
+// events are POSTed as JSON.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SignatureHeader is the HTTP header holding the signature
+// of an event sent to a webhook with a secret.
+const SignatureHeader = "X-Juju-Signature"
+
+// Hook holds the configuration of a webhook.
+type Hook struct {
+	// Name identifies the webhook.
+	Name string
+
+	// URL holds the http or https URL that events are POSTed to.
+	URL string
+
+	// Secret, if not empty, is used to sign the events
+	// sent to the webhook, so that the receiver can
+	// check they were sent by juju.
+	Secret string `json:",omitempty"`
+
+	// Rules holds the rules that select the events sent
+	// to the webhook. If there are none, all events are sent.
+	Rules []Rule `json:",omitempty"`
+}
+
+// Rule matches events about entities of the given kind that
+// change to the given status. Empty fields match anything.
+type Rule struct {
+	Kind   string `json:",omitempty"`
+	Status string `json:",omitempty"`
+}
+
+// ruleKinds holds the kinds of entity that events are sent for.
+var ruleKinds = map[string]bool{
+	"machine": true,
+	"unit":    true,
+}
+
+// ParseRule parses a rule of the form <kind>[:<status>], where
+// either part may be "*" or empty to match anything.
+func ParseRule(s string) (Rule, error) {
+	parts := strings.SplitN(s, ":", 2)
+	var r Rule
+	if kind := parts[0]; kind != "*" {
+		r.Kind = kind
+	}
+	if len(parts) > 1 && parts[1] != "*" {
+		r.Status = parts[1]
+	}
+	if err := r.validate(); err != nil {
+		return Rule{}, err
+	}
+	return r, nil
+}
+
+func (r Rule) validate() error {
+	if r.Kind != "" && !ruleKinds[r.Kind] {
+		return fmt.Errorf("invalid rule: unknown entity kind %q", r.Kind)
+	}
+	return nil
+}
+
+// String returns the rule in the form accepted by ParseRule.
+func (r Rule) String() string {
+	kind, status := r.Kind, r.Status
+	if kind == "" {
+		kind = "*"
+	}
+	if status == "" {
+		return kind
+	}
+	return kind + ":" + status
+}
+
+// Matches reports whether the rule matches the given event.
+func (r Rule) Matches(e *Event) bool {
+	return (r.Kind == "" || r.Kind == e.Kind) &&
+		(r.Status == "" || r.Status == e.Status)
+}
+
+// Matches reports whether the event should be sent to the webhook.
+func (h *Hook) Matches(e *Event) bool {
+	if len(h.Rules) == 0 {
+		return true
+	}
+	for _, r := range h.Rules {
+		if r.Matches(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns an error if the webhook is not valid.
+func (h *Hook) Validate() error {
+	if h.Name == "" {
+		return fmt.Errorf("webhook has no name")
+	}
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook %q: invalid URL %q", h.Name, h.URL)
+	}
+	for _, r := range h.Rules {
+		if err := r.validate(); err != nil {
+			return fmt.Errorf("webhook %q: %v", h.Name, err)
+		}
+	}
+	return nil
+}
+
+// Parse parses a list of webhooks, as held in the environment
+// configuration, checking that they are valid and that their
+// names are unique. The empty string holds no webhooks.
+func Parse(s string) ([]Hook, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var hooks []Hook
+	if err := json.Unmarshal([]byte(s), &hooks); err != nil {
+		return nil, fmt.Errorf("cannot parse webhooks: %v", err)
+	}
+	names := make(map[string]bool)
+	for _, h := range hooks {
+		if err := h.Validate(); err != nil {
+			return nil, err
+		}
+		if names[h.Name] {
+			return nil, fmt.Errorf("duplicate webhook %q", h.Name)
+		}
+		names[h.Name] = true
+	}
+	return hooks, nil
+}
+
+// Format returns the given webhooks in the form accepted by Parse.
+func Format(hooks []Hook) (string, error) {
+	if len(hooks) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(hooks)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// RedactedSecret replaces the secrets of webhooks
+// returned by Redact.
+const RedactedSecret = "<redacted>"
+
+// Redact returns a copy of the given webhooks with their secrets
+// replaced by RedactedSecret, so that they can be shown to users.
+func Redact(hooks []Hook) []Hook {
+	if hooks == nil {
+		return nil
+	}
+	redacted := make([]Hook, len(hooks))
+	for i, h := range hooks {
+		if h.Secret != "" {
+			h.Secret = RedactedSecret
+		}
+		redacted[i] = h
+	}
+	return redacted
+}
+
+// RestoreSecrets returns a copy of the given webhooks in which
+// secrets removed by Redact are restored from the webhooks with
+// the same names in old. It returns an error if a redacted
+// secret cannot be restored.
+func RestoreSecrets(hooks, old []Hook) ([]Hook, error) {
+	if hooks == nil {
+		return nil, nil
+	}
+	secrets := make(map[string]string)
+	for _, h := range old {
+		secrets[h.Name] = h.Secret
+	}
+	restored := make([]Hook, len(hooks))
+	for i, h := range hooks {
+		if h.Secret == RedactedSecret {
+			secret, ok := secrets[h.Name]
+			if !ok || secret == "" {
+				return nil, fmt.Errorf("webhook %q: no secret to restore", h.Name)
+			}
+			h.Secret = secret
+		}
+		restored[i] = h
+	}
+	return restored, nil
+}
+
+// Event holds an event sent to webhooks.
+type Event struct {
+	// Environment holds the name of the environment
+	// the event happened in.
+	Environment string
+
+	// Kind and Id identify the entity that the
+	// event is about, such as "unit" and "mysql/0".
+	Kind string
+	Id   string
+
+	// Status and StatusInfo hold the new status of the entity,
+	// and PreviousStatus its status before the event, if known.
+	Status         string
+	StatusInfo     string `json:",omitempty"`
+	PreviousStatus string `json:",omitempty"`
+
+	// Time holds the time that the event was observed.
+	Time time.Time
+}
+
+// Sign returns the signature of an event body
+// sent to a webhook with the given secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,186 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package webhook_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/testing/testbase"
+	"launchpad.net/juju-core/utils/webhook"
+)
+
+type webhookSuite struct {
+	testbase.LoggingSuite
+}
+
+var _ = gc.Suite(&webhookSuite{})
+
+var parseRuleTests = []struct {
+	rule   string
+	expect webhook.Rule
+	err    string
+}{{
+	rule:   "unit:error",
+	expect: webhook.Rule{Kind: "unit", Status: "error"},
+}, {
+	rule:   "machine",
+	expect: webhook.Rule{Kind: "machine"},
+}, {
+	rule:   "*:error",
+	expect: webhook.Rule{Status: "error"},
+}, {
+	rule:   "machine:*",
+	expect: webhook.Rule{Kind: "machine"},
+}, {
+	rule:   "*",
+	expect: webhook.Rule{},
+}, {
+	rule: "service:error",
+	err:  `invalid rule: unknown entity kind "service"`,
+}}
+
+func (*webhookSuite) TestParseRule(c *gc.C) {
+	for i, test := range parseRuleTests {
+		c.Logf("test %d: %q", i, test.rule)
+		r, err := webhook.ParseRule(test.rule)
+		if test.err != "" {
+			c.Check(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Assert(err, gc.IsNil)
+		c.Check(r, gc.Equals, test.expect)
+		r1, err := webhook.ParseRule(r.String())
+		c.Assert(err, gc.IsNil)
+		c.Check(r1, gc.Equals, r)
+	}
+}
+
+func (*webhookSuite) TestMatches(c *gc.C) {
+	unitError := &webhook.Event{Kind: "unit", Id: "mysql/0", Status: "error"}
+	machineStarted := &webhook.Event{Kind: "machine", Id: "0", Status: "started"}
+
+	hook := webhook.Hook{Name: "all"}
+	c.Check(hook.Matches(unitError), jc.IsTrue)
+	c.Check(hook.Matches(machineStarted), jc.IsTrue)
+
+	hook.Rules = []webhook.Rule{{Kind: "unit", Status: "error"}}
+	c.Check(hook.Matches(unitError), jc.IsTrue)
+	c.Check(hook.Matches(machineStarted), jc.IsFalse)
+
+	hook.Rules = append(hook.Rules, webhook.Rule{Kind: "machine"})
+	c.Check(hook.Matches(unitError), jc.IsTrue)
+	c.Check(hook.Matches(machineStarted), jc.IsTrue)
+
+	hook.Rules = []webhook.Rule{{Status: "started"}}
+	c.Check(hook.Matches(unitError), jc.IsFalse)
+	c.Check(hook.Matches(machineStarted), jc.IsTrue)
+}
+
+var parseTests = []struct {
+	about  string
+	hooks  string
+	expect []webhook.Hook
+	err    string
+}{{
+	about: "no webhooks",
+}, {
+	about: "valid webhooks",
+	hooks: `[{"Name": "a", "URL": "http://a.example.com/"}, {"Name": "b", "URL": "https://b.example.com/hook", "Secret": "x", "Rules": [{"Kind": "unit"}]}]`,
+	expect: []webhook.Hook{{
+		Name: "a",
+		URL:  "http://a.example.com/",
+	}, {
+		Name:   "b",
+		URL:    "https://b.example.com/hook",
+		Secret: "x",
+		Rules:  []webhook.Rule{{Kind: "unit"}},
+	}},
+}, {
+	about: "invalid JSON",
+	hooks: `{`,
+	err:   "cannot parse webhooks: .*",
+}, {
+	about: "no name",
+	hooks: `[{"URL": "http://a.example.com/"}]`,
+	err:   "webhook has no name",
+}, {
+	about: "relative URL",
+	hooks: `[{"Name": "a", "URL": "/hook"}]`,
+	err:   `webhook "a": invalid URL "/hook"`,
+}, {
+	about: "unsupported scheme",
+	hooks: `[{"Name": "a", "URL": "ftp://a.example.com/"}]`,
+	err:   `webhook "a": invalid URL "ftp://a.example.com/"`,
+}, {
+	about: "invalid rule",
+	hooks: `[{"Name": "a", "URL": "http://a.example.com/", "Rules": [{"Kind": "relation"}]}]`,
+	err:   `webhook "a": invalid rule: unknown entity kind "relation"`,
+}, {
+	about: "duplicate names",
+	hooks: `[{"Name": "a", "URL": "http://a.example.com/"}, {"Name": "a", "URL": "http://b.example.com/"}]`,
+	err:   `duplicate webhook "a"`,
+}}
+
+func (*webhookSuite) TestParse(c *gc.C) {
+	for i, test := range parseTests {
+		c.Logf("test %d: %s", i, test.about)
+		hooks, err := webhook.Parse(test.hooks)
+		if test.err != "" {
+			c.Check(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Assert(err, gc.IsNil)
+		c.Check(hooks, jc.DeepEquals, test.expect)
+	}
+}
+
+func (*webhookSuite) TestFormat(c *gc.C) {
+	s, err := webhook.Format(nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s, gc.Equals, "")
+
+	hooks := []webhook.Hook{{
+		Name:   "a",
+		URL:    "http://a.example.com/",
+		Secret: "x",
+		Rules:  []webhook.Rule{{Kind: "unit", Status: "error"}},
+	}}
+	s, err = webhook.Format(hooks)
+	c.Assert(err, gc.IsNil)
+	parsed, err := webhook.Parse(s)
+	c.Assert(err, gc.IsNil)
+	c.Assert(parsed, jc.DeepEquals, hooks)
+}
+
+func (*webhookSuite) TestSign(c *gc.C) {
+	// The expected signature was generated with:
+	// printf 'hello' | openssl dgst -sha256 -hmac secret
+	sig := webhook.Sign("secret", []byte("hello"))
+	c.Assert(sig, gc.Equals, "sha256=88aab3ede8d3adf94d26ab90d3bafd4a2083070c3bcce9c014ee04a443847c0b")
+}
+
+func (*webhookSuite) TestRedact(c *gc.C) {
+	hooks := []webhook.Hook{
+		{Name: "a", URL: "http://a.invalid", Secret: "sekrit"},
+		{Name: "b", URL: "http://b.invalid"},
+	}
+	redacted := webhook.Redact(hooks)
+	c.Assert(redacted, jc.DeepEquals, []webhook.Hook{
+		{Name: "a", URL: "http://a.invalid", Secret: webhook.RedactedSecret},
+		{Name: "b", URL: "http://b.invalid"},
+	})
+	// The original webhooks are unchanged.
+	c.Assert(hooks[0].Secret, gc.Equals, "sekrit")
+
+	restored, err := webhook.RestoreSecrets(redacted, hooks)
+	c.Assert(err, gc.IsNil)
+	c.Assert(restored, jc.DeepEquals, hooks)
+}
+
+func (*webhookSuite) TestRestoreSecretsNotFound(c *gc.C) {
+	hooks := []webhook.Hook{{Name: "a", URL: "http://a.invalid", Secret: webhook.RedactedSecret}}
+	_, err := webhook.RestoreSecrets(hooks, nil)
+	c.Assert(err, gc.ErrorMatches, `webhook "a": no secret to restore`)
+}
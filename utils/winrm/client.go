@@ -0,0 +1,326 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package winrm implements a client for Windows Remote Management,
+// used to run commands on and copy files to Windows machines.
+//
+// The client only supports the HTTPS transport with certificate
+// authentication: the client certificate must be mapped to a local
+// user on the remote machine. The server's certificate is always
+// verified, either with a CA certificate or against the certificate
+// recorded when the server was first connected to.
+package winrm
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"unicode/utf16"
+
+	"github.com/juju/loggo"
+)
+
+var logger = loggo.GetLogger("juju.utils.winrm")
+
+// DefaultPort is the port of the WinRM HTTPS listener.
+const DefaultPort = 5986
+
+// authCertificate is the value of the Authorization header
+// used to request certificate authentication.
+const authCertificate = "http://schemas.dmtf.org/wbem/wsman/1/wsman/secprofile/https/mutual"
+
+// sendChunkSize is the maximum amount of input
+// sent to a command in a single request.
+const sendChunkSize = 32 * 1024
+
+// ClientParams holds the parameters for connecting
+// to a WinRM server.
+type ClientParams struct {
+	// Host is the address of the remote machine.
+	Host string
+
+	// Port is the port of the WinRM HTTPS listener.
+	// If it is zero, DefaultPort is used.
+	Port int
+
+	// Cert and Key hold the PEM-encoded client
+	// certificate and key used to authenticate.
+	Cert []byte
+	Key  []byte
+
+	// CACert holds the PEM-encoded certificate used to
+	// verify the server.
+	CACert []byte
+
+	// KnownHostsFile is the path of the file that records the
+	// certificates of servers, used if CACert is nil. The
+	// server's certificate must match the one recorded for
+	// Host; if there is none, it is recorded, and trusted
+	// from then on.
+	KnownHostsFile string
+}
+
+// Client runs commands on a remote machine via WinRM.
+type Client struct {
+	// endpoint is the address of the WinRM service,
+	// and url is the URL that requests are posted to;
+	// these differ only in scheme when the server's
+	// certificate is checked by knownHosts.
+	endpoint string
+	url      string
+	http     *http.Client
+}
+
+// NewClient returns a client that connects to the
+// WinRM server described by params.
+func NewClient(params ClientParams) (*Client, error) {
+	if params.Host == "" {
+		return nil, fmt.Errorf("no host specified")
+	}
+	port := params.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+	clientCert, err := tls.X509KeyPair(params.Cert, params.Key)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load client certificate: %v", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{clientCert},
+	}
+	addr := net.JoinHostPort(params.Host, strconv.Itoa(port))
+	switch {
+	case params.CACert != nil:
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(params.CACert) {
+			return nil, fmt.Errorf("cannot parse CA certificate")
+		}
+		tlsConfig.RootCAs = pool
+		return &Client{
+			endpoint: fmt.Sprintf("https://%s/wsman", addr),
+			url:      fmt.Sprintf("https://%s/wsman", addr),
+			http: &http.Client{
+				Transport: &http.Transport{TLSClientConfig: tlsConfig},
+			},
+		}, nil
+	case params.KnownHostsFile != "":
+		// The certificate is checked against the recorded one
+		// by knownHosts.dial, which makes the TLS connection
+		// itself, before any request is sent; so the transport
+		// must not add another TLS layer, and requests are
+		// posted to an http URL.
+		tlsConfig.InsecureSkipVerify = true
+		known := &knownHosts{
+			path:   params.KnownHostsFile,
+			host:   params.Host,
+			config: tlsConfig,
+		}
+		return &Client{
+			endpoint: fmt.Sprintf("https://%s/wsman", addr),
+			url:      fmt.Sprintf("http://%s/wsman", addr),
+			http: &http.Client{
+				Transport: &http.Transport{Dial: known.dial},
+			},
+		}, nil
+	}
+	return nil, fmt.Errorf("no CA certificate or known hosts file specified to verify %s", params.Host)
+}
+
+// Run runs the given command with arguments on the remote machine,
+// and returns its exit code. The command's standard input is read
+// from stdin, and its output is written to stdout and stderr; any
+// of these may be nil.
+func (c *Client) Run(command string, args []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	resp, err := c.post(actionCreate, "", createShellBody())
+	if err != nil {
+		return -1, fmt.Errorf("cannot create shell: %v", err)
+	}
+	shellId := resp.shellId()
+	if shellId == "" {
+		return -1, fmt.Errorf("cannot create shell: no shell id returned")
+	}
+	defer func() {
+		if _, err := c.post(actionDelete, shellId, ""); err != nil {
+			logger.Warningf("cannot delete shell %s: %v", shellId, err)
+		}
+	}()
+	resp, err = c.post(actionCommand, shellId, commandBody(command, args))
+	if err != nil {
+		return -1, fmt.Errorf("cannot start command: %v", err)
+	}
+	commandId := resp.Body.CommandId
+	if commandId == "" {
+		return -1, fmt.Errorf("cannot start command: no command id returned")
+	}
+	defer func() {
+		if _, err := c.post(actionSignal, shellId, signalBody(commandId, signalTerminate)); err != nil {
+			logger.Warningf("cannot terminate command %s: %v", commandId, err)
+		}
+	}()
+	if stdin != nil {
+		if err := c.send(shellId, commandId, stdin); err != nil {
+			return -1, fmt.Errorf("cannot send input: %v", err)
+		}
+	}
+	return c.receive(shellId, commandId, stdout, stderr)
+}
+
+// RunPowerShell runs the given PowerShell script on the
+// remote machine, and returns its exit code. The arguments
+// are as for Run.
+func (c *Client) RunPowerShell(script string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	return c.Run("powershell.exe", powerShellArgs(script), stdin, stdout, stderr)
+}
+
+// Copy copies the contents of r to the file with the given
+// path on the remote machine, replacing any existing file.
+func (c *Client) Copy(r io.Reader, remotePath string) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	script := fmt.Sprintf(
+		"$ErrorActionPreference = 'Stop'\n"+
+			"$data = [Convert]::FromBase64String([Console]::In.ReadToEnd())\n"+
+			"[IO.File]::WriteAllBytes('%s', $data)\n",
+		powerShellQuote(remotePath),
+	)
+	input := base64.StdEncoding.EncodeToString(data)
+	var stderr bytes.Buffer
+	code, err := c.RunPowerShell(script, bytes.NewBufferString(input), nil, &stderr)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return fmt.Errorf("cannot write %s: exit status %d (%s)", remotePath, code, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return nil
+}
+
+// send sends the contents of r to the command's standard input.
+func (c *Client) send(shellId, commandId string, r io.Reader) error {
+	buf := make([]byte, sendChunkSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		end := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !end {
+			return err
+		}
+		data := base64.StdEncoding.EncodeToString(buf[:n])
+		if _, err := c.post(actionSend, shellId, sendBody(commandId, data, end)); err != nil {
+			return err
+		}
+		if end {
+			return nil
+		}
+	}
+}
+
+// receive copies the command's output to stdout and stderr
+// until the command finishes, and returns its exit code.
+func (c *Client) receive(shellId, commandId string, stdout, stderr io.Writer) (int, error) {
+	for {
+		resp, err := c.post(actionReceive, shellId, receiveBody(commandId))
+		if fault, ok := err.(*Fault); ok && fault.Code == faultTimedOut {
+			// The command has produced no output yet.
+			continue
+		}
+		if err != nil {
+			return -1, fmt.Errorf("cannot receive output: %v", err)
+		}
+		for _, s := range resp.Body.Streams {
+			var w io.Writer
+			switch s.Name {
+			case "stdout":
+				w = stdout
+			case "stderr":
+				w = stderr
+			}
+			if w == nil || s.Data == "" {
+				continue
+			}
+			data, err := base64.StdEncoding.DecodeString(s.Data)
+			if err != nil {
+				return -1, fmt.Errorf("cannot decode %s: %v", s.Name, err)
+			}
+			if _, err := w.Write(data); err != nil {
+				return -1, err
+			}
+		}
+		if state := resp.Body.CommandState; state != nil && state.State == commandDone {
+			return state.ExitCode, nil
+		}
+	}
+}
+
+// post sends a request with the given action and
+// body to the server, and returns its response.
+func (c *Client) post(action, shellId, body string) (*response, error) {
+	env, err := envelope(c.endpoint, action, shellId, body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", c.url, bytes.NewBufferString(env))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/soap+xml;charset=UTF-8")
+	req.Header.Set("Authorization", authCertificate)
+	httpResp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+	data, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var resp response
+	if len(data) > 0 {
+		if err := xml.Unmarshal(data, &resp); err != nil {
+			if httpResp.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("unexpected response: %s", httpResp.Status)
+			}
+			return nil, fmt.Errorf("cannot parse response: %v", err)
+		}
+	}
+	if resp.Body.Fault != nil {
+		return nil, resp.Body.Fault
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response: %s", httpResp.Status)
+	}
+	return &resp, nil
+}
+
+// powerShellArgs returns the arguments to powershell.exe
+// to run the given script non-interactively. The script
+// is passed encoded, which avoids the need for quoting.
+func powerShellArgs(script string) []string {
+	return []string{"-NoProfile", "-NonInteractive", "-EncodedCommand", EncodePowerShell(script)}
+}
+
+// EncodePowerShell encodes a PowerShell script as expected
+// by the -EncodedCommand argument of powershell.exe.
+func EncodePowerShell(script string) string {
+	var buf bytes.Buffer
+	for _, c := range utf16.Encode([]rune(script)) {
+		binary.Write(&buf, binary.LittleEndian, c)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+// powerShellQuote escapes s for inclusion
+// in a single-quoted PowerShell string.
+func powerShellQuote(s string) string {
+	return string(bytes.Replace([]byte(s), []byte("'"), []byte("''"), -1))
+}
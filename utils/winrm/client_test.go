@@ -0,0 +1,377 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package winrm_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	stdtesting "testing"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/testing/testbase"
+	"launchpad.net/juju-core/utils/winrm"
+)
+
+func Test(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+const envelopeStart = `<s:Envelope` +
+	` xmlns:s="http://www.w3.org/2003/05/soap-envelope"` +
+	` xmlns:a="http://schemas.xmlsoap.org/ws/2004/08/addressing"` +
+	` xmlns:x="http://schemas.xmlsoap.org/ws/2004/09/transfer"` +
+	` xmlns:w="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd"` +
+	` xmlns:rsp="http://schemas.microsoft.com/wbem/wsman/1/windows/shell"><s:Body>`
+
+const envelopeEnd = `</s:Body></s:Envelope>`
+
+// fakeRequest holds the parts of a request that
+// the fake server is interested in.
+type fakeRequest struct {
+	Action  string   `xml:"Header>Action"`
+	ShellId string   `xml:"Header>SelectorSet>Selector"`
+	Command string   `xml:"Body>CommandLine>Command"`
+	Args    []string `xml:"Body>CommandLine>Arguments"`
+	Stdin   struct {
+		End  bool   `xml:"End,attr"`
+		Data string `xml:",chardata"`
+	} `xml:"Body>Send>Stream"`
+}
+
+type fakeCommand struct {
+	Command string
+	Args    []string
+	Stdin   string
+}
+
+// fakeServer is a WinRM server that runs
+// a single fake command in each shell.
+type fakeServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	auth     []string
+	actions  []string
+	commands []fakeCommand
+	receives int
+
+	// The following fields control the
+	// server's responses.
+	stdout     string
+	stderr     string
+	exitCode   int
+	timeouts   int
+	createFail bool
+}
+
+func newFakeServer() *fakeServer {
+	srv := &fakeServer{}
+	srv.Server = httptest.NewTLSServer(http.HandlerFunc(srv.serveHTTP))
+	return srv
+}
+
+func (srv *fakeServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	srv.auth = append(srv.auth, r.Header.Get("Authorization"))
+	var req fakeRequest
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	action := path.Base(req.Action)
+	srv.actions = append(srv.actions, action)
+	var body string
+	switch action {
+	case "Create":
+		if srv.createFail {
+			srv.fault(w, "w:AccessDenied", "Access is denied.")
+			return
+		}
+		body = `<x:ResourceCreated><a:ReferenceParameters><w:SelectorSet>` +
+			`<w:Selector Name="ShellId">shell-0</w:Selector>` +
+			`</w:SelectorSet></a:ReferenceParameters></x:ResourceCreated>`
+	case "Command":
+		srv.commands = append(srv.commands, fakeCommand{
+			Command: req.Command,
+			Args:    req.Args,
+		})
+		body = `<rsp:CommandResponse><rsp:CommandId>command-0</rsp:CommandId></rsp:CommandResponse>`
+	case "Send":
+		data, err := base64.StdEncoding.DecodeString(req.Stdin.Data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		srv.commands[len(srv.commands)-1].Stdin += string(data)
+		body = `<rsp:SendResponse/>`
+	case "Receive":
+		if srv.timeouts > 0 {
+			srv.timeouts--
+			srv.fault(w, "w:TimedOut", "The operation timed out.")
+			return
+		}
+		srv.receives++
+		if srv.receives == 1 {
+			body = `<rsp:ReceiveResponse>` +
+				srv.stream("stdout", srv.stdout, false) +
+				`<rsp:CommandState CommandId="command-0" State="http://schemas.microsoft.com/wbem/wsman/1/windows/shell/CommandState/Running"/>` +
+				`</rsp:ReceiveResponse>`
+		} else {
+			body = fmt.Sprintf(`<rsp:ReceiveResponse>`+
+				srv.stream("stdout", "", true)+
+				srv.stream("stderr", srv.stderr, true)+
+				`<rsp:CommandState CommandId="command-0" State="http://schemas.microsoft.com/wbem/wsman/1/windows/shell/CommandState/Done">`+
+				`<rsp:ExitCode>%d</rsp:ExitCode></rsp:CommandState>`+
+				`</rsp:ReceiveResponse>`, srv.exitCode)
+		}
+	case "Signal":
+		body = `<rsp:SignalResponse/>`
+	case "Delete":
+	default:
+		srv.fault(w, "w:ActionNotSupported", "unknown action")
+		return
+	}
+	fmt.Fprint(w, envelopeStart+body+envelopeEnd)
+}
+
+func (srv *fakeServer) stream(name, data string, end bool) string {
+	return fmt.Sprintf(`<rsp:Stream Name="%s" CommandId="command-0" End="%v">%s</rsp:Stream>`,
+		name, end, base64.StdEncoding.EncodeToString([]byte(data)))
+}
+
+func (srv *fakeServer) fault(w http.ResponseWriter, code, reason string) {
+	w.WriteHeader(http.StatusInternalServerError)
+	fmt.Fprint(w, envelopeStart+
+		`<s:Fault><s:Code><s:Value>s:Receiver</s:Value>`+
+		`<s:Subcode><s:Value>`+code+`</s:Value></s:Subcode></s:Code>`+
+		`<s:Reason><s:Text xml:lang="en-US">`+reason+`</s:Text></s:Reason></s:Fault>`+
+		envelopeEnd)
+}
+
+type clientSuite struct {
+	testbase.LoggingSuite
+	server     *fakeServer
+	host       string
+	port       int
+	knownHosts string
+	client     *winrm.Client
+}
+
+var _ = gc.Suite(&clientSuite{})
+
+var clientCert, clientKey []byte
+
+func (s *clientSuite) SetUpSuite(c *gc.C) {
+	s.LoggingSuite.SetUpSuite(c)
+	var err error
+	clientCert, clientKey, err = winrm.NewClientCert("juju")
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *clientSuite) SetUpTest(c *gc.C) {
+	s.LoggingSuite.SetUpTest(c)
+	s.server = newFakeServer()
+	u, err := url.Parse(s.server.URL)
+	c.Assert(err, gc.IsNil)
+	host, port, err := net.SplitHostPort(u.Host)
+	c.Assert(err, gc.IsNil)
+	s.host = host
+	s.port, err = strconv.Atoi(port)
+	c.Assert(err, gc.IsNil)
+	s.knownHosts = filepath.Join(c.MkDir(), "known_hosts")
+	s.client = s.newClient(c)
+}
+
+func (s *clientSuite) newClient(c *gc.C) *winrm.Client {
+	client, err := winrm.NewClient(winrm.ClientParams{
+		Host:           s.host,
+		Port:           s.port,
+		Cert:           clientCert,
+		Key:            clientKey,
+		KnownHostsFile: s.knownHosts,
+	})
+	c.Assert(err, gc.IsNil)
+	return client
+}
+
+// serverFingerprint returns the fingerprint of
+// the fake server's certificate.
+func (s *clientSuite) serverFingerprint() string {
+	sum := sha256.Sum256(s.server.TLS.Certificates[0].Certificate[0])
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *clientSuite) TearDownTest(c *gc.C) {
+	s.server.Close()
+	s.LoggingSuite.TearDownTest(c)
+}
+
+func (s *clientSuite) TestNewClientErrors(c *gc.C) {
+	_, err := winrm.NewClient(winrm.ClientParams{Cert: clientCert, Key: clientKey})
+	c.Assert(err, gc.ErrorMatches, "no host specified")
+	_, err = winrm.NewClient(winrm.ClientParams{Host: "foo", Cert: clientCert})
+	c.Assert(err, gc.ErrorMatches, "cannot load client certificate: .*")
+	_, err = winrm.NewClient(winrm.ClientParams{
+		Host:   "foo",
+		Cert:   clientCert,
+		Key:    clientKey,
+		CACert: []byte("rubbish"),
+	})
+	c.Assert(err, gc.ErrorMatches, "cannot parse CA certificate")
+	_, err = winrm.NewClient(winrm.ClientParams{Host: "foo", Cert: clientCert, Key: clientKey})
+	c.Assert(err, gc.ErrorMatches, "no CA certificate or known hosts file specified to verify foo")
+}
+
+func (s *clientSuite) TestKnownHostsRecordsCertificate(c *gc.C) {
+	_, err := s.client.Run("hostname", nil, nil, nil, nil)
+	c.Assert(err, gc.IsNil)
+	data, err := ioutil.ReadFile(s.knownHosts)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, s.host+" "+s.serverFingerprint()+"\n")
+
+	// The recorded certificate is accepted by later clients,
+	// and not recorded again.
+	_, err = s.newClient(c).Run("hostname", nil, nil, nil, nil)
+	c.Assert(err, gc.IsNil)
+	again, err := ioutil.ReadFile(s.knownHosts)
+	c.Assert(err, gc.IsNil)
+	c.Assert(again, gc.DeepEquals, data)
+}
+
+func (s *clientSuite) TestKnownHostsMismatch(c *gc.C) {
+	err := ioutil.WriteFile(s.knownHosts, []byte("other.example.com "+s.serverFingerprint()+"\n"+s.host+" 0123abcd\n"), 0600)
+	c.Assert(err, gc.IsNil)
+	_, err = s.client.Run("hostname", nil, nil, nil, nil)
+	c.Assert(err, gc.ErrorMatches, "cannot create shell: .*WinRM server certificate of "+s.host+" does not match the one recorded in .*")
+	// Nothing was sent to the server.
+	c.Assert(s.server.actions, gc.HasLen, 0)
+}
+
+func (s *clientSuite) TestRun(c *gc.C) {
+	s.server.stdout = "hello"
+	s.server.stderr = "oops"
+	s.server.exitCode = 3
+	var stdout, stderr bytes.Buffer
+	code, err := s.client.Run("cmd.exe", []string{"/c", "<echo>"}, strings.NewReader("input"), &stdout, &stderr)
+	c.Assert(err, gc.IsNil)
+	c.Assert(code, gc.Equals, 3)
+	c.Assert(stdout.String(), gc.Equals, "hello")
+	c.Assert(stderr.String(), gc.Equals, "oops")
+	c.Assert(s.server.commands, gc.DeepEquals, []fakeCommand{{
+		Command: "cmd.exe",
+		Args:    []string{"/c", "<echo>"},
+		Stdin:   "input",
+	}})
+	c.Assert(s.server.actions, gc.DeepEquals, []string{
+		"Create", "Command", "Send", "Receive", "Receive", "Signal", "Delete",
+	})
+	for _, auth := range s.server.auth {
+		c.Assert(auth, gc.Equals, "http://schemas.dmtf.org/wbem/wsman/1/wsman/secprofile/https/mutual")
+	}
+}
+
+func (s *clientSuite) TestRunNoInput(c *gc.C) {
+	code, err := s.client.Run("hostname", nil, nil, nil, nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(s.server.actions, gc.DeepEquals, []string{
+		"Create", "Command", "Receive", "Receive", "Signal", "Delete",
+	})
+}
+
+func (s *clientSuite) TestRunLargeInput(c *gc.C) {
+	input := strings.Repeat("x", 100*1024)
+	_, err := s.client.Run("more", nil, strings.NewReader(input), nil, nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.server.commands[0].Stdin, gc.Equals, input)
+	c.Assert(s.server.actions[2:6], gc.DeepEquals, []string{"Send", "Send", "Send", "Send"})
+}
+
+func (s *clientSuite) TestRunRetriesTimedOutReceive(c *gc.C) {
+	s.server.timeouts = 2
+	code, err := s.client.Run("hostname", nil, nil, nil, nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(s.server.actions, gc.DeepEquals, []string{
+		"Create", "Command", "Receive", "Receive", "Receive", "Receive", "Signal", "Delete",
+	})
+}
+
+func (s *clientSuite) TestRunFault(c *gc.C) {
+	s.server.createFail = true
+	_, err := s.client.Run("hostname", nil, nil, nil, nil)
+	c.Assert(err, gc.ErrorMatches, "cannot create shell: winrm fault w:AccessDenied: Access is denied.")
+}
+
+func decodePowerShell(c *gc.C, encoded string) string {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	c.Assert(err, gc.IsNil)
+	c.Assert(len(data)%2, gc.Equals, 0)
+	var script []byte
+	for i := 0; i < len(data); i += 2 {
+		c.Assert(data[i+1], gc.Equals, byte(0))
+		script = append(script, data[i])
+	}
+	return string(script)
+}
+
+func (s *clientSuite) TestEncodePowerShell(c *gc.C) {
+	c.Assert(winrm.EncodePowerShell("ls"), gc.Equals, "bABzAA==")
+}
+
+func (s *clientSuite) TestRunPowerShell(c *gc.C) {
+	_, err := s.client.RunPowerShell(`Write-Host "hello"`, nil, nil, nil)
+	c.Assert(err, gc.IsNil)
+	cmd := s.server.commands[0]
+	c.Assert(cmd.Command, gc.Equals, "powershell.exe")
+	c.Assert(cmd.Args, gc.HasLen, 4)
+	c.Assert(cmd.Args[:3], gc.DeepEquals, []string{"-NoProfile", "-NonInteractive", "-EncodedCommand"})
+	c.Assert(decodePowerShell(c, cmd.Args[3]), gc.Equals, `Write-Host "hello"`)
+}
+
+func (s *clientSuite) TestCopy(c *gc.C) {
+	err := s.client.Copy(strings.NewReader("file contents"), `C:\Juju\it's.txt`)
+	c.Assert(err, gc.IsNil)
+	cmd := s.server.commands[0]
+	script := decodePowerShell(c, cmd.Args[3])
+	c.Assert(script, gc.Matches, `(?s).*WriteAllBytes\('C:\\Juju\\it''s\.txt', \$data\).*`)
+	data, err := base64.StdEncoding.DecodeString(cmd.Stdin)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, "file contents")
+}
+
+func (s *clientSuite) TestCopyFails(c *gc.C) {
+	s.server.exitCode = 1
+	s.server.stderr = "access denied\r\n"
+	err := s.client.Copy(strings.NewReader(""), `C:\foo`)
+	c.Assert(err, gc.ErrorMatches, `cannot write C:\\foo: exit status 1 \(access denied\)`)
+}
+
+func (s *clientSuite) TestCopyReadError(c *gc.C) {
+	err := s.client.Copy(errorReader{}, `C:\foo`)
+	c.Assert(err, gc.ErrorMatches, "read failed")
+	c.Assert(s.server.actions, gc.HasLen, 0)
+}
+
+type errorReader struct{}
+
+func (errorReader) Read([]byte) (int, error) {
+	return 0, fmt.Errorf("read failed")
+}
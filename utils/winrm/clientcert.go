@@ -0,0 +1,209 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package winrm
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"launchpad.net/juju-core/cert"
+	"launchpad.net/juju-core/utils"
+)
+
+const (
+	clientCertName = "client.crt"
+	clientKeyName  = "client.key"
+	caCertName     = "ca.crt"
+	knownHostsName = "known_hosts"
+)
+
+// ClientUser is the name of the user on Windows machines
+// that the generated client certificate is issued for.
+const ClientUser = "juju"
+
+// clientCertExpiry is the validity period of
+// generated client certificates.
+const clientCertExpiry = 10 * 365 * 24 * time.Hour
+
+// ClientCert holds the PEM-encoded certificates
+// and key used to connect to WinRM servers, and
+// the path of the file that records the certificates
+// of servers not verified with CACert.
+type ClientCert struct {
+	Cert           []byte
+	Key            []byte
+	CACert         []byte
+	KnownHostsFile string
+}
+
+// Params returns the parameters for connecting
+// to the WinRM server on the given host with
+// the certificates in c.
+func (c *ClientCert) Params(host string) ClientParams {
+	return ClientParams{
+		Host:           host,
+		Cert:           c.Cert,
+		Key:            c.Key,
+		CACert:         c.CACert,
+		KnownHostsFile: c.KnownHostsFile,
+	}
+}
+
+// LoadClientCert loads the WinRM client certificate and key from
+// the specified directory. If the directory does not exist, or does
+// not contain a certificate, a new self-signed certificate is
+// generated there, issued for ClientUser. If the directory contains
+// a CA certificate, it is used to verify servers; otherwise the
+// certificates of servers are recorded in the directory when first
+// seen, and must not change.
+func LoadClientCert(dir string) (*ClientCert, error) {
+	dir, err := utils.NormalizePath(dir)
+	if err != nil {
+		return nil, err
+	}
+	certFile := filepath.Join(dir, clientCertName)
+	keyFile := filepath.Join(dir, clientKeyName)
+	var result ClientCert
+	result.Cert, err = ioutil.ReadFile(certFile)
+	if os.IsNotExist(err) {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, err
+		}
+		result.Cert, result.Key, err = NewClientCert(ClientUser)
+		if err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(certFile, result.Cert, 0600); err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(keyFile, result.Key, 0600); err != nil {
+			os.Remove(certFile)
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	} else {
+		result.Key, err = ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		if _, _, err := cert.ParseCertAndKey(result.Cert, result.Key); err != nil {
+			return nil, fmt.Errorf("parsing client certificate in %q: %v", dir, err)
+		}
+	}
+	result.CACert, err = ioutil.ReadFile(filepath.Join(dir, caCertName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	result.KnownHostsFile = filepath.Join(dir, knownHostsName)
+	return &result, nil
+}
+
+// Object identifiers of the subject alternative name extension,
+// and of the Microsoft user principal name within its otherName.
+var (
+	oidUPN            = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 20, 2, 3}
+	oidSubjectAltName = asn1.ObjectIdentifier{2, 5, 29, 17}
+)
+
+// NewClientCert generates a self-signed certificate and key
+// suitable for authenticating to WinRM as the given local user.
+// WinRM maps certificates to users by the user principal name
+// in the subject alternative name, which is user@localhost.
+func NewClientCert(user string) (certPEM, keyPEM []byte, err error) {
+	key, err := rsa.GenerateKey(rand.Reader, cert.KeyBits)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot generate key: %v", err)
+	}
+	altName, err := upnAltName(user + "@localhost")
+	if err != nil {
+		return nil, nil, err
+	}
+	h := sha1.New()
+	h.Write(key.N.Bytes())
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: new(big.Int),
+		Subject: pkix.Name{
+			CommonName:   user,
+			Organization: []string{"juju"},
+		},
+		NotBefore:    now.UTC().Add(-5 * time.Minute),
+		NotAfter:     now.UTC().Add(clientCertExpiry),
+		SubjectKeyId: h.Sum(nil),
+		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		ExtraExtensions: []pkix.Extension{{
+			Id:    oidSubjectAltName,
+			Value: altName,
+		}},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: certDER,
+	})
+	keyPEM = pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+	return certPEM, keyPEM, nil
+}
+
+// upnAltName returns the DER encoding of a subject
+// alternative name holding the given user principal name.
+func upnAltName(upn string) ([]byte, error) {
+	typeId, err := asn1.Marshal(oidUPN)
+	if err != nil {
+		return nil, err
+	}
+	name, err := asn1.Marshal(asn1.RawValue{
+		Class: asn1.ClassUniversal,
+		Tag:   asn1.TagUTF8String,
+		Bytes: []byte(upn),
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The value is explicitly tagged [0].
+	value, err := asn1.Marshal(asn1.RawValue{
+		Class:      asn1.ClassContextSpecific,
+		Tag:        0,
+		IsCompound: true,
+		Bytes:      name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The otherName choice of GeneralName is implicitly tagged [0].
+	otherName, err := asn1.Marshal(asn1.RawValue{
+		Class:      asn1.ClassContextSpecific,
+		Tag:        0,
+		IsCompound: true,
+		Bytes:      append(typeId, value...),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(asn1.RawValue{
+		Class:      asn1.ClassUniversal,
+		Tag:        asn1.TagSequence,
+		IsCompound: true,
+		Bytes:      otherName,
+	})
+}
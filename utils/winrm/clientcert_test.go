@@ -0,0 +1,83 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package winrm_test
+
+import (
+	"bytes"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/cert"
+	"launchpad.net/juju-core/testing/testbase"
+	"launchpad.net/juju-core/utils/winrm"
+)
+
+type clientCertSuite struct {
+	testbase.LoggingSuite
+}
+
+var _ = gc.Suite(&clientCertSuite{})
+
+func (s *clientCertSuite) TestNewClientCert(c *gc.C) {
+	certPEM, keyPEM, err := winrm.NewClientCert("someone")
+	c.Assert(err, gc.IsNil)
+	clientCert, _, err := cert.ParseCertAndKey(certPEM, keyPEM)
+	c.Assert(err, gc.IsNil)
+	c.Assert(clientCert.Subject.CommonName, gc.Equals, "someone")
+	c.Assert(clientCert.ExtKeyUsage, gc.DeepEquals, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth})
+	var found bool
+	for _, ext := range clientCert.Extensions {
+		if ext.Id.String() == "2.5.29.17" {
+			found = bytes.Contains(ext.Value, []byte("someone@localhost"))
+		}
+	}
+	c.Assert(found, gc.Equals, true)
+}
+
+func (s *clientCertSuite) TestLoadClientCertGenerates(c *gc.C) {
+	dir := filepath.Join(c.MkDir(), "winrm")
+	clientCert, err := winrm.LoadClientCert(dir)
+	c.Assert(err, gc.IsNil)
+	c.Assert(clientCert.CACert, gc.IsNil)
+	parsed, _, err := cert.ParseCertAndKey(clientCert.Cert, clientCert.Key)
+	c.Assert(err, gc.IsNil)
+	c.Assert(parsed.Subject.CommonName, gc.Equals, winrm.ClientUser)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "client.crt"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(data, gc.DeepEquals, clientCert.Cert)
+	info, err := os.Stat(filepath.Join(dir, "client.key"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Mode().Perm(), gc.Equals, os.FileMode(0600))
+
+	// Loading again returns the same certificate.
+	again, err := winrm.LoadClientCert(dir)
+	c.Assert(err, gc.IsNil)
+	c.Assert(again, gc.DeepEquals, clientCert)
+}
+
+func (s *clientCertSuite) TestLoadClientCertWithCA(c *gc.C) {
+	dir := c.MkDir()
+	_, err := winrm.LoadClientCert(dir)
+	c.Assert(err, gc.IsNil)
+	err = ioutil.WriteFile(filepath.Join(dir, "ca.crt"), []byte("ca cert"), 0644)
+	c.Assert(err, gc.IsNil)
+	clientCert, err := winrm.LoadClientCert(dir)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(clientCert.CACert), gc.Equals, "ca cert")
+}
+
+func (s *clientCertSuite) TestLoadClientCertInvalid(c *gc.C) {
+	dir := c.MkDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "client.crt"), []byte("rubbish"), 0600)
+	c.Assert(err, gc.IsNil)
+	err = ioutil.WriteFile(filepath.Join(dir, "client.key"), []byte("rubbish"), 0600)
+	c.Assert(err, gc.IsNil)
+	_, err = winrm.LoadClientCert(dir)
+	c.Assert(err, gc.ErrorMatches, `parsing client certificate in ".*": .*`)
+}
@@ -0,0 +1,89 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package winrm
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// knownHostsMutex serialises updates to known hosts files.
+var knownHostsMutex sync.Mutex
+
+// knownHosts pins the certificates of WinRM servers that
+// are not verified with a CA certificate. The fingerprint
+// of a server's certificate is recorded in a file the first
+// time the server is connected to, and the certificate must
+// match it from then on, as with ssh's known_hosts file.
+type knownHosts struct {
+	path   string
+	host   string
+	config *tls.Config
+}
+
+// dial connects to the server at addr, and checks its certificate
+// against the fingerprint recorded for the host, or records it if
+// there is none.
+func (k *knownHosts) dial(network, addr string) (net.Conn, error) {
+	conn, err := tls.Dial(network, addr, k.config)
+	if err != nil {
+		return nil, err
+	}
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		conn.Close()
+		return nil, fmt.Errorf("WinRM server %s presented no certificate", k.host)
+	}
+	sum := sha256.Sum256(certs[0].Raw)
+	if err := k.check(hex.EncodeToString(sum[:])); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// check checks the given certificate fingerprint against the
+// one recorded for the host, recording it if there is none.
+func (k *knownHosts) check(fingerprint string) error {
+	knownHostsMutex.Lock()
+	defer knownHostsMutex.Unlock()
+	data, err := ioutil.ReadFile(k.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[0] != k.host {
+			continue
+		}
+		if fields[1] != fingerprint {
+			return fmt.Errorf(
+				"WinRM server certificate of %s does not match the one recorded in %s "+
+					"(got fingerprint %s); if the machine has been reinstalled, remove its entry",
+				k.host, k.path, fingerprint,
+			)
+		}
+		return nil
+	}
+	f, err := os.OpenFile(k.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintf(f, "%s %s\n", k.host, fingerprint); err != nil {
+		return err
+	}
+	logger.Infof("recorded WinRM server certificate of %s in %s (fingerprint %s)", k.host, k.path, fingerprint)
+	return nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package winrm
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"launchpad.net/juju-core/utils"
+)
+
+// WS-Management actions and URIs used by the client.
+const (
+	resourceURI = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/cmd"
+
+	actionCreate  = "http://schemas.xmlsoap.org/ws/2004/09/transfer/Create"
+	actionDelete  = "http://schemas.xmlsoap.org/ws/2004/09/transfer/Delete"
+	actionCommand = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/Command"
+	actionSend    = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/Send"
+	actionReceive = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/Receive"
+	actionSignal  = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/Signal"
+
+	signalTerminate = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/signal/terminate"
+	commandDone     = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/CommandState/Done"
+
+	// faultTimedOut is the fault subcode returned when
+	// a Receive request times out without output.
+	faultTimedOut = "w:TimedOut"
+)
+
+// maxEnvelopeSize is the maximum size of a response envelope,
+// as advertised to the server in each request.
+const maxEnvelopeSize = 153600
+
+const envelopeTemplate = `<s:Envelope` +
+	` xmlns:s="http://www.w3.org/2003/05/soap-envelope"` +
+	` xmlns:a="http://schemas.xmlsoap.org/ws/2004/08/addressing"` +
+	` xmlns:w="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd"` +
+	` xmlns:rsp="http://schemas.microsoft.com/wbem/wsman/1/windows/shell">` +
+	`<s:Header>` +
+	`<a:To>%s</a:To>` +
+	`<a:ReplyTo><a:Address s:mustUnderstand="true">http://schemas.xmlsoap.org/ws/2004/08/addressing/role/anonymous</a:Address></a:ReplyTo>` +
+	`<a:Action s:mustUnderstand="true">%s</a:Action>` +
+	`<a:MessageID>uuid:%s</a:MessageID>` +
+	`<w:ResourceURI s:mustUnderstand="true">` + resourceURI + `</w:ResourceURI>` +
+	`<w:MaxEnvelopeSize s:mustUnderstand="true">%d</w:MaxEnvelopeSize>` +
+	`<w:OperationTimeout>PT60S</w:OperationTimeout>` +
+	`%s` +
+	`</s:Header>` +
+	`<s:Body>%s</s:Body>` +
+	`</s:Envelope>`
+
+// envelope returns a SOAP envelope for the given action, addressed
+// to the shell with the given id if it is non-empty.
+func envelope(endpoint, action, shellId, body string) (string, error) {
+	uuid, err := utils.NewUUID()
+	if err != nil {
+		return "", err
+	}
+	var selector string
+	if shellId != "" {
+		selector = `<w:SelectorSet><w:Selector Name="ShellId">` + escape(shellId) + `</w:Selector></w:SelectorSet>`
+	}
+	return fmt.Sprintf(envelopeTemplate,
+		escape(endpoint), action, uuid.String(), maxEnvelopeSize, selector, body,
+	), nil
+}
+
+// escape returns s with XML special characters escaped.
+func escape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+func createShellBody() string {
+	return `<rsp:Shell>` +
+		`<rsp:InputStreams>stdin</rsp:InputStreams>` +
+		`<rsp:OutputStreams>stdout stderr</rsp:OutputStreams>` +
+		`</rsp:Shell>`
+}
+
+func commandBody(command string, args []string) string {
+	var body bytes.Buffer
+	body.WriteString(`<rsp:CommandLine><rsp:Command>`)
+	body.WriteString(escape(command))
+	body.WriteString(`</rsp:Command>`)
+	for _, arg := range args {
+		body.WriteString(`<rsp:Arguments>`)
+		body.WriteString(escape(arg))
+		body.WriteString(`</rsp:Arguments>`)
+	}
+	body.WriteString(`</rsp:CommandLine>`)
+	return body.String()
+}
+
+func sendBody(commandId, data string, end bool) string {
+	endAttr := ""
+	if end {
+		endAttr = ` End="true"`
+	}
+	return fmt.Sprintf(`<rsp:Send><rsp:Stream Name="stdin" CommandId="%s"%s>%s</rsp:Stream></rsp:Send>`,
+		escape(commandId), endAttr, data)
+}
+
+func receiveBody(commandId string) string {
+	return fmt.Sprintf(`<rsp:Receive><rsp:DesiredStream CommandId="%s">stdout stderr</rsp:DesiredStream></rsp:Receive>`,
+		escape(commandId))
+}
+
+func signalBody(commandId, code string) string {
+	return fmt.Sprintf(`<rsp:Signal CommandId="%s"><rsp:Code>%s</rsp:Code></rsp:Signal>`,
+		escape(commandId), code)
+}
+
+// response holds the parts of a response envelope
+// that the client is interested in.
+type response struct {
+	Body struct {
+		Selectors    []selector    `xml:"ResourceCreated>ReferenceParameters>SelectorSet>Selector"`
+		ShellId      string        `xml:"Shell>ShellId"`
+		CommandId    string        `xml:"CommandResponse>CommandId"`
+		Streams      []stream      `xml:"ReceiveResponse>Stream"`
+		CommandState *commandState `xml:"ReceiveResponse>CommandState"`
+		Fault        *Fault        `xml:"Fault"`
+	}
+}
+
+type selector struct {
+	Name  string `xml:"Name,attr"`
+	Value string `xml:",chardata"`
+}
+
+type stream struct {
+	Name string `xml:"Name,attr"`
+	End  bool   `xml:"End,attr"`
+	Data string `xml:",chardata"`
+}
+
+type commandState struct {
+	State    string `xml:"State,attr"`
+	ExitCode int    `xml:"ExitCode"`
+}
+
+// Fault holds a SOAP fault returned by the server.
+type Fault struct {
+	Code   string `xml:"Code>Subcode>Value"`
+	Reason string `xml:"Reason>Text"`
+}
+
+func (f *Fault) Error() string {
+	return fmt.Sprintf("winrm fault %s: %s", f.Code, strings.TrimSpace(f.Reason))
+}
+
+// shellId returns the id of the shell created by a Create request.
+func (r *response) shellId() string {
+	for _, s := range r.Body.Selectors {
+		if s.Name == "ShellId" {
+			return s.Value
+		}
+	}
+	return r.Body.ShellId
+}
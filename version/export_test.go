@@ -6,4 +6,6 @@ package version
 var (
 	ReadSeries        = readSeries
 	LSBReleaseFileVar = &lsbReleaseFile
+
+	WindowsSeriesForProduct = windowsSeriesForProduct
 )
@@ -0,0 +1,66 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package version
+
+import (
+	"sort"
+	"strings"
+)
+
+// windowsVersions maps the names of Windows products, as reported
+// by Win32_OperatingSystem, to the series juju uses for them. Products
+// come in various editions (Standard, Datacenter etc), so names are
+// matched by prefix; a name must precede any name that is a prefix
+// of it, so that "2012 R2" is matched before "2012".
+var windowsVersions = []struct {
+	name   string
+	series string
+}{
+	{"Microsoft Hyper-V Server 2012 R2", "win2012hvr2"},
+	{"Microsoft Hyper-V Server 2012", "win2012hv"},
+	{"Microsoft Windows Server 2012 R2", "win2012r2"},
+	{"Microsoft Windows Server 2012", "win2012"},
+	{"Windows Storage Server 2012 R2", "win2012r2"},
+	{"Windows Storage Server 2012", "win2012"},
+	{"Microsoft Windows 8.1", "win81"},
+	{"Microsoft Windows 8", "win8"},
+	{"Microsoft Windows 7", "win7"},
+}
+
+// windowsSeriesForProduct returns the series of the
+// Windows product with the given name, or "unknown".
+func windowsSeriesForProduct(name string) string {
+	name = strings.TrimSpace(name)
+	for _, v := range windowsVersions {
+		if strings.HasPrefix(name, v.name) {
+			return v.series
+		}
+	}
+	return "unknown"
+}
+
+// IsWindowsSeries reports whether the given
+// series is one of the known Windows series.
+func IsWindowsSeries(series string) bool {
+	for _, v := range windowsVersions {
+		if v.series == series {
+			return true
+		}
+	}
+	return false
+}
+
+// WindowsSeries returns the known Windows series, sorted.
+func WindowsSeries() []string {
+	seen := make(map[string]bool)
+	var series []string
+	for _, v := range windowsVersions {
+		if !seen[v.series] {
+			seen[v.series] = true
+			series = append(series, v.series)
+		}
+	}
+	sort.Strings(series)
+	return series
+}
@@ -0,0 +1,55 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package version_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/testing/testbase"
+	"launchpad.net/juju-core/version"
+)
+
+type seriesSuite struct {
+	testbase.LoggingSuite
+}
+
+var _ = gc.Suite(&seriesSuite{})
+
+var windowsSeriesForProductTests = []struct {
+	name   string
+	series string
+}{
+	{"Microsoft Windows Server 2012 R2 Standard", "win2012r2"},
+	{"Microsoft Windows Server 2012 Datacenter", "win2012"},
+	{"Microsoft Hyper-V Server 2012 R2", "win2012hvr2"},
+	{"Microsoft Hyper-V Server 2012\r\n", "win2012hv"},
+	{"Windows Storage Server 2012 R2 Workgroup", "win2012r2"},
+	{"Microsoft Windows 8.1 Pro", "win81"},
+	{"Microsoft Windows 8 Enterprise", "win8"},
+	{"Microsoft Windows 7 Ultimate", "win7"},
+	{"Microsoft Windows XP", "unknown"},
+	{"", "unknown"},
+}
+
+func (*seriesSuite) TestWindowsSeriesForProduct(c *gc.C) {
+	for i, t := range windowsSeriesForProductTests {
+		c.Logf("test %d: %q", i, t.name)
+		c.Check(version.WindowsSeriesForProduct(t.name), gc.Equals, t.series)
+	}
+}
+
+func (*seriesSuite) TestIsWindowsSeries(c *gc.C) {
+	for _, series := range version.WindowsSeries() {
+		c.Check(version.IsWindowsSeries(series), gc.Equals, true)
+	}
+	for _, series := range []string{"precise", "trusty", "win", "windows", ""} {
+		c.Check(version.IsWindowsSeries(series), gc.Equals, false)
+	}
+}
+
+func (*seriesSuite) TestWindowsSeries(c *gc.C) {
+	c.Assert(version.WindowsSeries(), gc.DeepEquals, []string{
+		"win2012", "win2012hv", "win2012hvr2", "win2012r2", "win7", "win8", "win81",
+	})
+}
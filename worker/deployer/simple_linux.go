@@ -93,6 +93,7 @@ func (ctx *SimpleContext) DeployUnit(unitName, initialPassword string) (err erro
 			LogDir:            logDir,
 			UpgradedToVersion: version.Current.Number,
 			Tag:               tag,
+			EnvironTag:        ctx.agentConfig.EnvironTag(),
 			Password:          initialPassword,
 			Nonce:             "unused",
 			// TODO: remove the state addresses here and test when api only.
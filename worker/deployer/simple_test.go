@@ -240,6 +240,7 @@ func (fix *SimpleToolsFixture) checkUnitInstalled(c *gc.C, name, password string
 	c.Assert(err, gc.IsNil)
 	c.Assert(conf.Tag(), gc.Equals, tag)
 	c.Assert(conf.DataDir(), gc.Equals, fix.dataDir)
+	c.Assert(conf.EnvironTag(), gc.Equals, testEnvironTag)
 
 	jujudData, err := ioutil.ReadFile(jujudPath)
 	c.Assert(err, gc.IsNil)
@@ -302,6 +303,10 @@ func (mock *mockConfig) WriteUpgradedToVersion(newVersion version.Number) error
 	return nil
 }
 
+func (mock *mockConfig) EnvironTag() string {
+	return testEnvironTag
+}
+
 func (mock *mockConfig) CACert() []byte {
 	return []byte(testing.CACert)
 }
@@ -310,6 +315,10 @@ func (mock *mockConfig) Value(_ string) string {
 	return ""
 }
 
+// testEnvironTag holds the environment tag of the machine agent
+// running the deployer, which its unit agents are given.
+const testEnvironTag = "environment-0fa6ab96-7a5c-4c3b-9d0c-4ea1c8a0e7e2"
+
 func agentConfig(tag, datadir, logdir string) agent.Config {
 	return &mockConfig{tag: tag, datadir: datadir, logdir: logdir}
 }
@@ -0,0 +1,16 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package hostedenvironments
+
+import (
+	"time"
+)
+
+func SetInterval(i time.Duration) {
+	interval = i
+}
+
+func RestoreInterval() {
+	interval = defaultInterval
+}
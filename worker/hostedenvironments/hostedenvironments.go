@@ -0,0 +1,133 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package hostedenvironments
+
+import (
+	"time"
+
+	"github.com/juju/loggo"
+	"launchpad.net/tomb"
+
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.hostedenvironments")
+
+// defaultInterval is the standard value for the interval setting.
+const defaultInterval = 10 * time.Second
+
+// interval sets how often the hosted environments are listed. The
+// registry of hosted environments is not changed in transactions, so
+// it cannot be watched.
+var interval = defaultInterval
+
+// StartFunc starts the workers that manage the hosted environment
+// with the given UUID.
+type StartFunc func(uuid string) (worker.Worker, error)
+
+// Worker runs the workers that manage each environment hosted by a
+// state server, from the time the environment is created until it is
+// removed. A failed environment's workers are restarted without
+// affecting those of other environments.
+type Worker struct {
+	tomb   tomb.Tomb
+	st     *state.State
+	start  StartFunc
+	runner worker.Runner
+}
+
+// NewWorker returns a worker that starts the workers of each
+// environment hosted by the state server's environment held by st.
+func NewWorker(st *state.State, start StartFunc) *Worker {
+	w := &Worker{
+		st:    st,
+		start: start,
+		runner: worker.NewRunner(
+			func(error) bool { return false },
+			func(err0, err1 error) bool { return true },
+		),
+	}
+	go func() {
+		defer w.tomb.Done()
+		w.tomb.Kill(w.loop())
+	}()
+	return w
+}
+
+func (w *Worker) String() string {
+	return "hostedenvironments"
+}
+
+func (w *Worker) Kill() {
+	w.tomb.Kill(nil)
+}
+
+func (w *Worker) Stop() error {
+	w.tomb.Kill(nil)
+	return w.tomb.Wait()
+}
+
+func (w *Worker) Wait() error {
+	return w.tomb.Wait()
+}
+
+func (w *Worker) loop() error {
+	defer func() {
+		if err := worker.Stop(w.runner); err != nil {
+			logger.Errorf("error stopping hosted environment workers: %v", err)
+		}
+	}()
+	running := make(map[string]bool)
+	delay := time.Duration(0)
+	for {
+		select {
+		case <-w.tomb.Dying():
+			return tomb.ErrDying
+		case <-time.After(delay):
+			if err := w.update(running); err != nil {
+				return err
+			}
+			delay = interval
+		}
+	}
+}
+
+// update starts the workers of the hosted environments that are not
+// yet running, and stops those of the environments that have been
+// removed. It records the environments whose workers are running in
+// running.
+func (w *Worker) update(running map[string]bool) error {
+	envs, err := w.st.AllHostedEnvironments()
+	if err != nil {
+		return err
+	}
+	hosted := make(map[string]bool)
+	for _, env := range envs {
+		uuid := env.UUID()
+		hosted[uuid] = true
+		if running[uuid] {
+			continue
+		}
+		logger.Infof("starting workers for hosted environment %q", env.Name())
+		err := w.runner.StartWorker(uuid, func() (worker.Worker, error) {
+			return w.start(uuid)
+		})
+		if err != nil {
+			return err
+		}
+		running[uuid] = true
+	}
+	for uuid := range running {
+		if hosted[uuid] {
+			continue
+		}
+		logger.Infof("stopping workers for removed environment %s", uuid)
+		if err := w.runner.StopWorker(uuid); err != nil {
+			return err
+		}
+		delete(running, uuid)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package hostedenvironments_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	coretesting "launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/worker"
+	"launchpad.net/juju-core/worker/hostedenvironments"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type WorkerSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&WorkerSuite{})
+
+func (s *WorkerSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	hostedenvironments.SetInterval(10 * time.Millisecond)
+	s.AddCleanup(func(*gc.C) { hostedenvironments.RestoreInterval() })
+}
+
+func (s *WorkerSuite) addHosted(c *gc.C, name string) *state.HostedEnvironment {
+	cfg := coretesting.CustomEnvironConfig(c, coretesting.Attrs{"name": name})
+	env, err := s.State.AddHostedEnvironment(cfg, "user-admin", "hosted-secret")
+	c.Assert(err, gc.IsNil)
+	return env
+}
+
+// startRecorder records the environments whose workers are started
+// and stopped.
+type startRecorder struct {
+	started chan string
+	stopped chan string
+}
+
+func (r *startRecorder) start(uuid string) (worker.Worker, error) {
+	r.started <- uuid
+	return worker.NewSimpleWorker(func(stop <-chan struct{}) error {
+		<-stop
+		r.stopped <- uuid
+		return nil
+	}), nil
+}
+
+func (r *startRecorder) assertEvent(c *gc.C, events chan string, uuid string) {
+	select {
+	case got := <-events:
+		c.Assert(got, gc.Equals, uuid)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for environment %s", uuid)
+	}
+}
+
+func (r *startRecorder) assertNoEvent(c *gc.C) {
+	select {
+	case uuid := <-r.started:
+		c.Fatalf("unexpected start of environment %s", uuid)
+	case uuid := <-r.stopped:
+		c.Fatalf("unexpected stop of environment %s", uuid)
+	case <-time.After(coretesting.ShortWait):
+	}
+}
+
+func (s *WorkerSuite) TestStartsAndStopsEnvironmentWorkers(c *gc.C) {
+	first := s.addHosted(c, "first")
+	r := &startRecorder{
+		started: make(chan string, 10),
+		stopped: make(chan string, 10),
+	}
+	w := hostedenvironments.NewWorker(s.State, r.start)
+	defer func() { c.Assert(w.Stop(), gc.IsNil) }()
+	r.assertEvent(c, r.started, first.UUID())
+	r.assertNoEvent(c)
+
+	second := s.addHosted(c, "second")
+	r.assertEvent(c, r.started, second.UUID())
+	r.assertNoEvent(c)
+
+	hst, err := s.State.OpenHostedEnvironment(first.UUID())
+	c.Assert(err, gc.IsNil)
+	defer hst.Close()
+	env, err := hst.Environment()
+	c.Assert(err, gc.IsNil)
+	c.Assert(env.Destroy(), gc.IsNil)
+	c.Assert(first.Remove(), gc.IsNil)
+	r.assertEvent(c, r.stopped, first.UUID())
+	r.assertNoEvent(c)
+
+	c.Assert(w.Stop(), gc.IsNil)
+	r.assertEvent(c, r.stopped, second.UUID())
+}
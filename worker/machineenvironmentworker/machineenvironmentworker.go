@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path"
+	"runtime"
 
 	"github.com/juju/loggo"
 
@@ -42,9 +43,10 @@ var (
 // changes are apt proxy configuration and the juju proxies stored in the juju
 // proxy file.
 type MachineEnvironmentWorker struct {
-	api      *environment.Facade
-	aptProxy osenv.ProxySettings
-	proxy    osenv.ProxySettings
+	api       *environment.Facade
+	aptProxy  osenv.ProxySettings
+	aptMirror string
+	proxy     osenv.ProxySettings
 
 	writeSystemFiles bool
 	// The whole point of the first value is to make sure that the the files
@@ -64,9 +66,12 @@ var _ worker.NotifyWatchHandler = (*MachineEnvironmentWorker)(nil)
 // watcher returned from the setup.
 func NewMachineEnvironmentWorker(api *environment.Facade, agentConfig agent.Config) worker.Worker {
 	// We don't write out system files for the local provider on machine zero
-	// as that is the host machine.
-	writeSystemFiles := (agentConfig.Tag() != names.MachineTag("0") ||
-		agentConfig.Value(agent.ProviderType) != provider.Local)
+	// as that is the host machine. Windows machines have neither apt nor
+	// the ubuntu user, so their proxy settings are only applied through
+	// SetEnvironmentValues.
+	writeSystemFiles := runtime.GOOS != "windows" &&
+		(agentConfig.Tag() != names.MachineTag("0") ||
+			agentConfig.Value(agent.ProviderType) != provider.Local)
 	logger.Debugf("write system files: %v", writeSystemFiles)
 	envWorker := &MachineEnvironmentWorker{
 		api:              api,
@@ -139,6 +144,34 @@ func (w *MachineEnvironmentWorker) handleAptProxyValues(aptSettings osenv.ProxyS
 	}
 }
 
+// handleAptMirrorValue points the apt sources of the machine at the
+// given mirror. Clearing the mirror leaves the sources as they are, as
+// the original archive is not known.
+func (w *MachineEnvironmentWorker) handleAptMirrorValue(aptMirror string) {
+	if !w.writeSystemFiles || aptMirror == w.aptMirror {
+		return
+	}
+	logger.Debugf("new apt mirror %q", aptMirror)
+	w.aptMirror = aptMirror
+	if aptMirror == "" {
+		logger.Infof("apt mirror cleared, leaving apt sources unchanged")
+		return
+	}
+	content, err := ioutil.ReadFile(utils.AptSourcesFile)
+	if err != nil {
+		// It isn't really fatal, but we should record it.
+		logger.Errorf("error reading apt sources: %v", err)
+		return
+	}
+	newContent := utils.AptSourcesWithMirror(string(content), aptMirror)
+	if newContent == string(content) {
+		return
+	}
+	if err := ioutil.WriteFile(utils.AptSourcesFile, []byte(newContent), 0644); err != nil {
+		logger.Errorf("error writing apt sources: %v", err)
+	}
+}
+
 func (w *MachineEnvironmentWorker) onChange() error {
 	env, err := w.api.EnvironConfig()
 	if err != nil {
@@ -146,6 +179,7 @@ func (w *MachineEnvironmentWorker) onChange() error {
 	}
 	w.handleProxyValues(env.ProxySettings())
 	w.handleAptProxyValues(env.AptProxySettings())
+	w.handleAptMirrorValue(env.AptMirror())
 	return nil
 }
 
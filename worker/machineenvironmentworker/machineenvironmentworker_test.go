@@ -56,6 +56,7 @@ func (s *MachineEnvironmentWatcherSuite) SetUpTest(c *gc.C) {
 	s.started = false
 	s.PatchValue(&machineenvironmentworker.Started, s.setStarted)
 	s.PatchValue(&utils.AptConfFile, path.Join(proxyDir, "juju-apt-proxy"))
+	s.PatchValue(&utils.AptSourcesFile, path.Join(proxyDir, "sources.list"))
 	s.proxyFile = path.Join(proxyDir, machineenvironmentworker.ProxyFile)
 }
 
@@ -201,6 +202,31 @@ func (s *MachineEnvironmentWatcherSuite) TestInitialStateLocalMachine0(c *gc.C)
 	c.Assert(s.proxyFile, jc.DoesNotExist)
 }
 
+func (s *MachineEnvironmentWatcherSuite) TestAptMirror(c *gc.C) {
+	sources := "deb http://archive.ubuntu.com/ubuntu precise main\n" +
+		"deb http://security.ubuntu.com/ubuntu precise-security main\n"
+	err := ioutil.WriteFile(utils.AptSourcesFile, []byte(sources), 0644)
+	c.Assert(err, gc.IsNil)
+
+	agentConfig := agentConfig("0", "ec2")
+	envWorker := s.makeWorker(c, agentConfig)
+	defer worker.Stop(envWorker)
+	s.waitForPostSetup(c)
+
+	oldConfig, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	envConfig, err := oldConfig.Apply(map[string]interface{}{
+		"apt-mirror": "http://mirror.example.com/ubuntu",
+	})
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetEnvironConfig(envConfig, oldConfig)
+	c.Assert(err, gc.IsNil)
+
+	s.waitForFile(c, utils.AptSourcesFile,
+		"deb http://mirror.example.com/ubuntu precise main\n"+
+			"deb http://security.ubuntu.com/ubuntu precise-security main\n")
+}
+
 type mockConfig struct {
 	agent.Config
 	tag      string
@@ -0,0 +1,24 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package metricpruner
+
+import (
+	"time"
+)
+
+func SetInterval(i time.Duration) {
+	interval = i
+}
+
+func RestoreInterval() {
+	interval = defaultInterval
+}
+
+func SetRetention(r time.Duration) {
+	retention = r
+}
+
+func RestoreRetention() {
+	retention = defaultRetention
+}
@@ -0,0 +1,83 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package metricpruner
+
+import (
+	"time"
+
+	"github.com/juju/loggo"
+	"launchpad.net/tomb"
+)
+
+var logger = loggo.GetLogger("juju.worker.metricpruner")
+
+const (
+	// defaultInterval is the standard value for the interval setting.
+	defaultInterval = time.Hour
+
+	// defaultRetention is the standard value for the retention setting.
+	defaultRetention = 7 * 24 * time.Hour
+)
+
+var (
+	// interval sets how often the metrics are pruned.
+	interval = defaultInterval
+
+	// retention sets how long the metrics are kept.
+	retention = defaultRetention
+)
+
+// MetricPruner defines the interface for types
+// capable of removing old metrics.
+type MetricPruner interface {
+	// PruneMetrics removes the metrics received before the given time.
+	PruneMetrics(before time.Time) error
+}
+
+// Pruner is responsible for periodically removing the
+// metrics received longer ago than the retention period.
+type Pruner struct {
+	tomb tomb.Tomb
+	mp   MetricPruner
+}
+
+// NewPruner periodically removes old metrics.
+func NewPruner(mp MetricPruner) *Pruner {
+	p := &Pruner{mp: mp}
+	go func() {
+		defer p.tomb.Done()
+		p.tomb.Kill(p.loop())
+	}()
+	return p
+}
+
+func (p *Pruner) String() string {
+	return "metricpruner"
+}
+
+func (p *Pruner) Kill() {
+	p.tomb.Kill(nil)
+}
+
+func (p *Pruner) Stop() error {
+	p.tomb.Kill(nil)
+	return p.tomb.Wait()
+}
+
+func (p *Pruner) Wait() error {
+	return p.tomb.Wait()
+}
+
+func (p *Pruner) loop() error {
+	for {
+		select {
+		case <-p.tomb.Dying():
+			return tomb.ErrDying
+		case <-time.After(interval):
+			if err := p.mp.PruneMetrics(time.Now().Add(-retention)); err != nil {
+				logger.Errorf("cannot prune metrics: %v", err)
+			}
+		}
+	}
+}
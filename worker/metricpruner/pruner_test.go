@@ -0,0 +1,69 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package metricpruner_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/juju/testing"
+	coretesting "launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/worker/metricpruner"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type PrunerSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&PrunerSuite{})
+
+func (s *PrunerSuite) TestRunStopWithState(c *gc.C) {
+	// Test with state ensures that state fulfills the
+	// MetricPruner interface.
+	p := metricpruner.NewPruner(s.State)
+
+	c.Assert(p.Stop(), gc.IsNil)
+}
+
+func (s *PrunerSuite) TestPrunerCalls(c *gc.C) {
+	testInterval := 10 * time.Millisecond
+	metricpruner.SetInterval(testInterval)
+	defer metricpruner.RestoreInterval()
+	metricpruner.SetRetention(time.Hour)
+	defer metricpruner.RestoreRetention()
+
+	mp := &metricPrunerMock{make(chan time.Time, 1)}
+	p := metricpruner.NewPruner(mp)
+	defer func() { c.Assert(p.Stop(), gc.IsNil) }()
+
+	select {
+	case before := <-mp.calls:
+		// Metrics received within the retention period are kept.
+		expected := time.Now().Add(-time.Hour)
+		c.Assert(before.After(expected), gc.Equals, false)
+		c.Assert(before.After(expected.Add(-coretesting.LongWait)), gc.Equals, true)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("metrics not pruned")
+	}
+}
+
+// metricPrunerMock is used to check the
+// calls of PruneMetrics().
+type metricPrunerMock struct {
+	calls chan time.Time
+}
+
+func (mp *metricPrunerMock) PruneMetrics(before time.Time) error {
+	select {
+	case mp.calls <- before:
+	default:
+	}
+	return nil
+}
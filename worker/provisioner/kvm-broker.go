@@ -86,6 +86,7 @@ func (broker *kvmBroker) StartInstance(
 		config.SSLHostnameVerification,
 		config.Proxy,
 		config.AptProxy,
+		config.AptMirror,
 	); err != nil {
 		kvmLogger.Errorf("failed to populate machine config: %v", err)
 		return nil, nil, err
@@ -80,6 +80,7 @@ func (broker *lxcBroker) StartInstance(cons constraints.Value, possibleTools too
 		config.SSLHostnameVerification,
 		config.Proxy,
 		config.AptProxy,
+		config.AptMirror,
 	); err != nil {
 		lxcLogger.Errorf("failed to populate machine config: %v", err)
 		return nil, nil, err
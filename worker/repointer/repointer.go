@@ -0,0 +1,75 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package repointer
+
+import (
+	"errors"
+
+	"github.com/juju/loggo"
+
+	"launchpad.net/juju-core/agent"
+	apiagent "launchpad.net/juju-core/state/api/agent"
+	"launchpad.net/juju-core/state/api/watcher"
+	"launchpad.net/juju-core/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.repointer")
+
+// ErrRepointed is returned by the worker once it has pointed the agent
+// at the state server its environment has been imported into. The
+// agent's API connection must then be reopened.
+var ErrRepointed = errors.New("agent repointed to new state server")
+
+// Repointer is responsible for pointing the agent at the state server
+// its environment has been imported into, by rewriting the API
+// addresses, CA certificate and environment tag in its configuration.
+type Repointer struct {
+	api         *apiagent.State
+	agentConfig agent.Config
+}
+
+var _ worker.NotifyWatchHandler = (*Repointer)(nil)
+
+// NewRepointer returns a worker.Worker that repoints the agent when
+// the migration target of its environment is set.
+func NewRepointer(api *apiagent.State, agentConfig agent.Config) worker.Worker {
+	return worker.NewNotifyWorker(&Repointer{
+		api:         api,
+		agentConfig: agentConfig,
+	})
+}
+
+func (r *Repointer) SetUp() (watcher.NotifyWatcher, error) {
+	// The NotifyWorker sucks up the first event, so the target
+	// must be checked here; it may have been set while the
+	// agent was down.
+	if err := r.repoint(); err != nil {
+		return nil, err
+	}
+	return r.api.WatchMigrationTarget(r.agentConfig.Tag())
+}
+
+func (r *Repointer) Handle() error {
+	return r.repoint()
+}
+
+func (r *Repointer) TearDown() error {
+	// Nothing to cleanup, only state is the watcher
+	return nil
+}
+
+func (r *Repointer) repoint() error {
+	target, err := r.api.MigrationTarget(r.agentConfig.Tag())
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return nil
+	}
+	logger.Infof("environment imported into state server at %v; repointing agent", target.Addrs)
+	if err := r.agentConfig.WriteAPIInfo(target.Addrs, target.CACert, target.EnvironTag); err != nil {
+		return err
+	}
+	return ErrRepointed
+}
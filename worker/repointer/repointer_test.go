@@ -0,0 +1,105 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package repointer_test
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/agent"
+	"launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api"
+	"launchpad.net/juju-core/worker"
+	"launchpad.net/juju-core/worker/repointer"
+)
+
+// worstCase is used for timeouts when timing out
+// will fail the test. Raising this value should
+// not affect the overall running time of the tests
+// unless they fail.
+const worstCase = 5 * time.Second
+
+type RepointerSuite struct {
+	testing.JujuConnSuite
+
+	apiRoot *api.State
+	machine *state.Machine
+}
+
+var _ = gc.Suite(&RepointerSuite{})
+
+func (s *RepointerSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.apiRoot, s.machine = s.OpenAPIAsNewMachine(c)
+}
+
+type mockConfig struct {
+	agent.Config
+	tag        string
+	addrs      []string
+	caCert     []byte
+	environTag string
+}
+
+func (mock *mockConfig) Tag() string {
+	return mock.tag
+}
+
+func (mock *mockConfig) WriteAPIInfo(addrs []string, caCert []byte, environTag string) error {
+	mock.addrs = addrs
+	mock.caCert = caCert
+	mock.environTag = environTag
+	return nil
+}
+
+func (s *RepointerSuite) makeRepointer(c *gc.C) (worker.Worker, *mockConfig) {
+	config := &mockConfig{tag: s.machine.Tag()}
+	return repointer.NewRepointer(s.apiRoot.Agent(), config), config
+}
+
+func (s *RepointerSuite) setMigrationTarget(c *gc.C) *state.Environment {
+	env, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	err = env.SetMigrationTarget([]string{"target.example:17070"}, "target-ca-cert")
+	c.Assert(err, gc.IsNil)
+	return env
+}
+
+func (s *RepointerSuite) waitRepointed(c *gc.C, w worker.Worker, config *mockConfig, env *state.Environment) {
+	done := make(chan error)
+	go func() {
+		done <- w.Wait()
+	}()
+	select {
+	case err := <-done:
+		c.Assert(err, gc.Equals, repointer.ErrRepointed)
+	case <-time.After(worstCase):
+		c.Fatalf("timed out waiting for agent to be repointed")
+	}
+	c.Assert(config.addrs, gc.DeepEquals, []string{"target.example:17070"})
+	c.Assert(string(config.caCert), gc.Equals, "target-ca-cert")
+	c.Assert(config.environTag, gc.Equals, env.Tag())
+}
+
+func (s *RepointerSuite) TestRunStop(c *gc.C) {
+	repointerWorker, config := s.makeRepointer(c)
+	c.Assert(worker.Stop(repointerWorker), gc.IsNil)
+	c.Assert(config.addrs, gc.IsNil)
+}
+
+func (s *RepointerSuite) TestRepointsWhenTargetSet(c *gc.C) {
+	repointerWorker, config := s.makeRepointer(c)
+	defer worker.Stop(repointerWorker)
+	env := s.setMigrationTarget(c)
+	s.waitRepointed(c, repointerWorker, config, env)
+}
+
+func (s *RepointerSuite) TestRepointsWhenTargetAlreadySet(c *gc.C) {
+	env := s.setMigrationTarget(c)
+	repointerWorker, config := s.makeRepointer(c)
+	defer worker.Stop(repointerWorker)
+	s.waitRepointed(c, repointerWorker, config, env)
+}
@@ -55,6 +55,35 @@ func IsMissingHookError(err error) bool {
 	return ok
 }
 
+// hookKillWait holds how long to wait for a hook to exit after its
+// process tree has been killed for timing out.
+var hookKillWait = 10 * time.Second
+
+// hookTimeoutError is returned when a hook is killed for running
+// for longer than its timeout.
+type hookTimeoutError struct {
+	hookName string
+	timeout  time.Duration
+}
+
+func (e *hookTimeoutError) Error() string {
+	return fmt.Sprintf("%s timed out after %v", e.hookName, e.timeout)
+}
+
+func IsHookTimeoutError(err error) bool {
+	_, ok := err.(*hookTimeoutError)
+	return ok
+}
+
+// timedOutAfter returns the timeout after which the hook that produced
+// err was killed, or zero if err does not describe a hook timeout.
+func timedOutAfter(err error) time.Duration {
+	if e, ok := err.(*hookTimeoutError); ok {
+		return e.timeout
+	}
+	return 0
+}
+
 // HookContext is the implementation of jujuc.Context.
 type HookContext struct {
 	unit *uniter.Unit
@@ -101,6 +130,22 @@ type HookContext struct {
 
 	// proxySettings are the current proxy settings that the uniter knows about
 	proxySettings osenv.ProxySettings
+
+	// timeout holds the maximum time a hook run in the context may
+	// take before it is killed. If it is zero, the hook may run
+	// indefinitely.
+	timeout time.Duration
+
+	// definedMetrics holds the metrics declared by the charm. It is
+	// nil unless the context is running the collect-metrics hook,
+	// and metrics holds the metric values added by that hook.
+	definedMetrics *charm.Metrics
+	metrics        []jujuc.Metric
+
+	// migrationSettings holds the settings through which a migrated
+	// unit's data is passed to the unit replacing it. It is nil
+	// unless the context is running a migrate-from or migrate-to hook.
+	migrationSettings *uniter.Settings
 }
 
 func NewHookContext(unit *uniter.Unit, id, uuid, envName string,
@@ -155,6 +200,28 @@ func (ctx *HookContext) OwnerTag() string {
 	return ctx.serviceOwner
 }
 
+func (ctx *HookContext) AddMetric(key, value string, created time.Time) error {
+	if ctx.definedMetrics == nil {
+		return fmt.Errorf("metrics disabled")
+	}
+	if err := ctx.definedMetrics.ValidateMetric(key, value); err != nil {
+		return err
+	}
+	ctx.metrics = append(ctx.metrics, jujuc.Metric{
+		Key:   key,
+		Value: value,
+		Time:  created,
+	})
+	return nil
+}
+
+func (ctx *HookContext) MigrationSettings() (jujuc.Settings, error) {
+	if ctx.migrationSettings == nil {
+		return nil, fmt.Errorf("unit is not being migrated")
+	}
+	return ctx.migrationSettings, nil
+}
+
 func (ctx *HookContext) ConfigSettings() (charm.Settings, error) {
 	if ctx.configSettings == nil {
 		var err error
@@ -217,6 +284,15 @@ func (ctx *HookContext) finalizeContext(process string, err error) error {
         }
         rctx.ClearCache()
     }
+    if writeChanges && ctx.migrationSettings != nil {
+        if e := ctx.migrationSettings.Write(); e != nil {
+            e = fmt.Errorf("could not write migration settings from %q: %v", process, e)
+            logger.Errorf("%v", e)
+            if err == nil {
+                err = e
+            }
+        }
+    }
     return err
 }
 
@@ -252,6 +328,34 @@ func (ctx *HookContext) RunHook(hookName, charmDir, toolsDir, socketPath string)
     return ctx.finalizeContext(hookName, err)
 }
 
+// waitHook waits for the started hook process to exit. If the hook runs
+// for longer than the context's timeout, the hook's process tree is
+// killed and a hookTimeoutError is returned.
+func (ctx *HookContext) waitHook(hookName string, ps *exec.Cmd) error {
+	if ctx.timeout <= 0 {
+		return ps.Wait()
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- ps.Wait()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(ctx.timeout):
+	}
+	logger.Errorf("%q hook timed out after %v; killing it", hookName, ctx.timeout)
+	if err := killProcessTree(ps.Process); err != nil {
+		logger.Errorf("cannot kill %q hook: %v", hookName, err)
+	}
+	select {
+	case <-done:
+	case <-time.After(hookKillWait):
+		logger.Errorf("%q hook has not exited %v after being killed", hookName, hookKillWait)
+	}
+	return &hookTimeoutError{hookName, ctx.timeout}
+}
+
 type hookLogger struct {
 	r       io.ReadCloser
 	done    chan struct{}
@@ -7,6 +7,7 @@ import (
     "path/filepath"
     "strings"
     "strconv"
+    "syscall"
 
     "launchpad.net/juju-core/juju/osenv"
 )
@@ -18,6 +19,9 @@ func (ctx *HookContext) runCharmHook(hookName, charmDir string, env []string) er
     ps := exec.Command(hookFile)
     ps.Env = env
     ps.Dir = charmDir
+    // Run the hook in its own process group, so that the whole
+    // group can be killed if the hook times out.
+    ps.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
     outReader, outWriter, err := os.Pipe()
     if err != nil {
         return fmt.Errorf("cannot make logging pipe: %v", err)
@@ -33,7 +37,7 @@ func (ctx *HookContext) runCharmHook(hookName, charmDir string, env []string) er
     err = ps.Start()
     outWriter.Close()
     if err == nil {
-        err = ps.Wait()
+        err = ctx.waitHook(hookName, ps)
     }
     hookLogger.stop()
     if ee, ok := err.(*exec.Error); ok && err != nil {
@@ -71,4 +75,10 @@ func (ctx *HookContext) hookVars(charmDir, toolsDir, socketPath string) []string
     }
     vars = append(vars, ctx.proxySettings.AsEnvironmentValues()...)
     return vars
-}
\ No newline at end of file
+}
+
+// killProcessTree kills the given hook process and any processes
+// in its process group.
+func killProcessTree(p *os.Process) error {
+    return syscall.Kill(-p.Pid, syscall.SIGKILL)
+}
@@ -20,6 +20,7 @@ import (
 	"launchpad.net/juju-core/state/api"
 	"launchpad.net/juju-core/state/api/params"
 	apiuniter "launchpad.net/juju-core/state/api/uniter"
+	coretesting "launchpad.net/juju-core/testing"
 	jc "launchpad.net/juju-core/testing/checkers"
 	"launchpad.net/juju-core/utils"
 	"launchpad.net/juju-core/worker/uniter"
@@ -47,6 +48,9 @@ type hookSpec struct {
 	stderr string
 	// background holds a string to print in the background after 0.2s.
 	background string
+	// sleep holds a number of seconds to sleep, in a child process,
+	// before exiting.
+	sleep int
 }
 
 // makeCharm constructs a fake charm dir containing a single named hook
@@ -85,6 +89,9 @@ func makeCharm(c *gc.C, spec hookSpec) (charmDir, outPath string) {
 		// expected.
 		printf("(sleep 0.2; echo %s; sleep 10) &", spec.background)
 	}
+	if spec.sleep != 0 {
+		printf("sleep %d", spec.sleep)
+	}
 	printf("exit %d", spec.code)
 	return charmDir, outPath
 }
@@ -260,6 +267,34 @@ func (s *RunHookSuite) TestRunHook(c *gc.C) {
 	}
 }
 
+func (s *RunHookSuite) TestRunHookTimeout(c *gc.C) {
+	uuid, err := utils.NewUUID()
+	c.Assert(err, gc.IsNil)
+	ctx := s.getHookContext(c, uuid.String(), -1, "", osenv.ProxySettings{})
+	uniter.SetHookContextTimeout(ctx, 200*time.Millisecond)
+	charmDir, _ := makeCharm(c, hookSpec{
+		name:  "something-happened",
+		perm:  0700,
+		sleep: 10,
+	})
+	t0 := time.Now()
+	err = ctx.RunHook("something-happened", charmDir, c.MkDir(), "/path/to/socket")
+	c.Assert(err, gc.ErrorMatches, "something-happened timed out after 200ms")
+	c.Assert(uniter.IsHookTimeoutError(err), jc.IsTrue)
+	if time.Now().Sub(t0) > 5*time.Second {
+		c.Errorf("hook was not killed when it timed out")
+	}
+
+	// A hook that finishes in time is unaffected by the timeout.
+	charmDir, _ = makeCharm(c, hookSpec{
+		name: "something-happened",
+		perm: 0700,
+		code: 99,
+	})
+	err = ctx.RunHook("something-happened", charmDir, c.MkDir(), "/path/to/socket")
+	c.Assert(err, gc.ErrorMatches, "exit status 99")
+}
+
 // split the line into buffer-sized lengths.
 func splitLine(s string) []string {
 	var ss []string
@@ -642,6 +677,24 @@ func (s *InterfaceSuite) TestConfigCaching(c *gc.C) {
 	c.Assert(settings, gc.DeepEquals, charm.Settings{"blog-title": "My Title"})
 }
 
+func (s *InterfaceSuite) TestAddMetric(c *gc.C) {
+	ctx := s.HookContextSuite.getHookContext(c, "TestCtx", -1, "", noProxies)
+	now := time.Now()
+	err := ctx.AddMetric("users", "5", now)
+	c.Assert(err, gc.ErrorMatches, "metrics disabled")
+
+	uniter.EnableMetrics(ctx, coretesting.Charms.Dir("metered").Metrics())
+	err = ctx.AddMetric("users", "5", now)
+	c.Assert(err, gc.IsNil)
+	err = ctx.AddMetric("requests", "-1", now)
+	c.Assert(err, gc.ErrorMatches, `invalid value for metric "requests": absolute metrics must not be negative`)
+	err = ctx.AddMetric("unknown", "1", now)
+	c.Assert(err, gc.ErrorMatches, `metric "unknown" not declared`)
+	c.Assert(uniter.ContextMetrics(ctx), gc.DeepEquals, []jujuc.Metric{
+		{Key: "users", Value: "5", Time: now},
+	})
+}
+
 type HookContextSuite struct {
 	testing.JujuConnSuite
 	service  *state.Service
@@ -69,7 +69,7 @@ func (ctx *HookContext) runCharmHook(hookName, charmDir string, env []string) er
     err = ps.Start()
     outWriter.Close()
     if err == nil {
-        err = ps.Wait()
+        err = ctx.waitHook(hookName, ps)
     }
     hookLogger.stop()
     if ee, ok := err.(*exec.Error); ok && err != nil {
@@ -112,4 +112,15 @@ func (ctx *HookContext) hookVars(charmDir, toolsDir, socketPath string) []string
         environ = append(environ, "JUJU_REMOTE_UNIT="+name)
     }
     return environ
-}
\ No newline at end of file
+}
+
+// killProcessTree kills the given hook process and any processes
+// it started.
+func killProcessTree(p *os.Process) error {
+    kill := exec.Command("taskkill", "/F", "/T", "/PID", strconv.Itoa(p.Pid))
+    if out, err := kill.CombinedOutput(); err != nil {
+        logger.Warningf("taskkill failed: %v (%s)", err, out)
+        return p.Kill()
+    }
+    return nil
+}
@@ -4,7 +4,11 @@
 package uniter
 
 import (
+	"time"
+
+	"launchpad.net/juju-core/charm"
 	"launchpad.net/juju-core/juju/osenv"
+	"launchpad.net/juju-core/worker/uniter/jujuc"
 )
 
 func SetUniterObserver(u *Uniter, observer UniterExecutionObserver) {
@@ -16,3 +20,15 @@ func (u *Uniter) GetProxyValues() osenv.ProxySettings {
 	defer u.proxyMutex.Unlock()
 	return u.proxy
 }
+
+func SetHookContextTimeout(ctx *HookContext, timeout time.Duration) {
+	ctx.timeout = timeout
+}
+
+func EnableMetrics(ctx *HookContext, metrics *charm.Metrics) {
+	ctx.definedMetrics = metrics
+}
+
+func ContextMetrics(ctx *HookContext) []jujuc.Metric {
+	return ctx.metrics
+}
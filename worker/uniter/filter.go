@@ -5,11 +5,15 @@ package uniter
 
 import (
 	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/juju/loggo"
 	"launchpad.net/tomb"
 
 	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/charm/hooks"
 	"launchpad.net/juju-core/names"
 	"launchpad.net/juju-core/state/api/params"
 	"launchpad.net/juju-core/state/api/uniter"
@@ -34,14 +38,18 @@ type filter struct {
 	// The out* chans, when set to the corresponding out*On chan (rather than
 	// nil) indicate that an event of the appropriate type is ready to send
 	// to the client.
-	outConfig      chan struct{}
-	outConfigOn    chan struct{}
-	outUpgrade     chan *charm.URL
-	outUpgradeOn   chan *charm.URL
-	outResolved    chan params.ResolvedMode
-	outResolvedOn  chan params.ResolvedMode
-	outRelations   chan []int
-	outRelationsOn chan []int
+	outConfig        chan struct{}
+	outConfigOn      chan struct{}
+	outUpgrade       chan *charm.URL
+	outUpgradeOn     chan *charm.URL
+	outResolved      chan params.ResolvedMode
+	outResolvedOn    chan params.ResolvedMode
+	outRelations     chan []int
+	outRelationsOn   chan []int
+	outMigration     chan hooks.Kind
+	outMigrationOn   chan hooks.Kind
+	outMaintenance   chan bool
+	outMaintenanceOn chan bool
 
 	// The want* chans are used to indicate that the filter should send
 	// events if it has them available.
@@ -86,6 +94,18 @@ type filter struct {
 	upgradeAvailable serviceCharm
 	upgrade          *charm.URL
 	relations        []int
+	migration        hooks.Kind
+	maintenance      bool
+
+	// pendingHooks holds the number of hook events ready to be sent,
+	// and is accessed atomically so that PendingHooks can be called
+	// from other goroutines.
+	pendingHooks int32
+
+	// hookTimeout holds the service's hook timeout, as last read
+	// when the service changed.
+	hookTimeoutMu sync.Mutex
+	hookTimeout   time.Duration
 }
 
 // newFilter returns a filter that handles state changes pertaining to the
@@ -102,6 +122,10 @@ func newFilter(st *uniter.State, unitTag string) (*filter, error) {
 		outResolvedOn:     make(chan params.ResolvedMode),
 		outRelations:      make(chan []int),
 		outRelationsOn:    make(chan []int),
+		outMigration:      make(chan hooks.Kind),
+		outMigrationOn:    make(chan hooks.Kind),
+		outMaintenance:    make(chan bool),
+		outMaintenanceOn:  make(chan bool),
 		wantForcedUpgrade: make(chan bool),
 		wantResolved:      make(chan struct{}),
 		discardConfig:     make(chan struct{}),
@@ -128,6 +152,14 @@ func (f *filter) Dead() <-chan struct{} {
 	return f.tomb.Dead()
 }
 
+// HookTimeout returns the service's hook timeout, or zero if the
+// timeouts in the charm metadata apply.
+func (f *filter) HookTimeout() time.Duration {
+	f.hookTimeoutMu.Lock()
+	defer f.hookTimeoutMu.Unlock()
+	return f.hookTimeout
+}
+
 func (f *filter) Wait() error {
 	return f.tomb.Wait()
 }
@@ -164,6 +196,45 @@ func (f *filter) RelationsEvents() <-chan []int {
 	return f.outRelationsOn
 }
 
+// MigrationEvents returns a channel that will receive the kind of
+// migration hook to run when the unit starts being migrated to
+// another unit, or when the data of the unit it replaces is ready.
+func (f *filter) MigrationEvents() <-chan hooks.Kind {
+	return f.outMigrationOn
+}
+
+// MaintenanceEvents returns a channel that will receive whether the
+// unit's machine is in maintenance mode, once when the filter starts
+// and then whenever that changes.
+func (f *filter) MaintenanceEvents() <-chan bool {
+	return f.outMaintenanceOn
+}
+
+// PendingHooks returns the number of events the filter has ready to
+// send that will cause hooks to run.
+func (f *filter) PendingHooks() int {
+	return int(atomic.LoadInt32(&f.pendingHooks))
+}
+
+// countPendingHooks returns the number of events ready to be sent
+// that will cause hooks to run.
+func (f *filter) countPendingHooks() int {
+	n := 0
+	if f.outConfig == f.outConfigOn {
+		n++
+	}
+	if f.outUpgrade == f.outUpgradeOn {
+		n++
+	}
+	if f.outMigration == f.outMigrationOn {
+		n++
+	}
+	if f.outMaintenance == f.outMaintenanceOn {
+		n++
+	}
+	return n
+}
+
 // WantUpgradeEvent controls whether the filter will generate upgrade
 // events for unforced service charm changes.
 func (f *filter) WantUpgradeEvent(mustForce bool) {
@@ -271,6 +342,20 @@ func (f *filter) loop(unitTag string) (err error) {
 		return err
 	}
 	defer f.maybeStopWatcher(servicew)
+	// maintenanceChanges is left nil when the state server does not
+	// support maintenance mode.
+	var maintenanceChanges <-chan struct{}
+	maintenancew, err := f.unit.WatchMaintenance()
+	if err == nil {
+		defer f.maybeStopWatcher(maintenancew)
+		maintenanceChanges = maintenancew.Changes()
+		if f.maintenance, err = f.unit.InMaintenance(); err != nil {
+			return err
+		}
+		f.outMaintenance = f.outMaintenanceOn
+	} else if !params.IsCodeNotImplemented(err) {
+		return err
+	}
 	// configw and relationsw can get restarted, so we need to use
 	// their eventual values in the defer calls.
 	var configw apiwatcher.NotifyWatcher
@@ -307,6 +392,7 @@ func (f *filter) loop(unitTag string) (err error) {
 	// setting this channel to its namesake on f.
 	var discardConfig chan struct{}
 	for {
+		atomic.StoreInt32(&f.pendingHooks, int32(f.countPendingHooks()))
 		var ok bool
 		select {
 		case <-f.tomb.Dying():
@@ -329,6 +415,14 @@ func (f *filter) loop(unitTag string) (err error) {
 			if err = f.serviceChanged(); err != nil {
 				return err
 			}
+		case _, ok = <-maintenanceChanges:
+			filterLogger.Debugf("got machine change")
+			if !ok {
+				return watcher.MustErr(maintenancew)
+			}
+			if err = f.maintenanceChanged(); err != nil {
+				return err
+			}
 		case _, ok = <-configChanges:
 			filterLogger.Debugf("got config change")
 			if !ok {
@@ -371,6 +465,12 @@ func (f *filter) loop(unitTag string) (err error) {
 			filterLogger.Debugf("sent relations event")
 			f.outRelations = nil
 			f.relations = nil
+		case f.outMigration <- f.migration:
+			filterLogger.Debugf("sent %q migration event", f.migration)
+			f.outMigration = nil
+		case f.outMaintenance <- f.maintenance:
+			filterLogger.Debugf("sent maintenance event (%v)", f.maintenance)
+			f.outMaintenance = nil
 
 		// Handle explicit requests.
 		case curl := <-f.setCharm:
@@ -469,6 +569,51 @@ func (f *filter) unitChanged() error {
 			f.outResolved = f.outResolvedOn
 		}
 	}
+	return f.migrationChanged()
+}
+
+// migrationChanged responds to changes in the unit's migration status.
+// A migrate-from event is prepared when the unit starts being migrated,
+// and a migrate-to event when the data of the unit it replaces is ready.
+func (f *filter) migrationChanged() error {
+	status, err := f.unit.MigrationStatus()
+	if params.IsCodeNotImplemented(err) {
+		// The state server does not support migrations.
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var migration hooks.Kind
+	switch {
+	case f.life != params.Alive:
+	case status.MigratingTo != "" && !status.Ready:
+		migration = hooks.MigrateFrom
+	case status.MigratedFrom != "" && status.Ready:
+		migration = hooks.MigrateTo
+	}
+	if migration != f.migration {
+		f.migration = migration
+		f.outMigration = nil
+		if migration != "" {
+			filterLogger.Debugf("preparing %q migration event", migration)
+			f.outMigration = f.outMigrationOn
+		}
+	}
+	return nil
+}
+
+// maintenanceChanged responds to changes in the unit's machine, and
+// prepares a maintenance event when its maintenance mode has changed.
+func (f *filter) maintenanceChanged() error {
+	maintenance, err := f.unit.InMaintenance()
+	if err != nil {
+		return err
+	}
+	if maintenance != f.maintenance {
+		filterLogger.Debugf("preparing maintenance event (%v)", maintenance)
+		f.maintenance = maintenance
+		f.outMaintenance = f.outMaintenanceOn
+	}
 	return nil
 }
 
@@ -482,6 +627,15 @@ func (f *filter) serviceChanged() error {
 		return err
 	}
 	f.upgradeAvailable = serviceCharm{url, force}
+	// The hook timeout is only advisory, so a failure to read it
+	// leaves the last known value in place.
+	if timeout, err := f.service.HookTimeout(); err != nil {
+		filterLogger.Warningf("cannot read hook timeout: %v", err)
+	} else {
+		f.hookTimeoutMu.Lock()
+		f.hookTimeout = timeout
+		f.hookTimeoutMu.Unlock()
+	}
 	switch f.service.Life() {
 	case params.Dying:
 		if err := f.unit.Destroy(); err != nil {
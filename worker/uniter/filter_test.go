@@ -11,6 +11,7 @@ import (
 	"launchpad.net/tomb"
 
 	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/charm/hooks"
 	jujutesting "launchpad.net/juju-core/juju/testing"
 	"launchpad.net/juju-core/state"
 	"launchpad.net/juju-core/state/api"
@@ -158,6 +159,91 @@ loop:
 	// Can't set s.wordpress to Dead while it still has units.
 }
 
+func (s *FilterSuite) TestMigrationEvents(c *gc.C) {
+	f, err := newFilter(s.uniter, s.unit.Tag())
+	c.Assert(err, gc.IsNil)
+	defer statetesting.AssertStop(c, f)
+	migrationAsserter := coretesting.ContentAsserterC{
+		C:       c,
+		Precond: func() { s.BackingState.StartSync() },
+		Chan:    f.MigrationEvents(),
+	}
+	migrationAsserter.AssertNoReceive()
+
+	// Start migrating the unit; a migrate-from event is received.
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	target, err := s.unit.StartMigration(machine)
+	c.Assert(err, gc.IsNil)
+	kind := migrationAsserter.AssertOneReceive().(hooks.Kind)
+	c.Assert(kind, gc.Equals, hooks.MigrateFrom)
+
+	// Irrelevant changes do not resend it.
+	err = s.unit.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+	migrationAsserter.AssertNoReceive()
+
+	// Once the data is ready, the migrated unit gets no more events...
+	err = s.unit.SetMigrationReady()
+	c.Assert(err, gc.IsNil)
+	migrationAsserter.AssertNoReceive()
+
+	// ...and the unit replacing it gets a migrate-to event.
+	s.APILogin(c, target)
+	tf, err := newFilter(s.uniter, target.Tag())
+	c.Assert(err, gc.IsNil)
+	defer statetesting.AssertStop(c, tf)
+	targetAsserter := coretesting.ContentAsserterC{
+		C:       c,
+		Precond: func() { s.BackingState.StartSync() },
+		Chan:    tf.MigrationEvents(),
+	}
+	kind = targetAsserter.AssertOneReceive().(hooks.Kind)
+	c.Assert(kind, gc.Equals, hooks.MigrateTo)
+
+	err = target.Refresh()
+	c.Assert(err, gc.IsNil)
+	err = target.FinishMigration()
+	c.Assert(err, gc.IsNil)
+	targetAsserter.AssertNoReceive()
+}
+
+func (s *FilterSuite) TestMaintenanceEvents(c *gc.C) {
+	f, err := newFilter(s.uniter, s.unit.Tag())
+	c.Assert(err, gc.IsNil)
+	defer statetesting.AssertStop(c, f)
+	maintenanceAsserter := coretesting.ContentAsserterC{
+		C:       c,
+		Precond: func() { s.BackingState.StartSync() },
+		Chan:    f.MaintenanceEvents(),
+	}
+
+	// The initial maintenance mode is always sent.
+	maintenance := maintenanceAsserter.AssertOneReceive().(bool)
+	c.Assert(maintenance, gc.Equals, false)
+
+	// Put the machine into maintenance mode; an event is received.
+	mid, err := s.unit.AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	machine, err := s.State.Machine(mid)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetMaintenance(true)
+	c.Assert(err, gc.IsNil)
+	maintenance = maintenanceAsserter.AssertOneReceive().(bool)
+	c.Assert(maintenance, gc.Equals, true)
+
+	// Irrelevant changes to the machine do not resend it.
+	err = machine.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+	maintenanceAsserter.AssertNoReceive()
+
+	// Take the machine out of maintenance mode; an event is received.
+	err = machine.SetMaintenance(false)
+	c.Assert(err, gc.IsNil)
+	maintenance = maintenanceAsserter.AssertOneReceive().(bool)
+	c.Assert(maintenance, gc.Equals, false)
+}
+
 func (s *FilterSuite) TestResolvedEvents(c *gc.C) {
 	f, err := newFilter(s.uniter, s.unit.Tag())
 	c.Assert(err, gc.IsNil)
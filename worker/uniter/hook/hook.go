@@ -36,7 +36,9 @@ func (hi Info) Validate() error {
 			return fmt.Errorf("%q hook requires a remote unit", hi.Kind)
 		}
 		fallthrough
-	case hooks.Install, hooks.Start, hooks.ConfigChanged, hooks.UpgradeCharm, hooks.Stop, hooks.RelationBroken:
+	case hooks.Install, hooks.Start, hooks.ConfigChanged, hooks.UpgradeCharm, hooks.Stop, hooks.RelationBroken,
+		hooks.MigrateFrom, hooks.MigrateTo,
+		hooks.MaintenanceStarted, hooks.MaintenanceFinished:
 		return nil
 	}
 	return fmt.Errorf("unknown hook kind %q", hi.Kind)
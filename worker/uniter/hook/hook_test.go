@@ -42,6 +42,10 @@ var validateTests = []struct {
 	{hook.Info{Kind: hooks.ConfigChanged}, ""},
 	{hook.Info{Kind: hooks.UpgradeCharm}, ""},
 	{hook.Info{Kind: hooks.Stop}, ""},
+	{hook.Info{Kind: hooks.MigrateFrom}, ""},
+	{hook.Info{Kind: hooks.MigrateTo}, ""},
+	{hook.Info{Kind: hooks.MaintenanceStarted}, ""},
+	{hook.Info{Kind: hooks.MaintenanceFinished}, ""},
 	{hook.Info{Kind: hooks.RelationJoined, RemoteUnit: "x"}, ""},
 	{hook.Info{Kind: hooks.RelationChanged, RemoteUnit: "x"}, ""},
 	{hook.Info{Kind: hooks.RelationDeparted, RemoteUnit: "x"}, ""},
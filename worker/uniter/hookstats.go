@@ -0,0 +1,175 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package uniter
+
+import (
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"launchpad.net/tomb"
+
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// hookExitCode returns the exit code of the hook process that
+// produced the given error, or zero if the error does not
+// describe the exit of a process.
+func hookExitCode(err error) int {
+	if ee, ok := err.(*exec.ExitError); ok {
+		if status, ok := ee.Sys().(syscall.WaitStatus); ok {
+			return status.ExitStatus()
+		}
+	}
+	return 0
+}
+
+// recordHookStarted reports to the state server that the named hook
+// has started running, and returns the time at which it did so.
+func (u *Uniter) recordHookStarted(hookName string) time.Time {
+	started := time.Now()
+	u.hookRecorder.record(params.HookExecution{
+		Hook:    hookName,
+		Started: started,
+		Queued:  u.queuedHooks(),
+	})
+	return started
+}
+
+// recordHookFinished reports to the state server that the named hook,
+// which started running at the given time, has finished with the
+// given error. A missing hook did not run, so it is reported as
+// skipped and does not count as an execution.
+func (u *Uniter) recordHookFinished(hookName string, started time.Time, err error) {
+	exec := params.HookExecution{
+		Hook:     hookName,
+		Started:  started,
+		Finished: time.Now(),
+		ExitCode: hookExitCode(err),
+		Queued:   u.queuedHooks(),
+	}
+	if IsMissingHookError(err) {
+		exec.Skipped = true
+	} else if err != nil {
+		exec.Error = err.Error()
+	}
+	u.hookRecorder.record(exec)
+}
+
+// queuedHooks returns the number of hooks the uniter has queued to run.
+func (u *Uniter) queuedHooks() int {
+	n := u.f.PendingHooks()
+	for _, r := range u.relationers {
+		n += r.QueuedHooks()
+	}
+	return n
+}
+
+// hookRecorderStopTimeout bounds how long stopping a hook recorder
+// waits for the executions still pending to be reported.
+var hookRecorderStopTimeout = 5 * time.Second
+
+// hookExecutionRecorder records hook executions in state; it is
+// implemented by the API unit.
+type hookExecutionRecorder interface {
+	RecordHookExecutions(execs []params.HookExecution) ([]error, error)
+}
+
+// hookRecorder reports hook executions to the state server in the
+// background, so that running a hook never waits on the API. The
+// executions recorded while a report is in progress are sent together
+// in the next one.
+type hookRecorder struct {
+	tomb    tomb.Tomb
+	unit    hookExecutionRecorder
+	changed chan struct{}
+
+	mu      sync.Mutex
+	pending []params.HookExecution
+}
+
+func newHookRecorder(unit hookExecutionRecorder) *hookRecorder {
+	r := &hookRecorder{
+		unit:    unit,
+		changed: make(chan struct{}, 1),
+	}
+	go func() {
+		defer r.tomb.Done()
+		r.tomb.Kill(r.loop())
+	}()
+	return r
+}
+
+// Stop reports any executions still pending and stops the recorder.
+// If reporting them takes longer than hookRecorderStopTimeout, as when
+// the state server is not responding, they are abandoned so that the
+// uniter is not prevented from stopping.
+func (r *hookRecorder) Stop() error {
+	r.tomb.Kill(nil)
+	select {
+	case <-r.tomb.Dead():
+		return r.tomb.Err()
+	case <-time.After(hookRecorderStopTimeout):
+		logger.Warningf("timed out reporting hook executions; abandoning them")
+		return nil
+	}
+}
+
+// record queues the given execution to be reported. If the hook it
+// finishes has not been reported as started yet, only the finished
+// execution is sent; a skipped hook that was never reported as
+// started is not reported at all.
+func (r *hookRecorder) record(exec params.HookExecution) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if n := len(r.pending); n > 0 && !exec.Finished.IsZero() {
+		if last := r.pending[n-1]; last.Finished.IsZero() && last.Hook == exec.Hook {
+			r.pending = r.pending[:n-1]
+			if exec.Skipped {
+				return
+			}
+		}
+	}
+	r.pending = append(r.pending, exec)
+	select {
+	case r.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (r *hookRecorder) loop() error {
+	for {
+		select {
+		case <-r.tomb.Dying():
+			r.send()
+			return nil
+		case <-r.changed:
+			r.send()
+		}
+	}
+}
+
+// send reports the pending executions. Hook statistics are
+// informational only, so a failure to record them is logged rather
+// than allowed to affect the uniter.
+func (r *hookRecorder) send() {
+	r.mu.Lock()
+	execs := r.pending
+	r.pending = nil
+	r.mu.Unlock()
+	if len(execs) == 0 {
+		return
+	}
+	errs, err := r.unit.RecordHookExecutions(execs)
+	if err != nil {
+		logger.Warningf("cannot record hook executions: %v", err)
+		return
+	}
+	for i, err := range errs {
+		if err != nil {
+			logger.Warningf("cannot record execution of %q hook: %v", execs[i].Hook, err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package uniter
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/state/api/params"
+	coretesting "launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/testing/testbase"
+)
+
+type HookRecorderSuite struct {
+	testbase.LoggingSuite
+}
+
+var _ = gc.Suite(&HookRecorderSuite{})
+
+// blockingRecorder records hook executions, blocking each call until
+// it is released.
+type blockingRecorder struct {
+	called  chan []params.HookExecution
+	release chan struct{}
+}
+
+func (r *blockingRecorder) RecordHookExecutions(execs []params.HookExecution) ([]error, error) {
+	r.called <- execs
+	<-r.release
+	return make([]error, len(execs)), nil
+}
+
+func (s *HookRecorderSuite) TestRecord(c *gc.C) {
+	unit := &blockingRecorder{
+		called:  make(chan []params.HookExecution, 1),
+		release: make(chan struct{}),
+	}
+	close(unit.release)
+	r := newHookRecorder(unit)
+	exec := params.HookExecution{Hook: "install", Started: time.Unix(100, 0)}
+	r.record(exec)
+	select {
+	case execs := <-unit.called:
+		c.Assert(execs, gc.DeepEquals, []params.HookExecution{exec})
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("executions not recorded")
+	}
+	c.Assert(r.Stop(), gc.IsNil)
+}
+
+func (s *HookRecorderSuite) TestStopTimesOut(c *gc.C) {
+	s.PatchValue(&hookRecorderStopTimeout, coretesting.ShortWait)
+	unit := &blockingRecorder{
+		called:  make(chan []params.HookExecution, 1),
+		release: make(chan struct{}),
+	}
+	defer close(unit.release)
+	r := newHookRecorder(unit)
+	r.record(params.HookExecution{Hook: "install", Started: time.Unix(100, 0)})
+	select {
+	case <-unit.called:
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("executions not recorded")
+	}
+
+	// The recorder is blocked on the state server, but stops anyway.
+	stopped := make(chan error)
+	go func() {
+		stopped <- r.Stop()
+	}()
+	select {
+	case err := <-stopped:
+		c.Assert(err, gc.IsNil)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("recorder did not stop")
+	}
+}
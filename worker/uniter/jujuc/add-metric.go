@@ -0,0 +1,74 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"launchpad.net/juju-core/cmd"
+)
+
+// Metric holds a single metric value added by a hook.
+type Metric struct {
+	Key   string
+	Value string
+	Time  time.Time
+}
+
+// AddMetricCommand implements the add-metric command.
+type AddMetricCommand struct {
+	cmd.CommandBase
+	ctx     Context
+	Metrics []Metric
+}
+
+func NewAddMetricCommand(ctx Context) cmd.Command {
+	return &AddMetricCommand{ctx: ctx}
+}
+
+const addMetricDoc = `
+add-metric records a value for each of the given metrics, which must be
+declared in the charm's metrics.yaml file. It may only be used in the
+collect-metrics hook.
+`
+
+func (c *AddMetricCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "add-metric",
+		Args:    "<key>=<value> [<key>=<value> ...]",
+		Purpose: "add metric values",
+		Doc:     addMetricDoc,
+	}
+}
+
+func (c *AddMetricCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no metrics specified")
+	}
+	now := time.Now()
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf(`expected "key=value", got %q`, arg)
+		}
+		c.Metrics = append(c.Metrics, Metric{
+			Key:   parts[0],
+			Value: parts[1],
+			Time:  now,
+		})
+	}
+	return nil
+}
+
+func (c *AddMetricCommand) Run(ctx *cmd.Context) error {
+	for _, metric := range c.Metrics {
+		if err := c.ctx.AddMetric(metric.Key, metric.Value, metric.Time); err != nil {
+			return fmt.Errorf("cannot record metric: %v", err)
+		}
+	}
+	return nil
+}
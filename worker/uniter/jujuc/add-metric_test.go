@@ -0,0 +1,89 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/worker/uniter/jujuc"
+)
+
+type AddMetricSuite struct {
+	ContextSuite
+}
+
+var _ = gc.Suite(&AddMetricSuite{})
+
+func (s *AddMetricSuite) TestHelp(c *gc.C) {
+	hctx := s.GetHookContext(c, -1, "")
+	com, err := jujuc.NewCommand(hctx, "add-metric")
+	c.Assert(err, gc.IsNil)
+	ctx := testing.Context(c)
+	code := cmd.Main(com, ctx, []string{"--help"})
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(bufferString(ctx.Stdout), gc.Equals, `usage: add-metric <key>=<value> [<key>=<value> ...]
+purpose: add metric values
+
+add-metric records a value for each of the given metrics, which must be
+declared in the charm's metrics.yaml file. It may only be used in the
+collect-metrics hook.
+`)
+	c.Assert(bufferString(ctx.Stderr), gc.Equals, "")
+}
+
+func (s *AddMetricSuite) TestAddMetric(c *gc.C) {
+	hctx := s.GetHookContext(c, -1, "")
+	com, err := jujuc.NewCommand(hctx, "add-metric")
+	c.Assert(err, gc.IsNil)
+	ctx := testing.Context(c)
+	code := cmd.Main(com, ctx, []string{"users=5", "requests=1.5"})
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(bufferString(ctx.Stdout), gc.Equals, "")
+	c.Assert(bufferString(ctx.Stderr), gc.Equals, "")
+	c.Assert(hctx.metrics, gc.HasLen, 2)
+	c.Assert(hctx.metrics[0].Key, gc.Equals, "users")
+	c.Assert(hctx.metrics[0].Value, gc.Equals, "5")
+	c.Assert(hctx.metrics[1].Key, gc.Equals, "requests")
+	c.Assert(hctx.metrics[1].Value, gc.Equals, "1.5")
+	c.Assert(hctx.metrics[0].Time.IsZero(), gc.Equals, false)
+}
+
+func (s *AddMetricSuite) TestAddMetricError(c *gc.C) {
+	hctx := s.GetHookContext(c, -1, "")
+	com, err := jujuc.NewCommand(hctx, "add-metric")
+	c.Assert(err, gc.IsNil)
+	ctx := testing.Context(c)
+	code := cmd.Main(com, ctx, []string{"unknown=1"})
+	c.Assert(code, gc.Equals, 1)
+	c.Assert(bufferString(ctx.Stderr), gc.Equals, `error: cannot record metric: metric "unknown" not declared`+"\n")
+}
+
+var addMetricInitErrorTests = []struct {
+	args []string
+	err  string
+}{{
+	err: "no metrics specified",
+}, {
+	args: []string{"users"},
+	err:  `expected "key=value", got "users"`,
+}, {
+	args: []string{"users=5", "=1"},
+	err:  `expected "key=value", got "=1"`,
+}, {
+	args: []string{"users="},
+	err:  `expected "key=value", got "users="`,
+}}
+
+func (s *AddMetricSuite) TestInitErrors(c *gc.C) {
+	hctx := s.GetHookContext(c, -1, "")
+	for i, test := range addMetricInitErrorTests {
+		c.Logf("test %d: %q", i, test.args)
+		com, err := jujuc.NewCommand(hctx, "add-metric")
+		c.Assert(err, gc.IsNil)
+		err = testing.InitCommand(com, test.args)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
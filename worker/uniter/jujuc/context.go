@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"launchpad.net/juju-core/charm"
 	"launchpad.net/juju-core/state/api/params"
@@ -55,6 +56,17 @@ type Context interface {
 
 	// OwnerTag returns the owner of the service the executing units belongs to
 	OwnerTag() string
+
+	// AddMetric records a value for the named metric, which must be
+	// declared by the charm. Metrics may only be added while the
+	// collect-metrics hook is executing.
+	AddMetric(key, value string, created time.Time) error
+
+	// MigrationSettings allows read/write access to the settings through
+	// which the data of a migrated unit is passed to the unit replacing
+	// it. They are only available while the migrate-from or migrate-to
+	// hook is executing.
+	MigrationSettings() (Settings, error)
 }
 
 // ContextRelation expresses the capabilities of a hook with respect to a relation.
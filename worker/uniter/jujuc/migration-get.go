@@ -0,0 +1,67 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+)
+
+// MigrationGetCommand implements the migration-get command.
+type MigrationGetCommand struct {
+	cmd.CommandBase
+	ctx Context
+	Key string
+	out cmd.Output
+}
+
+func NewMigrationGetCommand(ctx Context) cmd.Command {
+	return &MigrationGetCommand{ctx: ctx}
+}
+
+func (c *MigrationGetCommand) Info() *cmd.Info {
+	doc := `
+migration-get prints the value of a migration setting, specified by key.
+If no key is given, or if the key is "-", all keys and values will be printed.
+Migration settings carry the data of a unit being migrated to the unit
+replacing it, and are only available in the migrate-from and migrate-to hooks.
+`
+	return &cmd.Info{
+		Name:    "migration-get",
+		Args:    "[<key>]",
+		Purpose: "get migration settings",
+		Doc:     doc,
+	}
+}
+
+func (c *MigrationGetCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "smart", cmd.DefaultFormatters)
+}
+
+func (c *MigrationGetCommand) Init(args []string) error {
+	c.Key = ""
+	if len(args) > 0 {
+		if c.Key = args[0]; c.Key == "-" {
+			c.Key = ""
+		}
+		args = args[1:]
+	}
+	return cmd.CheckEmpty(args)
+}
+
+func (c *MigrationGetCommand) Run(ctx *cmd.Context) error {
+	node, err := c.ctx.MigrationSettings()
+	if err != nil {
+		return err
+	}
+	settings := node.Map()
+	if c.Key == "" {
+		return c.out.Write(ctx, settings)
+	}
+	if value, ok := settings[c.Key]; ok {
+		return c.out.Write(ctx, value)
+	}
+	return c.out.Write(ctx, nil)
+}
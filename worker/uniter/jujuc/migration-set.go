@@ -0,0 +1,62 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"fmt"
+	"strings"
+
+	"launchpad.net/juju-core/cmd"
+)
+
+// MigrationSetCommand implements the migration-set command.
+type MigrationSetCommand struct {
+	cmd.CommandBase
+	ctx      Context
+	Settings map[string]string
+}
+
+func NewMigrationSetCommand(ctx Context) cmd.Command {
+	return &MigrationSetCommand{ctx: ctx, Settings: map[string]string{}}
+}
+
+func (c *MigrationSetCommand) Info() *cmd.Info {
+	doc := `
+migration-set sets migration settings, which carry the data of a unit being
+migrated to the unit replacing it. Setting a key to an empty value deletes it.
+Migration settings are only available in the migrate-from and migrate-to hooks.
+`
+	return &cmd.Info{
+		Name:    "migration-set",
+		Args:    "key=value [key=value ...]",
+		Purpose: "set migration settings",
+		Doc:     doc,
+	}
+}
+
+func (c *MigrationSetCommand) Init(args []string) error {
+	for _, kv := range args {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 {
+			return fmt.Errorf(`expected "key=value", got %q`, kv)
+		}
+		c.Settings[parts[0]] = parts[1]
+	}
+	return nil
+}
+
+func (c *MigrationSetCommand) Run(ctx *cmd.Context) error {
+	settings, err := c.ctx.MigrationSettings()
+	if err != nil {
+		return err
+	}
+	for k, v := range c.Settings {
+		if v != "" {
+			settings.Set(k, v)
+		} else {
+			settings.Delete(k)
+		}
+	}
+	return nil
+}
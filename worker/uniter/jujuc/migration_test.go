@@ -0,0 +1,63 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/worker/uniter/jujuc"
+)
+
+type MigrationSuite struct {
+	ContextSuite
+}
+
+var _ = gc.Suite(&MigrationSuite{})
+
+func (s *MigrationSuite) run(c *gc.C, hctx jujuc.Context, name string, args ...string) (int, string, string) {
+	com, err := jujuc.NewCommand(hctx, name)
+	c.Assert(err, gc.IsNil)
+	ctx := testing.Context(c)
+	code := cmd.Main(com, ctx, args)
+	return code, bufferString(ctx.Stdout), bufferString(ctx.Stderr)
+}
+
+func (s *MigrationSuite) TestSetAndGet(c *gc.C) {
+	hctx := s.GetHookContext(c, -1, "")
+	hctx.migration = Settings{"old": "value"}
+
+	code, _, stderr := s.run(c, hctx, "migration-set", "data=/srv/data", "old=")
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(stderr, gc.Equals, "")
+	c.Assert(hctx.migration.Map(), gc.DeepEquals, Settings{"data": "/srv/data"}.Map())
+
+	code, stdout, _ := s.run(c, hctx, "migration-get", "data")
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(stdout, gc.Equals, "/srv/data\n")
+	code, stdout, _ = s.run(c, hctx, "migration-get", "--format", "json")
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(stdout, gc.Equals, `{"data":"/srv/data"}`+"\n")
+	code, stdout, _ = s.run(c, hctx, "migration-get", "missing")
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(stdout, gc.Equals, "")
+}
+
+func (s *MigrationSuite) TestNotMigrating(c *gc.C) {
+	hctx := s.GetHookContext(c, -1, "")
+	code, _, stderr := s.run(c, hctx, "migration-get")
+	c.Assert(code, gc.Equals, 1)
+	c.Assert(stderr, gc.Equals, "error: unit is not being migrated\n")
+	code, _, stderr = s.run(c, hctx, "migration-set", "data=x")
+	c.Assert(code, gc.Equals, 1)
+	c.Assert(stderr, gc.Equals, "error: unit is not being migrated\n")
+}
+
+func (s *MigrationSuite) TestSetBadArgs(c *gc.C) {
+	hctx := s.GetHookContext(c, -1, "")
+	code, _, stderr := s.run(c, hctx, "migration-set", "data")
+	c.Assert(code, gc.Equals, 2)
+	c.Assert(stderr, gc.Equals, `error: expected "key=value", got "data"`+"\n")
+}
@@ -6,9 +6,12 @@ import (
 
 // newCommands maps Command names to initializers.
 var newCommands = map[string]func(Context) cmd.Command{
+    "add-metric":    NewAddMetricCommand,
     "close-port":    NewClosePortCommand,
     "config-get":    NewConfigGetCommand,
     "juju-log":      NewJujuLogCommand,
+    "migration-get": NewMigrationGetCommand,
+    "migration-set": NewMigrationSetCommand,
     "open-port":     NewOpenPortCommand,
     "relation-get":  NewRelationGetCommand,
     "relation-ids":  NewRelationIdsCommand,
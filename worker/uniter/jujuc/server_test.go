@@ -192,9 +192,12 @@ var newCommandTests = []struct {
 	name string
 	err  string
 }{
+	{"add-metric", ""},
 	{"close-port", ""},
 	{"config-get", ""},
 	{"juju-log", ""},
+	{"migration-get", ""},
+	{"migration-set", ""},
 	{"open-port", ""},
 	{"relation-get", ""},
 	{"relation-ids", ""},
@@ -8,9 +8,12 @@ import (
 
 // gsamfira: Windows cares about extensions
 var newCommands = map[string]func(Context) cmd.Command{
+	"add-metric.exe":		NewAddMetricCommand,
 	"close-port.exe":		NewClosePortCommand,
 	"config-get.exe":		NewConfigGetCommand,
 	"juju-log.exe":			NewJujuLogCommand,
+	"migration-get.exe":	NewMigrationGetCommand,
+	"migration-set.exe":	NewMigrationSetCommand,
 	"open-port.exe":		NewOpenPortCommand,
 	"relation-get.exe":		NewRelationGetCommand,
 	"relation-ids.exe":		NewRelationIdsCommand,
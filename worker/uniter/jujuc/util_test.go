@@ -9,6 +9,7 @@ import (
 	"io"
 	"sort"
 	"testing"
+	"time"
 
 	gc "launchpad.net/gocheck"
 
@@ -79,10 +80,12 @@ func setSettings(c *gc.C, ru *state.RelationUnit, settings map[string]interface{
 }
 
 type Context struct {
-	ports  set.Strings
-	relid  int
-	remote string
-	rels   map[int]*ContextRelation
+	ports     set.Strings
+	relid     int
+	remote    string
+	rels      map[int]*ContextRelation
+	metrics   []jujuc.Metric
+	migration Settings
 }
 
 func (c *Context) UnitName() string {
@@ -142,6 +145,21 @@ func (c *Context) OwnerTag() string {
 	return "test-owner"
 }
 
+func (c *Context) AddMetric(key, value string, created time.Time) error {
+	if key == "unknown" {
+		return fmt.Errorf("metric %q not declared", key)
+	}
+	c.metrics = append(c.metrics, jujuc.Metric{Key: key, Value: value, Time: created})
+	return nil
+}
+
+func (c *Context) MigrationSettings() (jujuc.Settings, error) {
+	if c.migration == nil {
+		return nil, fmt.Errorf("unit is not being migrated")
+	}
+	return c.migration, nil
+}
+
 type ContextRelation struct {
 	id    int
 	name  string
@@ -0,0 +1,249 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package uniter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	corecharm "launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/charm/hooks"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/utils"
+)
+
+// metricsCollectionInterval holds the interval at which the
+// collect-metrics hook is run, for charms that declare metrics.
+var metricsCollectionInterval = 5 * time.Minute
+
+// maxSpooledMetricBatches holds the number of metric batches kept
+// on disk while they cannot be sent to the state server; when it is
+// exceeded, the oldest batches are discarded.
+var maxSpooledMetricBatches = 1000
+
+// metricsSpool stores metric batches on disk, one file per batch,
+// until they have been sent to the state server.
+type metricsSpool struct {
+	dir string
+}
+
+// newMetricsSpool returns a metricsSpool that stores batches
+// in the given directory, creating it if necessary.
+func newMetricsSpool(dir string) (*metricsSpool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &metricsSpool{dir: dir}, nil
+}
+
+const batchFileExt = ".json"
+
+// path returns the path of the file holding the batch
+// with the given UUID.
+func (s *metricsSpool) path(uuid string) string {
+	return filepath.Join(s.dir, uuid+batchFileExt)
+}
+
+// add stores the given batch, discarding the oldest stored
+// batches if there are too many.
+func (s *metricsSpool) add(batch params.MetricBatch) error {
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	if err := utils.AtomicWriteFile(s.path(batch.UUID), data, 0644); err != nil {
+		return fmt.Errorf("cannot store metric batch: %v", err)
+	}
+	batches, err := s.batches()
+	if err != nil {
+		return err
+	}
+	for len(batches) > maxSpooledMetricBatches {
+		logger.Warningf("too many unsent metric batches; discarding batch %q", batches[0].UUID)
+		if err := s.remove(batches[0].UUID); err != nil {
+			return err
+		}
+		batches = batches[1:]
+	}
+	return nil
+}
+
+// batches returns all the stored batches, oldest first.
+func (s *metricsSpool) batches() ([]params.MetricBatch, error) {
+	infos, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var batches []params.MetricBatch
+	for _, info := range infos {
+		if filepath.Ext(info.Name()) != batchFileExt {
+			// Ignore temporary files left by interrupted writes.
+			continue
+		}
+		path := filepath.Join(s.dir, info.Name())
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var batch params.MetricBatch
+		if err := json.Unmarshal(data, &batch); err != nil {
+			logger.Warningf("discarding invalid metric batch %q: %v", path, err)
+			if err := os.Remove(path); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		batches = append(batches, batch)
+	}
+	sort.Sort(batchesByCreated(batches))
+	return batches, nil
+}
+
+// remove removes the batch with the given UUID.
+func (s *metricsSpool) remove(uuid string) error {
+	err := os.Remove(s.path(uuid))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+type batchesByCreated []params.MetricBatch
+
+func (b batchesByCreated) Len() int           { return len(b) }
+func (b batchesByCreated) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b batchesByCreated) Less(i, j int) bool { return b[i].Created.Before(b[j].Created) }
+
+// metricsTimer returns a channel that receives a value when the
+// collect-metrics hook is next due to run, or nil if the deployed
+// charm does not declare any metrics.
+func (u *Uniter) metricsTimer() (<-chan time.Time, error) {
+	ch, err := corecharm.ReadDir(u.charm.Path())
+	if err != nil {
+		return nil, err
+	}
+	if ch.Metrics() == nil {
+		return nil, nil
+	}
+	next := u.lastMetricsCollection.Add(metricsCollectionInterval)
+	return time.After(next.Sub(time.Now())), nil
+}
+
+// collectMetrics runs the collect-metrics hook, stores the metrics
+// it adds, and sends all stored metrics to the state server. Unlike
+// other hooks, the collect-metrics hook does not change the uniter's
+// state, and its failure does not put the unit into an error state.
+func (u *Uniter) collectMetrics() error {
+	u.lastMetricsCollection = time.Now()
+	batch, err := u.runCollectMetricsHook()
+	if err != nil {
+		return err
+	}
+	if batch != nil {
+		if err := u.metricsSpool.add(*batch); err != nil {
+			return err
+		}
+	}
+	u.sendMetrics()
+	return nil
+}
+
+// runCollectMetricsHook runs the collect-metrics hook and returns the
+// batch of metrics it added, or nil if it added none.
+func (u *Uniter) runCollectMetricsHook() (*params.MetricBatch, error) {
+	ch, err := corecharm.ReadDir(u.charm.Path())
+	if err != nil {
+		return nil, err
+	}
+	curl, err := u.unit.CharmURL()
+	if err != nil {
+		return nil, err
+	}
+	hookName := string(hooks.CollectMetrics)
+	hctxId := fmt.Sprintf("%s:%s:%d", u.unit.Name(), hookName, u.rand.Int63())
+
+	lockMessage := fmt.Sprintf("%s: running hook %q", u.unit.Name(), hookName)
+	if err := u.acquireHookLock(lockMessage); err != nil {
+		return nil, err
+	}
+	defer u.hookLock.Unlock()
+
+	hctx, err := u.getHookContext(hctxId, -1, "")
+	if err != nil {
+		return nil, err
+	}
+	hctx.definedMetrics = ch.Metrics()
+	hctx.timeout = u.hookTimeout(hookName)
+	srv, socketPath, err := u.startJujucServer(hctx)
+	if err != nil {
+		return nil, err
+	}
+	defer srv.Close()
+
+	logger.Infof("running %q hook", hookName)
+	started := u.recordHookStarted(hookName)
+	err = hctx.RunHook(hookName, u.charm.Path(), u.toolsDir, socketPath)
+	u.recordHookFinished(hookName, started, err)
+	if IsMissingHookError(err) {
+		logger.Infof("skipped %q hook (missing)", hookName)
+		return nil, nil
+	} else if err != nil {
+		logger.Errorf("%q hook failed: %v", hookName, err)
+		return nil, nil
+	}
+	logger.Infof("ran %q hook", hookName)
+	if len(hctx.metrics) == 0 {
+		return nil, nil
+	}
+	uuid, err := utils.NewUUID()
+	if err != nil {
+		return nil, err
+	}
+	batch := &params.MetricBatch{
+		UUID:     uuid.String(),
+		CharmURL: curl.String(),
+		Created:  started,
+	}
+	for _, m := range hctx.metrics {
+		batch.Metrics = append(batch.Metrics, params.Metric{
+			Key:   m.Key,
+			Value: m.Value,
+			Time:  m.Time,
+		})
+	}
+	return batch, nil
+}
+
+// sendMetrics sends the stored metric batches to the state server,
+// and removes those that were recorded. Batches that could not be
+// sent are kept, and sent again after the next collection.
+func (u *Uniter) sendMetrics() {
+	batches, err := u.metricsSpool.batches()
+	if err != nil {
+		logger.Errorf("cannot read stored metrics: %v", err)
+		return
+	}
+	if len(batches) == 0 {
+		return
+	}
+	errs, err := u.unit.AddMetricBatches(batches)
+	if err != nil {
+		logger.Warningf("cannot send metrics: %v", err)
+		return
+	}
+	for _, batch := range batches {
+		if err := errs[batch.UUID]; err != nil {
+			logger.Warningf("cannot send metric batch %q: %v", batch.UUID, err)
+			continue
+		}
+		if err := u.metricsSpool.remove(batch.UUID); err != nil {
+			logger.Errorf("cannot remove sent metric batch %q: %v", batch.UUID, err)
+		}
+	}
+}
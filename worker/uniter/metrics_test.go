@@ -0,0 +1,95 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package uniter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/testing/testbase"
+	"launchpad.net/juju-core/utils"
+)
+
+type MetricsSpoolSuite struct {
+	testbase.LoggingSuite
+	spool *metricsSpool
+}
+
+var _ = gc.Suite(&MetricsSpoolSuite{})
+
+func (s *MetricsSpoolSuite) SetUpTest(c *gc.C) {
+	s.LoggingSuite.SetUpTest(c)
+	var err error
+	s.spool, err = newMetricsSpool(filepath.Join(c.MkDir(), "metrics"))
+	c.Assert(err, gc.IsNil)
+}
+
+func newBatch(c *gc.C, created int64) params.MetricBatch {
+	uuid, err := utils.NewUUID()
+	c.Assert(err, gc.IsNil)
+	return params.MetricBatch{
+		UUID:     uuid.String(),
+		CharmURL: "cs:quantal/metered-1",
+		Created:  time.Unix(created, 0).UTC(),
+		Metrics: []params.Metric{{
+			Key:   "users",
+			Value: "5",
+			Time:  time.Unix(created, 0).UTC(),
+		}},
+	}
+}
+
+func (s *MetricsSpoolSuite) TestAddAndRemove(c *gc.C) {
+	batches, err := s.spool.batches()
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 0)
+
+	second := newBatch(c, 200)
+	first := newBatch(c, 100)
+	c.Assert(s.spool.add(second), gc.IsNil)
+	c.Assert(s.spool.add(first), gc.IsNil)
+	batches, err = s.spool.batches()
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.DeepEquals, []params.MetricBatch{first, second})
+
+	c.Assert(s.spool.remove(first.UUID), gc.IsNil)
+	c.Assert(s.spool.remove(first.UUID), gc.IsNil)
+	batches, err = s.spool.batches()
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.DeepEquals, []params.MetricBatch{second})
+}
+
+func (s *MetricsSpoolSuite) TestDiscardsOldestBatches(c *gc.C) {
+	s.PatchValue(&maxSpooledMetricBatches, 2)
+	b1, b2, b3 := newBatch(c, 100), newBatch(c, 200), newBatch(c, 300)
+	for _, b := range []params.MetricBatch{b2, b3, b1} {
+		c.Assert(s.spool.add(b), gc.IsNil)
+	}
+	batches, err := s.spool.batches()
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.DeepEquals, []params.MetricBatch{b2, b3})
+}
+
+func (s *MetricsSpoolSuite) TestIgnoresOtherFiles(c *gc.C) {
+	b := newBatch(c, 100)
+	c.Assert(s.spool.add(b), gc.IsNil)
+	err := ioutil.WriteFile(filepath.Join(s.spool.dir, b.UUID+".json123456"), []byte("partial"), 0644)
+	c.Assert(err, gc.IsNil)
+	invalid := filepath.Join(s.spool.dir, "invalid.json")
+	err = ioutil.WriteFile(invalid, []byte("invalid"), 0644)
+	c.Assert(err, gc.IsNil)
+
+	batches, err := s.spool.batches()
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.DeepEquals, []params.MetricBatch{b})
+
+	// Invalid batches are discarded.
+	_, err = os.Stat(invalid)
+	c.Assert(os.IsNotExist(err), gc.Equals, true)
+}
@@ -76,6 +76,7 @@ func ModeContinue(u *Uniter) (next Mode, err error) {
 		} else if err != nil {
 			return nil, err
 		}
+		u.hookTimedOut = u.s.HookTimedOut
 	}
 
 	// Filter out states not related to charm deployment.
@@ -231,6 +232,8 @@ func ModeTerminating(u *Uniter) (next Mode, err error) {
 
 // ModeAbide is the Uniter's usual steady state. It watches for and responds to:
 // * service configuration changes
+// * machine maintenance mode changes
+// * metrics collection, if the charm declares metrics
 // * charm upgrade requests
 // * relation changes
 // * unit death
@@ -264,6 +267,10 @@ func ModeAbide(u *Uniter) (next Mode, err error) {
 // modeAbideAliveLoop handles all state changes for ModeAbide when the unit
 // is in an Alive state.
 func modeAbideAliveLoop(u *Uniter) (Mode, error) {
+	collectMetrics, err := u.metricsTimer()
+	if err != nil {
+		return nil, err
+	}
 	for {
 		hi := hook.Info{}
 		select {
@@ -271,8 +278,26 @@ func modeAbideAliveLoop(u *Uniter) (Mode, error) {
 			return nil, tomb.ErrDying
 		case <-u.f.UnitDying():
 			return modeAbideDyingLoop(u)
+		case <-collectMetrics:
+			if err := u.collectMetrics(); err != nil {
+				return nil, err
+			}
+			if collectMetrics, err = u.metricsTimer(); err != nil {
+				return nil, err
+			}
+			continue
 		case <-u.f.ConfigEvents():
 			hi = hook.Info{Kind: hooks.ConfigChanged}
+		case kind := <-u.f.MigrationEvents():
+			hi = hook.Info{Kind: kind}
+		case maintenance := <-u.f.MaintenanceEvents():
+			if maintenance == u.s.Maintenance {
+				continue
+			}
+			hi = hook.Info{Kind: hooks.MaintenanceFinished}
+			if maintenance {
+				hi = hook.Info{Kind: hooks.MaintenanceStarted}
+			}
 		case hi = <-u.relationHooks:
 		case ids := <-u.f.RelationsEvents():
 			added, err := u.updateRelations(ids)
@@ -341,6 +366,10 @@ func ModeHookError(u *Uniter) (next Mode, err error) {
 	msg := fmt.Sprintf("hook failed: %q", u.currentHookName())
 	// Create error information for status.
 	data := params.StatusData{"hook": u.currentHookName()}
+	if u.s.HookTimedOut > 0 {
+		msg = fmt.Sprintf("hook timed out after %v: %q", u.s.HookTimedOut, u.currentHookName())
+		data["timeout"] = u.s.HookTimedOut.String()
+	}
 	if u.s.Hook.Kind.IsRelation() {
 		data["relation-id"] = u.s.Hook.RelationId
 		if u.s.Hook.RemoteUnit != "" {
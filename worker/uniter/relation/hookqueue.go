@@ -5,6 +5,7 @@ package relation
 
 import (
 	"sort"
+	"sync/atomic"
 
 	"launchpad.net/tomb"
 
@@ -19,6 +20,9 @@ import (
 type HookQueue interface {
 	hookQueue()
 	Stop() error
+
+	// Len returns the number of hooks the queue has yet to send.
+	Len() int
 }
 
 // RelationUnitsWatcher is used to enable deterministic testing of
@@ -54,6 +58,10 @@ type AliveHookQueue struct {
 	// If changedPending is not empty, the queue is considered non-
 	// empty, even if head is nil.
 	changedPending string
+
+	// length holds the number of hooks in the queue, and is
+	// accessed atomically so that Len can be called at any time.
+	length int32
 }
 
 // unitInfo holds unit information for management by AliveHookQueue.
@@ -129,6 +137,7 @@ func (q *AliveHookQueue) loop(initial *State) {
 	var next hook.Info
 	var out chan<- hook.Info
 	for {
+		atomic.StoreInt32(&q.length, int32(q.len()))
 		if q.empty() {
 			out = nil
 		} else {
@@ -161,11 +170,32 @@ func (q *AliveHookQueue) Stop() error {
 	return q.tomb.Wait()
 }
 
+// Len returns the number of hooks the queue has yet to send.
+func (q *AliveHookQueue) Len() int {
+	return int(atomic.LoadInt32(&q.length))
+}
+
 // empty returns true if the queue is empty.
 func (q *AliveHookQueue) empty() bool {
 	return q.head == nil && q.changedPending == ""
 }
 
+// len returns the number of hooks in the queue.
+func (q *AliveHookQueue) len() int {
+	n := 0
+	if q.changedPending != "" {
+		n++
+	}
+	for info := q.head; info != nil; info = info.next {
+		n++
+		if info.hookKind == hooks.RelationJoined {
+			// A relation-changed always follows the relation-joined.
+			n++
+		}
+	}
+	return n
+}
+
 // update modifies the queue such that the hook.Info values it sends will
 // reflect the supplied change.
 func (q *AliveHookQueue) update(ruc params.RelationUnitsChange) {
@@ -312,6 +342,11 @@ type DyingHookQueue struct {
 	relationId     int
 	members        map[string]int64
 	changedPending string
+
+	// length holds the number of hooks the queue has yet to send,
+	// and is accessed atomically so that Len can be called at any
+	// time.
+	length int32
 }
 
 // NewDyingHookQueue returns a new DyingHookQueue that shuts down the state in
@@ -326,6 +361,11 @@ func NewDyingHookQueue(initial *State, out chan<- hook.Info) *DyingHookQueue {
 	for m, v := range initial.Members {
 		q.members[m] = v
 	}
+	// Every member departs, and then the relation is broken.
+	q.length = int32(len(q.members) + 1)
+	if q.changedPending != "" {
+		q.length++
+	}
 	go q.loop()
 	return q
 }
@@ -339,6 +379,7 @@ func (q *DyingHookQueue) loop() {
 		case <-q.tomb.Dying():
 			return
 		case q.out <- q.hookInfo(hooks.RelationChanged, q.changedPending):
+			atomic.AddInt32(&q.length, -1)
 		}
 	}
 
@@ -353,6 +394,7 @@ func (q *DyingHookQueue) loop() {
 		case <-q.tomb.Dying():
 			return
 		case q.out <- q.hookInfo(hooks.RelationDeparted, unit):
+			atomic.AddInt32(&q.length, -1)
 		}
 	}
 
@@ -361,6 +403,7 @@ func (q *DyingHookQueue) loop() {
 	case <-q.tomb.Dying():
 		return
 	case q.out <- hook.Info{Kind: hooks.RelationBroken, RelationId: q.relationId}:
+		atomic.AddInt32(&q.length, -1)
 	}
 	q.tomb.Kill(nil)
 	return
@@ -378,6 +421,11 @@ func (q *DyingHookQueue) hookInfo(kind hooks.Kind, unit string) hook.Info {
 	return hi
 }
 
+// Len returns the number of hooks the queue has yet to send.
+func (q *DyingHookQueue) Len() int {
+	return int(atomic.LoadInt32(&q.length))
+}
+
 func (q *DyingHookQueue) hookQueue() {
 	panic("interface sentinel method, do not call")
 }
@@ -296,3 +296,50 @@ func (d expect) check(c *gc.C, in chan params.RelationUnitsChange, out chan hook
 		c.Fatalf("timed out waiting for %#v", expect)
 	}
 }
+
+// assertLen waits for the queue to report the given number of
+// hooks yet to be sent.
+func assertLen(c *gc.C, q relation.HookQueue, expect int) {
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		if q.Len() == expect {
+			return
+		}
+	}
+	c.Fatalf("queue has %d hooks, expected %d", q.Len(), expect)
+}
+
+func (s *HookQueueSuite) TestAliveHookQueueLen(c *gc.C) {
+	out := make(chan hook.Info)
+	in := make(chan params.RelationUnitsChange)
+	ruw := &RUW{in, false}
+	q := relation.NewAliveHookQueue(&relation.State{21345, nil, ""}, out, ruw)
+	defer q.Stop()
+	send{nil, nil}.check(c, in, out)
+	assertLen(c, q, 0)
+
+	// Each new unit queues a joined and a changed hook.
+	send{msi{"u/0": 0, "u/1": 0}, nil}.check(c, in, out)
+	assertLen(c, q, 4)
+	expect{hooks.RelationJoined, "u/0", 0}.check(c, in, out)
+	assertLen(c, q, 3)
+	expect{hooks.RelationChanged, "u/0", 0}.check(c, in, out)
+	assertLen(c, q, 2)
+
+	// A departure replaces the hooks of a unit that has not joined.
+	send{nil, []string{"u/1"}}.check(c, in, out)
+	assertLen(c, q, 0)
+}
+
+func (s *HookQueueSuite) TestDyingHookQueueLen(c *gc.C) {
+	out := make(chan hook.Info)
+	q := relation.NewDyingHookQueue(&relation.State{21345, msi{"u/0": 3, "u/1": 7}, "u/0"}, out)
+	defer q.Stop()
+	assertLen(c, q, 4)
+	expect{hooks.RelationChanged, "u/0", 3}.check(c, nil, out)
+	assertLen(c, q, 3)
+	expect{hooks.RelationDeparted, "u/0", 3}.check(c, nil, out)
+	expect{hooks.RelationDeparted, "u/1", 7}.check(c, nil, out)
+	assertLen(c, q, 1)
+	expect{hook: hooks.RelationBroken}.check(c, nil, out)
+	assertLen(c, q, 0)
+}
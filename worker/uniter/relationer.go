@@ -116,6 +116,15 @@ func (r *Relationer) StopHooks() error {
 	return queue.Stop()
 }
 
+// QueuedHooks returns the number of hooks the relationer has yet to send
+// on the hooks channel.
+func (r *Relationer) QueuedHooks() int {
+	if r.queue == nil {
+		return 0
+	}
+	return r.queue.Len()
+}
+
 // PrepareHook checks that the relation is in a state such that it makes
 // sense to execute the supplied hook, and ensures that the relation context
 // contains the latest relation state as communicated in the hook.Info. It
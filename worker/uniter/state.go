@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"launchpad.net/juju-core/charm"
 	"launchpad.net/juju-core/utils"
@@ -54,6 +55,10 @@ type State struct {
 	// Started indicates whether the start hook has run.
 	Started bool
 
+	// Maintenance indicates whether the maintenance-started hook has
+	// run more recently than the maintenance-finished hook.
+	Maintenance bool `yaml:"maintenance,omitempty"`
+
 	// Op indicates the current operation.
 	Op Op
 
@@ -70,6 +75,11 @@ type State struct {
 	// Charm describes the charm being deployed by an Install or Upgrade
 	// operation, and is otherwise blank.
 	CharmURL *charm.URL `yaml:"charm,omitempty"`
+
+	// HookTimedOut holds the timeout after which the hook being run
+	// was killed. It is only set when Op is RunHook and OpStep is
+	// Pending, and is zero if the hook has not timed out.
+	HookTimedOut time.Duration `yaml:"hook-timed-out,omitempty"`
 }
 
 // validate returns an error if the state violates expectations.
@@ -101,6 +111,9 @@ func (st State) validate() (err error) {
 	default:
 		return fmt.Errorf("unknown operation step %q", st.OpStep)
 	}
+	if st.HookTimedOut != 0 && (st.Op != RunHook || st.OpStep != Pending) {
+		return fmt.Errorf("unexpected hook timeout")
+	}
 	if hasHook {
 		return st.Hook.Validate()
 	}
@@ -135,13 +148,15 @@ func (f *StateFile) Read() (*State, error) {
 }
 
 // Write stores the supplied state to the file.
-func (f *StateFile) Write(started bool, op Op, step OpStep, hi *uhook.Info, url *charm.URL) error {
+func (f *StateFile) Write(started, maintenance bool, op Op, step OpStep, hi *uhook.Info, url *charm.URL, hookTimedOut time.Duration) error {
 	st := &State{
-		Started:  started,
-		Op:       op,
-		OpStep:   step,
-		Hook:     hi,
-		CharmURL: url,
+		Started:      started,
+		Maintenance:  maintenance,
+		Op:           op,
+		OpStep:       step,
+		Hook:         hi,
+		CharmURL:     url,
+		HookTimedOut: hookTimedOut,
 	}
 	if err := st.validate(); err != nil {
 		panic(err)
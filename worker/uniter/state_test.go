@@ -5,6 +5,7 @@ package uniter_test
 
 import (
 	"path/filepath"
+	"time"
 
 	gc "launchpad.net/gocheck"
 
@@ -97,6 +98,29 @@ var stateTests = []struct {
 			OpStep: uniter.Pending,
 			Hook:   relhook,
 		},
+	}, {
+		st: uniter.State{
+			Started:     true,
+			Maintenance: true,
+			Op:          uniter.RunHook,
+			OpStep:      uniter.Pending,
+			Hook:        &hook.Info{Kind: hooks.MaintenanceStarted},
+		},
+	}, {
+		st: uniter.State{
+			Op:           uniter.RunHook,
+			OpStep:       uniter.Pending,
+			Hook:         &hook.Info{Kind: hooks.ConfigChanged},
+			HookTimedOut: 5 * time.Minute,
+		},
+	}, {
+		st: uniter.State{
+			Op:           uniter.RunHook,
+			OpStep:       uniter.Done,
+			Hook:         &hook.Info{Kind: hooks.ConfigChanged},
+			HookTimedOut: 5 * time.Minute,
+		},
+		err: `unexpected hook timeout`,
 	},
 	// Upgrade operation.
 	{
@@ -151,7 +175,7 @@ func (s *StateFileSuite) TestStates(c *gc.C) {
 		_, err := file.Read()
 		c.Assert(err, gc.Equals, uniter.ErrNoStateFile)
 		write := func() {
-			err := file.Write(t.st.Started, t.st.Op, t.st.OpStep, t.st.Hook, t.st.CharmURL)
+			err := file.Write(t.st.Started, t.st.Maintenance, t.st.Op, t.st.OpStep, t.st.Hook, t.st.CharmURL, t.st.HookTimedOut)
 			c.Assert(err, gc.IsNil)
 		}
 		if t.err != "" {
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/juju/loggo"
 	"launchpad.net/tomb"
@@ -81,6 +82,24 @@ type Uniter struct {
 	proxyMutex sync.Mutex
 
 	ranConfigChanged bool
+
+	// metricsSpool stores collected metrics until they are sent
+	// to the state server, and lastMetricsCollection holds when
+	// the collect-metrics hook was last run.
+	metricsSpool          *metricsSpool
+	lastMetricsCollection time.Time
+
+	// hookRecorder reports hook executions to the state server.
+	hookRecorder *hookRecorder
+
+	// charmMeta caches the metadata of the deployed charm, from
+	// which hook timeouts are read.
+	charmMeta *corecharm.Meta
+
+	// hookTimedOut holds the timeout after which the most recently
+	// failed hook was killed, or zero if it failed of its own accord.
+	// It is recorded in the uniter state while the hook is pending.
+	hookTimedOut time.Duration
 	// The execution observer is only used in tests at this stage. Should this
 	// need to be extended, perhaps a list of observers would be needed.
 	observer UniterExecutionObserver
@@ -108,6 +127,9 @@ func (u *Uniter) loop(unitTag string) (err error) {
 	defer u.runListener.Close()
 	logger.Infof("unit %q started", u.unit)
 
+	u.hookRecorder = newHookRecorder(u.unit)
+	defer u.hookRecorder.Stop()
+
 	environWatcher, err := u.st.WatchForEnvironConfigChanges()
 	if err != nil {
 		return err
@@ -177,16 +199,28 @@ func (u *Uniter) Dead() <-chan struct{} {
 }
 
 // writeState saves uniter state with the supplied values, and infers the appropriate
-// value of Started.
+// values of Started, Maintenance and HookTimedOut.
 func (u *Uniter) writeState(op Op, step OpStep, hi *hook.Info, url *corecharm.URL) error {
 	s := State{
-		Started:  op == RunHook && hi.Kind == hooks.Start || u.s != nil && u.s.Started,
-		Op:       op,
-		OpStep:   step,
-		Hook:     hi,
-		CharmURL: url,
+		Started:     op == RunHook && hi.Kind == hooks.Start || u.s != nil && u.s.Started,
+		Maintenance: u.s != nil && u.s.Maintenance,
+		Op:          op,
+		OpStep:      step,
+		Hook:        hi,
+		CharmURL:    url,
+	}
+	if op == RunHook {
+		switch hi.Kind {
+		case hooks.MaintenanceStarted:
+			s.Maintenance = true
+		case hooks.MaintenanceFinished:
+			s.Maintenance = false
+		}
+	}
+	if op == RunHook && step == Pending {
+		s.HookTimedOut = u.hookTimedOut
 	}
-	if err := u.sf.Write(s.Started, s.Op, s.OpStep, s.Hook, s.CharmURL); err != nil {
+	if err := u.sf.Write(s.Started, s.Maintenance, s.Op, s.OpStep, s.Hook, s.CharmURL, s.HookTimedOut); err != nil {
 		return err
 	}
 	u.s = &s
@@ -236,6 +270,7 @@ func (u *Uniter) deploy(curl *corecharm.URL, reason Op) error {
 		if err = u.deployer.Deploy(); err != nil {
 			return err
 		}
+		u.charmMeta = nil
 		if err = u.writeState(reason, Done, hi, curl); err != nil {
 			return err
 		}
@@ -354,6 +389,23 @@ func (u *Uniter) notifyHookFailed(hook string, hctx *HookContext) {
 	}
 }
 
+// hookTimeout returns the maximum time the named hook may run for: the
+// service's hook timeout if one is set, or otherwise the timeout given
+// for the hook in the charm metadata. Zero means no timeout.
+func (u *Uniter) hookTimeout(hookName string) time.Duration {
+	if timeout := u.f.HookTimeout(); timeout > 0 {
+		return timeout
+	}
+	if u.charmMeta == nil {
+		ch, err := corecharm.ReadDir(u.charm.Path())
+		if err != nil {
+			logger.Warningf("cannot read hook timeouts from charm: %v", err)
+			return 0
+		}
+		u.charmMeta = ch.Meta()
+	}
+	return u.charmMeta.HookTimeout(hookName)
+}
 
 // commitHook ensures that state is consistent with the supplied hook, and
 // that the fact of the hook's completion is persisted.
@@ -370,6 +422,9 @@ func (u *Uniter) commitHook(hi hook.Info) error {
 	if hi.Kind == hooks.ConfigChanged {
 		u.ranConfigChanged = true
 	}
+	if err := u.commitMigrationHook(hi.Kind); err != nil {
+		return err
+	}
 	if err := u.writeState(Continue, Pending, &hi, nil); err != nil {
 		return err
 	}
@@ -377,6 +432,38 @@ func (u *Uniter) commitHook(hi hook.Info) error {
 	return nil
 }
 
+// prepareMigrationContext gives the context of a migration hook access
+// to the settings through which the migrated unit's data is passed on.
+func (u *Uniter) prepareMigrationContext(hctx *HookContext, kind hooks.Kind) (err error) {
+	if kind == hooks.MigrateFrom || kind == hooks.MigrateTo {
+		hctx.migrationSettings, err = u.unit.MigrationSettings()
+	}
+	return err
+}
+
+// commitMigrationHook records the effect of a migration hook. Once the
+// migrate-from hook has passed on the unit's data, the unit replacing
+// it is told that the data is ready; once the migrate-to hook has
+// taken the data over, the migrated unit is destroyed.
+func (u *Uniter) commitMigrationHook(kind hooks.Kind) error {
+	switch kind {
+	case hooks.MigrateFrom:
+		status, err := u.unit.MigrationStatus()
+		if err != nil {
+			return err
+		}
+		if status.MigratingTo == "" || status.Ready {
+			// The migration has been abandoned, or the
+			// hook is being committed again.
+			return nil
+		}
+		return u.unit.SetMigrationReady()
+	case hooks.MigrateTo:
+		return u.unit.FinishMigration()
+	}
+	return nil
+}
+
 // currentHookName returns the current full hook name.
 func (u *Uniter) currentHookName() string {
 	hookInfo := u.s.Hook
@@ -302,6 +302,200 @@ func (s *UniterSuite) TestUniterInstallHook(c *gc.C) {
 	s.runUniterTests(c, installHookTests)
 }
 
+var hookTimeoutTests = []uniterTest{
+	ut(
+		"hook timeout from charm metadata kills hook",
+		createCharm{customize: slowInstallHook("hook-timeouts: {install: 1s}")},
+		serveCharm{},
+		createUniter{},
+		waitUnit{
+			status: params.StatusError,
+			info:   `hook timed out after 1s: "install"`,
+			data: params.StatusData{
+				"hook":    "install",
+				"timeout": "1s",
+			},
+		},
+		waitHooks{"fail-install"},
+		verifyWaiting{},
+		// The timeout is still reported after the uniter restarts.
+		waitUnit{
+			status: params.StatusError,
+			info:   `hook timed out after 1s: "install"`,
+			data: params.StatusData{
+				"hook":    "install",
+				"timeout": "1s",
+			},
+		},
+
+		fixHook{"install"},
+		resolveError{state.ResolvedRetryHooks},
+		waitUnit{
+			status: params.StatusStarted,
+		},
+		waitHooks{"install", "config-changed", "start"},
+	), ut(
+		"service hook timeout overrides charm metadata",
+		createCharm{customize: slowInstallHook("hook-timeouts: {install: 1h}")},
+		serveCharm{},
+		ensureStateWorker{},
+		createServiceAndUnit{},
+		custom{func(c *gc.C, ctx *context) {
+			err := ctx.svc.SetHookTimeout(time.Second)
+			c.Assert(err, gc.IsNil)
+		}},
+		startUniter{},
+		waitAddresses{},
+		waitUnit{
+			status: params.StatusError,
+			info:   `hook timed out after 1s: "install"`,
+			data: params.StatusData{
+				"hook":    "install",
+				"timeout": "1s",
+			},
+		},
+	),
+}
+
+// slowInstallHook returns a createCharm customization that adds the
+// given line to the charm metadata and makes the install hook hang.
+func slowInstallHook(metadata string) func(*gc.C, *context, string) {
+	return func(c *gc.C, ctx *context, path string) {
+		metaPath := filepath.Join(path, "metadata.yaml")
+		data, err := ioutil.ReadFile(metaPath)
+		c.Assert(err, gc.IsNil)
+		data = append(data, []byte("\n"+metadata+"\n")...)
+		err = ioutil.WriteFile(metaPath, data, 0644)
+		c.Assert(err, gc.IsNil)
+		hook := "#!/bin/bash --norc\nsleep 3600\n"
+		err = ioutil.WriteFile(filepath.Join(path, "hooks", "install"), []byte(hook), 0755)
+		c.Assert(err, gc.IsNil)
+	}
+}
+
+func (s *UniterSuite) TestUniterHookTimeout(c *gc.C) {
+	s.runUniterTests(c, hookTimeoutTests)
+}
+
+var hookStatsTests = []uniterTest{
+	ut(
+		"hook executions are recorded",
+		startupError{"install"},
+		fixHook{"install"},
+		resolveError{state.ResolvedRetryHooks},
+		waitUnit{
+			status: params.StatusStarted,
+		},
+		waitHooks{"install", "config-changed", "start"},
+		verifyHookStats{
+			{"install", true},
+			{"install", false},
+			{"config-changed", false},
+			{"start", false},
+		},
+	), ut(
+		"missing hooks are not recorded",
+		createCharm{
+			customize: func(c *gc.C, ctx *context, path string) {
+				err := os.Remove(filepath.Join(path, "hooks", "config-changed"))
+				c.Assert(err, gc.IsNil)
+			},
+		},
+		serveCharm{},
+		createUniter{},
+		waitUnit{
+			status: params.StatusStarted,
+		},
+		waitHooks{"install", "start"},
+		verifyHookStats{
+			{"install", false},
+			{"start", false},
+		},
+	),
+}
+
+func (s *UniterSuite) TestUniterHookStats(c *gc.C) {
+	s.runUniterTests(c, hookStatsTests)
+}
+
+var collectMetricsTests = []uniterTest{
+	ut(
+		"metrics are collected and sent to the state server",
+		createCharm{customize: declareMetrics},
+		serveCharm{},
+		createUniter{},
+		waitUnit{
+			status: params.StatusStarted,
+		},
+		waitHooks{"install", "config-changed", "start"},
+		verifyMetrics{"users": "5", "requests": "42"},
+	),
+}
+
+// declareMetrics is a createCharm customization that declares metrics
+// in the charm and adds a collect-metrics hook that adds values for them.
+func declareMetrics(c *gc.C, ctx *context, path string) {
+	metrics := `
+metrics:
+  users:
+    type: gauge
+  requests:
+    type: absolute
+`
+	err := ioutil.WriteFile(filepath.Join(path, "metrics.yaml"), []byte(metrics), 0644)
+	c.Assert(err, gc.IsNil)
+	hook := "#!/bin/bash --norc\nadd-metric users=5 requests=42\n"
+	err = ioutil.WriteFile(filepath.Join(path, "hooks", "collect-metrics"), []byte(hook), 0755)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *UniterSuite) TestUniterCollectMetrics(c *gc.C) {
+	s.runUniterTests(c, collectMetricsTests)
+}
+
+// addMaintenanceHooks is a createCharm customization that adds
+// maintenance-started and maintenance-finished hooks to the charm.
+func addMaintenanceHooks(c *gc.C, ctx *context, path string) {
+	for _, name := range []string{"maintenance-started", "maintenance-finished"} {
+		ctx.writeHook(c, filepath.Join(path, "hooks", name), true)
+	}
+}
+
+var maintenanceHookTests = []uniterTest{
+	ut(
+		"maintenance hooks run when the machine changes maintenance mode",
+		createCharm{customize: addMaintenanceHooks},
+		serveCharm{},
+		createUniter{},
+		waitUnit{status: params.StatusStarted},
+		waitHooks{"install", "config-changed", "start"},
+
+		setMaintenance(true),
+		waitHooks{"maintenance-started"},
+		verifyRunning{},
+
+		setMaintenance(false),
+		waitHooks{"maintenance-finished"},
+		verifyRunning{},
+	), ut(
+		"maintenance-started runs after start when the machine is already in maintenance mode",
+		createCharm{customize: addMaintenanceHooks},
+		serveCharm{},
+		ensureStateWorker{},
+		createServiceAndUnit{},
+		setMaintenance(true),
+		startUniter{},
+		waitAddresses{},
+		waitUnit{status: params.StatusStarted},
+		waitHooks{"install", "config-changed", "start", "maintenance-started"},
+		verifyRunning{},
+	),
+}
+
+func (s *UniterSuite) TestUniterMaintenanceHooks(c *gc.C) {
+	s.runUniterTests(c, maintenanceHookTests)
+}
+
 var startHookTests = []uniterTest{
 	ut(
 		"start hook fail and resolve",
@@ -1549,6 +1743,66 @@ func (s waitHooks) step(c *gc.C, ctx *context) {
 	}
 }
 
+// verifyHookStats checks the hook executions recorded for the unit.
+type verifyHookStats []struct {
+	hook   string
+	failed bool
+}
+
+func (s verifyHookStats) step(c *gc.C, ctx *context) {
+	timeout := time.After(worstCase)
+	for {
+		ctx.s.BackingState.StartSync()
+		select {
+		case <-time.After(coretesting.ShortWait):
+			stats, err := ctx.unit.HookStats()
+			c.Assert(err, gc.IsNil)
+			if stats.Running != nil || len(stats.Executions) < len(s) {
+				c.Logf("waiting for hook executions to be recorded")
+				continue
+			}
+			c.Assert(stats.Executions, gc.HasLen, len(s))
+			for i, exec := range stats.Executions {
+				c.Check(exec.Hook, gc.Equals, s[i].hook)
+				c.Check(exec.Failed(), gc.Equals, s[i].failed)
+				c.Check(exec.Finished.Before(exec.Started), gc.Equals, false)
+			}
+			return
+		case <-timeout:
+			c.Fatalf("never recorded expected hook executions")
+		}
+	}
+}
+
+// verifyMetrics checks that the unit has sent metrics with the
+// given values to the state server.
+type verifyMetrics map[string]string
+
+func (s verifyMetrics) step(c *gc.C, ctx *context) {
+	timeout := time.After(worstCase)
+	for {
+		ctx.s.BackingState.StartSync()
+		select {
+		case <-time.After(coretesting.ShortWait):
+			batches, err := ctx.svc.MetricBatches()
+			c.Assert(err, gc.IsNil)
+			if len(batches) == 0 {
+				c.Logf("waiting for metrics to be sent")
+				continue
+			}
+			c.Assert(batches[0].Unit(), gc.Equals, ctx.unit.Name())
+			values := make(verifyMetrics)
+			for _, m := range batches[0].Metrics() {
+				values[m.Key] = m.Value
+			}
+			c.Assert(values, gc.DeepEquals, s)
+			return
+		case <-timeout:
+			c.Fatalf("metrics never sent")
+		}
+	}
+}
+
 type fixHook struct {
 	name string
 }
@@ -1565,6 +1819,17 @@ func (s changeConfig) step(c *gc.C, ctx *context) {
 	c.Assert(err, gc.IsNil)
 }
 
+type setMaintenance bool
+
+func (s setMaintenance) step(c *gc.C, ctx *context) {
+	mid, err := ctx.unit.AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	machine, err := ctx.st.Machine(mid)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetMaintenance(bool(s))
+	c.Assert(err, gc.IsNil)
+}
+
 type upgradeCharm struct {
 	revision int
 	forced   bool
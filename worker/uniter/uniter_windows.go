@@ -71,6 +71,10 @@ func (u *Uniter) init(unitTag string) (err error) {
     bundles := charm.NewBundlesDir(filepath.Join(u.baseDir, "state", "bundles"))
     u.deployer = charm.NewGitDeployer(u.charm.Path(), deployerPath, bundles)
     u.sf = NewStateFile(filepath.Join(u.baseDir, "state", "uniter"))
+    u.metricsSpool, err = newMetricsSpool(filepath.Join(u.baseDir, "state", "metrics"))
+    if err != nil {
+        return err
+    }
     u.rand = rand.New(rand.NewSource(time.Now().Unix()))
     return nil
 }
@@ -127,6 +131,10 @@ func (u *Uniter) runHook(hi hook.Info) (err error) {
     if err != nil {
         return err
     }
+    hctx.timeout = u.hookTimeout(hookName)
+    if err = u.prepareMigrationContext(hctx, hi.Kind); err != nil {
+        return err
+    }
     srv, socketPath, err := u.startJujucServer(hctx)
     if err != nil {
         return err
@@ -134,12 +142,15 @@ func (u *Uniter) runHook(hi hook.Info) (err error) {
     defer srv.Close()
 
     // Run the hook.
+    u.hookTimedOut = 0
     if err := u.writeState(RunHook, Pending, &hi, nil); err != nil {
         return err
     }
     logger.Infof("running %q hook", hookName)
     ranHook := true
+    started := u.recordHookStarted(hookName)
     err = hctx.RunHook(hookName, u.charm.Path(), u.toolsDir, socketPath)
+    u.recordHookFinished(hookName, started, err)
     if RebootRequiredError(err){
         logger.Infof("hook %q requested a reboot", hookName)
         if err := u.writeState(RunHook, Queued, &hi, nil); err != nil {
@@ -158,6 +169,12 @@ func (u *Uniter) runHook(hi hook.Info) (err error) {
         ranHook = false
     } else if err != nil {
         logger.Errorf("hook failed: %s", err)
+        if u.hookTimedOut = timedOutAfter(err); u.hookTimedOut > 0 {
+            // Record the timeout, so that it is reported after a restart.
+            if err := u.writeState(RunHook, Pending, &hi, nil); err != nil {
+                return err
+            }
+        }
         u.notifyHookFailed(hookName, hctx)
         return errHookFailed
     }
@@ -0,0 +1,324 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package webhooks
+
+import (
+	"sync"
+	"time"
+
+	"github.com/juju/loggo"
+	"launchpad.net/tomb"
+
+	"launchpad.net/juju-core/environs/config"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/state/presence"
+	"launchpad.net/juju-core/state/watcher"
+	"launchpad.net/juju-core/utils/webhook"
+	"launchpad.net/juju-core/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.webhooks")
+
+// watchedKinds holds the kinds of entity whose
+// status changes are sent to webhooks.
+var watchedKinds = []string{"machine", "unit"}
+
+// watchedEntity holds what the worker knows of a machine or unit.
+type watchedEntity struct {
+	name   string
+	status params.Status
+	info   string
+
+	// alive holds whether the entity's agent is alive.
+	// It is only valid if presenceKnown is true.
+	alive         bool
+	presenceKnown bool
+
+	// stopPresence, if not nil, stops watching
+	// the presence of the entity's agent.
+	stopPresence func()
+}
+
+// presenceChange holds a change in the presence of an entity's agent.
+type presenceChange struct {
+	id    params.EntityId
+	alive bool
+}
+
+// agentPresence is implemented by *state.Machine and *state.Unit.
+type agentPresence interface {
+	WatchAgentPresence(ch chan<- presence.Change)
+	UnwatchAgentPresence(ch chan<- presence.Change)
+}
+
+type webhookWorker struct {
+	st     *state.State
+	tomb   tomb.Tomb
+	sender *webhook.Sender
+
+	envName string
+	hooks   []webhook.Hook
+
+	entities  map[params.EntityId]*watchedEntity
+	presencec chan presenceChange
+	wg        sync.WaitGroup
+}
+
+// NewWorker returns a worker that watches the status of the
+// machines and units in the environment and sends an event
+// to the webhooks in the environment configuration whenever
+// a status changes. When the agent of a machine or unit that
+// has started goes away, a "down" event is sent, and when it
+// comes back, an event with its status is sent. Events are not
+// sent for the status that entities have when the worker starts.
+func NewWorker(st *state.State) worker.Worker {
+	w := &webhookWorker{
+		st:        st,
+		entities:  make(map[params.EntityId]*watchedEntity),
+		presencec: make(chan presenceChange),
+	}
+	go func() {
+		defer w.tomb.Done()
+		w.tomb.Kill(w.loop())
+	}()
+	return w
+}
+
+// Kill implements worker.Worker.Kill.
+func (w *webhookWorker) Kill() {
+	w.tomb.Kill(nil)
+}
+
+// Wait implements worker.Worker.Wait.
+func (w *webhookWorker) Wait() error {
+	return w.tomb.Wait()
+}
+
+func (w *webhookWorker) loop() error {
+	w.sender = webhook.NewSender(webhook.NewClient(webhook.DefaultRetry.Timeout))
+	defer w.sender.Stop()
+
+	configWatcher := w.st.WatchEnvironConfig()
+	defer watcher.Stop(configWatcher, &w.tomb)
+	select {
+	case <-w.tomb.Dying():
+		return tomb.ErrDying
+	case cfg, ok := <-configWatcher.Changes():
+		if !ok {
+			return watcher.MustErr(configWatcher)
+		}
+		w.setConfig(cfg)
+	}
+
+	allWatcher := w.st.WatchFiltered(params.WatchAllFilter{Kinds: watchedKinds})
+	defer watcher.Stop(allWatcher, &w.tomb)
+	deltasc := make(chan []params.Delta)
+	errc := make(chan error, 1)
+	go func() {
+		for {
+			deltas, err := allWatcher.Next()
+			if err != nil {
+				errc <- err
+				return
+			}
+			select {
+			case deltasc <- deltas:
+			case <-w.tomb.Dying():
+				return
+			}
+		}
+	}()
+	defer w.stopPresence()
+
+	initial := true
+	for {
+		select {
+		case <-w.tomb.Dying():
+			return tomb.ErrDying
+		case cfg, ok := <-configWatcher.Changes():
+			if !ok {
+				return watcher.MustErr(configWatcher)
+			}
+			w.setConfig(cfg)
+		case err := <-errc:
+			return err
+		case deltas := <-deltasc:
+			w.handleDeltas(deltas, initial)
+			initial = false
+		case change := <-w.presencec:
+			w.handlePresence(change)
+		}
+	}
+}
+
+func (w *webhookWorker) setConfig(cfg *config.Config) {
+	w.envName = cfg.Name()
+	hooks := cfg.Webhooks()
+	names := make(map[string]bool)
+	for _, hook := range hooks {
+		names[hook.Name] = true
+	}
+	// Stop sending events to webhooks that have been removed.
+	for _, hook := range w.hooks {
+		if !names[hook.Name] {
+			w.sender.Remove(hook.Name)
+		}
+	}
+	w.hooks = hooks
+	logger.Debugf("%d webhooks configured", len(w.hooks))
+}
+
+// handleDeltas records the status of the entities in deltas,
+// sending events for any status changes unless initial
+// is true, in which case the deltas describe the
+// entities that existed when the worker started.
+func (w *webhookWorker) handleDeltas(deltas []params.Delta, initial bool) {
+	for _, d := range deltas {
+		id := d.Entity.EntityId()
+		if d.Removed {
+			if e, ok := w.entities[id]; ok {
+				if e.stopPresence != nil {
+					e.stopPresence()
+				}
+				delete(w.entities, id)
+			}
+			continue
+		}
+		var name, info string
+		var status params.Status
+		switch entity := d.Entity.(type) {
+		case *params.MachineInfo:
+			name, status, info = entity.Id, entity.Status, entity.StatusInfo
+		case *params.UnitInfo:
+			name, status, info = entity.Name, entity.Status, entity.StatusInfo
+		default:
+			continue
+		}
+		e, known := w.entities[id]
+		if !known {
+			e = &watchedEntity{name: name}
+			e.stopPresence = w.watchPresence(id, name)
+			w.entities[id] = e
+		}
+		previous := e.status
+		e.status, e.info = status, info
+		if initial || (known && previous == status) {
+			continue
+		}
+		w.notify(&webhook.Event{
+			Environment:    w.envName,
+			Kind:           id.Kind,
+			Id:             name,
+			Status:         string(status),
+			StatusInfo:     info,
+			PreviousStatus: string(previous),
+			Time:           time.Now().UTC(),
+		})
+	}
+}
+
+// handlePresence records a change in the presence of an entity's
+// agent, sending a "down" event when the agent of an entity that is
+// not pending goes away, and an event with the entity's status when
+// it comes back. The first presence reported for an entity is only
+// recorded.
+func (w *webhookWorker) handlePresence(change presenceChange) {
+	e, ok := w.entities[change.id]
+	if !ok {
+		return
+	}
+	wasKnown, wasAlive := e.presenceKnown, e.alive
+	e.alive, e.presenceKnown = change.alive, true
+	if !wasKnown || wasAlive == change.alive {
+		return
+	}
+	if e.status == "" || e.status == params.StatusPending {
+		// The agent is not expected to be running.
+		return
+	}
+	event := &webhook.Event{
+		Environment:    w.envName,
+		Kind:           change.id.Kind,
+		Id:             e.name,
+		Status:         string(params.StatusDown),
+		StatusInfo:     e.info,
+		PreviousStatus: string(e.status),
+		Time:           time.Now().UTC(),
+	}
+	if change.alive {
+		event.Status, event.PreviousStatus = string(e.status), string(params.StatusDown)
+	}
+	w.notify(event)
+}
+
+// watchPresence starts watching the presence of the agent of the
+// entity with the given id and name, sending its changes on
+// w.presencec. It returns a function that stops watching, or nil
+// if the entity cannot be found.
+func (w *webhookWorker) watchPresence(id params.EntityId, name string) func() {
+	var agent agentPresence
+	switch id.Kind {
+	case "machine":
+		m, err := w.st.Machine(name)
+		if err != nil {
+			logger.Warningf("cannot watch agent presence of machine %s: %v", name, err)
+			return nil
+		}
+		agent = m
+	case "unit":
+		u, err := w.st.Unit(name)
+		if err != nil {
+			logger.Warningf("cannot watch agent presence of unit %s: %v", name, err)
+			return nil
+		}
+		agent = u
+	default:
+		return nil
+	}
+	ch := make(chan presence.Change)
+	done := make(chan struct{})
+	agent.WatchAgentPresence(ch)
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		for {
+			var change presence.Change
+			select {
+			case <-done:
+				return
+			case change = <-ch:
+			}
+			select {
+			case <-done:
+				return
+			case w.presencec <- presenceChange{id, change.Alive}:
+			}
+		}
+	}()
+	return func() {
+		agent.UnwatchAgentPresence(ch)
+		close(done)
+	}
+}
+
+// stopPresence stops watching the presence of all entities' agents.
+func (w *webhookWorker) stopPresence() {
+	for _, e := range w.entities {
+		if e.stopPresence != nil {
+			e.stopPresence()
+		}
+	}
+	w.wg.Wait()
+}
+
+// notify sends the event to all the webhooks that it matches.
+func (w *webhookWorker) notify(event *webhook.Event) {
+	for _, hook := range w.hooks {
+		if hook.Matches(event) {
+			logger.Debugf("sending %s %s status %q to webhook %q", event.Kind, event.Id, event.Status, hook.Name)
+			w.sender.Send(hook, event)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package webhooks_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	coretesting "launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/utils/webhook"
+	"launchpad.net/juju-core/worker"
+	"launchpad.net/juju-core/worker/webhooks"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type workerSuite struct {
+	testing.JujuConnSuite
+	server *httptest.Server
+	events chan *webhook.Event
+}
+
+var _ = gc.Suite(&workerSuite{})
+
+func (s *workerSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.events = make(chan *webhook.Event, 10)
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		c.Check(err, gc.IsNil)
+		c.Check(req.Header.Get(webhook.SignatureHeader), gc.Equals, webhook.Sign("secret", body))
+		var event webhook.Event
+		c.Check(json.Unmarshal(body, &event), gc.IsNil)
+		s.events <- &event
+	}))
+}
+
+func (s *workerSuite) TearDownTest(c *gc.C) {
+	s.server.Close()
+	s.JujuConnSuite.TearDownTest(c)
+}
+
+func (s *workerSuite) setWebhooks(c *gc.C, hooks []webhook.Hook) {
+	attr, err := webhook.Format(hooks)
+	c.Assert(err, gc.IsNil)
+	oldConfig, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	newConfig, err := oldConfig.Apply(map[string]interface{}{"webhooks": attr})
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetEnvironConfig(newConfig, oldConfig)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *workerSuite) nextEvent(c *gc.C) *webhook.Event {
+	select {
+	case event := <-s.events:
+		return event
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for webhook event")
+	}
+	panic("unreachable")
+}
+
+func (s *workerSuite) assertNoEvent(c *gc.C) {
+	select {
+	case event := <-s.events:
+		c.Fatalf("unexpected webhook event %#v", event)
+	case <-time.After(coretesting.ShortWait):
+	}
+}
+
+// waitForWorker waits until the worker has seen the initial state of
+// the environment, by changing the status of m until an event is sent.
+func (s *workerSuite) waitForWorker(c *gc.C, m *state.Machine) {
+	statuses := []params.Status{params.StatusStarted, params.StatusPending}
+	timeout := time.After(coretesting.LongWait)
+	for i := 0; ; i++ {
+		err := m.SetStatus(statuses[i%2], "", nil)
+		c.Assert(err, gc.IsNil)
+		select {
+		case <-s.events:
+			// Wait for any pending events to be delivered.
+			for {
+				select {
+				case <-s.events:
+				case <-time.After(coretesting.ShortWait):
+					return
+				}
+			}
+		case <-time.After(coretesting.ShortWait):
+		case <-timeout:
+			c.Fatalf("timed out waiting for worker")
+		}
+	}
+}
+
+func (s *workerSuite) TestStatusChanges(c *gc.C) {
+	s.setWebhooks(c, []webhook.Hook{{
+		Name:   "ops",
+		URL:    s.server.URL,
+		Secret: "secret",
+		Rules: []webhook.Rule{
+			{Kind: "machine"},
+			{Kind: "unit", Status: "error"},
+		},
+	}})
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	w := webhooks.NewWorker(s.State)
+	defer func() { c.Assert(worker.Stop(w), gc.IsNil) }()
+	s.waitForWorker(c, m)
+
+	err = m.SetStatus(params.StatusError, "cannot start", nil)
+	c.Assert(err, gc.IsNil)
+	event := s.nextEvent(c)
+	c.Assert(event.Environment, gc.Equals, "dummyenv")
+	c.Assert(event.Kind, gc.Equals, "machine")
+	c.Assert(event.Id, gc.Equals, m.Id())
+	c.Assert(event.Status, gc.Equals, "error")
+	c.Assert(event.StatusInfo, gc.Equals, "cannot start")
+
+	// Setting the same status again sends no event.
+	err = m.SetStatus(params.StatusError, "cannot start", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertNoEvent(c)
+
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	u, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = u.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertNoEvent(c)
+
+	err = u.SetStatus(params.StatusError, "hook failed", nil)
+	c.Assert(err, gc.IsNil)
+	event = s.nextEvent(c)
+	c.Assert(event.Kind, gc.Equals, "unit")
+	c.Assert(event.Id, gc.Equals, "wordpress/0")
+	c.Assert(event.Status, gc.Equals, "error")
+	c.Assert(event.PreviousStatus, gc.Equals, "started")
+	c.Assert(event.StatusInfo, gc.Equals, "hook failed")
+}
+
+func (s *workerSuite) TestWebhooksChanged(c *gc.C) {
+	hook := webhook.Hook{
+		Name:   "ops",
+		URL:    s.server.URL,
+		Secret: "secret",
+	}
+	s.setWebhooks(c, []webhook.Hook{hook})
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	w := webhooks.NewWorker(s.State)
+	defer func() { c.Assert(worker.Stop(w), gc.IsNil) }()
+	s.waitForWorker(c, m)
+
+	s.setWebhooks(c, nil)
+	// The config change may be observed after the next delta, so
+	// keep changing the status until no event is sent.
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		err = m.SetStatus(params.StatusError, "", nil)
+		c.Assert(err, gc.IsNil)
+		err = m.SetStatus(params.StatusStarted, "", nil)
+		c.Assert(err, gc.IsNil)
+		select {
+		case <-s.events:
+			for len(s.events) > 0 {
+				<-s.events
+			}
+			continue
+		case <-time.After(coretesting.ShortWait):
+		}
+		break
+	}
+	s.assertNoEvent(c)
+
+	s.setWebhooks(c, []webhook.Hook{hook})
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		err = m.SetStatus(params.StatusError, "", nil)
+		c.Assert(err, gc.IsNil)
+		err = m.SetStatus(params.StatusStarted, "", nil)
+		c.Assert(err, gc.IsNil)
+		select {
+		case <-s.events:
+			return
+		case <-time.After(coretesting.ShortWait):
+		}
+	}
+	c.Fatalf("no event sent after webhooks were restored")
+}
+
+// drainEvents discards any events sent.
+func (s *workerSuite) drainEvents() {
+	for {
+		select {
+		case <-s.events:
+		case <-time.After(coretesting.ShortWait):
+			return
+		}
+	}
+}
+
+// nextEventSyncing returns the next event sent, syncing
+// the state so that presence changes are observed.
+func (s *workerSuite) nextEventSyncing(c *gc.C) *webhook.Event {
+	timeout := time.After(coretesting.LongWait)
+	for {
+		s.State.StartSync()
+		select {
+		case event := <-s.events:
+			return event
+		case <-time.After(coretesting.ShortWait):
+		case <-timeout:
+			c.Fatalf("timed out waiting for webhook event")
+		}
+	}
+}
+
+func (s *workerSuite) TestAgentPresence(c *gc.C) {
+	s.setWebhooks(c, []webhook.Hook{{
+		Name:   "ops",
+		URL:    s.server.URL,
+		Secret: "secret",
+		Rules:  []webhook.Rule{{Kind: "machine"}},
+	}})
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	w := webhooks.NewWorker(s.State)
+	defer func() { c.Assert(worker.Stop(w), gc.IsNil) }()
+	s.waitForWorker(c, m)
+	err = m.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+	s.drainEvents()
+
+	pinger, err := m.SetAgentAlive()
+	c.Assert(err, gc.IsNil)
+	event := s.nextEventSyncing(c)
+	c.Assert(event.Kind, gc.Equals, "machine")
+	c.Assert(event.Id, gc.Equals, m.Id())
+	c.Assert(event.Status, gc.Equals, "started")
+	c.Assert(event.PreviousStatus, gc.Equals, "down")
+
+	err = pinger.Kill()
+	c.Assert(err, gc.IsNil)
+	event = s.nextEventSyncing(c)
+	c.Assert(event.Id, gc.Equals, m.Id())
+	c.Assert(event.Status, gc.Equals, "down")
+	c.Assert(event.PreviousStatus, gc.Equals, "started")
+}
+
+func (s *workerSuite) TestPendingAgentPresenceIgnored(c *gc.C) {
+	s.setWebhooks(c, []webhook.Hook{{
+		Name:   "ops",
+		URL:    s.server.URL,
+		Secret: "secret",
+	}})
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	other, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	w := webhooks.NewWorker(s.State)
+	defer func() { c.Assert(worker.Stop(w), gc.IsNil) }()
+	s.waitForWorker(c, m)
+
+	pinger, err := other.SetAgentAlive()
+	c.Assert(err, gc.IsNil)
+	defer pinger.Kill()
+	s.State.StartSync()
+	s.assertNoEvent(c)
+}